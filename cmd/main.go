@@ -2,16 +2,32 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/approval"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/backtest"
 	"autotransaction/internal/blockchain"
+	"autotransaction/internal/events"
 	"autotransaction/internal/execution"
+	"autotransaction/internal/health"
+	"autotransaction/internal/history"
 	"autotransaction/internal/llm"
 	"autotransaction/internal/market"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/news"
+	"autotransaction/internal/notify"
+	"autotransaction/internal/portfolio"
+	"autotransaction/internal/reconcile"
 	"autotransaction/internal/risk"
+	"autotransaction/internal/sentiment"
+	"autotransaction/internal/store"
 	"autotransaction/internal/strategy"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -19,19 +35,27 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// configPath 配置文件路径
+const configPath = "./configs/config.yaml"
+
 func main() {
 	// 加载配置
-	cfg, err := config.LoadConfig("./configs/config.yaml")
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"error": err,
-			"file":  "./configs/config.yaml",
+			"file":  configPath,
 		}).Fatal("加载配置失败")
 	}
 
 	// 设置日志级别
 	setLogLevel(cfg.System.LogLevel)
 
+	// 开始交易前校验配置，列出所有问题后退出
+	if err := cfg.Validate(strategy.RegisteredStrategies()); err != nil {
+		logrus.Fatal(err)
+	}
+
 	// 初始化上下文和取消函数
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -39,8 +63,101 @@ func main() {
 	// 使用ctx初始化各个模块
 	marketData := market.NewMarketDataService(cfg)
 	riskManager := risk.NewRiskManager(cfg)
+	if cfg.Risk.TrendFilter.Enabled {
+		riskManager.SetTrendProvider(risk.NewHistoricalTrendProvider(
+			marketData, cfg.Risk.TrendFilter.Interval, cfg.Risk.TrendFilter.Period))
+	}
+	// 交易对被交易所下架后，相关持仓标记为不可交易，等待人工处理
+	marketData.RegisterDelistHandler(riskManager.MarkUntradeable)
+	// 用实时价格检查止损止盈
+	marketData.RegisterHandler(riskManager)
 	strategyManager := strategy.NewStrategyManager(cfg, marketData)
+	strategyManager.SetHoldingsProvider(riskManager)
 	executor := execution.NewExecutor(cfg, riskManager)
+	// 用实时行情撮合限价单
+	marketData.RegisterHandler(executor)
+
+	// 按实时价格为账户持仓估值，计算盈亏和资产配置
+	valuation := portfolio.NewValuationService(cfg, riskManager, executor)
+	marketData.RegisterHandler(valuation)
+
+	// 跟踪账户资金，按账户权益计算下单数量；模拟交易模式以其作为虚拟余额
+	if cfg.Portfolio.Enabled || cfg.Execution.PaperTrading.Enabled {
+		accountPortfolio, err := portfolio.NewPortfolio(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化账户资金跟踪失败")
+		}
+		marketData.RegisterHandler(accountPortfolio)
+		executor.SetPortfolio(accountPortfolio)
+		valuation.SetPortfolio(accountPortfolio)
+		if cfg.Portfolio.Enabled {
+			strategyManager.SetOrderSizer(accountPortfolio)
+		}
+	}
+	if cfg.Execution.PaperTrading.Enabled {
+		logrus.Warn("模拟交易模式已启用，订单不会发送到交易所")
+	}
+
+	// 初始化持久化存储
+	var dataStore store.Store
+	if cfg.Store.Enabled {
+		dataStore, err = newStore(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化持久化存储失败")
+		}
+		defer dataStore.Close()
+		executor.SetStore(dataStore)
+	}
+
+	// 初始化历史行情存储，实时行情聚合为K线，策略预热和模拟模式从中读取历史数据
+	var historyStore history.Store
+	var backtestSource market.HistoryProvider = marketData
+	if cfg.History.Enabled {
+		historyStore, err = newHistoryStore(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化历史行情存储失败")
+		}
+		defer historyStore.Close()
+		exchangeHistory, err := history.NewRecorder(historyStore, history.SourceExchange, cfg.History.Intervals, marketData.FeedInterval())
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化历史行情记录失败")
+		}
+		marketData.RegisterHandler(exchangeHistory)
+		marketData.SetHistory(exchangeHistory)
+		backtestSource = exchangeHistory
+	}
+
+	// 回测服务，使用本地历史K线，未启用历史数据存储时使用交易所最近的K线
+	backtests := backtest.NewService(cfg, backtestSource)
+	if dataStore != nil {
+		backtests.SetStore(dataStore)
+	}
+	if err := backtests.Load(); err != nil {
+		logrus.WithError(err).Fatal("加载回测结果失败")
+	}
+
+	// 事件总线：信号、风险拒绝、成交和持仓变化实时推送给WebSocket客户端
+	eventBus := events.NewBus()
+	strategyManager.SetEventBus(eventBus)
+	riskManager.SetEventBus(eventBus)
+	executor.SetEventBus(eventBus)
+
+	// 初始化审计日志，记录信号、风险决策和订单生命周期
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		path := cfg.Audit.Path
+		if path == "" {
+			path = filepath.Join(cfg.System.DataDir, "audit.log")
+		}
+		auditLog, err = audit.NewLog(path)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化审计日志失败")
+		}
+		defer auditLog.Close()
+		strategyManager.SetAuditLog(auditLog)
+		riskManager.SetAuditLog(auditLog)
+		executor.SetAuditLog(auditLog)
+	}
 
 	// 将上下文传递给需要的模块（示例）
 	go func() {
@@ -51,6 +168,21 @@ func main() {
 	// 初始化LLM服务
 	llmService := llm.NewLLMService(cfg)
 
+	// 自定义的提示词模板，未覆盖的提示词使用内置模板
+	prompts, err := llm.LoadPrompts(cfg.LLM.Prompts)
+	if err != nil {
+		logrus.WithError(err).Fatal("加载LLM提示词模板失败")
+	}
+	llmService.SetPrompts(prompts)
+
+	// LLM用量持久化，月度预算跨重启累计
+	if dataStore != nil {
+		llmService.SetStore(dataStore)
+		if err := llmService.LoadUsage(); err != nil {
+			logrus.WithError(err).Fatal("加载LLM用量失败")
+		}
+	}
+
 	// 初始化Prometheus监控
 	prometheusRegistry := prometheus.NewRegistry()
 	prometheusRegistry.MustRegister(
@@ -58,6 +190,19 @@ func main() {
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 	)
 
+	// 业务监控指标：订单、信号、持仓价值、gas费用、LLM请求耗时和WebSocket客户端数
+	tradingMetrics, err := metrics.New(prometheusRegistry)
+	if err != nil {
+		logrus.WithError(err).Fatal("注册业务监控指标失败")
+	}
+	if err := tradingMetrics.RegisterPositions(riskManager); err != nil {
+		logrus.WithError(err).Fatal("注册持仓监控指标失败")
+	}
+	eventBus.Subscribe(tradingMetrics)
+	executor.SetMetrics(tradingMetrics)
+	llmService.SetMetrics(tradingMetrics)
+	tradingMetrics.SetLLMMonthlyCost(llmService.Budget().Spent)
+
 	// 初始化LLM控制器
 	llmController := blockchain.NewLLMController(llmService)
 
@@ -87,6 +232,16 @@ func main() {
 				"module": "blockchainMarket",
 			}).Fatal("初始化区块链市场数据服务失败")
 		}
+		if historyStore != nil {
+			blockchainHistory, err := history.NewRecorder(historyStore, history.SourceBlockchain, cfg.History.Intervals, 0)
+			if err != nil {
+				logrus.WithError(err).Fatal("初始化链上历史行情记录失败")
+			}
+			blockchainMarket.RegisterHandler(blockchainHistory)
+			blockchainMarket.SetHistory(blockchainHistory)
+			backtests.SetBlockchainSource(blockchainHistory)
+		}
+		blockchainMarket.RegisterHandler(valuation)
 
 		blockchainExecutor, err = blockchain.NewBlockchainExecutor(cfg, riskManager)
 		if err != nil {
@@ -95,6 +250,13 @@ func main() {
 				"module": "blockchainExecutor",
 			}).Fatal("初始化区块链交易执行器失败")
 		}
+		if dataStore != nil {
+			blockchainExecutor.SetStore(dataStore)
+		}
+		blockchainExecutor.SetAuditLog(auditLog)
+		blockchainExecutor.SetEventBus(eventBus)
+		blockchainExecutor.SetMetrics(tradingMetrics)
+		blockchainExecutor.SetDEXPriceProvider(blockchainMarket)
 
 		dappServer = blockchain.NewDAppAPIServer(cfg, blockchainExecutor, blockchainMarket, llmController)
 	} else {
@@ -111,9 +273,127 @@ func main() {
 		logrus.WithError(err).Fatal("注册监控指标端点失败")
 	}
 
-	// 启动市场数据服务
-	if err := marketData.Start(); err != nil {
-		logrus.Fatalf("启动市场数据服务失败: %v", err)
+	// 将交易系统接入DApp API
+	dappServer.AttachTradingSystem(strategyManager, executor, riskManager)
+	dappServer.SetMarketDataService(marketData)
+	dappServer.SetAuditLog(auditLog)
+	dappServer.SetValuationService(valuation)
+	dappServer.SetMetrics(tradingMetrics)
+	dappServer.SetBacktestService(backtests)
+
+	// 持仓对账，定期比较本地持仓与交易所余额和链上代币余额
+	reconciler := reconcile.NewService(cfg)
+	reconciler.SetMetrics(tradingMetrics)
+	reconciler.AddSource(metrics.VenueExchange, executor)
+	if blockchainExecutor != nil {
+		reconciler.AddSource(metrics.VenueBlockchain, blockchainExecutor)
+	}
+	dappServer.SetReconciliationService(reconciler)
+
+	// 新闻源，定时抓取与关注资产相关的新闻，供LLM新闻和情绪分析使用
+	var newsService *news.Service
+	if cfg.News.Enabled {
+		newsService, err = news.NewService(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化新闻源失败")
+		}
+		if dataStore != nil {
+			newsService.SetStore(dataStore)
+		}
+	}
+	dappServer.SetNewsService(newsService)
+
+	// 新闻情绪评分，定时由LLM对关注资产的最新新闻评分，供风险管理的情绪过滤使用
+	var sentimentService *sentiment.Service
+	if cfg.Sentiment.Enabled {
+		sentimentService = sentiment.NewService(cfg.Sentiment, llmService, newsService)
+		if dataStore != nil {
+			sentimentService.SetStore(dataStore)
+		}
+		riskManager.SetSentimentProvider(sentimentService)
+	}
+	dappServer.SetSentimentService(sentimentService)
+
+	// 组件健康检查，LLM只用于辅助分析，不可用时只算降级
+	healthChecks := health.NewRegistry(time.Duration(cfg.System.Health.CheckTimeoutSeconds) * time.Second)
+	healthChecks.Register("exchange", true, marketData.CheckHealth)
+	if blockchainExecutor != nil {
+		healthChecks.Register("blockchain", true, blockchainExecutor.CheckHealth)
+	}
+	if cfg.LLM.Enabled {
+		healthChecks.Register("llm", false, llmService.CheckHealth)
+	}
+	if dataStore != nil {
+		healthChecks.Register("store", true, func(ctx context.Context) health.Result {
+			if err := dataStore.Ping(); err != nil {
+				return health.Result{Status: health.StatusDown, Message: err.Error()}
+			}
+			return health.Result{Status: health.StatusOK}
+		})
+	}
+	dappServer.SetHealth(healthChecks)
+
+	// 成交、熔断、强制平仓和链上交易失败等事件按配置发送告警通知
+	var notifier *notify.Notifier
+	if cfg.Notify.Enabled {
+		notifier, err = notify.NewNotifier(cfg.Notify)
+		if err != nil {
+			logrus.WithError(err).Fatal("初始化告警通知失败")
+		}
+		eventBus.Subscribe(notifier)
+		notifier.Start()
+	}
+
+	// 行情和系统事件推送给订阅的WebSocket客户端
+	eventBus.Subscribe(dappServer)
+	marketData.RegisterHandler(dappServer)
+	if blockchainMarket != nil {
+		blockchainMarket.RegisterHandler(dappServer)
+	}
+
+	// 套利策略比较交易所行情与最新链上价格
+	if blockchainMarket != nil {
+		strategyManager.SetDEXPriceProvider(blockchainMarket)
+	}
+
+	// 策略信号交由交易执行器处理，启用人工审批时需要审批的信号先进入审批队列，批准后才交给执行器
+	var approvals *approval.Queue
+	if cfg.Approvals.Enabled {
+		approvals = approval.NewQueue(cfg.Approvals)
+		approvals.SetAuditLog(auditLog)
+		approvals.SetEventBus(eventBus)
+		approvals.RegisterSignalHandler(executor)
+		if blockchainExecutor != nil {
+			approvals.RegisterSignalHandler(blockchainExecutor)
+		}
+		strategyManager.RegisterSignalHandler(approvals)
+	} else {
+		strategyManager.RegisterSignalHandler(executor)
+		if blockchainExecutor != nil {
+			strategyManager.RegisterSignalHandler(blockchainExecutor)
+		}
+	}
+	dappServer.SetApprovalQueue(approvals)
+
+	// 先启动交易执行器并恢复持仓和未完成订单，再开始产生信号和推送行情
+	if err := executor.Start(); err != nil {
+		logrus.Fatalf("启动交易执行器失败: %v", err)
+	}
+
+	// 启动区块链交易执行器
+	if blockchainExecutor != nil {
+		if err := blockchainExecutor.Start(); err != nil {
+			logrus.Fatalf("启动区块链交易执行器失败: %v", err)
+		}
+	}
+
+	// 启动风险管理器，强制平仓信号交由交易执行器处理
+	riskManager.RegisterExitHandler(executor)
+	if blockchainExecutor != nil {
+		riskManager.RegisterExitHandler(blockchainExecutor)
+	}
+	if err := riskManager.Start(); err != nil {
+		logrus.Fatalf("启动风险管理器失败: %v", err)
 	}
 
 	// 启动策略管理器
@@ -121,9 +401,47 @@ func main() {
 		logrus.Fatalf("启动策略管理器失败: %v", err)
 	}
 
-	// 启动交易执行器
-	if err := executor.Start(); err != nil {
-		logrus.Fatalf("启动交易执行器失败: %v", err)
+	// 启动市场数据服务
+	if err := marketData.Start(); err != nil {
+		logrus.Fatalf("启动市场数据服务失败: %v", err)
+	}
+
+	// 执行器恢复持仓后开始定期对账
+	reconciler.Start()
+
+	// 定时记录账户估值快照
+	valuation.Start()
+
+	// 定时抓取新闻
+	if newsService != nil {
+		if err := newsService.Start(); err != nil {
+			logrus.WithError(err).Fatal("启动新闻源失败")
+		}
+	}
+
+	// 定时评分新闻情绪
+	if sentimentService != nil {
+		if err := sentimentService.Start(); err != nil {
+			logrus.WithError(err).Fatal("启动新闻情绪评分失败")
+		}
+	}
+
+	// 监听配置文件，风险限制、交易对和策略参数的变更无需重启即可生效
+	if cfg.System.HotReload {
+		watcher := config.NewWatcher(cfg, configPath)
+		watcher.SetStrategies(strategy.RegisteredStrategies())
+		watcher.RegisterHandler(marketData)
+		watcher.RegisterHandler(strategyManager)
+		watcher.OnChange(func(changes []config.Change) {
+			for _, change := range changes {
+				status := "applied"
+				if change.Restart {
+					status = "restart_required"
+				}
+				auditLog.Record(audit.Event{Type: audit.EventConfigChanged, Status: status, Detail: change.String()})
+			}
+		})
+		watcher.Start()
 	}
 
 	// 启动DApp API服务器
@@ -143,12 +461,61 @@ func main() {
 	// 优雅关闭
 	logrus.Info("正在关闭自动交易系统...")
 	dappServer.Stop()
+	reconciler.Stop()
+	backtests.Stop()
+	valuation.Stop()
+	if newsService != nil {
+		newsService.Stop()
+	}
+	if sentimentService != nil {
+		sentimentService.Stop()
+	}
+	riskManager.Stop()
+	if blockchainExecutor != nil {
+		blockchainExecutor.Stop()
+	}
 	executor.Stop()
 	strategyManager.Stop()
 	marketData.Stop()
+	if notifier != nil {
+		notifier.Stop()
+	}
 	logrus.Info("自动交易系统已关闭")
 }
 
+// newStore 根据配置创建持久化存储
+func newStore(cfg *config.Config) (store.Store, error) {
+	path := cfg.Store.Path
+	if path == "" {
+		path = filepath.Join(cfg.System.DataDir, "store")
+	}
+
+	switch cfg.Store.Type {
+	case "", "file":
+		return store.NewFileStore(path)
+	case "sqlite":
+		return store.NewSQLiteStore(filepath.Join(path, "store.db"))
+	default:
+		return nil, fmt.Errorf("未知的存储类型: %s", cfg.Store.Type)
+	}
+}
+
+func newHistoryStore(cfg *config.Config) (history.Store, error) {
+	path := cfg.History.Path
+	if path == "" {
+		path = filepath.Join(cfg.System.DataDir, "history")
+	}
+
+	switch cfg.History.Type {
+	case "", "file":
+		return history.NewFileStore(path, cfg.History.MaxCandles)
+	case "sqlite":
+		return history.NewSQLiteStore(filepath.Join(path, "candles.db"), cfg.History.MaxCandles)
+	default:
+		return nil, fmt.Errorf("未知的历史数据存储类型: %s", cfg.History.Type)
+	}
+}
+
 func setLogLevel(level string) {
 	switch level {
 	case "debug":
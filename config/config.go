@@ -1,6 +1,8 @@
 package config
 
 import (
+	"sync/atomic"
+
 	"github.com/spf13/viper"
 )
 
@@ -11,8 +13,210 @@ type Config struct {
 	Trading    TradingConfig    `mapstructure:"trading"`
 	Strategy   StrategyConfig   `mapstructure:"strategy"`
 	Risk       RiskConfig       `mapstructure:"risk"`
+	Execution  ExecutionConfig  `mapstructure:"execution"`
 	System     SystemConfig     `mapstructure:"system"`
 	LLM        LLMConfig        `mapstructure:"llm"`
+	Accounts   []AccountConfig  `mapstructure:"accounts"`
+	Store      StoreConfig      `mapstructure:"store"`
+	Audit      AuditConfig      `mapstructure:"audit"`
+	Portfolio  PortfolioConfig  `mapstructure:"portfolio"`
+	History    HistoryConfig    `mapstructure:"history"`
+	Notify     NotifyConfig     `mapstructure:"notify"`
+	Approvals  ApprovalConfig   `mapstructure:"approvals"`
+	News       NewsConfig       `mapstructure:"news"`
+	Sentiment  SentimentConfig  `mapstructure:"sentiment"`
+	Backtest   BacktestConfig   `mapstructure:"backtest"`
+	Fees       FeesConfig       `mapstructure:"fees"`
+
+	Reconciliation ReconciliationConfig `mapstructure:"reconciliation"`
+
+	live *atomic.Pointer[Config] // 热加载后发布的最新配置，由 Watcher 写入
+}
+
+// Current 返回最新发布的配置，风险限制、交易对和策略参数等可热加载的配置应通过它读取
+// 热加载时发布新的配置副本而不修改原配置，未热加载过时返回自身
+func (c *Config) Current() *Config {
+	if c.live != nil {
+		if latest := c.live.Load(); latest != nil {
+			return latest
+		}
+	}
+	return c
+}
+
+// ReconciliationConfig 持仓对账配置，定期将本地持仓与交易所余额和链上代币余额比较
+type ReconciliationConfig struct {
+	Enabled         bool    `mapstructure:"enabled"`
+	IntervalSeconds int     `mapstructure:"interval_seconds"` // 对账间隔
+	Tolerance       float64 `mapstructure:"tolerance"`        // 允许的差异，为本地与实际数量中较大一方的比例
+	AutoCorrect     bool    `mapstructure:"auto_correct"`     // 按实际余额校正本地持仓，持仓分属多个账户时只记录差异
+}
+
+// FeesConfig 手续费模型，交易执行、回测和盈亏统计按此计算手续费
+type FeesConfig struct {
+	MakerRate float64       `mapstructure:"maker_rate"` // 交易所挂单（限价类订单）手续费率，按成交额收取
+	TakerRate float64       `mapstructure:"taker_rate"` // 交易所吃单（市价单）手续费率，按成交额收取
+	DEX       DEXFeesConfig `mapstructure:"dex"`
+}
+
+// DEXFeesConfig 链上兑换的成本模型：流动性池手续费和gas费用
+type DEXFeesConfig struct {
+	LPFeeRate   float64           `mapstructure:"lp_fee_rate"`  // 流动性池手续费率，为0时 v3 路由按 pool_fee 计算，v2 路由为0.3%
+	GasCost     float64           `mapstructure:"gas_cost"`     // 每笔兑换的估计gas费用（计价货币），用于回测和无法换算实际gas费用时
+	NativePairs map[string]string `mapstructure:"native_pairs"` // 网络名称到原生币交易对的映射，如 ethereum: ETH/USDC，按其最新链上价格换算实际gas费用
+}
+
+// PortfolioConfig 账户资金跟踪与仓位计算配置，未启用时策略使用固定下单数量
+type PortfolioConfig struct {
+	Enabled  bool             `mapstructure:"enabled"`
+	Balances []InitialBalance `mapstructure:"balances"` // 各账户的初始余额
+	Sizer    SizerConfig      `mapstructure:"sizer"`
+
+	// 估值快照，不启用资金跟踪时也按持仓市值记录
+	ValuationIntervalSeconds int `mapstructure:"valuation_interval_seconds"` // 估值快照间隔
+	ValuationHistorySize     int `mapstructure:"valuation_history_size"`     // 每个账户保留的估值快照数量
+}
+
+// InitialBalance 账户某项资产的初始余额
+type InitialBalance struct {
+	Account string  `mapstructure:"account"` // 为空时为默认账户
+	Asset   string  `mapstructure:"asset"`
+	Amount  float64 `mapstructure:"amount"`
+}
+
+// SizerConfig 仓位计算方法配置
+type SizerConfig struct {
+	Method      string  `mapstructure:"method"`       // fixed_fraction, kelly, volatility_target
+	Fraction    float64 `mapstructure:"fraction"`     // fixed_fraction: 每笔使用的权益比例
+	MaxFraction float64 `mapstructure:"max_fraction"` // 单笔最多使用的权益比例
+
+	WinRate     float64 `mapstructure:"win_rate"`     // kelly: 胜率
+	PayoffRatio float64 `mapstructure:"payoff_ratio"` // kelly: 平均盈利与平均亏损之比
+	KellyScale  float64 `mapstructure:"kelly_scale"`  // kelly: 凯利比例的缩放，如0.5为半凯利
+
+	TargetVolatility   float64 `mapstructure:"target_volatility"`   // volatility_target: 每根K线的目标波动率
+	VolatilityLookback int     `mapstructure:"volatility_lookback"` // 估算波动率使用的K线数量
+}
+
+// StoreConfig 订单、成交和持仓的持久化存储配置
+type StoreConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Type    string `mapstructure:"type"` // 存储类型: file 快照加追加日志，sqlite 为 存储目录/store.db
+	Path    string `mapstructure:"path"` // 存储目录，为空时使用 数据目录/store
+}
+
+// HistoryConfig 历史行情存储配置，实时行情按周期聚合为K线后持久化，替代模拟的历史数据
+type HistoryConfig struct {
+	Enabled    bool     `mapstructure:"enabled"`
+	Type       string   `mapstructure:"type"`        // 存储类型: file 每个交易对每个周期一个 JSON Lines 文件，sqlite 为 存储目录/candles.db
+	Path       string   `mapstructure:"path"`        // 存储目录，为空时使用 数据目录/history
+	Intervals  []string `mapstructure:"intervals"`   // 聚合的K线周期，如 1m、5m、1h，更长的周期可由其合成
+	MaxCandles int      `mapstructure:"max_candles"` // 每个交易对每个周期保留的K线数量，超出时删除最早的K线，0表示保留全部历史
+}
+
+// NotifyConfig 告警通知配置，成交、风险拒绝、熔断、强制平仓和链上交易失败等事件按类型路由到通知渠道
+type NotifyConfig struct {
+	Enabled  bool                  `mapstructure:"enabled"`
+	Channels []NotifyChannelConfig `mapstructure:"channels"`
+	Routes   []NotifyRouteConfig   `mapstructure:"routes"`
+}
+
+// NotifyChannelConfig 通知渠道配置，按类型填写对应字段
+type NotifyChannelConfig struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"` // telegram, discord, email, webhook
+
+	BotToken string `mapstructure:"bot_token"` // telegram: 机器人令牌
+	ChatID   string `mapstructure:"chat_id"`   // telegram: 接收消息的会话ID
+
+	WebhookURL string `mapstructure:"webhook_url"` // discord: 频道的 Webhook 地址; webhook: 接收事件的地址
+
+	SMTPHost string   `mapstructure:"smtp_host"` // email: SMTP服务器
+	SMTPPort int      `mapstructure:"smtp_port"` // email: 为0时使用587
+	Username string   `mapstructure:"username"`  // email: 为空时不认证
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+
+	Secret     string `mapstructure:"secret"`      // webhook: HMAC-SHA256 签名密钥，为空时不签名
+	MaxRetries int    `mapstructure:"max_retries"` // webhook: 请求失败或返回5xx/429时的重试次数，为0时为3，为负数时不重试
+}
+
+// NotifyRouteConfig 将一类事件发送到指定的通知渠道
+type NotifyRouteConfig struct {
+	Event    string   `mapstructure:"event"`    // 事件类型，* 表示所有事件
+	Channels []string `mapstructure:"channels"` // 通知渠道名称
+}
+
+// NotifyEvents 可配置通知的事件类型，与 events 包中的事件类型一致
+var NotifyEvents = []string{"fill", "risk_rejection", "circuit_breaker", "forced_exit", "order_failed", "order_dead_letter", "approval", "trading_halt"}
+
+// ApprovalConfig 人工审批配置，需要审批的信号进入审批队列，批准后才交给执行器
+type ApprovalConfig struct {
+	Enabled           bool     `mapstructure:"enabled"`
+	NotionalThreshold float64  `mapstructure:"notional_threshold"` // 名义价值（价格×数量）超过该值的信号需要审批，为0时不按名义价值审批
+	Strategies        []string `mapstructure:"strategies"`         // 这些策略产生的信号全部需要审批，如 ["llm"]
+	TTLMinutes        int      `mapstructure:"ttl_minutes"`        // 待审批信号的有效期，为0时为30分钟
+}
+
+// NewsConfig 新闻源配置，定时抓取与关注资产相关的新闻，供LLM新闻和情绪分析使用
+type NewsConfig struct {
+	Enabled         bool               `mapstructure:"enabled"`
+	IntervalMinutes int                `mapstructure:"interval_minutes"` // 抓取间隔，为0时为15分钟
+	MaxArticles     int                `mapstructure:"max_articles"`     // 保留的新闻数量，为0时为500
+	MaxAgeHours     int                `mapstructure:"max_age_hours"`    // 超过该时间的新闻被丢弃，为0时为72小时
+	Assets          []string           `mapstructure:"assets"`           // 关注的资产，如 ["BTC", "ETH"]，为空时使用交易对的基础资产
+	Sources         []NewsSourceConfig `mapstructure:"sources"`
+}
+
+// NewsSourceConfig 新闻源
+type NewsSourceConfig struct {
+	Name   string `mapstructure:"name"`
+	Type   string `mapstructure:"type"`    // rss, cryptopanic, newsapi
+	URL    string `mapstructure:"url"`     // rss: 订阅地址; cryptopanic、newsapi: 为空时使用官方接口地址
+	APIKey string `mapstructure:"api_key"` // cryptopanic、newsapi: 接口密钥
+	Query  string `mapstructure:"query"`   // newsapi: 搜索关键词，为空时按关注资产的名称搜索
+}
+
+// SentimentConfig 新闻情绪评分配置，定时由LLM对各关注资产的最新新闻评分，保存为按资产的时间序列
+type SentimentConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	IntervalMinutes int  `mapstructure:"interval_minutes"` // 评分间隔，为0时为60分钟
+	MaxArticles     int  `mapstructure:"max_articles"`     // 每次评分使用的每个资产的最新新闻数量，为0时为20
+	WindowHours     int  `mapstructure:"window_hours"`     // 聚合情绪使用的时间窗口，为0时为24小时
+	HistorySize     int  `mapstructure:"history_size"`     // 每个资产保留的评分数量，为0时为500
+}
+
+// BacktestConfig 回测配置，回测通过 /api/backtests 提交，使用历史K线模拟策略的交易
+type BacktestConfig struct {
+	MaxConcurrent  int     `mapstructure:"max_concurrent"`  // 同时运行的回测数量，其余排队等待，为0时为1
+	MaxBars        int     `mapstructure:"max_bars"`        // 每个交易对最多使用的K线数量，为0时为10000
+	MaxResults     int     `mapstructure:"max_results"`     // 保留的回测结果数量，超出时删除最早的结果，为0时为100
+	InitialCapital float64 `mapstructure:"initial_capital"` // 请求未指定时的初始资金（计价货币），为0时为10000
+	SlippageBps    float64 `mapstructure:"slippage_bps"`    // 成交价相对收盘价的不利滑点（基点），手续费见 fees
+
+	// 参数优化
+	OptimizeWorkers int `mapstructure:"optimize_workers"` // 并行运行的候选参数回测数量，为0时为CPU核数
+	MaxCandidates   int `mapstructure:"max_candidates"`   // 一次优化最多评估的参数组合数量，为0时为200
+}
+
+// NewsSourceTypes 支持的新闻源类型
+var NewsSourceTypes = []string{"rss", "cryptopanic", "newsapi"}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"` // 审计日志文件，为空时使用 数据目录/audit.log
+}
+
+// DefaultAccountID 未指定账户时使用的默认账户
+const DefaultAccountID = "default"
+
+// AccountConfig 子账户配置，风险限制为0时使用全局风险配置
+type AccountConfig struct {
+	ID               string  `mapstructure:"id"`
+	MaxPositionSize  float64 `mapstructure:"max_position_size"`
+	MaxOpenPositions int     `mapstructure:"max_open_positions"`
 }
 
 // ExchangeConfig 交易所配置
@@ -21,25 +225,194 @@ type ExchangeConfig struct {
 	APIKey    string `mapstructure:"api_key"`
 	APISecret string `mapstructure:"api_secret"`
 	BaseURL   string `mapstructure:"base_url"`
+	WSURL     string `mapstructure:"ws_url"` // 行情推送地址
+
+	MockMode      bool   `mapstructure:"mock_mode"`      // 使用模拟行情数据，不连接交易所
+	KlineInterval string `mapstructure:"kline_interval"` // 实时订阅的K线周期
+
+	DelistAfterErrors int `mapstructure:"delist_after_errors"` // 连续返回交易对不存在达到该次数后判定为下架，0表示不检测
+
+	FuturesBaseURL     string `mapstructure:"futures_base_url"`     // 永续合约接口地址，为空时为 https://fapi.binance.com
+	FundingPollSeconds int    `mapstructure:"funding_poll_seconds"` // 获取永续合约资金费率的间隔，为0时为60秒
 }
 
 // LLMConfig LLM服务配置
 type LLMConfig struct {
 	Enabled        bool    `mapstructure:"enabled"`
 	APIKey         string  `mapstructure:"api_key"`
-	DefaultEngine  string  `mapstructure:"default_engine"`
-	DeepseekAPI    string  `mapstructure:"deepseek_api"`
-	QwenAPI        string  `mapstructure:"qwen_api"`
+	DefaultEngine  string  `mapstructure:"default_engine"` // providers 中的名称，或使用旧版接口的 deepseek、qwen
+	DeepseekAPI    string  `mapstructure:"deepseek_api"`   // 旧版 prompt/completion 接口，配置后可用 deepseek 引擎
+	QwenAPI        string  `mapstructure:"qwen_api"`       // 旧版 prompt/completion 接口，配置后可用 qwen 引擎
 	Temperature    float64 `mapstructure:"temperature"`
 	MaxTokens      int     `mapstructure:"max_tokens"`
-	RetryAttempts  int     `mapstructure:"retry_attempts"`
-	TimeoutSeconds int     `mapstructure:"timeout_seconds"`
+	RetryAttempts  int     `mapstructure:"retry_attempts"`  // 网络错误或返回5xx/429时的重试次数，按1s、2s、4s...退避
+	TimeoutSeconds int     `mapstructure:"timeout_seconds"` // 单次非流式请求的超时时间，为0时为60秒
+
+	ScaleMaxTokens   bool `mapstructure:"scale_max_tokens"`   // 根据输入内容大小放大每次请求的max_tokens
+	MaxTokensCeiling int  `mapstructure:"max_tokens_ceiling"` // 放大后的max_tokens上限，为0时使用 max_tokens
+
+	StreamFallback           bool `mapstructure:"stream_fallback"`             // 流式请求出错或停顿时改用非流式请求
+	StreamIdleTimeoutSeconds int  `mapstructure:"stream_idle_timeout_seconds"` // 流式响应超过该时间未收到数据视为停顿
+
+	Providers []LLMProviderConfig `mapstructure:"providers"` // 可用的LLM引擎，请求可通过 engine 参数选择
+
+	FallbackEngine string `mapstructure:"fallback_engine"` // 默认引擎重试后仍失败时改用的备用引擎，为空时不回退
+
+	AutoExecute LLMAutoExecuteConfig `mapstructure:"auto_execute"`
+
+	Prompts LLMPromptsConfig `mapstructure:"prompts"`
+
+	Budget LLMBudgetConfig `mapstructure:"budget"`
+
+	Conversation LLMConversationConfig `mapstructure:"conversation"`
+
+	Tools LLMToolsConfig `mapstructure:"tools"`
+
+	StrategyOptimization LLMStrategyOptimizationConfig `mapstructure:"strategy_optimization"`
+}
+
+// LLMStrategyOptimizationConfig LLM辅助策略优化配置：LLM提出参数调整，系统自动回测并将结果交给LLM继续改进
+type LLMStrategyOptimizationConfig struct {
+	Rounds       int     `mapstructure:"rounds"`        // 迭代轮数，为0时为3
+	Proposals    int     `mapstructure:"proposals"`     // 每轮LLM提出的参数组数，为0时为3
+	LookbackDays int     `mapstructure:"lookback_days"` // 请求未指定区间时回测最近的天数，为0时为90
+	TrainRatio   float64 `mapstructure:"train_ratio"`   // 回测区间中训练区间的比例，其余用于检验过拟合，为0时为0.7
+}
+
+// LLMToolsConfig LLM工具调用配置，启用后市场分析和问答接口可由LLM按需查询实时的持仓、成交、K线和风险限制
+// 旧版 deepseek_api、qwen_api 接口不支持工具调用
+type LLMToolsConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	MaxSteps int  `mapstructure:"max_steps"` // 一次回答中最多调用工具的轮数，达到后要求LLM直接回答，为0时为5
+}
+
+// LLMConversationConfig 问答接口的会话记忆配置，同一会话的后续提问会带上之前的问答
+type LLMConversationConfig struct {
+	TTLMinutes       int `mapstructure:"ttl_minutes"`        // 会话超过该时间没有新提问即被清除，为0时为30分钟
+	MaxTurns         int `mapstructure:"max_turns"`          // 每个会话保留的问答轮数，为0时为20
+	MaxHistoryTokens int `mapstructure:"max_history_tokens"` // 提供给LLM的历史问答的token上限，超出时丢弃最早的问答，为0时为4000
+	MaxSessions      int `mapstructure:"max_sessions"`       // 同时保留的会话数，超出时清除最久未使用的会话，为0时为1000
+}
+
+// LLMBudgetConfig LLM调用的费用预算，费用按各引擎配置的token单价估算
+type LLMBudgetConfig struct {
+	MonthlyLimit float64  `mapstructure:"monthly_limit"` // 每个自然月(UTC)的费用上限（美元），为0时不限制
+	Essential    []string `mapstructure:"essential"`     // 超出预算后仍可使用的提示词，见 LLMPromptNames
+}
+
+// LLMPromptsConfig 提示词模板配置，模板使用 Go text/template 语法，未覆盖的提示词使用内置模板
+type LLMPromptsConfig struct {
+	Dir       string              `mapstructure:"dir"`       // 模板文件目录，文件名为 <名称>.tmpl 或 <名称>@<版本>.tmpl，为空时不从文件加载
+	Templates []LLMPromptTemplate `mapstructure:"templates"` // 直接在配置中定义的模板
+	Versions  map[string]string   `mapstructure:"versions"`  // 各提示词使用的版本，内置模板的版本为 builtin；只有一个自定义版本时可不指定
+}
+
+// LLMPromptTemplate 一个版本的提示词模板
+type LLMPromptTemplate struct {
+	Prompt   string `mapstructure:"prompt"`  // 提示词名称，见 LLMPromptNames
+	Version  string `mapstructure:"version"` // 为空时为 custom
+	Template string `mapstructure:"template"`
+}
+
+// LLMPromptNames 可自定义模板的提示词
+var LLMPromptNames = []string{
+	"market_analysis", "optimize_strategy", "trading_recommendations", "answer_question",
+	"news_analysis", "explain_trade", "portfolio_risk", "market_summary",
+	"market_sentiment", "strategy_recommendations", "explain_market_movements", "portfolio_summary",
+	"trade_suggestions", "sentiment_score", "strategy_proposals",
+}
+
+// LLMAutoExecuteConfig 按LLM结构化交易建议下单的配置，下单仍需通过风险检查
+type LLMAutoExecuteConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	MinConfidence float64 `mapstructure:"min_confidence"` // 低于该置信度的建议不执行，0-1
+	MaxOrders     int     `mapstructure:"max_orders"`     // 单次最多执行的建议数，按置信度从高到低选择，为0时不限制
+
+	MaxNotional        float64 `mapstructure:"max_notional"`         // 单条建议的最大名义价值（价格×数量），超出时拒绝，为0时不限制
+	RequireApproval    bool    `mapstructure:"require_approval"`     // 建议先进入审批队列，人工批准后才下单
+	ApprovalTTLMinutes int     `mapstructure:"approval_ttl_minutes"` // 待审批建议的有效期，过期后不能再批准，为0时为30分钟
+	IntervalMinutes    int     `mapstructure:"interval_minutes"`     // 定时生成并处理交易建议的间隔，为0时只能通过接口触发
+	Account            string  `mapstructure:"account"`              // 定时模式的下单账户，为空时为默认账户
 }
 
+// LLMProviderConfig LLM引擎配置
+type LLMProviderConfig struct {
+	Name    string `mapstructure:"name"`
+	Type    string `mapstructure:"type"`     // openai (OpenAI 兼容的 chat completions 接口), anthropic, ollama
+	BaseURL string `mapstructure:"base_url"` // 为空时使用各类型的官方地址，ollama 为本机地址
+	APIKey  string `mapstructure:"api_key"`  // 为空时使用 llm.api_key，ollama 不需要
+	Model   string `mapstructure:"model"`
+
+	InputPrice  float64 `mapstructure:"input_price"`  // 每百万输入token的价格（美元），用于估算费用
+	OutputPrice float64 `mapstructure:"output_price"` // 每百万输出token的价格（美元）
+}
+
+// LLMProviderTypes 支持的LLM引擎类型
+var LLMProviderTypes = []string{"openai", "anthropic", "ollama"}
+
 // BlockchainConfig 区块链配置
 type BlockchainConfig struct {
 	Networks  []NetworkConfig `mapstructure:"networks"`
 	Contracts ContractsConfig `mapstructure:"contracts"`
+
+	RecoverPositions bool                `mapstructure:"recover_positions"` // 启动时根据链上余额恢复持仓
+	NonceRecovery    NonceRecoveryConfig `mapstructure:"nonce_recovery"`
+	StuckTx          StuckTxConfig       `mapstructure:"stuck_tx"`
+	Bridge           BridgeConfig        `mapstructure:"bridge"`
+	TokenSafety      TokenSafetyConfig   `mapstructure:"token_safety"`
+}
+
+// TokenSafetyConfig 代币安全检查配置，启用后买入前检查标的代币合约，未通过时拒绝订单
+type TokenSafetyConfig struct {
+	Enabled            bool     `mapstructure:"enabled"`
+	CacheMinutes       int      `mapstructure:"cache_minutes"`        // 检查结果的缓存时间，为0时为60分钟
+	RequireVerified    bool     `mapstructure:"require_verified"`     // 要求合约源码已在区块浏览器验证，需要配置网络的 explorer
+	MaxTransferTax     float64  `mapstructure:"max_transfer_tax"`     // 允许的最大买卖税率（百分比），为0时不检查
+	BlockBlacklist     bool     `mapstructure:"block_blacklist"`      // 合约包含黑名单函数时拒绝买入
+	MinLiquidityLocked float64  `mapstructure:"min_liquidity_locked"` // 流动性池LP代币中已销毁或锁仓的最低比例（百分比），为0时不检查，只支持 v2 路由
+	LockerAddresses    []string `mapstructure:"locker_addresses"`     // 视为锁仓的LP代币持有地址，如 Unicrypt、Team Finance 的锁仓合约
+	MaxHolderPercent   float64  `mapstructure:"max_holder_percent"`   // 合约所有者和部署者的最大持有比例（百分比），为0时不检查
+	Allowlist          []string `mapstructure:"allowlist"`            // 跳过检查的代币地址，如主流稳定币
+}
+
+// BridgeConfig 跨链转账配置，目标网络上输入代币不足时从其他网络转入
+type BridgeConfig struct {
+	Enabled        bool                `mapstructure:"enabled"`
+	Provider       string              `mapstructure:"provider"`        // 跨链服务，目前支持 "lifi"
+	APIURL         string              `mapstructure:"api_url"`         // 为空时使用服务的默认地址
+	APIKeyEnv      string              `mapstructure:"api_key_env"`     // 保存API密钥的环境变量名
+	Slippage       float64             `mapstructure:"slippage"`        // 跨链兑换的滑点，如 0.005 表示0.5%
+	BufferPercent  float64             `mapstructure:"buffer_percent"`  // 在缺少的数量之上多转入的百分比，用于覆盖跨链费用
+	TimeoutMinutes int                 `mapstructure:"timeout_minutes"` // 等待跨链到账的时间
+	Assets         []BridgeAssetConfig `mapstructure:"assets"`
+}
+
+// BridgeAssetConfig 可跨链的资产及其在各网络上的代币地址
+type BridgeAssetConfig struct {
+	Asset  string              `mapstructure:"asset"`
+	Tokens []BridgeTokenConfig `mapstructure:"tokens"`
+}
+
+// BridgeTokenConfig 资产在某个网络上的代币
+type BridgeTokenConfig struct {
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+}
+
+// NonceRecoveryConfig nonce缺口自动恢复配置
+type NonceRecoveryConfig struct {
+	Enabled             bool `mapstructure:"enabled"`
+	StallTimeoutSeconds int  `mapstructure:"stall_timeout_seconds"` // 链上nonce超过该时间未前进且有后续交易等待时视为阻塞
+	GasBumpPercent      int  `mapstructure:"gas_bump_percent"`      // 填补缺口的空交易在建议gas价格基础上上调的百分比
+}
+
+// StuckTxConfig 长时间未打包交易的替换配置
+type StuckTxConfig struct {
+	Enabled               bool   `mapstructure:"enabled"`
+	PendingTimeoutSeconds int    `mapstructure:"pending_timeout_seconds"` // 交易提交后超过该时间仍未打包视为卡住
+	Action                string `mapstructure:"action"`                  // "speed_up" 提高gas重发，"cancel" 发送同nonce空交易取消
+	GasBumpPercent        int    `mapstructure:"gas_bump_percent"`        // 替换交易的gas价格上调百分比，至少为10
+	MaxSpeedUps           int    `mapstructure:"max_speed_ups"`           // 加速次数上限，达到后改为取消，0表示不限制
 }
 
 // NetworkConfig 区块链网络配置
@@ -47,15 +420,119 @@ type NetworkConfig struct {
 	Name     string `mapstructure:"name"`
 	Enabled  bool   `mapstructure:"enabled"`
 	RPCURL   string `mapstructure:"rpc_url"`
+	WSURL    string `mapstructure:"ws_url"` // WebSocket节点地址，配置后通过订阅池子事件实时获取行情
 	ChainID  int    `mapstructure:"chain_id"`
 	GasLimit int    `mapstructure:"gas_limit"`
 	GasPrice string `mapstructure:"gas_price"`
+
+	EstimateGas   bool    `mapstructure:"estimate_gas"`   // 按交易估算gas上限，失败时使用 gas_limit
+	GasMultiplier float64 `mapstructure:"gas_multiplier"` // 估算结果的安全系数
+	MaxGasLimit   int     `mapstructure:"max_gas_limit"`  // gas上限的最大值
+	GasReserve    float64 `mapstructure:"gas_reserve"`    // 下单时钱包需在支付gas后保留的原生币数量
+
+	// RPCURLs 备用节点地址，配置后与 rpc_url 一起按健康检查和延迟选择节点，请求出错时自动切换，只支持 http(s) 地址
+	RPCURLs         []string `mapstructure:"rpc_urls"`
+	RPCCheckSeconds int      `mapstructure:"rpc_check_seconds"` // 节点健康检查间隔，为0时为30秒
+	RPCMaxBlockLag  int      `mapstructure:"rpc_max_block_lag"` // 最新区块号落后最高节点超过该数量时视为不健康，为0时为5个区块
+
+	ConfirmationDepth int `mapstructure:"confirmation_depth"` // 订单确认后持续检查区块重组的确认数，达到后视为最终确认，为0时为12
+
+	Router RouterConfig        `mapstructure:"router"`
+	MEV    MEVProtectionConfig `mapstructure:"mev"`
+
+	Explorer ExplorerConfig `mapstructure:"explorer"`
+}
+
+// ExplorerConfig 区块浏览器接口配置，兼容 Etherscan API（BscScan 等同类浏览器）
+type ExplorerConfig struct {
+	APIURL    string `mapstructure:"api_url"`     // 如 https://api.etherscan.io/api，为空时不查询浏览器
+	APIKeyEnv string `mapstructure:"api_key_env"` // 保存API密钥的环境变量名
+}
+
+// Endpoints 返回网络的所有RPC节点地址，rpc_url 在前，去除重复和空地址
+func (n NetworkConfig) Endpoints() []string {
+	seen := make(map[string]bool)
+	endpoints := make([]string, 0, len(n.RPCURLs)+1)
+	for _, endpoint := range append([]string{n.RPCURL}, n.RPCURLs...) {
+		if endpoint == "" || seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// MEVProtectionConfig 兑换交易的MEV防护配置
+type MEVProtectionConfig struct {
+	// PrivateRPCURL 私有交易中继地址，如 Flashbots Protect 或 MEV Blocker，配置后兑换交易不进入公开内存池
+	PrivateRPCURL     string `mapstructure:"private_rpc_url"`
+	PrivateOnRiskOnly bool   `mapstructure:"private_on_risk_only"` // 仅在检测到夹子风险时通过私有中继提交
+
+	// 内存池监控，需要配置 ws_url
+	MempoolWatch       bool `mapstructure:"mempool_watch"`
+	WatchWindowSeconds int  `mapstructure:"watch_window_seconds"` // 统计最近该时间内进入内存池的兑换交易
+	SandwichThreshold  int  `mapstructure:"sandwich_threshold"`   // 窗口内其他地址对同一代币的待处理兑换数达到该值时视为有夹子风险
+	BlockOnRisk        bool `mapstructure:"block_on_risk"`        // 有夹子风险且无法通过私有中继提交时放弃下单
+}
+
+// RouterConfig DEX路由合约配置
+type RouterConfig struct {
+	Address           string   `mapstructure:"address"`
+	Version           string   `mapstructure:"version"`             // v2: Uniswap V2/PancakeSwap 路由，v3: Uniswap V3 SwapRouter
+	QuoteTokenAddress string   `mapstructure:"quote_token_address"` // 计价代币合约地址，交易对可单独覆盖
+	PathVia           []string `mapstructure:"path_via"`            // v2 兑换路径的中间代币，如 WETH
+	PoolFee           int      `mapstructure:"pool_fee"`            // v3 池费率，如 3000 表示0.3%
+	DeadlineSeconds   int      `mapstructure:"deadline_seconds"`    // 兑换交易的有效期
+
+	ApprovalMode           string `mapstructure:"approval_mode"`            // 授权额度不足时的授权方式: exact(本次所需数量) / max(最大额度)
+	ApprovalTimeoutSeconds int    `mapstructure:"approval_timeout_seconds"` // 等待授权交易确认的时间
+
+	Aggregator AggregatorConfig `mapstructure:"aggregator"`
+}
+
+// AggregatorConfig DEX聚合器配置，配置后兑换前同时获取聚合器报价，扣除gas后获得数量更多时经聚合器兑换
+type AggregatorConfig struct {
+	Provider       string `mapstructure:"provider"`        // 为空时不使用聚合器，可选 0x / 1inch
+	APIURL         string `mapstructure:"api_url"`         // 为空时使用服务的默认地址，0x 的非以太坊网络需配置对应链的地址
+	APIKeyEnv      string `mapstructure:"api_key_env"`     // 保存API密钥的环境变量名
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"` // 获取报价的超时时间，为0时为10秒
 }
 
 // ContractsConfig 智能合约配置
 type ContractsConfig struct {
 	TradingContract  string `mapstructure:"trading_contract"`
-	WalletPrivateKey string `mapstructure:"wallet_private_key"`
+	WalletPrivateKey string `mapstructure:"wallet_private_key"` // 单钱包的十六进制私钥，作为名为 default 的钱包，配置了 wallets 时可省略
+
+	Wallets       []WalletConfig `mapstructure:"wallets"`
+	DefaultWallet string         `mapstructure:"default_wallet"` // 交易对未指定钱包时使用的钱包名称
+}
+
+// WalletConfig 签名钱包配置，未配置外部签名服务时，
+// 私钥来源按 private_key、private_key_env、private_key_file、keystore_file 的顺序取第一个已配置的
+type WalletConfig struct {
+	Name     string   `mapstructure:"name"`
+	Networks []string `mapstructure:"networks"` // 可使用该钱包的网络，为空时不限
+
+	PrivateKey     string `mapstructure:"private_key"`      // 十六进制私钥，不建议明文写入配置文件
+	PrivateKeyEnv  string `mapstructure:"private_key_env"`  // 保存十六进制私钥的环境变量名
+	PrivateKeyFile string `mapstructure:"private_key_file"` // 保存十六进制私钥的文件，如由密钥管理服务挂载的密钥文件
+
+	KeystoreFile string `mapstructure:"keystore_file"` // geth 加密keystore文件(JSON V3)
+	PasswordEnv  string `mapstructure:"password_env"`  // 保存keystore密码的环境变量名
+	PasswordFile string `mapstructure:"password_file"` // 保存keystore密码的文件
+
+	// Signer 外部签名服务，配置后由其签名交易，进程内不保存私钥
+	Signer SignerConfig `mapstructure:"signer"`
+}
+
+// SignerConfig 外部签名服务配置
+type SignerConfig struct {
+	Type           string `mapstructure:"type"`            // "clef": geth Clef; "remote": HTTP签名服务（KMS签名代理、托管钱包API等）
+	URL            string `mapstructure:"url"`             // 签名服务地址，Clef 可为 IPC 路径或 HTTP 地址
+	Address        string `mapstructure:"address"`         // 签名账户地址
+	AuthTokenEnv   string `mapstructure:"auth_token_env"`  // remote: 保存 Bearer 令牌的环境变量名
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"` // 等待签名的时间，Clef 需人工确认时应适当调大
 }
 
 // TradingConfig 交易配置
@@ -70,12 +547,138 @@ type PairConfig struct {
 	Enabled         bool   `mapstructure:"enabled"`
 	Blockchain      string `mapstructure:"blockchain,omitempty"`
 	ContractAddress string `mapstructure:"contract_address,omitempty"`
+	TokenAddress    string `mapstructure:"token_address,omitempty"` // 交易标的代币的ERC-20合约地址
+	// QuoteTokenAddress 计价代币的ERC-20合约地址，为空时使用网络路由配置中的计价代币
+	QuoteTokenAddress string `mapstructure:"quote_token_address,omitempty"`
+	// Wallet 交易该交易对使用的钱包名称，为空时使用 contracts.default_wallet
+	Wallet string `mapstructure:"wallet,omitempty"`
+	// PriceSource 链上价格来源，未配置时读取 contract_address 处 V2 交易对合约的储备量
+	PriceSource PriceSourceConfig `mapstructure:"price_source,omitempty"`
+
+	// 下单精度覆盖配置，非零时优先于从交易所获取的交易规则
+	TickSize    float64 `mapstructure:"tick_size,omitempty"`
+	StepSize    float64 `mapstructure:"step_size,omitempty"`
+	MinNotional float64 `mapstructure:"min_notional,omitempty"`
+
+	// 交易对单独的策略配置，为空时使用全局策略
+	Strategy       string                 `mapstructure:"strategy,omitempty"`        // 策略类型，为空时使用 strategy.name
+	StrategyParams map[string]interface{} `mapstructure:"strategy_params,omitempty"` // 策略参数，与全局策略类型相同时覆盖 strategy.params 中的同名参数
+
+	// 合约交易配置，market 为 perpetual 时在交易所永续合约市场交易，卖出可开空仓
+	Market   string  `mapstructure:"market,omitempty"`   // spot(默认) / perpetual
+	Leverage float64 `mapstructure:"leverage,omitempty"` // 杠杆倍数，为0时使用 risk.margin.default_leverage
+}
+
+// 交易对的市场类型
+const (
+	MarketSpot      = "spot"
+	MarketPerpetual = "perpetual"
+)
+
+// IsPerpetual 判断交易对是否在永续合约市场交易
+func (p PairConfig) IsPerpetual() bool {
+	return p.Market == MarketPerpetual
+}
+
+// PriceSourceConfig 链上价格来源配置
+type PriceSourceConfig struct {
+	Type    string `mapstructure:"type"`    // "v2_pool": 读取交易对储备量; "v3_pool": 读取池子slot0; "chainlink": 读取喂价合约
+	Address string `mapstructure:"address"` // 池子或喂价合约地址，为空时使用 contract_address
+	// Chainlink 喂价超过该时间未更新时视为失效，0表示不检查
+	MaxStalenessSeconds int `mapstructure:"max_staleness_seconds"`
+}
+
+// HasStrategyOverride 判断交易对是否单独配置了策略
+func (p PairConfig) HasStrategyOverride() bool {
+	return p.Strategy != "" || len(p.StrategyParams) > 0
 }
 
 // StrategyConfig 策略配置
 type StrategyConfig struct {
-	Name   string                 `mapstructure:"name"`
-	Params map[string]interface{} `mapstructure:"params"`
+	Name    string                 `mapstructure:"name"`
+	Account string                 `mapstructure:"account"` // 策略所属账户，为空时使用默认账户
+	Params  map[string]interface{} `mapstructure:"params"`
+
+	ReplayProtection bool   `mapstructure:"replay_protection"` // 持久化已执行信号，重启后不重复执行
+	ConflictPolicy   string `mapstructure:"conflict_policy"`   // 同一轮中买卖信号冲突的处理方式: net, suppress, confidence
+
+	SignalFilter SignalFilterConfig `mapstructure:"signal_filter"`
+
+	Regime RegimeConfig `mapstructure:"regime"`
+	Canary CanaryConfig `mapstructure:"canary"`
+
+	Rebalance RebalanceConfig `mapstructure:"rebalance"`
+
+	// Instances 同时运行的多个策略实例，为空时只运行 name 和 params 指定的策略
+	Instances []StrategyInstanceConfig `mapstructure:"instances"`
+}
+
+// StrategyInstanceConfig 策略实例配置
+type StrategyInstanceConfig struct {
+	Name     string                 `mapstructure:"name"` // 实例名称，同一类型可运行多个不同名称的实例
+	Type     string                 `mapstructure:"type"` // 策略类型，即注册时使用的名称
+	Params   map[string]interface{} `mapstructure:"params"`
+	Disabled bool                   `mapstructure:"disabled"`
+}
+
+// RebalanceConfig 投资组合再平衡策略配置
+type RebalanceConfig struct {
+	Capital            float64           `mapstructure:"capital"`              // 参与再平衡的资金总额（计价货币）
+	DriftBand          float64           `mapstructure:"drift_band"`           // 任一交易对权重偏离目标超过该值时触发
+	ScheduleMinutes    int               `mapstructure:"schedule_minutes"`     // 定时再平衡周期，0表示不定时
+	MinIntervalMinutes int               `mapstructure:"min_interval_minutes"` // 两次再平衡的最小间隔
+	Targets            []RebalanceTarget `mapstructure:"targets"`
+}
+
+// RebalanceTarget 交易对目标权重
+type RebalanceTarget struct {
+	Symbol string  `mapstructure:"symbol"`
+	Weight float64 `mapstructure:"weight"`
+}
+
+// SignalFilterConfig 信号去重和冷却配置，按 策略-交易对-场所 分别计算，0表示不限制
+type SignalFilterConfig struct {
+	CooldownSeconds    int                    `mapstructure:"cooldown_seconds"`     // 分发一个信号后，在该时间内丢弃同一策略同一交易对的任何信号
+	DedupWindowSeconds int                    `mapstructure:"dedup_window_seconds"` // 在该时间内丢弃与上一个信号方向相同的重复信号
+	Overrides          []SignalFilterOverride `mapstructure:"overrides"`            // 按策略实例覆盖上述时间
+}
+
+// SignalFilterOverride 单个策略实例的信号去重和冷却配置
+type SignalFilterOverride struct {
+	Name               string `mapstructure:"name"` // 策略实例名称
+	CooldownSeconds    int    `mapstructure:"cooldown_seconds"`
+	DedupWindowSeconds int    `mapstructure:"dedup_window_seconds"`
+}
+
+// CanaryConfig 金丝雀策略配置，使用新参数的策略实例与当前实例并行运行
+type CanaryConfig struct {
+	Enabled      bool                   `mapstructure:"enabled"`
+	SizeFraction float64                `mapstructure:"size_fraction"` // 金丝雀实例的下单数量相对当前实例的比例
+	Params       map[string]interface{} `mapstructure:"params"`        // 覆盖的策略参数
+}
+
+// RegimeConfig 市场状态标记配置
+type RegimeConfig struct {
+	Lookback            int     `mapstructure:"lookback"`             // 用于判断市场状态的K线数量
+	VolatilityThreshold float64 `mapstructure:"volatility_threshold"` // 收益率标准差超过该值视为高波动
+	TrendThreshold      float64 `mapstructure:"trend_threshold"`      // 效率比超过该值视为趋势行情
+}
+
+// GasQueueConfig gas价格超过 max_gas_price 时的订单排队配置，未启用时直接拒绝订单
+type GasQueueConfig struct {
+	Enabled              bool `mapstructure:"enabled"`
+	RetryIntervalSeconds int  `mapstructure:"retry_interval_seconds"` // 检查排队订单所在网络gas价格的间隔
+	MaxWaitSeconds       int  `mapstructure:"max_wait_seconds"`       // 排队超过该时间仍未回落到上限以下时放弃订单，0表示不限制
+}
+
+// PriceImpactConfig 链上兑换的流动性和价格冲击检查配置，按交易对流动性池的储备量估算
+type PriceImpactConfig struct {
+	Enabled              bool    `mapstructure:"enabled"`
+	MaxImpact            float64 `mapstructure:"max_impact"`             // 单笔兑换的预计价格冲击上限(%)
+	Action               string  `mapstructure:"action"`                 // 超过上限时的处理: reject(拒绝订单，默认) / split(拆分为多笔，每笔不超过上限)
+	MaxSplits            int     `mapstructure:"max_splits"`             // 拆分的最大笔数，需要更多笔时拒绝订单，为0时为5
+	SplitIntervalSeconds int     `mapstructure:"split_interval_seconds"` // 拆分后相邻两笔的下单间隔，等待池子价格恢复，为0时为30秒
+	MinLiquidity         float64 `mapstructure:"min_liquidity"`          // 池子流动性（计价货币）下限，低于时拒绝订单，为0时不检查
 }
 
 // RiskConfig 风险管理配置
@@ -84,32 +687,308 @@ type RiskConfig struct {
 	StopLoss          float64 `mapstructure:"stop_loss"`
 	TakeProfit        float64 `mapstructure:"take_profit"`
 	MaxOpenPositions  int     `mapstructure:"max_open_positions"`
-	MaxGasPrice       string  `mapstructure:"max_gas_price"`
-	SlippageTolerance float64 `mapstructure:"slippage_tolerance"`
+	MaxGasPrice       string  `mapstructure:"max_gas_price"`      // 链上交易的gas价格上限，如 "100gwei"，为空时不限制
+	SlippageTolerance float64 `mapstructure:"slippage_tolerance"` // 下单时当前报价相对信号价格的不利滑点上限(%)，链上兑换的最少获得数量也按此计算
+	SlippageAction    string  `mapstructure:"slippage_action"`    // 超过滑点容忍度时的处理: reject(拒绝订单，默认) / requote(按当前报价重新定价)
+
+	SlippageBreaker SlippageBreakerConfig `mapstructure:"slippage_breaker"`
+	GasQueue        GasQueueConfig        `mapstructure:"gas_queue"`
+	PriceImpact     PriceImpactConfig     `mapstructure:"price_impact"`
+	TrendFilter     TrendFilterConfig     `mapstructure:"trend_filter"`
+	SentimentFilter SentimentFilterConfig `mapstructure:"sentiment_filter"`
+	TradingSchedule TradingScheduleConfig `mapstructure:"trading_schedule"`
+	CircuitBreaker  CircuitBreakerConfig  `mapstructure:"circuit_breaker"`
+	ExposureLimits  ExposureLimitsConfig  `mapstructure:"exposure_limits"`
+	Margin          MarginConfig          `mapstructure:"margin"`
+
+	RiskCapital float64            `mapstructure:"risk_capital"` // 风险资金总额（计价货币），为0时不启用按交易对的风险预算
+	RiskBudget  []SymbolRiskBudget `mapstructure:"risk_budget"`
+}
+
+// MarginConfig 永续合约的杠杆和保证金配置
+type MarginConfig struct {
+	DefaultLeverage       float64 `mapstructure:"default_leverage"`        // 交易对未配置 leverage 时的杠杆倍数，为0时为1倍
+	MaxLeverage           float64 `mapstructure:"max_leverage"`            // 允许的最大杠杆倍数，为0时不限制
+	MaintenanceMarginRate float64 `mapstructure:"maintenance_margin_rate"` // 维持保证金率，用于估算强平价格
+	LiquidationBuffer     float64 `mapstructure:"liquidation_buffer"`      // 最新价格与强平价格的距离小于该比例时强制平仓，0表示不提前平仓
+}
+
+// ExposureLimitsConfig 最大敞口配置，按单个账户的持仓市值计算（计价货币），各账户分别限制
+type ExposureLimitsConfig struct {
+	Symbols []SymbolExposureLimit `mapstructure:"symbols"`
+	Groups  []AssetGroupLimit     `mapstructure:"groups"`
+}
+
+// SymbolExposureLimit 单个交易对的最大敞口
+type SymbolExposureLimit struct {
+	Symbol      string  `mapstructure:"symbol"`
+	MaxNotional float64 `mapstructure:"max_notional"`
+}
+
+// AssetGroupLimit 资产分组（如 L1、DeFi）的最大敞口
+type AssetGroupLimit struct {
+	Name        string   `mapstructure:"name"`
+	Assets      []string `mapstructure:"assets"` // 分组包含的标的资产，如 ["BTC", "ETH"]
+	MaxNotional float64  `mapstructure:"max_notional"`
+}
+
+// CircuitBreakerConfig 每日亏损熔断配置，每个账户分别统计当日盈亏和熔断
+type CircuitBreakerConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	MaxDailyLoss  float64 `mapstructure:"max_daily_loss"`  // 当日已实现与未实现亏损合计的阈值（计价货币）
+	FlattenOnTrip bool    `mapstructure:"flatten_on_trip"` // 触发熔断时平掉该账户的所有持仓
+}
+
+// SymbolRiskBudget 交易对分配到的风险资金比例
+type SymbolRiskBudget struct {
+	Symbol string  `mapstructure:"symbol"`
+	Weight float64 `mapstructure:"weight"` // 占风险资金总额的比例
+}
+
+// TradingScheduleConfig 交易时间窗口配置
+type TradingScheduleConfig struct {
+	Enabled                   bool                   `mapstructure:"enabled"`
+	Timezone                  string                 `mapstructure:"timezone"` // 如 "Asia/Shanghai"，为空时使用UTC
+	Windows                   []TradingWindowConfig  `mapstructure:"windows"`  // 全局交易时间窗口
+	Symbols                   []SymbolScheduleConfig `mapstructure:"symbols"`  // 按交易对覆盖全局窗口
+	FlattenBeforeCloseMinutes int                    `mapstructure:"flatten_before_close_minutes"`
+}
+
+// TradingWindowConfig 交易时间窗口
+type TradingWindowConfig struct {
+	Days  []string `mapstructure:"days"`  // 适用的星期，如 ["mon", "tue"]，为空表示每天
+	Start string   `mapstructure:"start"` // 开始时间 "HH:MM"
+	End   string   `mapstructure:"end"`   // 结束时间 "HH:MM"，不晚于开始时间表示跨夜
+}
+
+// SymbolScheduleConfig 交易对的交易时间窗口
+type SymbolScheduleConfig struct {
+	Symbol  string                `mapstructure:"symbol"`
+	Windows []TradingWindowConfig `mapstructure:"windows"`
+}
+
+// TrendFilterConfig 多周期趋势确认配置
+type TrendFilterConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Interval string `mapstructure:"interval"` // 更高时间周期，如 "1d"
+	Period   int    `mapstructure:"period"`   // 判断趋势的均线周期
+}
+
+// SentimentFilterConfig 新闻情绪过滤配置，资产的聚合情绪低于阈值时不允许买入
+type SentimentFilterConfig struct {
+	Enabled  bool    `mapstructure:"enabled"`
+	MinScore float64 `mapstructure:"min_score"` // 聚合情绪分数的下限，-1 到 1 之间
+}
+
+// SlippageBreakerConfig 实际滑点熔断配置
+type SlippageBreakerConfig struct {
+	Enabled        bool    `mapstructure:"enabled"`
+	Window         int     `mapstructure:"window"`           // 统计最近的成交笔数
+	MaxAvgSlippage float64 `mapstructure:"max_avg_slippage"` // 平均不利滑点阈值(%)
+}
+
+// ExecutionConfig 交易执行配置
+type ExecutionConfig struct {
+	AutoSymbolRules bool `mapstructure:"auto_symbol_rules"` // 启动时从交易所获取并缓存交易规则(价格/数量精度、最小名义价值)
+	// CancelOrphanChildren 父订单撤销或结束时撤销其挂单中的子订单，并持久化未完成订单以便重启后清理孤立子订单
+	CancelOrphanChildren bool `mapstructure:"cancel_orphan_children"`
+
+	DefaultOrderType         string  `mapstructure:"default_order_type"`          // 信号未指定时的订单类型: market, limit, stop_limit
+	LimitOrderTimeoutSeconds int     `mapstructure:"limit_order_timeout_seconds"` // GTC限价单超过该时间未全部成交时撤销剩余部分，0表示不撤销
+	LimitFillParticipation   float64 `mapstructure:"limit_fill_participation"`    // 限价单单根K线最多成交其成交量的比例，0表示不限制
+
+	PaperTrading PaperTradingConfig `mapstructure:"paper_trading"`
+	Queue        OrderQueueConfig   `mapstructure:"queue"`
+	Algo         AlgoConfig         `mapstructure:"algo"`
+}
+
+// AlgoConfig 执行算法的默认参数，信号指定 TWAP 或冰山单但未指定拆分笔数、时长时使用
+type AlgoConfig struct {
+	Slices              int `mapstructure:"slices"`                // 拆分笔数，冰山单每次只挂出 1/slices 的数量
+	TWAPDurationSeconds int `mapstructure:"twap_duration_seconds"` // TWAP 在该时长内等间隔下单
+	RefreshSeconds      int `mapstructure:"refresh_seconds"`       // 检查子订单是否成交的间隔，冰山单的挂出部分成交后挂出下一部分
+}
+
+// OrderQueueConfig 交易所订单队列配置，策略和强制平仓的信号按优先级（强制平仓 > 平仓 > 开仓）依次下单
+type OrderQueueConfig struct {
+	MaxQueued           int `mapstructure:"max_queued"`            // 排队的任务数上限，超出时拒绝新的开仓信号
+	MaxAttempts         int `mapstructure:"max_attempts"`          // 暂时性失败的最多尝试次数，用尽后进入死信队列
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"` // 首次重试的等待时间，之后每次翻倍
+	MaxBackoffSeconds   int `mapstructure:"max_backoff_seconds"`   // 重试等待时间的上限
+	DeadLetterSize      int `mapstructure:"dead_letter_size"`      // 保留的死信任务数
+}
+
+// PaperTradingConfig 模拟交易配置，启用后按实时行情模拟成交，使用 portfolio.balances 作为虚拟余额，手续费见 fees
+type PaperTradingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	SlippageBps float64 `mapstructure:"slippage_bps"` // 市价单相对最新价格的不利滑点（基点）
 }
 
 // SystemConfig 系统配置
 type SystemConfig struct {
-	LogLevel     string `mapstructure:"log_level"`
-	DataDir      string `mapstructure:"data_dir"`
-	BacktestMode bool   `mapstructure:"backtest_mode"`
-	DAppPort     int    `mapstructure:"dapp_port"`
+	LogLevel     string          `mapstructure:"log_level"`
+	DataDir      string          `mapstructure:"data_dir"`
+	BacktestMode bool            `mapstructure:"backtest_mode"`
+	DAppPort     int             `mapstructure:"dapp_port"`
+	HotReload    bool            `mapstructure:"hot_reload"` // 监听配置文件，变更时热加载风险限制、交易对和策略参数
+	Auth         AuthConfig      `mapstructure:"auth"`
+	RateLimit    RateLimitConfig `mapstructure:"rate_limit"`
+	Health       HealthConfig    `mapstructure:"health"`
+
+	Webhook WebhookConfig `mapstructure:"webhook"`
+}
+
+// WebhookConfig 外部信号 Webhook 配置，接收 TradingView 等平台的警报并转换为交易信号
+type WebhookConfig struct {
+	Enabled  bool    `mapstructure:"enabled"`
+	Secret   string  `mapstructure:"secret"`   // 共享密钥，警报的 passphrase 字段或 X-Webhook-Secret 请求头需与之一致
+	Account  string  `mapstructure:"account"`  // 信号所属账户，为空时为默认账户
+	Strategy string  `mapstructure:"strategy"` // 信号归属的策略名称，为空时为 webhook
+	Quantity float64 `mapstructure:"quantity"` // 警报未给出数量时的下单数量，为0时警报必须给出数量
+}
+
+// HealthConfig 健康检查配置，用于 /readyz 的组件检查
+type HealthConfig struct {
+	CheckTimeoutSeconds int `mapstructure:"check_timeout_seconds"` // 单次检查所有组件的超时时间，为0时为5秒
+	MaxBlockLagSeconds  int `mapstructure:"max_block_lag_seconds"` // 节点最新区块落后当前时间超过该值视为降级，为0时为60秒
+}
+
+// RateLimitConfig DApp API 限流配置，已认证的请求按API密钥或钱包会话计数，否则按客户端IP计数
+type RateLimitConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Default RateLimitRule `mapstructure:"default"` // 所有请求
+	LLM     RateLimitRule `mapstructure:"llm"`     // LLM 接口，在默认限制之外额外限制
+	Trade   RateLimitRule `mapstructure:"trade"`   // 下单和撤单接口，在默认限制之外额外限制
+}
+
+// RateLimitRule 令牌桶限流规则，RequestsPerMinute 为0时不限制
+type RateLimitRule struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	Burst             int `mapstructure:"burst"` // 允许的突发请求数，为0时等于每分钟请求数
+}
+
+// AuthConfig DApp API 认证配置
+type AuthConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	APIKeys []APIKeyConfig `mapstructure:"api_keys"`
+	SIWE    SIWEConfig     `mapstructure:"siwe"`
+}
+
+// SIWEConfig 钱包签名登录 (Sign-In-With-Ethereum, EIP-4361) 配置
+type SIWEConfig struct {
+	Enabled           bool               `mapstructure:"enabled"`
+	Domain            string             `mapstructure:"domain"`              // 签名消息中必须声明的域名，消息的 URI 也须指向该域名，启用时必填
+	NonceTTLSeconds   int                `mapstructure:"nonce_ttl_seconds"`   // 登录随机数的有效期
+	SessionTTLMinutes int                `mapstructure:"session_ttl_minutes"` // 登录后会话令牌的有效期
+	DefaultRole       string             `mapstructure:"default_role"`        // 未在 wallets 中列出的钱包的角色，为空时拒绝登录
+	Wallets           []SIWEWalletConfig `mapstructure:"wallets"`
+}
+
+// SIWEWalletConfig 钱包地址对应的权限
+type SIWEWalletConfig struct {
+	Address  string   `mapstructure:"address"`
+	Role     string   `mapstructure:"role"`     // viewer, trader, admin
+	Accounts []string `mapstructure:"accounts"` // 绑定的账户，为空时只能访问默认账户，admin 角色不限制
+}
+
+// APIKeyConfig API密钥及其角色
+// 角色: viewer 只读, trader 可下单和撤单, admin 可修改策略和重置熔断
+type APIKeyConfig struct {
+	Name     string   `mapstructure:"name"`
+	Key      string   `mapstructure:"key"`
+	KeyEnv   string   `mapstructure:"key_env"`  // 从环境变量读取密钥，优先于 key
+	Role     string   `mapstructure:"role"`     // viewer, trader, admin
+	Accounts []string `mapstructure:"accounts"` // 绑定的账户，为空时只能访问默认账户，admin 角色不限制
+}
+
+// HasAccount 判断账户是否可用，未配置任何子账户时只允许默认账户
+func (c *Config) HasAccount(accountID string) bool {
+	if len(c.Accounts) == 0 {
+		return accountID == DefaultAccountID
+	}
+
+	for _, account := range c.Accounts {
+		if account.ID == accountID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AccountLimits 返回账户的风险限制，未配置的项使用全局风险配置
+func (c *Config) AccountLimits(accountID string) AccountConfig {
+	limits := AccountConfig{
+		ID:               accountID,
+		MaxPositionSize:  c.Current().Risk.MaxPositionSize,
+		MaxOpenPositions: c.Current().Risk.MaxOpenPositions,
+	}
+
+	for _, account := range c.Accounts {
+		if account.ID != accountID {
+			continue
+		}
+		if account.MaxPositionSize > 0 {
+			limits.MaxPositionSize = account.MaxPositionSize
+		}
+		if account.MaxOpenPositions > 0 {
+			limits.MaxOpenPositions = account.MaxOpenPositions
+		}
+		break
+	}
+
+	return limits
+}
+
+// PerpetualLeverage 返回永续合约交易对的杠杆倍数，交易对不是永续合约时返回 false
+func (c *Config) PerpetualLeverage(symbol string) (float64, bool) {
+	c = c.Current()
+	for _, pair := range c.Trading.Pairs {
+		if pair.Symbol != symbol {
+			continue
+		}
+		if !pair.IsPerpetual() {
+			return 0, false
+		}
+		leverage := pair.Leverage
+		if leverage <= 0 {
+			leverage = c.Risk.Margin.DefaultLeverage
+		}
+		if leverage <= 0 {
+			leverage = 1
+		}
+		return leverage, true
+	}
+	return 0, false
 }
 
 // LoadConfig 从指定路径加载配置文件
+// 任意配置项可由 AUTOTRADE_ 前缀的环境变量覆盖，字符串中的 ${ENV:名称}、${FILE:路径}、${VAULT:路径#字段} 引用在加载时替换为实际值
 func LoadConfig(configPath string) (*Config, error) {
 	viper.SetConfigFile(configPath)
+	bindEnv(viper.GetViper())
 
 	err := viper.ReadInConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	var config Config
-	err = viper.Unmarshal(&config)
+	cfg, err := decode(viper.GetViper())
 	if err != nil {
 		return nil, err
 	}
+	cfg.live = new(atomic.Pointer[Config])
+	return cfg, nil
+}
 
+// decode 将 viper 中的配置解析为配置结构并解析密钥引用
+func decode(v *viper.Viper) (*Config, error) {
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(&config); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// ReloadHandler 配置热加载处理器，新配置通过校验并发布后调用
+// previous 为变更前的配置，新配置通过各组件持有的共享配置的 Current 读取
+type ReloadHandler interface {
+	ReloadConfig(previous *Config)
+}
+
+// Change 一项配置变更，Path 为配置文件中的键路径，如 risk.stop_loss
+type Change struct {
+	Path    string      `json:"path"`
+	Old     interface{} `json:"old"`
+	New     interface{} `json:"new"`
+	Restart bool        `json:"restart"` // 需要重启才能生效，热加载时未应用
+}
+
+// String 返回变更的可读描述
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Watcher 监听配置文件变化并热加载风险限制、交易对和策略参数
+// 只有这些配置会在运行中应用，其他配置的变更记录为需要重启
+type Watcher struct {
+	cfg        *Config
+	path       string
+	handlers   []ReloadHandler
+	onChange   func(changes []Change)
+	strategies []string // 已注册的策略类型，用于校验新配置
+	mutex      sync.Mutex
+}
+
+// NewWatcher 创建配置监听器，cfg 为各组件共享的配置，需在各组件开始读取配置前创建
+func NewWatcher(cfg *Config, path string) *Watcher {
+	if cfg.live == nil {
+		cfg.live = new(atomic.Pointer[Config])
+	}
+	return &Watcher{cfg: cfg, path: path}
+}
+
+// SetStrategies 设置已注册的策略类型，新配置中的未知策略会导致热加载失败
+func (w *Watcher) SetStrategies(strategies []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.strategies = strategies
+}
+
+// RegisterHandler 注册配置热加载处理器
+func (w *Watcher) RegisterHandler(handler ReloadHandler) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.handlers = append(w.handlers, handler)
+}
+
+// OnChange 设置配置变更回调，用于记录变更日志
+func (w *Watcher) OnChange(fn func(changes []Change)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.onChange = fn
+}
+
+// Start 开始监听配置文件，文件变化时重新加载
+func (w *Watcher) Start() {
+	viper.OnConfigChange(func(event fsnotify.Event) {
+		if err := w.Reload(); err != nil {
+			logrus.Errorf("配置热加载失败，继续使用当前配置: %v", err)
+		}
+	})
+	viper.WatchConfig()
+	logrus.Infof("已开始监听配置文件 %s", w.path)
+}
+
+// Reload 重新读取配置文件，校验通过后应用可热加载的配置并通知各处理器
+func (w *Watcher) Reload() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	v := viper.New()
+	v.SetConfigFile(w.path)
+	bindEnv(v)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	next, err := decode(v)
+	if err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	if err := next.Validate(w.strategies); err != nil {
+		return err
+	}
+
+	previous := w.cfg.Current()
+	changes := diffConfig(previous, next)
+	if len(changes) == 0 {
+		logrus.Debug("配置文件已变化，但配置内容未变更")
+		return nil
+	}
+
+	// 在副本上应用可热加载的配置后整体发布，正在读取旧配置的组件不受影响
+	published := *previous
+	applyReloadable(&published, next)
+	w.cfg.live.Store(&published)
+
+	for _, change := range changes {
+		if change.Restart {
+			logrus.Warnf("配置变更需要重启才能生效: %s", change)
+		} else {
+			logrus.Infof("配置已热加载: %s", change)
+		}
+	}
+	if w.onChange != nil {
+		w.onChange(changes)
+	}
+	for _, handler := range w.handlers {
+		handler.ReloadConfig(previous)
+	}
+	return nil
+}
+
+// applyReloadable 将可热加载的配置写入待发布的配置副本
+func applyReloadable(cfg, next *Config) {
+	cfg.Risk = next.Risk
+	cfg.Trading.Pairs = next.Trading.Pairs
+	cfg.Strategy.Params = next.Strategy.Params
+	cfg.Strategy.Instances = next.Strategy.Instances
+	cfg.Strategy.ConflictPolicy = next.Strategy.ConflictPolicy
+	cfg.Strategy.SignalFilter = next.Strategy.SignalFilter
+}
+
+// reloadablePaths 可热加载的配置路径，其余配置变更需要重启
+var reloadablePaths = []string{
+	"risk",
+	"trading.pairs",
+	"strategy.params",
+	"strategy.instances",
+	"strategy.conflict_policy",
+	"strategy.signal_filter",
+}
+
+// isReloadable 判断配置路径是否可热加载
+func isReloadable(path string) bool {
+	for _, prefix := range reloadablePaths {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffConfig 逐项比较两份配置，结构体按字段展开，列表和映射整体比较
+func diffConfig(current, next *Config) []Change {
+	var changes []Change
+	diffValue("", reflect.ValueOf(*current), reflect.ValueOf(*next), &changes)
+	return changes
+}
+
+// diffValue 比较配置值并记录变更
+func diffValue(path string, current, next reflect.Value, changes *[]Change) {
+	if current.Kind() == reflect.Slice && elementKey(current.Type().Elem()) != "" {
+		diffKeyedSlice(path, current, next, changes)
+		return
+	}
+	if current.Kind() == reflect.Struct {
+		for i := 0; i < current.NumField(); i++ {
+			field := current.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			diffValue(name, current.Field(i), next.Field(i), changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(current.Interface(), next.Interface()) {
+		return
+	}
+	change := Change{
+		Path:    path,
+		Old:     current.Interface(),
+		New:     next.Interface(),
+		Restart: !isReloadable(path),
+	}
+	if isSecretPath(path) {
+		change.Old, change.New = redacted, redacted
+	}
+	*changes = append(*changes, change)
+}
+
+// elementKey 返回列表元素用于标识的字段名，如交易对的 Symbol、策略实例的 Name，没有时返回空
+func elementKey(t reflect.Type) string {
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range []string{"Symbol", "Name"} {
+		if field, ok := t.FieldByName(name); ok && field.Type.Kind() == reflect.String {
+			return name
+		}
+	}
+	return ""
+}
+
+// diffKeyedSlice 按标识字段逐个比较列表元素，如 trading.pairs[BTC/USDT].enabled
+func diffKeyedSlice(path string, current, next reflect.Value, changes *[]Change) {
+	key := elementKey(current.Type().Elem())
+	index := func(list reflect.Value) (map[string]reflect.Value, []string) {
+		items := make(map[string]reflect.Value, list.Len())
+		var order []string
+		for i := 0; i < list.Len(); i++ {
+			id := list.Index(i).FieldByName(key).String()
+			if _, ok := items[id]; !ok {
+				order = append(order, id)
+			}
+			items[id] = list.Index(i)
+		}
+		return items, order
+	}
+	currentItems, currentOrder := index(current)
+	nextItems, nextOrder := index(next)
+
+	for _, id := range currentOrder {
+		itemPath := fmt.Sprintf("%s[%s]", path, id)
+		if item, ok := nextItems[id]; ok {
+			diffValue(itemPath, currentItems[id], item, changes)
+			continue
+		}
+		*changes = append(*changes, Change{Path: itemPath, Old: id, New: nil, Restart: !isReloadable(path)})
+	}
+	for _, id := range nextOrder {
+		if _, ok := currentItems[id]; !ok {
+			*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%s]", path, id), Old: nil, New: id, Restart: !isReloadable(path)})
+		}
+	}
+}
+
+// redacted 变更日志中替代密钥值的占位符
+const redacted = "******"
+
+// isSecretPath 判断配置路径是否为密钥类配置，变更日志中不记录其值
+func isSecretPath(path string) bool {
+	name := path[strings.LastIndex(path, ".")+1:]
+	if strings.HasSuffix(name, "key") || strings.HasSuffix(name, "keys") {
+		return true
+	}
+	for _, word := range []string{"secret", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingReloadHandler 记录热加载时收到的变更前配置
+type recordingReloadHandler struct {
+	previous *Config
+}
+
+func (h *recordingReloadHandler) ReloadConfig(previous *Config) {
+	h.previous = previous
+}
+
+func TestReloadPublishesNewConfigWithoutMutatingCurrent(t *testing.T) {
+	content, err := ioutil.ReadFile("../configs/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher := NewWatcher(cfg, path)
+	handler := &recordingReloadHandler{}
+	watcher.RegisterHandler(handler)
+	original := cfg.Current()
+
+	// 热加载期间并发读取可热加载的配置
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = cfg.Current().Risk.MaxOpenPositions
+				_ = len(cfg.Current().Trading.Pairs)
+			}
+		}
+	}()
+
+	updated := strings.Replace(string(content), "max_open_positions: 3", "max_open_positions: 7", 1)
+	if err := ioutil.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = watcher.Reload()
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.Current().Risk.MaxOpenPositions; got != 7 {
+		t.Fatalf("热加载后应读取到新的风险限制，实际 %d", got)
+	}
+	if original.Risk.MaxOpenPositions != 3 || cfg.Risk.MaxOpenPositions != 3 {
+		t.Fatal("热加载不应修改已发布的配置")
+	}
+	if handler.previous != original {
+		t.Fatal("处理器应收到变更前的配置")
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 覆盖配置的环境变量前缀，如 AUTOTRADE_RISK_STOP_LOSS 覆盖 risk.stop_loss
+const EnvPrefix = "AUTOTRADE"
+
+// secretPattern 配置值中的密钥引用，如 ${ENV:BINANCE_API_KEY}、${FILE:/run/secrets/key}、${VAULT:secret/data/autotrade#api_key}
+var secretPattern = regexp.MustCompile(`\$\{(ENV|FILE|VAULT):([^}]+)\}`)
+
+// vaultTimeout 读取 Vault 密钥的超时时间
+const vaultTimeout = 10 * time.Second
+
+// bindEnv 允许通过带前缀的环境变量覆盖任意配置项，键中的点号替换为下划线
+// 配置结构中的每个标量字段都显式绑定，配置文件中未出现的键同样可以覆盖；列表中的元素不支持单独覆盖
+func bindEnv(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, key := range configKeys("", reflect.TypeOf(Config{})) {
+		v.BindEnv(key)
+	}
+}
+
+// configKeys 返回配置结构中所有标量字段的键路径
+func configKeys(prefix string, t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, configKeys(name, field.Type)...)
+		case reflect.Slice, reflect.Map:
+			// 列表和映射整体来自配置文件
+		default:
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// resolveSecrets 将配置中所有字符串字段里的密钥引用替换为实际值，返回所有无法解析的引用
+func resolveSecrets(cfg *Config) error {
+	r := &secretResolver{vault: make(map[string]map[string]interface{})}
+	r.walk("", reflect.ValueOf(cfg).Elem())
+	if len(r.problems) > 0 {
+		return &ValidationError{Problems: r.problems}
+	}
+	return nil
+}
+
+// secretResolver 解析密钥引用，同一个 Vault 路径只读取一次
+type secretResolver struct {
+	vault    map[string]map[string]interface{}
+	problems []string
+}
+
+// walk 遍历配置值，替换可写字符串中的密钥引用
+func (r *secretResolver) walk(path string, value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			name := strings.Split(value.Type().Field(i).Tag.Get("mapstructure"), ",")[0]
+			if name == "" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			r.walk(name, value.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			r.walk(fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+		}
+	case reflect.String:
+		if value.CanSet() && strings.Contains(value.String(), "${") {
+			value.SetString(r.expand(path, value.String()))
+		}
+	}
+}
+
+// expand 替换字符串中的所有密钥引用
+func (r *secretResolver) expand(path, value string) string {
+	return secretPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := secretPattern.FindStringSubmatch(ref)
+		secret, err := r.lookup(match[1], strings.TrimSpace(match[2]))
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: 无法解析 %s: %v", path, ref, err))
+			return ""
+		}
+		return secret
+	})
+}
+
+// lookup 按引用类型读取密钥
+func (r *secretResolver) lookup(kind, ref string) (string, error) {
+	switch kind {
+	case "ENV":
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("环境变量未设置")
+		}
+		return value, nil
+	case "FILE":
+		content, err := ioutil.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	case "VAULT":
+		return r.lookupVault(ref)
+	}
+	return "", fmt.Errorf("未知的引用类型 %s", kind)
+}
+
+// lookupVault 从 HashiCorp Vault 读取密钥，引用格式为 路径#字段，如 secret/data/autotrade#wallet_private_key
+// 地址和令牌来自 VAULT_ADDR 和 VAULT_TOKEN 环境变量，同时支持 KV v1 和 v2 引擎的响应格式
+func (r *secretResolver) lookupVault(ref string) (string, error) {
+	index := strings.LastIndex(ref, "#")
+	if index <= 0 || index == len(ref)-1 {
+		return "", fmt.Errorf("格式应为 路径#字段")
+	}
+	path, field := strings.Trim(ref[:index], "/"), ref[index+1:]
+
+	data, ok := r.vault[path]
+	if !ok {
+		var err error
+		data, err = readVault(path)
+		if err != nil {
+			return "", err
+		}
+		r.vault[path] = data
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("Vault 密钥 %s 中没有字段 %s", path, field)
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// readVault 读取 Vault 路径下的所有字段
+func readVault(path string) (map[string]interface{}, error) {
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("需要设置 VAULT_ADDR 和 VAULT_TOKEN 环境变量")
+	}
+
+	req, err := http.NewRequest("GET", addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建Vault请求失败: %v", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	client := &http.Client{Timeout: vaultTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求Vault失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取Vault响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault返回状态码 %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析Vault响应失败: %v", err)
+	}
+	// KV v2 引擎的字段位于 data.data 中
+	if nested, ok := result.Data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := result.Data["metadata"]; hasMetadata {
+			return nested, nil
+		}
+	}
+	return result.Data, nil
+}
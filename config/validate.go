@@ -0,0 +1,917 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ValidationError 配置校验或密钥解析发现的所有问题
+type ValidationError struct {
+	Problems []string
+}
+
+// Error 实现 error 接口，每行一个问题
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("配置存在 %d 个问题:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// validator 收集校验问题
+type validator struct {
+	problems []string
+}
+
+// addf 记录一个问题，格式为 "键路径: 说明"
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// gasPricePattern 固定gas价格的格式，如 "20gwei"、"1.5 gwei"、"5000000000"
+var gasPricePattern = regexp.MustCompile(`^\d+(\.\d+)?\s*(wei|gwei)?$`)
+
+// intervalPattern K线周期的格式，如 "1m"、"4h"、"1d"
+var intervalPattern = regexp.MustCompile(`^[1-9]\d*[mhdw]$`)
+
+// Validate 校验配置，返回包含所有问题的 ValidationError，strategies 为已注册的策略类型
+// 在开始交易前发现缺失的节点地址、格式错误的私钥、无效的风险参数和未知的策略等问题
+func (c *Config) Validate(strategies []string) error {
+	v := &validator{}
+	c.validateExchange(v)
+	c.validateBlockchain(v)
+	c.validateTrading(v)
+	c.validateStrategy(v, strategies)
+	c.validateRisk(v)
+	c.validateSystem(v)
+	c.validateLLM(v)
+	c.validateNotify(v)
+	c.validateNews(v)
+	c.validateSentiment(v)
+	c.validateBacktest(v)
+	c.validateFees(v)
+
+	if c.Reconciliation.IntervalSeconds < 0 {
+		v.addf("reconciliation.interval_seconds", "不能为负数")
+	}
+	if c.Reconciliation.Tolerance < 0 || c.Reconciliation.Tolerance >= 1 {
+		v.addf("reconciliation.tolerance", "应在 0 到 1 之间，当前为 %v", c.Reconciliation.Tolerance)
+	}
+
+	queue := c.Execution.Queue
+	if queue.MaxQueued < 0 || queue.MaxAttempts < 0 || queue.RetryBackoffSeconds < 0 || queue.MaxBackoffSeconds < 0 || queue.DeadLetterSize < 0 {
+		v.addf("execution.queue", "max_queued、max_attempts、retry_backoff_seconds、max_backoff_seconds、dead_letter_size 不能为负数")
+	}
+	algo := c.Execution.Algo
+	if algo.Slices < 0 || algo.TWAPDurationSeconds < 0 || algo.RefreshSeconds < 0 {
+		v.addf("execution.algo", "slices、twap_duration_seconds、refresh_seconds 不能为负数")
+	}
+
+	if c.Portfolio.Enabled {
+		switch c.Portfolio.Sizer.Method {
+		case "", "fixed_fraction", "kelly", "volatility_target":
+		default:
+			v.addf("portfolio.sizer.method", "未知的仓位计算方法 %q，可选 fixed_fraction、kelly、volatility_target", c.Portfolio.Sizer.Method)
+		}
+	}
+	if c.Portfolio.ValuationIntervalSeconds < 0 {
+		v.addf("portfolio.valuation_interval_seconds", "不能为负数")
+	}
+	if c.Portfolio.ValuationHistorySize < 0 {
+		v.addf("portfolio.valuation_history_size", "不能为负数")
+	}
+	if c.Store.Enabled && c.Store.Type != "" && c.Store.Type != "file" && c.Store.Type != "sqlite" {
+		v.addf("store.type", "未知的存储类型 %q，应为 file 或 sqlite", c.Store.Type)
+	}
+	if c.History.Enabled {
+		if c.History.Type != "" && c.History.Type != "file" && c.History.Type != "sqlite" {
+			v.addf("history.type", "未知的存储类型 %q，应为 file 或 sqlite", c.History.Type)
+		}
+		if len(c.History.Intervals) == 0 {
+			v.addf("history.intervals", "至少需要一个K线周期")
+		}
+		for i, interval := range c.History.Intervals {
+			if !intervalPattern.MatchString(interval) {
+				v.addf(fmt.Sprintf("history.intervals[%d]", i), "无效的K线周期 %q，应为数字加单位 m、h、d 或 w，如 5m", interval)
+			}
+		}
+		if c.History.MaxCandles < 0 {
+			v.addf("history.max_candles", "不能为负数")
+		}
+	}
+
+	if c.Approvals.Enabled {
+		if c.Approvals.NotionalThreshold < 0 {
+			v.addf("approvals.notional_threshold", "不能为负数")
+		}
+		if c.Approvals.NotionalThreshold == 0 && len(c.Approvals.Strategies) == 0 {
+			v.addf("approvals", "已启用人工审批但未配置 notional_threshold 或 strategies，没有信号需要审批")
+		}
+		if c.Approvals.TTLMinutes < 0 {
+			v.addf("approvals.ttl_minutes", "不能为负数")
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *Config) validateExchange(v *validator) {
+	if c.Exchange.MockMode {
+		return
+	}
+	if !validURL(c.Exchange.BaseURL, "http", "https") {
+		v.addf("exchange.base_url", "需要 http(s) 地址，当前为 %q", c.Exchange.BaseURL)
+	}
+	if c.Exchange.WSURL != "" && !validURL(c.Exchange.WSURL, "ws", "wss") {
+		v.addf("exchange.ws_url", "需要 ws(s) 地址，当前为 %q", c.Exchange.WSURL)
+	}
+	if c.Exchange.DelistAfterErrors < 0 {
+		v.addf("exchange.delist_after_errors", "不能为负数")
+	}
+	if c.Exchange.FundingPollSeconds < 0 {
+		v.addf("exchange.funding_poll_seconds", "不能为负数")
+	}
+	if c.Exchange.FuturesBaseURL != "" && !validURL(c.Exchange.FuturesBaseURL, "http", "https") {
+		v.addf("exchange.futures_base_url", "需要 http(s) 地址，当前为 %q", c.Exchange.FuturesBaseURL)
+	}
+}
+
+func (c *Config) validateBlockchain(v *validator) {
+	networks := make(map[string]bool)
+	enabled := 0
+	for i, network := range c.Blockchain.Networks {
+		path := fmt.Sprintf("blockchain.networks[%d]", i)
+		if network.Name == "" {
+			v.addf(path+".name", "不能为空")
+			continue
+		}
+		path = fmt.Sprintf("blockchain.networks[%s]", network.Name)
+		if networks[network.Name] {
+			v.addf(path, "网络名称重复")
+		}
+		networks[network.Name] = true
+		if !network.Enabled {
+			continue
+		}
+		enabled++
+
+		if network.RPCURL == "" {
+			v.addf(path+".rpc_url", "已启用的网络必须配置节点地址")
+		} else if strings.Contains(network.RPCURL, "://") && !validURL(network.RPCURL, "http", "https", "ws", "wss") {
+			v.addf(path+".rpc_url", "需要 http(s)、ws(s) 地址或IPC路径，当前为 %q", network.RPCURL)
+		}
+		if len(network.RPCURLs) > 0 {
+			if network.RPCURL != "" && !validURL(network.RPCURL, "http", "https") {
+				v.addf(path+".rpc_url", "配置了 rpc_urls 时需要 http(s) 地址，当前为 %q", network.RPCURL)
+			}
+			for j, endpoint := range network.RPCURLs {
+				if !validURL(endpoint, "http", "https") {
+					v.addf(fmt.Sprintf("%s.rpc_urls[%d]", path, j), "需要 http(s) 地址，当前为 %q", endpoint)
+				}
+			}
+		}
+		if network.RPCCheckSeconds < 0 {
+			v.addf(path+".rpc_check_seconds", "不能为负数")
+		}
+		if network.RPCMaxBlockLag < 0 {
+			v.addf(path+".rpc_max_block_lag", "不能为负数")
+		}
+		if network.ConfirmationDepth < 0 {
+			v.addf(path+".confirmation_depth", "不能为负数")
+		}
+		if network.WSURL != "" && !validURL(network.WSURL, "ws", "wss") {
+			v.addf(path+".ws_url", "需要 ws(s) 地址，当前为 %q", network.WSURL)
+		}
+		if network.ChainID <= 0 {
+			v.addf(path+".chain_id", "必须为正数")
+		}
+		if network.GasPrice != "" && network.GasPrice != "auto" && !gasPricePattern.MatchString(strings.ToLower(network.GasPrice)) {
+			v.addf(path+".gas_price", "应为 \"auto\" 或如 \"20gwei\" 的固定价格，当前为 %q", network.GasPrice)
+		}
+		switch network.Router.Aggregator.Provider {
+		case "", "0x", "1inch":
+		default:
+			v.addf(path+".router.aggregator.provider", "应为 0x 或 1inch，当前为 %q", network.Router.Aggregator.Provider)
+		}
+		if network.Router.Aggregator.APIURL != "" && !validURL(network.Router.Aggregator.APIURL, "http", "https") {
+			v.addf(path+".router.aggregator.api_url", "需要 http(s) 地址，当前为 %q", network.Router.Aggregator.APIURL)
+		}
+		if network.Router.Address != "" && !common.IsHexAddress(network.Router.Address) {
+			v.addf(path+".router.address", "不是有效的合约地址: %q", network.Router.Address)
+		}
+		switch network.Router.Version {
+		case "", "v2", "v3":
+		default:
+			v.addf(path+".router.version", "应为 v2 或 v3，当前为 %q", network.Router.Version)
+		}
+		if network.MEV.MempoolWatch && network.WSURL == "" {
+			v.addf(path+".mev.mempool_watch", "内存池监控需要配置 ws_url")
+		}
+		if network.Explorer.APIURL != "" && !validURL(network.Explorer.APIURL, "http", "https") {
+			v.addf(path+".explorer.api_url", "需要 http(s) 地址，当前为 %q", network.Explorer.APIURL)
+		}
+	}
+	c.validateTokenSafety(v)
+
+	if enabled == 0 {
+		return
+	}
+
+	contracts := c.Blockchain.Contracts
+	wallets := make(map[string]bool)
+	if contracts.WalletPrivateKey != "" {
+		wallets["default"] = true
+		if !validPrivateKey(contracts.WalletPrivateKey) {
+			v.addf("blockchain.contracts.wallet_private_key", "不是有效的十六进制私钥（64位十六进制，可带 0x 前缀）")
+		}
+	}
+	for i, wallet := range contracts.Wallets {
+		if wallet.Name == "" {
+			v.addf(fmt.Sprintf("blockchain.contracts.wallets[%d].name", i), "不能为空")
+			continue
+		}
+		path := fmt.Sprintf("blockchain.contracts.wallets[%s]", wallet.Name)
+		if wallets[wallet.Name] {
+			v.addf(path, "钱包名称重复")
+		}
+		wallets[wallet.Name] = true
+		c.validateWallet(v, path, wallet, networks)
+	}
+	if len(wallets) == 0 {
+		v.addf("blockchain.contracts", "已启用区块链网络，但未配置 wallet_private_key 或 wallets")
+	}
+	if contracts.DefaultWallet != "" && !wallets[contracts.DefaultWallet] {
+		v.addf("blockchain.contracts.default_wallet", "钱包 %q 不存在", contracts.DefaultWallet)
+	}
+	for _, pair := range c.Trading.Pairs {
+		if pair.Wallet != "" && !wallets[pair.Wallet] {
+			v.addf(fmt.Sprintf("trading.pairs[%s].wallet", pair.Symbol), "钱包 %q 不存在", pair.Wallet)
+		}
+	}
+}
+
+// validateTokenSafety 校验代币安全检查的阈值和地址
+func (c *Config) validateTokenSafety(v *validator) {
+	safety := c.Blockchain.TokenSafety
+	if safety.CacheMinutes < 0 {
+		v.addf("blockchain.token_safety.cache_minutes", "不能为负数")
+	}
+	for name, percent := range map[string]float64{
+		"max_transfer_tax":     safety.MaxTransferTax,
+		"min_liquidity_locked": safety.MinLiquidityLocked,
+		"max_holder_percent":   safety.MaxHolderPercent,
+	} {
+		if percent < 0 || percent > 100 {
+			v.addf("blockchain.token_safety."+name, "应在0到100之间，当前为 %v", percent)
+		}
+	}
+	for _, address := range append(append([]string{}, safety.LockerAddresses...), safety.Allowlist...) {
+		if !common.IsHexAddress(address) {
+			v.addf("blockchain.token_safety", "不是有效的合约地址: %q", address)
+		}
+	}
+	if safety.Enabled && safety.RequireVerified {
+		for _, network := range c.Blockchain.Networks {
+			if network.Enabled && network.Explorer.APIURL == "" {
+				v.addf(fmt.Sprintf("blockchain.networks[%s].explorer.api_url", network.Name), "token_safety.require_verified 需要配置区块浏览器接口")
+			}
+		}
+	}
+}
+
+// validateWallet 校验钱包的私钥来源或外部签名服务
+func (c *Config) validateWallet(v *validator, path string, wallet WalletConfig, networks map[string]bool) {
+	for _, network := range wallet.Networks {
+		if !networks[network] {
+			v.addf(path+".networks", "网络 %q 不存在", network)
+		}
+	}
+
+	if wallet.Signer.Type != "" {
+		if wallet.Signer.Type != "clef" && wallet.Signer.Type != "remote" {
+			v.addf(path+".signer.type", "应为 clef 或 remote，当前为 %q", wallet.Signer.Type)
+		}
+		if wallet.Signer.URL == "" {
+			v.addf(path+".signer.url", "外部签名服务需要配置地址")
+		}
+		if !common.IsHexAddress(wallet.Signer.Address) {
+			v.addf(path+".signer.address", "不是有效的账户地址: %q", wallet.Signer.Address)
+		}
+		return
+	}
+
+	switch {
+	case wallet.PrivateKey != "":
+		if !validPrivateKey(wallet.PrivateKey) {
+			v.addf(path+".private_key", "不是有效的十六进制私钥（64位十六进制，可带 0x 前缀）")
+		}
+	case wallet.PrivateKeyEnv != "", wallet.PrivateKeyFile != "":
+	case wallet.KeystoreFile != "":
+		if wallet.PasswordEnv == "" && wallet.PasswordFile == "" {
+			v.addf(path, "使用 keystore_file 时需要配置 password_env 或 password_file")
+		}
+	default:
+		v.addf(path, "未配置私钥来源，需要 private_key、private_key_env、private_key_file、keystore_file 或 signer 之一")
+	}
+}
+
+func (c *Config) validateTrading(v *validator) {
+	networks := make(map[string]bool)
+	for _, network := range c.Blockchain.Networks {
+		if network.Enabled {
+			networks[network.Name] = true
+		}
+	}
+
+	symbols := make(map[string]bool)
+	enabled := 0
+	for i, pair := range c.Trading.Pairs {
+		if pair.Symbol == "" {
+			v.addf(fmt.Sprintf("trading.pairs[%d].symbol", i), "不能为空")
+			continue
+		}
+		path := fmt.Sprintf("trading.pairs[%s]", pair.Symbol)
+		if symbols[pair.Symbol] {
+			v.addf(path, "交易对重复")
+		}
+		symbols[pair.Symbol] = true
+		if parts := strings.Split(pair.Symbol, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			v.addf(path+".symbol", "格式应为 基础货币/计价货币，如 BTC/USDT")
+		}
+		if !pair.Enabled {
+			continue
+		}
+		enabled++
+
+		if pair.Blockchain != "" && !networks[pair.Blockchain] {
+			v.addf(path+".blockchain", "网络 %q 不存在或未启用", pair.Blockchain)
+		}
+		addresses := []struct{ name, value string }{
+			{"contract_address", pair.ContractAddress},
+			{"token_address", pair.TokenAddress},
+			{"quote_token_address", pair.QuoteTokenAddress},
+			{"price_source.address", pair.PriceSource.Address},
+		}
+		for _, address := range addresses {
+			if address.value != "" && !common.IsHexAddress(address.value) {
+				v.addf(path+"."+address.name, "不是有效的合约地址: %q", address.value)
+			}
+		}
+		if pair.TickSize < 0 || pair.StepSize < 0 || pair.MinNotional < 0 {
+			v.addf(path, "tick_size、step_size、min_notional 不能为负数")
+		}
+		switch pair.Market {
+		case "", MarketSpot:
+		case MarketPerpetual:
+			if pair.Blockchain != "" {
+				v.addf(path+".market", "永续合约只能在交易所交易，不能配置 blockchain")
+			}
+		default:
+			v.addf(path+".market", "未知的市场类型 %q，可选 spot、perpetual", pair.Market)
+		}
+		if pair.Leverage != 0 && pair.Leverage < 1 {
+			v.addf(path+".leverage", "应为0或不小于1，当前为 %v", pair.Leverage)
+		} else if maxLeverage := c.Risk.Margin.MaxLeverage; maxLeverage > 0 && pair.Leverage > maxLeverage {
+			v.addf(path+".leverage", "超过 risk.margin.max_leverage (%v)", maxLeverage)
+		}
+	}
+	if enabled == 0 {
+		v.addf("trading.pairs", "没有启用的交易对")
+	}
+}
+
+func (c *Config) validateStrategy(v *validator, strategies []string) {
+	known := make(map[string]bool, len(strategies))
+	for _, name := range strategies {
+		known[name] = true
+	}
+	checkKind := func(path, kind string) {
+		if kind == "" {
+			v.addf(path, "不能为空")
+		} else if len(known) > 0 && !known[kind] {
+			v.addf(path, "未知的策略 %q，可选 %s", kind, strings.Join(strategies, ", "))
+		}
+	}
+
+	if len(c.Strategy.Instances) == 0 {
+		checkKind("strategy.name", c.Strategy.Name)
+	}
+	names := make(map[string]bool)
+	for i, instance := range c.Strategy.Instances {
+		path := fmt.Sprintf("strategy.instances[%d]", i)
+		if instance.Name == "" {
+			v.addf(path+".name", "不能为空")
+		} else {
+			path = fmt.Sprintf("strategy.instances[%s]", instance.Name)
+			if names[instance.Name] {
+				v.addf(path, "实例名称重复")
+			}
+			names[instance.Name] = true
+		}
+		checkKind(path+".type", instance.Type)
+	}
+	for _, pair := range c.Trading.Pairs {
+		if pair.Enabled && pair.Strategy != "" {
+			checkKind(fmt.Sprintf("trading.pairs[%s].strategy", pair.Symbol), pair.Strategy)
+		}
+	}
+
+	switch c.Strategy.ConflictPolicy {
+	case "", "net", "suppress", "confidence":
+	default:
+		v.addf("strategy.conflict_policy", "应为 net、suppress 或 confidence，当前为 %q", c.Strategy.ConflictPolicy)
+	}
+	if c.Strategy.Canary.Enabled && (c.Strategy.Canary.SizeFraction <= 0 || c.Strategy.Canary.SizeFraction > 1) {
+		v.addf("strategy.canary.size_fraction", "应在 0 到 1 之间")
+	}
+	filter := c.Strategy.SignalFilter
+	if filter.CooldownSeconds < 0 || filter.DedupWindowSeconds < 0 {
+		v.addf("strategy.signal_filter", "cooldown_seconds 和 dedup_window_seconds 不能为负数")
+	}
+	for i, override := range filter.Overrides {
+		path := fmt.Sprintf("strategy.signal_filter.overrides[%d]", i)
+		if override.Name == "" {
+			v.addf(path+".name", "不能为空")
+		}
+		if override.CooldownSeconds < 0 || override.DedupWindowSeconds < 0 {
+			v.addf(path, "cooldown_seconds 和 dedup_window_seconds 不能为负数")
+		}
+	}
+}
+
+func (c *Config) validateRisk(v *validator) {
+	risk := c.Risk
+	if risk.MaxPositionSize <= 0 || risk.MaxPositionSize > 1 {
+		v.addf("risk.max_position_size", "应为 0 到 1 之间的正数，当前为 %v", risk.MaxPositionSize)
+	}
+	if risk.StopLoss <= 0 || risk.StopLoss >= 1 {
+		v.addf("risk.stop_loss", "应为 0 到 1 之间的正数，当前为 %v", risk.StopLoss)
+	}
+	if risk.TakeProfit <= 0 {
+		v.addf("risk.take_profit", "必须为正数，当前为 %v", risk.TakeProfit)
+	}
+	if risk.MaxOpenPositions <= 0 {
+		v.addf("risk.max_open_positions", "必须为正数，当前为 %d", risk.MaxOpenPositions)
+	}
+	if risk.SlippageTolerance < 0 || risk.SlippageTolerance > 100 {
+		v.addf("risk.slippage_tolerance", "应为 0 到 100 之间的百分比，当前为 %v", risk.SlippageTolerance)
+	}
+	switch risk.SlippageAction {
+	case "", "reject", "requote":
+	default:
+		v.addf("risk.slippage_action", "未知的处理方式 %q，可选 reject、requote", risk.SlippageAction)
+	}
+	if risk.MaxGasPrice != "" && !gasPricePattern.MatchString(strings.ToLower(risk.MaxGasPrice)) {
+		v.addf("risk.max_gas_price", "应为如 \"100gwei\" 的价格，当前为 %q", risk.MaxGasPrice)
+	}
+	if risk.GasQueue.RetryIntervalSeconds < 0 || risk.GasQueue.MaxWaitSeconds < 0 {
+		v.addf("risk.gas_queue", "retry_interval_seconds、max_wait_seconds 不能为负数")
+	}
+	impact := risk.PriceImpact
+	if impact.Enabled && (impact.MaxImpact <= 0 || impact.MaxImpact >= 100) {
+		v.addf("risk.price_impact.max_impact", "应在0到100之间（不含），当前为 %v", impact.MaxImpact)
+	}
+	switch impact.Action {
+	case "", "reject", "split":
+	default:
+		v.addf("risk.price_impact.action", "应为 reject 或 split，当前为 %q", impact.Action)
+	}
+	if impact.MaxSplits < 0 || impact.SplitIntervalSeconds < 0 || impact.MinLiquidity < 0 {
+		v.addf("risk.price_impact", "max_splits、split_interval_seconds、min_liquidity 不能为负数")
+	}
+	if risk.RiskCapital < 0 {
+		v.addf("risk.risk_capital", "不能为负数")
+	}
+	margin := risk.Margin
+	if (margin.DefaultLeverage != 0 && margin.DefaultLeverage < 1) || (margin.MaxLeverage != 0 && margin.MaxLeverage < 1) {
+		v.addf("risk.margin", "default_leverage、max_leverage 应为0或不小于1")
+	} else if margin.MaxLeverage > 0 && margin.DefaultLeverage > margin.MaxLeverage {
+		v.addf("risk.margin.default_leverage", "超过 max_leverage (%v)", margin.MaxLeverage)
+	}
+	if margin.MaintenanceMarginRate < 0 || margin.MaintenanceMarginRate >= 1 {
+		v.addf("risk.margin.maintenance_margin_rate", "应在 0 到 1 之间，当前为 %v", margin.MaintenanceMarginRate)
+	}
+	if margin.LiquidationBuffer < 0 || margin.LiquidationBuffer >= 1 {
+		v.addf("risk.margin.liquidation_buffer", "应在 0 到 1 之间，当前为 %v", margin.LiquidationBuffer)
+	}
+
+	for _, account := range c.Accounts {
+		path := fmt.Sprintf("accounts[%s]", account.ID)
+		if account.ID == "" {
+			v.addf("accounts", "账户ID不能为空")
+		}
+		if account.MaxPositionSize < 0 || account.MaxPositionSize > 1 {
+			v.addf(path+".max_position_size", "应在 0 到 1 之间，0 表示使用全局配置")
+		}
+		if account.MaxOpenPositions < 0 {
+			v.addf(path+".max_open_positions", "不能为负数，0 表示使用全局配置")
+		}
+	}
+}
+
+func (c *Config) validateNotify(v *validator) {
+	if !c.Notify.Enabled {
+		return
+	}
+
+	channels := make(map[string]bool)
+	for i, channel := range c.Notify.Channels {
+		path := fmt.Sprintf("notify.channels[%d]", i)
+		if channel.Name == "" {
+			v.addf(path+".name", "不能为空")
+		} else if channels[channel.Name] {
+			v.addf(path+".name", "重复的渠道名称 %q", channel.Name)
+		}
+		channels[channel.Name] = true
+
+		switch channel.Type {
+		case "telegram":
+			if channel.BotToken == "" || channel.ChatID == "" {
+				v.addf(path, "telegram 渠道需要 bot_token 和 chat_id")
+			}
+		case "discord":
+			if !validURL(channel.WebhookURL, "http", "https") {
+				v.addf(path+".webhook_url", "需要 http(s) 地址，当前为 %q", channel.WebhookURL)
+			}
+		case "email":
+			if channel.SMTPHost == "" || channel.From == "" || len(channel.To) == 0 {
+				v.addf(path, "email 渠道需要 smtp_host、from 和 to")
+			}
+			if channel.SMTPPort < 0 || channel.SMTPPort > 65535 {
+				v.addf(path+".smtp_port", "不是有效的端口: %d", channel.SMTPPort)
+			}
+		case "webhook":
+			if !validURL(channel.WebhookURL, "http", "https") {
+				v.addf(path+".webhook_url", "需要 http(s) 地址，当前为 %q", channel.WebhookURL)
+			}
+		default:
+			v.addf(path+".type", "未知的通知渠道类型 %q，可选 telegram、discord、email、webhook", channel.Type)
+		}
+	}
+
+	for i, route := range c.Notify.Routes {
+		path := fmt.Sprintf("notify.routes[%d]", i)
+		known := route.Event == "*"
+		for _, event := range NotifyEvents {
+			if route.Event == event {
+				known = true
+			}
+		}
+		if !known {
+			v.addf(path+".event", "未知的事件类型 %q，可选 %s 或 *", route.Event, strings.Join(NotifyEvents, "、"))
+		}
+		for j, name := range route.Channels {
+			if !channels[name] {
+				v.addf(fmt.Sprintf("%s.channels[%d]", path, j), "未配置的通知渠道 %q", name)
+			}
+		}
+	}
+}
+
+func (c *Config) validateNews(v *validator) {
+	if !c.News.Enabled {
+		return
+	}
+	if c.News.IntervalMinutes < 0 {
+		v.addf("news.interval_minutes", "不能为负数")
+	}
+	if c.News.MaxArticles < 0 {
+		v.addf("news.max_articles", "不能为负数")
+	}
+	if c.News.MaxAgeHours < 0 {
+		v.addf("news.max_age_hours", "不能为负数")
+	}
+	if len(c.News.Sources) == 0 {
+		v.addf("news.sources", "已启用新闻源但未配置任何来源")
+	}
+
+	sources := make(map[string]bool)
+	for i, source := range c.News.Sources {
+		path := fmt.Sprintf("news.sources[%d]", i)
+		if source.Name == "" {
+			v.addf(path+".name", "不能为空")
+		} else if sources[source.Name] {
+			v.addf(path+".name", "重复的新闻源名称 %q", source.Name)
+		}
+		sources[source.Name] = true
+
+		switch source.Type {
+		case "rss":
+			if !validURL(source.URL, "http", "https") {
+				v.addf(path+".url", "需要 http(s) 地址，当前为 %q", source.URL)
+			}
+		case "cryptopanic", "newsapi":
+			if source.APIKey == "" {
+				v.addf(path+".api_key", "%s 新闻源需要 api_key", source.Type)
+			}
+			if source.URL != "" && !validURL(source.URL, "http", "https") {
+				v.addf(path+".url", "需要 http(s) 地址，当前为 %q", source.URL)
+			}
+		default:
+			v.addf(path+".type", "未知的新闻源类型 %q，可选 %s", source.Type, strings.Join(NewsSourceTypes, "、"))
+		}
+	}
+}
+
+func (c *Config) validateSentiment(v *validator) {
+	if c.Sentiment.Enabled {
+		if !c.News.Enabled {
+			v.addf("sentiment.enabled", "情绪评分需要启用 news")
+		}
+		if !c.LLM.Enabled {
+			v.addf("sentiment.enabled", "情绪评分需要启用 llm")
+		}
+		if c.Sentiment.IntervalMinutes < 0 {
+			v.addf("sentiment.interval_minutes", "不能为负数")
+		}
+		if c.Sentiment.MaxArticles < 0 {
+			v.addf("sentiment.max_articles", "不能为负数")
+		}
+		if c.Sentiment.WindowHours < 0 {
+			v.addf("sentiment.window_hours", "不能为负数")
+		}
+		if c.Sentiment.HistorySize < 0 {
+			v.addf("sentiment.history_size", "不能为负数")
+		}
+	}
+
+	filter := c.Risk.SentimentFilter
+	if filter.Enabled {
+		if !c.Sentiment.Enabled {
+			v.addf("risk.sentiment_filter.enabled", "情绪过滤需要启用 sentiment")
+		}
+		if filter.MinScore < -1 || filter.MinScore > 1 {
+			v.addf("risk.sentiment_filter.min_score", "应在 -1 到 1 之间，当前为 %v", filter.MinScore)
+		}
+	}
+}
+
+func (c *Config) validateBacktest(v *validator) {
+	backtest := c.Backtest
+	if backtest.MaxConcurrent < 0 {
+		v.addf("backtest.max_concurrent", "不能为负数")
+	}
+	if backtest.MaxBars < 0 {
+		v.addf("backtest.max_bars", "不能为负数")
+	}
+	if backtest.MaxResults < 0 {
+		v.addf("backtest.max_results", "不能为负数")
+	}
+	if backtest.InitialCapital < 0 {
+		v.addf("backtest.initial_capital", "不能为负数")
+	}
+	if backtest.SlippageBps < 0 {
+		v.addf("backtest.slippage_bps", "不能为负数")
+	}
+	if backtest.OptimizeWorkers < 0 {
+		v.addf("backtest.optimize_workers", "不能为负数")
+	}
+	if backtest.MaxCandidates < 0 {
+		v.addf("backtest.max_candidates", "不能为负数")
+	}
+}
+
+func (c *Config) validateFees(v *validator) {
+	fees := c.Fees
+	if fees.MakerRate < 0 || fees.MakerRate >= 1 {
+		v.addf("fees.maker_rate", "应在 0 到 1 之间，当前为 %v", fees.MakerRate)
+	}
+	if fees.TakerRate < 0 || fees.TakerRate >= 1 {
+		v.addf("fees.taker_rate", "应在 0 到 1 之间，当前为 %v", fees.TakerRate)
+	}
+	if fees.DEX.LPFeeRate < 0 || fees.DEX.LPFeeRate >= 1 {
+		v.addf("fees.dex.lp_fee_rate", "应在 0 到 1 之间，当前为 %v", fees.DEX.LPFeeRate)
+	}
+	if fees.DEX.GasCost < 0 {
+		v.addf("fees.dex.gas_cost", "不能为负数")
+	}
+	for network, symbol := range fees.DEX.NativePairs {
+		found := false
+		for _, pair := range c.Trading.Pairs {
+			if pair.Symbol == symbol && pair.Blockchain == network {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addf("fees.dex.native_pairs."+network, "交易对 %q 未配置或不在该网络上", symbol)
+		}
+	}
+}
+
+func (c *Config) validateSystem(v *validator) {
+	if c.System.DAppPort < 0 || c.System.DAppPort > 65535 {
+		v.addf("system.dapp_port", "不是有效的端口: %d", c.System.DAppPort)
+	}
+	checkRole := func(path, role string) {
+		switch role {
+		case "viewer", "trader", "admin":
+		default:
+			v.addf(path, "应为 viewer、trader 或 admin，当前为 %q", role)
+		}
+	}
+	for i, key := range c.System.Auth.APIKeys {
+		checkRole(fmt.Sprintf("system.auth.api_keys[%d].role", i), key.Role)
+	}
+	if siwe := c.System.Auth.SIWE; siwe.Enabled {
+		if strings.TrimSpace(siwe.Domain) == "" {
+			v.addf("system.auth.siwe.domain", "启用钱包签名登录时不能为空")
+		}
+		if siwe.DefaultRole != "" {
+			checkRole("system.auth.siwe.default_role", siwe.DefaultRole)
+		}
+		for i, wallet := range siwe.Wallets {
+			path := fmt.Sprintf("system.auth.siwe.wallets[%d]", i)
+			if !common.IsHexAddress(wallet.Address) {
+				v.addf(path+".address", "不是有效的钱包地址: %q", wallet.Address)
+			}
+			checkRole(path+".role", wallet.Role)
+		}
+	}
+	if webhook := c.System.Webhook; webhook.Enabled {
+		if webhook.Secret == "" {
+			v.addf("system.webhook.secret", "启用 Webhook 时不能为空")
+		}
+		if webhook.Account != "" && !c.HasAccount(webhook.Account) {
+			v.addf("system.webhook.account", "未配置的账户: %s", webhook.Account)
+		}
+		if webhook.Quantity < 0 {
+			v.addf("system.webhook.quantity", "不能为负数: %v", webhook.Quantity)
+		}
+	}
+}
+
+func (c *Config) validateLLM(v *validator) {
+	if !c.LLM.Enabled {
+		return
+	}
+
+	engines := make(map[string]bool)
+	for i, provider := range c.LLM.Providers {
+		path := fmt.Sprintf("llm.providers[%d]", i)
+		if provider.Name == "" {
+			v.addf(path+".name", "不能为空")
+		} else if engines[provider.Name] {
+			v.addf(path+".name", "重复的引擎名称 %q", provider.Name)
+		}
+		engines[provider.Name] = true
+
+		known := false
+		for _, providerType := range LLMProviderTypes {
+			if provider.Type == providerType {
+				known = true
+			}
+		}
+		if !known {
+			v.addf(path+".type", "未知的引擎类型 %q，可选 %s", provider.Type, strings.Join(LLMProviderTypes, "、"))
+		}
+		if provider.Model == "" {
+			v.addf(path+".model", "不能为空")
+		}
+		if provider.BaseURL != "" && !validURL(provider.BaseURL, "http", "https") {
+			v.addf(path+".base_url", "需要 http(s) 地址，当前为 %q", provider.BaseURL)
+		}
+		if provider.InputPrice < 0 || provider.OutputPrice < 0 {
+			v.addf(path, "input_price 和 output_price 不能为负数")
+		}
+	}
+
+	// 未在 providers 中定义的 deepseek、qwen 使用旧版接口地址
+	switch {
+	case engines[c.LLM.DefaultEngine]:
+	case c.LLM.DefaultEngine == "deepseek":
+		if !validURL(c.LLM.DeepseekAPI, "http", "https") {
+			v.addf("llm.deepseek_api", "使用 deepseek 引擎时需要配置 http(s) 地址")
+		}
+	case c.LLM.DefaultEngine == "qwen":
+		if !validURL(c.LLM.QwenAPI, "http", "https") {
+			v.addf("llm.qwen_api", "使用 qwen 引擎时需要配置 http(s) 地址")
+		}
+	default:
+		v.addf("llm.default_engine", "未配置的引擎 %q，应为 providers 中的名称、deepseek 或 qwen", c.LLM.DefaultEngine)
+	}
+
+	if fallback := c.LLM.FallbackEngine; fallback != "" {
+		legacy := (fallback == "deepseek" && c.LLM.DeepseekAPI != "") || (fallback == "qwen" && c.LLM.QwenAPI != "")
+		if !engines[fallback] && !legacy {
+			v.addf("llm.fallback_engine", "未配置的引擎 %q", fallback)
+		} else if fallback == c.LLM.DefaultEngine {
+			v.addf("llm.fallback_engine", "不能与 default_engine 相同")
+		}
+	}
+	if autoExecute := c.LLM.AutoExecute; autoExecute.Enabled {
+		if autoExecute.MinConfidence < 0 || autoExecute.MinConfidence > 1 {
+			v.addf("llm.auto_execute.min_confidence", "应在0到1之间，当前为 %v", autoExecute.MinConfidence)
+		}
+		if autoExecute.MaxOrders < 0 {
+			v.addf("llm.auto_execute.max_orders", "不能为负数: %d", autoExecute.MaxOrders)
+		}
+		if autoExecute.MaxNotional < 0 {
+			v.addf("llm.auto_execute.max_notional", "不能为负数: %v", autoExecute.MaxNotional)
+		}
+		if autoExecute.ApprovalTTLMinutes < 0 || autoExecute.IntervalMinutes < 0 {
+			v.addf("llm.auto_execute", "approval_ttl_minutes 和 interval_minutes 不能为负数")
+		}
+		if autoExecute.Account != "" && !c.HasAccount(autoExecute.Account) {
+			v.addf("llm.auto_execute.account", "未配置的账户: %s", autoExecute.Account)
+		}
+	}
+	if c.LLM.RetryAttempts < 0 {
+		v.addf("llm.retry_attempts", "不能为负数: %d", c.LLM.RetryAttempts)
+	}
+	if c.LLM.TimeoutSeconds < 0 {
+		v.addf("llm.timeout_seconds", "不能为负数: %d", c.LLM.TimeoutSeconds)
+	}
+	if conversation := c.LLM.Conversation; conversation.TTLMinutes < 0 || conversation.MaxTurns < 0 ||
+		conversation.MaxHistoryTokens < 0 || conversation.MaxSessions < 0 {
+		v.addf("llm.conversation", "ttl_minutes、max_turns、max_history_tokens 和 max_sessions 不能为负数")
+	}
+	if c.LLM.Tools.MaxSteps < 0 {
+		v.addf("llm.tools.max_steps", "不能为负数: %d", c.LLM.Tools.MaxSteps)
+	}
+
+	optimization := c.LLM.StrategyOptimization
+	if optimization.Rounds < 0 {
+		v.addf("llm.strategy_optimization.rounds", "不能为负数: %d", optimization.Rounds)
+	}
+	if optimization.Proposals < 0 {
+		v.addf("llm.strategy_optimization.proposals", "不能为负数: %d", optimization.Proposals)
+	}
+	if optimization.LookbackDays < 0 {
+		v.addf("llm.strategy_optimization.lookback_days", "不能为负数: %d", optimization.LookbackDays)
+	}
+	if optimization.TrainRatio < 0 || optimization.TrainRatio >= 1 {
+		v.addf("llm.strategy_optimization.train_ratio", "应在 0 到 1 之间，当前为 %v", optimization.TrainRatio)
+	}
+	c.validatePrompts(v)
+}
+
+// validatePrompts 检查自定义提示词和预算中的提示词名称，模板语法在加载时检查
+func (c *Config) validatePrompts(v *validator) {
+	known := func(name string) bool {
+		for _, prompt := range LLMPromptNames {
+			if prompt == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	versions := make(map[string]bool)
+	for i, template := range c.LLM.Prompts.Templates {
+		path := fmt.Sprintf("llm.prompts.templates[%d]", i)
+		if !known(template.Prompt) {
+			v.addf(path+".prompt", "未知的提示词 %q，可选 %s", template.Prompt, strings.Join(LLMPromptNames, "、"))
+		}
+		if template.Version == "builtin" {
+			v.addf(path+".version", "不能使用内置模板的版本 builtin")
+		}
+		key := template.Prompt + "@" + template.Version
+		if versions[key] {
+			v.addf(path, "重复的提示词版本 %s", key)
+		}
+		versions[key] = true
+		if strings.TrimSpace(template.Template) == "" {
+			v.addf(path+".template", "不能为空")
+		}
+	}
+	for name := range c.LLM.Prompts.Versions {
+		if !known(name) {
+			v.addf("llm.prompts.versions."+name, "未知的提示词")
+		}
+	}
+
+	if c.LLM.Budget.MonthlyLimit < 0 {
+		v.addf("llm.budget.monthly_limit", "不能为负数: %v", c.LLM.Budget.MonthlyLimit)
+	}
+	for i, name := range c.LLM.Budget.Essential {
+		if !known(name) {
+			v.addf(fmt.Sprintf("llm.budget.essential[%d]", i), "未知的提示词 %q", name)
+		}
+	}
+}
+
+// validURL 判断是否为指定协议的有效地址
+func validURL(value string, schemes ...string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// validPrivateKey 判断是否为有效的十六进制私钥
+func validPrivateKey(value string) bool {
+	_, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(value), "0x"))
+	return err == nil
+}
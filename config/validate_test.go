@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRejectsNonHTTPFailoverEndpoints(t *testing.T) {
+	cfg := &Config{}
+	cfg.Blockchain.Networks = []NetworkConfig{{
+		Name:    "ethereum",
+		Enabled: true,
+		ChainID: 1,
+		RPCURL:  "wss://mainnet.example.com",
+		RPCURLs: []string{"https://backup.example.com", "ws://localhost:8546"},
+	}}
+
+	err := cfg.Validate(nil)
+	if err == nil {
+		t.Fatal("配置了 rpc_urls 时应拒绝 ws(s) 节点地址")
+	}
+	for _, path := range []string{"blockchain.networks[ethereum].rpc_url", "blockchain.networks[ethereum].rpc_urls[1]"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Fatalf("校验错误应包含 %s: %v", path, err)
+		}
+	}
+	if strings.Contains(err.Error(), "rpc_urls[0]") {
+		t.Fatalf("https 备用节点不应报错: %v", err)
+	}
+}
+
+func TestValidateRequiresSIWEDomain(t *testing.T) {
+	cfg := &Config{}
+	cfg.System.Auth.SIWE.Enabled = true
+
+	err := cfg.Validate(nil)
+	if err == nil || !strings.Contains(err.Error(), "system.auth.siwe.domain") {
+		t.Fatalf("启用钱包签名登录时应要求配置域名: %v", err)
+	}
+
+	cfg.System.Auth.SIWE.Domain = "localhost:3000"
+	if err := cfg.Validate(nil); err != nil && strings.Contains(err.Error(), "system.auth.siwe.domain") {
+		t.Fatalf("配置域名后不应报错: %v", err)
+	}
+}
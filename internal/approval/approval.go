@@ -0,0 +1,307 @@
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 审批请求的状态
+const (
+	StatusPending  = "pending"  // 等待人工审批
+	StatusApproved = "approved" // 已批准并交给执行器，订单仍可能被风险检查拒绝
+	StatusRejected = "rejected" // 被人工拒绝
+	StatusExpired  = "expired"  // 超过有效期未审批
+)
+
+// defaultTTL 未配置有效期时待审批信号的有效期
+const defaultTTL = 30 * time.Minute
+
+// retention 已处理的审批请求在队列中保留的时间
+const retention = 24 * time.Hour
+
+var (
+	// ErrNotFound 审批请求不存在或不属于该账户
+	ErrNotFound = errors.New("审批请求不存在")
+	// ErrDecided 审批请求已处理或已过期
+	ErrDecided = errors.New("审批请求已处理")
+)
+
+// requestSeq 审批请求ID的序号
+var requestSeq uint64
+
+// Request 审批队列中的一个信号
+type Request struct {
+	ID        string
+	Account   string
+	Signal    strategy.Signal
+	Notional  decimal.Decimal // 名义价值，价格×数量
+	Reason    string          // 需要审批的原因
+	Status    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	DecidedAt time.Time
+	DecidedBy string // 审批人，即API密钥名称
+	Note      string // 拒绝原因
+}
+
+// Queue 人工审批队列，作为策略管理器的信号处理器位于执行器之前，
+// 需要审批的信号放入队列，其余信号直接交给注册的处理器
+type Queue struct {
+	cfg        config.ApprovalConfig
+	strategies map[string]bool
+	handlers   []strategy.SignalHandler
+	requests   map[string]*Request // 键为请求ID
+	audit      *audit.Log          // 为nil时不记录审计事件
+	events     *events.Bus         // 为nil时不发布审批事件
+	mutex      sync.Mutex
+}
+
+// NewQueue 创建审批队列
+func NewQueue(cfg config.ApprovalConfig) *Queue {
+	strategies := make(map[string]bool)
+	for _, name := range cfg.Strategies {
+		strategies[name] = true
+	}
+	return &Queue{
+		cfg:        cfg,
+		strategies: strategies,
+		requests:   make(map[string]*Request),
+	}
+}
+
+// SetAuditLog 设置审计日志，信号进入队列、批准、拒绝和过期都会记录审计事件
+func (q *Queue) SetAuditLog(log *audit.Log) {
+	q.audit = log
+}
+
+// SetEventBus 设置事件总线，信号进入队列和审批结果会发布到总线
+func (q *Queue) SetEventBus(bus *events.Bus) {
+	q.events = bus
+}
+
+// RegisterSignalHandler 注册无需审批或已批准的信号的处理器，即交易执行器
+func (q *Queue) RegisterSignalHandler(handler strategy.SignalHandler) {
+	q.handlers = append(q.handlers, handler)
+}
+
+// HandleSignal 实现 strategy.SignalHandler 接口，需要审批的信号放入队列，其余信号直接交给执行器
+func (q *Queue) HandleSignal(signal strategy.Signal) {
+	if _, queued := q.Submit(signal); queued {
+		return
+	}
+	q.dispatch(signal)
+}
+
+// Submit 信号需要审批时放入队列并返回审批请求，不需要审批时返回 false，队列为nil时不审批
+// 相同账户和幂等键的信号已在队列中时返回已有的请求，不重复排队
+func (q *Queue) Submit(signal strategy.Signal) (Request, bool) {
+	if q == nil {
+		return Request{}, false
+	}
+	notional := signal.Price.Mul(signal.Quantity)
+	reason := q.requires(signal, notional)
+	if reason == "" {
+		return Request{}, false
+	}
+	account := signal.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	ttl := time.Duration(q.cfg.TTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	now := time.Now()
+
+	q.mutex.Lock()
+	for id, existing := range q.requests {
+		q.expireLocked(existing)
+		if existing.Status != StatusPending && now.Sub(existing.CreatedAt) > retention {
+			delete(q.requests, id)
+			continue
+		}
+		if signal.ClientOrderID != "" && existing.Signal.ClientOrderID == signal.ClientOrderID &&
+			existing.Account == account {
+			snapshot := *existing
+			q.mutex.Unlock()
+			logrus.Infof("幂等键 %s 已有审批请求 %s，不重复排队", signal.ClientOrderID, existing.ID)
+			return snapshot, true
+		}
+	}
+	request := &Request{
+		ID:        fmt.Sprintf("APPROVAL-%d-%d", now.UnixNano(), atomic.AddUint64(&requestSeq, 1)),
+		Account:   account,
+		Signal:    signal,
+		Notional:  notional,
+		Reason:    reason,
+		Status:    StatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	q.requests[request.ID] = request
+	snapshot := *request
+	q.mutex.Unlock()
+
+	logrus.Infof("信号 %s %s 需要人工审批 (%s)，审批请求: %s", signal.Symbol, signal.Direction, reason, request.ID)
+	q.record(snapshot, request.ID)
+	q.publish(snapshot)
+	return snapshot, true
+}
+
+// Requires 返回信号需要审批的原因，不需要审批或队列为nil时返回空字符串，不放入队列
+func (q *Queue) Requires(signal strategy.Signal) string {
+	if q == nil {
+		return ""
+	}
+	return q.requires(signal, signal.Price.Mul(signal.Quantity))
+}
+
+// requires 返回信号需要审批的原因，不需要审批时返回空字符串
+func (q *Queue) requires(signal strategy.Signal, notional decimal.Decimal) string {
+	name := signal.StrategyName
+	if index := strings.Index(name, ":"); index >= 0 {
+		// 外部 Webhook 信号的策略名为 webhook:告警名称，按前缀匹配
+		name = name[:index]
+	}
+	if q.strategies[signal.StrategyName] || q.strategies[name] {
+		return fmt.Sprintf("策略 %s 的信号需要审批", signal.StrategyName)
+	}
+	threshold := decimal.NewFromFloat(q.cfg.NotionalThreshold)
+	if threshold.IsPositive() && notional.GreaterThan(threshold) {
+		return fmt.Sprintf("名义价值 %s 超过审批阈值 %s", notional.StringFixed(2), threshold.String())
+	}
+	return ""
+}
+
+// List 返回账户的审批请求，最新的在前，status 为空时返回所有状态
+func (q *Queue) List(account, status string) []Request {
+	q.mutex.Lock()
+	list := make([]Request, 0, len(q.requests))
+	for _, request := range q.requests {
+		q.expireLocked(request)
+		if request.Account == account && (status == "" || request.Status == status) {
+			list = append(list, *request)
+		}
+	}
+	q.mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Approve 批准待审批的信号并交给执行器，decidedBy 为审批人
+func (q *Queue) Approve(id, account, decidedBy string) (Request, error) {
+	request, err := q.decide(id, account, decidedBy, StatusApproved, "")
+	if err != nil {
+		return Request{}, err
+	}
+
+	logrus.Infof("审批请求 %s 已批准: %s %s", id, request.Signal.Symbol, request.Signal.Direction)
+	q.dispatch(request.Signal)
+	return request, nil
+}
+
+// Reject 拒绝待审批的信号，note 为拒绝原因
+func (q *Queue) Reject(id, account, decidedBy, note string) (Request, error) {
+	request, err := q.decide(id, account, decidedBy, StatusRejected, note)
+	if err != nil {
+		return Request{}, err
+	}
+
+	logrus.Infof("审批请求 %s 已拒绝: %s %s", id, request.Signal.Symbol, request.Signal.Direction)
+	return request, nil
+}
+
+// decide 记录对待审批信号的决定，请求不存在、不属于该账户、已处理或已过期时返回错误
+func (q *Queue) decide(id, account, decidedBy, status, note string) (Request, error) {
+	q.mutex.Lock()
+	request, ok := q.requests[id]
+	if !ok || request.Account != account {
+		q.mutex.Unlock()
+		return Request{}, ErrNotFound
+	}
+	q.expireLocked(request)
+	if request.Status != StatusPending {
+		status := request.Status
+		q.mutex.Unlock()
+		return Request{}, fmt.Errorf("%w，当前状态: %s", ErrDecided, status)
+	}
+
+	request.Status = status
+	request.DecidedAt = time.Now()
+	request.DecidedBy = decidedBy
+	request.Note = note
+	snapshot := *request
+	q.mutex.Unlock()
+
+	reason := id
+	if decidedBy != "" {
+		reason += "，审批人: " + decidedBy
+	}
+	if note != "" {
+		reason += "，原因: " + note
+	}
+	q.record(snapshot, reason)
+	q.publish(snapshot)
+	return snapshot, nil
+}
+
+// dispatch 将信号交给注册的处理器
+func (q *Queue) dispatch(signal strategy.Signal) {
+	for _, handler := range q.handlers {
+		handler.HandleSignal(signal)
+	}
+}
+
+// expireLocked 将超过有效期的待审批请求标记为过期，调用方需持有 mutex
+func (q *Queue) expireLocked(request *Request) {
+	if request.Status == StatusPending && time.Now().After(request.ExpiresAt) {
+		request.Status = StatusExpired
+		q.record(*request, request.ID)
+		q.publish(*request)
+	}
+}
+
+// record 记录审批请求的审计事件，reason 为请求ID及审批人
+func (q *Queue) record(request Request, reason string) {
+	signal := request.Signal
+	q.audit.Record(audit.Event{
+		Type:      audit.EventApproval,
+		Account:   request.Account,
+		Symbol:    signal.Symbol,
+		Direction: signal.Direction,
+		Strategy:  signal.StrategyName,
+		SignalID:  signal.ID,
+		Price:     signal.Price,
+		Quantity:  signal.Quantity,
+		Status:    request.Status,
+		Reason:    reason,
+		Detail:    request.Reason,
+	})
+}
+
+// publish 发布审批事件
+func (q *Queue) publish(request Request) {
+	q.events.Publish(events.Event{
+		Type:    events.EventApproval,
+		Account: request.Account,
+		Symbol:  request.Signal.Symbol,
+		Payload: request,
+	})
+}
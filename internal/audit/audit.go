@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 事件类型
+const (
+	EventSignal         = "signal"          // 策略产生交易信号
+	EventRiskApproved   = "risk_approved"   // 信号通过风险检查
+	EventRiskRejected   = "risk_rejected"   // 信号被风险检查拒绝
+	EventOrderSubmitted = "order_submitted" // 订单已提交
+	EventOrderFilled    = "order_filled"    // 订单成交（含部分成交）
+	EventOrderCanceled  = "order_canceled"  // 订单撤销（含超时撤销）
+	EventOrderFailed    = "order_failed"    // 订单下单或执行失败
+	EventOrderReorged   = "order_reorged"   // 已确认的链上订单因区块重组回到待确认
+	EventConfigChanged  = "config_changed"  // 配置文件热加载产生的变更
+	EventLLMSuggestion  = "llm_suggestion"  // LLM交易建议的执行、审批或拒绝
+	EventApproval       = "approval"        // 信号进入人工审批队列、被批准、拒绝或过期
+	EventTradingHalt    = "trading_halt"    // 紧急停止或恢复交易
+)
+
+// maxLineSize 读取审计日志时单行的最大长度
+const maxLineSize = 1024 * 1024
+
+// Event 审计事件
+type Event struct {
+	Seq       int64           `json:"seq"`
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Account   string          `json:"account,omitempty"`
+	Symbol    string          `json:"symbol,omitempty"`
+	Direction string          `json:"direction,omitempty"`
+	Strategy  string          `json:"strategy,omitempty"`
+	OrderID   string          `json:"orderId,omitempty"`
+	SignalID  string          `json:"signalId,omitempty"` // 产生订单的信号，可关联信号与订单事件
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Status    string          `json:"status,omitempty"`
+	Reason    string          `json:"reason,omitempty"` // 拒绝、撤销或失败的原因
+	Detail    string          `json:"detail,omitempty"` // 其他信息，如链上交易哈希
+}
+
+// Filter 审计事件查询条件，零值字段不参与过滤
+type Filter struct {
+	Type     string
+	Account  string
+	Symbol   string
+	OrderID  string
+	SignalID string
+	Since    time.Time
+	Until    time.Time
+	Offset   int
+	Limit    int
+}
+
+// matches 判断事件是否满足查询条件
+func (f Filter) matches(event Event) bool {
+	if f.Type != "" && event.Type != f.Type {
+		return false
+	}
+	if f.Account != "" && event.Account != f.Account {
+		return false
+	}
+	if f.Symbol != "" && event.Symbol != f.Symbol {
+		return false
+	}
+	if f.OrderID != "" && event.OrderID != f.OrderID {
+		return false
+	}
+	if f.SignalID != "" && event.SignalID != f.SignalID {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Log 只追加的审计日志，每个事件一行JSON，启动时加载已有事件供查询
+// 方法对nil接收者安全，未启用审计时各模块无需判断
+type Log struct {
+	path    string
+	file    *os.File
+	events  []Event
+	nextSeq int64
+	mutex   sync.RWMutex
+}
+
+// NewLog 打开审计日志文件并加载已有事件
+func NewLog(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %v", err)
+	}
+
+	l := &Log{path: path, events: make([]Event, 0), nextSeq: 1}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// load 读取已有的审计事件
+func (l *Log) load() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// 进程异常退出时最后一行可能不完整
+			logrus.Warnf("跳过无法解析的审计日志记录: %v", err)
+			continue
+		}
+		l.events = append(l.events, event)
+		if event.Seq >= l.nextSeq {
+			l.nextSeq = event.Seq + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	return nil
+}
+
+// Record 追加一条审计事件，写入失败只记录日志不影响交易流程
+func (l *Log) Record(event Event) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	event.Seq = l.nextSeq
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	content, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("序列化审计事件失败: %v", err)
+		return
+	}
+	if _, err := l.file.Write(append(content, '\n')); err != nil {
+		logrus.Errorf("写入审计日志失败: %v", err)
+		return
+	}
+
+	l.nextSeq++
+	l.events = append(l.events, event)
+}
+
+// Query 按条件查询审计事件，按时间倒序分页返回，同时返回满足条件的事件总数
+func (l *Log) Query(filter Filter) ([]Event, int) {
+	if l == nil {
+		return []Event{}, 0
+	}
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	result := make([]Event, 0)
+	total := 0
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if !filter.matches(l.events[i]) {
+			continue
+		}
+		if total >= filter.Offset && (filter.Limit <= 0 || len(result) < filter.Limit) {
+			result = append(result, l.events[i])
+		}
+		total++
+	}
+	return result, total
+}
+
+// Close 关闭审计日志文件
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,417 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/store"
+	"autotransaction/internal/strategy"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultMaxConcurrent 未配置时同时运行的回测数量
+	defaultMaxConcurrent = 1
+	// defaultMaxBars 未配置时每个交易对最多使用的K线数量
+	defaultMaxBars = 10000
+	// defaultMaxResults 未配置时保留的回测结果数量
+	defaultMaxResults = 100
+	// defaultInitialCapital 请求和配置都未指定时的初始资金
+	defaultInitialCapital = 10000
+	// defaultPositionSize 请求未指定时每笔买入使用的权益比例
+	defaultPositionSize = 0.1
+	// defaultInterval 策略未声明K线周期且请求未指定时使用的周期
+	defaultInterval = "1h"
+	// maxQueued 等待运行的回测数量上限
+	maxQueued = 20
+)
+
+// 回测的状态
+const (
+	StatusPending   = "pending"   // 等待运行
+	StatusRunning   = "running"   // 运行中
+	StatusCompleted = "completed" // 已完成，结果可用
+	StatusFailed    = "failed"    // 失败，见 Error
+)
+
+// ErrQueueFull 等待运行的回测过多
+var ErrQueueFull = errors.New("等待运行的回测过多，请稍后再试")
+
+// backtestSeq 回测ID的序号
+var backtestSeq uint64
+
+// Request 回测请求
+type Request struct {
+	Strategy       string                 `json:"strategy"` // 策略类型，如 moving_average_crossover
+	Params         map[string]interface{} `json:"params"`
+	Pairs          []string               `json:"pairs"`
+	From           time.Time              `json:"from"`
+	To             time.Time              `json:"to"`
+	Interval       string                 `json:"interval"`       // 策略未声明K线周期时使用，为空时为1h
+	InitialCapital float64                `json:"initialCapital"` // 为0时使用配置的初始资金
+	PositionSize   float64                `json:"positionSize"`   // 每笔买入使用的权益比例，为0时为0.1
+}
+
+// Backtest 一次回测及其结果
+type Backtest struct {
+	ID          string    `json:"id"`
+	Account     string    `json:"account"`
+	Request     Request   `json:"request"`
+	Status      string    `json:"status"`
+	Progress    float64   `json:"progress"` // 0 到 1
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+	Result      *Result   `json:"result,omitempty"` // 完成后可用
+}
+
+// Finished 判断回测是否已结束
+func (b Backtest) Finished() bool {
+	return b.Status == StatusCompleted || b.Status == StatusFailed
+}
+
+// Service 在后台运行回测和参数优化并保存结果
+type Service struct {
+	cfg           *config.Config
+	exchange      market.HistoryProvider
+	blockchain    market.HistoryProvider   // 为nil时不能回测链上交易对
+	backtests     map[string]*Backtest     // 键为回测ID
+	optimizations map[string]*Optimization // 键为参数优化ID
+	store         store.Store              // 为nil时结果不持久化
+	slots         chan struct{}            // 限制同时运行的回测和参数优化数量
+	mutex         sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+// NewService 创建回测服务，exchange 为交易所交易对的历史K线来源
+func NewService(cfg *config.Config, exchange market.HistoryProvider) *Service {
+	maxConcurrent := cfg.Backtest.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultMaxConcurrent
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		cfg:           cfg,
+		exchange:      exchange,
+		backtests:     make(map[string]*Backtest),
+		optimizations: make(map[string]*Optimization),
+		slots:         make(chan struct{}, maxConcurrent),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// SetBlockchainSource 设置链上交易对的历史K线来源
+func (s *Service) SetBlockchainSource(source market.HistoryProvider) {
+	s.blockchain = source
+}
+
+// SetStore 设置持久化存储，回测结果将在重启后保留
+func (s *Service) SetStore(st store.Store) {
+	s.store = st
+}
+
+// Stop 中止正在运行和等待运行的回测
+func (s *Service) Stop() {
+	s.cancel()
+}
+
+// Submit 校验请求并提交回测，回测在后台运行，返回已创建的回测
+func (s *Service) Submit(account string, request Request) (Backtest, error) {
+	if err := s.validate(&request); err != nil {
+		return Backtest{}, err
+	}
+
+	s.mutex.Lock()
+	if s.queuedLocked() >= maxQueued {
+		s.mutex.Unlock()
+		return Backtest{}, ErrQueueFull
+	}
+
+	now := time.Now()
+	backtest := &Backtest{
+		ID:        fmt.Sprintf("BT-%d-%d", now.UnixNano(), atomic.AddUint64(&backtestSeq, 1)),
+		Account:   account,
+		Request:   request,
+		Status:    StatusPending,
+		CreatedAt: now,
+	}
+	s.backtests[backtest.ID] = backtest
+	snapshot := *backtest
+	s.mutex.Unlock()
+
+	s.persist(snapshot)
+	go s.run(backtest.ID)
+	return snapshot, nil
+}
+
+// queuedLocked 返回未结束的回测和参数优化数量，调用方需持有锁
+func (s *Service) queuedLocked() int {
+	queued := 0
+	for _, backtest := range s.backtests {
+		if !backtest.Finished() {
+			queued++
+		}
+	}
+	for _, optimization := range s.optimizations {
+		if !optimization.Finished() {
+			queued++
+		}
+	}
+	return queued
+}
+
+// Get 返回账户的回测
+func (s *Service) Get(account, id string) (Backtest, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	backtest, ok := s.backtests[id]
+	if !ok || backtest.Account != account {
+		return Backtest{}, false
+	}
+	return *backtest, true
+}
+
+// List 返回账户的所有回测，最新提交的在前
+func (s *Service) List(account string) []Backtest {
+	s.mutex.RLock()
+	list := make([]Backtest, 0)
+	for _, backtest := range s.backtests {
+		if backtest.Account == account {
+			list = append(list, *backtest)
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// validate 校验请求并填充默认值
+func (s *Service) validate(request *Request) error {
+	known := false
+	for _, kind := range strategy.RegisteredStrategies() {
+		if kind == request.Strategy {
+			known = true
+		}
+	}
+	if !known {
+		return fmt.Errorf("未知的策略: %q", request.Strategy)
+	}
+
+	if len(request.Pairs) == 0 {
+		return fmt.Errorf("至少需要一个交易对")
+	}
+	for _, symbol := range request.Pairs {
+		pair, ok := s.pairConfig(symbol)
+		if !ok {
+			return fmt.Errorf("未配置的交易对: %s", symbol)
+		}
+		if pair.Blockchain != "" && s.blockchain == nil {
+			return fmt.Errorf("没有链上交易对 %s 的历史K线", symbol)
+		}
+	}
+
+	if request.To.IsZero() {
+		request.To = time.Now()
+	}
+	if request.From.IsZero() || !request.From.Before(request.To) {
+		return fmt.Errorf("开始时间必须早于结束时间")
+	}
+	if request.Interval != "" {
+		if _, err := market.ParseInterval(request.Interval); err != nil {
+			return err
+		}
+	}
+
+	if request.InitialCapital < 0 {
+		return fmt.Errorf("初始资金不能为负数")
+	}
+	if request.InitialCapital == 0 {
+		request.InitialCapital = s.cfg.Backtest.InitialCapital
+		if request.InitialCapital <= 0 {
+			request.InitialCapital = defaultInitialCapital
+		}
+	}
+	if request.PositionSize < 0 || request.PositionSize > 1 {
+		return fmt.Errorf("positionSize 应在 0 到 1 之间")
+	}
+	if request.PositionSize == 0 {
+		request.PositionSize = defaultPositionSize
+	}
+	return nil
+}
+
+// pairConfig 返回交易对的配置
+func (s *Service) pairConfig(symbol string) (config.PairConfig, bool) {
+	for _, pair := range s.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol {
+			return pair, true
+		}
+	}
+	return config.PairConfig{}, false
+}
+
+// run 等待空闲的运行槽位后运行回测，并保存结果
+func (s *Service) run(id string) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-s.ctx.Done():
+		s.finish(id, nil, fmt.Errorf("服务已停止"))
+		return
+	}
+
+	s.mutex.Lock()
+	backtest := s.backtests[id]
+	backtest.Status = StatusRunning
+	backtest.StartedAt = time.Now()
+	request := backtest.Request
+	s.mutex.Unlock()
+
+	logrus.Infof("开始回测 %s: 策略 %s，交易对 %v，%s 至 %s", id, request.Strategy, request.Pairs,
+		request.From.Format(time.RFC3339), request.To.Format(time.RFC3339))
+	result, err := s.safeSimulate(request, newBarCache(s, request.From), func(progress float64) {
+		s.mutex.Lock()
+		s.backtests[id].Progress = progress
+		s.mutex.Unlock()
+	})
+	s.finish(id, result, err)
+}
+
+// safeSimulate 运行回测，策略因参数不当等原因 panic 时返回错误，避免影响整个服务
+func (s *Service) safeSimulate(request Request, cache *barCache, progress func(float64)) (result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("策略运行出错: %v", r)
+		}
+	}()
+	return s.simulate(request, cache, progress)
+}
+
+// finish 记录回测的结果或错误，保存后清理超出保留数量的结果
+func (s *Service) finish(id string, result *Result, err error) {
+	s.mutex.Lock()
+	backtest := s.backtests[id]
+	backtest.CompletedAt = time.Now()
+	if err != nil {
+		backtest.Status = StatusFailed
+		backtest.Error = err.Error()
+		logrus.Warnf("回测 %s 失败: %v", id, err)
+	} else {
+		backtest.Status = StatusCompleted
+		backtest.Progress = 1
+		backtest.Result = result
+		logrus.Infof("回测 %s 完成: 收益率 %.2f%%，最大回撤 %.2f%%，%d 笔成交", id,
+			result.Metrics.TotalReturn, result.Metrics.MaxDrawdown, result.Metrics.Trades)
+	}
+	snapshot := *backtest
+	removed := s.pruneLocked()
+	s.mutex.Unlock()
+
+	s.persist(snapshot)
+	s.remove(removed)
+}
+
+// pruneLocked 删除超出保留数量的最早的已结束回测，返回被删除的回测ID，调用方需持有锁
+func (s *Service) pruneLocked() []string {
+	maxResults := s.cfg.Backtest.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+
+	finished := make([]*Backtest, 0, len(s.backtests))
+	for _, backtest := range s.backtests {
+		if backtest.Finished() {
+			finished = append(finished, backtest)
+		}
+	}
+	if len(finished) <= maxResults {
+		return nil
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+
+	removed := make([]string, 0, len(finished)-maxResults)
+	for _, backtest := range finished[:len(finished)-maxResults] {
+		delete(s.backtests, backtest.ID)
+		removed = append(removed, backtest.ID)
+	}
+	return removed
+}
+
+// persist 保存回测到存储
+func (s *Service) persist(backtest Backtest) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(store.CollectionBacktests, backtest.ID, backtest); err != nil {
+		logrus.Warnf("保存回测 %s 失败: %v", backtest.ID, err)
+	}
+}
+
+// remove 从存储中删除回测
+func (s *Service) remove(ids []string) {
+	if s.store == nil {
+		return
+	}
+	for _, id := range ids {
+		if err := s.store.Delete(store.CollectionBacktests, id); err != nil {
+			logrus.Warnf("删除回测 %s 失败: %v", id, err)
+		}
+	}
+}
+
+// Load 从存储中加载回测和参数优化，重启前未完成的标记为失败
+func (s *Service) Load() error {
+	if s.store == nil {
+		return nil
+	}
+
+	backtests := make([]Backtest, 0)
+	err := s.store.Load(store.CollectionBacktests, func(id string, decode func(v interface{}) error) error {
+		var backtest Backtest
+		if err := decode(&backtest); err != nil {
+			return fmt.Errorf("解析回测 %s 失败: %v", id, err)
+		}
+		backtests = append(backtests, backtest)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	interrupted := make([]Backtest, 0)
+	s.mutex.Lock()
+	for i := range backtests {
+		backtest := backtests[i]
+		if !backtest.Finished() {
+			backtest.Status = StatusFailed
+			backtest.Error = "服务重启时回测尚未完成"
+			interrupted = append(interrupted, backtest)
+		}
+		s.backtests[backtest.ID] = &backtest
+	}
+	s.mutex.Unlock()
+
+	for _, backtest := range interrupted {
+		s.persist(backtest)
+	}
+	logrus.Infof("已加载 %d 个回测", len(backtests))
+	return s.loadOptimizations()
+}
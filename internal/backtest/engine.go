@@ -0,0 +1,412 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/fees"
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// warmupMargin 在回测区间之外多获取的K线数量，供策略初始化时预热指标
+const warmupMargin = 500
+
+// Trade 回测中的一笔模拟成交
+type Trade struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pair      string    `json:"pair"`
+	Direction string    `json:"direction"`
+	Price     float64   `json:"price"` // 含滑点的成交价
+	Quantity  float64   `json:"quantity"`
+	Fee       float64   `json:"fee"`
+	PnL       float64   `json:"pnl"` // 卖出时按持仓均价计算的已实现盈亏，已扣除手续费
+}
+
+// EquityPoint 权益曲线上的一点
+type EquityPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Equity    float64   `json:"equity"`
+}
+
+// Metrics 回测的绩效指标，比例以百分比表示
+type Metrics struct {
+	InitialCapital float64 `json:"initialCapital"`
+	FinalEquity    float64 `json:"finalEquity"`
+	TotalReturn    float64 `json:"totalReturn"`
+	MaxDrawdown    float64 `json:"maxDrawdown"`
+	SharpeRatio    float64 `json:"sharpeRatio"` // 按K线周期年化，无风险利率取0
+	Trades         int     `json:"trades"`
+	ClosedTrades   int     `json:"closedTrades"` // 产生已实现盈亏的卖出成交
+	WinRate        float64 `json:"winRate"`
+	TotalFees      float64 `json:"totalFees"`
+}
+
+// Result 回测结果
+type Result struct {
+	Interval    string        `json:"interval"`
+	Bars        int           `json:"bars"`     // 回测区间内的K线数量
+	DataFrom    time.Time     `json:"dataFrom"` // 实际可用数据的起止时间，历史数据不足时晚于请求的开始时间
+	DataTo      time.Time     `json:"dataTo"`
+	Metrics     Metrics       `json:"metrics"`
+	EquityCurve []EquityPoint `json:"equityCurve"`
+	Trades      []Trade       `json:"trades"`
+}
+
+// simulate 按K线回放历史行情运行策略，以收盘价加减滑点模拟成交
+// 限价、止损等下单方式按市价处理，不支持做空，卖出数量不超过持仓
+// cache 为多次回测共用的K线缓存，需覆盖 request.From
+func (s *Service) simulate(request Request, cache *barCache, progress func(float64)) (*Result, error) {
+	replay := &replaySource{cache: cache, from: request.From}
+
+	pairs := make([]config.PairConfig, 0, len(request.Pairs))
+	for _, symbol := range request.Pairs {
+		pair, _ := s.pairConfig(symbol)
+		pair.Enabled = true
+		pairs = append(pairs, pair)
+	}
+	btCfg := *s.cfg
+	btCfg.Exchange.MockMode = true
+	btCfg.Trading.Pairs = pairs
+	marketData := market.NewMarketDataService(&btCfg)
+	marketData.SetHistory(replay)
+
+	portfolio := newPortfolio(request, &btCfg)
+	instance, err := strategy.New(request.Strategy, "backtest", strategy.Dependencies{
+		Config:     &btCfg,
+		MarketData: marketData,
+		Holdings:   portfolio,
+		Sizer:      portfolio,
+	}, request.Params)
+	if err != nil {
+		return nil, err
+	}
+	if err := instance.Init(); err != nil {
+		return nil, fmt.Errorf("初始化策略失败: %v", err)
+	}
+
+	interval := request.Interval
+	if periodic, ok := instance.(strategy.IntervalStrategy); ok && periodic.Interval() != "" {
+		interval = periodic.Interval()
+	}
+
+	// 多周期策略按最小周期回放，更高周期的K线由最小周期K线聚合得到
+	var timeframes *strategy.TimeframeAggregator
+	multi, ok := instance.(strategy.MultiTimeframeStrategy)
+	if ok && len(multi.Timeframes()) > 0 {
+		var baseDuration time.Duration
+		for _, timeframe := range multi.Timeframes() {
+			duration, err := market.ParseInterval(timeframe)
+			if err != nil {
+				return nil, err
+			}
+			if baseDuration == 0 || duration < baseDuration {
+				interval, baseDuration = timeframe, duration
+			}
+		}
+		if timeframes, err = strategy.NewTimeframeAggregator(multi.Timeframes(), baseDuration); err != nil {
+			return nil, err
+		}
+	}
+	if interval == "" {
+		interval = defaultInterval
+	}
+	duration, err := market.ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	bars := make([]market.MarketData, 0)
+	for _, symbol := range request.Pairs {
+		data, err := cache.fetch(symbol, interval)
+		if err != nil {
+			return nil, err
+		}
+		for _, bar := range data {
+			if !bar.Timestamp.Before(request.From) && !bar.Timestamp.After(request.To) {
+				bars = append(bars, bar)
+			}
+		}
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("回测区间内没有 %s 周期的历史K线", interval)
+	}
+	sort.SliceStable(bars, func(i, j int) bool {
+		return bars[i].Timestamp.Before(bars[j].Timestamp)
+	})
+
+	result := &Result{
+		Interval:    interval,
+		Bars:        len(bars),
+		DataFrom:    bars[0].Timestamp,
+		DataTo:      bars[len(bars)-1].Timestamp,
+		EquityCurve: make([]EquityPoint, 0),
+		Trades:      make([]Trade, 0),
+	}
+	for i, bar := range bars {
+		select {
+		case <-s.ctx.Done():
+			return nil, fmt.Errorf("服务已停止")
+		default:
+		}
+
+		portfolio.mark(bar)
+		signals, err := processBar(instance, timeframes, bar)
+		if err != nil {
+			return nil, fmt.Errorf("策略处理 %s 的K线失败: %v", bar.Symbol, err)
+		}
+		for _, signal := range signals {
+			if trade, ok := portfolio.fill(signal, bar); ok {
+				result.Trades = append(result.Trades, trade)
+			}
+		}
+
+		// 同一时间的K线全部处理后记录权益
+		if i == len(bars)-1 || !bars[i+1].Timestamp.Equal(bar.Timestamp) {
+			result.EquityCurve = append(result.EquityCurve, EquityPoint{Timestamp: bar.Timestamp, Equity: portfolio.equity()})
+			progress(float64(i+1) / float64(len(bars)))
+		}
+	}
+
+	result.Metrics = calculateMetrics(request.InitialCapital, result.EquityCurve, result.Trades, duration)
+	return result, nil
+}
+
+// processBar 将一根K线交给策略处理，多周期策略在聚合出各周期K线后调用 ProcessBars
+func processBar(instance strategy.Strategy, timeframes *strategy.TimeframeAggregator, bar market.MarketData) ([]strategy.Signal, error) {
+	multi, ok := instance.(strategy.MultiTimeframeStrategy)
+	if timeframes == nil || !ok {
+		return instance.Process(bar)
+	}
+
+	var signals []strategy.Signal
+	for _, bars := range timeframes.Add(bar) {
+		barSignals, err := multi.ProcessBars(bars)
+		if err != nil {
+			return signals, err
+		}
+		signals = append(signals, barSignals...)
+	}
+	return signals, nil
+}
+
+// replaySource 回测使用的历史K线来源，策略初始化时只能读取回测开始之前的K线
+type replaySource struct {
+	cache *barCache
+	from  time.Time
+}
+
+// Candles 实现 market.HistoryProvider 接口，返回回测开始之前最近的 limit 根K线
+func (r *replaySource) Candles(symbol, interval string, limit int) ([]market.MarketData, error) {
+	data, err := r.cache.fetch(symbol, interval)
+	if err != nil {
+		return nil, err
+	}
+	end := sort.Search(len(data), func(i int) bool {
+		return !data[i].Timestamp.Before(r.from)
+	})
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	return append([]market.MarketData(nil), data[start:end]...), nil
+}
+
+// barCache 缓存从历史K线来源获取的K线，同一回测或参数优化的多次回测共用
+type barCache struct {
+	service *Service
+	from    time.Time                      // 需要覆盖的最早回测开始时间
+	bars    map[string][]market.MarketData // 键为交易对和K线周期
+	mutex   sync.Mutex
+}
+
+// newBarCache 创建覆盖 from 至今的K线缓存
+func newBarCache(s *Service, from time.Time) *barCache {
+	return &barCache{service: s, from: from, bars: make(map[string][]market.MarketData)}
+}
+
+// fetch 获取覆盖回测开始时间至今及预热所需的K线，按时间升序排列，结果按交易对和周期缓存
+func (c *barCache) fetch(symbol, interval string) ([]market.MarketData, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := symbol + "/" + interval
+	if data, ok := c.bars[key]; ok {
+		return data, nil
+	}
+
+	duration, err := market.ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	maxBars := c.service.cfg.Backtest.MaxBars
+	if maxBars <= 0 {
+		maxBars = defaultMaxBars
+	}
+	limit := int(time.Since(c.from)/duration) + 1 + warmupMargin
+	if limit > maxBars {
+		limit = maxBars
+	}
+
+	source := c.service.exchange
+	if pair, _ := c.service.pairConfig(symbol); pair.Blockchain != "" {
+		source = c.service.blockchain
+	}
+	data, err := source.Candles(symbol, interval, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的历史K线失败: %v", symbol, err)
+	}
+	data = append([]market.MarketData(nil), data...)
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Timestamp.Before(data[j].Timestamp)
+	})
+	c.bars[key] = data
+	return data, nil
+}
+
+// position 回测中一个交易对的持仓
+type position struct {
+	quantity  decimal.Decimal
+	avgPrice  decimal.Decimal
+	lastPrice decimal.Decimal
+}
+
+// portfolio 回测的模拟账户，为策略提供下单数量和持仓
+type portfolio struct {
+	cash         decimal.Decimal
+	positionSize decimal.Decimal
+	fees         *fees.Model
+	slippage     decimal.Decimal // 滑点比例
+	positions    map[string]*position
+	mutex        sync.Mutex
+}
+
+// newPortfolio 按请求的初始资金和配置的手续费模型、滑点创建模拟账户
+// 交易所交易对按吃单费率收取手续费，链上交易对收取流动性池手续费和估计的gas费用
+func newPortfolio(request Request, cfg *config.Config) *portfolio {
+	return &portfolio{
+		cash:         decimal.NewFromFloat(request.InitialCapital),
+		positionSize: decimal.NewFromFloat(request.PositionSize),
+		fees:         fees.NewModel(cfg),
+		slippage:     decimal.NewFromFloat(cfg.Backtest.SlippageBps).Div(decimal.NewFromInt(10000)),
+		positions:    make(map[string]*position),
+	}
+}
+
+// OrderQuantity 实现 strategy.OrderSizer 接口，买入按权益的固定比例，卖出为全部持仓
+func (p *portfolio) OrderQuantity(account, symbol, direction string, price decimal.Decimal) decimal.Decimal {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if direction == "sell" {
+		if pos, ok := p.positions[symbol]; ok {
+			return pos.quantity
+		}
+		return decimal.Zero
+	}
+	if !price.IsPositive() {
+		return decimal.Zero
+	}
+	return p.equityLocked().Mul(p.positionSize).Div(price)
+}
+
+// Holdings 实现 strategy.HoldingsProvider 接口，返回各交易对的持仓数量
+func (p *portfolio) Holdings(account string) map[string]decimal.Decimal {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	holdings := make(map[string]decimal.Decimal, len(p.positions))
+	for symbol, pos := range p.positions {
+		holdings[symbol] = pos.quantity
+	}
+	return holdings
+}
+
+// mark 以K线收盘价更新持仓的市值
+func (p *portfolio) mark(bar market.MarketData) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if pos, ok := p.positions[bar.Symbol]; ok {
+		pos.lastPrice = bar.Close
+	}
+}
+
+// fill 以K线收盘价加减滑点模拟成交，买入受现金限制，卖出受持仓限制，无法成交时 ok 为 false
+func (p *portfolio) fill(signal strategy.Signal, bar market.MarketData) (Trade, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if signal.Symbol != bar.Symbol || !bar.Close.IsPositive() {
+		return Trade{}, false
+	}
+	pos, ok := p.positions[signal.Symbol]
+	if !ok {
+		pos = &position{}
+		p.positions[signal.Symbol] = pos
+	}
+	pos.lastPrice = bar.Close
+
+	quantity := signal.Quantity
+	feeRate, fixedFee := p.fees.PairFee(signal.Symbol, false)
+	trade := Trade{Timestamp: bar.Timestamp, Pair: signal.Symbol, Direction: signal.Direction}
+	switch signal.Direction {
+	case "buy":
+		price := bar.Close.Mul(decimal.NewFromInt(1).Add(p.slippage))
+		affordable := p.cash.Sub(fixedFee).Div(price.Mul(decimal.NewFromInt(1).Add(feeRate)))
+		if quantity.GreaterThan(affordable) {
+			quantity = affordable
+		}
+		if !quantity.IsPositive() {
+			return Trade{}, false
+		}
+		cost := price.Mul(quantity)
+		fee := cost.Mul(feeRate).Add(fixedFee)
+		p.cash = p.cash.Sub(cost).Sub(fee)
+		pos.avgPrice = pos.avgPrice.Mul(pos.quantity).Add(cost).Div(pos.quantity.Add(quantity))
+		pos.quantity = pos.quantity.Add(quantity)
+		trade.Price, trade.Fee = price.InexactFloat64(), fee.InexactFloat64()
+	case "sell":
+		price := bar.Close.Mul(decimal.NewFromInt(1).Sub(p.slippage))
+		if quantity.GreaterThan(pos.quantity) {
+			quantity = pos.quantity
+		}
+		if !quantity.IsPositive() {
+			return Trade{}, false
+		}
+		proceeds := price.Mul(quantity)
+		fee := proceeds.Mul(feeRate).Add(fixedFee)
+		p.cash = p.cash.Add(proceeds).Sub(fee)
+		pnl := price.Sub(pos.avgPrice).Mul(quantity).Sub(fee)
+		pos.quantity = pos.quantity.Sub(quantity)
+		if pos.quantity.IsZero() {
+			pos.avgPrice = decimal.Zero
+		}
+		trade.Price, trade.Fee, trade.PnL = price.InexactFloat64(), fee.InexactFloat64(), pnl.InexactFloat64()
+	default:
+		return Trade{}, false
+	}
+	trade.Quantity = quantity.InexactFloat64()
+	return trade, true
+}
+
+// equity 返回现金与持仓市值之和
+func (p *portfolio) equity() float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.equityLocked().InexactFloat64()
+}
+
+// equityLocked 返回现金与持仓市值之和，调用方需持有锁
+func (p *portfolio) equityLocked() decimal.Decimal {
+	equity := p.cash
+	for _, pos := range p.positions {
+		equity = equity.Add(pos.quantity.Mul(pos.lastPrice))
+	}
+	return equity
+}
@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// minSignificantTrades 训练区间的成交少于该数量时结果缺乏统计意义
+	minSignificantTrades = 6
+	// overfitRatio 测试区间的夏普比率低于训练区间的该比例时提示过拟合
+	overfitRatio = 0.5
+)
+
+// Evaluation 一组参数在训练区间和随后的测试区间上的表现
+type Evaluation struct {
+	Params       map[string]interface{} `json:"params"`
+	Train        Window                 `json:"train"`
+	Test         Window                 `json:"test"`
+	TrainMetrics Metrics                `json:"trainMetrics"`
+	TestMetrics  Metrics                `json:"testMetrics"`
+	Warnings     []string               `json:"warnings"` // 过拟合等提示
+}
+
+// Evaluate 同步回测一组参数：区间的前 trainRatio 部分为训练区间，其余为测试区间，
+// 比较两者的表现给出过拟合提示；运行时占用一个回测槽位
+func (s *Service) Evaluate(request Request, trainRatio float64) (*Evaluation, error) {
+	if err := s.validate(&request); err != nil {
+		return nil, err
+	}
+	if trainRatio <= 0 || trainRatio >= 1 {
+		trainRatio = defaultTrainRatio
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-s.ctx.Done():
+		return nil, fmt.Errorf("服务已停止")
+	}
+
+	split := request.From.Add(time.Duration(float64(request.To.Sub(request.From)) * trainRatio))
+	evaluation := &Evaluation{
+		Params:   request.Params,
+		Train:    Window{From: request.From, To: split},
+		Test:     Window{From: split, To: request.To},
+		Warnings: make([]string, 0),
+	}
+	cache := newBarCache(s, request.From)
+
+	trainRequest := request
+	trainRequest.To = split
+	train, err := s.safeSimulate(trainRequest, cache, func(float64) {})
+	if err != nil {
+		return nil, fmt.Errorf("训练区间回测失败: %v", err)
+	}
+	testRequest := request
+	testRequest.From = split
+	test, err := s.safeSimulate(testRequest, cache, func(float64) {})
+	if err != nil {
+		return nil, fmt.Errorf("测试区间回测失败: %v", err)
+	}
+	evaluation.TrainMetrics, evaluation.TestMetrics = train.Metrics, test.Metrics
+	evaluation.Warnings = overfitWarnings(train.Metrics, test.Metrics)
+	return evaluation, nil
+}
+
+// overfitWarnings 比较训练区间和测试区间的表现，返回过拟合提示
+func overfitWarnings(train, test Metrics) []string {
+	warnings := make([]string, 0)
+	if train.Trades < minSignificantTrades {
+		warnings = append(warnings, fmt.Sprintf("训练区间只有 %d 笔成交，结果缺乏统计意义", train.Trades))
+	}
+	if train.SharpeRatio > 0 && test.SharpeRatio < train.SharpeRatio*overfitRatio {
+		warnings = append(warnings, fmt.Sprintf("测试区间夏普比率 %.2f 远低于训练区间的 %.2f，参数可能过拟合", test.SharpeRatio, train.SharpeRatio))
+	}
+	if train.TotalReturn > 0 && test.TotalReturn < 0 {
+		warnings = append(warnings, fmt.Sprintf("训练区间盈利 %.2f%% 而测试区间亏损 %.2f%%", train.TotalReturn, -test.TotalReturn))
+	}
+	if test.Trades == 0 {
+		warnings = append(warnings, "测试区间没有成交，无法验证参数")
+	}
+	return warnings
+}
@@ -0,0 +1,71 @@
+package backtest
+
+import (
+	"math"
+	"time"
+)
+
+// calculateMetrics 由权益曲线和成交计算绩效指标
+func calculateMetrics(initialCapital float64, curve []EquityPoint, trades []Trade, interval time.Duration) Metrics {
+	metrics := Metrics{InitialCapital: initialCapital, FinalEquity: initialCapital, Trades: len(trades)}
+	if len(curve) > 0 {
+		metrics.FinalEquity = curve[len(curve)-1].Equity
+	}
+	if initialCapital > 0 {
+		metrics.TotalReturn = (metrics.FinalEquity - initialCapital) / initialCapital * 100
+	}
+
+	peak := initialCapital
+	for _, point := range curve {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			drawdown := (peak - point.Equity) / peak * 100
+			if drawdown > metrics.MaxDrawdown {
+				metrics.MaxDrawdown = drawdown
+			}
+		}
+	}
+
+	// 按每根K线的收益率计算夏普比率，再按一年的K线数量年化
+	returns := make([]float64, 0, len(curve))
+	previous := initialCapital
+	for _, point := range curve {
+		if previous > 0 {
+			returns = append(returns, point.Equity/previous-1)
+		}
+		previous = point.Equity
+	}
+	if len(returns) > 1 && interval > 0 {
+		mean := 0.0
+		for _, r := range returns {
+			mean += r
+		}
+		mean /= float64(len(returns))
+		variance := 0.0
+		for _, r := range returns {
+			variance += (r - mean) * (r - mean)
+		}
+		stddev := math.Sqrt(variance / float64(len(returns)-1))
+		if stddev > 0 {
+			periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+			metrics.SharpeRatio = mean / stddev * math.Sqrt(periodsPerYear)
+		}
+	}
+
+	wins := 0
+	for _, trade := range trades {
+		metrics.TotalFees += trade.Fee
+		if trade.Direction == "sell" {
+			metrics.ClosedTrades++
+			if trade.PnL > 0 {
+				wins++
+			}
+		}
+	}
+	if metrics.ClosedTrades > 0 {
+		metrics.WinRate = float64(wins) / float64(metrics.ClosedTrades) * 100
+	}
+	return metrics
+}
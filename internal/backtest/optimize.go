@@ -0,0 +1,691 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultFolds 请求未指定时的前推窗口数量
+	defaultFolds = 3
+	// maxFolds 前推窗口数量上限
+	maxFolds = 20
+	// defaultTrainRatio 请求未指定时每个窗口中训练区间所占的比例
+	defaultTrainRatio = 0.7
+	// defaultSamples 随机采样时请求未指定的采样数量
+	defaultSamples = 50
+	// defaultMaxCandidates 未配置时一次优化最多评估的参数组合数量
+	defaultMaxCandidates = 200
+	// topCandidates 每个交易对在测试区间验证并报告的参数组合数量
+	topCandidates = 5
+)
+
+// 参数的搜索方式
+const (
+	MethodGrid   = "grid"   // 遍历所有参数组合
+	MethodRandom = "random" // 从参数组合中随机采样
+)
+
+// 优化目标
+const (
+	ObjectiveSharpe = "sharpe" // 夏普比率
+	ObjectiveReturn = "return" // 收益率
+)
+
+// optimizationSeq 优化任务ID的序号
+var optimizationSeq uint64
+
+// OptimizeRequest 参数优化请求
+// 回测区间被均分为 Folds 个前推窗口，每个窗口的前 TrainRatio 部分为训练区间、其余为测试区间，
+// 候选参数在训练区间评分，得分最高的参数在随后的测试区间上验证
+type OptimizeRequest struct {
+	Strategy       string                   `json:"strategy"`
+	Params         map[string]interface{}   `json:"params"`  // 不参与优化的固定参数
+	Grid           map[string][]interface{} `json:"grid"`    // 待优化的参数及其候选值
+	Method         string                   `json:"method"`  // grid 或 random，为空时为 grid
+	Samples        int                      `json:"samples"` // 随机采样的参数组合数量，为0时为50
+	Pairs          []string                 `json:"pairs"`   // 每个交易对分别优化
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	Interval       string                   `json:"interval"`
+	InitialCapital float64                  `json:"initialCapital"`
+	PositionSize   float64                  `json:"positionSize"`
+	Folds          int                      `json:"folds"`      // 前推窗口数量，为0时为3
+	TrainRatio     float64                  `json:"trainRatio"` // 训练区间占窗口的比例，为0时为0.7
+	Objective      string                   `json:"objective"`  // sharpe 或 return，为空时为 sharpe
+}
+
+// Window 一段回测区间
+type Window struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// FoldResult 一个前推窗口的优化结果
+type FoldResult struct {
+	Train       Window                 `json:"train"`
+	Test        Window                 `json:"test"`
+	BestParams  map[string]interface{} `json:"bestParams"` // 训练区间得分最高的参数，所有候选都失败时为nil
+	TrainScore  float64                `json:"trainScore"`
+	TestScore   float64                `json:"testScore"`
+	TestMetrics *Metrics               `json:"testMetrics,omitempty"` // 最优参数在测试区间的绩效，回测失败时为nil
+}
+
+// CandidateResult 一组参数在各前推窗口上的汇总表现
+type CandidateResult struct {
+	Params      map[string]interface{} `json:"params"`
+	TrainScore  float64                `json:"trainScore"`  // 各训练区间得分的平均值
+	TestScore   float64                `json:"testScore"`   // 各测试区间得分的平均值
+	TestReturn  float64                `json:"testReturn"`  // 各测试区间收益率的平均值
+	MaxDrawdown float64                `json:"maxDrawdown"` // 各测试区间最大回撤的最大值
+	Folds       int                    `json:"folds"`       // 测试成功的窗口数量
+}
+
+// PairOptimization 一个交易对的优化结果
+type PairOptimization struct {
+	Pair  string            `json:"pair"`
+	Best  []CandidateResult `json:"best"` // 按测试区间平均得分从高到低
+	Folds []FoldResult      `json:"folds"`
+}
+
+// OptimizationResult 参数优化结果
+type OptimizationResult struct {
+	Candidates int                `json:"candidates"` // 评估的参数组合数量
+	Runs       int                `json:"runs"`       // 运行的回测次数
+	Pairs      []PairOptimization `json:"pairs"`
+}
+
+// Optimization 一次参数优化及其结果
+type Optimization struct {
+	ID          string              `json:"id"`
+	Account     string              `json:"account"`
+	Request     OptimizeRequest     `json:"request"`
+	Status      string              `json:"status"`
+	Progress    float64             `json:"progress"` // 0 到 1
+	Error       string              `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	StartedAt   time.Time           `json:"startedAt,omitempty"`
+	CompletedAt time.Time           `json:"completedAt,omitempty"`
+	Result      *OptimizationResult `json:"result,omitempty"` // 完成后可用
+}
+
+// Finished 判断优化是否已结束
+func (o Optimization) Finished() bool {
+	return o.Status == StatusCompleted || o.Status == StatusFailed
+}
+
+// evalTask 一次候选参数回测
+type evalTask struct {
+	pair      string
+	candidate int
+	window    Window
+}
+
+// evalResult 候选参数回测的结果，回测失败时 ok 为 false
+type evalResult struct {
+	evalTask
+	metrics Metrics
+	score   float64
+	ok      bool
+}
+
+// SubmitOptimization 校验请求并提交参数优化，优化在后台运行
+func (s *Service) SubmitOptimization(account string, request OptimizeRequest) (Optimization, error) {
+	if err := s.validateOptimization(&request); err != nil {
+		return Optimization{}, err
+	}
+	if _, err := s.candidates(request); err != nil {
+		return Optimization{}, err
+	}
+
+	s.mutex.Lock()
+	if s.queuedLocked() >= maxQueued {
+		s.mutex.Unlock()
+		return Optimization{}, ErrQueueFull
+	}
+	now := time.Now()
+	optimization := &Optimization{
+		ID:        fmt.Sprintf("OPT-%d-%d", now.UnixNano(), atomic.AddUint64(&optimizationSeq, 1)),
+		Account:   account,
+		Request:   request,
+		Status:    StatusPending,
+		CreatedAt: now,
+	}
+	s.optimizations[optimization.ID] = optimization
+	snapshot := *optimization
+	s.mutex.Unlock()
+
+	s.persistOptimization(snapshot)
+	go s.runOptimization(optimization.ID)
+	return snapshot, nil
+}
+
+// GetOptimization 返回账户的参数优化
+func (s *Service) GetOptimization(account, id string) (Optimization, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	optimization, ok := s.optimizations[id]
+	if !ok || optimization.Account != account {
+		return Optimization{}, false
+	}
+	return *optimization, true
+}
+
+// ListOptimizations 返回账户的所有参数优化，最新提交的在前
+func (s *Service) ListOptimizations(account string) []Optimization {
+	s.mutex.RLock()
+	list := make([]Optimization, 0)
+	for _, optimization := range s.optimizations {
+		if optimization.Account == account {
+			list = append(list, *optimization)
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// validateOptimization 校验请求并填充默认值
+func (s *Service) validateOptimization(request *OptimizeRequest) error {
+	base := request.baseRequest()
+	if err := s.validate(&base); err != nil {
+		return err
+	}
+	request.To, request.InitialCapital, request.PositionSize = base.To, base.InitialCapital, base.PositionSize
+
+	if len(request.Grid) == 0 {
+		return fmt.Errorf("至少需要一个待优化的参数")
+	}
+	for name, values := range request.Grid {
+		if len(values) == 0 {
+			return fmt.Errorf("参数 %s 没有候选值", name)
+		}
+	}
+	switch request.Method {
+	case "":
+		request.Method = MethodGrid
+	case MethodGrid, MethodRandom:
+	default:
+		return fmt.Errorf("未知的搜索方式: %q", request.Method)
+	}
+	if request.Samples < 0 {
+		return fmt.Errorf("samples 不能为负数")
+	}
+	if request.Samples == 0 {
+		request.Samples = defaultSamples
+	}
+	switch request.Objective {
+	case "":
+		request.Objective = ObjectiveSharpe
+	case ObjectiveSharpe, ObjectiveReturn:
+	default:
+		return fmt.Errorf("未知的优化目标: %q", request.Objective)
+	}
+	if request.Folds < 0 || request.Folds > maxFolds {
+		return fmt.Errorf("folds 应在 1 到 %d 之间", maxFolds)
+	}
+	if request.Folds == 0 {
+		request.Folds = defaultFolds
+	}
+	if request.TrainRatio < 0 || request.TrainRatio >= 1 {
+		return fmt.Errorf("trainRatio 应在 0 到 1 之间")
+	}
+	if request.TrainRatio == 0 {
+		request.TrainRatio = defaultTrainRatio
+	}
+	return nil
+}
+
+// baseRequest 返回优化请求对应的回测请求，参数为固定参数
+func (r OptimizeRequest) baseRequest() Request {
+	return Request{
+		Strategy:       r.Strategy,
+		Params:         r.Params,
+		Pairs:          r.Pairs,
+		From:           r.From,
+		To:             r.To,
+		Interval:       r.Interval,
+		InitialCapital: r.InitialCapital,
+		PositionSize:   r.PositionSize,
+	}
+}
+
+// windows 将回测区间均分为前推窗口，返回各窗口的训练区间和测试区间
+func (r OptimizeRequest) windows() (train, test []Window) {
+	length := r.To.Sub(r.From) / time.Duration(r.Folds)
+	for i := 0; i < r.Folds; i++ {
+		start := r.From.Add(time.Duration(i) * length)
+		split := start.Add(time.Duration(float64(length) * r.TrainRatio))
+		train = append(train, Window{From: start, To: split})
+		test = append(test, Window{From: split, To: start.Add(length)})
+	}
+	return train, test
+}
+
+// candidates 生成候选参数组合，每个组合包含固定参数
+// 遍历方式下组合数量超过上限时返回错误，随机采样时最多采样上限数量的不重复组合
+func (s *Service) candidates(request OptimizeRequest) ([]map[string]interface{}, error) {
+	maxCandidates := s.cfg.Backtest.MaxCandidates
+	if maxCandidates <= 0 {
+		maxCandidates = defaultMaxCandidates
+	}
+
+	names := make([]string, 0, len(request.Grid))
+	total := 1
+	for name, values := range request.Grid {
+		names = append(names, name)
+		if total <= maxCandidates {
+			total *= len(values)
+		}
+	}
+	sort.Strings(names)
+
+	combine := func(pick func(name string) interface{}) map[string]interface{} {
+		params := make(map[string]interface{}, len(request.Params)+len(names))
+		for key, value := range request.Params {
+			params[key] = value
+		}
+		for _, name := range names {
+			params[name] = pick(name)
+		}
+		return params
+	}
+
+	if request.Method == MethodRandom && (total > request.Samples || total > maxCandidates) {
+		samples := request.Samples
+		if samples > maxCandidates {
+			samples = maxCandidates
+		}
+		random := rand.New(rand.NewSource(time.Now().UnixNano()))
+		seen := make(map[string]bool, samples)
+		result := make([]map[string]interface{}, 0, samples)
+		for attempt := 0; len(result) < samples && attempt < samples*10; attempt++ {
+			params := combine(func(name string) interface{} {
+				values := request.Grid[name]
+				return values[random.Intn(len(values))]
+			})
+			key := fmt.Sprintf("%v", params)
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, params)
+			}
+		}
+		return result, nil
+	}
+
+	if total > maxCandidates {
+		return nil, fmt.Errorf("参数组合数量超过上限 %d，请减少候选值或使用随机采样", maxCandidates)
+	}
+	result := make([]map[string]interface{}, 0, total)
+	for i := 0; i < total; i++ {
+		index := i
+		result = append(result, combine(func(name string) interface{} {
+			values := request.Grid[name]
+			value := values[index%len(values)]
+			index /= len(values)
+			return value
+		}))
+	}
+	return result, nil
+}
+
+// runOptimization 等待空闲的运行槽位后运行参数优化，并保存结果
+func (s *Service) runOptimization(id string) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-s.ctx.Done():
+		s.finishOptimization(id, nil, fmt.Errorf("服务已停止"))
+		return
+	}
+
+	s.mutex.Lock()
+	optimization := s.optimizations[id]
+	optimization.Status = StatusRunning
+	optimization.StartedAt = time.Now()
+	request := optimization.Request
+	s.mutex.Unlock()
+
+	logrus.Infof("开始参数优化 %s: 策略 %s，交易对 %v，%d 个前推窗口", id, request.Strategy, request.Pairs, request.Folds)
+	result, err := s.optimize(request, func(progress float64) {
+		s.mutex.Lock()
+		s.optimizations[id].Progress = progress
+		s.mutex.Unlock()
+	})
+	s.finishOptimization(id, result, err)
+}
+
+// optimize 对每个交易对做前推验证：所有候选参数在各训练区间评分，
+// 训练平均得分最高的几组参数和各窗口训练得分最高的参数在测试区间验证，按测试平均得分报告
+func (s *Service) optimize(request OptimizeRequest, progress func(float64)) (*OptimizationResult, error) {
+	candidates, err := s.candidates(request)
+	if err != nil {
+		return nil, err
+	}
+	train, test := request.windows()
+	cache := newBarCache(s, request.From)
+
+	validated := len(candidates)
+	if validated > topCandidates+request.Folds {
+		validated = topCandidates + request.Folds
+	}
+	totalRuns := len(request.Pairs) * request.Folds * (len(candidates) + validated)
+	var done int64
+	onDone := func() {
+		progress(math.Min(float64(atomic.AddInt64(&done, 1))/float64(totalRuns), 0.99))
+	}
+
+	// 训练区间评分
+	tasks := make([]evalTask, 0, len(request.Pairs)*len(candidates)*len(train))
+	for _, pair := range request.Pairs {
+		for candidate := range candidates {
+			for _, window := range train {
+				tasks = append(tasks, evalTask{pair: pair, candidate: candidate, window: window})
+			}
+		}
+	}
+	trainResults := s.evaluate(request, candidates, cache, tasks, onDone)
+	if err := s.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("服务已停止")
+	}
+
+	result := &OptimizationResult{Candidates: len(candidates), Runs: len(tasks)}
+	selected := make(map[string][]int, len(request.Pairs))
+	foldBest := make(map[string][]int, len(request.Pairs))
+	trainScores := make(map[string][]float64, len(request.Pairs))
+	for _, pair := range request.Pairs {
+		scores := make([]float64, len(candidates))
+		counts := make([]int, len(candidates))
+		best := make([]int, len(train))
+		bestScores := make([]float64, len(train))
+		for i := range best {
+			best[i], bestScores[i] = -1, math.Inf(-1)
+		}
+		for _, r := range trainResults {
+			if r.pair != pair || !r.ok {
+				continue
+			}
+			scores[r.candidate] += r.score
+			counts[r.candidate]++
+			fold := windowIndex(train, r.window)
+			if r.score > bestScores[fold] {
+				best[fold], bestScores[fold] = r.candidate, r.score
+			}
+		}
+
+		ranked := make([]int, 0, len(candidates))
+		for candidate := range candidates {
+			if counts[candidate] > 0 {
+				scores[candidate] /= float64(counts[candidate])
+				ranked = append(ranked, candidate)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return scores[ranked[i]] > scores[ranked[j]]
+		})
+		if len(ranked) > topCandidates {
+			ranked = ranked[:topCandidates]
+		}
+		for _, candidate := range best {
+			if candidate >= 0 && !containsInt(ranked, candidate) {
+				ranked = append(ranked, candidate)
+			}
+		}
+		selected[pair], foldBest[pair], trainScores[pair] = ranked, best, scores
+	}
+
+	// 测试区间验证
+	tasks = tasks[:0]
+	for _, pair := range request.Pairs {
+		for _, candidate := range selected[pair] {
+			for _, window := range test {
+				tasks = append(tasks, evalTask{pair: pair, candidate: candidate, window: window})
+			}
+		}
+	}
+	testResults := s.evaluate(request, candidates, cache, tasks, onDone)
+	if err := s.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("服务已停止")
+	}
+	result.Runs += len(tasks)
+
+	for _, pair := range request.Pairs {
+		optimization := PairOptimization{Pair: pair, Best: make([]CandidateResult, 0), Folds: make([]FoldResult, 0, len(train))}
+		summaries := make(map[int]*CandidateResult)
+		for _, r := range testResults {
+			if r.pair != pair || !r.ok {
+				continue
+			}
+			summary, ok := summaries[r.candidate]
+			if !ok {
+				summary = &CandidateResult{Params: candidates[r.candidate], TrainScore: trainScores[pair][r.candidate]}
+				summaries[r.candidate] = summary
+			}
+			summary.TestScore += r.score
+			summary.TestReturn += r.metrics.TotalReturn
+			summary.MaxDrawdown = math.Max(summary.MaxDrawdown, r.metrics.MaxDrawdown)
+			summary.Folds++
+		}
+		for _, summary := range summaries {
+			summary.TestScore /= float64(summary.Folds)
+			summary.TestReturn /= float64(summary.Folds)
+			optimization.Best = append(optimization.Best, *summary)
+		}
+		sort.SliceStable(optimization.Best, func(i, j int) bool {
+			return optimization.Best[i].TestScore > optimization.Best[j].TestScore
+		})
+		if len(optimization.Best) > topCandidates {
+			optimization.Best = optimization.Best[:topCandidates]
+		}
+
+		for fold, candidate := range foldBest[pair] {
+			foldResult := FoldResult{Train: train[fold], Test: test[fold]}
+			if candidate >= 0 {
+				foldResult.BestParams = candidates[candidate]
+				for _, r := range trainResults {
+					if r.pair == pair && r.candidate == candidate && r.window == train[fold] {
+						foldResult.TrainScore = r.score
+					}
+				}
+				for _, r := range testResults {
+					if r.pair == pair && r.candidate == candidate && r.window == test[fold] && r.ok {
+						metrics := r.metrics
+						foldResult.TestScore, foldResult.TestMetrics = r.score, &metrics
+					}
+				}
+			}
+			optimization.Folds = append(optimization.Folds, foldResult)
+		}
+		result.Pairs = append(result.Pairs, optimization)
+	}
+	return result, nil
+}
+
+// evaluate 用多个协程并行运行候选参数回测，每完成一次调用 onDone
+func (s *Service) evaluate(request OptimizeRequest, candidates []map[string]interface{}, cache *barCache, tasks []evalTask, onDone func()) []evalResult {
+	workers := s.cfg.Backtest.OptimizeWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]evalResult, len(tasks))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				task := tasks[index]
+				btRequest := request.baseRequest()
+				btRequest.Params = candidates[task.candidate]
+				btRequest.Pairs = []string{task.pair}
+				btRequest.From, btRequest.To = task.window.From, task.window.To
+
+				result := evalResult{evalTask: task}
+				backtest, err := s.safeSimulate(btRequest, cache, func(float64) {})
+				if err != nil {
+					logrus.Debugf("参数优化的回测失败 (%s, %v): %v", task.pair, btRequest.Params, err)
+				} else {
+					result.metrics, result.ok = backtest.Metrics, true
+					result.score = objectiveScore(request.Objective, backtest.Metrics)
+				}
+				results[index] = result
+				onDone()
+			}
+		}()
+	}
+
+	for index := range tasks {
+		if s.ctx.Err() != nil {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// objectiveScore 按优化目标返回回测的得分，越高越好
+func objectiveScore(objective string, metrics Metrics) float64 {
+	if objective == ObjectiveReturn {
+		return metrics.TotalReturn
+	}
+	return metrics.SharpeRatio
+}
+
+// windowIndex 返回窗口在列表中的位置
+func windowIndex(windows []Window, window Window) int {
+	for i, w := range windows {
+		if w == window {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// finishOptimization 记录参数优化的结果或错误，保存后清理超出保留数量的结果
+func (s *Service) finishOptimization(id string, result *OptimizationResult, err error) {
+	s.mutex.Lock()
+	optimization := s.optimizations[id]
+	optimization.CompletedAt = time.Now()
+	if err != nil {
+		optimization.Status = StatusFailed
+		optimization.Error = err.Error()
+		logrus.Warnf("参数优化 %s 失败: %v", id, err)
+	} else {
+		optimization.Status = StatusCompleted
+		optimization.Progress = 1
+		optimization.Result = result
+		logrus.Infof("参数优化 %s 完成: %d 组参数，%d 次回测", id, result.Candidates, result.Runs)
+	}
+	snapshot := *optimization
+	removed := s.pruneOptimizationsLocked()
+	s.mutex.Unlock()
+
+	s.persistOptimization(snapshot)
+	if s.store != nil {
+		for _, id := range removed {
+			if err := s.store.Delete(store.CollectionOptimizations, id); err != nil {
+				logrus.Warnf("删除参数优化 %s 失败: %v", id, err)
+			}
+		}
+	}
+}
+
+// pruneOptimizationsLocked 删除超出保留数量的最早的已结束参数优化，返回被删除的ID，调用方需持有锁
+func (s *Service) pruneOptimizationsLocked() []string {
+	maxResults := s.cfg.Backtest.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+
+	finished := make([]*Optimization, 0, len(s.optimizations))
+	for _, optimization := range s.optimizations {
+		if optimization.Finished() {
+			finished = append(finished, optimization)
+		}
+	}
+	if len(finished) <= maxResults {
+		return nil
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+
+	removed := make([]string, 0, len(finished)-maxResults)
+	for _, optimization := range finished[:len(finished)-maxResults] {
+		delete(s.optimizations, optimization.ID)
+		removed = append(removed, optimization.ID)
+	}
+	return removed
+}
+
+// persistOptimization 保存参数优化到存储
+func (s *Service) persistOptimization(optimization Optimization) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(store.CollectionOptimizations, optimization.ID, optimization); err != nil {
+		logrus.Warnf("保存参数优化 %s 失败: %v", optimization.ID, err)
+	}
+}
+
+// loadOptimizations 从存储中加载参数优化，重启前未完成的标记为失败
+func (s *Service) loadOptimizations() error {
+	optimizations := make([]Optimization, 0)
+	err := s.store.Load(store.CollectionOptimizations, func(id string, decode func(v interface{}) error) error {
+		var optimization Optimization
+		if err := decode(&optimization); err != nil {
+			return fmt.Errorf("解析参数优化 %s 失败: %v", id, err)
+		}
+		optimizations = append(optimizations, optimization)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	interrupted := make([]Optimization, 0)
+	s.mutex.Lock()
+	for i := range optimizations {
+		optimization := optimizations[i]
+		if !optimization.Finished() {
+			optimization.Status = StatusFailed
+			optimization.Error = "服务重启时参数优化尚未完成"
+			interrupted = append(interrupted, optimization)
+		}
+		s.optimizations[optimization.ID] = &optimization
+	}
+	s.mutex.Unlock()
+
+	for _, optimization := range interrupted {
+		s.persistOptimization(optimization)
+	}
+	logrus.Infof("已加载 %d 个参数优化", len(optimizations))
+	return nil
+}
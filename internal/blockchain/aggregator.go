@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 兑换路径
+const (
+	routeRouter = "router" // 直接通过配置的DEX路由合约兑换
+)
+
+// defaultAggregatorTimeout 未配置时获取聚合器报价的超时时间
+const defaultAggregatorTimeout = 10 * time.Second
+
+// aggregatorRequest 聚合器报价请求，数量为输入代币的最小单位
+type aggregatorRequest struct {
+	ChainID  int
+	TokenIn  common.Address
+	TokenOut common.Address
+	AmountIn *big.Int
+	Taker    common.Address
+	Slippage float64 // 滑点容忍度，百分比
+}
+
+// aggregatorQuote 聚合器返回的报价和待发送的兑换交易
+type aggregatorQuote struct {
+	Source    string         // 聚合器名称
+	To        common.Address // 交易目标合约
+	Spender   common.Address // 需要授权输入代币的合约
+	Data      []byte
+	AmountOut *big.Int // 预计获得数量，输出代币的最小单位
+	GasLimit  uint64   // 聚合器估算的gas上限，为0时自行估算
+}
+
+// Aggregator DEX聚合器，跨多个流动性池计算最优兑换路径
+type Aggregator interface {
+	Quote(ctx context.Context, request aggregatorRequest) (aggregatorQuote, error)
+}
+
+// newAggregator 按路由配置创建聚合器，未配置时返回nil
+func newAggregator(aggregatorCfg config.AggregatorConfig) (Aggregator, error) {
+	if aggregatorCfg.Provider == "" {
+		return nil, nil
+	}
+	client := &aggregatorClient{
+		apiURL:     strings.TrimRight(aggregatorCfg.APIURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if aggregatorCfg.APIKeyEnv != "" {
+		client.apiKey = os.Getenv(aggregatorCfg.APIKeyEnv)
+	}
+
+	switch aggregatorCfg.Provider {
+	case aggregatorProvider0x:
+		return newZeroExAggregator(client), nil
+	case aggregatorProvider1inch:
+		return newOneInchAggregator(client), nil
+	default:
+		return nil, fmt.Errorf("未知的DEX聚合器: %s", aggregatorCfg.Provider)
+	}
+}
+
+// aggregatorClient 聚合器接口的HTTP客户端
+type aggregatorClient struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// get 调用聚合器接口并解析JSON响应
+func (c *aggregatorClient) get(ctx context.Context, rawURL string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求聚合器失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("聚合器返回错误 %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// routeSwap 获取聚合器报价，与路由合约的直接兑换比较扣除gas后的获得数量，聚合器更优时改用聚合器的交易；
+// 未配置聚合器或报价失败时使用路由合约。直接兑换的获得数量按订单价格估算
+func (b *BlockchainExecutor) routeSwap(client *ethclient.Client, order BlockchainOrder, wallet common.Address, direct swapCall, expectedOut decimal.Decimal, decimalsOut int32) swapCall {
+	direct.route = routeRouter
+	aggregator, ok := b.aggregators[order.Network]
+	if !ok {
+		return direct
+	}
+	networkCfg, _ := b.networkConfig(order.Network)
+
+	timeout := time.Duration(networkCfg.Router.Aggregator.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultAggregatorTimeout
+	}
+	ctx, cancel := context.WithTimeout(b.ctx, timeout)
+	defer cancel()
+	quote, err := aggregator.Quote(ctx, aggregatorRequest{
+		ChainID:  networkCfg.ChainID,
+		TokenIn:  direct.tokenIn,
+		TokenOut: direct.tokenOut,
+		AmountIn: direct.amountIn,
+		Taker:    wallet,
+		Slippage: b.cfg.Current().Risk.SlippageTolerance,
+	})
+	if err != nil {
+		logrus.Warnf("获取订单 %s 的聚合器报价失败，使用路由合约兑换: %v", order.ID, err)
+		return direct
+	}
+
+	gasPrice, err := b.getGasPrice(client, order.Network)
+	if err != nil {
+		logrus.Warnf("获取gas价格失败，使用路由合约兑换: %v", err)
+		return direct
+	}
+	directGas := b.resolveGasLimit(client, networkCfg, ethereum.CallMsg{From: wallet, To: &direct.router, GasPrice: gasPrice, Data: direct.data})
+	aggregatorGas := quote.GasLimit
+	if aggregatorGas == 0 {
+		aggregatorGas = b.resolveGasLimit(client, networkCfg, ethereum.CallMsg{From: wallet, To: &quote.To, GasPrice: gasPrice, Data: quote.Data})
+	}
+
+	// gas费用换算为输出代币：卖出时输出为计价代币，买入时按订单价格换算为标的代币
+	gasInOutput := func(gasLimit uint64) decimal.Decimal {
+		native := fromTokenUnits(new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice), nativeDecimals)
+		cost := b.gasCost(order.Network, native)
+		if order.Direction == "buy" && order.Price.IsPositive() {
+			cost = cost.Div(order.Price)
+		}
+		return cost
+	}
+	directNet := expectedOut.Sub(gasInOutput(directGas))
+	aggregatorOut := fromTokenUnits(quote.AmountOut, decimalsOut)
+	aggregatorNet := aggregatorOut.Sub(gasInOutput(aggregatorGas))
+
+	if !aggregatorNet.GreaterThan(directNet) {
+		logrus.Infof("订单 %s 路由合约扣除gas后获得 %s，不低于聚合器 %s 的 %s，使用路由合约兑换",
+			order.ID, directNet.String(), quote.Source, aggregatorNet.String())
+		return direct
+	}
+
+	logrus.Infof("订单 %s 经聚合器 %s 兑换: 扣除gas后获得 %s，路由合约为 %s",
+		order.ID, quote.Source, aggregatorNet.String(), directNet.String())
+	tolerance := decimal.NewFromFloat(b.cfg.Current().Risk.SlippageTolerance).Div(decimal.NewFromInt(100))
+	routed := direct
+	routed.router = quote.To
+	routed.spender = quote.Spender
+	routed.data = quote.Data
+	routed.amountOutMin = toTokenUnits(aggregatorOut.Mul(decimal.NewFromInt(1).Sub(tolerance)), decimalsOut)
+	routed.route = quote.Source
+	return routed
+}
@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// aggregatorProvider0x 0x Swap API
+const aggregatorProvider0x = "0x"
+
+// default0xAPIURL 0x 以太坊主网接口地址，其他网络使用各自的地址，如 https://bsc.api.0x.org
+const default0xAPIURL = "https://api.0x.org"
+
+// zeroExAggregator 通过 0x Swap API 获取兑换报价
+type zeroExAggregator struct {
+	client *aggregatorClient
+}
+
+// newZeroExAggregator 创建 0x 聚合器
+func newZeroExAggregator(client *aggregatorClient) *zeroExAggregator {
+	if client.apiURL == "" {
+		client.apiURL = default0xAPIURL
+	}
+	return &zeroExAggregator{client: client}
+}
+
+// zeroExQuoteResponse /swap/v1/quote 接口的响应
+type zeroExQuoteResponse struct {
+	To              string `json:"to"`
+	Data            string `json:"data"`
+	Gas             string `json:"gas"`
+	BuyAmount       string `json:"buyAmount"`
+	AllowanceTarget string `json:"allowanceTarget"`
+}
+
+// Quote 实现 Aggregator 接口
+func (z *zeroExAggregator) Quote(ctx context.Context, request aggregatorRequest) (aggregatorQuote, error) {
+	params := url.Values{}
+	params.Set("sellToken", request.TokenIn.Hex())
+	params.Set("buyToken", request.TokenOut.Hex())
+	params.Set("sellAmount", request.AmountIn.String())
+	params.Set("takerAddress", request.Taker.Hex())
+	params.Set("slippagePercentage", strconv.FormatFloat(request.Slippage/100, 'f', -1, 64))
+	// 尚未授权时报价仍然有效，授权在发送兑换交易前完成
+	params.Set("skipValidation", "true")
+
+	headers := map[string]string{}
+	if z.client.apiKey != "" {
+		headers["0x-api-key"] = z.client.apiKey
+	}
+	var resp zeroExQuoteResponse
+	if err := z.client.get(ctx, z.client.apiURL+"/swap/v1/quote?"+params.Encode(), headers, &resp); err != nil {
+		return aggregatorQuote{}, err
+	}
+	if !common.IsHexAddress(resp.To) {
+		return aggregatorQuote{}, fmt.Errorf("报价缺少交易目标地址")
+	}
+	data, err := hexutil.Decode(resp.Data)
+	if err != nil {
+		return aggregatorQuote{}, fmt.Errorf("解析交易数据失败: %v", err)
+	}
+	amountOut, ok := new(big.Int).SetString(resp.BuyAmount, 10)
+	if !ok {
+		return aggregatorQuote{}, fmt.Errorf("报价的获得数量格式错误: %q", resp.BuyAmount)
+	}
+	gasLimit, _ := strconv.ParseUint(resp.Gas, 10, 64)
+
+	spender := resp.AllowanceTarget
+	if !common.IsHexAddress(spender) {
+		spender = resp.To
+	}
+	return aggregatorQuote{
+		Source:    aggregatorProvider0x,
+		To:        common.HexToAddress(resp.To),
+		Spender:   common.HexToAddress(spender),
+		Data:      data,
+		AmountOut: amountOut,
+		GasLimit:  gasLimit,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/sirupsen/logrus"
+)
+
+// 授权额度模式
+const (
+	approvalExact = "exact" // 每次只授权本次兑换所需的数量
+	approvalMax   = "max"   // 授权最大额度，之后的兑换无需再次授权
+)
+
+// defaultApprovalTimeout 未配置时等待授权交易确认的时间
+const defaultApprovalTimeout = 2 * time.Minute
+
+const erc20ABI = `[{"name":"allowance","type":"function","stateMutability":"view",
+"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],
+"outputs":[{"name":"","type":"uint256"}]},
+{"name":"approve","type":"function","stateMutability":"nonpayable",
+"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],
+"outputs":[{"name":"","type":"bool"}]}]`
+
+var (
+	parsedERC20ABI = mustParseABI(erc20ABI)
+	// maxAllowance uint256 的最大值
+	maxAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+// ensureAllowance 检查路由合约（或聚合器的授权合约）对输入代币的授权额度，不足时发送 approve 交易并等待确认
+func (b *BlockchainExecutor) ensureAllowance(client *ethclient.Client, network string, chainID *big.Int, w *wallet, swap swapCall) error {
+	// 串行处理授权，避免并发订单重复授权
+	b.approvalMutex.Lock()
+	defer b.approvalMutex.Unlock()
+
+	networkCfg, _ := b.networkConfig(network)
+	routerCfg := networkCfg.Router
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	allowance, err := queryAllowance(ctx, client, swap.tokenIn, w.address, swap.approvalTarget())
+	cancel()
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(swap.amountIn) >= 0 {
+		return nil
+	}
+
+	amount := new(big.Int).Set(swap.amountIn)
+	switch routerCfg.ApprovalMode {
+	case "", approvalExact:
+	case approvalMax:
+		amount = maxAllowance
+	default:
+		return fmt.Errorf("未知的授权额度模式: %s", routerCfg.ApprovalMode)
+	}
+
+	data, err := parsedERC20ABI.Pack("approve", swap.approvalTarget(), amount)
+	if err != nil {
+		return fmt.Errorf("编码approve调用失败: %v", err)
+	}
+
+	nonces := w.nonces[network]
+	nonce, err := nonces.Next(context.Background())
+	if err != nil {
+		return fmt.Errorf("获取nonce失败: %v", err)
+	}
+	gasPrice, err := b.getGasPrice(client, network)
+	if err != nil {
+		nonces.Reset(nonce)
+		return fmt.Errorf("获取gas价格失败: %v", err)
+	}
+	gasLimit := b.resolveGasLimit(client, networkCfg, ethereum.CallMsg{
+		From:     w.address,
+		To:       &swap.tokenIn,
+		GasPrice: gasPrice,
+		Data:     data,
+	})
+
+	tx := types.NewTransaction(nonce, swap.tokenIn, big.NewInt(0), gasLimit, gasPrice, data)
+	signedTx, err := w.sign(b.ctx, tx, chainID)
+	if err != nil {
+		nonces.Reset(nonce)
+		return fmt.Errorf("签名approve交易失败: %v", err)
+	}
+	if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+		nonces.Reset(nonce)
+		return fmt.Errorf("发送approve交易失败: %v", err)
+	}
+	nonces.Track(signedTx)
+	logrus.Infof("代币 %s 对路由合约的授权额度不足(%s < %s)，已发送approve交易: %s",
+		swap.tokenIn.Hex(), allowance.String(), swap.amountIn.String(), signedTx.Hash().Hex())
+
+	// 等待授权交易确认后再兑换，否则兑换交易会因额度不足失败
+	timeout := defaultApprovalTimeout
+	if routerCfg.ApprovalTimeoutSeconds > 0 {
+		timeout = time.Duration(routerCfg.ApprovalTimeoutSeconds) * time.Second
+	}
+	waitCtx, waitCancel := context.WithTimeout(b.ctx, timeout)
+	defer waitCancel()
+	receipt, err := bind.WaitMined(waitCtx, client, signedTx)
+	if err != nil {
+		return fmt.Errorf("等待approve交易确认失败: %v", err)
+	}
+	b.recordGasSpent(network, receipt)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("approve交易执行失败: %s", signedTx.Hash().Hex())
+	}
+
+	logrus.Infof("代币 %s 授权已确认", swap.tokenIn.Hex())
+	return nil
+}
+
+// queryAllowance 查询 owner 授权给 spender 的代币额度
+func queryAllowance(ctx context.Context, client *ethclient.Client, token, owner, spender common.Address) (*big.Int, error) {
+	data, err := parsedERC20ABI.Pack("allowance", owner, spender)
+	if err != nil {
+		return nil, fmt.Errorf("编码allowance调用失败: %v", err)
+	}
+
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用allowance失败: %v", err)
+	}
+	return new(big.Int).SetBytes(result), nil
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"strings"
+
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/metrics"
+)
+
+// SetAuditLog 设置审计日志，记录链上订单的提交、确认、取消和失败
+func (b *BlockchainExecutor) SetAuditLog(log *audit.Log) {
+	b.audit = log
+}
+
+// SetEventBus 设置事件总线，链上交易失败时发布告警事件
+func (b *BlockchainExecutor) SetEventBus(bus *events.Bus) {
+	b.events = bus
+	b.jobs.SetEventBus(bus)
+}
+
+// orderEventType 根据订单状态的变化判断需要记录的审计事件，无需记录时返回空字符串
+func orderEventType(previous, order BlockchainOrder) string {
+	if previous.Status == order.Status && previous.TxHash == order.TxHash {
+		return ""
+	}
+	switch order.Status {
+	case "pending":
+		// 交易首次发送到链上，替换交易不重复记录
+		if previous.TxHash == "" && order.TxHash != "" {
+			return audit.EventOrderSubmitted
+		}
+		if previous.Status == "confirmed" {
+			return audit.EventOrderReorged
+		}
+	case "confirmed":
+		if previous.Status != order.Status {
+			return audit.EventOrderFilled
+		}
+	case "failed":
+		if previous.Status != order.Status {
+			return audit.EventOrderFailed
+		}
+	case "canceled":
+		if previous.Status != order.Status {
+			return audit.EventOrderCanceled
+		}
+	}
+	return ""
+}
+
+// recordOrderEvent 记录链上订单的审计事件和监控指标
+func (b *BlockchainExecutor) recordOrderEvent(eventType string, order BlockchainOrder) {
+	b.metrics.ObserveOrder(metrics.VenueBlockchain, order.Symbol, strings.TrimPrefix(eventType, "order_"))
+	b.audit.Record(audit.Event{
+		Type:      eventType,
+		Account:   order.Account,
+		Symbol:    order.Symbol,
+		Direction: order.Direction,
+		Strategy:  order.StrategyName,
+		OrderID:   order.ID,
+		SignalID:  order.SignalID,
+		Price:     order.Price,
+		Quantity:  order.Quantity,
+		Status:    order.Status,
+		Reason:    order.ErrorMessage,
+		Detail:    order.TxHash,
+	})
+	if eventType == audit.EventOrderFailed {
+		b.events.Publish(events.Event{
+			Type:    events.EventOrderFailed,
+			Account: order.Account,
+			Symbol:  order.Symbol,
+			Payload: order,
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// nativeDecimals 原生币（ETH、BNB等）的精度
+const nativeDecimals = 18
+
+// TokenBalance 钱包持有的单个代币余额
+type TokenBalance struct {
+	Asset   string
+	Address string
+	Balance decimal.Decimal
+
+	Metadata TokenMetadata // 代币合约的符号、名称和精度
+}
+
+// WalletBalance 钱包在某个网络上的原生币和交易相关代币余额
+type WalletBalance struct {
+	Wallet  string
+	Network string
+	Address string
+	Native  decimal.Decimal
+	Tokens  []TokenBalance
+	Error   string // 查询失败时的错误信息
+}
+
+// queryRawTokenBalance 查询钱包持有的ERC-20代币数量（最小单位）
+func queryRawTokenBalance(ctx context.Context, client *ethclient.Client, token, owner common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, erc20BalanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用balanceOf失败: %v", err)
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// checkTokenBalance 检查钱包的输入代币余额是否足够本次兑换
+func checkTokenBalance(ctx context.Context, client *ethclient.Client, owner common.Address, swap swapCall) error {
+	balance, err := queryRawTokenBalance(ctx, client, swap.tokenIn, owner)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(swap.amountIn) >= 0 {
+		return nil
+	}
+
+	decimals, err := queryTokenDecimals(ctx, client, swap.tokenIn)
+	if err != nil {
+		return fmt.Errorf("代币 %s 余额不足", swap.tokenIn.Hex())
+	}
+	return fmt.Errorf("代币 %s 余额不足: 需要 %s，钱包余额 %s", swap.tokenIn.Hex(),
+		fromTokenUnits(swap.amountIn, decimals).String(), fromTokenUnits(balance, decimals).String())
+}
+
+// checkGasBalance 检查钱包的原生币余额是否足够支付gas，并在交易后保留配置的储备量
+func checkGasBalance(ctx context.Context, client *ethclient.Client, owner common.Address, gasLimit uint64, gasPrice *big.Int, reserve float64) error {
+	balance, err := client.BalanceAt(ctx, owner, nil)
+	if err != nil {
+		return fmt.Errorf("查询原生币余额失败: %v", err)
+	}
+
+	gasCost := fromTokenUnits(new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice), nativeDecimals)
+	required := gasCost.Add(decimal.NewFromFloat(reserve))
+	available := fromTokenUnits(balance, nativeDecimals)
+	if available.LessThan(required) {
+		return fmt.Errorf("原生币余额不足以支付gas: 需要 %s（gas费用 %s + 储备 %s），钱包余额 %s",
+			required.String(), gasCost.String(), decimal.NewFromFloat(reserve).String(), available.String())
+	}
+	return nil
+}
+
+// GetWalletBalances 查询所有钱包在各可用网络上的原生币余额，以及该网络上交易对涉及的代币余额
+func (b *BlockchainExecutor) GetWalletBalances() []WalletBalance {
+	names := make([]string, 0, len(b.wallets))
+	for name := range b.wallets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]WalletBalance, 0)
+	for _, name := range names {
+		w := b.wallets[name]
+		for _, network := range b.cfg.Blockchain.Networks {
+			client, ok := b.clients[network.Name]
+			if !ok || !w.usableOn(network.Name) {
+				continue
+			}
+			result = append(result, b.walletBalance(client, w, network.Name))
+		}
+	}
+	return result
+}
+
+// walletBalance 查询钱包在单个网络上的余额
+func (b *BlockchainExecutor) walletBalance(client *ethclient.Client, w *wallet, network string) WalletBalance {
+	balance := WalletBalance{
+		Wallet:  w.name,
+		Network: network,
+		Address: w.address.Hex(),
+		Tokens:  make([]TokenBalance, 0),
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, 15*time.Second)
+	defer cancel()
+
+	native, err := client.BalanceAt(ctx, w.address, nil)
+	if err != nil {
+		balance.Error = fmt.Sprintf("查询原生币余额失败: %v", err)
+		return balance
+	}
+	balance.Native = fromTokenUnits(native, nativeDecimals)
+
+	for _, token := range b.networkTokens(network) {
+		amount, err := b.queryTokenBalance(client, common.HexToAddress(token.Address), w.address)
+		if err != nil {
+			balance.Error = fmt.Sprintf("查询 %s 余额失败: %v", token.Asset, err)
+			continue
+		}
+		token.Balance = amount
+		token.Metadata, _ = queryTokenMetadata(ctx, client, common.HexToAddress(token.Address))
+		balance.Tokens = append(balance.Tokens, token)
+	}
+	return balance
+}
+
+// networkTokens 返回网络上已启用交易对涉及的标的代币和计价代币，按地址去重
+func (b *BlockchainExecutor) networkTokens(network string) []TokenBalance {
+	networkCfg, _ := b.networkConfig(network)
+
+	tokens := make([]TokenBalance, 0)
+	seen := make(map[common.Address]bool)
+	add := func(asset, address string) {
+		if address == "" || seen[common.HexToAddress(address)] {
+			return
+		}
+		seen[common.HexToAddress(address)] = true
+		tokens = append(tokens, TokenBalance{Asset: asset, Address: address})
+	}
+
+	for _, pair := range b.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || pair.Blockchain != network {
+			continue
+		}
+		base, quote := pair.Symbol, ""
+		if parts := strings.SplitN(pair.Symbol, "/", 2); len(parts) == 2 {
+			base, quote = parts[0], parts[1]
+		}
+
+		add(base, pair.TokenAddress)
+		quoteToken := pair.QuoteTokenAddress
+		if quoteToken == "" {
+			quoteToken = networkCfg.Router.QuoteTokenAddress
+		}
+		add(quote, quoteToken)
+	}
+	return tokens
+}
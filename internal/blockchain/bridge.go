@@ -0,0 +1,270 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 跨链转账状态
+const (
+	bridgeStatusPending = "pending"
+	bridgeStatusDone    = "done"
+	bridgeStatusFailed  = "failed"
+)
+
+// 跨链配置未设置时的默认值
+const (
+	defaultBridgeTimeout      = 30 * time.Minute
+	defaultBridgePollInterval = 30 * time.Second
+	defaultBridgeBuffer       = 1.0 // 百分比
+)
+
+// bridgeRequest 跨链转账请求，金额为源代币的最小单位
+type bridgeRequest struct {
+	FromChainID int
+	ToChainID   int
+	FromToken   common.Address
+	ToToken     common.Address
+	Amount      *big.Int
+	Address     common.Address
+}
+
+// bridgeQuote 跨链服务返回的报价和待发送的源链交易
+type bridgeQuote struct {
+	Request         bridgeRequest
+	Tool            string         // 实际使用的跨链桥
+	ApprovalAddress common.Address // 需要授权代币的合约
+	To              common.Address
+	Data            []byte
+	Value           *big.Int
+	GasLimit        uint64 // 为0时自行估算
+	ToAmountMin     *big.Int
+}
+
+// Bridge 跨链服务，可由官方跨链桥或跨链聚合器实现
+type Bridge interface {
+	// Quote 获取跨链报价和源链交易
+	Quote(ctx context.Context, request bridgeRequest) (bridgeQuote, error)
+	// Status 查询源链交易对应的跨链转账状态
+	Status(ctx context.Context, quote bridgeQuote, txHash string) (string, error)
+}
+
+// newBridge 按配置创建跨链服务
+func newBridge(bridgeCfg config.BridgeConfig) (Bridge, error) {
+	switch bridgeCfg.Provider {
+	case "", bridgeProviderLiFi:
+		return newLiFiBridge(bridgeCfg), nil
+	default:
+		return nil, fmt.Errorf("未知的跨链服务: %s", bridgeCfg.Provider)
+	}
+}
+
+// bridgeAndRetry 从其他网络跨链转入订单所缺的输入代币，到账后重新执行订单
+func (b *BlockchainExecutor) bridgeAndRetry(order BlockchainOrder, w *wallet, swap swapCall) {
+	order.Status = "bridging"
+	order.Bridged = true
+	b.updateOrderInMap(order)
+
+	txHash, err := b.bridgeShortfall(order.Network, w, swap)
+	if err != nil {
+		order.Status = "failed"
+		order.ErrorMessage = fmt.Sprintf("跨链转入资金失败: %v", err)
+		b.updateOrderInMap(order)
+		return
+	}
+
+	logrus.Infof("订单 %s 所需资金已跨链转入，重新执行订单", order.ID)
+	order.BridgeTxHash = txHash
+	order.Status = "pending"
+	b.updateOrderInMap(order)
+	b.executeBlockchainOrder(order)
+}
+
+// bridgeShortfall 选择余额足够的其他网络，跨链转入兑换所缺的输入代币并等待到账，返回源链交易哈希
+func (b *BlockchainExecutor) bridgeShortfall(network string, w *wallet, swap swapCall) (string, error) {
+	bridgeCfg := b.cfg.Blockchain.Bridge
+	asset, ok := findBridgeAsset(bridgeCfg.Assets, network, swap.tokenIn)
+	if !ok {
+		return "", fmt.Errorf("代币 %s 未配置跨链资产", swap.tokenIn.Hex())
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, 30*time.Second)
+	defer cancel()
+
+	// 计算目标网络上缺少的数量，并按配置多转入一部分以覆盖跨链费用
+	destClient := b.clients[network]
+	balance, err := queryRawTokenBalance(ctx, destClient, swap.tokenIn, w.address)
+	if err != nil {
+		return "", err
+	}
+	destDecimals, err := queryTokenDecimals(ctx, destClient, swap.tokenIn)
+	if err != nil {
+		return "", err
+	}
+	if balance.Cmp(swap.amountIn) >= 0 {
+		return "", nil
+	}
+	buffer := bridgeCfg.BufferPercent
+	if buffer <= 0 {
+		buffer = defaultBridgeBuffer
+	}
+	shortfall := fromTokenUnits(new(big.Int).Sub(swap.amountIn, balance), destDecimals).
+		Mul(decimal.NewFromFloat(1 + buffer/100))
+
+	// 选择余额足够的源网络
+	for _, token := range asset.Tokens {
+		if token.Network == network || !w.usableOn(token.Network) {
+			continue
+		}
+		srcClient, ok := b.clients[token.Network]
+		if !ok {
+			continue
+		}
+
+		fromToken := common.HexToAddress(token.Address)
+		srcDecimals, err := queryTokenDecimals(ctx, srcClient, fromToken)
+		if err != nil {
+			logrus.Warnf("查询 %s 在 %s 上的精度失败: %v", asset.Asset, token.Network, err)
+			continue
+		}
+		amount := toTokenUnits(shortfall, srcDecimals)
+		srcBalance, err := queryRawTokenBalance(ctx, srcClient, fromToken, w.address)
+		if err != nil || srcBalance.Cmp(amount) < 0 {
+			continue
+		}
+
+		logrus.Infof("从 %s 跨链转入 %s %s 到 %s", token.Network, shortfall.String(), asset.Asset, network)
+		return b.bridgeFrom(token.Network, network, w, fromToken, swap.tokenIn, amount)
+	}
+
+	return "", fmt.Errorf("其他网络上没有足够的 %s 可转入（需要 %s）", asset.Asset, shortfall.String())
+}
+
+// bridgeFrom 在源链上发送跨链交易并等待目标链到账
+func (b *BlockchainExecutor) bridgeFrom(srcNetwork, destNetwork string, w *wallet, fromToken, toToken common.Address, amount *big.Int) (string, error) {
+	srcCfg, _ := b.networkConfig(srcNetwork)
+	destCfg, _ := b.networkConfig(destNetwork)
+	client := b.clients[srcNetwork]
+
+	ctx, cancel := context.WithTimeout(b.ctx, 30*time.Second)
+	quote, err := b.bridge.Quote(ctx, bridgeRequest{
+		FromChainID: srcCfg.ChainID,
+		ToChainID:   destCfg.ChainID,
+		FromToken:   fromToken,
+		ToToken:     toToken,
+		Amount:      amount,
+		Address:     w.address,
+	})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("获取跨链报价失败: %v", err)
+	}
+
+	chainID := big.NewInt(int64(srcCfg.ChainID))
+
+	// 授权跨链合约使用源代币
+	if err := b.ensureAllowance(client, srcNetwork, chainID, w, swapCall{
+		router:   quote.ApprovalAddress,
+		tokenIn:  fromToken,
+		amountIn: amount,
+	}); err != nil {
+		return "", err
+	}
+
+	nonces := w.nonces[srcNetwork]
+	nonce, err := nonces.Next(b.ctx)
+	if err != nil {
+		return "", fmt.Errorf("获取nonce失败: %v", err)
+	}
+	gasPrice, err := b.getGasPrice(client, srcNetwork)
+	if err != nil {
+		nonces.Reset(nonce)
+		return "", fmt.Errorf("获取gas价格失败: %v", err)
+	}
+	gasLimit := quote.GasLimit
+	if gasLimit == 0 {
+		gasLimit = b.resolveGasLimit(client, srcCfg, ethereum.CallMsg{
+			From:     w.address,
+			To:       &quote.To,
+			GasPrice: gasPrice,
+			Value:    quote.Value,
+			Data:     quote.Data,
+		})
+	}
+
+	tx := types.NewTransaction(nonce, quote.To, quote.Value, gasLimit, gasPrice, quote.Data)
+	signedTx, err := w.sign(b.ctx, tx, chainID)
+	if err != nil {
+		nonces.Reset(nonce)
+		return "", fmt.Errorf("签名跨链交易失败: %v", err)
+	}
+	if err := client.SendTransaction(b.ctx, signedTx); err != nil {
+		nonces.Reset(nonce)
+		return "", fmt.Errorf("发送跨链交易失败: %v", err)
+	}
+	nonces.Track(signedTx)
+
+	txHash := signedTx.Hash().Hex()
+	logrus.Infof("已通过 %s 发送跨链交易 %s: %s -> %s", quote.Tool, txHash, srcNetwork, destNetwork)
+
+	return txHash, b.waitBridge(quote, txHash)
+}
+
+// waitBridge 轮询跨链转账状态直到完成、失败或超时
+func (b *BlockchainExecutor) waitBridge(quote bridgeQuote, txHash string) error {
+	timeout := defaultBridgeTimeout
+	if b.cfg.Blockchain.Bridge.TimeoutMinutes > 0 {
+		timeout = time.Duration(b.cfg.Blockchain.Bridge.TimeoutMinutes) * time.Minute
+	}
+	deadline := time.After(timeout)
+
+	ticker := time.NewTicker(defaultBridgePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return b.ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("跨链交易 %s 超过 %s 未到账", txHash, timeout)
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(b.ctx, 15*time.Second)
+			status, err := b.bridge.Status(ctx, quote, txHash)
+			cancel()
+			if err != nil {
+				logrus.Warnf("查询跨链交易 %s 状态失败: %v", txHash, err)
+				continue
+			}
+
+			switch status {
+			case bridgeStatusDone:
+				return nil
+			case bridgeStatusFailed:
+				return fmt.Errorf("跨链交易 %s 失败", txHash)
+			}
+		}
+	}
+}
+
+// findBridgeAsset 查找在指定网络上使用该代币的跨链资产
+func findBridgeAsset(assets []config.BridgeAssetConfig, network string, token common.Address) (config.BridgeAssetConfig, bool) {
+	for _, asset := range assets {
+		for _, assetToken := range asset.Tokens {
+			if assetToken.Network == network && strings.EqualFold(assetToken.Address, token.Hex()) {
+				return asset, true
+			}
+		}
+	}
+	return config.BridgeAssetConfig{}, false
+}
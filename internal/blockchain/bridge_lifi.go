@@ -0,0 +1,177 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// bridgeProviderLiFi LI.FI 跨链聚合器
+const bridgeProviderLiFi = "lifi"
+
+// defaultLiFiAPIURL LI.FI 接口地址
+const defaultLiFiAPIURL = "https://li.quest/v1"
+
+// lifiBridge 通过 LI.FI 聚合器选择跨链桥
+type lifiBridge struct {
+	apiURL     string
+	apiKey     string
+	slippage   float64
+	httpClient *http.Client
+}
+
+// newLiFiBridge 创建 LI.FI 跨链服务
+func newLiFiBridge(bridgeCfg config.BridgeConfig) *lifiBridge {
+	apiURL := bridgeCfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultLiFiAPIURL
+	}
+	apiKey := ""
+	if bridgeCfg.APIKeyEnv != "" {
+		apiKey = os.Getenv(bridgeCfg.APIKeyEnv)
+	}
+	return &lifiBridge{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		apiKey:     apiKey,
+		slippage:   bridgeCfg.Slippage,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// lifiQuoteResponse /quote 接口的响应
+type lifiQuoteResponse struct {
+	Tool     string `json:"tool"`
+	Estimate struct {
+		ApprovalAddress string `json:"approvalAddress"`
+		ToAmountMin     string `json:"toAmountMin"`
+	} `json:"estimate"`
+	TransactionRequest struct {
+		To       string `json:"to"`
+		Data     string `json:"data"`
+		Value    string `json:"value"`
+		GasLimit string `json:"gasLimit"`
+	} `json:"transactionRequest"`
+}
+
+// Quote 实现 Bridge 接口
+func (l *lifiBridge) Quote(ctx context.Context, request bridgeRequest) (bridgeQuote, error) {
+	params := url.Values{}
+	params.Set("fromChain", strconv.Itoa(request.FromChainID))
+	params.Set("toChain", strconv.Itoa(request.ToChainID))
+	params.Set("fromToken", request.FromToken.Hex())
+	params.Set("toToken", request.ToToken.Hex())
+	params.Set("fromAmount", request.Amount.String())
+	params.Set("fromAddress", request.Address.Hex())
+	if l.slippage > 0 {
+		params.Set("slippage", strconv.FormatFloat(l.slippage, 'f', -1, 64))
+	}
+
+	var resp lifiQuoteResponse
+	if err := l.get(ctx, "/quote", params, &resp); err != nil {
+		return bridgeQuote{}, err
+	}
+	if !common.IsHexAddress(resp.TransactionRequest.To) {
+		return bridgeQuote{}, fmt.Errorf("报价缺少交易目标地址")
+	}
+
+	data, err := hexutil.Decode(resp.TransactionRequest.Data)
+	if err != nil {
+		return bridgeQuote{}, fmt.Errorf("解析交易数据失败: %v", err)
+	}
+	value := big.NewInt(0)
+	if resp.TransactionRequest.Value != "" {
+		if value, err = hexutil.DecodeBig(resp.TransactionRequest.Value); err != nil {
+			return bridgeQuote{}, fmt.Errorf("解析交易金额失败: %v", err)
+		}
+	}
+	var gasLimit uint64
+	if resp.TransactionRequest.GasLimit != "" {
+		if gasLimit, err = hexutil.DecodeUint64(resp.TransactionRequest.GasLimit); err != nil {
+			gasLimit = 0
+		}
+	}
+	toAmountMin, _ := new(big.Int).SetString(resp.Estimate.ToAmountMin, 10)
+
+	approval := resp.Estimate.ApprovalAddress
+	if approval == "" {
+		approval = resp.TransactionRequest.To
+	}
+
+	return bridgeQuote{
+		Request:         request,
+		Tool:            resp.Tool,
+		ApprovalAddress: common.HexToAddress(approval),
+		To:              common.HexToAddress(resp.TransactionRequest.To),
+		Data:            data,
+		Value:           value,
+		GasLimit:        gasLimit,
+		ToAmountMin:     toAmountMin,
+	}, nil
+}
+
+// Status 实现 Bridge 接口
+func (l *lifiBridge) Status(ctx context.Context, quote bridgeQuote, txHash string) (string, error) {
+	params := url.Values{}
+	params.Set("txHash", txHash)
+	params.Set("bridge", quote.Tool)
+	params.Set("fromChain", strconv.Itoa(quote.Request.FromChainID))
+	params.Set("toChain", strconv.Itoa(quote.Request.ToChainID))
+
+	var resp struct {
+		Status string `json:"status"`
+	}
+	if err := l.get(ctx, "/status", params, &resp); err != nil {
+		return "", err
+	}
+
+	switch resp.Status {
+	case "DONE":
+		return bridgeStatusDone, nil
+	case "FAILED", "INVALID":
+		return bridgeStatusFailed, nil
+	default: // PENDING, NOT_FOUND
+		return bridgeStatusPending, nil
+	}
+}
+
+// get 调用 LI.FI 接口并解析JSON响应
+func (l *lifiBridge) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.apiURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if l.apiKey != "" {
+		req.Header.Set("x-lifi-api-key", l.apiKey)
+	}
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回错误 %d: %s", path, resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
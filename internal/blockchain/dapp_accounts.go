@@ -0,0 +1,165 @@
+package blockchain
+
+import (
+	"net/http"
+	"strings"
+
+	"autotransaction/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// accountHeader 标识请求所属账户的请求头
+	accountHeader = "X-Account-ID"
+	// accountContextKey gin上下文中保存当前账户的键
+	accountContextKey = "account"
+)
+
+// accountMiddleware 根据请求的认证身份确定账户，X-Account-ID 只能在身份绑定的账户中选择，后续处理函数只能访问该账户的数据
+func (s *DAppAPIServer) accountMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, err := boundAccount(c, strings.TrimSpace(c.GetHeader(accountHeader)))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if !s.cfg.HasAccount(account) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "未知的账户: " + account,
+			})
+			return
+		}
+
+		c.Set(accountContextKey, account)
+		c.Next()
+	}
+}
+
+// currentAccount 获取当前请求所属的账户
+func currentAccount(c *gin.Context) string {
+	if account := c.GetString(accountContextKey); account != "" {
+		return account
+	}
+	return config.DefaultAccountID
+}
+
+// accountPositions 获取账户在交易所和区块链上的持仓
+func (s *DAppAPIServer) accountPositions(account string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0)
+	if s.exchangeExecutor != nil {
+		for key, position := range s.exchangeExecutor.GetPositions() {
+			if position.Account == account {
+				result = append(result, s.exchangePositionToMap(key, position))
+			}
+		}
+	}
+
+	if s.executor == nil {
+		return result
+	}
+	for key, position := range s.executor.GetBlockchainPositions() {
+		if position.Account != account {
+			continue
+		}
+
+		value := position.CurrentPrice.Mul(position.Quantity)
+		// 浮动盈亏扣除开仓手续费和gas费用
+		profitLoss := position.CurrentPrice.Sub(position.EntryPrice).Mul(position.Quantity).Sub(position.Fees)
+
+		result = append(result, map[string]interface{}{
+			"id":           key,
+			"asset":        strings.Split(position.Symbol, "/")[0],
+			"pair":         position.Symbol,
+			"network":      position.Network,
+			"amount":       position.Quantity.InexactFloat64(),
+			"entryPrice":   position.EntryPrice.InexactFloat64(),
+			"currentPrice": position.CurrentPrice.InexactFloat64(),
+			"value":        value.InexactFloat64(),
+			"profitLoss":   profitLoss.InexactFloat64(),
+			"fees":         position.Fees.InexactFloat64(),
+		})
+	}
+
+	return result
+}
+
+// tradeFilter 订单查询条件，零值字段不参与过滤
+type tradeFilter struct {
+	Regime   string // 下单时的市场状态
+	Strategy string // 产生订单的策略实例名称，手动下单为 manual
+	SignalID string
+}
+
+// matches 判断订单是否满足查询条件
+func (f tradeFilter) matches(regime, strategyName, signalID string) bool {
+	if f.Regime != "" && regime != f.Regime {
+		return false
+	}
+	if f.Strategy != "" && strategyName != f.Strategy {
+		return false
+	}
+	if f.SignalID != "" && signalID != f.SignalID {
+		return false
+	}
+	return true
+}
+
+// accountTrades 获取账户在交易所和区块链上满足查询条件的订单
+func (s *DAppAPIServer) accountTrades(account string, filter tradeFilter) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0)
+	if s.exchangeExecutor != nil {
+		for _, order := range s.exchangeExecutor.GetOrders() {
+			if order.Account != account || !filter.matches(order.Regime, order.StrategyName, order.SignalID) {
+				continue
+			}
+			result = append(result, exchangeOrderToMap(order))
+		}
+	}
+
+	if s.executor == nil {
+		return result
+	}
+	for _, order := range s.executor.GetBlockchainOrders() {
+		if order.Account != account || !filter.matches(order.Regime, order.StrategyName, order.SignalID) {
+			continue
+		}
+		result = append(result, blockchainOrderToMap(order))
+	}
+
+	return result
+}
+
+// blockchainOrderToMap 将区块链订单转换为API响应格式
+func blockchainOrderToMap(order BlockchainOrder) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        order.ID,
+		"pair":      order.Symbol,
+		"type":      order.Direction,
+		"amount":    order.Quantity.InexactFloat64(),
+		"price":     order.Price.InexactFloat64(),
+		"timestamp": order.Timestamp.Unix(),
+		"status":    order.Status,
+		"network":   order.Network,
+		"txHash":    order.TxHash,
+		"regime":    order.Regime,
+		"strategy":  order.StrategyName,
+		"fee":       order.Fee.InexactFloat64(),
+		"gasFee":    order.GasFee.InexactFloat64(),
+
+		"gasUsed":     order.GasUsed,
+		"gasPrice":    order.GasPrice.InexactFloat64(),
+		"gasCost":     order.GasCost.InexactFloat64(),
+		"realizedPnl": order.RealizedPnL.InexactFloat64(),
+
+		"clientOrderId": order.ClientOrderID,
+		"signalId":      order.SignalID,
+		"route":         order.Route,
+		"parentId":      order.ParentID,
+		"splits":        order.Splits,
+
+		"blockNumber": order.BlockNumber,
+		"finalized":   order.Finalized,
+		"reorgs":      order.Reorgs,
+	}
+}
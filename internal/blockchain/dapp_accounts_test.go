@@ -0,0 +1,99 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/market"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// testAccountServer 返回启用API认证、有账户 a 和 b 的服务器，两个密钥分别绑定一个账户，各账户在交易所各有一笔成交
+func testAccountServer(t *testing.T) *DAppAPIServer {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.System.DataDir = t.TempDir()
+	cfg.System.LogLevel = "error"
+	cfg.Risk.MaxPositionSize = 1000
+	cfg.Risk.MaxOpenPositions = 10
+	cfg.Accounts = []config.AccountConfig{{ID: "a"}, {ID: "b"}}
+	cfg.System.Auth = config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "key-a", Key: "secret-a", Role: roleTrader, Accounts: []string{"a"}},
+			{Name: "key-b", Key: "secret-b", Role: roleTrader, Accounts: []string{"b"}},
+		},
+	}
+
+	riskManager := risk.NewRiskManager(cfg)
+	executor := execution.NewExecutor(cfg, riskManager)
+	t.Cleanup(executor.Stop)
+	executor.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), High: decimal.NewFromInt(100), Low: decimal.NewFromInt(100)})
+	for _, account := range []string{"a", "b"} {
+		if _, err := executor.SubmitSignal(strategy.Signal{
+			Account:   account,
+			Symbol:    "BTC/USDT",
+			Direction: "buy",
+			Price:     decimal.NewFromInt(100),
+			Quantity:  decimal.NewFromInt(1),
+		}); err != nil {
+			t.Fatalf("账户 %s 下单失败: %v", account, err)
+		}
+	}
+
+	server := NewDAppAPIServer(cfg, nil, nil, nil)
+	t.Cleanup(server.Stop)
+	server.AttachTradingSystem(nil, executor, riskManager)
+	return server
+}
+
+// accountRequest 以指定密钥和 X-Account-ID 请求接口，返回状态码和响应中的数据
+func accountRequest(server *DAppAPIServer, path, key, account string) (int, []map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(apiKeyHeader, key)
+	if account != "" {
+		req.Header.Set(accountHeader, account)
+	}
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body.Data
+}
+
+func TestAPIKeyOnlyReadsBoundAccount(t *testing.T) {
+	server := testAccountServer(t)
+
+	for _, path := range []string{"/api/trades", "/api/positions"} {
+		code, data := accountRequest(server, path, "secret-a", "")
+		if code != http.StatusOK {
+			t.Fatalf("%s 返回 %d", path, code)
+		}
+		if len(data) != 1 {
+			t.Fatalf("%s 应只返回账户 a 的 1 条记录，实际 %d 条", path, len(data))
+		}
+	}
+}
+
+func TestAccountHeaderCannotEscalate(t *testing.T) {
+	server := testAccountServer(t)
+
+	for _, path := range []string{"/api/trades", "/api/positions"} {
+		if code, data := accountRequest(server, path, "secret-a", "b"); code != http.StatusForbidden {
+			t.Fatalf("绑定账户 a 的密钥通过请求头访问账户 b 的 %s 应返回 403，实际 %d，数据 %v", path, code, data)
+		}
+	}
+	if code, _ := accountRequest(server, "/api/trades", "secret-b", "b"); code != http.StatusOK {
+		t.Fatalf("密钥访问自己绑定的账户应成功，实际 %d", code)
+	}
+}
@@ -2,16 +2,30 @@ package blockchain
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/approval"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/backtest"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/health"
+	"autotransaction/internal/llm"
+	"autotransaction/internal/market"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/news"
+	"autotransaction/internal/portfolio"
+	"autotransaction/internal/reconcile"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/sentiment"
+	"autotransaction/internal/strategy"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,24 +35,54 @@ type DAppAPIServer struct {
 	executor      *BlockchainExecutor
 	marketService *BlockchainMarketDataService
 	llmController *LLMController
-	router        *gin.Engine
-	clients       map[*websocket.Conn]bool
-	clientsMutex  sync.RWMutex
-	upgrader      websocket.Upgrader
-	ctx           context.Context
-	cancel        context.CancelFunc
+
+	// 交易系统组件，通过 AttachTradingSystem 设置
+	strategyManager  *strategy.StrategyManager
+	exchangeExecutor *execution.Executor
+	riskManager      *risk.RiskManager
+	startedAt        time.Time
+	auditLog         *audit.Log                  // 为nil时审计查询不可用
+	valuation        *portfolio.ValuationService // 为nil时账户估值不可用
+	approvals        *approval.Queue             // 为nil时不需要人工审批
+	news             *news.Service               // 为nil时新闻列表不可用
+	sentiment        *sentiment.Service          // 为nil时新闻情绪评分不可用
+	exchangeMarket   *market.MarketDataService   // 为nil时LLM无法查询交易所交易对的K线
+	backtests        *backtest.Service           // 为nil时回测不可用
+	reconciliation   *reconcile.Service          // 为nil时持仓对账不可用
+
+	router       *gin.Engine
+	clients      map[*wsClient]bool
+	clientsMutex sync.RWMutex
+	upgrader     websocket.Upgrader
+	tickers      map[string]*tickerHistory // 键为交易对，由 HandleData 更新
+	tickersMutex sync.RWMutex
+	apiKeys      []apiKey               // 启用认证时允许访问的API密钥
+	metrics      *metrics.Metrics       // 为nil时不记录监控指标
+	health       *health.Registry       // 为nil时 /readyz 只报告进程存活
+	siweNonces   map[string]time.Time   // 钱包登录随机数及其过期时间
+	sessions     map[string]siweSession // 钱包登录会话，键为会话令牌
+	authMutex    sync.Mutex
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	suggestions      map[string]*pendingSuggestion // LLM交易建议审批队列，键为建议ID
+	suggestionsMutex sync.Mutex
+
+	conversations *llm.Conversations // LLM问答会话
 }
 
 // NewDAppAPIServer 创建一个新的DApp API服务器
 func NewDAppAPIServer(cfg *config.Config, executor *BlockchainExecutor, marketService *BlockchainMarketDataService, llmController *LLMController) *DAppAPIServer {
 	ctx, cancel := context.WithCancel(context.Background())
-	router := gin.Default()
+	gin.SetMode(ginModeForLogLevel(cfg.System.LogLevel))
+	router := gin.New()
+	router.Use(ginLogger(), ginRecovery())
 
 	// 设置CORS
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Authorization, X-API-Key, X-Account-ID, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 
 		if c.Request.Method == "OPTIONS" {
@@ -55,7 +99,8 @@ func NewDAppAPIServer(cfg *config.Config, executor *BlockchainExecutor, marketSe
 		marketService: marketService,
 		llmController: llmController,
 		router:        router,
-		clients:       make(map[*websocket.Conn]bool),
+		clients:       make(map[*wsClient]bool),
+		tickers:       make(map[string]*tickerHistory),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -63,10 +108,21 @@ func NewDAppAPIServer(cfg *config.Config, executor *BlockchainExecutor, marketSe
 				return true // 允许所有来源
 			},
 		},
-		ctx:    ctx,
-		cancel: cancel,
+		startedAt:  time.Now(),
+		apiKeys:    loadAPIKeys(cfg.System.Auth),
+		siweNonces: make(map[string]time.Time),
+		sessions:   make(map[string]siweSession),
+		ctx:        ctx,
+		cancel:     cancel,
+
+		suggestions: make(map[string]*pendingSuggestion),
+
+		conversations: llm.NewConversations(cfg.LLM.Conversation),
 	}
 
+	// 认证在CORS预检之后进行，覆盖所有路由；限流在认证之后，已认证的请求按密钥计数
+	router.Use(server.authMiddleware(), server.rateLimit(cfg.System.RateLimit.Default))
+
 	// 设置路由
 	server.setupRoutes()
 
@@ -76,6 +132,9 @@ func NewDAppAPIServer(cfg *config.Config, executor *BlockchainExecutor, marketSe
 // Start 启动API服务器
 func (s *DAppAPIServer) Start() error {
 	go s.broadcastUpdates()
+	if autoExecute := s.cfg.LLM.AutoExecute; autoExecute.Enabled && autoExecute.IntervalMinutes > 0 {
+		go s.runAutoTrading()
+	}
 
 	port := s.cfg.System.DAppPort
 	if port == 0 {
@@ -93,7 +152,7 @@ func (s *DAppAPIServer) Stop() {
 	// 关闭所有WebSocket连接
 	s.clientsMutex.Lock()
 	for client := range s.clients {
-		client.Close()
+		client.conn.Close()
 	}
 	s.clientsMutex.Unlock()
 
@@ -105,9 +164,21 @@ func (s *DAppAPIServer) setupRoutes() {
 	// WebSocket端点
 	s.router.GET("/ws", s.handleWebSocket)
 
-	// API端点
-	api := s.router.Group("/api")
+	// Kubernetes 存活和就绪探针
+	s.router.GET("/healthz", s.handleLiveness)
+	s.router.GET("/readyz", s.handleReadiness)
+
+	// API端点，所有请求按账户隔离
+	api := s.router.Group("/api", s.accountMiddleware())
 	{
+		// 钱包签名登录
+		auth := api.Group("/auth")
+		{
+			auth.GET("/nonce", s.getSIWENonce)
+			auth.POST("/verify", s.verifySIWE)
+			auth.POST("/logout", s.logoutSIWE)
+		}
+
 		// 市场数据
 		api.GET("/markets", s.getMarketData)
 
@@ -116,34 +187,106 @@ func (s *DAppAPIServer) setupRoutes() {
 		{
 			strategies.GET("", s.getStrategies)
 			strategies.GET("/:id", s.getStrategy)
-			strategies.POST("", s.createStrategy)
-			strategies.PUT("/:id", s.updateStrategy)
-			strategies.DELETE("/:id", s.deleteStrategy)
-			strategies.PUT("/:id/toggle", s.toggleStrategy)
+			strategies.GET("/:id/performance", s.getStrategyPerformance)
+			strategies.POST("", s.requireRole(roleAdmin), s.createStrategy)
+			strategies.PUT("/:id", s.requireRole(roleAdmin), s.updateStrategy)
+			strategies.DELETE("/:id", s.requireRole(roleAdmin), s.deleteStrategy)
+			strategies.PUT("/:id/toggle", s.requireRole(roleAdmin), s.toggleStrategy)
 		}
 
-		// 交易
+		// 交易，下单和撤单共用一个限流器
+		tradeLimit := s.rateLimit(s.cfg.System.RateLimit.Trade)
 		trades := api.Group("/trades")
 		{
 			trades.GET("", s.getTrades)
 			trades.GET("/:id", s.getTrade)
-			trades.POST("", s.executeTrade)
-			trades.PUT("/:id/cancel", s.cancelTrade)
+			trades.POST("", s.requireRole(roleTrader), tradeLimit, s.executeTrade)
+			trades.PUT("/:id/cancel", s.requireRole(roleTrader), tradeLimit, s.cancelTrade)
+		}
+
+		// 交易所订单队列和死信队列，重新提交与下单共用限流器
+		api.GET("/order-queue", s.getOrderQueue)
+		api.POST("/order-queue/dead-letters/:id/retry", s.requireRole(roleTrader), tradeLimit, s.retryDeadLetter)
+
+		// 持仓对账，结果包含所有账户的持仓，仅管理员可查看和手动触发
+		api.GET("/reconciliation", s.requireRole(roleAdmin), s.getReconciliation)
+		api.POST("/reconciliation/run", s.requireRole(roleAdmin), s.runReconciliation)
+
+		// 人工审批队列，批准后信号交给执行器，与下单共用限流器
+		approvals := api.Group("/approvals")
+		{
+			approvals.GET("", s.getApprovals)
+			approvals.POST("/:id/approve", s.requireRole(roleTrader), tradeLimit, s.approveApproval)
+			approvals.POST("/:id/reject", s.requireRole(roleTrader), s.rejectApproval)
 		}
 
+		// 外部信号 Webhook，以共享密钥认证，与下单共用限流器
+		api.POST("/webhooks/signals", tradeLimit, s.handleSignalWebhook)
+
 		// 持仓
 		api.GET("/positions", s.getPositions)
 
+		// 账户估值和盈亏
+		api.GET("/portfolio", s.getPortfolio)
+
 		// 系统状态
 		api.GET("/status", s.getSystemStatus)
 
+		// 紧急停止（kill switch），停止和恢复需要管理员权限
+		api.GET("/system/halt", s.getHaltStatus)
+		api.POST("/system/halt", s.requireRole(roleAdmin), s.haltTrading)
+		api.POST("/system/resume", s.requireRole(roleAdmin), s.resumeTrading)
+
+		// 钱包余额
+		api.GET("/wallet", s.getWalletBalances)
+		api.GET("/tokens/:address/safety", s.getTokenSafety)
+
+		// 审计日志
+		api.GET("/audit", s.getAuditEvents)
+
+		// 新闻源抓取的新闻
+		api.GET("/news", s.getNews)
+
+		// LLM 相关的端点共用一个限流器
+		llmLimit := s.rateLimit(s.cfg.System.RateLimit.LLM)
+
+		// 按资产的新闻情绪分数，手动评分会调用LLM
+		api.GET("/sentiment", s.getSentiment)
+		api.GET("/sentiment/:asset", s.getSentimentHistory)
+		api.POST("/sentiment/refresh", s.requireRole(roleAdmin), llmLimit, s.refreshSentiment)
+
+		// 策略回测，回测在后台运行，通过ID查询进度和结果
+		api.POST("/backtests", s.requireRole(roleTrader), s.submitBacktest)
+		api.GET("/backtests", s.getBacktests)
+		api.GET("/backtests/:id", s.getBacktest)
+
+		// 策略参数优化，前推验证各交易对的候选参数
+		api.POST("/optimizations", s.requireRole(roleTrader), s.submitOptimization)
+		api.GET("/optimizations", s.getOptimizations)
+		api.GET("/optimizations/:id", s.getOptimization)
+
+		// 每日亏损熔断按账户统计，查看和解除当前账户的熔断，所有账户的熔断状态仅管理员可查看
+		api.GET("/risk/circuit-breaker", s.getCircuitBreaker)
+		api.GET("/risk/circuit-breakers", s.requireRole(roleAdmin), s.getCircuitBreakers)
+		api.POST("/risk/circuit-breaker/reset", s.requireRole(roleAdmin), s.resetCircuitBreaker)
+
+		// 最近被风险检查拒绝的信号及原因
+		api.GET("/risk/rejections", s.getRejections)
+
 		// LLM 相关的端点
-		llm := api.Group("/llm")
+		llm := api.Group("/llm", llmLimit)
 		{
-			llm.GET("/market-analysis", s.llmController.AnalyzeMarket)
-			llm.POST("/optimize-strategy/:id", s.llmController.OptimizeStrategy)
+			// 以下接口均可通过 engine 查询参数选择LLM引擎
+			llm.GET("/engines", s.llmController.ListEngines)
+			llm.GET("/prompts", s.llmController.ListPrompts)
+			llm.GET("/usage", s.llmController.GetUsage)
+			llm.GET("/market-analysis", s.analyzeMarket)
+			llm.POST("/optimize-strategy/:id", s.optimizeStrategy)
 			llm.POST("/trading-recommendations", s.llmController.GetTradingRecommendations)
-			llm.POST("/ask", s.llmController.AnswerQuestion)
+			llm.POST("/ask", s.answerQuestion)
+			llm.POST("/ask/stream", s.answerQuestionStream)
+			llm.GET("/conversations/:id", s.getConversation)
+			llm.DELETE("/conversations/:id", s.deleteConversation)
 			llm.GET("/news-sentiment", s.llmController.AnalyzeNewsSentiment)
 			llm.GET("/explain-trade/:id", s.llmController.ExplainTrade)
 			llm.POST("/portfolio-risk", s.llmController.AnalyzePortfolioRisk)
@@ -151,6 +294,12 @@ func (s *DAppAPIServer) setupRoutes() {
 
 			// 新增的LLM端点
 			llm.GET("/trade-suggestions", s.llmController.GetTradeSuggestions)
+			llm.POST("/trade-suggestions/execute", s.requireRole(roleTrader), tradeLimit, s.executeTradeSuggestions)
+
+			// LLM交易建议审批队列
+			llm.GET("/suggestions", s.getSuggestions)
+			llm.POST("/suggestions/:id/approve", s.requireRole(roleTrader), tradeLimit, s.approveSuggestion)
+			llm.POST("/suggestions/:id/reject", s.requireRole(roleTrader), s.rejectSuggestion)
 			llm.GET("/market-sentiment", s.llmController.GetMarketSentiment)
 			llm.POST("/strategy-recommendations", s.llmController.GetStrategyRecommendations)
 			llm.GET("/explain-market-movements", s.llmController.ExplainMarketMovements)
@@ -160,187 +309,161 @@ func (s *DAppAPIServer) setupRoutes() {
 	}
 }
 
-// handleWebSocket 处理WebSocket连接
-func (s *DAppAPIServer) handleWebSocket(c *gin.Context) {
-	ws, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		logrus.Errorf("升级WebSocket连接失败: %v", err)
-		return
-	}
-
-	// 注册新客户端
-	s.clientsMutex.Lock()
-	s.clients[ws] = true
-	s.clientsMutex.Unlock()
-
-	logrus.Infof("新的WebSocket客户端已连接: %s", ws.RemoteAddr())
-
-	// 处理断开连接
-	defer func() {
-		s.clientsMutex.Lock()
-		delete(s.clients, ws)
-		s.clientsMutex.Unlock()
-		ws.Close()
-		logrus.Infof("WebSocket客户端已断开连接: %s", ws.RemoteAddr())
-	}()
-
-	// 处理来自客户端的消息
-	for {
-		_, message, err := ws.ReadMessage()
-		if err != nil {
-			logrus.Debugf("读取WebSocket消息失败: %v", err)
-			break
-		}
-
-		logrus.Debugf("收到WebSocket消息: %s", string(message))
+// API端点处理函数
 
-		// 这里可以处理客户端发来的消息
-		// 例如可以解析JSON命令并执行相应操作
+func (s *DAppAPIServer) getMarketData(c *gin.Context) {
+	data := make([]map[string]interface{}, 0)
+	for _, ticker := range s.getLatestMarketData() {
+		data = append(data, map[string]interface{}{
+			"pair":      ticker.Pair,
+			"price":     ticker.Price.InexactFloat64(),
+			"change24h": ticker.Change24h.InexactFloat64(),
+		})
 	}
-}
 
-// broadcastUpdates 定期向所有WebSocket客户端广播更新
-func (s *DAppAPIServer) broadcastUpdates() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case <-ticker.C:
-			// 获取最新数据
-			update := map[string]interface{}{
-				"type":       "marketUpdate",
-				"timestamp":  time.Now().Unix(),
-				"marketData": s.getLatestMarketData(),
-			}
-
-			data, err := json.Marshal(update)
-			if err != nil {
-				logrus.Errorf("序列化市场数据更新失败: %v", err)
-				continue
-			}
-
-			// 广播给所有客户端
-			s.clientsMutex.RLock()
-			for client := range s.clients {
-				err := client.WriteMessage(websocket.TextMessage, data)
-				if err != nil {
-					logrus.Debugf("向WebSocket客户端发送消息失败: %v", err)
-					client.Close()
-					delete(s.clients, client)
-				}
-			}
-			s.clientsMutex.RUnlock()
-		}
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
 }
 
-// getLatestMarketData 获取最新的市场数据
-func (s *DAppAPIServer) getLatestMarketData() []map[string]interface{} {
-	// 这里应该从marketService获取最新的市场数据
-	// 示例数据
-	return []map[string]interface{}{
-		{
-			"pair":      "BTC/USDT",
-			"price":     68432.21,
-			"change24h": 2.34,
-		},
-		{
-			"pair":      "ETH/USDT",
-			"price":     4532.67,
-			"change24h": -1.12,
-		},
+func (s *DAppAPIServer) getStrategies(c *gin.Context) {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
 	}
-}
-
-// API端点处理函数
 
-func (s *DAppAPIServer) getMarketData(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"data": s.getLatestMarketData(),
+		"data": s.strategyManager.Strategies(),
 	})
 }
 
-func (s *DAppAPIServer) getStrategies(c *gin.Context) {
-	// 实现获取所有策略的逻辑
+func (s *DAppAPIServer) getStrategy(c *gin.Context) {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
+	info, ok := s.strategyManager.GetStrategyInfo(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "策略不存在"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": []map[string]interface{}{
-			{
-				"id":          1,
-				"name":        "Moving Average Crossover",
-				"description": "Trades based on MA crossovers",
-				"status":      true,
-			},
-		},
+		"data": info,
 	})
 }
 
-func (s *DAppAPIServer) getStrategy(c *gin.Context) {
-	id := c.Param("id")
-	// 实现获取特定策略的逻辑
+// getStrategyPerformance 根据订单记录计算策略实例的绩效，已删除的策略只要有成交记录也可查询
+func (s *DAppAPIServer) getStrategyPerformance(c *gin.Context) {
+	if s.exchangeExecutor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "交易执行器不可用"})
+		return
+	}
+
+	name := c.Param("id")
+	report := s.exchangeExecutor.GetPerformanceReport(name)
+	if report.Orders == 0 && !s.strategyExists(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "策略不存在"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":          id,
-			"name":        "Moving Average Crossover",
-			"description": "Trades based on MA crossovers",
-			"status":      true,
-			"params": map[string]interface{}{
-				"shortPeriod": 5,
-				"longPeriod":  20,
-				"interval":    "1h",
-			},
-		},
+		"data": performanceReportToMap(report),
 	})
 }
 
 func (s *DAppAPIServer) createStrategy(c *gin.Context) {
-	var strategy map[string]interface{}
-	if err := c.BindJSON(&strategy); err != nil {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
+	var body struct {
+		Type   string                 `json:"type"`
+		Name   string                 `json:"name"`
+		Params map[string]interface{} `json:"params"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少策略类型"})
+		return
+	}
+
+	if err := s.strategyManager.CreateStrategy(body.Type, body.Name, body.Params); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 实现创建策略的逻辑
+	name := body.Name
+	if name == "" {
+		name = body.Type
+	}
+	info, _ := s.strategyManager.GetStrategyInfo(name)
 	c.JSON(http.StatusCreated, gin.H{
-		"data": map[string]interface{}{
-			"id":      999, // 使用一个示例ID
-			"message": "Strategy created successfully",
-		},
+		"data": info,
 	})
 }
 
 func (s *DAppAPIServer) updateStrategy(c *gin.Context) {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
 	id := c.Param("id")
-	var strategy map[string]interface{}
-	if err := c.BindJSON(&strategy); err != nil {
+	var body struct {
+		Params map[string]interface{} `json:"params"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := s.strategyManager.GetStrategyInfo(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "策略不存在"})
+		return
+	}
+	if err := s.strategyManager.UpdateStrategy(id, body.Params); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 实现更新策略的逻辑
+	info, _ := s.strategyManager.GetStrategyInfo(id)
 	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":      id,
-			"message": "Strategy updated successfully",
-		},
+		"data": info,
 	})
 }
 
 func (s *DAppAPIServer) deleteStrategy(c *gin.Context) {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
 	id := c.Param("id")
+	if err := s.strategyManager.RemoveStrategy(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 实现删除策略的逻辑
 	c.JSON(http.StatusOK, gin.H{
 		"data": map[string]interface{}{
 			"id":      id,
-			"message": "Strategy deleted successfully",
+			"message": "策略已删除",
 		},
 	})
 }
 
 func (s *DAppAPIServer) toggleStrategy(c *gin.Context) {
+	if s.strategyManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
 	id := c.Param("id")
 	var body struct {
 		Status bool `json:"status"`
@@ -350,109 +473,239 @@ func (s *DAppAPIServer) toggleStrategy(c *gin.Context) {
 		return
 	}
 
-	// 实现开启/关闭策略的逻辑
+	if err := s.strategyManager.SetStrategyEnabled(id, body.Status); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": map[string]interface{}{
-			"id":      id,
-			"status":  body.Status,
-			"message": "Strategy status updated successfully",
+			"id":     id,
+			"status": body.Status,
 		},
 	})
 }
 
 func (s *DAppAPIServer) getTrades(c *gin.Context) {
-	// 实现获取所有交易的逻辑
 	c.JSON(http.StatusOK, gin.H{
-		"data": []map[string]interface{}{
-			{
-				"id":        1,
-				"pair":      "BTC/USDT",
-				"type":      "buy",
-				"amount":    0.5,
-				"price":     68432.21,
-				"timestamp": time.Now().Add(-2 * time.Hour).Unix(),
-				"status":    "completed",
-			},
-		},
+		"data": s.accountTrades(currentAccount(c), tradeFilter{
+			Regime:   c.Query("regime"),
+			Strategy: c.Query("strategy"),
+			SignalID: c.Query("signalId"),
+		}),
 	})
 }
 
 func (s *DAppAPIServer) getTrade(c *gin.Context) {
-	id := c.Param("id")
-	// 实现获取特定交易的逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":        id,
-			"pair":      "BTC/USDT",
-			"type":      "buy",
-			"amount":    0.5,
-			"price":     68432.21,
-			"timestamp": time.Now().Add(-2 * time.Hour).Unix(),
-			"status":    "completed",
-		},
-	})
+	trade, ok := s.findTrade(currentAccount(c), c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": trade})
+}
+
+// findTrade 按ID查找账户的交易所订单或链上交易，返回API响应格式
+func (s *DAppAPIServer) findTrade(account, id string) (map[string]interface{}, bool) {
+	if s.exchangeExecutor != nil {
+		if order, ok := s.exchangeExecutor.GetOrders()[id]; ok && order.Account == account {
+			return exchangeOrderToMap(order), true
+		}
+	}
+	if s.executor != nil {
+		if order, ok := s.executor.GetBlockchainOrders()[id]; ok && order.Account == account {
+			return blockchainOrderToMap(order), true
+		}
+	}
+	return nil, false
 }
 
 func (s *DAppAPIServer) executeTrade(c *gin.Context) {
-	var trade map[string]interface{}
-	if err := c.BindJSON(&trade); err != nil {
+	var body struct {
+		Pair   string  `json:"pair"`
+		Type   string  `json:"type"`
+		Amount float64 `json:"amount"`
+		Price  float64 `json:"price"`
+
+		OrderType   string  `json:"orderType"` // market, limit, stop_limit
+		StopPrice   float64 `json:"stopPrice"`
+		TimeInForce string  `json:"timeInForce"` // GTC, IOC, FOK
+
+		Algo                string `json:"algo"`                // twap, iceberg，为空时一次性下单
+		AlgoSlices          int    `json:"algoSlices"`          // 拆分笔数
+		AlgoDurationSeconds int    `json:"algoDurationSeconds"` // TWAP 的执行时长
+
+		ClientOrderID string `json:"clientOrderId"` // 幂等键，也可通过 Idempotency-Key 请求头传入
+
+		DryRun bool `json:"dryRun"` // 只模拟执行并返回预览，不下单，也可通过 dryRun 查询参数指定
+	}
+	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if body.ClientOrderID == "" {
+		body.ClientOrderID = c.GetHeader("Idempotency-Key")
+	}
+	if body.Pair == "" || (body.Type != "buy" && body.Type != "sell") || body.Amount <= 0 || body.Price <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易参数"})
+		return
+	}
 
-	// 实现执行交易的逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":      999, // 使用一个示例ID
-			"message": "Trade executed successfully",
-		},
-	})
+	signal := strategy.Signal{
+		Symbol:        body.Pair,
+		Direction:     body.Type,
+		Price:         decimal.NewFromFloat(body.Price),
+		Quantity:      decimal.NewFromFloat(body.Amount),
+		Timestamp:     time.Now().Unix(),
+		Confidence:    1,
+		Account:       currentAccount(c),
+		StrategyName:  manualStrategyName,
+		OrderType:     body.OrderType,
+		StopPrice:     decimal.NewFromFloat(body.StopPrice),
+		TimeInForce:   body.TimeInForce,
+		ClientOrderID: body.ClientOrderID,
+		ID:            strategy.NewSignalID(),
+		Algo:          body.Algo,
+		AlgoSlices:    body.AlgoSlices,
+		AlgoDuration:  body.AlgoDurationSeconds,
+	}
+
+	if dryRunRequested(c, body.DryRun) {
+		c.JSON(s.previewSignal(signal))
+		return
+	}
+	s.submitSignal(c, signal)
+}
+
+// submitSignal 将交易信号交给对应的执行器并写入响应
+func (s *DAppAPIServer) submitSignal(c *gin.Context, signal strategy.Signal) {
+	c.JSON(s.routeSignal(signal))
+}
+
+// routeSignal 将交易信号交给对应的执行器，返回响应状态码和响应体
+// 需要人工审批的信号放入审批队列，区块链交易对由区块链交易执行器异步处理，其余交易对由交易所执行器同步下单
+func (s *DAppAPIServer) routeSignal(signal strategy.Signal) (int, gin.H) {
+	// 需要人工审批的信号先进入审批队列，批准后交给执行器
+	if request, queued := s.approvals.Submit(signal); queued {
+		return http.StatusAccepted, gin.H{
+			"data": map[string]interface{}{
+				"message":  "交易需要人工审批",
+				"approval": approvalRequestToMap(request),
+			},
+		}
+	}
+
+	// 区块链交易对由区块链交易执行器处理，交易结果通过 WebSocket 推送
+	if s.isBlockchainPair(signal.Symbol) {
+		if s.executor == nil {
+			return http.StatusServiceUnavailable, gin.H{"error": "区块链交易执行器不可用"}
+		}
+		// 相同幂等键的重试请求直接返回已创建的订单
+		if existing, ok := s.executor.findClientOrder(signal.Account, signal.ClientOrderID); ok {
+			return http.StatusOK, gin.H{"data": blockchainOrderToMap(existing)}
+		}
+		// 先同步执行风险检查，使拒绝原因能直接返回给调用方；执行器下单前会再次检查并记录结果（如占用滑点熔断的试单）
+		if s.riskManager != nil {
+			if err := s.riskManager.PreviewSignal(signal); err != nil {
+				return http.StatusBadRequest, gin.H{"error": "交易被拒绝: 未通过风险检查: " + err.Error()}
+			}
+		}
+		s.executor.HandleSignal(signal)
+		return http.StatusAccepted, gin.H{
+			"data": map[string]interface{}{
+				"message": "交易已提交",
+			},
+		}
+	}
+
+	if s.exchangeExecutor == nil {
+		return http.StatusServiceUnavailable, gin.H{"error": "交易执行器不可用"}
+	}
+	order, err := s.exchangeExecutor.SubmitSignal(signal)
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": "交易被拒绝: " + err.Error()}
+	}
+
+	return http.StatusCreated, gin.H{
+		"data": exchangeOrderToMap(order),
+	}
 }
 
 func (s *DAppAPIServer) cancelTrade(c *gin.Context) {
 	id := c.Param("id")
 
-	// 实现取消交易的逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":      id,
-			"message": "Trade cancelled successfully",
-		},
-	})
+	if s.exchangeExecutor != nil {
+		if order, ok := s.exchangeExecutor.GetOrders()[id]; ok && order.Account == currentAccount(c) {
+			if err := s.exchangeExecutor.CancelOrder(id); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"data": map[string]interface{}{
+					"id":      id,
+					"message": "订单已撤销",
+				},
+			})
+			return
+		}
+	}
+
+	if s.executor != nil {
+		if order, ok := s.executor.GetBlockchainOrders()[id]; ok && order.Account == currentAccount(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "已提交的链上交易无法撤销"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "交易不存在"})
 }
 
 func (s *DAppAPIServer) getPositions(c *gin.Context) {
-	// 实现获取所有持仓的逻辑
 	c.JSON(http.StatusOK, gin.H{
-		"data": []map[string]interface{}{
-			{
-				"id":           1,
-				"asset":        "BTC",
-				"pair":         "BTC/USDT",
-				"amount":       0.15,
-				"entryPrice":   64532.78,
-				"currentPrice": 68432.21,
-				"value":        10264.83,
-				"profitLoss":   585.90,
-			},
-		},
+		"data": s.accountPositions(currentAccount(c)),
 	})
 }
 
 func (s *DAppAPIServer) getSystemStatus(c *gin.Context) {
-	// 实现获取系统状态的逻辑
+	strategies := 0
+	if s.strategyManager != nil {
+		strategies = len(s.strategyManager.Strategies())
+	}
+
+	activeTrades := 0
+	realizedPnL := decimal.Zero
+	if s.exchangeExecutor != nil {
+		for _, order := range s.exchangeExecutor.GetOrders() {
+			if order.Status == "pending" {
+				activeTrades++
+			}
+		}
+		for _, perf := range s.exchangeExecutor.GetStrategyPerformance() {
+			realizedPnL = realizedPnL.Add(perf.RealizedPnL)
+		}
+	}
+	if s.executor != nil {
+		for _, order := range s.executor.GetBlockchainOrders() {
+			if order.Status == "pending" || order.Status == "gas_queued" {
+				activeTrades++
+			}
+		}
+	}
+
+	status := "running"
+	if s.riskManager != nil && s.riskManager.GetHaltStatus().Halted {
+		status = "halted"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": map[string]interface{}{
-			"status":       "running",
-			"uptime":       12345, // 秒
+			"status":       status,
+			"uptime":       int64(time.Since(s.startedAt).Seconds()), // 秒
 			"version":      "1.0.0",
-			"strategies":   3,
-			"activeTrades": 2,
-			"performance": map[string]interface{}{
-				"daily":   2.34,
-				"weekly":  5.67,
-				"monthly": 12.45,
-			},
+			"strategies":   strategies,
+			"activeTrades": activeTrades,
+			"realizedPnL":  realizedPnL.InexactFloat64(),
+			"health":       s.healthSummary(c),
 		},
 	})
 }
@@ -467,3 +720,8 @@ func (s *DAppAPIServer) RegisterMetricsHandler(handler http.Handler) error {
 	logrus.Info("Prometheus指标端点已注册在 /metrics")
 	return nil
 }
+
+// SetMetrics 设置监控指标，记录WebSocket客户端数
+func (s *DAppAPIServer) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"errors"
+	"net/http"
+
+	"autotransaction/internal/approval"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetApprovalQueue 设置人工审批队列，需要审批的手动下单、外部 Webhook 和LLM建议的信号先进入队列
+func (s *DAppAPIServer) SetApprovalQueue(queue *approval.Queue) {
+	s.approvals = queue
+}
+
+// getApprovals 获取当前账户的审批队列
+// 支持的查询参数: status (pending, approved, rejected, expired)，为空时返回所有状态
+func (s *DAppAPIServer) getApprovals(c *gin.Context) {
+	if s.approvals == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "人工审批未启用"})
+		return
+	}
+
+	data := make([]map[string]interface{}, 0)
+	for _, request := range s.approvals.List(currentAccount(c), c.Query("status")) {
+		data = append(data, approvalRequestToMap(request))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// approveApproval 批准待审批的信号，信号随即交给执行器，下单结果通过 WebSocket 推送
+func (s *DAppAPIServer) approveApproval(c *gin.Context) {
+	s.respondApproval(c, true, "")
+}
+
+// rejectApproval 拒绝待审批的信号，请求体可选地给出拒绝原因: {"reason": "..."}
+func (s *DAppAPIServer) rejectApproval(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	// 请求体可为空
+	_ = c.ShouldBindJSON(&body)
+	s.respondApproval(c, false, body.Reason)
+}
+
+// respondApproval 执行审批决定并写入响应
+func (s *DAppAPIServer) respondApproval(c *gin.Context, approve bool, note string) {
+	request, code, err := s.decideApproval(c.Param("id"), currentAccount(c), requestKeyName(c), approve, note)
+	if err != nil {
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": approvalRequestToMap(request)})
+}
+
+// decideApproval 批准或拒绝账户的审批请求，失败时返回对应的HTTP状态码，REST 和 WebSocket 共用
+func (s *DAppAPIServer) decideApproval(id, account, decidedBy string, approve bool, note string) (approval.Request, int, error) {
+	if s.approvals == nil {
+		return approval.Request{}, http.StatusServiceUnavailable, errors.New("人工审批未启用")
+	}
+
+	var request approval.Request
+	var err error
+	if approve {
+		request, err = s.approvals.Approve(id, account, decidedBy)
+	} else {
+		request, err = s.approvals.Reject(id, account, decidedBy, note)
+	}
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return request, http.StatusNotFound, err
+	case errors.Is(err, approval.ErrDecided):
+		return request, http.StatusConflict, err
+	}
+	return request, http.StatusOK, err
+}
+
+// approvalRequestToMap 将审批请求转换为API响应格式
+func approvalRequestToMap(request approval.Request) map[string]interface{} {
+	signal := request.Signal
+	data := map[string]interface{}{
+		"id":            request.ID,
+		"pair":          signal.Symbol,
+		"type":          signal.Direction,
+		"price":         signal.Price.InexactFloat64(),
+		"amount":        signal.Quantity.InexactFloat64(),
+		"notional":      request.Notional.InexactFloat64(),
+		"strategy":      signal.StrategyName,
+		"signalId":      signal.ID,
+		"clientOrderId": signal.ClientOrderID,
+		"reason":        request.Reason,
+		"status":        request.Status,
+		"createdAt":     request.CreatedAt.Unix(),
+		"expiresAt":     request.ExpiresAt.Unix(),
+	}
+	if !request.DecidedAt.IsZero() {
+		data["decidedAt"] = request.DecidedAt.Unix()
+		data["decidedBy"] = request.DecidedBy
+	}
+	if request.Note != "" {
+		data["note"] = request.Note
+	}
+	return data
+}
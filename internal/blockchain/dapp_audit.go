@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"autotransaction/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计日志查询的分页参数
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// SetAuditLog 设置审计日志，通过 /api/audit 查询
+func (s *DAppAPIServer) SetAuditLog(log *audit.Log) {
+	s.auditLog = log
+}
+
+// getAuditEvents 按条件分页查询当前账户的审计事件，最新的在前
+// 支持的查询参数: type, symbol, orderId, signalId, since, until (unix秒), offset, limit
+func (s *DAppAPIServer) getAuditEvents(c *gin.Context) {
+	if s.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "审计日志未启用"})
+		return
+	}
+
+	filter := audit.Filter{
+		Type:     c.Query("type"),
+		Account:  currentAccount(c),
+		Symbol:   c.Query("symbol"),
+		OrderID:  c.Query("orderId"),
+		SignalID: c.Query("signalId"),
+		Limit:    defaultAuditLimit,
+	}
+
+	var err error
+	if filter.Since, err = queryUnixTime(c, "since"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的since参数"})
+		return
+	}
+	if filter.Until, err = queryUnixTime(c, "until"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的until参数"})
+		return
+	}
+	if value := c.Query("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil || filter.Offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的offset参数"})
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的limit参数"})
+			return
+		}
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	events, total := s.auditLog.Query(filter)
+	c.JSON(http.StatusOK, gin.H{
+		"data":   events,
+		"total":  total,
+		"offset": filter.Offset,
+		"limit":  filter.Limit,
+	})
+}
+
+// queryUnixTime 解析unix秒格式的查询参数，参数为空时返回零值
+func queryUnixTime(c *gin.Context, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"autotransaction/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// API角色，权限依次递增
+const (
+	roleViewer = "viewer"
+	roleTrader = "trader"
+	roleAdmin  = "admin"
+)
+
+var roleLevels = map[string]int{
+	roleViewer: 1,
+	roleTrader: 2,
+	roleAdmin:  3,
+}
+
+const (
+	apiKeyHeader     = "X-API-Key"
+	apiKeyQueryParam = "api_key" // 浏览器无法为WebSocket设置请求头，WebSocket可通过查询参数携带密钥
+	apiKeyContextKey = "apiKey"
+)
+
+// apiKey 已加载的API密钥
+type apiKey struct {
+	name     string
+	key      string
+	role     string
+	accounts map[string]bool // 为空时不限制账户
+}
+
+// loadAPIKeys 加载配置的API密钥，缺少密钥或角色无效的条目会被忽略
+func loadAPIKeys(cfg config.AuthConfig) []apiKey {
+	keys := make([]apiKey, 0, len(cfg.APIKeys))
+	for _, keyCfg := range cfg.APIKeys {
+		key := keyCfg.Key
+		if keyCfg.KeyEnv != "" {
+			key = os.Getenv(keyCfg.KeyEnv)
+		}
+		if key == "" {
+			logrus.Warnf("API密钥 %s 未配置密钥，已忽略", keyCfg.Name)
+			continue
+		}
+		if _, ok := roleLevels[keyCfg.Role]; !ok {
+			logrus.Errorf("API密钥 %s 的角色 %q 无效，已忽略", keyCfg.Name, keyCfg.Role)
+			continue
+		}
+
+		accounts := make(map[string]bool)
+		for _, account := range keyCfg.Accounts {
+			accounts[account] = true
+		}
+		keys = append(keys, apiKey{name: keyCfg.Name, key: key, role: keyCfg.Role, accounts: accounts})
+	}
+
+	if cfg.Enabled && len(keys) == 0 && !cfg.SIWE.Enabled {
+		logrus.Warn("已启用API认证但没有可用的API密钥，所有请求都将被拒绝")
+	}
+	return keys
+}
+
+// authMiddleware 校验请求携带的API密钥或钱包登录的会话令牌，未启用认证时放行所有请求
+func (s *DAppAPIServer) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.cfg.System.Auth.Enabled || isPublicPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		credential := requestAPIKey(c)
+		key, ok := s.lookupAPIKey(credential)
+		if !ok {
+			key, ok = s.lookupSession(credential)
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少或无效的API密钥"})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// requireRole 要求请求的API密钥至少具有指定角色，未启用认证时放行
+func (s *DAppAPIServer) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.cfg.System.Auth.Enabled {
+			c.Next()
+			return
+		}
+
+		value, _ := c.Get(apiKeyContextKey)
+		key, ok := value.(apiKey)
+		if !ok || roleLevels[key.role] < roleLevels[role] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足，需要 " + role + " 角色"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// lookupAPIKey 查找与请求密钥匹配的API密钥
+func (s *DAppAPIServer) lookupAPIKey(candidate string) (apiKey, bool) {
+	if candidate == "" {
+		return apiKey{}, false
+	}
+	for _, key := range s.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key.key), []byte(candidate)) == 1 {
+			return key, true
+		}
+	}
+	return apiKey{}, false
+}
+
+// isPublicPath 判断是否为无需认证的路径，即钱包登录获取令牌的接口、健康检查探针和以共享密钥认证的外部信号 Webhook
+func isPublicPath(path string) bool {
+	switch path {
+	case "/api/auth/nonce", "/api/auth/verify", "/healthz", "/readyz", webhookPath:
+		return true
+	}
+	return false
+}
+
+// requestAPIKey 从 Authorization: Bearer、X-API-Key 请求头或 WebSocket 的查询参数中取出密钥或钱包登录的会话令牌
+func requestAPIKey(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if c.Request.URL.Path == "/ws" {
+		return c.Query(apiKeyQueryParam)
+	}
+	return ""
+}
+
+// boundAccount 按请求的认证身份（API密钥或钱包登录会话）确定可访问的账户，requested 为客户端指定的账户，可为空
+// 身份只绑定一个账户时使用该账户；绑定多个账户时在其中选择，未指定时使用默认账户（需在绑定范围内）；
+// 未绑定账户的身份只能访问默认账户，admin 角色不限制；未启用认证时没有身份，只能访问默认账户
+func boundAccount(c *gin.Context, requested string) (string, error) {
+	value, exists := c.Get(apiKeyContextKey)
+	if !exists {
+		if requested != "" && requested != config.DefaultAccountID {
+			return "", fmt.Errorf("未启用API认证时只能访问默认账户")
+		}
+		return config.DefaultAccountID, nil
+	}
+
+	key := value.(apiKey)
+	if len(key.accounts) == 0 {
+		if requested == "" {
+			return config.DefaultAccountID, nil
+		}
+		if requested != config.DefaultAccountID && key.role != roleAdmin {
+			return "", fmt.Errorf("%s 未绑定账户，只能访问默认账户", key.name)
+		}
+		return requested, nil
+	}
+
+	if requested == "" {
+		if len(key.accounts) == 1 {
+			for account := range key.accounts {
+				return account, nil
+			}
+		}
+		if key.accounts[config.DefaultAccountID] {
+			return config.DefaultAccountID, nil
+		}
+		return "", fmt.Errorf("%s 绑定了多个账户，需要通过 %s 请求头指定账户", key.name, accountHeader)
+	}
+	if !key.accounts[requested] {
+		return "", fmt.Errorf("%s 无权访问账户: %s", key.name, requested)
+	}
+	return requested, nil
+}
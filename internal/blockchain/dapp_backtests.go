@@ -0,0 +1,181 @@
+package blockchain
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"autotransaction/internal/backtest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetBacktestService 设置回测服务，通过 /api/backtests 提交回测和查询结果
+func (s *DAppAPIServer) SetBacktestService(backtests *backtest.Service) {
+	s.backtests = backtests
+}
+
+// backtestRequest 提交回测的请求体
+type backtestRequest struct {
+	Strategy       string                 `json:"strategy"`
+	Params         map[string]interface{} `json:"params"`
+	Pairs          []string               `json:"pairs"`
+	From           int64                  `json:"from"` // unix秒
+	To             int64                  `json:"to"`   // unix秒，为0时为当前时间
+	Interval       string                 `json:"interval"`
+	InitialCapital float64                `json:"initialCapital"`
+	PositionSize   float64                `json:"positionSize"`
+}
+
+// submitBacktest 提交回测，立即返回回测ID，回测在后台运行
+func (s *DAppAPIServer) submitBacktest(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	var request backtestRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	btRequest := backtest.Request{
+		Strategy:       request.Strategy,
+		Params:         request.Params,
+		Pairs:          request.Pairs,
+		Interval:       request.Interval,
+		InitialCapital: request.InitialCapital,
+		PositionSize:   request.PositionSize,
+	}
+	if request.From > 0 {
+		btRequest.From = time.Unix(request.From, 0)
+	}
+	if request.To > 0 {
+		btRequest.To = time.Unix(request.To, 0)
+	}
+
+	bt, err := s.backtests.Submit(currentAccount(c), btRequest)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, backtest.ErrQueueFull) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": bt})
+}
+
+// getBacktests 获取当前账户的回测列表，最新提交的在前，不包含回测结果
+func (s *DAppAPIServer) getBacktests(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	list := s.backtests.List(currentAccount(c))
+	for i := range list {
+		list[i].Result = nil
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// getBacktest 获取回测的状态和进度，完成后包含权益曲线、成交和绩效指标
+func (s *DAppAPIServer) getBacktest(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	bt, ok := s.backtests.Get(currentAccount(c), c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回测不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bt})
+}
+
+// optimizationRequest 提交参数优化的请求体
+type optimizationRequest struct {
+	backtestRequest
+	Grid       map[string][]interface{} `json:"grid"`   // 待优化的参数及其候选值，params 为固定参数
+	Method     string                   `json:"method"` // grid 或 random
+	Samples    int                      `json:"samples"`
+	Folds      int                      `json:"folds"`
+	TrainRatio float64                  `json:"trainRatio"`
+	Objective  string                   `json:"objective"` // sharpe 或 return
+}
+
+// submitOptimization 提交参数优化，立即返回优化ID，优化在后台运行
+func (s *DAppAPIServer) submitOptimization(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	var request optimizationRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	optRequest := backtest.OptimizeRequest{
+		Strategy:       request.Strategy,
+		Params:         request.Params,
+		Grid:           request.Grid,
+		Method:         request.Method,
+		Samples:        request.Samples,
+		Pairs:          request.Pairs,
+		Interval:       request.Interval,
+		InitialCapital: request.InitialCapital,
+		PositionSize:   request.PositionSize,
+		Folds:          request.Folds,
+		TrainRatio:     request.TrainRatio,
+		Objective:      request.Objective,
+	}
+	if request.From > 0 {
+		optRequest.From = time.Unix(request.From, 0)
+	}
+	if request.To > 0 {
+		optRequest.To = time.Unix(request.To, 0)
+	}
+
+	optimization, err := s.backtests.SubmitOptimization(currentAccount(c), optRequest)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, backtest.ErrQueueFull) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": optimization})
+}
+
+// getOptimizations 获取当前账户的参数优化列表，最新提交的在前，不包含优化结果
+func (s *DAppAPIServer) getOptimizations(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	list := s.backtests.ListOptimizations(currentAccount(c))
+	for i := range list {
+		list[i].Result = nil
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// getOptimization 获取参数优化的状态和进度，完成后包含各交易对的最优参数和各前推窗口的验证结果
+func (s *DAppAPIServer) getOptimization(c *gin.Context) {
+	if s.backtests == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "回测服务不可用"})
+		return
+	}
+
+	optimization, ok := s.backtests.GetOptimization(currentAccount(c), c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "参数优化不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": optimization})
+}
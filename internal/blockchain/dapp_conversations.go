@@ -0,0 +1,209 @@
+package blockchain
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"autotransaction/internal/llm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReferencedTrades 每个会话保留的引用交易数量，超出时丢弃最早引用的交易
+const maxReferencedTrades = 10
+
+// askRequest 问答接口的请求体
+type askRequest struct {
+	Question  string                 `json:"question"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+	SessionID string                 `json:"sessionId,omitempty"` // 为空时开始新的会话
+	TradeIDs  []string               `json:"tradeIds,omitempty"`  // 本次提问引用的交易，会话中后续提问仍会提供给LLM
+}
+
+// askSession 一次提问所需的会话信息
+type askSession struct {
+	id       string
+	account  string
+	context  map[string]interface{}
+	history  []llm.ChatMessage
+	tradeIDs []string
+}
+
+// prepareQuestion 解析提问并加载会话，将会话引用的交易加入上下文，失败时已写入错误响应
+func (s *DAppAPIServer) prepareQuestion(c *gin.Context, request *askRequest) (*askSession, bool) {
+	if err := c.BindJSON(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return nil, false
+	}
+	if strings.TrimSpace(request.Question) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "问题不能为空"})
+		return nil, false
+	}
+
+	session := &askSession{id: request.SessionID, account: currentAccount(c), context: request.Context}
+	if session.id != "" {
+		conversation, ok := s.conversations.Get(session.account, session.id)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在或已过期"})
+			return nil, false
+		}
+		session.history = s.conversations.History(conversation)
+		session.tradeIDs = conversation.TradeIDs
+	} else {
+		id, err := randomHex(16)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成会话ID失败"})
+			return nil, false
+		}
+		session.id = id
+	}
+
+	for _, id := range request.TradeIDs {
+		if _, ok := s.findTrade(session.account, id); !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "交易不存在: " + id})
+			return nil, false
+		}
+	}
+	session.tradeIDs = mergeTradeIDs(session.tradeIDs, request.TradeIDs)
+
+	trades := make([]map[string]interface{}, 0, len(session.tradeIDs))
+	for _, id := range session.tradeIDs {
+		if trade, ok := s.findTrade(session.account, id); ok {
+			trades = append(trades, trade)
+		}
+	}
+	if len(trades) > 0 {
+		if session.context == nil {
+			session.context = make(map[string]interface{})
+		}
+		session.context["referenced_trades"] = trades
+	}
+	return session, true
+}
+
+// mergeTradeIDs 将本次引用的交易追加到会话引用的交易之后，去掉重复并只保留最近引用的交易
+func mergeTradeIDs(existing, added []string) []string {
+	merged := make([]string, 0, len(existing)+len(added))
+	for _, id := range append(append([]string(nil), existing...), added...) {
+		for i, seen := range merged {
+			if seen == id {
+				merged = append(merged[:i], merged[i+1:]...)
+				break
+			}
+		}
+		merged = append(merged, id)
+	}
+	if len(merged) > maxReferencedTrades {
+		merged = merged[len(merged)-maxReferencedTrades:]
+	}
+	return merged
+}
+
+// answerQuestion 回答用户问题，带上 sessionId 时LLM会参考同一会话中之前的问答和引用的交易
+func (s *DAppAPIServer) answerQuestion(c *gin.Context) {
+	var request askRequest
+	session, ok := s.prepareQuestion(c, &request)
+	if !ok {
+		return
+	}
+
+	llmService, ok := s.llmController.engineService(c)
+	if !ok {
+		return
+	}
+	llmService = llmService.WithTools(s.liveDataTools(session.account))
+
+	response, err := llmService.AnswerQuestion(request.Question, session.context, session.history)
+	if err != nil {
+		logrus.Errorf("LLM回答问题失败: %v", err)
+		c.JSON(llmErrorStatus(err), gin.H{
+			"error": "回答问题失败: " + err.Error(),
+		})
+		return
+	}
+	s.conversations.Append(session.account, session.id, llm.Turn{
+		Question:  request.Question,
+		Answer:    response.Completion,
+		Timestamp: time.Now(),
+	}, session.tradeIDs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"sessionId": session.id,
+	})
+}
+
+// answerQuestionStream 以SSE流式返回问题的回答，会话与 answerQuestion 相同
+// 事件: session 携带会话ID，后续提问时带上；chunk 为增量文本；restart 表示流式请求失败已改为非流式重新生成，
+// 客户端应清空已显示内容；done 携带完整回答；error 携带错误信息
+func (s *DAppAPIServer) answerQuestionStream(c *gin.Context) {
+	var request askRequest
+	session, ok := s.prepareQuestion(c, &request)
+	if !ok {
+		return
+	}
+
+	llmService, ok := s.llmController.engineService(c)
+	if !ok {
+		return
+	}
+	llmService = llmService.WithTools(s.liveDataTools(session.account))
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.SSEvent("session", session.id)
+	c.Writer.Flush()
+
+	response, err := llmService.AnswerQuestionStream(request.Question, session.context, session.history, llm.StreamHandler{
+		OnChunk: func(chunk string) {
+			c.SSEvent("chunk", chunk)
+			c.Writer.Flush()
+		},
+		OnRestart: func() {
+			c.SSEvent("restart", "")
+			c.Writer.Flush()
+		},
+	})
+	if err != nil {
+		logrus.Errorf("LLM流式回答问题失败: %v", err)
+		c.SSEvent("error", "回答问题失败: "+err.Error())
+		c.Writer.Flush()
+		return
+	}
+	s.conversations.Append(session.account, session.id, llm.Turn{
+		Question:  request.Question,
+		Answer:    response.Completion,
+		Timestamp: time.Now(),
+	}, session.tradeIDs)
+
+	c.SSEvent("done", response.Completion)
+	c.Writer.Flush()
+}
+
+// getConversation 获取当前账户的问答会话
+func (s *DAppAPIServer) getConversation(c *gin.Context) {
+	conversation, ok := s.conversations.Get(currentAccount(c), c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在或已过期"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": conversation})
+}
+
+// deleteConversation 结束当前账户的问答会话
+func (s *DAppAPIServer) deleteConversation(c *gin.Context) {
+	if !s.conversations.Delete(currentAccount(c), c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在或已过期"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"id":      c.Param("id"),
+			"message": "会话已删除",
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"fmt"
+	"net/http"
+
+	"autotransaction/internal/risk"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// haltTrading 紧急停止交易，立即拒绝新信号，可选撤销未完成订单并平掉所有持仓
+func (s *DAppAPIServer) haltTrading(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+
+	var body struct {
+		Reason       string `json:"reason"`
+		CancelOrders bool   `json:"cancelOrders"`
+		Flatten      bool   `json:"flatten"`
+	}
+	// 请求体可为空
+	_ = c.ShouldBindJSON(&body)
+	reason := body.Reason
+	if reason == "" {
+		reason = "手动紧急停止"
+	}
+	if operator := requestKeyName(c); operator != "" {
+		reason = fmt.Sprintf("%s (操作者: %s)", reason, operator)
+	}
+
+	// 先停止接收新信号，再撤单和平仓，避免撤单期间产生新订单
+	status := s.riskManager.Halt(reason)
+
+	canceledOrders, droppedJobs := 0, 0
+	if body.CancelOrders {
+		if s.exchangeExecutor != nil {
+			canceledOrders, droppedJobs = s.exchangeExecutor.CancelOpenOrders(reason)
+		}
+		if s.executor != nil {
+			canceledOrders += s.executor.CancelOpenOrders(reason)
+		}
+	}
+
+	flattened := 0
+	if body.Flatten {
+		flattened = s.riskManager.FlattenAll("紧急停止")
+	}
+	logrus.Warnf("紧急停止: 撤销 %d 个订单，丢弃 %d 个排队任务，平仓 %d 个持仓", canceledOrders, droppedJobs, flattened)
+
+	data := haltStatusToMap(status)
+	data["canceledOrders"] = canceledOrders
+	data["droppedJobs"] = droppedJobs
+	data["flattenedPositions"] = flattened
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// resumeTrading 解除紧急停止，恢复处理新信号
+func (s *DAppAPIServer) resumeTrading(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": haltStatusToMap(s.riskManager.Resume())})
+}
+
+// getHaltStatus 获取紧急停止状态
+func (s *DAppAPIServer) getHaltStatus(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": haltStatusToMap(s.riskManager.GetHaltStatus())})
+}
+
+// haltStatusToMap 将紧急停止状态转换为API响应格式
+func haltStatusToMap(status risk.HaltStatus) map[string]interface{} {
+	data := map[string]interface{}{
+		"halted": status.Halted,
+		"reason": status.Reason,
+	}
+	if !status.HaltedAt.IsZero() {
+		data["haltedAt"] = status.HaltedAt.Unix()
+	}
+	if !status.ResumedAt.IsZero() {
+		data["resumedAt"] = status.ResumedAt.Unix()
+	}
+	return data
+}
@@ -0,0 +1,56 @@
+package blockchain
+
+import (
+	"net/http"
+	"time"
+
+	"autotransaction/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetHealth 设置组件健康检查，用于 /readyz 和 /api/status
+func (s *DAppAPIServer) SetHealth(registry *health.Registry) {
+	s.health = registry
+}
+
+// handleLiveness 存活探针，进程能处理请求即返回200，不检查外部依赖，避免依赖故障时进程被反复重启
+func (s *DAppAPIServer) handleLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": health.StatusOK,
+		"uptime": int64(time.Since(s.startedAt).Seconds()), // 秒
+	})
+}
+
+// handleReadiness 就绪探针，返回各组件的检查结果；关键组件不可用时返回503，降级时仍返回200继续接收流量
+func (s *DAppAPIServer) handleReadiness(c *gin.Context) {
+	if s.health == nil {
+		c.JSON(http.StatusOK, health.Report{Status: health.StatusOK, CheckedAt: time.Now(), Components: map[string]health.Result{}})
+		return
+	}
+
+	report := s.health.Check(c.Request.Context())
+	code := http.StatusOK
+	if report.Status == health.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// healthSummary 返回整体状态和各组件状态，未设置健康检查时为nil
+func (s *DAppAPIServer) healthSummary(c *gin.Context) map[string]interface{} {
+	if s.health == nil {
+		return nil
+	}
+
+	report := s.health.Check(c.Request.Context())
+	components := make(map[string]string, len(report.Components))
+	for name, result := range report.Components {
+		components[name] = result.Status
+	}
+	return map[string]interface{}{
+		"status":     report.Status,
+		"checkedAt":  report.CheckedAt,
+		"components": components,
+	}
+}
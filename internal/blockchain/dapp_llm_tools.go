@@ -0,0 +1,221 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"autotransaction/internal/llm"
+	"autotransaction/internal/market"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// LLM工具返回的数量限制
+const (
+	defaultToolTrades  = 20
+	maxToolTrades      = 100
+	defaultToolCandles = 50
+	maxToolCandles     = 200
+)
+
+// SetMarketDataService 设置交易所行情服务，LLM工具据此查询交易所交易对的K线
+func (s *DAppAPIServer) SetMarketDataService(marketData *market.MarketDataService) {
+	s.exchangeMarket = marketData
+}
+
+// liveDataTools 返回LLM可调用的工具，用于查询账户的实时持仓、成交和系统的K线、风险限制
+func (s *DAppAPIServer) liveDataTools(account string) []llm.Tool {
+	return []llm.Tool{
+		{
+			Name:        "getPositions",
+			Description: "获取当前账户的所有持仓，包括交易所和链上持仓的数量、均价和未实现盈亏",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			Handler: func(args json.RawMessage) (interface{}, error) {
+				return s.accountPositions(account), nil
+			},
+		},
+		{
+			Name:        "getRecentTrades",
+			Description: "获取当前账户最近的交易（订单），最新的在前",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pair":  map[string]interface{}{"type": "string", "description": "只返回该交易对的交易，如 BTC/USDT，为空时返回所有交易对"},
+					"limit": map[string]interface{}{"type": "integer", "description": fmt.Sprintf("返回的数量，默认 %d，最多 %d", defaultToolTrades, maxToolTrades)},
+				},
+			},
+			Handler: func(args json.RawMessage) (interface{}, error) {
+				var params struct {
+					Pair  string `json:"pair"`
+					Limit int    `json:"limit"`
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return nil, fmt.Errorf("无效的参数: %v", err)
+				}
+				return s.recentTrades(account, params.Pair, params.Limit), nil
+			},
+		},
+		{
+			Name:        "getCandles",
+			Description: "获取交易对的历史K线，按时间从早到晚排列",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pair":     map[string]interface{}{"type": "string", "description": "交易对，如 BTC/USDT"},
+					"interval": map[string]interface{}{"type": "string", "description": "K线周期，如 1m、5m、1h、1d，默认 1h"},
+					"limit":    map[string]interface{}{"type": "integer", "description": fmt.Sprintf("返回的K线数量，默认 %d，最多 %d", defaultToolCandles, maxToolCandles)},
+				},
+				"required": []string{"pair"},
+			},
+			Handler: func(args json.RawMessage) (interface{}, error) {
+				var params struct {
+					Pair     string `json:"pair"`
+					Interval string `json:"interval"`
+					Limit    int    `json:"limit"`
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return nil, fmt.Errorf("无效的参数: %v", err)
+				}
+				return s.candles(params.Pair, params.Interval, params.Limit)
+			},
+		},
+		{
+			Name:        "getRiskLimits",
+			Description: "获取风险管理的限制，包括单笔仓位上限、止损止盈、最大持仓数、敞口限制和每日亏损熔断状态",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			Handler: func(args json.RawMessage) (interface{}, error) {
+				return s.riskLimits(account), nil
+			},
+		},
+	}
+}
+
+// recentTrades 返回账户最近的交易，最新的在前
+func (s *DAppAPIServer) recentTrades(account, pair string, limit int) []map[string]interface{} {
+	if limit <= 0 {
+		limit = defaultToolTrades
+	}
+	if limit > maxToolTrades {
+		limit = maxToolTrades
+	}
+
+	trades := make([]map[string]interface{}, 0)
+	for _, trade := range s.accountTrades(account, tradeFilter{}) {
+		if pair == "" || trade["pair"] == pair {
+			trades = append(trades, trade)
+		}
+	}
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i]["timestamp"].(int64) > trades[j]["timestamp"].(int64)
+	})
+	if len(trades) > limit {
+		trades = trades[:limit]
+	}
+	return trades
+}
+
+// candles 返回已配置交易对的K线，链上交易对从区块链行情服务获取
+func (s *DAppAPIServer) candles(pair, interval string, limit int) ([]map[string]interface{}, error) {
+	if !s.isTradingPair(pair) {
+		return nil, fmt.Errorf("未配置的交易对: %s", pair)
+	}
+	if interval == "" {
+		interval = "1h"
+	}
+	if limit <= 0 {
+		limit = defaultToolCandles
+	}
+	if limit > maxToolCandles {
+		limit = maxToolCandles
+	}
+
+	var data []market.MarketData
+	var err error
+	switch {
+	case s.isBlockchainPair(pair) && s.marketService != nil:
+		data, err = s.marketService.GetHistoricalData(pair, "", interval, limit)
+	case !s.isBlockchainPair(pair) && s.exchangeMarket != nil:
+		data, err = s.exchangeMarket.GetHistoricalData(pair, interval, limit)
+	default:
+		return nil, fmt.Errorf("%s 的行情服务不可用", pair)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的K线失败: %v", pair, err)
+	}
+
+	candles := make([]map[string]interface{}, 0, len(data))
+	for _, candle := range data {
+		candles = append(candles, map[string]interface{}{
+			"timestamp": candle.Timestamp.Unix(),
+			"open":      candle.Open.InexactFloat64(),
+			"high":      candle.High.InexactFloat64(),
+			"low":       candle.Low.InexactFloat64(),
+			"close":     candle.Close.InexactFloat64(),
+			"volume":    candle.Volume.InexactFloat64(),
+		})
+	}
+	return candles, nil
+}
+
+// isTradingPair 判断交易对是否已配置
+func (s *DAppAPIServer) isTradingPair(symbol string) bool {
+	for _, pair := range s.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// riskLimits 返回风险管理的配置限制和账户的当日熔断状态
+func (s *DAppAPIServer) riskLimits(account string) map[string]interface{} {
+	riskCfg := s.cfg.Current().Risk
+	limits := map[string]interface{}{
+		"maxPositionSize":   riskCfg.MaxPositionSize,
+		"stopLoss":          riskCfg.StopLoss,
+		"takeProfit":        riskCfg.TakeProfit,
+		"maxOpenPositions":  riskCfg.MaxOpenPositions,
+		"slippageTolerance": riskCfg.SlippageTolerance,
+		"slippageAction":    riskCfg.SlippageAction,
+		"exposureLimits":    riskCfg.ExposureLimits,
+		"riskCapital":       riskCfg.RiskCapital,
+		"riskBudget":        riskCfg.RiskBudget,
+	}
+	if s.riskManager != nil {
+		limits["circuitBreaker"] = circuitBreakerToMap(s.riskManager.GetCircuitBreakerStatus(account))
+	}
+	return limits
+}
+
+// analyzeMarket 使用最新行情分析市场，启用工具调用时LLM可进一步查询K线、持仓和风险限制
+func (s *DAppAPIServer) analyzeMarket(c *gin.Context) {
+	llmService, ok := s.llmController.engineService(c)
+	if !ok {
+		return
+	}
+
+	tickers := s.getLatestMarketData()
+	marketData := make(map[string]interface{}, len(tickers))
+	for _, ticker := range tickers {
+		marketData[ticker.Pair] = map[string]interface{}{
+			"price":     ticker.Price.InexactFloat64(),
+			"change24h": ticker.Change24h.InexactFloat64(),
+		}
+	}
+
+	response, err := llmService.WithTools(s.liveDataTools(currentAccount(c))).AnalyzeMarket(marketData)
+	if err != nil {
+		logrus.Errorf("LLM市场分析失败: %v", err)
+		c.JSON(llmErrorStatus(err), gin.H{
+			"error": "分析市场失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
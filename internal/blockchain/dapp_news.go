@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"net/http"
+	"strconv"
+
+	"autotransaction/internal/news"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultNewsLimit 新闻列表默认返回的数量
+const defaultNewsLimit = 50
+
+// SetNewsService 设置新闻服务，同时用于LLM的新闻和情绪分析
+func (s *DAppAPIServer) SetNewsService(newsService *news.Service) {
+	s.news = newsService
+	if s.llmController != nil {
+		s.llmController.SetNewsService(newsService)
+	}
+}
+
+// getNews 获取抓取到的最新新闻，最新发布的在前
+// 支持的查询参数: asset (如 BTC)，limit (默认50)
+func (s *DAppAPIServer) getNews(c *gin.Context) {
+	if s.news == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "新闻源未启用"})
+		return
+	}
+
+	limit := defaultNewsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的limit参数"})
+			return
+		}
+		limit = parsed
+	}
+
+	data := make([]map[string]interface{}, 0)
+	for _, article := range s.news.Latest(c.Query("asset"), limit) {
+		data = append(data, newsArticleToMap(article))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"assets":   s.news.Assets(),
+			"articles": data,
+		},
+	})
+}
+
+// newsArticleToMap 将新闻转换为API响应格式
+func newsArticleToMap(article news.Article) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          article.ID,
+		"title":       article.Title,
+		"content":     article.Content,
+		"source":      article.Source,
+		"feed":        article.Feed,
+		"url":         article.URL,
+		"publishedAt": article.PublishedAt.Unix(),
+		"assets":      article.Assets,
+	}
+}
@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"net/http"
+
+	"autotransaction/config"
+	"autotransaction/internal/execution"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobQueue 交易所或区块链交易执行器的订单队列
+type jobQueue interface {
+	QueuedJobs() []execution.Job
+	DeadLetters() []execution.Job
+	RetryDeadLetter(id string) (execution.Job, error)
+}
+
+// jobQueues 返回可用的订单队列：交易所订单队列和区块链订单队列
+func (s *DAppAPIServer) jobQueues() []jobQueue {
+	queues := make([]jobQueue, 0, 2)
+	if s.exchangeExecutor != nil {
+		queues = append(queues, s.exchangeExecutor)
+	}
+	if s.executor != nil {
+		queues = append(queues, s.executor)
+	}
+	return queues
+}
+
+// getOrderQueue 获取当前账户在交易所和区块链订单队列中等待执行的任务和死信队列中的任务
+func (s *DAppAPIServer) getOrderQueue(c *gin.Context) {
+	queues := s.jobQueues()
+	if len(queues) == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "交易执行器不可用"})
+		return
+	}
+
+	account := currentAccount(c)
+	queued := make([]map[string]interface{}, 0)
+	deadLetters := make([]map[string]interface{}, 0)
+	for _, queue := range queues {
+		for _, job := range queue.QueuedJobs() {
+			if jobAccount(job) == account {
+				queued = append(queued, jobToMap(job))
+			}
+		}
+		for _, job := range queue.DeadLetters() {
+			if jobAccount(job) == account {
+				deadLetters = append(deadLetters, jobToMap(job))
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"queued":      queued,
+			"deadLetters": deadLetters,
+		},
+	})
+}
+
+// retryDeadLetter 将死信队列中的任务重新放入其所在的订单队列
+func (s *DAppAPIServer) retryDeadLetter(c *gin.Context) {
+	queues := s.jobQueues()
+	if len(queues) == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "交易执行器不可用"})
+		return
+	}
+
+	id := c.Param("id")
+	for _, queue := range queues {
+		for _, job := range queue.DeadLetters() {
+			if job.ID != id || jobAccount(job) != currentAccount(c) {
+				continue
+			}
+			job, err := queue.RetryDeadLetter(id)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{"data": jobToMap(job)})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+}
+
+// jobAccount 返回任务所属账户，信号未指定账户时为默认账户
+func jobAccount(job execution.Job) string {
+	if job.Signal.Account == "" {
+		return config.DefaultAccountID
+	}
+	return job.Signal.Account
+}
+
+// jobToMap 将订单任务转换为API响应
+func jobToMap(job execution.Job) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":          job.ID,
+		"pair":        job.Signal.Symbol,
+		"type":        job.Signal.Direction,
+		"amount":      job.Signal.Quantity.InexactFloat64(),
+		"price":       job.Signal.Price.InexactFloat64(),
+		"strategy":    job.Signal.StrategyName,
+		"signalId":    job.Signal.ID,
+		"forced":      job.Signal.Forced,
+		"priority":    job.Priority,
+		"attempts":    job.Attempts,
+		"lastError":   job.LastError,
+		"enqueuedAt":  job.EnqueuedAt.Unix(),
+		"nextAttempt": job.NextAttempt.Unix(),
+	}
+	if !job.FailedAt.IsZero() {
+		result["failedAt"] = job.FailedAt.Unix()
+	}
+	return result
+}
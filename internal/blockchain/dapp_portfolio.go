@@ -0,0 +1,85 @@
+package blockchain
+
+import (
+	"net/http"
+	"strings"
+
+	"autotransaction/internal/portfolio"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetValuationService 设置账户估值服务，通过 /api/portfolio 查询，LLM投资组合摘要也使用其数据
+func (s *DAppAPIServer) SetValuationService(valuation *portfolio.ValuationService) {
+	s.valuation = valuation
+	if s.llmController != nil {
+		s.llmController.SetValuationService(valuation)
+	}
+}
+
+// getPortfolio 获取当前账户按实时价格的估值、盈亏、资产配置和估值历史
+// 支持的查询参数: since (unix秒)，只返回该时间之后的估值快照
+func (s *DAppAPIServer) getPortfolio(c *gin.Context) {
+	if s.valuation == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "账户估值服务不可用"})
+		return
+	}
+
+	since, err := queryUnixTime(c, "since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的since参数"})
+		return
+	}
+
+	account := currentAccount(c)
+	data := valuationToMap(s.valuation.Value(account))
+	history := make([]map[string]interface{}, 0)
+	for _, point := range s.valuation.History(account, since) {
+		history = append(history, map[string]interface{}{
+			"timestamp":      point.Timestamp.Unix(),
+			"equity":         point.Equity.InexactFloat64(),
+			"positionsValue": point.PositionsValue.InexactFloat64(),
+			"unrealizedPnl":  point.UnrealizedPnL.InexactFloat64(),
+			"realizedPnl":    point.RealizedPnL.InexactFloat64(),
+		})
+	}
+	data["history"] = history
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+// valuationToMap 将账户估值转换为API响应格式，配置比例以百分比表示
+func valuationToMap(valuation portfolio.Valuation) map[string]interface{} {
+	assets := make([]map[string]interface{}, 0, len(valuation.Positions))
+	for _, position := range valuation.Positions {
+		asset := map[string]interface{}{
+			"asset":         strings.Split(position.Symbol, "/")[0],
+			"pair":          position.Symbol,
+			"amount":        position.Quantity.InexactFloat64(),
+			"entryPrice":    position.EntryPrice.InexactFloat64(),
+			"currentPrice":  position.Price.InexactFloat64(),
+			"value":         position.Value.InexactFloat64(),
+			"unrealizedPnl": position.UnrealizedPnL.InexactFloat64(),
+			"allocation":    position.Allocation * 100,
+		}
+		if position.Side != "" {
+			asset["side"] = position.Side
+		}
+		assets = append(assets, asset)
+	}
+
+	return map[string]interface{}{
+		"account":        valuation.Account,
+		"timestamp":      valuation.Timestamp.Unix(),
+		"cash":           valuation.Cash.InexactFloat64(),
+		"cashAllocation": valuation.CashAllocation * 100,
+		"positionsValue": valuation.PositionsValue.InexactFloat64(),
+		"totalValue":     valuation.Equity.InexactFloat64(),
+		"unrealizedPnl":  valuation.UnrealizedPnL.InexactFloat64(),
+		"realizedPnl":    valuation.RealizedPnL.InexactFloat64(),
+		"totalPnl":       valuation.UnrealizedPnL.Add(valuation.RealizedPnL).InexactFloat64(),
+		"assets":         assets,
+	}
+}
@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"net/http"
+	"strconv"
+
+	"autotransaction/internal/execution"
+	"autotransaction/internal/strategy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunRequested 判断请求是否为模拟下单（dry-run），请求体的 dryRun 字段或 dryRun 查询参数为 true 时成立
+func dryRunRequested(c *gin.Context, bodyFlag bool) bool {
+	if bodyFlag {
+		return true
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	return dryRun
+}
+
+// previewSignal 模拟执行交易信号，返回风险检查、审批、预计成交价格、手续费和gas估算，不下单
+func (s *DAppAPIServer) previewSignal(signal strategy.Signal) (int, gin.H) {
+	data := map[string]interface{}{
+		"dryRun":           true,
+		"pair":             signal.Symbol,
+		"type":             signal.Direction,
+		"amount":           signal.Quantity.InexactFloat64(),
+		"price":            signal.Price.InexactFloat64(),
+		"requiresApproval": false,
+	}
+	if reason := s.approvals.Requires(signal); reason != "" {
+		data["requiresApproval"] = true
+		data["approvalReason"] = reason
+	}
+
+	if s.isBlockchainPair(signal.Symbol) {
+		if s.executor == nil {
+			return http.StatusServiceUnavailable, gin.H{"error": "区块链交易执行器不可用"}
+		}
+		preview := s.executor.PreviewSignal(signal)
+		data["venue"] = strategy.VenueBlockchain
+		data["network"] = preview.Order.Network
+		data["wallet"] = preview.Order.Wallet
+		data["accepted"] = preview.Accepted()
+		data["riskError"] = preview.RiskError
+		data["error"] = preview.Error
+		data["warnings"] = preview.Warnings
+		data["expectedPrice"] = preview.ExpectedPrice.InexactFloat64()
+		data["notional"] = preview.Notional.InexactFloat64()
+		data["lpFee"] = preview.LPFee.InexactFloat64()
+		data["gasLimit"] = preview.GasLimit
+		data["gasCost"] = preview.GasCost.InexactFloat64()
+		data["fee"] = preview.LPFee.Add(preview.GasCost).InexactFloat64()
+		if preview.GasPrice != nil {
+			data["gasPrice"] = formatGwei(preview.GasPrice)
+		}
+		if preview.Impact != nil {
+			data["priceImpact"] = map[string]interface{}{
+				"pool":        preview.Impact.Pool,
+				"liquidity":   preview.Impact.Liquidity.InexactFloat64(),
+				"impact":      preview.Impact.Impact.InexactFloat64(),
+				"splits":      preview.Impact.Splits,
+				"chunkImpact": preview.Impact.ChunkImpact.InexactFloat64(),
+			}
+		}
+		return http.StatusOK, gin.H{"data": data}
+	}
+
+	if s.exchangeExecutor == nil {
+		return http.StatusServiceUnavailable, gin.H{"error": "交易执行器不可用"}
+	}
+	preview := s.exchangeExecutor.PreviewSignal(signal)
+	data["venue"] = strategy.VenueExchange
+	data["accepted"] = preview.Accepted()
+	data["riskError"] = preview.RiskError
+	data["error"] = preview.Error
+	data["order"] = previewOrderToMap(preview.Order)
+	data["expectedPrice"] = preview.ExpectedPrice.InexactFloat64()
+	data["notional"] = preview.Notional.InexactFloat64()
+	data["fee"] = preview.Fee.InexactFloat64()
+	return http.StatusOK, gin.H{"data": data}
+}
+
+// previewOrderToMap 将模拟下单调整后的订单转换为API响应格式，订单未实际创建，不含订单ID和状态
+func previewOrderToMap(order execution.Order) map[string]interface{} {
+	data := exchangeOrderToMap(order)
+	delete(data, "id")
+	delete(data, "status")
+	delete(data, "filledAmount")
+	delete(data, "avgFillPrice")
+	return data
+}
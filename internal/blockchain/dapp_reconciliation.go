@@ -0,0 +1,32 @@
+package blockchain
+
+import (
+	"net/http"
+
+	"autotransaction/internal/reconcile"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetReconciliationService 设置持仓对账服务
+func (s *DAppAPIServer) SetReconciliationService(reconciliation *reconcile.Service) {
+	s.reconciliation = reconciliation
+}
+
+// getReconciliation 获取各场所最近一次对账的结果和发现的持仓差异
+func (s *DAppAPIServer) getReconciliation(c *gin.Context) {
+	if s.reconciliation == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "持仓对账不可用"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s.reconciliation.Statuses()})
+}
+
+// runReconciliation 立即执行一次对账并返回结果
+func (s *DAppAPIServer) runReconciliation(c *gin.Context) {
+	if s.reconciliation == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "持仓对账不可用"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s.reconciliation.Run()})
+}
@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"net/http"
+
+	"autotransaction/internal/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getCircuitBreaker 获取当前账户的每日亏损熔断器状态
+func (s *DAppAPIServer) getCircuitBreaker(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": circuitBreakerToMap(s.riskManager.GetCircuitBreakerStatus(currentAccount(c)))})
+}
+
+// getCircuitBreakers 获取所有账户的每日亏损熔断器状态
+func (s *DAppAPIServer) getCircuitBreakers(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, status := range s.riskManager.GetCircuitBreakerStatuses() {
+		result = append(result, circuitBreakerToMap(status))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// circuitBreakerToMap 将熔断器状态转换为API响应格式
+func circuitBreakerToMap(status risk.CircuitBreakerStatus) map[string]interface{} {
+	data := map[string]interface{}{
+		"account":       status.Account,
+		"enabled":       status.Enabled,
+		"day":           status.Day,
+		"realizedPnL":   status.RealizedPnL.InexactFloat64(),
+		"unrealizedPnL": status.UnrealizedPnL.InexactFloat64(),
+		"dailyPnL":      status.DailyPnL.InexactFloat64(),
+		"maxDailyLoss":  status.MaxDailyLoss.InexactFloat64(),
+		"tripped":       status.Tripped,
+		"reason":        status.Reason,
+	}
+	if status.Tripped {
+		data["trippedAt"] = status.TrippedAt.Unix()
+	}
+	return data
+}
+
+// resetCircuitBreaker 手动解除当前账户的每日亏损熔断
+func (s *DAppAPIServer) resetCircuitBreaker(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+
+	s.riskManager.ResetCircuitBreaker(currentAccount(c))
+	s.getCircuitBreaker(c)
+}
+
+// getRejections 获取当前账户最近被风险检查拒绝的信号及原因
+func (s *DAppAPIServer) getRejections(c *gin.Context) {
+	if s.riskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "风险管理器不可用"})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, rejection := range s.riskManager.GetRejections(currentAccount(c)) {
+		result = append(result, map[string]interface{}{
+			"pair":      rejection.Symbol,
+			"type":      rejection.Direction,
+			"strategy":  rejection.Strategy,
+			"reason":    rejection.Reason,
+			"timestamp": rejection.Timestamp.Unix(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,99 @@
+package blockchain
+
+import (
+	"net/http"
+	"time"
+
+	"autotransaction/internal/sentiment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSentimentService 设置新闻情绪评分服务，通过 /api/sentiment 查询
+func (s *DAppAPIServer) SetSentimentService(sentimentService *sentiment.Service) {
+	s.sentiment = sentimentService
+}
+
+// getSentiment 获取各关注资产在时间窗口内的聚合情绪
+func (s *DAppAPIServer) getSentiment(c *gin.Context) {
+	if s.sentiment == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "新闻情绪评分未启用"})
+		return
+	}
+
+	data := make([]map[string]interface{}, 0)
+	for _, asset := range s.sentiment.Assets() {
+		item := map[string]interface{}{
+			"asset":   asset,
+			"score":   nil,
+			"samples": 0,
+			"latest":  nil,
+		}
+		if aggregate, ok := s.sentiment.Aggregate(asset); ok {
+			item["score"] = aggregate.Score
+			item["samples"] = aggregate.Samples
+			item["latest"] = sentimentPointToMap(aggregate.Latest)
+		}
+		data = append(data, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// getSentimentHistory 获取资产的情绪分数时间序列，按时间升序
+// 支持的查询参数: since (unix秒，默认最近7天)
+func (s *DAppAPIServer) getSentimentHistory(c *gin.Context) {
+	if s.sentiment == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "新闻情绪评分未启用"})
+		return
+	}
+
+	since, err := queryUnixTime(c, "since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的since参数"})
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().AddDate(0, 0, -7)
+	}
+
+	data := make([]map[string]interface{}, 0)
+	for _, point := range s.sentiment.History(c.Param("asset"), since) {
+		data = append(data, sentimentPointToMap(point))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// refreshSentiment 立即使用最新新闻评分
+func (s *DAppAPIServer) refreshSentiment(c *gin.Context) {
+	if s.sentiment == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "新闻情绪评分未启用"})
+		return
+	}
+
+	points, err := s.sentiment.Refresh()
+	if err != nil {
+		c.JSON(llmErrorStatus(err), gin.H{"error": "新闻情绪评分失败: " + err.Error()})
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(points))
+	for _, point := range points {
+		data = append(data, sentimentPointToMap(point))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// sentimentPointToMap 将情绪评分转换为API响应格式
+func sentimentPointToMap(point sentiment.Point) map[string]interface{} {
+	return map[string]interface{}{
+		"asset":      point.Asset,
+		"score":      point.Score,
+		"confidence": point.Confidence,
+		"summary":    point.Summary,
+		"articles":   point.Articles,
+		"timestamp":  point.Timestamp.Unix(),
+	}
+}
@@ -0,0 +1,344 @@
+package blockchain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// 钱包签名登录的默认有效期
+const (
+	defaultSIWENonceTTL   = 5 * time.Minute
+	defaultSIWESessionTTL = 12 * time.Hour
+)
+
+// siweHeaderSuffix EIP-4361 消息首行中域名之后的固定内容
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// siweMessage 解析后的 EIP-4361 登录消息
+type siweMessage struct {
+	Domain         string
+	Address        common.Address
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+}
+
+// siweSession 钱包登录后的会话
+type siweSession struct {
+	key       apiKey
+	expiresAt time.Time
+}
+
+// getSIWENonce 生成一次性的登录随机数
+func (s *DAppAPIServer) getSIWENonce(c *gin.Context) {
+	if !s.cfg.System.Auth.SIWE.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用钱包签名登录"})
+		return
+	}
+
+	nonce, err := randomHex(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成随机数失败"})
+		return
+	}
+
+	ttl := defaultSIWENonceTTL
+	if seconds := s.cfg.System.Auth.SIWE.NonceTTLSeconds; seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	s.authMutex.Lock()
+	s.pruneAuthLocked()
+	s.siweNonces[nonce] = expiresAt
+	s.authMutex.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"domain":    s.cfg.System.Auth.SIWE.Domain,
+		"expiresAt": expiresAt.Unix(),
+	})
+}
+
+// verifySIWE 校验钱包对登录消息的签名，通过后按钱包地址的权限签发会话令牌
+func (s *DAppAPIServer) verifySIWE(c *gin.Context) {
+	siweCfg := s.cfg.System.Auth.SIWE
+	if !siweCfg.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用钱包签名登录"})
+		return
+	}
+
+	var body struct {
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
+		return
+	}
+
+	message, err := parseSIWEMessage(body.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 随机数只能使用一次，无论签名是否有效
+	s.authMutex.Lock()
+	nonceExpiry, ok := s.siweNonces[message.Nonce]
+	delete(s.siweNonces, message.Nonce)
+	s.authMutex.Unlock()
+
+	now := time.Now()
+	if !ok || now.After(nonceExpiry) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "随机数无效或已过期"})
+		return
+	}
+	if err := s.checkSIWEScope(message); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !message.ExpirationTime.IsZero() && now.After(message.ExpirationTime) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录消息已过期"})
+		return
+	}
+	if !message.NotBefore.IsZero() && now.Before(message.NotBefore) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录消息尚未生效"})
+		return
+	}
+
+	signer, err := recoverPersonalSigner(body.Message, body.Signature)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if signer != message.Address {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "签名与登录消息中的地址不匹配"})
+		return
+	}
+
+	key, ok := s.walletPermission(signer)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "钱包地址未被授权: " + signer.Hex()})
+		return
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成会话令牌失败"})
+		return
+	}
+	ttl := defaultSIWESessionTTL
+	if minutes := siweCfg.SessionTTLMinutes; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	session := siweSession{key: key, expiresAt: now.Add(ttl)}
+
+	s.authMutex.Lock()
+	s.sessions[token] = session
+	s.authMutex.Unlock()
+
+	logrus.Infof("钱包 %s 已通过签名登录，角色: %s", signer.Hex(), key.role)
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"address":   signer.Hex(),
+		"role":      key.role,
+		"expiresAt": session.expiresAt.Unix(),
+	})
+}
+
+// checkSIWEScope 校验登录消息声明的域名、URI 和链ID：域名须与配置一致，URI 须指向该域名，链ID须为已启用的网络
+func (s *DAppAPIServer) checkSIWEScope(message siweMessage) error {
+	domain := s.cfg.System.Auth.SIWE.Domain
+	if domain == "" {
+		return fmt.Errorf("未配置钱包签名登录的域名")
+	}
+	if message.Domain != domain {
+		return fmt.Errorf("登录消息的域名不匹配")
+	}
+
+	uri, err := url.Parse(message.URI)
+	if err != nil || uri.Host != domain {
+		return fmt.Errorf("登录消息的URI不匹配: %s", message.URI)
+	}
+
+	chainID, err := strconv.Atoi(message.ChainID)
+	if err != nil {
+		return fmt.Errorf("登录消息的链ID无效: %s", message.ChainID)
+	}
+	for _, network := range s.cfg.Blockchain.Networks {
+		if network.Enabled && network.ChainID == chainID {
+			return nil
+		}
+	}
+	return fmt.Errorf("登录消息的链ID %d 不是已启用的网络", chainID)
+}
+
+// logoutSIWE 注销当前的钱包登录会话
+func (s *DAppAPIServer) logoutSIWE(c *gin.Context) {
+	token := requestAPIKey(c)
+
+	s.authMutex.Lock()
+	_, ok := s.sessions[token]
+	delete(s.sessions, token)
+	s.authMutex.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// lookupSession 查找未过期的钱包登录会话
+func (s *DAppAPIServer) lookupSession(token string) (apiKey, bool) {
+	if token == "" {
+		return apiKey{}, false
+	}
+
+	s.authMutex.Lock()
+	defer s.authMutex.Unlock()
+
+	session, ok := s.sessions[token]
+	if !ok {
+		return apiKey{}, false
+	}
+	if time.Now().After(session.expiresAt) {
+		delete(s.sessions, token)
+		return apiKey{}, false
+	}
+	return session.key, true
+}
+
+// walletPermission 查找钱包地址对应的角色和可访问账户，未列出的钱包使用默认角色
+func (s *DAppAPIServer) walletPermission(address common.Address) (apiKey, bool) {
+	siweCfg := s.cfg.System.Auth.SIWE
+	role := siweCfg.DefaultRole
+	var allowed []string
+	for _, walletCfg := range siweCfg.Wallets {
+		if common.HexToAddress(walletCfg.Address) == address {
+			role = walletCfg.Role
+			allowed = walletCfg.Accounts
+			break
+		}
+	}
+	if _, ok := roleLevels[role]; !ok {
+		return apiKey{}, false
+	}
+
+	accounts := make(map[string]bool)
+	for _, account := range allowed {
+		accounts[account] = true
+	}
+	return apiKey{name: "wallet:" + address.Hex(), role: role, accounts: accounts}, true
+}
+
+// pruneAuthLocked 清理过期的随机数和会话，调用方需持有 s.authMutex
+func (s *DAppAPIServer) pruneAuthLocked() {
+	now := time.Now()
+	for nonce, expiresAt := range s.siweNonces {
+		if now.After(expiresAt) {
+			delete(s.siweNonces, nonce)
+		}
+	}
+	for token, session := range s.sessions {
+		if now.After(session.expiresAt) {
+			delete(s.sessions, token)
+		}
+	}
+}
+
+// parseSIWEMessage 解析 EIP-4361 登录消息
+func parseSIWEMessage(text string) (siweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return siweMessage{}, fmt.Errorf("无效的登录消息格式")
+	}
+
+	var message siweMessage
+	message.Domain = strings.TrimSuffix(lines[0], siweHeaderSuffix)
+	address := strings.TrimSpace(lines[1])
+	if !common.IsHexAddress(address) {
+		return siweMessage{}, fmt.Errorf("登录消息中的地址无效: %s", address)
+	}
+	message.Address = common.HexToAddress(address)
+
+	var err error
+	for _, line := range lines[2:] {
+		field, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch field {
+		case "URI":
+			message.URI = value
+		case "Version":
+			message.Version = value
+		case "Chain ID":
+			message.ChainID = value
+		case "Nonce":
+			message.Nonce = value
+		case "Issued At":
+			message.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			message.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		case "Not Before":
+			message.NotBefore, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return siweMessage{}, fmt.Errorf("登录消息中的 %s 无效: %v", field, err)
+		}
+	}
+
+	if message.Version != "1" {
+		return siweMessage{}, fmt.Errorf("不支持的登录消息版本: %s", message.Version)
+	}
+	if message.Nonce == "" {
+		return siweMessage{}, fmt.Errorf("登录消息缺少随机数")
+	}
+	return message, nil
+}
+
+// recoverPersonalSigner 从 personal_sign 签名中恢复签名者地址
+func recoverPersonalSigner(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("无效的签名格式")
+	}
+	// 钱包返回的 v 为 27/28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("恢复签名者失败: %v", err)
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// randomHex 生成指定字节数的随机十六进制字符串
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testSIWEServer 返回启用钱包签名登录的服务器，域名为 localhost:3000，只启用链ID为1的网络
+func testSIWEServer(t *testing.T) *DAppAPIServer {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.System.DataDir = t.TempDir()
+	cfg.System.LogLevel = "error"
+	cfg.Blockchain.Networks = []config.NetworkConfig{
+		{Name: "ethereum", Enabled: true, ChainID: 1},
+		{Name: "bsc", Enabled: false, ChainID: 56},
+	}
+	cfg.System.Auth = config.AuthConfig{
+		Enabled: true,
+		SIWE:    config.SIWEConfig{Enabled: true, Domain: "localhost:3000", DefaultRole: roleViewer},
+	}
+	server := NewDAppAPIServer(cfg, nil, nil, nil)
+	t.Cleanup(server.Stop)
+	return server
+}
+
+// signIn 获取随机数后按指定的域名、URI 和链ID签名登录消息，返回登录接口的状态码
+func signIn(t *testing.T, server *DAppAPIServer, domain, uri string, chainID int) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/nonce", nil))
+	var nonce struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &nonce); err != nil || nonce.Nonce == "" {
+		t.Fatalf("获取随机数失败: %d %s", w.Code, w.Body.String())
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := fmt.Sprintf("%s%s\n%s\n\nURI: %s\nVersion: 1\nChain ID: %d\nNonce: %s\nIssued At: %s",
+		domain, siweHeaderSuffix, crypto.PubkeyToAddress(key.PublicKey).Hex(), uri, chainID, nonce.Nonce,
+		time.Now().UTC().Format(time.RFC3339))
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	body, _ := json.Marshal(map[string]string{"message": message, "signature": hexutil.Encode(sig)})
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/auth/verify", bytes.NewReader(body)))
+	return w.Code
+}
+
+func TestSIWEVerifiesDomainURIAndChain(t *testing.T) {
+	server := testSIWEServer(t)
+
+	if code := signIn(t, server, "localhost:3000", "http://localhost:3000", 1); code != http.StatusOK {
+		t.Fatalf("域名、URI 和链ID均正确时应登录成功，返回 %d", code)
+	}
+
+	cases := []struct {
+		name    string
+		domain  string
+		uri     string
+		chainID int
+	}{
+		{"错误的域名", "evil.example.com", "http://localhost:3000", 1},
+		{"错误的URI", "localhost:3000", "https://evil.example.com", 1},
+		{"未启用网络的链ID", "localhost:3000", "http://localhost:3000", 56},
+		{"未配置的链ID", "localhost:3000", "http://localhost:3000", 137},
+	}
+	for _, tc := range cases {
+		if code := signIn(t, server, tc.domain, tc.uri, tc.chainID); code != http.StatusUnauthorized {
+			t.Fatalf("%s 应拒绝登录，返回 %d", tc.name, code)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package blockchain
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"autotransaction/internal/backtest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// LLM辅助策略优化的默认值
+const (
+	defaultOptimizationRounds    = 3
+	maxOptimizationRounds        = 10
+	defaultOptimizationProposals = 3
+	defaultOptimizationLookback  = 90 // 天
+)
+
+// strategyOptimizationRequest LLM辅助策略优化的请求体，均可省略
+type strategyOptimizationRequest struct {
+	Rounds int      `json:"rounds"` // 迭代轮数，为0时使用配置
+	From   int64    `json:"from"`   // unix秒，为0时为 to 之前 lookback_days 天
+	To     int64    `json:"to"`     // unix秒，为0时为当前时间
+	Pairs  []string `json:"pairs"`  // 为空时为已启用的交易所交易对
+}
+
+// optimizeStrategy 由LLM提出策略参数调整，系统自动回测并将结果交给LLM继续改进，迭代多轮后返回各组参数的表现
+// 每组参数在训练区间和随后的测试区间分别回测并给出过拟合提示，最优参数按测试区间的夏普比率选出，不会自动应用
+// 回测服务不可用时只由LLM根据实盘表现给出建议
+func (s *DAppAPIServer) optimizeStrategy(c *gin.Context) {
+	if s.backtests == nil {
+		s.llmController.OptimizeStrategy(c)
+		return
+	}
+	if s.strategyManager == nil || s.exchangeExecutor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略管理器不可用"})
+		return
+	}
+
+	var request strategyOptimizationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+			return
+		}
+	}
+
+	name := c.Param("id")
+	info, ok := s.strategyManager.GetStrategyInfo(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "策略不存在"})
+		return
+	}
+	strategyData, _ := s.llmController.getStrategyData(name)
+
+	llmService, ok := s.llmController.engineService(c)
+	if !ok {
+		return
+	}
+
+	cfg := s.cfg.LLM.StrategyOptimization
+	rounds := request.Rounds
+	if rounds <= 0 {
+		rounds = cfg.Rounds
+	}
+	if rounds <= 0 {
+		rounds = defaultOptimizationRounds
+	}
+	if rounds > maxOptimizationRounds {
+		rounds = maxOptimizationRounds
+	}
+	proposals := cfg.Proposals
+	if proposals <= 0 {
+		proposals = defaultOptimizationProposals
+	}
+
+	base := backtest.Request{Strategy: info.Type, Params: info.Params, Pairs: request.Pairs, To: time.Now()}
+	if request.To > 0 {
+		base.To = time.Unix(request.To, 0)
+	}
+	if request.From > 0 {
+		base.From = time.Unix(request.From, 0)
+	} else {
+		lookback := cfg.LookbackDays
+		if lookback <= 0 {
+			lookback = defaultOptimizationLookback
+		}
+		base.From = base.To.AddDate(0, 0, -lookback)
+	}
+	if len(base.Pairs) == 0 {
+		for _, pair := range s.cfg.Current().Trading.Pairs {
+			if pair.Enabled && pair.Blockchain == "" {
+				base.Pairs = append(base.Pairs, pair.Symbol)
+			}
+		}
+	}
+
+	baseline, err := s.backtests.Evaluate(base, cfg.TrainRatio)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "回测当前参数失败: " + err.Error()})
+		return
+	}
+
+	best, bestRationale := baseline, "当前参数"
+	tried := []map[string]interface{}{evaluationToMap(baseline, bestRationale)}
+	roundResults := make([]map[string]interface{}, 0, rounds)
+	for round := 1; round <= rounds; round++ {
+		proposed, err := llmService.ProposeStrategyParams(map[string]interface{}{
+			"strategy":    strategyData,
+			"evaluations": tried,
+		}, proposals)
+		if err != nil {
+			logrus.Errorf("LLM提出策略参数失败: %v", err)
+			if round == 1 {
+				c.JSON(llmErrorStatus(err), gin.H{"error": "优化策略失败: " + err.Error()})
+				return
+			}
+			roundResults = append(roundResults, map[string]interface{}{"round": round, "error": err.Error()})
+			break
+		}
+
+		results := make([]map[string]interface{}, 0, len(proposed.Proposals))
+		for _, proposal := range proposed.Proposals {
+			params := make(map[string]interface{}, len(info.Params)+len(proposal.Params))
+			for key, value := range info.Params {
+				params[key] = value
+			}
+			for key, value := range proposal.Params {
+				params[key] = value
+			}
+
+			candidate := base
+			candidate.Params = params
+			evaluation, err := s.backtests.Evaluate(candidate, cfg.TrainRatio)
+			if err != nil {
+				results = append(results, map[string]interface{}{
+					"params":    params,
+					"rationale": proposal.Rationale,
+					"error":     err.Error(),
+				})
+				tried = append(tried, map[string]interface{}{"params": params, "error": err.Error()})
+				continue
+			}
+			result := evaluationToMap(evaluation, proposal.Rationale)
+			results = append(results, result)
+			tried = append(tried, result)
+			if evaluation.TestMetrics.SharpeRatio > best.TestMetrics.SharpeRatio {
+				best, bestRationale = evaluation, proposal.Rationale
+			}
+		}
+		roundResults = append(roundResults, map[string]interface{}{
+			"round":     round,
+			"proposals": results,
+			"prompt":    proposed.Prompt,
+		})
+	}
+
+	bestResult := evaluationToMap(best, bestRationale)
+	warnings := append([]string(nil), best.Warnings...)
+	if best != baseline && len(tried) > 2 {
+		// 在多组参数中按测试区间挑选，测试区间的结果本身也会偏乐观
+		warnings = append(warnings, fmt.Sprintf("最优参数是从 %d 组参数中按测试区间表现选出的，实盘表现可能低于回测", len(tried)))
+	}
+	bestResult["warnings"] = warnings
+	bestResult["improved"] = best != baseline
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"strategy": name,
+			"type":     info.Type,
+			"pairs":    base.Pairs,
+			"from":     base.From.Unix(),
+			"to":       base.To.Unix(),
+			"baseline": evaluationToMap(baseline, "当前参数"),
+			"rounds":   roundResults,
+			"best":     bestResult,
+		},
+	})
+}
+
+// evaluationToMap 将参数的回测评估转换为响应和提供给LLM的数据
+func evaluationToMap(evaluation *backtest.Evaluation, rationale string) map[string]interface{} {
+	return map[string]interface{}{
+		"params":       evaluation.Params,
+		"rationale":    rationale,
+		"trainFrom":    evaluation.Train.From.Unix(),
+		"trainTo":      evaluation.Train.To.Unix(),
+		"testFrom":     evaluation.Test.From.Unix(),
+		"testTo":       evaluation.Test.To.Unix(),
+		"trainMetrics": evaluation.TrainMetrics,
+		"testMetrics":  evaluation.TestMetrics,
+		"warnings":     evaluation.Warnings,
+	}
+}
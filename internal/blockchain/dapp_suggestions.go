@@ -0,0 +1,385 @@
+package blockchain
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/llm"
+	"autotransaction/internal/strategy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// llmStrategyName 按LLM交易建议下单时使用的策略名称
+const llmStrategyName = "llm"
+
+// defaultApprovalTTL 未配置有效期时待审批建议的有效期
+const defaultApprovalTTL = 30 * time.Minute
+
+// suggestionRetention 已处理的建议在审批队列中保留的时间
+const suggestionRetention = 24 * time.Hour
+
+// 交易建议的状态
+const (
+	suggestionRejected = "rejected" // 未通过LLM专用限制或被人工拒绝
+	suggestionPending  = "pending"  // 等待人工审批
+	suggestionExecuted = "executed" // 已交给执行器，订单仍可能被风险检查拒绝
+	suggestionFailed   = "failed"   // 下单失败
+	suggestionExpired  = "expired"  // 超过有效期未审批
+)
+
+// suggestionSeq 交易建议ID的序号
+var suggestionSeq uint64
+
+// pendingSuggestion 进入审批队列的交易建议
+type pendingSuggestion struct {
+	ID             string
+	Account        string
+	Recommendation llm.TradeRecommendation
+	Status         string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	DecidedAt      time.Time
+	DecidedBy      string                 // 审批人，即API密钥名称
+	Result         map[string]interface{} // 批准后的下单结果
+}
+
+// tradeSuggestionPreferences 生成交易建议时使用的用户偏好
+func tradeSuggestionPreferences() map[string]interface{} {
+	return map[string]interface{}{
+		"risk_tolerance":     "medium", // 默认值
+		"investment_horizon": "medium_term",
+		"preferred_assets":   []string{"BTC", "ETH"},
+	}
+}
+
+// executeTradeSuggestions 获取LLM的结构化交易建议，按LLM专用限制处理后下单或放入审批队列，返回每条建议的处理结果
+func (s *DAppAPIServer) executeTradeSuggestions(c *gin.Context) {
+	if !s.cfg.LLM.AutoExecute.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "未启用按LLM交易建议下单"})
+		return
+	}
+
+	llmService, ok := s.llmController.engineService(c)
+	if !ok {
+		return
+	}
+	suggestions, err := llmService.GetTradeSuggestions(s.llmController.getMarketData(), tradeSuggestionPreferences())
+	if err != nil {
+		logrus.Errorf("获取LLM交易建议失败: %v", err)
+		c.JSON(llmErrorStatus(err), gin.H{"error": "获取交易建议失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"recommendations": s.processSuggestions(currentAccount(c), suggestions),
+			"invalid":         suggestions.Invalid,
+			"prompt":          suggestions.Prompt,
+		},
+	})
+}
+
+// runAutoTrading 按配置的间隔定时生成交易建议并处理，服务器停止时退出
+func (s *DAppAPIServer) runAutoTrading() {
+	cfg := s.cfg.LLM.AutoExecute
+	account := cfg.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.IntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+	logrus.Infof("LLM自动交易已启用，每 %d 分钟生成一次交易建议，账户: %s", cfg.IntervalMinutes, account)
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			suggestions, err := s.llmController.llmService.GetTradeSuggestions(s.llmController.getMarketData(), tradeSuggestionPreferences())
+			if err != nil {
+				logrus.Errorf("LLM自动交易获取交易建议失败: %v", err)
+				continue
+			}
+			s.processSuggestions(account, suggestions)
+		}
+	}
+}
+
+// processSuggestions 按置信度从高到低处理交易建议，返回通过校验的建议的处理结果
+// 低于置信度阈值、超出单次数量上限或超出最大名义价值的建议被拒绝；需要审批时放入审批队列，否则直接下单
+func (s *DAppAPIServer) processSuggestions(account string, suggestions *llm.TradeSuggestions) []map[string]interface{} {
+	cfg := s.cfg.LLM.AutoExecute
+	for _, invalid := range suggestions.Invalid {
+		s.recordSuggestion(account, "", invalid.Recommendation, suggestionRejected, invalid.Reason)
+	}
+
+	recommendations := suggestions.Recommendations
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Confidence > recommendations[j].Confidence
+	})
+
+	results := make([]map[string]interface{}, 0, len(recommendations))
+	accepted := 0
+	for _, recommendation := range recommendations {
+		result := tradeRecommendationToMap(recommendation)
+		results = append(results, result)
+
+		reason := ""
+		notional := recommendation.Price * recommendation.Size
+		switch {
+		case recommendation.Confidence < cfg.MinConfidence:
+			reason = fmt.Sprintf("置信度 %.2f 低于执行阈值 %.2f", recommendation.Confidence, cfg.MinConfidence)
+		case cfg.MaxOrders > 0 && accepted >= cfg.MaxOrders:
+			reason = "已达到单次执行的建议数上限"
+		case cfg.MaxNotional > 0 && notional > cfg.MaxNotional:
+			reason = fmt.Sprintf("名义价值 %.2f 超过上限 %.2f", notional, cfg.MaxNotional)
+		}
+		if reason != "" {
+			result["status"] = suggestionRejected
+			result["error"] = reason
+			s.recordSuggestion(account, "", recommendation, suggestionRejected, reason)
+			continue
+		}
+		accepted++
+
+		if cfg.RequireApproval {
+			suggestion := s.enqueueSuggestion(account, recommendation)
+			result["id"] = suggestion.ID
+			result["status"] = suggestionPending
+			result["expiresAt"] = suggestion.ExpiresAt.Unix()
+			continue
+		}
+
+		for key, value := range s.executeSuggestion(account, "", recommendation) {
+			result[key] = value
+		}
+	}
+	return results
+}
+
+// executeSuggestion 将交易建议作为 llm 策略的信号交给执行器，返回下单结果，note 记录到审计事件
+func (s *DAppAPIServer) executeSuggestion(account, note string, recommendation llm.TradeRecommendation) map[string]interface{} {
+	signal := strategy.Signal{
+		Symbol:       recommendation.Symbol,
+		Direction:    recommendation.Side,
+		Price:        decimal.NewFromFloat(recommendation.Price),
+		Quantity:     decimal.NewFromFloat(recommendation.Size),
+		Timestamp:    time.Now().Unix(),
+		Confidence:   recommendation.Confidence,
+		Account:      account,
+		StrategyName: llmStrategyName,
+		ID:           strategy.NewSignalID(),
+	}
+	code, body := s.routeSignal(signal)
+
+	result := map[string]interface{}{
+		"status":   suggestionExecuted,
+		"signalId": signal.ID,
+	}
+	if data, ok := body["data"]; ok {
+		result["order"] = data
+	}
+	if code >= http.StatusMultipleChoices {
+		result["status"] = suggestionFailed
+		result["error"] = body["error"]
+		s.recordSuggestion(account, signal.ID, recommendation, suggestionFailed, fmt.Sprint(body["error"]))
+		return result
+	}
+
+	logrus.Infof("按LLM交易建议下单: %s %s 价格=%v 数量=%v 置信度=%.2f",
+		recommendation.Side, recommendation.Symbol, recommendation.Price, recommendation.Size, recommendation.Confidence)
+	s.recordSuggestion(account, signal.ID, recommendation, suggestionExecuted, note)
+	return result
+}
+
+// enqueueSuggestion 将交易建议放入审批队列
+func (s *DAppAPIServer) enqueueSuggestion(account string, recommendation llm.TradeRecommendation) *pendingSuggestion {
+	ttl := time.Duration(s.cfg.LLM.AutoExecute.ApprovalTTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultApprovalTTL
+	}
+
+	now := time.Now()
+	suggestion := &pendingSuggestion{
+		ID:             fmt.Sprintf("SUGGESTION-%d-%d", now.UnixNano(), atomic.AddUint64(&suggestionSeq, 1)),
+		Account:        account,
+		Recommendation: recommendation,
+		Status:         suggestionPending,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(ttl),
+	}
+
+	s.suggestionsMutex.Lock()
+	for id, existing := range s.suggestions {
+		s.expireSuggestionLocked(existing)
+		if existing.Status != suggestionPending && now.Sub(existing.CreatedAt) > suggestionRetention {
+			delete(s.suggestions, id)
+		}
+	}
+	s.suggestions[suggestion.ID] = suggestion
+	s.suggestionsMutex.Unlock()
+
+	s.recordSuggestion(account, "", recommendation, suggestionPending, suggestion.ID)
+	return suggestion
+}
+
+// getSuggestions 获取当前账户的交易建议审批队列
+// 支持的查询参数: status，为空时返回所有状态
+func (s *DAppAPIServer) getSuggestions(c *gin.Context) {
+	account := currentAccount(c)
+	status := c.Query("status")
+
+	s.suggestionsMutex.Lock()
+	list := make([]pendingSuggestion, 0, len(s.suggestions))
+	for _, suggestion := range s.suggestions {
+		s.expireSuggestionLocked(suggestion)
+		if suggestion.Account == account && (status == "" || suggestion.Status == status) {
+			list = append(list, *suggestion)
+		}
+	}
+	s.suggestionsMutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	data := make([]map[string]interface{}, 0, len(list))
+	for _, suggestion := range list {
+		data = append(data, pendingSuggestionToMap(suggestion))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+// approveSuggestion 批准待审批的交易建议并下单
+func (s *DAppAPIServer) approveSuggestion(c *gin.Context) {
+	suggestion, ok := s.decideSuggestion(c, suggestionExecuted)
+	if !ok {
+		return
+	}
+
+	note := suggestion.ID
+	if suggestion.DecidedBy != "" {
+		note += "，批准人: " + suggestion.DecidedBy
+	}
+	result := s.executeSuggestion(suggestion.Account, note, suggestion.Recommendation)
+
+	s.suggestionsMutex.Lock()
+	stored := s.suggestions[suggestion.ID]
+	stored.Status = result["status"].(string)
+	stored.Result = result
+	snapshot := *stored
+	s.suggestionsMutex.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": pendingSuggestionToMap(snapshot),
+	})
+}
+
+// rejectSuggestion 拒绝待审批的交易建议
+func (s *DAppAPIServer) rejectSuggestion(c *gin.Context) {
+	suggestion, ok := s.decideSuggestion(c, suggestionRejected)
+	if !ok {
+		return
+	}
+
+	s.recordSuggestion(suggestion.Account, "", suggestion.Recommendation, suggestionRejected, "人工拒绝: "+suggestion.DecidedBy)
+	c.JSON(http.StatusOK, gin.H{
+		"data": pendingSuggestionToMap(suggestion),
+	})
+}
+
+// decideSuggestion 记录对待审批建议的决定，建议不存在、不属于当前账户、已处理或已过期时写入错误响应
+// 批准的建议先标记为 executed，防止并发的重复批准
+func (s *DAppAPIServer) decideSuggestion(c *gin.Context, status string) (pendingSuggestion, bool) {
+	s.suggestionsMutex.Lock()
+	defer s.suggestionsMutex.Unlock()
+
+	suggestion, ok := s.suggestions[c.Param("id")]
+	if !ok || suggestion.Account != currentAccount(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易建议不存在"})
+		return pendingSuggestion{}, false
+	}
+	s.expireSuggestionLocked(suggestion)
+	if suggestion.Status != suggestionPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "交易建议已处理，当前状态: " + suggestion.Status})
+		return pendingSuggestion{}, false
+	}
+
+	suggestion.Status = status
+	suggestion.DecidedAt = time.Now()
+	suggestion.DecidedBy = requestKeyName(c)
+	return *suggestion, true
+}
+
+// expireSuggestionLocked 将超过有效期的待审批建议标记为过期，调用方需持有 suggestionsMutex
+func (s *DAppAPIServer) expireSuggestionLocked(suggestion *pendingSuggestion) {
+	if suggestion.Status == suggestionPending && time.Now().After(suggestion.ExpiresAt) {
+		suggestion.Status = suggestionExpired
+		s.recordSuggestion(suggestion.Account, "", suggestion.Recommendation, suggestionExpired, suggestion.ID)
+	}
+}
+
+// recordSuggestion 记录交易建议的审计事件，reason 为拒绝或失败的原因，以及进入审批队列或批准执行的建议ID
+func (s *DAppAPIServer) recordSuggestion(account, signalID string, recommendation llm.TradeRecommendation, status, reason string) {
+	s.auditLog.Record(audit.Event{
+		Type:      audit.EventLLMSuggestion,
+		Account:   account,
+		Symbol:    recommendation.Symbol,
+		Direction: recommendation.Side,
+		Strategy:  llmStrategyName,
+		SignalID:  signalID,
+		Price:     decimal.NewFromFloat(recommendation.Price),
+		Quantity:  decimal.NewFromFloat(recommendation.Size),
+		Status:    status,
+		Reason:    reason,
+		Detail:    fmt.Sprintf("置信度 %.2f: %s", recommendation.Confidence, recommendation.Rationale),
+	})
+}
+
+// requestKeyName 返回请求使用的API密钥名称，未启用认证时为空
+func requestKeyName(c *gin.Context) string {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		return value.(apiKey).name
+	}
+	return ""
+}
+
+// tradeRecommendationToMap 将交易建议转换为API响应格式
+func tradeRecommendationToMap(recommendation llm.TradeRecommendation) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":     recommendation.Symbol,
+		"side":       recommendation.Side,
+		"confidence": recommendation.Confidence,
+		"size":       recommendation.Size,
+		"price":      recommendation.Price,
+		"rationale":  recommendation.Rationale,
+	}
+}
+
+// pendingSuggestionToMap 将审批队列中的交易建议转换为API响应格式
+func pendingSuggestionToMap(suggestion pendingSuggestion) map[string]interface{} {
+	data := tradeRecommendationToMap(suggestion.Recommendation)
+	data["id"] = suggestion.ID
+	data["status"] = suggestion.Status
+	data["createdAt"] = suggestion.CreatedAt.Unix()
+	data["expiresAt"] = suggestion.ExpiresAt.Unix()
+	if !suggestion.DecidedAt.IsZero() {
+		data["decidedAt"] = suggestion.DecidedAt.Unix()
+		data["decidedBy"] = suggestion.DecidedBy
+	}
+	if suggestion.Result != nil {
+		data["result"] = suggestion.Result
+	}
+	return data
+}
@@ -0,0 +1,57 @@
+package blockchain
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTokenSafety 获取代币合约的安全检查结果
+// 未指定 network 时使用配置了该代币的交易对所在网络，refresh=true 时忽略缓存重新检查
+func (s *DAppAPIServer) getTokenSafety(c *gin.Context) {
+	if s.executor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "区块链执行器不可用"})
+		return
+	}
+
+	address := c.Param("address")
+	network := c.Query("network")
+	if network == "" {
+		for _, pair := range s.cfg.Current().Trading.Pairs {
+			if pair.Blockchain != "" && strings.EqualFold(pair.TokenAddress, address) {
+				network = pair.Blockchain
+				break
+			}
+		}
+	}
+	if network == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置该代币的交易对，需要指定 network"})
+		return
+	}
+
+	report, err := s.executor.TokenSafety(network, address, c.Query("refresh") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checks := make([]map[string]interface{}, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		checks = append(checks, map[string]interface{}{
+			"name":    check.Name,
+			"passed":  check.Passed,
+			"skipped": check.Skipped,
+			"detail":  check.Detail,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": map[string]interface{}{
+		"network":     report.Network,
+		"address":     report.Address,
+		"safe":        report.Safe,
+		"allowlisted": report.Allowlisted,
+		"checks":      checks,
+		"checkedAt":   report.CheckedAt.Unix(),
+	}})
+}
@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"strings"
+
+	"autotransaction/internal/execution"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+)
+
+// manualStrategyName 通过API手动下单时使用的策略名称
+const manualStrategyName = "manual"
+
+// AttachTradingSystem 将策略管理器、交易执行器和风险管理器接入API，REST接口据此操作实时状态
+func (s *DAppAPIServer) AttachTradingSystem(strategyManager *strategy.StrategyManager, executor *execution.Executor, riskManager *risk.RiskManager) {
+	s.strategyManager = strategyManager
+	s.exchangeExecutor = executor
+	s.riskManager = riskManager
+	if s.llmController != nil {
+		s.llmController.SetTradingSystem(strategyManager, executor)
+	}
+}
+
+// strategyExists 判断策略实例是否正在运行，未接入策略管理器时返回 false
+func (s *DAppAPIServer) strategyExists(name string) bool {
+	if s.strategyManager == nil {
+		return false
+	}
+	_, ok := s.strategyManager.GetStrategyInfo(name)
+	return ok
+}
+
+// isBlockchainPair 判断交易对是否在区块链上交易
+func (s *DAppAPIServer) isBlockchainPair(symbol string) bool {
+	for _, pair := range s.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol {
+			return pair.Blockchain != ""
+		}
+	}
+	return false
+}
+
+// exchangeOrderToMap 将交易所订单转换为API响应格式
+func exchangeOrderToMap(order execution.Order) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        order.ID,
+		"pair":      order.Symbol,
+		"type":      order.Direction,
+		"amount":    order.Quantity.InexactFloat64(),
+		"price":     order.Price.InexactFloat64(),
+		"timestamp": order.Timestamp.Unix(),
+		"status":    order.Status,
+		"strategy":  order.StrategyName,
+		"regime":    order.Regime,
+
+		"orderType":    order.Type,
+		"stopPrice":    order.StopPrice.InexactFloat64(),
+		"timeInForce":  order.TimeInForce,
+		"filledAmount": order.FilledQuantity.InexactFloat64(),
+		"avgFillPrice": order.AvgFillPrice.InexactFloat64(),
+		"fee":          order.Fee.InexactFloat64(),
+
+		"clientOrderId": order.ClientOrderID,
+		"signalId":      order.SignalID,
+
+		"parentId":   order.ParentID,
+		"algo":       order.Algo,
+		"algoSlices": order.AlgoSlices,
+	}
+}
+
+// performanceReportToMap 将策略绩效转换为API响应格式，胜率和收益率以百分比表示
+func performanceReportToMap(report execution.PerformanceReport) map[string]interface{} {
+	openPositions := make(map[string]float64)
+	for symbol, quantity := range report.OpenQty {
+		openPositions[symbol] = quantity.InexactFloat64()
+	}
+
+	result := map[string]interface{}{
+		"strategy":       report.Strategy,
+		"totalTrades":    report.Orders,
+		"closedTrades":   report.ClosedTrades,
+		"wins":           report.Wins,
+		"losses":         report.ClosedTrades - report.Wins,
+		"winRate":        report.WinRate * 100,
+		"avgProfit":      report.AvgReturn * 100,
+		"realizedPnl":    report.RealizedPnL.InexactFloat64(),
+		"fees":           report.Fees.InexactFloat64(),
+		"avgHoldSeconds": report.AvgHoldTime.Seconds(),
+		"maxDrawdown":    report.MaxDrawdown.InexactFloat64(),
+		"openPositions":  openPositions,
+	}
+	if !report.FirstTrade.IsZero() {
+		result["firstTrade"] = report.FirstTrade.Unix()
+		result["lastTrade"] = report.LastTrade.Unix()
+	}
+	return result
+}
+
+// exchangePositionToMap 将交易所持仓转换为API响应格式
+func (s *DAppAPIServer) exchangePositionToMap(key string, position execution.Position) map[string]interface{} {
+	currentPrice := position.CurrentPrice
+	value := currentPrice.Mul(position.Quantity)
+	profitLoss := risk.Position{
+		Side:         position.Side,
+		Quantity:     position.Quantity,
+		EntryPrice:   position.EntryPrice,
+		CurrentPrice: currentPrice,
+	}.UnrealizedPnL()
+
+	result := map[string]interface{}{
+		"id":           key,
+		"asset":        strings.Split(position.Symbol, "/")[0],
+		"pair":         position.Symbol,
+		"amount":       position.Quantity.InexactFloat64(),
+		"entryPrice":   position.EntryPrice.InexactFloat64(),
+		"currentPrice": currentPrice.InexactFloat64(),
+		"value":        value.InexactFloat64(),
+		"profitLoss":   profitLoss.InexactFloat64(),
+	}
+	if position.Side != "" {
+		result["side"] = position.Side
+		result["leverage"] = position.Leverage.InexactFloat64()
+		result["margin"] = position.Margin.InexactFloat64()
+		result["liquidationPrice"] = position.LiquidationPrice.InexactFloat64()
+	}
+	if s.riskManager != nil && s.riskManager.IsUntradeable(position.Symbol) {
+		result["untradeable"] = true
+	}
+	return result
+}
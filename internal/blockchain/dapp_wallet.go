@@ -0,0 +1,44 @@
+package blockchain
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getWalletBalances 获取各钱包在各网络上的原生币和代币余额
+func (s *DAppAPIServer) getWalletBalances(c *gin.Context) {
+	if s.executor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "区块链执行器不可用"})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, balance := range s.executor.GetWalletBalances() {
+		tokens := make([]map[string]interface{}, 0, len(balance.Tokens))
+		for _, token := range balance.Tokens {
+			tokens = append(tokens, map[string]interface{}{
+				"asset":    token.Asset,
+				"address":  token.Address,
+				"balance":  token.Balance.InexactFloat64(),
+				"symbol":   token.Metadata.Symbol,
+				"name":     token.Metadata.Name,
+				"decimals": token.Metadata.Decimals,
+			})
+		}
+
+		item := map[string]interface{}{
+			"wallet":  balance.Wallet,
+			"network": balance.Network,
+			"address": balance.Address,
+			"native":  balance.Native.InexactFloat64(),
+			"tokens":  tokens,
+		}
+		if balance.Error != "" {
+			item["error"] = balance.Error
+		}
+		result = append(result, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,168 @@
+package blockchain
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookPath 外部信号 Webhook 路径，不经过API密钥认证
+const webhookPath = "/api/webhooks/signals"
+
+// webhookSecretHeader 携带 Webhook 共享密钥的请求头，TradingView 无法自定义请求头时使用请求体的 passphrase 字段
+const webhookSecretHeader = "X-Webhook-Secret"
+
+// defaultWebhookStrategy 未配置策略名称时 Webhook 信号归属的策略
+const defaultWebhookStrategy = "webhook"
+
+// webhookAlert TradingView 风格的警报消息，数值字段可以是数字或字符串
+type webhookAlert struct {
+	Passphrase string          `json:"passphrase"`
+	Ticker     string          `json:"ticker"` // 如 BTCUSDT、BINANCE:BTCUSDT 或 BTC/USDT
+	Symbol     string          `json:"symbol"` // ticker 的别名
+	Action     string          `json:"action"` // buy 或 sell，不区分大小写
+	Side       string          `json:"side"`   // action 的别名
+	Price      decimal.Decimal `json:"price"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Contracts  decimal.Decimal `json:"contracts"` // TradingView {{strategy.order.contracts}}，未给出 quantity 时使用
+	Strategy   string          `json:"strategy"`  // 策略名称后缀，用于区分同一 Webhook 的多个警报来源
+
+	OrderType     string          `json:"orderType"`
+	StopPrice     decimal.Decimal `json:"stopPrice"`
+	TimeInForce   string          `json:"timeInForce"`
+	ClientOrderID string          `json:"clientOrderId"` // 幂等键，警报重发时避免重复下单
+
+	DryRun bool `json:"dryRun"` // 只模拟执行并返回预览，不下单，用于测试警报配置
+}
+
+// handleSignalWebhook 接收外部警报，转换为交易信号后经风险检查交给执行器
+func (s *DAppAPIServer) handleSignalWebhook(c *gin.Context) {
+	cfg := s.cfg.System.Webhook
+	if !cfg.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook 未启用"})
+		return
+	}
+
+	var alert webhookAlert
+	if err := c.BindJSON(&alert); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := c.GetHeader(webhookSecretHeader)
+	if secret == "" {
+		secret = alert.Passphrase
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(cfg.Secret)) != 1 {
+		logrus.Warnf("拒绝 Webhook 请求: 共享密钥无效，来源: %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Webhook 密钥"})
+		return
+	}
+
+	signal, err := s.webhookSignal(cfg, alert)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logrus.Infof("收到外部信号: %s %s 价格=%s 数量=%s 策略=%s",
+		signal.Direction, signal.Symbol, signal.Price.String(), signal.Quantity.String(), signal.StrategyName)
+	if dryRunRequested(c, alert.DryRun) {
+		c.JSON(s.previewSignal(signal))
+		return
+	}
+	s.submitSignal(c, signal)
+}
+
+// webhookSignal 将警报转换为交易信号
+func (s *DAppAPIServer) webhookSignal(cfg config.WebhookConfig, alert webhookAlert) (strategy.Signal, error) {
+	ticker := alert.Ticker
+	if ticker == "" {
+		ticker = alert.Symbol
+	}
+	symbol, ok := s.resolveWebhookSymbol(ticker)
+	if !ok {
+		return strategy.Signal{}, fmt.Errorf("未配置的交易对: %s", ticker)
+	}
+
+	action := alert.Action
+	if action == "" {
+		action = alert.Side
+	}
+	direction := strings.ToLower(strings.TrimSpace(action))
+	if direction != "buy" && direction != "sell" {
+		return strategy.Signal{}, fmt.Errorf("无效的交易方向: %s", action)
+	}
+
+	quantity := alert.Quantity
+	if !quantity.IsPositive() {
+		quantity = alert.Contracts
+	}
+	if !quantity.IsPositive() {
+		quantity = decimal.NewFromFloat(cfg.Quantity)
+	}
+	if !quantity.IsPositive() || !alert.Price.IsPositive() {
+		return strategy.Signal{}, fmt.Errorf("无效的价格或数量")
+	}
+
+	account := cfg.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	strategyName := cfg.Strategy
+	if strategyName == "" {
+		strategyName = defaultWebhookStrategy
+	}
+	if alert.Strategy != "" {
+		strategyName += ":" + alert.Strategy
+	}
+
+	return strategy.Signal{
+		Symbol:        symbol,
+		Direction:     direction,
+		Price:         alert.Price,
+		Quantity:      quantity,
+		Timestamp:     time.Now().Unix(),
+		Confidence:    1,
+		Account:       account,
+		StrategyName:  strategyName,
+		OrderType:     alert.OrderType,
+		StopPrice:     alert.StopPrice,
+		TimeInForce:   alert.TimeInForce,
+		ClientOrderID: alert.ClientOrderID,
+		ID:            strategy.NewSignalID(),
+	}, nil
+}
+
+// resolveWebhookSymbol 将警报中的代码匹配到已启用的交易对
+// 支持 BTC/USDT、BTCUSDT 以及带交易所前缀的 BINANCE:BTCUSDT
+func (s *DAppAPIServer) resolveWebhookSymbol(ticker string) (string, bool) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if i := strings.LastIndex(ticker, ":"); i >= 0 {
+		ticker = ticker[i+1:]
+	}
+	if ticker == "" {
+		return "", false
+	}
+
+	compact := strings.NewReplacer("/", "", "-", "", "_", "")
+	for _, pair := range s.cfg.Current().Trading.Pairs {
+		if !pair.Enabled {
+			continue
+		}
+		symbol := strings.ToUpper(pair.Symbol)
+		if symbol == ticker || compact.Replace(symbol) == compact.Replace(ticker) {
+			return pair.Symbol, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,425 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/approval"
+	"autotransaction/internal/events"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/market"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// wsSendBuffer 每个客户端待发送消息的缓冲数量，缓冲满时丢弃新消息，避免慢客户端阻塞推送
+const wsSendBuffer = 256
+
+// tickerWindow 计算涨跌幅使用的价格窗口
+const tickerWindow = 24 * time.Hour
+
+// wsClient 一个WebSocket连接及其订阅
+type wsClient struct {
+	conn     *websocket.Conn
+	account  string
+	key      apiKey // 连接使用的API密钥，未启用认证时为空
+	send     chan []byte
+	channels map[string]bool
+	symbols  map[string]bool // 为空时接收所有交易对
+	mutex    sync.RWMutex
+}
+
+// wsRequest 客户端发来的订阅请求或审批操作
+type wsRequest struct {
+	Action   string   `json:"action"` // "subscribe"、"unsubscribe"、"approve" 或 "reject"
+	Channels []string `json:"channels"`
+	Symbols  []string `json:"symbols"`
+	ID       string   `json:"id"`     // approve/reject: 审批请求ID
+	Reason   string   `json:"reason"` // reject: 拒绝原因
+}
+
+// priceSample 某一时刻的价格
+type priceSample struct {
+	timestamp time.Time
+	price     decimal.Decimal
+}
+
+// tickerHistory 交易对最近一个窗口内的价格，用于计算涨跌幅
+type tickerHistory struct {
+	samples []priceSample
+}
+
+// add 记录新价格并丢弃窗口外的旧价格
+func (h *tickerHistory) add(timestamp time.Time, price decimal.Decimal) {
+	h.samples = append(h.samples, priceSample{timestamp: timestamp, price: price})
+	cutoff := timestamp.Add(-tickerWindow)
+	drop := 0
+	for drop < len(h.samples)-1 && h.samples[drop].timestamp.Before(cutoff) {
+		drop++
+	}
+	h.samples = h.samples[drop:]
+}
+
+// ticker 返回最新价格和窗口内的涨跌幅(%)
+func (h *tickerHistory) ticker(pair string) marketTicker {
+	first, last := h.samples[0], h.samples[len(h.samples)-1]
+	change := decimal.Zero
+	if !first.price.IsZero() {
+		change = last.price.Sub(first.price).Div(first.price).Mul(decimal.NewFromInt(100))
+	}
+	return marketTicker{Pair: pair, Price: last.price, Change24h: change}
+}
+
+// wants 判断客户端是否订阅了某个账户、交易对在该频道上的消息，account 为空表示公共消息
+func (c *wsClient) wants(channel, account, symbol string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if !c.channels[channel] {
+		return false
+	}
+	if account != "" && account != c.account {
+		return false
+	}
+	return len(c.symbols) == 0 || c.symbols[symbol]
+}
+
+// apply 执行订阅或取消订阅，返回当前的订阅状态
+func (c *wsClient) apply(request wsRequest) (wsMessage, error) {
+	for _, channel := range request.Channels {
+		if !isWSChannel(channel) {
+			return wsMessage{}, fmt.Errorf("未知的频道: %s", channel)
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch request.Action {
+	case "subscribe":
+		for _, channel := range request.Channels {
+			c.channels[channel] = true
+		}
+		for _, symbol := range request.Symbols {
+			c.symbols[symbol] = true
+		}
+	case "unsubscribe":
+		// 未指定频道和交易对时取消全部订阅
+		if len(request.Channels) == 0 && len(request.Symbols) == 0 {
+			c.channels = make(map[string]bool)
+			c.symbols = make(map[string]bool)
+		}
+		for _, channel := range request.Channels {
+			delete(c.channels, channel)
+		}
+		for _, symbol := range request.Symbols {
+			delete(c.symbols, symbol)
+		}
+	default:
+		return wsMessage{}, fmt.Errorf("未知的操作: %s", request.Action)
+	}
+
+	return newSubscriptionsMessage(sortedKeys(c.channels), sortedKeys(c.symbols)), nil
+}
+
+// handleWebSocket 处理WebSocket连接，客户端通过 subscribe/unsubscribe 消息选择需要推送的频道和交易对
+// 账户按认证身份确定，绑定多个账户时可通过请求头或 account 查询参数选择（浏览器无法为WebSocket设置请求头），只推送该账户的订单、持仓和信号
+func (s *DAppAPIServer) handleWebSocket(c *gin.Context) {
+	requested := strings.TrimSpace(c.GetHeader(accountHeader))
+	if requested == "" {
+		requested = strings.TrimSpace(c.Query("account"))
+	}
+	account, err := boundAccount(c, requested)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.cfg.HasAccount(account) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "未知的账户: " + account})
+		return
+	}
+
+	ws, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Errorf("升级WebSocket连接失败: %v", err)
+		return
+	}
+
+	// 审批操作需要按连接使用的API密钥检查角色
+	var key apiKey
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		key = value.(apiKey)
+	}
+	client := &wsClient{
+		conn:     ws,
+		account:  account,
+		key:      key,
+		send:     make(chan []byte, wsSendBuffer),
+		channels: make(map[string]bool),
+		symbols:  make(map[string]bool),
+	}
+
+	// 注册新客户端
+	s.clientsMutex.Lock()
+	s.clients[client] = true
+	s.metrics.SetWebSocketClients(len(s.clients))
+	s.clientsMutex.Unlock()
+	go client.writeLoop()
+
+	logrus.Infof("新的WebSocket客户端已连接: %s 账户: %s", ws.RemoteAddr(), account)
+
+	// 处理断开连接
+	defer func() {
+		s.clientsMutex.Lock()
+		delete(s.clients, client)
+		close(client.send)
+		s.metrics.SetWebSocketClients(len(s.clients))
+		s.clientsMutex.Unlock()
+		ws.Close()
+		logrus.Infof("WebSocket客户端已断开连接: %s", ws.RemoteAddr())
+	}()
+
+	// 处理来自客户端的订阅请求和审批操作
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			logrus.Debugf("读取WebSocket消息失败: %v", err)
+			break
+		}
+
+		var request wsRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			s.sendTo(client, newErrorMessage("无效的消息格式"))
+			continue
+		}
+		if request.Action == "approve" || request.Action == "reject" {
+			s.sendTo(client, s.handleApprovalRequest(client, request))
+			continue
+		}
+		reply, err := client.apply(request)
+		if err != nil {
+			s.sendTo(client, newErrorMessage(err.Error()))
+			continue
+		}
+		s.sendTo(client, reply)
+	}
+}
+
+// writeLoop 依次发送消息，保证同一连接上不会并发写入
+func (c *wsClient) writeLoop() {
+	for data := range c.send {
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			logrus.Debugf("向WebSocket客户端发送消息失败: %v", err)
+			// 关闭连接后读循环退出并注销客户端
+			c.conn.Close()
+			for range c.send {
+			}
+			return
+		}
+	}
+}
+
+// sendTo 向单个客户端发送消息
+func (s *DAppAPIServer) sendTo(client *wsClient, message wsMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		logrus.Errorf("序列化WebSocket消息 %s 失败: %v", message.Type, err)
+		return
+	}
+
+	s.clientsMutex.RLock()
+	defer s.clientsMutex.RUnlock()
+	if s.clients[client] {
+		client.enqueue(data)
+	}
+}
+
+// publish 向订阅了该频道、账户和交易对的客户端推送消息
+func (s *DAppAPIServer) publish(channel, account, symbol string, message wsMessage) {
+	s.clientsMutex.RLock()
+	defer s.clientsMutex.RUnlock()
+
+	var data []byte
+	for client := range s.clients {
+		if !client.wants(channel, account, symbol) {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(message); err != nil {
+				logrus.Errorf("序列化WebSocket消息 %s 失败: %v", message.Type, err)
+				return
+			}
+		}
+		client.enqueue(data)
+	}
+}
+
+// enqueue 将消息放入发送缓冲，调用方需持有 clientsMutex 以保证发送通道未关闭
+func (c *wsClient) enqueue(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		logrus.Debugf("WebSocket客户端 %s 发送缓冲已满，丢弃消息", c.conn.RemoteAddr())
+	}
+}
+
+// HandleData 实现 market.DataHandler 接口，记录最新行情并推送给订阅了该交易对行情的客户端
+func (s *DAppAPIServer) HandleData(data market.MarketData) {
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	s.tickersMutex.Lock()
+	history, ok := s.tickers[data.Symbol]
+	if !ok {
+		history = &tickerHistory{}
+		s.tickers[data.Symbol] = history
+	}
+	history.add(timestamp, data.Close)
+	ticker := history.ticker(data.Symbol)
+	s.tickersMutex.Unlock()
+
+	s.publish(wsChannelMarket, "", data.Symbol, newMarketUpdateMessage([]marketTicker{ticker}))
+}
+
+// HandleEvent 实现 events.Handler 接口，将信号、风险拒绝、成交、持仓变化和审批请求实时推送给订阅的客户端
+func (s *DAppAPIServer) HandleEvent(event events.Event) {
+	account := event.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	switch payload := event.Payload.(type) {
+	case strategy.Signal:
+		s.publish(wsChannelSignals, account, event.Symbol, newSignalMessage(payload))
+	case risk.Rejection:
+		s.publish(wsChannelRisk, account, event.Symbol, newRiskRejectionMessage(payload))
+	case execution.Order:
+		s.publish(wsChannelTrades, account, event.Symbol, newFillMessage(payload))
+	case execution.Position:
+		s.publish(wsChannelPositions, account, event.Symbol, newExchangePositionUpdateMessage(payload))
+	case approval.Request:
+		s.publish(wsChannelApprovals, account, event.Symbol, newApprovalMessage(payload))
+	}
+}
+
+// handleApprovalRequest 处理客户端的批准或拒绝操作，需要 trader 角色，返回审批后的请求或错误消息
+func (s *DAppAPIServer) handleApprovalRequest(client *wsClient, request wsRequest) wsMessage {
+	if s.cfg.System.Auth.Enabled && roleLevels[client.key.role] < roleLevels[roleTrader] {
+		return newErrorMessage("权限不足，需要 " + roleTrader + " 角色")
+	}
+	if request.ID == "" {
+		return newErrorMessage("缺少审批请求ID")
+	}
+
+	decided, _, err := s.decideApproval(request.ID, client.account, client.key.name, request.Action == "approve", request.Reason)
+	if err != nil {
+		return newErrorMessage(err.Error())
+	}
+	return newApprovalMessage(decided)
+}
+
+// getLatestMarketData 获取各交易对的最新行情，按交易对排序
+func (s *DAppAPIServer) getLatestMarketData() []marketTicker {
+	s.tickersMutex.RLock()
+	defer s.tickersMutex.RUnlock()
+
+	tickers := make([]marketTicker, 0, len(s.tickers))
+	for pair, history := range s.tickers {
+		tickers = append(tickers, history.ticker(pair))
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair < tickers[j].Pair
+	})
+	return tickers
+}
+
+// broadcastUpdates 定期检查订单状态和链上持仓，将发生变化的推送给订阅的客户端
+// 交易所成交和持仓变化通过事件总线实时推送，见 HandleEvent
+func (s *DAppAPIServer) broadcastUpdates() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	// 记录上次推送时的订单状态和持仓数量，只推送发生变化的订单和持仓
+	lastOrders := make(map[string]string)
+	lastPositions := make(map[string]BlockchainPosition)
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.publishExchangeUpdates(lastOrders)
+			s.publishBlockchainUpdates(lastOrders, lastPositions)
+		}
+	}
+}
+
+// publishExchangeUpdates 推送状态或成交数量发生变化的交易所订单
+func (s *DAppAPIServer) publishExchangeUpdates(lastOrders map[string]string) {
+	if s.exchangeExecutor == nil {
+		return
+	}
+
+	for id, order := range s.exchangeExecutor.GetOrders() {
+		state := order.Status + "/" + order.FilledQuantity.String()
+		if lastOrders[id] != state {
+			lastOrders[id] = state
+			s.publish(wsChannelTrades, order.Account, order.Symbol, newExchangeOrderUpdateMessage(order))
+		}
+	}
+}
+
+// publishBlockchainUpdates 推送状态发生变化的链上订单，以及数量发生变化的链上持仓
+func (s *DAppAPIServer) publishBlockchainUpdates(lastOrders map[string]string, lastPositions map[string]BlockchainPosition) {
+	if s.executor == nil {
+		return
+	}
+
+	for id, order := range s.executor.GetBlockchainOrders() {
+		if lastOrders[id] != order.Status {
+			lastOrders[id] = order.Status
+			s.publish(wsChannelTrades, order.Account, order.Symbol, newOrderUpdateMessage(order))
+		}
+	}
+
+	// 已清仓的持仓以数量0推送
+	positions := s.executor.GetBlockchainPositions()
+	for key, position := range positions {
+		last, ok := lastPositions[key]
+		if !ok || !last.Quantity.Equal(position.Quantity) {
+			lastPositions[key] = position
+			s.publish(wsChannelPositions, position.Account, position.Symbol, newPositionUpdateMessage(position))
+		}
+	}
+	for key, last := range lastPositions {
+		if _, ok := positions[key]; !ok {
+			delete(lastPositions, key)
+			last.Quantity = decimal.Zero
+			s.publish(wsChannelPositions, last.Account, last.Symbol, newPositionUpdateMessage(last))
+		}
+	}
+}
+
+// sortedKeys 返回集合中排好序的键
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,19 +2,24 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/fees"
+	"autotransaction/internal/metrics"
 	"autotransaction/internal/risk"
+	"autotransaction/internal/store"
 	"autotransaction/internal/strategy"
 
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
@@ -22,77 +27,193 @@ import (
 
 // BlockchainOrder 表示区块链上的交易订单
 type BlockchainOrder struct {
-	ID           string
-	Symbol       string
-	Direction    string // "buy" 或 "sell"
-	Price        decimal.Decimal
-	Quantity     decimal.Decimal
-	Status       string // "pending", "confirmed", "failed"
-	Network      string
-	TxHash       string
-	BlockNumber  uint64
-	ErrorMessage string
-	Timestamp    time.Time
+	ID            string
+	Account       string
+	Symbol        string
+	Direction     string // "buy" 或 "sell"
+	Price         decimal.Decimal
+	Quantity      decimal.Decimal
+	Status        string // "pending", "bridging", "gas_queued", "confirmed", "failed", "canceled"
+	Network       string
+	Wallet        string // 签名交易的钱包名称
+	TxHash        string
+	BlockNumber   uint64
+	ErrorMessage  string
+	Regime        string // 下单时的市场状态
+	StrategyName  string // 产生订单的策略实例名称
+	SignalID      string // 产生订单的信号ID
+	ClientOrderID string // 幂等键，同一账户下唯一
+	Timestamp     time.Time
+
+	// 交易成本，交易打包后按交易收据和 fees 配置计算
+	Fee         decimal.Decimal // 流动性池手续费与gas费用之和（计价货币）
+	GasFee      decimal.Decimal // 实际消耗的gas费用（原生币）
+	GasUsed     uint64          // 实际消耗的gas数量
+	GasPrice    decimal.Decimal // 实际gas价格（gwei）
+	GasCost     decimal.Decimal // gas费用换算为计价货币
+	RealizedPnL decimal.Decimal // 卖出订单扣除开平仓手续费和gas费用后的已实现盈亏（计价货币）
+	ClosedFees  decimal.Decimal // 卖出订单平掉部分分摊的开仓费用，区块重组撤销时还原到持仓
+
+	// 交易替换状态，见 handleStuckTransactions
+	SubmittedAt      time.Time // 当前交易的提交时间
+	Replacements     int       // 已发送的替换交易数
+	PreviousTxHashes []string  // 被替换的交易哈希，仍可能先于替换交易被打包
+	Canceling        bool      // 当前交易为取消交易
+	PrivateRelay     bool      // 通过私有交易中继提交，替换交易也经由中继发送
+
+	// 跨链转入资金，见 bridgeAndRetry
+	Bridged      bool   // 已尝试跨链转入，不再重复尝试
+	BridgeTxHash string // 源链上的跨链交易哈希
+
+	// gas价格排队，见 queueForGas
+	GasQueuedAt time.Time // 因gas价格超过上限开始排队的时间
+
+	// 兑换路径，见 routeSwap
+	Route string // router 或聚合器名称
+
+	// 按价格冲击拆单，见 checkMarketImpact
+	ParentID      string          // 拆单子订单对应的原订单ID，子订单不再拆分
+	Splits        int             // 原订单拆分的笔数，原订单执行首笔
+	SplitQuantity decimal.Decimal // 原订单拆分前的数量
+
+	// 区块重组检测，见 checkReorgs
+	BlockHash string // 交易所在区块的哈希
+	Finalized bool   // 确认数已达到 confirmation_depth，不再检查区块重组
+	Reorgs    int    // 因区块重组回到待确认的次数
 }
 
 // BlockchainPosition 表示区块链上的持仓
 type BlockchainPosition struct {
+	Account      string
 	Symbol       string
 	Network      string
 	TokenAddress string
 	Quantity     decimal.Decimal
-	EntryPrice   decimal.Decimal
+	EntryPrice   decimal.Decimal // 为0表示持仓成本未知
 	CurrentPrice decimal.Decimal
+	Recovered    bool // 是否为启动时从链上余额恢复的持仓
 	Timestamp    time.Time
+
+	Fees decimal.Decimal // 未平部分的开仓手续费和gas费用（计价货币），平仓时按数量分摊计入已实现盈亏
 }
 
 // BlockchainExecutor 负责在区块链上执行交易
 type BlockchainExecutor struct {
-	cfg         *config.Config
-	riskManager *risk.RiskManager
-	clients     map[string]*ethclient.Client // 每个网络一个客户端
-	privateKey  *ecdsa.PrivateKey
-	positions   map[string]BlockchainPosition
-	orders      map[string]BlockchainOrder
-	mutex       sync.RWMutex
-	ctx         context.Context
-	cancel      context.CancelFunc
+	cfg            *config.Config
+	riskManager    *risk.RiskManager
+	fees           *fees.Model
+	dexPrices      strategy.DEXPriceProvider     // 为nil时下单前不检查滑点，并按 fees.dex.gas_cost 估算gas费用
+	clients        map[string]*ethclient.Client  // 每个网络一个客户端
+	wallets        map[string]*wallet            // 键为钱包名称
+	positions      map[string]BlockchainPosition // 键为 账户-交易对-网络
+	orders         map[string]BlockchainOrder
+	clientOrders   map[string]string            // 幂等键到订单ID的映射，键为 账户-幂等键
+	privateClients map[string]*ethclient.Client // 配置了私有交易中继的网络
+	rpcPools       map[string]*rpcPool          // 配置了多个RPC节点的网络
+	aggregators    map[string]Aggregator        // 配置了DEX聚合器的网络
+	mempool        map[string]*mempoolWatcher   // 启用了内存池监控的网络
+	bridge         Bridge                       // 未启用跨链转账时为nil
+	safety         *tokenSafetyChecker          // 买入前的代币安全检查，见 checkTokenSafety
+	store          store.Store                  // 为nil时不持久化
+	audit          *audit.Log                   // 为nil时不记录审计日志
+	metrics        *metrics.Metrics             // 为nil时不记录监控指标
+	events         *events.Bus                  // 为nil时不发布事件
+	mutex          sync.RWMutex
+	approvalMutex  sync.Mutex // 串行化代币授权
+	ctx            context.Context
+	cancel         context.CancelFunc
+
+	jobs *execution.JobQueue // 区块链信号的订单队列，与交易执行器相同的优先级、重试和死信处理
 }
 
 // NewBlockchainExecutor 创建一个新的区块链交易执行器
 func NewBlockchainExecutor(cfg *config.Config, riskManager *risk.RiskManager) (*BlockchainExecutor, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// 解析私钥
-	privateKey, err := crypto.HexToECDSA(cfg.Blockchain.Contracts.WalletPrivateKey)
+	// 加载签名钱包
+	wallets, err := loadWallets(cfg.Blockchain.Contracts)
 	if err != nil {
-		return nil, fmt.Errorf("解析私钥失败: %v", err)
+		cancel()
+		return nil, err
 	}
 
 	executor := &BlockchainExecutor{
-		cfg:         cfg,
-		riskManager: riskManager,
-		clients:     make(map[string]*ethclient.Client),
-		privateKey:  privateKey,
-		positions:   make(map[string]BlockchainPosition),
-		orders:      make(map[string]BlockchainOrder),
-		ctx:         ctx,
-		cancel:      cancel,
+		cfg:            cfg,
+		riskManager:    riskManager,
+		fees:           fees.NewModel(cfg),
+		clients:        make(map[string]*ethclient.Client),
+		wallets:        wallets,
+		positions:      make(map[string]BlockchainPosition),
+		orders:         make(map[string]BlockchainOrder),
+		clientOrders:   make(map[string]string),
+		privateClients: make(map[string]*ethclient.Client),
+		rpcPools:       make(map[string]*rpcPool),
+		aggregators:    make(map[string]Aggregator),
+		safety:         newTokenSafetyChecker(cfg),
+		mempool:        make(map[string]*mempoolWatcher),
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 
+	executor.jobs = execution.NewJobQueue(cfg, executor.submitSignal)
+
 	// 初始化每个区块链网络的客户端
 	for _, network := range cfg.Blockchain.Networks {
 		if !network.Enabled {
 			continue
 		}
 
-		client, err := ethclient.Dial(network.RPCURL)
+		client, pool, err := dialNetwork(network)
 		if err != nil {
+			cancel()
 			return nil, fmt.Errorf("连接到区块链网络 %s 失败: %v", network.Name, err)
 		}
 
 		executor.clients[network.Name] = client
+		if pool != nil {
+			executor.rpcPools[network.Name] = pool
+		}
+		for _, w := range wallets {
+			if w.usableOn(network.Name) {
+				w.nonces[network.Name] = newNonceManager(network.Name, client, w.address)
+			}
+		}
 		logrus.Infof("已连接到区块链网络: %s", network.Name)
+
+		aggregator, err := newAggregator(network.Router.Aggregator)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("网络 %s: %v", network.Name, err)
+		}
+		if aggregator != nil {
+			executor.aggregators[network.Name] = aggregator
+		}
+
+		// MEV防护: 私有交易中继和内存池监控
+		if network.MEV.PrivateRPCURL != "" {
+			privateClient, err := ethclient.Dial(network.MEV.PrivateRPCURL)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("连接到网络 %s 的私有交易中继失败: %v", network.Name, err)
+			}
+			executor.privateClients[network.Name] = privateClient
+		}
+		if network.MEV.MempoolWatch {
+			if network.WSURL == "" {
+				logrus.Warnf("网络 %s 未配置 ws_url，无法监控内存池", network.Name)
+			} else {
+				executor.mempool[network.Name] = newMempoolWatcher(network, executor.walletAddresses(network.Name), cfg.Current().Trading.Pairs)
+			}
+		}
+	}
+
+	if cfg.Blockchain.Bridge.Enabled {
+		bridge, err := newBridge(cfg.Blockchain.Bridge)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		executor.bridge = bridge
 	}
 
 	return executor, nil
@@ -102,8 +223,29 @@ func NewBlockchainExecutor(cfg *config.Config, riskManager *risk.RiskManager) (*
 func (b *BlockchainExecutor) Start() error {
 	logrus.Info("启动区块链交易执行器")
 
-	// 启动订单状态更新协程
+	// 从存储中恢复订单和持仓
+	if err := b.loadHistory(); err != nil {
+		return fmt.Errorf("加载历史订单失败: %v", err)
+	}
+
+	// 根据链上余额恢复持仓
+	if b.cfg.Blockchain.RecoverPositions {
+		b.recoverPositions()
+	}
+
+	// 启动订单状态更新协程和订单队列
 	go b.updateOrderStatus()
+	go b.jobs.Run(b.ctx)
+	if b.cfg.Current().Risk.GasQueue.Enabled && b.cfg.Current().Risk.MaxGasPrice != "" {
+		go b.retryGasQueue()
+	}
+
+	for _, watcher := range b.mempool {
+		go watcher.run(b.ctx)
+	}
+	for _, pool := range b.rpcPools {
+		go pool.run(b.ctx)
+	}
 
 	return nil
 }
@@ -118,128 +260,240 @@ func (b *BlockchainExecutor) Stop() {
 		client.Close()
 		logrus.Infof("已断开与区块链网络 %s 的连接", name)
 	}
+	for _, pool := range b.rpcPools {
+		pool.close()
+	}
 }
 
 // HandleSignal 实现 strategy.SignalHandler 接口
 func (b *BlockchainExecutor) HandleSignal(signal strategy.Signal) {
-	// 检查该交易对是否配置为区块链交易
-	var blockchain, contractAddress string
+	// 检查该交易对是否配置为区块链交易，指定在交易所执行的信号由交易执行器处理
+	if signal.Venue == strategy.VenueExchange || b.pairNetwork(signal.Symbol) == "" {
+		return
+	}
 
-	for _, pair := range b.cfg.Trading.Pairs {
-		if pair.Symbol == signal.Symbol && pair.Blockchain != "" {
-			blockchain = pair.Blockchain
-			contractAddress = pair.ContractAddress
-			break
+	if err := b.jobs.Enqueue(signal); err != nil {
+		logrus.Warnf("区块链信号 %s %s 已拒绝: %v", signal.Symbol, signal.Direction, err)
+	}
+}
+
+// pairNetwork 返回交易对配置的区块链网络，不是区块链交易对时返回空
+func (b *BlockchainExecutor) pairNetwork(symbol string) string {
+	for _, pair := range b.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol && pair.Blockchain != "" {
+			return pair.Blockchain
 		}
 	}
+	return ""
+}
 
+// submitSignal 执行订单队列中的区块链信号：风险检查、创建订单并发送交易
+// 节点暂时不可用时返回包装了 execution.ErrTransient 的错误，由订单队列稍后重试
+func (b *BlockchainExecutor) submitSignal(signal strategy.Signal) error {
+	blockchain := b.pairNetwork(signal.Symbol)
 	if blockchain == "" {
-		// 不是区块链交易对，忽略
-		return
+		return fmt.Errorf("%s 不是区块链交易对", signal.Symbol)
+	}
+
+	account := signal.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	if existing, ok := b.findClientOrder(account, signal.ClientOrderID); ok {
+		logrus.Infof("幂等键 %s 已创建链上订单 %s，不重复下单", signal.ClientOrderID, existing.ID)
+		return nil
 	}
 
 	// 检查风险控制
-	if !b.riskManager.CheckSignal(signal) {
-		logrus.Warnf("区块链信号 %s %s 未通过风险检查，已拒绝", signal.Symbol, signal.Direction)
-		return
+	if err := b.riskManager.ValidateSignal(signal); err != nil {
+		return fmt.Errorf("未通过风险检查: %v", err)
+	}
+
+	// 选择交易对使用的钱包
+	w, err := b.pairWallet(signal.Symbol, blockchain)
+	if err != nil {
+		b.riskManager.ReleaseSlippageProbe(signal.Symbol)
+		return fmt.Errorf("无可用钱包: %v", err)
 	}
 
 	// 创建订单
 	order := BlockchainOrder{
-		ID:        generateBlockchainOrderID(),
-		Symbol:    signal.Symbol,
-		Direction: signal.Direction,
-		Price:     signal.Price,
-		Quantity:  signal.Quantity,
-		Status:    "pending",
-		Network:   blockchain,
-		Timestamp: time.Now(),
-	}
-
-	// 执行区块链订单
-	b.executeBlockchainOrder(order, contractAddress)
+		ID:            generateBlockchainOrderID(),
+		Account:       account,
+		Symbol:        signal.Symbol,
+		Direction:     signal.Direction,
+		Price:         signal.Price,
+		Quantity:      signal.Quantity,
+		Status:        "pending",
+		Network:       blockchain,
+		Wallet:        w.name,
+		Regime:        signal.Regime,
+		StrategyName:  signal.StrategyName,
+		SignalID:      signal.ID,
+		ClientOrderID: signal.ClientOrderID,
+		Timestamp:     time.Now(),
+	}
+
+	// 幂等键已被并发提交的相同信号占用时不重复下单
+	if existing, added := b.addOrderOnce(order); !added {
+		b.riskManager.ReleaseSlippageProbe(signal.Symbol)
+		logrus.Infof("幂等键 %s 已创建链上订单 %s，不重复下单", order.ClientOrderID, existing.ID)
+		return nil
+	}
+
+	// 订单发送失败时释放试单；暂时性失败的订单已标记为失败，释放幂等键以便重试时重新下单
+	err = b.executeBlockchainOrder(order)
+	if err != nil {
+		b.riskManager.ReleaseSlippageProbe(signal.Symbol)
+	}
+	if errors.Is(err, execution.ErrTransient) {
+		b.releaseClientOrder(order)
+	}
+	return err
+}
+
+// failOrder 将订单标记为失败并返回失败原因
+func (b *BlockchainExecutor) failOrder(order *BlockchainOrder, err error) error {
+	order.Status = "failed"
+	order.ErrorMessage = err.Error()
+	b.updateOrderInMap(*order)
+	return err
 }
 
-// executeBlockchainOrder 执行区块链订单
-func (b *BlockchainExecutor) executeBlockchainOrder(order BlockchainOrder, contractAddress string) {
+// QueuedJobs 返回区块链订单队列中等待执行的任务
+func (b *BlockchainExecutor) QueuedJobs() []execution.Job {
+	return b.jobs.Jobs()
+}
+
+// DeadLetters 返回区块链订单死信队列中的任务，最近失败的在后
+func (b *BlockchainExecutor) DeadLetters() []execution.Job {
+	return b.jobs.DeadLetters()
+}
+
+// RetryDeadLetter 将死信队列中的任务重新放入区块链订单队列
+func (b *BlockchainExecutor) RetryDeadLetter(id string) (execution.Job, error) {
+	return b.jobs.RetryDeadLetter(id)
+}
+
+// executeBlockchainOrder 执行区块链订单，失败时将订单标记为失败并返回原因
+func (b *BlockchainExecutor) executeBlockchainOrder(order BlockchainOrder) error {
 	logrus.Infof("执行区块链订单: %s %s %s 价格: %s 数量: %s 网络: %s",
 		order.ID, order.Symbol, order.Direction, order.Price.String(), order.Quantity.String(), order.Network)
 
 	// 获取对应的客户端
 	client, ok := b.clients[order.Network]
 	if !ok {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("未找到网络 %s 的客户端", order.Network)
-		b.updateOrderInMap(order)
-		return
+		return b.failOrder(&order, fmt.Errorf("未找到网络 %s 的客户端", order.Network))
 	}
 
-	// 获取当前账户地址
-	publicKey := b.privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		order.Status = "failed"
-		order.ErrorMessage = "无法转换公钥"
-		b.updateOrderInMap(order)
-		return
+	// 获取订单使用的钱包
+	w, err := b.orderWallet(order)
+	if err != nil {
+		return b.failOrder(&order, err)
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	fromAddress := w.address
 
 	// 获取网络ID和nonce
 	networkID, err := client.NetworkID(context.Background())
 	if err != nil {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("获取网络ID失败: %v", err)
-		b.updateOrderInMap(order)
-		return
+		return b.failOrder(&order, fmt.Errorf("%w: 获取网络ID失败: %v", execution.ErrTransient, err))
 	}
 
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("获取nonce失败: %v", err)
-		b.updateOrderInMap(order)
-		return
+	// gas价格超过上限时拒绝订单，启用排队时等待gas价格回落后再执行
+	if err := b.checkGasCap(client, order.Network); err != nil {
+		if errors.Is(err, errGasPriceTooHigh) && b.cfg.Current().Risk.GasQueue.Enabled {
+			b.queueForGas(order, err)
+			return nil
+		}
+		return b.failOrder(&order, err)
 	}
 
-	// 获取gas价格
-	gasPrice, err := b.getGasPrice(client, order.Network)
+	// 买入前检查标的代币合约，拒绝疑似貔貅盘等不安全的代币
+	if err := b.checkTokenSafety(order); err != nil {
+		return b.failOrder(&order, err)
+	}
+
+	// 按流动性池储备量估算价格冲击，超过上限时拒绝订单或拆单
+	if _, err := b.checkMarketImpact(client, &order); err != nil {
+		return b.failOrder(&order, err)
+	}
+
+	// 按最新链上价格检查滑点，最少获得数量按（可能重新定价后的）订单价格计算
+	if err := b.checkQuoteSlippage(&order); err != nil {
+		return b.failOrder(&order, err)
+	}
+
+	// 按订单构建DEX路由合约的兑换调用
+	swap, err := b.buildSwap(client, order, fromAddress)
 	if err != nil {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("获取gas价格失败: %v", err)
-		b.updateOrderInMap(order)
-		return
+		return b.failOrder(&order, fmt.Errorf("构建兑换交易失败: %v", err))
+	}
+	if !swap.quantity.Equal(order.Quantity) {
+		logrus.Infof("区块链订单 %s 的数量按代币精度从 %s 截断为 %s", order.ID, order.Quantity.String(), swap.quantity.String())
+		order.Quantity = swap.quantity
+	}
+	order.Route = swap.route
+
+	// 检查钱包的输入代币余额
+	balanceCtx, balanceCancel := context.WithTimeout(b.ctx, 10*time.Second)
+	err = checkTokenBalance(balanceCtx, client, fromAddress, swap)
+	balanceCancel()
+	if err != nil && b.bridge != nil && !order.Bridged {
+		logrus.Infof("订单 %s 在 %s 上%v，尝试从其他网络跨链转入", order.ID, order.Network, err)
+		go b.bridgeAndRetry(order, w, swap)
+		return nil
+	}
+	if err != nil {
+		return b.failOrder(&order, err)
 	}
 
-	// 创建交易（实际实现中，需要调用特定DEX的智能合约函数）
-	// 这里简化为发送以太币交易作为示例
-	contractAddr := common.HexToAddress(contractAddress)
+	// 确保路由合约对输入代币有足够的授权额度
+	if err := b.ensureAllowance(client, order.Network, networkID, w, swap); err != nil {
+		return b.failOrder(&order, fmt.Errorf("代币授权失败: %v", err))
+	}
 
-	// 根据交易方向构建交易数据
-	var data []byte
-	var value *big.Int
+	// 按MEV防护配置选择提交节点，有夹子风险时可改走私有交易中继
+	sendClient, private, err := b.submissionClient(order.Network, client, swap)
+	if err != nil {
+		return b.failOrder(&order, fmt.Errorf("MEV防护: %v", err))
+	}
 
-	// 实际实现中，这里需要根据具体DEX的ABI构建交易数据
-	// 以下是示例代码，实际项目中需要替换
-	if order.Direction == "buy" {
-		// 买入操作
-		// 示例：调用DEX合约的swap函数
-		data = []byte("buyTokens") // 应替换为实际的合约调用数据
-		value = big.NewInt(0)      // 如果需要发送ETH，这里设置数量
-	} else {
-		// 卖出操作
-		data = []byte("sellTokens") // 应替换为实际的合约调用数据
-		value = big.NewInt(0)
+	nonces := w.nonces[order.Network]
+	nonce, err := nonces.Next(context.Background())
+	if err != nil {
+		return b.failOrder(&order, fmt.Errorf("%w: 获取nonce失败: %v", execution.ErrTransient, err))
 	}
 
-	// 获取网络的gas限制
-	var gasLimit uint64
-	for _, network := range b.cfg.Blockchain.Networks {
-		if network.Name == order.Network {
-			gasLimit = uint64(network.GasLimit)
-			break
-		}
+	// 获取gas价格
+	gasPrice, err := b.getGasPrice(client, order.Network)
+	if err != nil {
+		nonces.Reset(nonce)
+		return b.failOrder(&order, fmt.Errorf("%w: 获取gas价格失败: %v", execution.ErrTransient, err))
+	}
+
+	contractAddr := swap.router
+	data := swap.data
+	value := big.NewInt(0) // 代币兑换代币，不发送原生币
+	logrus.Infof("订单 %s 兑换输入: %s 最少获得: %s", order.ID, swap.amountIn.String(), swap.amountOutMin.String())
+
+	// 估算交易的gas上限
+	networkCfg, _ := b.networkConfig(order.Network)
+	gasLimit := b.resolveGasLimit(client, networkCfg, ethereum.CallMsg{
+		From:     fromAddress,
+		To:       &contractAddr,
+		GasPrice: gasPrice,
+		Value:    value,
+		Data:     data,
+	})
+
+	// 检查原生币余额是否足够支付gas
+	gasCtx, gasCancel := context.WithTimeout(b.ctx, 10*time.Second)
+	err = checkGasBalance(gasCtx, client, fromAddress, gasLimit, gasPrice, networkCfg.GasReserve)
+	gasCancel()
+	if err != nil {
+		nonces.Reset(nonce)
+		return b.failOrder(&order, err)
 	}
 
 	// 创建交易
@@ -253,29 +507,37 @@ func (b *BlockchainExecutor) executeBlockchainOrder(order BlockchainOrder, contr
 	)
 
 	// 签名交易
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(networkID), b.privateKey)
+	signedTx, err := w.sign(b.ctx, tx, networkID)
 	if err != nil {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("签名交易失败: %v", err)
-		b.updateOrderInMap(order)
-		return
+		nonces.Reset(nonce)
+		return b.failOrder(&order, fmt.Errorf("签名交易失败: %v", err))
 	}
 
 	// 发送交易
-	err = client.SendTransaction(context.Background(), signedTx)
+	err = sendClient.SendTransaction(context.Background(), signedTx)
 	if err != nil {
-		order.Status = "failed"
-		order.ErrorMessage = fmt.Sprintf("发送交易失败: %v", err)
-		b.updateOrderInMap(order)
-		return
+		nonces.Reset(nonce)
+		return b.failOrder(&order, fmt.Errorf("发送交易失败: %v", err))
 	}
+	nonces.Track(signedTx)
 
 	// 更新订单状态
 	order.TxHash = signedTx.Hash().Hex()
 	order.Status = "pending"
+	order.SubmittedAt = time.Now()
+	order.PrivateRelay = private
 	b.updateOrderInMap(order)
 
-	logrus.Infof("区块链交易已提交: %s", order.TxHash)
+	if private {
+		logrus.Infof("区块链交易已通过私有中继提交: %s", order.TxHash)
+	} else {
+		logrus.Infof("区块链交易已提交: %s", order.TxHash)
+	}
+
+	if order.Splits > 1 {
+		b.scheduleSplits(order)
+	}
+	return nil
 }
 
 // updateOrderStatus 更新订单状态
@@ -288,6 +550,10 @@ func (b *BlockchainExecutor) updateOrderStatus() {
 		case <-b.ctx.Done():
 			return
 		case <-ticker.C:
+			b.recoverNonceGaps()
+			b.handleStuckTransactions()
+			b.checkReorgs()
+
 			b.mutex.RLock()
 			pendingOrders := make([]BlockchainOrder, 0)
 			for _, order := range b.orders {
@@ -308,27 +574,45 @@ func (b *BlockchainExecutor) updateOrderStatus() {
 					continue
 				}
 
-				txHash := common.HexToHash(order.TxHash)
-				receipt, err := client.TransactionReceipt(context.Background(), txHash)
-				if err != nil {
+				receipt, minedHash := findReceipt(client, order)
+				if receipt == nil {
 					// 交易可能还未被打包
 					continue
 				}
 
 				// 更新订单状态
 				order.BlockNumber = receipt.BlockNumber.Uint64()
-
-				if receipt.Status == 1 {
+				order.BlockHash = receipt.BlockHash.Hex()
+				b.recordGasSpent(order.Network, receipt)
+				order.GasUsed = receipt.GasUsed
+				order.GasPrice = effectiveGasPrice(receipt)
+				order.GasFee = gasFee(receipt)
+				order.GasCost = b.gasCost(order.Network, order.GasFee)
+				// 取消和失败的交易同样消耗gas
+				order.Fee = order.GasCost
+
+				if order.Canceling && minedHash == order.TxHash {
+					// 取消交易已打包，原交易不会再执行
+					order.Status = "canceled"
+					logrus.Infof("区块链订单 %s 已取消: %s", order.ID, minedHash)
+				} else if receipt.Status == 1 {
 					// 交易成功
+					order.TxHash = minedHash
 					order.Status = "confirmed"
+					order.Fee = order.Fee.Add(b.fees.LPFee(order.Network, order.Price, order.Quantity))
 
-					// 更新持仓
-					b.updateBlockchainPosition(order)
+					// 更新持仓，卖出订单计算扣除费用后的已实现盈亏
+					b.updateBlockchainPosition(&order)
 				} else {
 					// 交易失败
+					order.TxHash = minedHash
 					order.Status = "failed"
 					order.ErrorMessage = "交易执行失败"
 				}
+				// 区块重组后重新打包的订单已记录过费用
+				if order.Reorgs == 0 {
+					b.riskManager.RecordFee(order.Account, order.Fee)
+				}
 
 				b.updateOrderInMap(order)
 			}
@@ -339,22 +623,30 @@ func (b *BlockchainExecutor) updateOrderStatus() {
 // updateOrderInMap 更新订单映射
 func (b *BlockchainExecutor) updateOrderInMap(order BlockchainOrder) {
 	b.mutex.Lock()
-	defer b.mutex.Unlock()
+	eventType := orderEventType(b.orders[order.ID], order)
 	b.orders[order.ID] = order
+	b.saveOrder(order)
+	b.mutex.Unlock()
+
+	if eventType != "" {
+		b.recordOrderEvent(eventType, order)
+	}
 }
 
 // updateBlockchainPosition 更新区块链持仓信息
-func (b *BlockchainExecutor) updateBlockchainPosition(order BlockchainOrder) {
+// 买入订单的费用计入持仓的开仓费用；卖出订单按平仓数量分摊开仓费用，记录扣除开平仓费用后的已实现盈亏
+func (b *BlockchainExecutor) updateBlockchainPosition(order *BlockchainOrder) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	key := fmt.Sprintf("%s-%s", order.Symbol, order.Network)
+	key := fmt.Sprintf("%s-%s", risk.PositionKey(order.Account, order.Symbol), order.Network)
 	position, exists := b.positions[key]
 
 	if order.Direction == "buy" {
 		if !exists {
 			// 新建仓位
 			position = BlockchainPosition{
+				Account:      order.Account,
 				Symbol:       order.Symbol,
 				Network:      order.Network,
 				Quantity:     order.Quantity,
@@ -374,17 +666,30 @@ func (b *BlockchainExecutor) updateBlockchainPosition(order BlockchainOrder) {
 			position.CurrentPrice = order.Price
 			position.Timestamp = time.Now()
 		}
+		position.Fees = position.Fees.Add(order.Fee)
 	} else if order.Direction == "sell" {
 		if !exists {
 			logrus.Warnf("尝试卖出不存在的仓位: %s", key)
+			order.RealizedPnL = order.Fee.Neg()
 			return
 		}
 
+		// 按平仓数量分摊开仓费用，持仓成本未知时只扣除费用
+		closed := decimal.Min(order.Quantity, position.Quantity)
+		order.ClosedFees = position.Fees.Mul(closed).Div(position.Quantity)
+		order.RealizedPnL = order.ClosedFees.Add(order.Fee).Neg()
+		if position.EntryPrice.IsPositive() {
+			order.RealizedPnL = order.RealizedPnL.Add(order.Price.Sub(position.EntryPrice).Mul(closed))
+		}
+		position.Fees = position.Fees.Sub(order.ClosedFees)
+
 		// 减少仓位
 		newQuantity := position.Quantity.Sub(order.Quantity)
 
 		if newQuantity.LessThanOrEqual(decimal.Zero) {
 			// 清仓
+			position.Quantity = decimal.Zero
+			position.CurrentPrice = order.Price
 			delete(b.positions, key)
 		} else {
 			// 部分减仓
@@ -395,18 +700,43 @@ func (b *BlockchainExecutor) updateBlockchainPosition(order BlockchainOrder) {
 		}
 	}
 
-	if exists && position.Quantity.GreaterThan(decimal.Zero) {
+	if position.Quantity.GreaterThan(decimal.Zero) {
 		b.positions[key] = position
 	}
+	b.savePosition(key, position)
 
 	// 通知风险管理器更新持仓信息
-	riskPosition := risk.Position{
-		Symbol:       position.Symbol,
-		Quantity:     position.Quantity,
-		EntryPrice:   position.EntryPrice,
-		CurrentPrice: position.CurrentPrice,
+	b.riskManager.UpdatePosition(b.riskPositionLocked(order.Account, order.Symbol))
+}
+
+// riskPositionLocked 合计账户在各网络上同一交易对的持仓，作为风险管理器中的链上持仓
+// 开仓均价按成本已知的持仓加权，数量为0时风险管理器删除该持仓，调用方需持有 b.mutex
+func (b *BlockchainExecutor) riskPositionLocked(account, symbol string) risk.Position {
+	result := risk.Position{
+		Account: account,
+		Symbol:  symbol,
+		Venue:   strategy.VenueBlockchain,
 	}
-	b.riskManager.UpdatePosition(riskPosition)
+	cost, costQuantity := decimal.Zero, decimal.Zero
+	var latest time.Time
+	for _, position := range b.positions {
+		if position.Account != account || position.Symbol != symbol {
+			continue
+		}
+		result.Quantity = result.Quantity.Add(position.Quantity)
+		if position.EntryPrice.IsPositive() {
+			cost = cost.Add(position.EntryPrice.Mul(position.Quantity))
+			costQuantity = costQuantity.Add(position.Quantity)
+		}
+		if position.CurrentPrice.IsPositive() && !position.Timestamp.Before(latest) {
+			result.CurrentPrice = position.CurrentPrice
+			latest = position.Timestamp
+		}
+	}
+	if costQuantity.IsPositive() {
+		result.EntryPrice = cost.Div(costQuantity)
+	}
+	return result
 }
 
 // getGasPrice 获取gas价格
@@ -420,15 +750,13 @@ func (b *BlockchainExecutor) getGasPrice(client *ethclient.Client, network strin
 		}
 	}
 
-	if gasPrice == "auto" {
+	if gasPrice == "" || gasPrice == "auto" {
 		// 使用网络建议的gas价格
 		return client.SuggestGasPrice(context.Background())
 	}
 
 	// 使用配置的固定gas价格
-	// 假设格式为 "5gwei"
-	// 实际实现应该解析单位，这里简化处理
-	return big.NewInt(5000000000), nil
+	return parseGasPrice(gasPrice)
 }
 
 // GetBlockchainPositions 获取当前所有区块链持仓
@@ -458,8 +786,3 @@ func (b *BlockchainExecutor) GetBlockchainOrders() map[string]BlockchainOrder {
 
 	return result
 }
-
-// generateBlockchainOrderID 生成区块链订单ID
-func generateBlockchainOrderID() string {
-	return fmt.Sprintf("BLOCKCHAIN-ORDER-%d", time.Now().UnixNano())
-}
@@ -0,0 +1,34 @@
+package blockchain
+
+import (
+	"testing"
+
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRiskPositionAggregatesNetworks(t *testing.T) {
+	b := &BlockchainExecutor{positions: map[string]BlockchainPosition{
+		"a-ETH/USDT-ethereum": {Account: "a", Symbol: "ETH/USDT", Network: "ethereum", Quantity: decimal.NewFromInt(1), EntryPrice: decimal.NewFromInt(100)},
+		"a-ETH/USDT-arbitrum": {Account: "a", Symbol: "ETH/USDT", Network: "arbitrum", Quantity: decimal.NewFromInt(3), EntryPrice: decimal.NewFromInt(200)},
+		"b-ETH/USDT-ethereum": {Account: "b", Symbol: "ETH/USDT", Network: "ethereum", Quantity: decimal.NewFromInt(5), EntryPrice: decimal.NewFromInt(100)},
+	}}
+
+	position := b.riskPositionLocked("a", "ETH/USDT")
+	if position.Venue != strategy.VenueBlockchain {
+		t.Fatalf("链上持仓的场所应为 %s，实际 %s", strategy.VenueBlockchain, position.Venue)
+	}
+	if !position.Quantity.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("应合计账户 a 在各网络上的持仓，实际 %s", position.Quantity)
+	}
+	if !position.EntryPrice.Equal(decimal.NewFromInt(175)) {
+		t.Fatalf("开仓均价应按数量加权，实际 %s", position.EntryPrice)
+	}
+
+	// 一个网络平仓后，另一个网络的持仓仍然保留
+	delete(b.positions, "a-ETH/USDT-arbitrum")
+	if position := b.riskPositionLocked("a", "ETH/USDT"); !position.Quantity.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("一个网络平仓后应保留其他网络的持仓，实际 %s", position.Quantity)
+	}
+}
@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/metrics"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultGasMultiplier 未配置安全系数时使用的默认值
+const defaultGasMultiplier = 1.2
+
+// resolveGasLimit 确定交易的gas上限:
+// 启用估算时使用 EstimateGas 结果乘以安全系数，估算失败时回退到配置的 gas_limit，结果不超过 max_gas_limit
+func (b *BlockchainExecutor) resolveGasLimit(client *ethclient.Client, network config.NetworkConfig, msg ethereum.CallMsg) uint64 {
+	gasLimit := uint64(network.GasLimit)
+
+	if network.EstimateGas {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		estimated, err := client.EstimateGas(ctx, msg)
+		cancel()
+
+		if err != nil {
+			logrus.Warnf("网络 %s 估算gas失败，使用配置的gas上限 %d: %v", network.Name, gasLimit, err)
+		} else {
+			multiplier := network.GasMultiplier
+			if multiplier <= 0 {
+				multiplier = defaultGasMultiplier
+			}
+			gasLimit = uint64(math.Ceil(float64(estimated) * multiplier))
+			logrus.Debugf("网络 %s 估算gas: %d，安全系数: %.2f，gas上限: %d", network.Name, estimated, multiplier, gasLimit)
+		}
+	}
+
+	if network.MaxGasLimit > 0 && gasLimit > uint64(network.MaxGasLimit) {
+		logrus.Warnf("网络 %s 的gas上限 %d 超过最大值，限制为 %d", network.Name, gasLimit, network.MaxGasLimit)
+		gasLimit = uint64(network.MaxGasLimit)
+	}
+
+	return gasLimit
+}
+
+// SetMetrics 设置监控指标，统计链上订单和已打包交易消耗的gas费用
+func (b *BlockchainExecutor) SetMetrics(m *metrics.Metrics) {
+	b.metrics = m
+}
+
+// recordGasSpent 按交易收据累计消耗的gas费用（原生币）
+func (b *BlockchainExecutor) recordGasSpent(network string, receipt *types.Receipt) {
+	if receipt.EffectiveGasPrice == nil {
+		return
+	}
+	b.metrics.AddGasSpent(network, gasFee(receipt).InexactFloat64())
+}
+
+// effectiveGasPrice 返回交易收据中的实际gas价格（gwei），收据不含gas价格时为0
+func effectiveGasPrice(receipt *types.Receipt) decimal.Decimal {
+	if receipt.EffectiveGasPrice == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(receipt.EffectiveGasPrice, -9)
+}
+
+// gasFee 返回交易收据中实际消耗的gas费用（原生币），收据不含gas价格时为0
+func gasFee(receipt *types.Receipt) decimal.Decimal {
+	if receipt.EffectiveGasPrice == nil {
+		return decimal.Zero
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	return fromTokenUnits(fee, nativeDecimals)
+}
+
+// gasCost 将消耗的gas费用（原生币）换算为计价货币，
+// 未配置原生币交易对、没有价格来源或价格不可用时使用 fees.dex.gas_cost 估算值
+func (b *BlockchainExecutor) gasCost(network string, native decimal.Decimal) decimal.Decimal {
+	if symbol, ok := b.fees.NativePair(network); ok && b.dexPrices != nil && native.IsPositive() {
+		if price, _, ok := b.dexPrices.LatestDEXPrice(symbol); ok && price.IsPositive() {
+			return native.Mul(price)
+		}
+		logrus.Debugf("网络 %s 的原生币 %s 暂无链上价格，按估计值计算gas费用", network, symbol)
+	}
+	return b.fees.EstimatedGasCost()
+}
+
+// networkConfig 查找网络配置
+func (b *BlockchainExecutor) networkConfig(name string) (config.NetworkConfig, bool) {
+	for _, network := range b.cfg.Blockchain.Networks {
+		if network.Name == name {
+			return network, true
+		}
+	}
+	return config.NetworkConfig{}, false
+}
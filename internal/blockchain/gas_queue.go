@@ -0,0 +1,141 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultGasQueueRetry 未配置时检查排队订单所在网络gas价格的间隔
+const defaultGasQueueRetry = 30 * time.Second
+
+// errGasPriceTooHigh 网络当前gas价格超过 risk.max_gas_price
+var errGasPriceTooHigh = errors.New("gas价格超过上限")
+
+// parseGasPrice 解析如 "20gwei"、"1.5 gwei"、"5000000000" 的gas价格，未指定单位时为wei
+func parseGasPrice(value string) (*big.Int, error) {
+	amount := strings.ToLower(strings.TrimSpace(value))
+	exponent := int32(0)
+	switch {
+	case strings.HasSuffix(amount, "gwei"):
+		amount, exponent = strings.TrimSuffix(amount, "gwei"), 9
+	case strings.HasSuffix(amount, "wei"):
+		amount = strings.TrimSuffix(amount, "wei")
+	}
+	price, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil || price.IsNegative() {
+		return nil, fmt.Errorf("无效的gas价格: %q", value)
+	}
+	return price.Shift(exponent).Truncate(0).BigInt(), nil
+}
+
+// formatGwei 将以wei为单位的gas价格格式化为gwei
+func formatGwei(price *big.Int) string {
+	return decimal.NewFromBigInt(price, -9).StringFixed(2) + " gwei"
+}
+
+// checkGasCap 检查网络当前gas价格是否超过 risk.max_gas_price，超过时返回 errGasPriceTooHigh，未配置上限时不检查
+func (b *BlockchainExecutor) checkGasCap(client *ethclient.Client, network string) error {
+	if b.cfg.Current().Risk.MaxGasPrice == "" {
+		return nil
+	}
+	maxGasPrice, err := parseGasPrice(b.cfg.Current().Risk.MaxGasPrice)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := b.getGasPrice(client, network)
+	if err != nil {
+		return fmt.Errorf("获取gas价格失败: %v", err)
+	}
+	if gasPrice.Cmp(maxGasPrice) > 0 {
+		return fmt.Errorf("%w: 网络 %s 当前为 %s，上限 %s", errGasPriceTooHigh, network, formatGwei(gasPrice), formatGwei(maxGasPrice))
+	}
+	return nil
+}
+
+// queueForGas 将gas价格过高的订单排队，gas价格回落后由 retryGasQueue 重新执行
+func (b *BlockchainExecutor) queueForGas(order BlockchainOrder, reason error) {
+	if order.GasQueuedAt.IsZero() {
+		order.GasQueuedAt = time.Now()
+		logrus.Warnf("区块链订单 %s %v，排队等待gas价格回落", order.ID, reason)
+	}
+	order.Status = "gas_queued"
+	order.ErrorMessage = reason.Error()
+	b.updateOrderInMap(order)
+}
+
+// retryGasQueue 定期检查排队订单所在网络的gas价格，回落到上限以下时执行订单，排队超时的订单放弃
+func (b *BlockchainExecutor) retryGasQueue() {
+	queueCfg := b.cfg.Current().Risk.GasQueue
+	interval := time.Duration(queueCfg.RetryIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultGasQueueRetry
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.processGasQueue()
+		}
+	}
+}
+
+// processGasQueue 处理一轮排队订单，每个网络只查询一次gas价格
+func (b *BlockchainExecutor) processGasQueue() {
+	b.mutex.RLock()
+	queued := make([]BlockchainOrder, 0)
+	for _, order := range b.orders {
+		if order.Status == "gas_queued" {
+			queued = append(queued, order)
+		}
+	}
+	b.mutex.RUnlock()
+	if len(queued) == 0 {
+		return
+	}
+
+	maxWait := time.Duration(b.cfg.Current().Risk.GasQueue.MaxWaitSeconds) * time.Second
+	blocked := make(map[string]bool)
+	for _, order := range queued {
+		if maxWait > 0 && time.Since(order.GasQueuedAt) > maxWait {
+			order.Status = "failed"
+			order.ErrorMessage = fmt.Sprintf("排队 %s 后gas价格仍高于上限，已放弃", maxWait)
+			logrus.Warnf("区块链订单 %s %s", order.ID, order.ErrorMessage)
+			b.updateOrderInMap(order)
+			continue
+		}
+
+		client, ok := b.clients[order.Network]
+		if !ok {
+			continue
+		}
+		high, checked := blocked[order.Network]
+		if !checked {
+			err := b.checkGasCap(client, order.Network)
+			high = err != nil
+			blocked[order.Network] = high
+			if err != nil && !errors.Is(err, errGasPriceTooHigh) {
+				logrus.Warnf("检查网络 %s 的gas价格失败: %v", order.Network, err)
+			}
+		}
+		if high {
+			continue
+		}
+
+		logrus.Infof("网络 %s gas价格已回落到上限以下，执行排队订单 %s", order.Network, order.ID)
+		order.Status = "pending"
+		order.ErrorMessage = ""
+		b.updateOrderInMap(order)
+		go b.executeBlockchainOrder(order)
+	}
+}
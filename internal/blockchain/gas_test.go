@@ -0,0 +1,37 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+func TestResolveGasLimit(t *testing.T) {
+	estimate := func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_estimateGas" {
+			return nil, fmt.Errorf("不支持的方法: %s", method)
+		}
+		return "0x186a0", nil // 100000
+	}
+	failing := func(method string, params []json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("execution reverted")
+	}
+	network := config.NetworkConfig{Name: "ethereum", GasLimit: 300000, EstimateGas: true, GasMultiplier: 1.5}
+	b := &BlockchainExecutor{cfg: &config.Config{}}
+
+	if gasLimit := b.resolveGasLimit(newMockClient(t, estimate), network, ethereum.CallMsg{}); gasLimit != 150000 {
+		t.Fatalf("估算成功时应使用估算值乘以安全系数，实际 %d", gasLimit)
+	}
+	if gasLimit := b.resolveGasLimit(newMockClient(t, failing), network, ethereum.CallMsg{}); gasLimit != 300000 {
+		t.Fatalf("估算失败时应使用配置的 gas_limit，实际 %d", gasLimit)
+	}
+
+	network.MaxGasLimit = 120000
+	if gasLimit := b.resolveGasLimit(newMockClient(t, estimate), network, ethereum.CallMsg{}); gasLimit != 120000 {
+		t.Fatalf("gas上限不应超过 max_gas_limit，实际 %d", gasLimit)
+	}
+}
@@ -0,0 +1,98 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"autotransaction/internal/health"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// defaultMaxBlockLag 未配置时节点最新区块允许落后的时间
+const defaultMaxBlockLag = 60 * time.Second
+
+// CheckHealth 检查各网络RPC节点的连通性和最新区块的落后时间，配置了多个节点的网络附带各节点最近一次检查的结果
+// 节点不可达时该网络为不可用，最新区块落后超过 max_block_lag_seconds 时为降级；
+// 所有网络都不可用时整体为不可用，部分网络异常时整体为降级
+func (b *BlockchainExecutor) CheckHealth(ctx context.Context) health.Result {
+	maxLag := time.Duration(b.cfg.System.Health.MaxBlockLagSeconds) * time.Second
+	if maxLag <= 0 {
+		maxLag = defaultMaxBlockLag
+	}
+
+	b.mutex.RLock()
+	clients := make(map[string]*ethclient.Client, len(b.clients))
+	for name, client := range b.clients {
+		clients[name] = client
+	}
+	pools := make(map[string]*rpcPool, len(b.rpcPools))
+	for name, pool := range b.rpcPools {
+		pools[name] = pool
+	}
+	b.mutex.RUnlock()
+
+	if len(clients) == 0 {
+		return health.Result{Status: health.StatusOK, Message: "未启用区块链网络"}
+	}
+
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	details := make(map[string]interface{}, len(names))
+	var problems []string
+	down := 0
+	for _, name := range names {
+		result := checkNetwork(ctx, clients[name], maxLag)
+		if pool, ok := pools[name]; ok {
+			if result.Details == nil {
+				result.Details = make(map[string]interface{})
+			}
+			result.Details["endpoints"] = pool.status()
+		}
+		details[name] = result
+		if result.Status == health.StatusDown {
+			down++
+		}
+		if result.Status != health.StatusOK {
+			problems = append(problems, name+": "+result.Message)
+		}
+	}
+
+	status := health.StatusOK
+	switch {
+	case down == len(names):
+		status = health.StatusDown
+	case len(problems) > 0:
+		status = health.StatusDegraded
+	}
+	return health.Result{Status: status, Message: strings.Join(problems, "; "), Details: details}
+}
+
+// checkNetwork 检查单个网络的RPC节点
+func checkNetwork(ctx context.Context, client *ethclient.Client, maxLag time.Duration) health.Result {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Message: fmt.Sprintf("获取最新区块失败: %v", err)}
+	}
+
+	lag := time.Since(time.Unix(int64(header.Time), 0))
+	details := map[string]interface{}{
+		"blockNumber":     header.Number.Uint64(),
+		"blockLagSeconds": int64(lag.Seconds()),
+	}
+	if lag > maxLag {
+		return health.Result{
+			Status:  health.StatusDegraded,
+			Message: fmt.Sprintf("最新区块落后 %s", lag.Truncate(time.Second)),
+			Details: details,
+		}
+	}
+	return health.Result{Status: health.StatusOK, Details: details}
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/pkg/utils"
+)
+
+// orderSequence 链上订单ID的序号，避免同一纳秒内生成相同的ID
+var orderSequence uint64
+
+// generateBlockchainOrderID 生成区块链订单ID
+func generateBlockchainOrderID() string {
+	return fmt.Sprintf("BLOCKCHAIN-ORDER-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&orderSequence, 1))
+}
+
+// findClientOrder 查找幂等键已创建的链上订单
+func (b *BlockchainExecutor) findClientOrder(account, clientOrderID string) (BlockchainOrder, bool) {
+	if clientOrderID == "" {
+		return BlockchainOrder{}, false
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	id, ok := b.clientOrders[utils.ClientOrderKey(account, clientOrderID)]
+	if !ok {
+		return BlockchainOrder{}, false
+	}
+	order, ok := b.orders[id]
+	return order, ok
+}
+
+// addOrderOnce 添加新订单，订单的幂等键已被其他订单占用时不添加并返回该订单
+func (b *BlockchainExecutor) addOrderOnce(order BlockchainOrder) (BlockchainOrder, bool) {
+	b.mutex.Lock()
+	if order.ClientOrderID != "" {
+		key := utils.ClientOrderKey(order.Account, order.ClientOrderID)
+		if id, ok := b.clientOrders[key]; ok {
+			existing := b.orders[id]
+			b.mutex.Unlock()
+			return existing, false
+		}
+		b.clientOrders[key] = order.ID
+	}
+	eventType := orderEventType(b.orders[order.ID], order)
+	b.orders[order.ID] = order
+	b.saveOrder(order)
+	b.mutex.Unlock()
+
+	if eventType != "" {
+		b.recordOrderEvent(eventType, order)
+	}
+	return order, true
+}
+
+// releaseClientOrder 释放订单占用的幂等键并清除订单上的幂等键，相同信号重试时可以重新下单
+func (b *BlockchainExecutor) releaseClientOrder(order BlockchainOrder) {
+	if order.ClientOrderID == "" {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := utils.ClientOrderKey(order.Account, order.ClientOrderID)
+	if b.clientOrders[key] == order.ID {
+		delete(b.clientOrders, key)
+	}
+	if current, ok := b.orders[order.ID]; ok {
+		current.ClientOrderID = ""
+		b.orders[order.ID] = current
+		b.saveOrder(current)
+	}
+}
@@ -1,18 +1,39 @@
 package blockchain
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"autotransaction/internal/execution"
 	"autotransaction/internal/llm"
+	"autotransaction/internal/news"
+	"autotransaction/internal/portfolio"
+	"autotransaction/internal/strategy"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// maxStrategyHistory 策略优化时提供给LLM的最近成交数量
+const maxStrategyHistory = 50
+
+// maxPromptArticles 新闻和情绪分析时提供给LLM的最新新闻数量
+const maxPromptArticles = 20
+
+// LLM用量查询的天数
+const (
+	defaultUsageDays = 30
+	maxUsageDays     = 90
+)
+
 // LLMController 处理与LLM相关的API请求
 type LLMController struct {
-	llmService *llm.LLMService
+	llmService      *llm.LLMService
+	strategyManager *strategy.StrategyManager // 为nil时无法优化策略
+	executor        *execution.Executor
+	valuation       *portfolio.ValuationService // 为nil时无法生成投资组合摘要
+	news            *news.Service               // 为nil时没有可供分析的新闻
 }
 
 // NewLLMController 创建一个新的LLM控制器
@@ -22,46 +43,122 @@ func NewLLMController(llmService *llm.LLMService) *LLMController {
 	}
 }
 
-// AnalyzeMarket 分析市场情况
-func (c *LLMController) AnalyzeMarket(ctx *gin.Context) {
-	// 从marketService获取当前市场数据
-	marketData := c.getMarketData()
+// SetTradingSystem 设置策略管理器和交易执行器，策略优化据此获取策略参数和真实绩效
+func (c *LLMController) SetTradingSystem(strategyManager *strategy.StrategyManager, executor *execution.Executor) {
+	c.strategyManager = strategyManager
+	c.executor = executor
+}
 
-	// 调用LLM服务分析市场
-	response, err := c.llmService.AnalyzeMarket(marketData)
+// SetValuationService 设置账户估值服务，投资组合摘要据此获取实时估值
+func (c *LLMController) SetValuationService(valuation *portfolio.ValuationService) {
+	c.valuation = valuation
+}
+
+// SetNewsService 设置新闻服务，新闻分析、市场情绪和走势解释据此获取最新新闻
+func (c *LLMController) SetNewsService(newsService *news.Service) {
+	c.news = newsService
+}
+
+// engineService 按 engine 查询参数选择本次请求使用的LLM引擎，未知的引擎返回400
+func (c *LLMController) engineService(ctx *gin.Context) (*llm.LLMService, bool) {
+	llmService, err := c.llmService.WithEngine(ctx.Query("engine"))
 	if err != nil {
-		logrus.Errorf("LLM市场分析失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "分析市场失败: " + err.Error(),
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
-		return
+		return nil, false
 	}
+	return llmService, true
+}
 
+// ListEngines 获取可用的LLM引擎和默认引擎
+func (c *LLMController) ListEngines(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": response,
+		"data": map[string]interface{}{
+			"default": c.llmService.Engine(),
+			"engines": c.llmService.Engines(),
+		},
+	})
+}
+
+// ListPrompts 获取各提示词当前使用的模板版本和可用的版本
+func (c *LLMController) ListPrompts(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": c.llmService.Prompts(),
+	})
+}
+
+// llmErrorStatus 返回LLM请求失败时的HTTP状态码，超出月度预算时为503
+func llmErrorStatus(err error) int {
+	if errors.Is(err, llm.ErrBudgetExceeded) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// GetUsage 获取LLM的token用量、估算费用和本月预算
+// 支持的查询参数: days (默认30，最多90)
+func (c *LLMController) GetUsage(ctx *gin.Context) {
+	days := defaultUsageDays
+	if value := ctx.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的days参数",
+			})
+			return
+		}
+		days = parsed
+	}
+	if days > maxUsageDays {
+		days = maxUsageDays
+	}
+
+	daily := make([]map[string]interface{}, 0)
+	for _, day := range c.llmService.DailyUsage(days) {
+		daily = append(daily, map[string]interface{}{
+			"date":      day.Date,
+			"total":     day.Total(),
+			"endpoints": day.Endpoints,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"budget": c.llmService.Budget(),
+			"daily":  daily,
+		},
 	})
 }
 
 // OptimizeStrategy 优化交易策略
 func (c *LLMController) OptimizeStrategy(ctx *gin.Context) {
-	// 获取策略ID
-	strategyIDStr := ctx.Param("id")
-	strategyID, err := strconv.ParseUint(strategyIDStr, 10, 64)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的策略ID",
+	if c.strategyManager == nil || c.executor == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "策略管理器不可用",
+		})
+		return
+	}
+
+	// 获取策略数据，ID 为策略实例名称
+	strategyData, ok := c.getStrategyData(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "策略不存在",
 		})
 		return
 	}
 
-	// 获取策略数据
-	strategyData := c.getStrategyData(uint(strategyID))
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
 
 	// 调用LLM服务优化策略
-	response, err := c.llmService.OptimizeStrategy(strategyData)
+	response, err := llmService.OptimizeStrategy(strategyData)
 	if err != nil {
 		logrus.Errorf("LLM策略优化失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "优化策略失败: " + err.Error(),
 		})
 		return
@@ -86,11 +183,16 @@ func (c *LLMController) GetTradingRecommendations(ctx *gin.Context) {
 	// 获取市场数据
 	marketData := c.getMarketData()
 
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
+
 	// 调用LLM服务获取交易建议
-	response, err := c.llmService.GetTradingRecommendations(marketData, userPreferences)
+	response, err := llmService.GetTradingRecommendations(marketData, userPreferences)
 	if err != nil {
 		logrus.Errorf("获取LLM交易建议失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "获取交易建议失败: " + err.Error(),
 		})
 		return
@@ -101,46 +203,27 @@ func (c *LLMController) GetTradingRecommendations(ctx *gin.Context) {
 	})
 }
 
-// AnswerQuestion 回答用户问题
-func (c *LLMController) AnswerQuestion(ctx *gin.Context) {
-	// 从请求体获取问题
-	var request struct {
-		Question string                 `json:"question"`
-		Context  map[string]interface{} `json:"context,omitempty"`
-	}
-
-	if err := ctx.BindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的请求数据",
+// AnalyzeNewsSentiment 分析新闻情感
+func (c *LLMController) AnalyzeNewsSentiment(ctx *gin.Context) {
+	// 获取最新的新闻文章，可通过 asset 查询参数只分析涉及某个资产的新闻
+	newsArticles := c.getLatestNews(ctx.Query("asset"))
+	if len(newsArticles) == 0 {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "暂无可分析的新闻，请检查新闻源配置",
 		})
 		return
 	}
 
-	// 调用LLM服务回答问题
-	response, err := c.llmService.AnswerQuestion(request.Question, request.Context)
-	if err != nil {
-		logrus.Errorf("LLM回答问题失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "回答问题失败: " + err.Error(),
-		})
+	llmService, ok := c.engineService(ctx)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": response,
-	})
-}
-
-// AnalyzeNewsSentiment 分析新闻情感
-func (c *LLMController) AnalyzeNewsSentiment(ctx *gin.Context) {
-	// 获取最新的新闻文章
-	newsArticles := c.getLatestNews()
-
 	// 调用LLM服务分析新闻
-	response, err := c.llmService.AnalyzeNews(newsArticles)
+	response, err := llmService.AnalyzeNews(newsArticles)
 	if err != nil {
 		logrus.Errorf("LLM新闻分析失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "分析新闻失败: " + err.Error(),
 		})
 		return
@@ -166,11 +249,16 @@ func (c *LLMController) ExplainTrade(ctx *gin.Context) {
 	// 获取交易数据
 	tradeData := c.getTradeData(uint(tradeID))
 
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
+
 	// 调用LLM服务解释交易
-	response, err := c.llmService.ExplainTrade(tradeData)
+	response, err := llmService.ExplainTrade(tradeData)
 	if err != nil {
 		logrus.Errorf("LLM解释交易失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "解释交易失败: " + err.Error(),
 		})
 		return
@@ -192,11 +280,16 @@ func (c *LLMController) AnalyzePortfolioRisk(ctx *gin.Context) {
 		return
 	}
 
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
+
 	// 调用LLM服务分析投资组合风险
-	response, err := c.llmService.AnalyzePortfolioRisk(portfolioData)
+	response, err := llmService.AnalyzePortfolioRisk(portfolioData)
 	if err != nil {
 		logrus.Errorf("LLM投资组合风险分析失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "分析投资组合风险失败: " + err.Error(),
 		})
 		return
@@ -212,11 +305,16 @@ func (c *LLMController) GetMarketSummary(ctx *gin.Context) {
 	// 获取市场数据
 	marketData := c.getMarketData()
 
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
+
 	// 调用LLM服务获取市场摘要
-	response, err := c.llmService.GetMarketSummary(marketData)
+	response, err := llmService.GetMarketSummary(marketData)
 	if err != nil {
 		logrus.Errorf("LLM市场摘要获取失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "获取市场摘要失败: " + err.Error(),
 		})
 		return
@@ -247,39 +345,38 @@ func (c *LLMController) getMarketData() map[string]interface{} {
 	}
 }
 
-// getStrategyData 获取策略数据
-func (c *LLMController) getStrategyData(strategyID uint) map[string]interface{} {
-	// 示例数据，实际应用中应该从数据库或策略管理器获取
-	return map[string]interface{}{
-		"id":   strategyID,
-		"name": "Moving Average Crossover",
-		"params": map[string]interface{}{
-			"shortPeriod": 5,
-			"longPeriod":  20,
-			"interval":    "1h",
-		},
-		"performance": map[string]interface{}{
-			"totalTrades": 42,
-			"winRate":     68,
-			"avgProfit":   2.4,
-			"totalProfit": 13.5,
-		},
-		"history": []map[string]interface{}{
-			{
-				"timestamp": 1621500000,
-				"action":    "buy",
-				"price":     42000.0,
-				"amount":    0.1,
-			},
-			{
-				"timestamp": 1621600000,
-				"action":    "sell",
-				"price":     43500.0,
-				"amount":    0.1,
-			},
-			// 更多历史数据
-		},
+// getStrategyData 获取策略的配置参数、绩效和最近的成交，绩效根据订单记录计算
+func (c *LLMController) getStrategyData(name string) (map[string]interface{}, bool) {
+	info, ok := c.strategyManager.GetStrategyInfo(name)
+	if !ok {
+		return nil, false
 	}
+
+	orders := c.executor.StrategyOrders(name)
+	report := c.executor.GetPerformanceReport(name)
+	if len(orders) > maxStrategyHistory {
+		orders = orders[len(orders)-maxStrategyHistory:]
+	}
+	history := make([]map[string]interface{}, 0, len(orders))
+	for _, order := range orders {
+		history = append(history, map[string]interface{}{
+			"timestamp": order.Timestamp.Unix(),
+			"pair":      order.Symbol,
+			"action":    order.Direction,
+			"price":     order.AvgFillPrice.InexactFloat64(),
+			"amount":    order.FilledQuantity.InexactFloat64(),
+			"fee":       order.Fee.InexactFloat64(),
+		})
+	}
+
+	return map[string]interface{}{
+		"name":        info.Name,
+		"type":        info.Type,
+		"enabled":     info.Enabled,
+		"params":      info.Params,
+		"performance": performanceReportToMap(report),
+		"history":     history,
+	}, true
 }
 
 // getTradeData 获取交易数据
@@ -301,22 +398,16 @@ func (c *LLMController) getTradeData(tradeID uint) map[string]interface{} {
 	}
 }
 
-// getLatestNews 获取最新新闻
-func (c *LLMController) getLatestNews() []map[string]string {
-	// 示例数据，实际应用中应该从新闻API或数据库获取
-	return []map[string]string{
-		{
-			"title":   "比特币突破7万美元大关",
-			"content": "今日比特币价格突破7万美元大关，创下历史新高。分析师认为，这一上涨趋势可能会持续，因为机构投资者继续增加对加密货币的配置...",
-			"source":  "CoinDesk",
-			"date":    "2023-05-21",
-		},
-		{
-			"title":   "以太坊ETH2.0升级即将完成",
-			"content": "以太坊开发团队宣布，ETH2.0的最终升级阶段即将完成，这将使网络更加高效、安全和可扩展...",
-			"source":  "CryptoNews",
-			"date":    "2023-05-20",
-		},
-		// 更多新闻
+// getLatestNews 获取最新的新闻，asset 不为空时只返回涉及该资产的新闻，未启用新闻源时返回空列表
+func (c *LLMController) getLatestNews(asset string) []map[string]string {
+	if c.news == nil {
+		return []map[string]string{}
+	}
+
+	articles := c.news.Latest(asset, maxPromptArticles)
+	result := make([]map[string]string, 0, len(articles))
+	for _, article := range articles {
+		result = append(result, article.PromptData())
 	}
+	return result
 }
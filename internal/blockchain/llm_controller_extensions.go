@@ -7,23 +7,21 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// GetTradeSuggestions 获取交易建议
+// GetTradeSuggestions 获取结构化的交易建议，未通过校验的建议在 invalid 中返回
 func (c *LLMController) GetTradeSuggestions(ctx *gin.Context) {
 	// 获取市场数据
 	marketData := c.getMarketData()
 
-	// 构建用户偏好
-	userPreferences := map[string]interface{}{
-		"risk_tolerance":     "medium", // 默认值
-		"investment_horizon": "medium_term",
-		"preferred_assets":   []string{"BTC", "ETH"},
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
 	}
 
 	// 调用LLM服务获取交易建议
-	response, err := c.llmService.GetTradeSuggestions(marketData, userPreferences)
+	response, err := llmService.GetTradeSuggestions(marketData, tradeSuggestionPreferences())
 	if err != nil {
 		logrus.Errorf("获取LLM交易建议失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "获取交易建议失败: " + err.Error(),
 		})
 		return
@@ -40,13 +38,18 @@ func (c *LLMController) GetMarketSentiment(ctx *gin.Context) {
 	marketData := c.getMarketData()
 
 	// 获取新闻数据
-	newsData := c.getLatestNews()
+	newsData := c.getLatestNews("")
+
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
 
 	// 调用LLM服务分析市场情绪
-	response, err := c.llmService.AnalyzeMarketSentiment(marketData, newsData)
+	response, err := llmService.AnalyzeMarketSentiment(marketData, newsData)
 	if err != nil {
 		logrus.Errorf("LLM市场情绪分析失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "分析市场情绪失败: " + err.Error(),
 		})
 		return
@@ -73,11 +76,16 @@ func (c *LLMController) GetStrategyRecommendations(ctx *gin.Context) {
 	// 获取市场数据
 	marketData := c.getMarketData()
 
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
+
 	// 调用LLM服务获取策略建议
-	response, err := c.llmService.GetStrategyRecommendations(userPreferences, marketData)
+	response, err := llmService.GetStrategyRecommendations(userPreferences, marketData)
 	if err != nil {
 		logrus.Errorf("获取LLM策略建议失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "获取策略建议失败: " + err.Error(),
 		})
 		return
@@ -94,13 +102,18 @@ func (c *LLMController) ExplainMarketMovements(ctx *gin.Context) {
 	marketData := c.getMarketData()
 
 	// 获取新闻数据
-	newsData := c.getLatestNews()
+	newsData := c.getLatestNews("")
+
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
 
 	// 调用LLM服务解释市场走势
-	response, err := c.llmService.ExplainMarketMovements(marketData, newsData)
+	response, err := llmService.ExplainMarketMovements(marketData, newsData)
 	if err != nil {
 		logrus.Errorf("LLM解释市场走势失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "解释市场走势失败: " + err.Error(),
 		})
 		return
@@ -113,32 +126,26 @@ func (c *LLMController) ExplainMarketMovements(ctx *gin.Context) {
 
 // GetPortfolioSummary 获取投资组合摘要
 func (c *LLMController) GetPortfolioSummary(ctx *gin.Context) {
-	// 获取投资组合数据
-	portfolioData := map[string]interface{}{
-		"assets": []map[string]interface{}{
-			{
-				"symbol": "BTC",
-				"amount": 0.15,
-				"value":  10264.83,
-				"profit": 585.90,
-			},
-			{
-				"symbol": "ETH",
-				"amount": 2.5,
-				"value":  11331.68,
-				"profit": 331.68,
-			},
-		},
-		"total_value":       21596.51,
-		"total_profit":      917.58,
-		"profit_percentage": 4.43,
+	if c.valuation == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "账户估值服务不可用",
+		})
+		return
+	}
+
+	// 获取当前账户按实时价格的估值
+	portfolioData := valuationToMap(c.valuation.Value(currentAccount(ctx)))
+
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
 	}
 
 	// 调用LLM服务获取投资组合摘要
-	response, err := c.llmService.GetPortfolioSummary(portfolioData)
+	response, err := llmService.GetPortfolioSummary(portfolioData)
 	if err != nil {
 		logrus.Errorf("LLM获取投资组合摘要失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "获取投资组合摘要失败: " + err.Error(),
 		})
 		return
@@ -151,14 +158,25 @@ func (c *LLMController) GetPortfolioSummary(ctx *gin.Context) {
 
 // GetNewsAnalysis 获取新闻分析
 func (c *LLMController) GetNewsAnalysis(ctx *gin.Context) {
-	// 获取最新的新闻文章
-	newsArticles := c.getLatestNews()
+	// 获取最新的新闻文章，可通过 asset 查询参数只分析涉及某个资产的新闻
+	newsArticles := c.getLatestNews(ctx.Query("asset"))
+	if len(newsArticles) == 0 {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "暂无可分析的新闻，请检查新闻源配置",
+		})
+		return
+	}
+
+	llmService, ok := c.engineService(ctx)
+	if !ok {
+		return
+	}
 
 	// 调用LLM服务分析新闻
-	response, err := c.llmService.AnalyzeNews(newsArticles)
+	response, err := llmService.AnalyzeNews(newsArticles)
 	if err != nil {
 		logrus.Errorf("LLM新闻分析失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(llmErrorStatus(err), gin.H{
 			"error": "分析新闻失败: " + err.Error(),
 		})
 		return
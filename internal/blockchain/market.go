@@ -3,14 +3,12 @@ package blockchain
 import (
 	"context"
 	"fmt"
-	"math/big"
 	"sync"
 	"time"
 
 	"autotransaction/config"
 	"autotransaction/internal/market"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
@@ -20,8 +18,13 @@ import (
 type BlockchainMarketDataService struct {
 	cfg           *config.Config
 	clients       map[string]*ethclient.Client // 每个网络一个客户端
+	wsClients     map[string]*ethclient.Client // 配置了WebSocket节点的网络，用于订阅池子事件
+	rpcPools      map[string]*rpcPool          // 配置了多个RPC节点的网络
 	handlers      []market.DataHandler
 	handlersMutex sync.RWMutex
+	latest        map[string]market.MarketData // 各交易对最新的链上价格
+	latestMutex   sync.RWMutex
+	history       market.HistoryProvider // 未启用历史数据存储时为nil
 	ctx           context.Context
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup
@@ -31,11 +34,14 @@ type BlockchainMarketDataService struct {
 func NewBlockchainMarketDataService(cfg *config.Config) (*BlockchainMarketDataService, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	service := &BlockchainMarketDataService{
-		cfg:      cfg,
-		clients:  make(map[string]*ethclient.Client),
-		handlers: make([]market.DataHandler, 0),
-		ctx:      ctx,
-		cancel:   cancel,
+		cfg:       cfg,
+		clients:   make(map[string]*ethclient.Client),
+		wsClients: make(map[string]*ethclient.Client),
+		rpcPools:  make(map[string]*rpcPool),
+		handlers:  make([]market.DataHandler, 0),
+		latest:    make(map[string]market.MarketData),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	// 初始化每个区块链网络的客户端
@@ -44,13 +50,24 @@ func NewBlockchainMarketDataService(cfg *config.Config) (*BlockchainMarketDataSe
 			continue
 		}
 
-		client, err := ethclient.Dial(network.RPCURL)
+		client, pool, err := dialNetwork(network)
 		if err != nil {
 			return nil, fmt.Errorf("连接到区块链网络 %s 失败: %v", network.Name, err)
 		}
 
 		service.clients[network.Name] = client
+		if pool != nil {
+			service.rpcPools[network.Name] = pool
+		}
 		logrus.Infof("已连接到区块链网络: %s", network.Name)
+
+		if network.WSURL != "" {
+			wsClient, err := ethclient.Dial(network.WSURL)
+			if err != nil {
+				return nil, fmt.Errorf("连接到区块链网络 %s 的WebSocket节点失败: %v", network.Name, err)
+			}
+			service.wsClients[network.Name] = wsClient
+		}
 	}
 
 	return service, nil
@@ -60,8 +77,8 @@ func NewBlockchainMarketDataService(cfg *config.Config) (*BlockchainMarketDataSe
 func (b *BlockchainMarketDataService) Start() error {
 	logrus.Info("启动区块链市场数据服务")
 
-	// 为每个区块链交易对启动一个数据获取协程
-	for _, pair := range b.cfg.Trading.Pairs {
+	// 为每个区块链交易对启动一个数据获取协程：配置了WebSocket节点时订阅池子事件，否则定时轮询
+	for _, pair := range b.cfg.Current().Trading.Pairs {
 		if !pair.Enabled || pair.Blockchain == "" {
 			continue
 		}
@@ -73,7 +90,20 @@ func (b *BlockchainMarketDataService) Start() error {
 		}
 
 		b.wg.Add(1)
-		go b.fetchDataForPair(pair.Symbol, pair.Blockchain, pair.ContractAddress)
+		if b.subscribesEvents(pair) {
+			go b.subscribePair(pair)
+		} else {
+			go b.fetchDataForPair(pair)
+		}
+	}
+
+	// 定时检查配置了多个RPC节点的网络，选择当前使用的节点
+	for _, pool := range b.rpcPools {
+		b.wg.Add(1)
+		go func(pool *rpcPool) {
+			defer b.wg.Done()
+			pool.run(b.ctx)
+		}(pool)
 	}
 
 	return nil
@@ -90,6 +120,12 @@ func (b *BlockchainMarketDataService) Stop() {
 		client.Close()
 		logrus.Infof("已断开与区块链网络 %s 的连接", name)
 	}
+	for _, client := range b.wsClients {
+		client.Close()
+	}
+	for _, pool := range b.rpcPools {
+		pool.close()
+	}
 }
 
 // RegisterHandler 注册一个数据处理器
@@ -100,14 +136,14 @@ func (b *BlockchainMarketDataService) RegisterHandler(handler market.DataHandler
 }
 
 // fetchDataForPair 为特定交易对获取区块链数据
-func (b *BlockchainMarketDataService) fetchDataForPair(symbol string, blockchain string, contractAddress string) {
+func (b *BlockchainMarketDataService) fetchDataForPair(pair config.PairConfig) {
 	defer b.wg.Done()
 
+	symbol, blockchain := pair.Symbol, pair.Blockchain
 	logrus.Infof("开始获取区块链 %s 上 %s 的市场数据", blockchain, symbol)
 
 	// 获取对应的客户端
 	client := b.clients[blockchain]
-	contract := common.HexToAddress(contractAddress)
 
 	ticker := time.NewTicker(time.Minute) // 每分钟获取一次数据
 	defer ticker.Stop()
@@ -118,9 +154,10 @@ func (b *BlockchainMarketDataService) fetchDataForPair(symbol string, blockchain
 			logrus.Infof("停止获取区块链 %s 上 %s 的市场数据", blockchain, symbol)
 			return
 		case <-ticker.C:
-			// 获取区块链上的价格数据
-			// 这里是示例实现，实际中需要根据具体的DEX合约调用相应方法获取价格
-			price, err := b.getTokenPrice(client, contract)
+			// 按交易对配置的价格来源获取链上价格
+			ctx, cancel := context.WithTimeout(b.ctx, 15*time.Second)
+			price, err := fetchPrice(ctx, client, pair)
+			cancel()
 			if err != nil {
 				logrus.Errorf("获取 %s 价格失败: %v", symbol, err)
 				continue
@@ -144,6 +181,10 @@ func (b *BlockchainMarketDataService) fetchDataForPair(symbol string, blockchain
 
 // distributeData 将数据分发给所有处理器
 func (b *BlockchainMarketDataService) distributeData(data market.MarketData) {
+	b.latestMutex.Lock()
+	b.latest[data.Symbol] = data
+	b.latestMutex.Unlock()
+
 	b.handlersMutex.RLock()
 	defer b.handlersMutex.RUnlock()
 
@@ -152,26 +193,30 @@ func (b *BlockchainMarketDataService) distributeData(data market.MarketData) {
 	}
 }
 
-// getTokenPrice 从区块链获取代币价格（示例实现）
-func (b *BlockchainMarketDataService) getTokenPrice(client *ethclient.Client, tokenAddress common.Address) (decimal.Decimal, error) {
-	// 实际实现中，需要调用特定DEX的智能合约来获取价格
-	// 这里为了示例，返回一个模拟价格
+// LatestDEXPrice 实现 strategy.DEXPriceProvider 接口，返回交易对最新的链上价格及其时间
+func (b *BlockchainMarketDataService) LatestDEXPrice(symbol string) (decimal.Decimal, time.Time, bool) {
+	b.latestMutex.RLock()
+	defer b.latestMutex.RUnlock()
 
-	// 获取最新区块
-	blockNumber, err := client.BlockNumber(context.Background())
-	if err != nil {
-		return decimal.Zero, err
+	data, ok := b.latest[symbol]
+	if !ok {
+		return decimal.Zero, time.Time{}, false
 	}
+	return data.Close, data.Timestamp, true
+}
 
-	// 简单的模拟价格生成逻辑，使用区块号的最后3位
-	price := decimal.NewFromBigInt(big.NewInt(int64(blockNumber%1000)), 0)
-	return price, nil
+// SetHistory 设置本地记录的链上历史K线来源
+func (b *BlockchainMarketDataService) SetHistory(history market.HistoryProvider) {
+	b.history = history
 }
 
-// GetHistoricalData 获取区块链上的历史数据
+// GetHistoricalData 获取区块链上的历史数据，每个交易对只在一个网络上交易，本地历史K线按交易对存储
 func (b *BlockchainMarketDataService) GetHistoricalData(symbol string, blockchain string, interval string, limit int) ([]market.MarketData, error) {
-	// 实际实现中，可能需要查询区块链上的历史事件来获取价格历史
-	// 这里返回模拟数据
+	if b.history != nil {
+		return b.history.Candles(symbol, interval, limit)
+	}
+
+	// 未启用历史数据存储时返回模拟数据
 
 	result := make([]market.MarketData, limit)
 	baseTime := time.Now()
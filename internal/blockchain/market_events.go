@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// resubscribeDelay 事件订阅中断后重新订阅前的等待时间
+const resubscribeDelay = 5 * time.Second
+
+const v2PairEventsABI = `[{"type":"event","name":"Sync","anonymous":false,
+"inputs":[{"name":"reserve0","type":"uint112","indexed":false},{"name":"reserve1","type":"uint112","indexed":false}]},
+{"type":"event","name":"Swap","anonymous":false,
+"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"amount0In","type":"uint256","indexed":false},{"name":"amount1In","type":"uint256","indexed":false},{"name":"amount0Out","type":"uint256","indexed":false},{"name":"amount1Out","type":"uint256","indexed":false},{"name":"to","type":"address","indexed":true}]}]`
+
+const v3PoolEventsABI = `[{"type":"event","name":"Swap","anonymous":false,
+"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"recipient","type":"address","indexed":true},{"name":"amount0","type":"int256","indexed":false},{"name":"amount1","type":"int256","indexed":false},{"name":"sqrtPriceX96","type":"uint160","indexed":false},{"name":"liquidity","type":"uint128","indexed":false},{"name":"tick","type":"int24","indexed":false}]}]`
+
+var (
+	parsedV2PairEventsABI = mustParseABI(v2PairEventsABI)
+	parsedV3PoolEventsABI = mustParseABI(v3PoolEventsABI)
+
+	v2SyncTopic = parsedV2PairEventsABI.Events["Sync"].ID
+	v2SwapTopic = parsedV2PairEventsABI.Events["Swap"].ID
+	v3SwapTopic = parsedV3PoolEventsABI.Events["Swap"].ID
+)
+
+// subscribesEvents 判断交易对是否通过订阅池子事件获取行情
+// 需要网络配置了WebSocket节点且价格来源为池子，Chainlink 喂价仍使用轮询
+func (b *BlockchainMarketDataService) subscribesEvents(pair config.PairConfig) bool {
+	if _, ok := b.wsClients[pair.Blockchain]; !ok {
+		return false
+	}
+	return pair.PriceSource.Type != priceSourceChainlink
+}
+
+// subscribePair 订阅交易对池子的 Swap/Sync 事件，订阅中断后自动重新订阅
+func (b *BlockchainMarketDataService) subscribePair(pair config.PairConfig) {
+	defer b.wg.Done()
+
+	logrus.Infof("开始订阅区块链 %s 上 %s 的池子事件", pair.Blockchain, pair.Symbol)
+	client := b.wsClients[pair.Blockchain]
+
+	for {
+		err := b.watchPoolEvents(client, pair)
+		if b.ctx.Err() != nil {
+			logrus.Infof("停止订阅区块链 %s 上 %s 的池子事件", pair.Blockchain, pair.Symbol)
+			return
+		}
+		logrus.Warnf("订阅 %s 的池子事件中断: %v，%s 后重新订阅", pair.Symbol, err, resubscribeDelay)
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// watchPoolEvents 订阅池子事件并在每次兑换时分发行情，直到订阅出错或服务停止
+func (b *BlockchainMarketDataService) watchPoolEvents(client *ethclient.Client, pair config.PairConfig) error {
+	pool, err := priceSourceAddress(pair)
+	if err != nil {
+		return err
+	}
+	if pair.TokenAddress == "" {
+		return fmt.Errorf("交易对 %s 未配置代币合约地址，无法确定池子中的标的代币", pair.Symbol)
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, 15*time.Second)
+	info, err := loadPoolInfo(ctx, client, pool, common.HexToAddress(pair.TokenAddress))
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	isV3 := pair.PriceSource.Type == priceSourceV3Pool
+	topics := []common.Hash{v2SyncTopic, v2SwapTopic}
+	if isV3 {
+		topics = []common.Hash{v3SwapTopic}
+	}
+
+	logs := make(chan types.Log, 64)
+	sub, err := client.SubscribeFilterLogs(b.ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{pool},
+		Topics:    [][]common.Hash{topics},
+	}, logs)
+	if err != nil {
+		return fmt.Errorf("订阅池子 %s 的事件失败: %v", pool.Hex(), err)
+	}
+	defer sub.Unsubscribe()
+
+	// V2 的 Swap 事件不含价格，使用同一交易中先于它发出的 Sync 事件的储备量计算
+	var lastPrice decimal.Decimal
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case log := <-logs:
+			if log.Removed || len(log.Topics) == 0 {
+				continue
+			}
+
+			var price, volume decimal.Decimal
+			var err error
+			switch {
+			case isV3 && log.Topics[0] == v3SwapTopic:
+				price, volume, err = v3SwapData(info, log)
+			case !isV3 && log.Topics[0] == v2SyncTopic:
+				lastPrice, err = v2SyncPrice(info, log)
+				if err != nil {
+					logrus.Warnf("解析 %s 的Sync事件失败: %v", pair.Symbol, err)
+				}
+				continue
+			case !isV3 && log.Topics[0] == v2SwapTopic:
+				price = lastPrice
+				volume, err = v2SwapVolume(info, log)
+			default:
+				continue
+			}
+			if err != nil {
+				logrus.Warnf("解析 %s 的Swap事件失败: %v", pair.Symbol, err)
+				continue
+			}
+			if price.IsZero() {
+				continue
+			}
+
+			b.distributeData(market.MarketData{
+				Symbol:    pair.Symbol,
+				Timestamp: time.Now(),
+				Open:      price,
+				High:      price,
+				Low:       price,
+				Close:     price,
+				Volume:    volume,
+			})
+		}
+	}
+}
+
+// v2SyncPrice 根据V2交易对 Sync 事件中的储备量计算价格
+func v2SyncPrice(info poolInfo, log types.Log) (decimal.Decimal, error) {
+	out, err := parsedV2PairEventsABI.Unpack("Sync", log.Data)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return info.priceFromReserves(out[0].(*big.Int), out[1].(*big.Int))
+}
+
+// v2SwapVolume 返回V2交易对 Swap 事件中标的代币的成交数量
+func v2SwapVolume(info poolInfo, log types.Log) (decimal.Decimal, error) {
+	out, err := parsedV2PairEventsABI.Unpack("Swap", log.Data)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	amount0 := new(big.Int).Add(out[0].(*big.Int), out[2].(*big.Int))
+	amount1 := new(big.Int).Add(out[1].(*big.Int), out[3].(*big.Int))
+	return info.baseAmount(amount0, amount1), nil
+}
+
+// v3SwapData 返回V3池子 Swap 事件之后的价格和标的代币的成交数量
+func v3SwapData(info poolInfo, log types.Log) (decimal.Decimal, decimal.Decimal, error) {
+	out, err := parsedV3PoolEventsABI.Unpack("Swap", log.Data)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	price, err := info.priceFromSqrtPrice(out[2].(*big.Int))
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return price, info.baseAmount(out[0].(*big.Int), out[1].(*big.Int)), nil
+}
@@ -0,0 +1,248 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 未配置时的拆单参数
+const (
+	defaultMaxSplits     = 5
+	defaultSplitInterval = 30 * time.Second
+)
+
+// q96 V3 价格 sqrtPriceX96 的缩放因子 2^96
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// MarketImpact 按订单数量和流动性池储备量估算的价格冲击
+type MarketImpact struct {
+	Pool        string
+	Liquidity   decimal.Decimal // 池子流动性（计价货币），按标的代币一侧的价值乘2估算
+	BaseReserve decimal.Decimal // 按恒定乘积计算价格冲击使用的标的代币储备量，V3 为当前价格区间的虚拟储备量
+	Impact      decimal.Decimal // 整笔订单的预计价格冲击(%)，成交均价相对当前池子价格的不利偏离
+	Splits      int             // 拆分的笔数，为1时不拆分
+	ChunkImpact decimal.Decimal // 拆分后每笔的预计价格冲击(%)
+}
+
+// priceImpact 按恒定乘积公式估算兑换 quantity 个标的代币的价格冲击(%)，不含流动性池手续费
+// 买入从池子取出标的代币，成交均价偏离 quantity/(reserve-quantity)；卖出存入标的代币，偏离 quantity/(reserve+quantity)
+func priceImpact(direction string, quantity, reserve decimal.Decimal) decimal.Decimal {
+	hundred := decimal.NewFromInt(100)
+	if direction == "buy" {
+		if quantity.GreaterThanOrEqual(reserve) {
+			return hundred
+		}
+		return quantity.Div(reserve.Sub(quantity)).Mul(hundred)
+	}
+	return quantity.Div(reserve.Add(quantity)).Mul(hundred)
+}
+
+// maxChunkQuantity 返回价格冲击不超过 maxImpact(%) 的最大兑换数量，为 priceImpact 的反函数
+func maxChunkQuantity(direction string, reserve decimal.Decimal, maxImpact float64) decimal.Decimal {
+	m := decimal.NewFromFloat(maxImpact).Div(decimal.NewFromInt(100))
+	one := decimal.NewFromInt(1)
+	if direction == "buy" {
+		return m.Mul(reserve).Div(one.Add(m))
+	}
+	return m.Mul(reserve).Div(one.Sub(m))
+}
+
+// impactPool 返回交易对用于估算价格冲击的流动性池地址，以及是否为 V3 池子；
+// 价格来源为 Chainlink 时使用 contract_address 处的 V2 交易对合约
+func impactPool(pair config.PairConfig) (common.Address, bool, error) {
+	switch pair.PriceSource.Type {
+	case priceSourceChainlink:
+		if pair.ContractAddress == "" {
+			return common.Address{}, false, fmt.Errorf("交易对 %s 的价格来源为 Chainlink，需要配置 contract_address 处的流动性池", pair.Symbol)
+		}
+		return common.HexToAddress(pair.ContractAddress), false, nil
+	default:
+		pool, err := priceSourceAddress(pair)
+		return pool, pair.PriceSource.Type == priceSourceV3Pool, err
+	}
+}
+
+// estimateImpact 读取流动性池的储备量，估算按 price 兑换 quantity 个标的代币的价格冲击和池子流动性
+func estimateImpact(ctx context.Context, client *ethclient.Client, pair config.PairConfig, direction string, quantity, price decimal.Decimal) (MarketImpact, error) {
+	if pair.TokenAddress == "" {
+		return MarketImpact{}, fmt.Errorf("交易对 %s 未配置代币合约地址", pair.Symbol)
+	}
+	pool, v3, err := impactPool(pair)
+	if err != nil {
+		return MarketImpact{}, err
+	}
+	baseToken := common.HexToAddress(pair.TokenAddress)
+	info, err := loadPoolInfo(ctx, client, pool, baseToken)
+	if err != nil {
+		return MarketImpact{}, err
+	}
+
+	var reserve, balance decimal.Decimal
+	if v3 {
+		// 当前价格区间内的虚拟储备量: x = L / sqrtP，y = L * sqrtP
+		out, err := callView(ctx, client, parsedPoolABI, pool, "liquidity")
+		if err != nil {
+			return MarketImpact{}, err
+		}
+		liquidity := out[0].(*big.Int)
+		out, err = callView(ctx, client, parsedPoolABI, pool, "slot0")
+		if err != nil {
+			return MarketImpact{}, err
+		}
+		sqrtPrice := out[0].(*big.Int)
+		if sqrtPrice.Sign() == 0 {
+			return MarketImpact{}, fmt.Errorf("池子 %s 尚未初始化", pool.Hex())
+		}
+		amount0 := new(big.Int).Div(new(big.Int).Mul(liquidity, q96), sqrtPrice)
+		amount1 := new(big.Int).Div(new(big.Int).Mul(liquidity, sqrtPrice), q96)
+		reserve = info.baseAmount(amount0, amount1)
+
+		// 流动性按池子实际持有的标的代币估算
+		raw, err := queryRawTokenBalance(ctx, client, baseToken, pool)
+		if err != nil {
+			return MarketImpact{}, err
+		}
+		if info.baseIsToken0 {
+			balance = fromTokenUnits(raw, info.decimals0)
+		} else {
+			balance = fromTokenUnits(raw, info.decimals1)
+		}
+	} else {
+		out, err := callView(ctx, client, parsedPoolABI, pool, "getReserves")
+		if err != nil {
+			return MarketImpact{}, err
+		}
+		reserve = info.baseAmount(out[0].(*big.Int), out[1].(*big.Int))
+		balance = reserve
+	}
+	if !reserve.IsPositive() {
+		return MarketImpact{}, fmt.Errorf("池子 %s 没有流动性", pool.Hex())
+	}
+
+	impact := priceImpact(direction, quantity, reserve)
+	return MarketImpact{
+		Pool:        pool.Hex(),
+		Liquidity:   balance.Mul(price).Mul(decimal.NewFromInt(2)),
+		BaseReserve: reserve,
+		Impact:      impact,
+		Splits:      1,
+		ChunkImpact: impact,
+	}, nil
+}
+
+// checkMarketImpact 估算订单的价格冲击并检查池子流动性，未启用时返回nil
+// 价格冲击超过上限时按 risk.price_impact.action 拒绝订单，或将订单数量减为拆单后的首笔，其余笔在订单提交后由 scheduleSplits 下单
+func (b *BlockchainExecutor) checkMarketImpact(client *ethclient.Client, order *BlockchainOrder) (*MarketImpact, error) {
+	impactCfg := b.cfg.Current().Risk.PriceImpact
+	if !impactCfg.Enabled {
+		return nil, nil
+	}
+	pair, ok := findPair(b.cfg.Current().Trading.Pairs, order.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("未找到交易对 %s 的配置", order.Symbol)
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	defer cancel()
+	impact, err := estimateImpact(ctx, client, pair, order.Direction, order.Quantity, order.Price)
+	if err != nil {
+		return nil, fmt.Errorf("估算价格冲击失败: %v", err)
+	}
+
+	if impactCfg.MinLiquidity > 0 && impact.Liquidity.LessThan(decimal.NewFromFloat(impactCfg.MinLiquidity)) {
+		return &impact, fmt.Errorf("池子 %s 流动性 %s 低于下限 %v", impact.Pool, impact.Liquidity.StringFixed(2), impactCfg.MinLiquidity)
+	}
+	maxImpact := decimal.NewFromFloat(impactCfg.MaxImpact)
+	if impact.Impact.LessThanOrEqual(maxImpact) {
+		return &impact, nil
+	}
+	exceeded := fmt.Errorf("预计价格冲击 %s%% 超过上限 %v%%", impact.Impact.StringFixed(2), impactCfg.MaxImpact)
+	// 拆单产生的子订单不再拆分
+	if impactCfg.Action != "split" || order.ParentID != "" {
+		return &impact, exceeded
+	}
+
+	maxSplits := impactCfg.MaxSplits
+	if maxSplits <= 0 {
+		maxSplits = defaultMaxSplits
+	}
+	maxChunk := maxChunkQuantity(order.Direction, impact.BaseReserve, impactCfg.MaxImpact)
+	if !maxChunk.IsPositive() {
+		return &impact, exceeded
+	}
+	splits := order.Quantity.Div(maxChunk).Ceil()
+	if splits.GreaterThan(decimal.NewFromInt(int64(maxSplits))) {
+		return &impact, fmt.Errorf("%v，需要拆分为 %s 笔，超过 %d 笔上限", exceeded, splits.String(), maxSplits)
+	}
+
+	impact.Splits = int(splits.IntPart())
+	order.SplitQuantity = order.Quantity
+	order.Splits = impact.Splits
+	order.Quantity = order.Quantity.DivRound(splits, pricePrecision)
+	impact.ChunkImpact = priceImpact(order.Direction, order.Quantity, impact.BaseReserve)
+	logrus.Infof("区块链订单 %s %v，拆分为 %d 笔，每笔 %s（预计价格冲击 %s%%）",
+		order.ID, exceeded, impact.Splits, order.Quantity.String(), impact.ChunkImpact.StringFixed(2))
+	return &impact, nil
+}
+
+// scheduleSplits 原订单（拆单首笔）提交后，按 split_interval_seconds 的间隔依次创建并执行其余笔，
+// 最后一笔为拆单前数量的剩余部分
+func (b *BlockchainExecutor) scheduleSplits(parent BlockchainOrder) {
+	interval := time.Duration(b.cfg.Current().Risk.PriceImpact.SplitIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultSplitInterval
+	}
+
+	go func() {
+		remaining := parent.SplitQuantity.Sub(parent.Quantity)
+		for i := 2; i <= parent.Splits && remaining.IsPositive(); i++ {
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			quantity := parent.Quantity
+			if i == parent.Splits || quantity.GreaterThan(remaining) {
+				quantity = remaining
+			}
+			remaining = remaining.Sub(quantity)
+
+			child := BlockchainOrder{
+				ID:           generateBlockchainOrderID(),
+				Account:      parent.Account,
+				Symbol:       parent.Symbol,
+				Direction:    parent.Direction,
+				Price:        parent.Price,
+				Quantity:     quantity,
+				Status:       "pending",
+				Network:      parent.Network,
+				Wallet:       parent.Wallet,
+				Regime:       parent.Regime,
+				StrategyName: parent.StrategyName,
+				SignalID:     parent.SignalID,
+				Timestamp:    time.Now(),
+				ParentID:     parent.ID,
+			}
+			if parent.ClientOrderID != "" {
+				child.ClientOrderID = fmt.Sprintf("%s-%d", parent.ClientOrderID, i)
+			}
+
+			if existing, added := b.addOrderOnce(child); !added {
+				logrus.Infof("幂等键 %s 已创建链上订单 %s，不重复下单", child.ClientOrderID, existing.ID)
+				continue
+			}
+			logrus.Infof("执行区块链订单 %s 的第 %d/%d 笔拆单 %s", parent.ID, i, parent.Splits, child.ID)
+			b.executeBlockchainOrder(child)
+		}
+	}()
+}
@@ -0,0 +1,213 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// 内存池监控未配置时的默认值
+const (
+	defaultWatchWindow       = 30 * time.Second
+	defaultSandwichThreshold = 3
+)
+
+// pendingSwap 内存池中涉及交易代币的待处理交易
+type pendingSwap struct {
+	hash   common.Hash
+	tokens []common.Address
+	seenAt time.Time
+}
+
+// mempoolWatcher 订阅网络内存池中的待处理交易，记录其他地址对交易代币的兑换，用于评估夹子攻击风险
+type mempoolWatcher struct {
+	network string
+	wsURL   string
+	wallets []common.Address // 本系统的钱包地址，其交易不计入风险
+	tokens  []common.Address // 该网络上交易对的标的代币
+	window  time.Duration
+	mutex   sync.Mutex
+	pending []pendingSwap
+}
+
+// newMempoolWatcher 为网络创建内存池监控，监控该网络上所有已启用交易对的标的代币
+func newMempoolWatcher(network config.NetworkConfig, wallets []common.Address, pairs []config.PairConfig) *mempoolWatcher {
+	window := time.Duration(network.MEV.WatchWindowSeconds) * time.Second
+	if window <= 0 {
+		window = defaultWatchWindow
+	}
+
+	tokens := make([]common.Address, 0)
+	for _, pair := range pairs {
+		if pair.Enabled && pair.Blockchain == network.Name && pair.TokenAddress != "" {
+			tokens = append(tokens, common.HexToAddress(pair.TokenAddress))
+		}
+	}
+
+	return &mempoolWatcher{
+		network: network.Name,
+		wsURL:   network.WSURL,
+		wallets: wallets,
+		tokens:  tokens,
+		window:  window,
+	}
+}
+
+// run 持续订阅内存池，订阅中断后自动重新订阅
+func (w *mempoolWatcher) run(ctx context.Context) {
+	logrus.Infof("开始监控网络 %s 的内存池", w.network)
+	for {
+		err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Warnf("监控网络 %s 的内存池中断: %v，%s 后重新订阅", w.network, err, resubscribeDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// watch 订阅完整的待处理交易，直到订阅出错或服务停止
+func (w *mempoolWatcher) watch(ctx context.Context) error {
+	rpcClient, err := rpc.DialContext(ctx, w.wsURL)
+	if err != nil {
+		return fmt.Errorf("连接WebSocket节点失败: %v", err)
+	}
+	defer rpcClient.Close()
+
+	txs := make(chan *types.Transaction, 256)
+	// 第二个参数为 true 时节点推送完整交易而非交易哈希
+	sub, err := rpcClient.EthSubscribe(ctx, txs, "newPendingTransactions", true)
+	if err != nil {
+		return fmt.Errorf("订阅待处理交易失败: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case tx := <-txs:
+			w.observe(tx)
+		}
+	}
+}
+
+// observe 记录调用数据中包含交易代币地址的合约调用，忽略本系统钱包发出的交易
+func (w *mempoolWatcher) observe(tx *types.Transaction) {
+	if tx.To() == nil || len(tx.Data()) < 4 {
+		return
+	}
+
+	matched := make([]common.Address, 0)
+	for _, token := range w.tokens {
+		if bytes.Contains(tx.Data(), token.Bytes()) {
+			matched = append(matched, token)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err == nil && containsAny([]common.Address{sender}, w.wallets) {
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	now := time.Now()
+	w.pruneLocked(now)
+	w.pending = append(w.pending, pendingSwap{hash: tx.Hash(), tokens: matched, seenAt: now})
+}
+
+// pendingSwaps 返回统计窗口内涉及任一给定代币的其他地址的待处理交易数
+func (w *mempoolWatcher) pendingSwaps(tokens ...common.Address) int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pruneLocked(time.Now())
+
+	count := 0
+	for _, swap := range w.pending {
+		if containsAny(swap.tokens, tokens) {
+			count++
+		}
+	}
+	return count
+}
+
+// pruneLocked 丢弃统计窗口之外的记录，调用方需持有 w.mutex
+func (w *mempoolWatcher) pruneLocked(now time.Time) {
+	cutoff := now.Add(-w.window)
+	kept := w.pending[:0]
+	for _, swap := range w.pending {
+		if swap.seenAt.After(cutoff) {
+			kept = append(kept, swap)
+		}
+	}
+	w.pending = kept
+}
+
+// containsAny 判断两组地址是否有交集
+func containsAny(tokens, targets []common.Address) bool {
+	for _, token := range tokens {
+		for _, target := range targets {
+			if token == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// submissionClient 按网络的MEV防护配置选择提交兑换交易的节点，返回是否通过私有中继提交
+// 有夹子风险且无法使用私有中继时，配置了 block_on_risk 则放弃提交
+func (b *BlockchainExecutor) submissionClient(network string, client *ethclient.Client, swap swapCall) (*ethclient.Client, bool, error) {
+	networkCfg, _ := b.networkConfig(network)
+	mevCfg := networkCfg.MEV
+
+	atRisk := b.sandwichRisk(network, mevCfg, swap)
+	if private, ok := b.privateClients[network]; ok && (!mevCfg.PrivateOnRiskOnly || atRisk) {
+		return private, true, nil
+	}
+	if atRisk && mevCfg.BlockOnRisk {
+		return nil, false, fmt.Errorf("内存池中存在夹子攻击风险且未配置私有交易中继")
+	}
+	return client, false, nil
+}
+
+// sandwichRisk 根据内存池中其他地址对同一代币的待处理兑换数判断是否有夹子攻击风险
+func (b *BlockchainExecutor) sandwichRisk(network string, mevCfg config.MEVProtectionConfig, swap swapCall) bool {
+	watcher, ok := b.mempool[network]
+	if !ok {
+		return false
+	}
+
+	threshold := mevCfg.SandwichThreshold
+	if threshold <= 0 {
+		threshold = defaultSandwichThreshold
+	}
+
+	count := watcher.pendingSwaps(swap.tokenIn, swap.tokenOut)
+	if count < threshold {
+		return false
+	}
+	logrus.Warnf("网络 %s 的内存池中最近有 %d 笔其他地址的同代币兑换交易，存在夹子攻击风险", network, count)
+	return true
+}
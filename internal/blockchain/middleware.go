@@ -0,0 +1,81 @@
+package blockchain
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ginLogger 使用logrus记录HTTP请求日志，替代gin自带的日志中间件
+func ginLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			// 不在日志中记录查询参数中的API密钥
+			query := c.Request.URL.Query()
+			if query.Get(apiKeyQueryParam) != "" {
+				query.Set(apiKeyQueryParam, "***")
+			}
+			path += "?" + query.Encode()
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := logrus.WithFields(logrus.Fields{
+			"status":    status,
+			"method":    c.Request.Method,
+			"path":      path,
+			"client_ip": c.ClientIP(),
+			"latency":   time.Since(start).String(),
+		})
+
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("HTTP请求处理失败")
+		case status >= http.StatusBadRequest:
+			entry.Warn("HTTP请求异常")
+		default:
+			entry.Debug("HTTP请求")
+		}
+	}
+}
+
+// ginRecovery 捕获处理函数中的panic，通过logrus记录请求上下文并返回500
+func ginRecovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method":    c.Request.Method,
+					"path":      c.Request.URL.Path,
+					"client_ip": c.ClientIP(),
+					"panic":     err,
+					"stack":     string(debug.Stack()),
+				}).Error("HTTP请求处理发生panic")
+
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "服务器内部错误",
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// ginModeForLogLevel 根据日志级别选择gin运行模式
+func ginModeForLogLevel(level string) string {
+	if level == "debug" {
+		return gin.DebugMode
+	}
+	return gin.ReleaseMode
+}
@@ -0,0 +1,42 @@
+package blockchain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestGinRecoveryLogsPanic(t *testing.T) {
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(ginLogger(), ginRecovery())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("处理函数出错")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("panic 后应返回 500，实际 %d", w.Code)
+	}
+
+	var logged *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "HTTP请求处理发生panic" {
+			logged = entry
+		}
+	}
+	if logged == nil {
+		t.Fatal("panic 应通过 logrus 记录")
+	}
+	if logged.Level != logrus.ErrorLevel || logged.Data["panic"] != "处理函数出错" || logged.Data["path"] != "/panic" {
+		t.Fatalf("panic 日志应包含请求上下文: %v", logged.Data)
+	}
+}
@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/sirupsen/logrus"
+)
+
+// trackedTx 已提交但尚未被打包的交易
+type trackedTx struct {
+	tx          *types.Transaction
+	submittedAt time.Time
+	resubmitted bool
+}
+
+// nonceManager 在本地分配钱包在某个网络上的nonce，并跟踪未确认的交易，
+// 当某笔交易被丢弃导致nonce缺口阻塞后续交易时负责检测并填补缺口
+type nonceManager struct {
+	network      string
+	client       *ethclient.Client
+	address      common.Address
+	next         uint64
+	synced       bool
+	pending      map[uint64]*trackedTx
+	confirmed    uint64    // 链上已确认的nonce
+	lastProgress time.Time // 链上nonce最近一次前进的时间
+	mutex        sync.Mutex
+}
+
+// newNonceManager 创建nonce管理器
+func newNonceManager(network string, client *ethclient.Client, address common.Address) *nonceManager {
+	return &nonceManager{
+		network:      network,
+		client:       client,
+		address:      address,
+		pending:      make(map[uint64]*trackedTx),
+		lastProgress: time.Now(),
+	}
+}
+
+// Next 分配下一个nonce
+func (n *nonceManager) Next(ctx context.Context) (uint64, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if !n.synced {
+		nonce, err := n.client.PendingNonceAt(ctx, n.address)
+		if err != nil {
+			return 0, err
+		}
+		n.next = nonce
+		n.synced = true
+	}
+
+	nonce := n.next
+	n.next++
+	return nonce, nil
+}
+
+// Track 记录已成功提交的交易
+func (n *nonceManager) Track(tx *types.Transaction) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.pending[tx.Nonce()] = &trackedTx{tx: tx, submittedAt: time.Now()}
+}
+
+// Reset 使用 nonce 的交易提交失败时调用，避免留下缺口:
+// nonce 是最近分配的一个时直接收回；否则没有待确认交易时下次分配前重新与链上同步，
+// 仍有待确认交易时不重新同步（链上的 pending nonce 可能尚未包含这些交易，重新同步会重复分配），缺口由 recoverNonceGaps 填补
+func (n *nonceManager) Reset(nonce uint64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.synced && nonce+1 == n.next {
+		n.next = nonce
+		return
+	}
+	if len(n.pending) == 0 {
+		n.synced = false
+	}
+}
+
+// stalledNonce 检查是否存在阻塞的nonce缺口
+// 当链上nonce超过 stallTimeout 未前进且存在更高nonce的待确认交易时，返回缺口处的nonce；
+// 该nonce上已跟踪的交易尚未重新广播过时一并返回并标记为已重新广播，否则返回的交易为nil
+func (n *nonceManager) stalledNonce(ctx context.Context, stallTimeout time.Duration) (uint64, *types.Transaction, bool, error) {
+	confirmed, err := n.client.NonceAt(ctx, n.address, nil)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if confirmed > n.confirmed {
+		n.confirmed = confirmed
+		n.lastProgress = time.Now()
+	}
+
+	// 清理已被打包的交易
+	for nonce := range n.pending {
+		if nonce < confirmed {
+			delete(n.pending, nonce)
+		}
+	}
+
+	if len(n.pending) == 0 || time.Since(n.lastProgress) < stallTimeout {
+		return 0, nil, false, nil
+	}
+
+	hasLater := false
+	for nonce := range n.pending {
+		if nonce > confirmed {
+			hasLater = true
+			break
+		}
+	}
+	if !hasLater {
+		return 0, nil, false, nil
+	}
+
+	// 重新计时，避免在补齐交易被打包前重复处理
+	n.lastProgress = time.Now()
+	tracked, ok := n.pending[confirmed]
+	if !ok || tracked.resubmitted {
+		return confirmed, nil, true, nil
+	}
+	tracked.resubmitted = true
+	return confirmed, tracked.tx, true, nil
+}
+
+// recoverNonceGaps 检测并填补各钱包在各网络上阻塞的nonce缺口:
+// 缺口处的交易仍被跟踪时先原样重新广播一次，否则（或重新广播后仍阻塞）发送一笔转给自己的空交易占用该nonce
+func (b *BlockchainExecutor) recoverNonceGaps() {
+	recoveryCfg := b.cfg.Blockchain.NonceRecovery
+	if !recoveryCfg.Enabled {
+		return
+	}
+
+	stallTimeout := time.Duration(recoveryCfg.StallTimeoutSeconds) * time.Second
+	if stallTimeout <= 0 {
+		stallTimeout = 5 * time.Minute
+	}
+
+	for _, w := range b.wallets {
+		for network, manager := range w.nonces {
+			b.recoverNonceGap(w, network, manager, stallTimeout)
+		}
+	}
+}
+
+// recoverNonceGap 检测并填补钱包在单个网络上的nonce缺口
+func (b *BlockchainExecutor) recoverNonceGap(w *wallet, network string, manager *nonceManager, stallTimeout time.Duration) {
+	ctx, cancel := context.WithTimeout(b.ctx, 30*time.Second)
+	defer cancel()
+
+	nonce, resubmit, stalled, err := manager.stalledNonce(ctx, stallTimeout)
+	if err != nil {
+		logrus.Warnf("检查钱包 %s 在网络 %s 的nonce状态失败: %v", w.name, network, err)
+		return
+	}
+	if !stalled {
+		return
+	}
+
+	logrus.Warnf("钱包 %s 在网络 %s 的nonce %d 处存在缺口，后续交易已阻塞超过 %s", w.name, network, nonce, stallTimeout)
+
+	if resubmit != nil {
+		if err := manager.client.SendTransaction(ctx, resubmit); err != nil {
+			logrus.Warnf("重新广播nonce %d 的交易失败: %v", nonce, err)
+		} else {
+			logrus.Infof("已重新广播网络 %s 上nonce %d 的交易: %s", network, nonce, resubmit.Hash().Hex())
+		}
+		return
+	}
+
+	fillTx, err := b.sendNoopTx(ctx, w, manager, nonce, b.cfg.Blockchain.NonceRecovery.GasBumpPercent)
+	if err != nil {
+		logrus.Errorf("填补网络 %s 上nonce %d 的缺口失败: %v", network, nonce, err)
+	} else {
+		logrus.Infof("已发送空交易填补网络 %s 上nonce %d 的缺口: %s", network, nonce, fillTx.Hash().Hex())
+	}
+}
+
+// sendNoopTx 在指定nonce上发送一笔转给自己的0值交易，gas价格按比例上调以替换可能滞留的交易
+func (b *BlockchainExecutor) sendNoopTx(ctx context.Context, w *wallet, manager *nonceManager, nonce uint64, gasBumpPercent int) (*types.Transaction, error) {
+	chainID, err := manager.client.NetworkID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取网络ID失败: %v", err)
+	}
+
+	gasPrice, err := manager.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取gas价格失败: %v", err)
+	}
+	if gasBumpPercent > 0 {
+		gasPrice = bumpGasPrice(gasPrice, gasBumpPercent)
+	}
+
+	tx := types.NewTransaction(nonce, manager.address, big.NewInt(0), 21000, gasPrice, nil)
+	signedTx, err := w.sign(ctx, tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+
+	if err := manager.client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("发送交易失败: %v", err)
+	}
+
+	manager.Track(signedTx)
+	return signedTx, nil
+}
+
+// bumpGasPrice 按百分比上调gas价格
+func bumpGasPrice(gasPrice *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(gasPrice, big.NewInt(int64(100+percent)))
+	return bumped.Div(bumped, big.NewInt(100))
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// nonceNode 模拟链上nonce停在 confirmed 的节点，记录广播的交易
+type nonceNode struct {
+	confirmed uint64
+	sent      []*types.Transaction
+	mutex     sync.Mutex
+}
+
+func (n *nonceNode) handle(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eth_getTransactionCount":
+		return hexutil.EncodeUint64(n.confirmed), nil
+	case "net_version":
+		return "1", nil
+	case "eth_gasPrice":
+		return hexutil.EncodeBig(big.NewInt(1000000000)), nil
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(params[0], &raw); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		n.mutex.Lock()
+		n.sent = append(n.sent, tx)
+		n.mutex.Unlock()
+		return tx.Hash().Hex(), nil
+	}
+	return nil, fmt.Errorf("不支持的方法: %s", method)
+}
+
+// stalledManager 返回链上nonce停在 5 的nonce管理器，已跟踪的待确认交易使用 nonces 中的nonce，链上nonce已超过阻塞时间未前进
+func stalledManager(t *testing.T, node *nonceNode, w *wallet, nonces ...uint64) *nonceManager {
+	t.Helper()
+	manager := newNonceManager("ethereum", newMockClient(t, node.handle), w.address)
+	manager.confirmed = node.confirmed
+	manager.lastProgress = time.Now().Add(-time.Hour)
+	for _, nonce := range nonces {
+		tx, err := w.sign(context.Background(), types.NewTransaction(nonce, w.address, big.NewInt(0), 21000, big.NewInt(1), nil), big.NewInt(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		manager.Track(tx)
+	}
+	return manager
+}
+
+func testWallet(t *testing.T) *wallet {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := newKeySigner(key)
+	return &wallet{name: defaultWalletName, signer: signer, address: signer.Address()}
+}
+
+func TestNonceGapFilledWithNoop(t *testing.T) {
+	w := testWallet(t)
+	node := &nonceNode{confirmed: 5}
+	manager := stalledManager(t, node, w, 6, 7)
+	b := &BlockchainExecutor{cfg: &config.Config{}, ctx: context.Background()}
+
+	b.recoverNonceGap(w, "ethereum", manager, time.Minute)
+	if len(node.sent) != 1 {
+		t.Fatalf("应发送 1 笔填补缺口的交易，实际 %d 笔", len(node.sent))
+	}
+	fill := node.sent[0]
+	if fill.Nonce() != 5 || fill.To() == nil || *fill.To() != w.address || fill.Value().Sign() != 0 {
+		t.Fatalf("应在缺口nonce 5 上发送转给自己的0值交易: nonce %d, to %v, value %s", fill.Nonce(), fill.To(), fill.Value())
+	}
+
+	// 补齐交易被打包前不重复填补
+	b.recoverNonceGap(w, "ethereum", manager, time.Minute)
+	if len(node.sent) != 1 {
+		t.Fatalf("补齐交易被打包前不应重复填补，实际发送 %d 笔", len(node.sent))
+	}
+}
+
+func TestNonceGapResubmitsTrackedTxOnce(t *testing.T) {
+	w := testWallet(t)
+	node := &nonceNode{confirmed: 5}
+	manager := stalledManager(t, node, w, 5, 6)
+	tracked := manager.pending[5].tx
+	b := &BlockchainExecutor{cfg: &config.Config{}, ctx: context.Background()}
+	b.cfg.Blockchain.NonceRecovery.GasBumpPercent = 20
+
+	b.recoverNonceGap(w, "ethereum", manager, time.Minute)
+	if len(node.sent) != 1 || node.sent[0].Hash() != tracked.Hash() {
+		t.Fatal("缺口处的交易仍被跟踪时应先原样重新广播")
+	}
+
+	// 重新广播后仍阻塞时改为发送gas价格上调的空交易
+	manager.lastProgress = time.Now().Add(-time.Hour)
+	b.recoverNonceGap(w, "ethereum", manager, time.Minute)
+	if len(node.sent) != 2 || node.sent[1].Nonce() != 5 || node.sent[1].GasPrice().Cmp(big.NewInt(1200000000)) != 0 {
+		t.Fatal("重新广播后仍阻塞时应发送gas价格上调后的空交易")
+	}
+}
+
+func TestNonceResetKeepsOutstandingNonces(t *testing.T) {
+	w := testWallet(t)
+	node := &nonceNode{confirmed: 5}
+	manager := newNonceManager("ethereum", newMockClient(t, node.handle), w.address)
+	ctx := context.Background()
+
+	first, _ := manager.Next(ctx)
+	tx, _ := w.sign(ctx, types.NewTransaction(first, w.address, big.NewInt(0), 21000, big.NewInt(1), nil), big.NewInt(1))
+	manager.Track(tx)
+
+	// 最近分配的nonce提交失败时收回
+	second, _ := manager.Next(ctx)
+	manager.Reset(second)
+	if again, _ := manager.Next(ctx); again != second {
+		t.Fatalf("提交失败的nonce应被收回，期望 %d，实际 %d", second, again)
+	}
+
+	// 仍有待确认交易时不按链上的 pending nonce 重新同步，否则会重复分配 first 之后的nonce
+	third, _ := manager.Next(ctx)
+	manager.Reset(second)
+	if next, _ := manager.Next(ctx); next != third+1 {
+		t.Fatalf("仍有待确认交易时不应重新同步，期望 %d，实际 %d", third+1, next)
+	}
+}
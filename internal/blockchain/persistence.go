@@ -0,0 +1,103 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"autotransaction/internal/risk"
+	"autotransaction/internal/store"
+	"autotransaction/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SetStore 设置持久化存储，链上订单和持仓将在重启后保留
+func (b *BlockchainExecutor) SetStore(s store.Store) {
+	b.store = s
+}
+
+// loadHistory 从存储中加载链上订单和持仓，并将持仓同步给风险管理器
+func (b *BlockchainExecutor) loadHistory() error {
+	if b.store == nil {
+		return nil
+	}
+
+	orders := make(map[string]BlockchainOrder)
+	err := b.store.Load(store.CollectionBlockchainOrders, func(id string, decode func(v interface{}) error) error {
+		var order BlockchainOrder
+		if err := decode(&order); err != nil {
+			return fmt.Errorf("解析链上订单 %s 失败: %v", id, err)
+		}
+		orders[id] = order
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	positions := make(map[string]BlockchainPosition)
+	err = b.store.Load(store.CollectionBlockchainPositions, func(id string, decode func(v interface{}) error) error {
+		var position BlockchainPosition
+		if err := decode(&position); err != nil {
+			return fmt.Errorf("解析链上持仓 %s 失败: %v", id, err)
+		}
+		positions[id] = position
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	for id, order := range orders {
+		b.orders[id] = order
+		if order.ClientOrderID != "" {
+			b.clientOrders[utils.ClientOrderKey(order.Account, order.ClientOrderID)] = id
+		}
+	}
+	for key, position := range positions {
+		b.positions[key] = position
+	}
+	// 各网络上同一交易对的持仓合计后同步给风险管理器
+	riskPositions := make(map[string]risk.Position)
+	for _, position := range positions {
+		key := risk.PositionKey(position.Account, position.Symbol)
+		if _, ok := riskPositions[key]; !ok {
+			riskPositions[key] = b.riskPositionLocked(position.Account, position.Symbol)
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, position := range riskPositions {
+		b.riskManager.UpdatePosition(position)
+	}
+
+	logrus.Infof("已从存储加载 %d 个链上订单和 %d 个链上持仓", len(orders), len(positions))
+	return nil
+}
+
+// saveOrder 持久化链上订单
+func (b *BlockchainExecutor) saveOrder(order BlockchainOrder) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.Put(store.CollectionBlockchainOrders, order.ID, order); err != nil {
+		logrus.Errorf("保存链上订单 %s 失败: %v", order.ID, err)
+	}
+}
+
+// savePosition 持久化链上持仓，数量为0时删除
+func (b *BlockchainExecutor) savePosition(key string, position BlockchainPosition) {
+	if b.store == nil {
+		return
+	}
+
+	var err error
+	if position.Quantity.IsPositive() {
+		err = b.store.Put(store.CollectionBlockchainPositions, key, position)
+	} else {
+		err = b.store.Delete(store.CollectionBlockchainPositions, key)
+	}
+	if err != nil {
+		logrus.Errorf("保存链上持仓 %s 失败: %v", key, err)
+	}
+}
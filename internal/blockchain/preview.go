@@ -0,0 +1,149 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/shopspring/decimal"
+)
+
+// BlockchainPreview 链上订单的模拟执行结果（dry-run），只做检查和估算，不发送交易
+type BlockchainPreview struct {
+	Order         BlockchainOrder
+	RiskError     string          // 未通过风险检查的原因
+	Error         string          // 未通过下单前检查（gas价格上限、代币安全、价格冲击、滑点、代币和原生币余额）的原因
+	Warnings      []string        // 不阻止下单的提示，如gas价格过高时订单将排队
+	ExpectedPrice decimal.Decimal // 按最新链上价格检查滑点（可能重新定价）后的预计成交价格
+	Notional      decimal.Decimal // 预计成交额
+	LPFee         decimal.Decimal // 流动性池手续费（计价货币）
+	GasPrice      *big.Int        // 为nil时未能获取gas价格
+	GasLimit      uint64
+	GasCost       decimal.Decimal // 预计gas费用（计价货币）
+	Impact        *MarketImpact   // 流动性和价格冲击估算，未启用 risk.price_impact 或估算失败时为nil
+}
+
+// Accepted 判断信号是否通过全部检查，实际提交时会发送交易
+func (p BlockchainPreview) Accepted() bool {
+	return p.RiskError == "" && p.Error == ""
+}
+
+// PreviewSignal 对链上交易对的信号执行与下单相同的检查，并估算成交价格、流动性池手续费和gas费用
+// 不发送交易、不占用nonce，也不记录审计日志
+func (b *BlockchainExecutor) PreviewSignal(signal strategy.Signal) BlockchainPreview {
+	account := signal.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	pair, _ := findPair(b.cfg.Current().Trading.Pairs, signal.Symbol)
+
+	preview := BlockchainPreview{
+		Order: BlockchainOrder{
+			Account:       account,
+			Symbol:        signal.Symbol,
+			Direction:     signal.Direction,
+			Price:         signal.Price,
+			Quantity:      signal.Quantity,
+			Status:        "pending",
+			Network:       pair.Blockchain,
+			Regime:        signal.Regime,
+			StrategyName:  signal.StrategyName,
+			SignalID:      signal.ID,
+			ClientOrderID: signal.ClientOrderID,
+			Timestamp:     time.Now(),
+		},
+	}
+	if err := b.riskManager.PreviewSignal(signal); err != nil {
+		preview.RiskError = err.Error()
+	}
+	if err := b.previewOrder(&preview); err != nil {
+		preview.Error = err.Error()
+	}
+	return preview
+}
+
+// previewOrder 依次执行 executeBlockchainOrder 中的下单前检查并填充估算结果，返回第一个不通过的原因
+func (b *BlockchainExecutor) previewOrder(preview *BlockchainPreview) error {
+	order := &preview.Order
+	preview.ExpectedPrice = order.Price
+	preview.Notional = order.Price.Mul(order.Quantity)
+	preview.LPFee = b.fees.LPFee(order.Network, order.Price, order.Quantity)
+	preview.GasCost = b.fees.EstimatedGasCost()
+
+	if order.Network == "" {
+		return fmt.Errorf("%s 不是区块链交易对", order.Symbol)
+	}
+	client, ok := b.clients[order.Network]
+	if !ok {
+		return fmt.Errorf("未找到网络 %s 的客户端", order.Network)
+	}
+	w, err := b.pairWallet(order.Symbol, order.Network)
+	if err != nil {
+		return err
+	}
+	order.Wallet = w.name
+
+	if err := b.checkGasCap(client, order.Network); err != nil {
+		if !errors.Is(err, errGasPriceTooHigh) || !b.cfg.Current().Risk.GasQueue.Enabled {
+			return err
+		}
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%v，订单将排队等待gas价格回落", err))
+	}
+
+	if err := b.checkTokenSafety(*order); err != nil {
+		return err
+	}
+	impact, err := b.checkMarketImpact(client, order)
+	preview.Impact = impact
+	if err != nil {
+		return err
+	}
+	if impact != nil && impact.Splits > 1 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("预计价格冲击 %s%%，订单将拆分为 %d 笔，以下估算为首笔",
+			impact.Impact.StringFixed(2), impact.Splits))
+	}
+	if err := b.checkQuoteSlippage(order); err != nil {
+		return err
+	}
+	swap, err := b.buildSwap(client, *order, w.address)
+	if err != nil {
+		return fmt.Errorf("构建兑换交易失败: %v", err)
+	}
+	order.Quantity = swap.quantity
+	preview.ExpectedPrice = order.Price
+	preview.Notional = order.Price.Mul(order.Quantity)
+	preview.LPFee = b.fees.LPFee(order.Network, order.Price, order.Quantity)
+
+	gasPrice, err := b.getGasPrice(client, order.Network)
+	if err != nil {
+		return fmt.Errorf("获取gas价格失败: %v", err)
+	}
+	networkCfg, _ := b.networkConfig(order.Network)
+	gasLimit := b.resolveGasLimit(client, networkCfg, ethereum.CallMsg{
+		From:     w.address,
+		To:       &swap.router,
+		GasPrice: gasPrice,
+		Value:    big.NewInt(0),
+		Data:     swap.data,
+	})
+	preview.GasPrice = gasPrice
+	preview.GasLimit = gasLimit
+	native := fromTokenUnits(new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice), nativeDecimals)
+	preview.GasCost = b.gasCost(order.Network, native)
+
+	ctx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	defer cancel()
+	if err := checkTokenBalance(ctx, client, w.address, swap); err != nil {
+		if b.bridge == nil {
+			return err
+		}
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%v，将尝试从其他网络跨链转入", err))
+	}
+	return checkGasBalance(ctx, client, w.address, gasLimit, gasPrice, networkCfg.GasReserve)
+}
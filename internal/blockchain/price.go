@@ -0,0 +1,253 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// 链上价格来源类型
+const (
+	priceSourceV2Pool    = "v2_pool"   // Uniswap V2 / PancakeSwap 交易对合约的储备量
+	priceSourceV3Pool    = "v3_pool"   // Uniswap V3 池子的 slot0 当前价格
+	priceSourceChainlink = "chainlink" // Chainlink 喂价合约
+)
+
+// pricePrecision 价格相除时保留的小数位数
+const pricePrecision = 18
+
+// feeProtocol 在 PancakeSwap V3 中为 uint32，按 uint32 解码兼容两者
+const poolABI = `[{"name":"getReserves","type":"function","stateMutability":"view","inputs":[],
+"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]},
+{"name":"slot0","type":"function","stateMutability":"view","inputs":[],
+"outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},{"name":"observationIndex","type":"uint16"},{"name":"observationCardinality","type":"uint16"},{"name":"observationCardinalityNext","type":"uint16"},{"name":"feeProtocol","type":"uint32"},{"name":"unlocked","type":"bool"}]},
+{"name":"liquidity","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+{"name":"token0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+{"name":"token1","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}]`
+
+const aggregatorABI = `[{"name":"latestRoundData","type":"function","stateMutability":"view","inputs":[],
+"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]},
+{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]}]`
+
+var (
+	parsedPoolABI       = mustParseABI(poolABI)
+	parsedAggregatorABI = mustParseABI(aggregatorABI)
+	// q192 V3 价格 sqrtPriceX96 平方后的缩放因子 2^192
+	q192 = new(big.Int).Lsh(big.NewInt(1), 192)
+)
+
+// fetchPrice 按交易对配置的价格来源读取链上价格，以计价代币表示
+func fetchPrice(ctx context.Context, client *ethclient.Client, pair config.PairConfig) (decimal.Decimal, error) {
+	source := pair.PriceSource
+	contract, err := priceSourceAddress(pair)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	switch source.Type {
+	case priceSourceChainlink:
+		return chainlinkPrice(ctx, client, contract, time.Duration(source.MaxStalenessSeconds)*time.Second)
+	case "", priceSourceV2Pool, priceSourceV3Pool:
+		if pair.TokenAddress == "" {
+			return decimal.Zero, fmt.Errorf("交易对 %s 未配置代币合约地址，无法确定池子中的标的代币", pair.Symbol)
+		}
+		if source.Type == priceSourceV3Pool {
+			return v3PoolPrice(ctx, client, contract, common.HexToAddress(pair.TokenAddress))
+		}
+		return v2PoolPrice(ctx, client, contract, common.HexToAddress(pair.TokenAddress))
+	default:
+		return decimal.Zero, fmt.Errorf("不支持的价格来源: %s", source.Type)
+	}
+}
+
+// poolInfo 池子的代币顺序和精度，用于把池子数据换算为标的代币价格
+type poolInfo struct {
+	address      common.Address
+	decimals0    int32
+	decimals1    int32
+	baseIsToken0 bool
+}
+
+// loadPoolInfo 读取池子的代币地址和精度，并确认标的代币属于该池子
+func loadPoolInfo(ctx context.Context, client *ethclient.Client, pool, baseToken common.Address) (poolInfo, error) {
+	token0, token1, err := poolTokens(ctx, client, pool, baseToken)
+	if err != nil {
+		return poolInfo{}, err
+	}
+	decimals0, decimals1, err := tokenPairDecimals(ctx, client, token0, token1)
+	if err != nil {
+		return poolInfo{}, err
+	}
+	return poolInfo{
+		address:      pool,
+		decimals0:    decimals0,
+		decimals1:    decimals1,
+		baseIsToken0: baseToken == token0,
+	}, nil
+}
+
+// priceFromReserves 根据V2交易对的储备量计算标的代币价格
+func (p poolInfo) priceFromReserves(reserve0Raw, reserve1Raw *big.Int) (decimal.Decimal, error) {
+	reserve0 := fromTokenUnits(reserve0Raw, p.decimals0)
+	reserve1 := fromTokenUnits(reserve1Raw, p.decimals1)
+	if reserve0.IsZero() || reserve1.IsZero() {
+		return decimal.Zero, fmt.Errorf("交易对 %s 储备量为0", p.address.Hex())
+	}
+
+	if p.baseIsToken0 {
+		return reserve1.DivRound(reserve0, pricePrecision), nil
+	}
+	return reserve0.DivRound(reserve1, pricePrecision), nil
+}
+
+// priceFromSqrtPrice 根据V3池子的 sqrtPriceX96 计算标的代币价格
+// token0 以 token1 计价的价格 = sqrtPriceX96^2 / 2^192 * 10^(decimals0-decimals1)
+func (p poolInfo) priceFromSqrtPrice(sqrtPrice *big.Int) (decimal.Decimal, error) {
+	if sqrtPrice.Sign() == 0 {
+		return decimal.Zero, fmt.Errorf("池子 %s 尚未初始化", p.address.Hex())
+	}
+
+	squared := new(big.Int).Mul(sqrtPrice, sqrtPrice)
+	price0 := decimal.NewFromBigInt(squared, p.decimals0-p.decimals1).
+		DivRound(decimal.NewFromBigInt(q192, 0), 2*pricePrecision)
+	if price0.IsZero() {
+		return decimal.Zero, fmt.Errorf("池子 %s 价格过小", p.address.Hex())
+	}
+
+	if p.baseIsToken0 {
+		return price0.Round(pricePrecision), nil
+	}
+	return decimal.NewFromInt(1).DivRound(price0, pricePrecision), nil
+}
+
+// baseAmount 返回池子代币数量变化中标的代币一侧的数量（已按精度换算，取绝对值）
+func (p poolInfo) baseAmount(amount0, amount1 *big.Int) decimal.Decimal {
+	if p.baseIsToken0 {
+		return fromTokenUnits(amount0, p.decimals0).Abs()
+	}
+	return fromTokenUnits(amount1, p.decimals1).Abs()
+}
+
+// priceSourceAddress 返回交易对价格来源的合约地址，未单独配置时使用 contract_address
+func priceSourceAddress(pair config.PairConfig) (common.Address, error) {
+	address := pair.PriceSource.Address
+	if address == "" {
+		address = pair.ContractAddress
+	}
+	if address == "" {
+		return common.Address{}, fmt.Errorf("交易对 %s 未配置价格来源合约地址", pair.Symbol)
+	}
+	return common.HexToAddress(address), nil
+}
+
+// v2PoolPrice 根据V2交易对合约的储备量计算标的代币价格
+func v2PoolPrice(ctx context.Context, client *ethclient.Client, pool, baseToken common.Address) (decimal.Decimal, error) {
+	info, err := loadPoolInfo(ctx, client, pool, baseToken)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	out, err := callView(ctx, client, parsedPoolABI, pool, "getReserves")
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return info.priceFromReserves(out[0].(*big.Int), out[1].(*big.Int))
+}
+
+// v3PoolPrice 根据V3池子的 slot0 计算标的代币价格
+func v3PoolPrice(ctx context.Context, client *ethclient.Client, pool, baseToken common.Address) (decimal.Decimal, error) {
+	info, err := loadPoolInfo(ctx, client, pool, baseToken)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	out, err := callView(ctx, client, parsedPoolABI, pool, "slot0")
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return info.priceFromSqrtPrice(out[0].(*big.Int))
+}
+
+// chainlinkPrice 读取Chainlink喂价合约的最新价格
+func chainlinkPrice(ctx context.Context, client *ethclient.Client, feed common.Address, maxStaleness time.Duration) (decimal.Decimal, error) {
+	out, err := callView(ctx, client, parsedAggregatorABI, feed, "decimals")
+	if err != nil {
+		return decimal.Zero, err
+	}
+	decimals := int32(out[0].(uint8))
+
+	out, err = callView(ctx, client, parsedAggregatorABI, feed, "latestRoundData")
+	if err != nil {
+		return decimal.Zero, err
+	}
+	answer := out[1].(*big.Int)
+	updatedAt := time.Unix(out[3].(*big.Int).Int64(), 0)
+
+	if answer.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("喂价合约 %s 返回无效价格 %s", feed.Hex(), answer.String())
+	}
+	if maxStaleness > 0 && time.Since(updatedAt) > maxStaleness {
+		return decimal.Zero, fmt.Errorf("喂价合约 %s 的价格已 %s 未更新", feed.Hex(), time.Since(updatedAt).Round(time.Second))
+	}
+
+	return decimal.NewFromBigInt(answer, -decimals), nil
+}
+
+// poolTokens 读取池子的两个代币地址，并确认标的代币属于该池子
+func poolTokens(ctx context.Context, client *ethclient.Client, pool, baseToken common.Address) (common.Address, common.Address, error) {
+	out, err := callView(ctx, client, parsedPoolABI, pool, "token0")
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	token0 := out[0].(common.Address)
+
+	out, err = callView(ctx, client, parsedPoolABI, pool, "token1")
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	token1 := out[0].(common.Address)
+
+	if baseToken != token0 && baseToken != token1 {
+		return common.Address{}, common.Address{}, fmt.Errorf("代币 %s 不属于池子 %s", baseToken.Hex(), pool.Hex())
+	}
+	return token0, token1, nil
+}
+
+// tokenPairDecimals 查询两个代币的精度
+func tokenPairDecimals(ctx context.Context, client *ethclient.Client, token0, token1 common.Address) (int32, int32, error) {
+	decimals0, err := queryTokenDecimals(ctx, client, token0)
+	if err != nil {
+		return 0, 0, err
+	}
+	decimals1, err := queryTokenDecimals(ctx, client, token1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return decimals0, decimals1, nil
+}
+
+// callView 调用合约的只读方法并解码返回值
+func callView(ctx context.Context, client *ethclient.Client, parsed abi.ABI, contract common.Address, method string) ([]interface{}, error) {
+	data, err := parsed.Pack(method)
+	if err != nil {
+		return nil, fmt.Errorf("编码 %s 调用失败: %v", method, err)
+	}
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用 %s 失败: %v", method, err)
+	}
+	out, err := parsed.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("解码 %s 返回值失败: %v", method, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+func TestBlockchainSignalsRetriedThenDeadLettered(t *testing.T) {
+	// 节点暂时不可用，获取网络ID总是失败
+	var calls int32
+	client := newMockClient(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, fmt.Errorf("node unavailable")
+	})
+
+	cfg := &config.Config{}
+	cfg.Risk.MaxPositionSize = 1000
+	cfg.Risk.MaxOpenPositions = 10
+	cfg.Trading.Pairs = []config.PairConfig{{Symbol: "ETH/USDT", Enabled: true, Blockchain: "ethereum"}}
+	cfg.Execution.Queue.MaxAttempts = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &BlockchainExecutor{
+		cfg:          cfg,
+		riskManager:  risk.NewRiskManager(cfg),
+		clients:      map[string]*ethclient.Client{"ethereum": client},
+		wallets:      map[string]*wallet{defaultWalletName: {name: defaultWalletName}},
+		orders:       make(map[string]BlockchainOrder),
+		clientOrders: make(map[string]string),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	b.jobs = execution.NewJobQueue(cfg, b.submitSignal)
+
+	signal := strategy.Signal{
+		Symbol:        "ETH/USDT",
+		Direction:     "buy",
+		Price:         decimal.NewFromInt(200),
+		Quantity:      decimal.NewFromInt(1),
+		ClientOrderID: "entry-1",
+	}
+
+	// 暂时性失败释放幂等键，重试时可以重新下单
+	if err := b.submitSignal(signal); !errors.Is(err, execution.ErrTransient) {
+		t.Fatalf("节点不可用应返回暂时性错误: %v", err)
+	}
+	if _, ok := b.findClientOrder(config.DefaultAccountID, "entry-1"); ok {
+		t.Fatal("暂时性失败后应释放幂等键")
+	}
+
+	go b.jobs.Run(ctx)
+	b.HandleSignal(signal)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(b.DeadLetters()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	deadLetters := b.DeadLetters()
+	if len(deadLetters) != 1 || deadLetters[0].Attempts != 2 {
+		t.Fatalf("重试用尽后应进入死信队列: %+v", deadLetters)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("订单队列应按最大次数重试，节点实际被调用 %d 次", got)
+	}
+	if len(b.QueuedJobs()) != 0 {
+		t.Fatal("进入死信队列的任务不应留在订单队列中")
+	}
+}
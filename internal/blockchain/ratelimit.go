@@ -0,0 +1,114 @@
+package blockchain
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bucketIdleTimeout 超过该时间未使用的令牌桶会被清理
+const bucketIdleTimeout = 10 * time.Minute
+
+// tokenBucket 单个调用方的令牌桶
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter 按调用方分别计数的令牌桶限流器
+type rateLimiter struct {
+	rate      float64 // 每秒补充的令牌数
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	mutex     sync.Mutex
+}
+
+// newRateLimiter 按规则创建限流器，规则未设置请求数时返回nil表示不限制
+func newRateLimiter(rule config.RateLimitRule) *rateLimiter {
+	if rule.RequestsPerMinute <= 0 {
+		return nil
+	}
+	burst := rule.Burst
+	if burst <= 0 {
+		burst = rule.RequestsPerMinute
+	}
+	return &rateLimiter{
+		rate:      float64(rule.RequestsPerMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow 消耗调用方的一个令牌，令牌不足时返回需要等待的时间
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.pruneLocked(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = bucket
+	} else {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*l.rate)
+		bucket.lastSeen = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// pruneLocked 定期清理长时间未使用的令牌桶，调用方需持有 l.mutex
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < bucketIdleTimeout {
+		return
+	}
+	l.lastPrune = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimit 返回限流中间件，限流未启用或规则未设置时放行
+// 需放在 authMiddleware 之后，已认证的请求按API密钥或钱包会话计数，否则按客户端IP计数
+func (s *DAppAPIServer) rateLimit(rule config.RateLimitRule) gin.HandlerFunc {
+	limiter := newRateLimiter(rule)
+	return func(c *gin.Context) {
+		if !s.cfg.System.RateLimit.Enabled || limiter == nil {
+			c.Next()
+			return
+		}
+
+		allowed, wait := limiter.allow(rateLimitKey(c))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后重试"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey 限流计数的调用方标识
+func rateLimitKey(c *gin.Context) string {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		return "key:" + value.(apiKey).name
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,124 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/reconcile"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// tokenHolding 同一钱包在同一网络上持有的一种代币，可能对应多个交易对
+type tokenHolding struct {
+	network      string
+	tokenAddress string
+	wallet       common.Address
+	symbols      []string
+}
+
+// ReconcilePositions 实现 reconcile.Source 接口，按代币比较本地持仓合计与钱包的链上余额
+// 未连接任何网络时返回 reconcile.ErrUnavailable
+func (b *BlockchainExecutor) ReconcilePositions(tolerance decimal.Decimal, correct bool) ([]reconcile.Mismatch, error) {
+	if len(b.clients) == 0 {
+		return nil, fmt.Errorf("%w: 未连接任何区块链网络", reconcile.ErrUnavailable)
+	}
+
+	holdings := make(map[string]*tokenHolding)
+	keys := make([]string, 0)
+	for _, pair := range b.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || pair.Blockchain == "" || pair.TokenAddress == "" {
+			continue
+		}
+		if _, ok := b.clients[pair.Blockchain]; !ok {
+			continue
+		}
+		w, err := b.pairWallet(pair.Symbol, pair.Blockchain)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 的钱包失败: %v", pair.Symbol, err)
+		}
+
+		key := strings.ToLower(fmt.Sprintf("%s|%s|%s", pair.Blockchain, w.address.Hex(), pair.TokenAddress))
+		holding, exists := holdings[key]
+		if !exists {
+			holding = &tokenHolding{network: pair.Blockchain, tokenAddress: pair.TokenAddress, wallet: w.address}
+			holdings[key] = holding
+			keys = append(keys, key)
+		}
+		holding.symbols = append(holding.symbols, pair.Symbol)
+	}
+	sort.Strings(keys)
+
+	positions := b.GetBlockchainPositions()
+	mismatches := make([]reconcile.Mismatch, 0)
+	for _, key := range keys {
+		holding := holdings[key]
+		actual, err := b.queryTokenBalance(b.clients[holding.network], common.HexToAddress(holding.tokenAddress), holding.wallet)
+		if err != nil {
+			return nil, fmt.Errorf("查询 %s 在 %s 上的余额失败: %v", holding.symbols[0], holding.network, err)
+		}
+
+		local := decimal.Zero
+		accounts := make([]string, 0)
+		for _, position := range positions {
+			if position.Network != holding.network || !containsSymbol(holding.symbols, position.Symbol) ||
+				!position.Quantity.IsPositive() {
+				continue
+			}
+			local = local.Add(position.Quantity)
+			accounts = append(accounts, position.Account)
+		}
+		if !reconcile.Exceeds(local, actual, tolerance) {
+			continue
+		}
+
+		mismatch := reconcile.Mismatch{
+			Venue:      metrics.VenueBlockchain,
+			Asset:      strings.Split(holding.symbols[0], "/")[0],
+			Network:    holding.network,
+			Accounts:   accounts,
+			Local:      local,
+			Actual:     actual,
+			Difference: actual.Sub(local),
+			DetectedAt: time.Now(),
+		}
+		if len(holding.symbols) == 1 {
+			mismatch.Symbol = holding.symbols[0]
+		}
+		if correct {
+			switch {
+			case mismatch.Symbol == "":
+				mismatch.Note = "该代币对应多个交易对，无法确定校正哪个持仓"
+			case len(accounts) > 1:
+				mismatch.Note = "持仓分属多个账户，无法确定校正哪个账户"
+			default:
+				account := b.cfg.Strategy.Account
+				if len(accounts) == 1 {
+					account = accounts[0]
+				}
+				if account == "" {
+					account = config.DefaultAccountID
+				}
+				b.reconcilePosition(account, mismatch.Symbol, holding.network, holding.tokenAddress, actual)
+				mismatch.Corrected = true
+			}
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches, nil
+}
+
+// containsSymbol 判断交易对是否在列表中
+func containsSymbol(symbols []string, symbol string) bool {
+	for _, s := range symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
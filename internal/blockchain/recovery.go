@@ -0,0 +1,101 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/risk"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// erc20BalanceOfSelector balanceOf(address) 的函数选择器
+var erc20BalanceOfSelector = common.FromHex("0x70a08231")
+
+// recoverPositions 启动时根据链上代币余额重建持仓
+// 钱包中的余额可能包含并非由本系统交易产生的已有持仓，这类持仓的成本未知，EntryPrice 记为0
+func (b *BlockchainExecutor) recoverPositions() {
+	account := b.cfg.Strategy.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	for _, pair := range b.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || pair.Blockchain == "" || pair.TokenAddress == "" {
+			continue
+		}
+
+		client, ok := b.clients[pair.Blockchain]
+		if !ok {
+			continue
+		}
+
+		w, err := b.pairWallet(pair.Symbol, pair.Blockchain)
+		if err != nil {
+			logrus.Errorf("恢复 %s 的链上持仓失败: %v", pair.Symbol, err)
+			continue
+		}
+
+		quantity, err := b.queryTokenBalance(client, common.HexToAddress(pair.TokenAddress), w.address)
+		if err != nil {
+			logrus.Errorf("查询 %s 在 %s 上的余额失败: %v", pair.Symbol, pair.Blockchain, err)
+			continue
+		}
+
+		b.reconcilePosition(account, pair.Symbol, pair.Blockchain, pair.TokenAddress, quantity)
+	}
+}
+
+// reconcilePosition 用链上余额校正本地持仓并通知风险管理器
+func (b *BlockchainExecutor) reconcilePosition(account, symbol, network, tokenAddress string, quantity decimal.Decimal) {
+	b.mutex.Lock()
+	key := fmt.Sprintf("%s-%s", risk.PositionKey(account, symbol), network)
+	position, exists := b.positions[key]
+
+	if quantity.IsZero() {
+		position.Quantity = decimal.Zero
+		delete(b.positions, key)
+	} else {
+		if !exists {
+			position = BlockchainPosition{
+				Account:      account,
+				Symbol:       symbol,
+				Network:      network,
+				TokenAddress: tokenAddress,
+				Recovered:    true,
+			}
+			logrus.Infof("发现链上已有持仓 %s (%s): %s，成本未知", symbol, network, quantity.String())
+		}
+		position.Quantity = quantity
+		position.Timestamp = time.Now()
+		b.positions[key] = position
+	}
+	b.savePosition(key, position)
+	riskPosition := b.riskPositionLocked(account, symbol)
+	b.mutex.Unlock()
+
+	b.riskManager.UpdatePosition(riskPosition)
+}
+
+// queryTokenBalance 查询钱包持有的ERC-20代币数量（已按代币精度换算）
+func (b *BlockchainExecutor) queryTokenBalance(client *ethclient.Client, token, wallet common.Address) (decimal.Decimal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	balance, err := queryRawTokenBalance(ctx, client, token, wallet)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	decimals, err := queryTokenDecimals(ctx, client, token)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return fromTokenUnits(balance, decimals), nil
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/risk"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// rpcHandler 模拟节点处理一个JSON-RPC调用，返回错误时作为JSON-RPC错误响应
+type rpcHandler func(method string, params []json.RawMessage) (interface{}, error)
+
+// newMockClient 返回连接到模拟节点的客户端
+func newMockClient(t *testing.T, handler rpcHandler) *ethclient.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if result, err := handler(req.Method, req.Params); err != nil {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// tokenBalances 模拟ERC-20合约的 eth_call，按代币地址返回余额，精度均为18位
+func tokenBalances(balances map[common.Address]*big.Int) rpcHandler {
+	return func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_call" {
+			return nil, fmt.Errorf("不支持的方法: %s", method)
+		}
+		var call struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+			Data  hexutil.Bytes  `json:"data"`
+		}
+		if err := json.Unmarshal(params[0], &call); err != nil {
+			return nil, err
+		}
+		input := call.Input
+		if len(input) == 0 {
+			input = call.Data
+		}
+		switch {
+		case strings.HasPrefix(hexutil.Encode(input), hexutil.Encode(erc20BalanceOfSelector)):
+			balance, ok := balances[call.To]
+			if !ok {
+				balance = new(big.Int)
+			}
+			return hexutil.Encode(common.LeftPadBytes(balance.Bytes(), 32)), nil
+		case hexutil.Encode(input) == "0x313ce567": // decimals()
+			return hexutil.Encode(common.LeftPadBytes([]byte{18}, 32)), nil
+		}
+		return nil, fmt.Errorf("execution reverted")
+	}
+}
+
+func TestRecoverPositionsFromBalances(t *testing.T) {
+	eth := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	uni := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	link := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	client := newMockClient(t, tokenBalances(map[common.Address]*big.Int{
+		eth: new(big.Int).Mul(big.NewInt(2), ether),
+		uni: new(big.Int).Mul(big.NewInt(50), ether),
+	}))
+
+	cfg := &config.Config{}
+	cfg.Risk.MaxPositionSize = 1000
+	cfg.Risk.MaxOpenPositions = 10
+	cfg.Trading.Pairs = []config.PairConfig{
+		{Symbol: "ETH/USDT", Enabled: true, Blockchain: "ethereum", TokenAddress: eth.Hex()},
+		{Symbol: "UNI/USDT", Enabled: true, Blockchain: "ethereum", TokenAddress: uni.Hex()},
+		{Symbol: "LINK/USDT", Enabled: true, Blockchain: "ethereum", TokenAddress: link.Hex()},
+	}
+	riskManager := risk.NewRiskManager(cfg)
+	ethKey := fmt.Sprintf("%s-ethereum", risk.PositionKey(config.DefaultAccountID, "ETH/USDT"))
+	linkKey := fmt.Sprintf("%s-ethereum", risk.PositionKey(config.DefaultAccountID, "LINK/USDT"))
+	b := &BlockchainExecutor{
+		cfg:         cfg,
+		riskManager: riskManager,
+		clients:     map[string]*ethclient.Client{"ethereum": client},
+		wallets:     map[string]*wallet{defaultWalletName: {name: defaultWalletName}},
+		// 本地记录的 ETH 持仓与链上不一致，LINK 持仓已在链上卖出
+		positions: map[string]BlockchainPosition{
+			ethKey:  {Account: config.DefaultAccountID, Symbol: "ETH/USDT", Network: "ethereum", Quantity: decimal.NewFromInt(1), EntryPrice: decimal.NewFromInt(2000)},
+			linkKey: {Account: config.DefaultAccountID, Symbol: "LINK/USDT", Network: "ethereum", Quantity: decimal.NewFromInt(10), EntryPrice: decimal.NewFromInt(15)},
+		},
+	}
+
+	b.recoverPositions()
+
+	position := b.positions[ethKey]
+	if !position.Quantity.Equal(decimal.NewFromInt(2)) || !position.EntryPrice.Equal(decimal.NewFromInt(2000)) || position.Recovered {
+		t.Fatalf("已有持仓应按链上余额校正数量并保留成本: %+v", position)
+	}
+	uniKey := fmt.Sprintf("%s-ethereum", risk.PositionKey(config.DefaultAccountID, "UNI/USDT"))
+	position, ok := b.positions[uniKey]
+	if !ok || !position.Quantity.Equal(decimal.NewFromInt(50)) || !position.Recovered || !position.EntryPrice.IsZero() {
+		t.Fatalf("钱包中已有的代币应恢复为成本未知的持仓: %+v", position)
+	}
+	if _, ok := b.positions[linkKey]; ok {
+		t.Fatal("链上余额为0的持仓应被移除")
+	}
+
+	positions := riskManager.GetAccountPositions(config.DefaultAccountID)
+	if len(positions) != 2 {
+		t.Fatalf("风险管理器应有 2 个链上持仓，实际 %d 个", len(positions))
+	}
+	if holding := riskManager.Holdings(config.DefaultAccountID)["UNI/USDT"]; !holding.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("风险管理器中的 UNI 持仓应为 50，实际 %s", holding)
+	}
+}
@@ -0,0 +1,151 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"autotransaction/internal/risk"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultConfirmationDepth 未配置 confirmation_depth 时的最终确认数
+const defaultConfirmationDepth = 12
+
+// confirmationDepth 返回网络的最终确认数
+func (b *BlockchainExecutor) confirmationDepth(network string) uint64 {
+	for _, n := range b.cfg.Blockchain.Networks {
+		if n.Name == network && n.ConfirmationDepth > 0 {
+			return uint64(n.ConfirmationDepth)
+		}
+	}
+	return defaultConfirmationDepth
+}
+
+// checkReorgs 重新核对已确认但未达到最终确认数的订单：交易仍在原区块时累计确认数，达到 confirmation_depth 后视为最终确认；
+// 交易被打包到其他区块时更新区块信息；交易所在区块被重组掉时订单回到待确认并撤销持仓变动，重新打包后按正常流程再次确认
+func (b *BlockchainExecutor) checkReorgs() {
+	b.mutex.RLock()
+	orders := make([]BlockchainOrder, 0)
+	for _, order := range b.orders {
+		// 升级前确认的订单没有记录区块哈希，无法核对
+		if order.Status == "confirmed" && !order.Finalized && order.TxHash != "" && order.BlockHash != "" {
+			orders = append(orders, order)
+		}
+	}
+	b.mutex.RUnlock()
+
+	heads := make(map[string]uint64)
+	for _, order := range orders {
+		client, ok := b.clients[order.Network]
+		if !ok {
+			continue
+		}
+		head, ok := heads[order.Network]
+		if !ok {
+			latest, err := client.BlockNumber(b.ctx)
+			if err != nil {
+				logrus.Warnf("获取网络 %s 的最新区块失败，跳过区块重组检查: %v", order.Network, err)
+				continue
+			}
+			head = latest
+			heads[order.Network] = head
+		}
+
+		receipt, err := client.TransactionReceipt(b.ctx, common.HexToHash(order.TxHash))
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			b.revertReorgedOrder(order)
+		case err != nil:
+			logrus.Warnf("核对区块链订单 %s 的交易收据失败: %v", order.ID, err)
+		case receipt.BlockHash.Hex() != order.BlockHash:
+			if receipt.Status != 1 {
+				// 重新打包后交易执行失败，撤销原来的持仓变动
+				b.reversePosition(order)
+				order.Fee = order.GasCost
+				order.RealizedPnL = decimal.Zero
+				order.ClosedFees = decimal.Zero
+				order.Status = "failed"
+				order.ErrorMessage = "区块重组后交易执行失败"
+				logrus.Errorf("告警: 区块链订单 %s 的交易 %s 在区块重组后执行失败，已撤销持仓变动", order.ID, order.TxHash)
+			} else {
+				logrus.Warnf("区块链订单 %s 的交易因区块重组从区块 %d 移到区块 %d", order.ID, order.BlockNumber, receipt.BlockNumber.Uint64())
+			}
+			order.BlockNumber = receipt.BlockNumber.Uint64()
+			order.BlockHash = receipt.BlockHash.Hex()
+			b.updateOrderInMap(order)
+		case head >= order.BlockNumber && head-order.BlockNumber+1 >= b.confirmationDepth(order.Network):
+			order.Finalized = true
+			b.updateOrderInMap(order)
+		}
+	}
+}
+
+// revertReorgedOrder 交易所在区块被重组掉，订单回到待确认并撤销持仓变动
+func (b *BlockchainExecutor) revertReorgedOrder(order BlockchainOrder) {
+	logrus.Errorf("告警: 区块链订单 %s 的交易 %s 所在区块 %d 已被重组，订单回到待确认并撤销持仓变动",
+		order.ID, order.TxHash, order.BlockNumber)
+
+	b.reversePosition(order)
+	order.Status = "pending"
+	order.RealizedPnL = decimal.Zero
+	order.ClosedFees = decimal.Zero
+	order.BlockNumber = 0
+	order.BlockHash = ""
+	order.Reorgs++
+	// 重新计时，避免立即被当作卡住的交易替换
+	order.SubmittedAt = time.Now()
+	b.updateOrderInMap(order)
+}
+
+// reversePosition 撤销已确认订单对持仓的变动：买入订单减少数量和开仓费用并按成交价还原开仓均价，
+// 卖出订单加回数量和分摊的开仓费用；卖出后已清仓的持仓无法还原原开仓价，以卖出价作为开仓价
+func (b *BlockchainExecutor) reversePosition(order BlockchainOrder) {
+	b.mutex.Lock()
+	key := fmt.Sprintf("%s-%s", risk.PositionKey(order.Account, order.Symbol), order.Network)
+	position, exists := b.positions[key]
+	position.Timestamp = time.Now()
+
+	switch order.Direction {
+	case "buy":
+		if !exists {
+			b.mutex.Unlock()
+			logrus.Warnf("撤销买入订单 %s 时持仓 %s 已不存在", order.ID, key)
+			return
+		}
+		remaining := position.Quantity.Sub(order.Quantity)
+		if remaining.IsPositive() {
+			cost := position.EntryPrice.Mul(position.Quantity).Sub(order.Price.Mul(order.Quantity))
+			position.EntryPrice = decimal.Max(cost.Div(remaining), decimal.Zero)
+			position.Quantity = remaining
+			position.Fees = decimal.Max(position.Fees.Sub(order.Fee), decimal.Zero)
+			b.positions[key] = position
+		} else {
+			position.Quantity = decimal.Zero
+			delete(b.positions, key)
+		}
+	case "sell":
+		if !exists {
+			position = BlockchainPosition{
+				Account:      order.Account,
+				Symbol:       order.Symbol,
+				Network:      order.Network,
+				EntryPrice:   order.Price,
+				CurrentPrice: order.Price,
+				Timestamp:    time.Now(),
+			}
+		}
+		position.Quantity = position.Quantity.Add(order.Quantity)
+		position.Fees = position.Fees.Add(order.ClosedFees)
+		b.positions[key] = position
+	}
+	b.savePosition(key, position)
+	riskPosition := b.riskPositionLocked(order.Account, order.Symbol)
+	b.mutex.Unlock()
+
+	b.riskManager.UpdatePosition(riskPosition)
+}
@@ -0,0 +1,336 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRPCCheckInterval = 30 * time.Second
+	defaultRPCMaxBlockLag   = 5
+	rpcCheckTimeout         = 5 * time.Second
+)
+
+// rpcEndpoint 网络的一个RPC节点及其最近一次健康检查的结果
+type rpcEndpoint struct {
+	target *url.URL
+	client *ethclient.Client // 直连该节点的客户端，只用于健康检查
+
+	healthy     bool
+	latency     time.Duration
+	blockNumber uint64
+	lastError   string
+	checkedAt   time.Time
+}
+
+// rpcPool 同一网络的多个RPC节点：定时检查各节点的最新区块号和延迟，选择延迟最低的健康节点；
+// 作为 http.RoundTripper 使用时把请求发往当前节点，连接失败或返回5xx/429时依次改用其他节点重试
+type rpcPool struct {
+	network   string
+	endpoints []*rpcEndpoint
+	active    int
+	interval  time.Duration
+	maxLag    uint64
+	transport http.RoundTripper
+	mutex     sync.RWMutex
+}
+
+// dialNetwork 连接网络的RPC节点；只配置了一个节点时直接连接，返回的 pool 为nil，
+// 配置了多个节点时返回的客户端通过 pool 自动选择和切换节点
+func dialNetwork(network config.NetworkConfig) (*ethclient.Client, *rpcPool, error) {
+	endpoints := network.Endpoints()
+	if len(endpoints) <= 1 {
+		client, err := ethclient.Dial(network.RPCURL)
+		return client, nil, err
+	}
+
+	pool, err := newRPCPool(network, endpoints)
+	if err != nil {
+		return nil, nil, err
+	}
+	rpcClient, err := rpc.DialOptions(context.Background(), endpoints[0], rpc.WithHTTPClient(&http.Client{Transport: pool}))
+	if err != nil {
+		pool.close()
+		return nil, nil, err
+	}
+	return ethclient.NewClient(rpcClient), pool, nil
+}
+
+// newRPCPool 创建节点池，首次健康检查之前所有节点视为健康，rpc_url 为当前节点
+func newRPCPool(network config.NetworkConfig, endpoints []string) (*rpcPool, error) {
+	pool := &rpcPool{
+		network:   network.Name,
+		interval:  time.Duration(network.RPCCheckSeconds) * time.Second,
+		maxLag:    uint64(network.RPCMaxBlockLag),
+		transport: http.DefaultTransport,
+	}
+	if pool.interval <= 0 {
+		pool.interval = defaultRPCCheckInterval
+	}
+	if pool.maxLag == 0 {
+		pool.maxLag = defaultRPCMaxBlockLag
+	}
+
+	for _, endpoint := range endpoints {
+		target, err := url.Parse(endpoint)
+		if err != nil {
+			pool.close()
+			return nil, fmt.Errorf("网络 %s 的节点地址无效: %v", network.Name, err)
+		}
+		// 节点切换通过 HTTP 传输层实现，WebSocket 和 IPC 连接无法切换
+		if target.Scheme != "http" && target.Scheme != "https" {
+			pool.close()
+			return nil, fmt.Errorf("网络 %s 配置了多个节点时只支持 http(s) 地址: %s", network.Name, endpointLabel(target))
+		}
+		client, err := ethclient.Dial(endpoint)
+		if err != nil {
+			pool.close()
+			return nil, fmt.Errorf("连接到网络 %s 的节点 %s 失败: %v", network.Name, endpointLabel(target), err)
+		}
+		pool.endpoints = append(pool.endpoints, &rpcEndpoint{target: target, client: client, healthy: true})
+	}
+	return pool, nil
+}
+
+// endpointLabel 返回用于日志和健康检查的节点名称，只保留协议和主机，避免泄露地址中的API密钥
+func endpointLabel(target *url.URL) string {
+	return target.Scheme + "://" + target.Host
+}
+
+// RoundTrip 实现 http.RoundTripper 接口，按当前节点、其他健康节点、不健康节点的顺序发送请求，
+// 直到某个节点正常响应；请求被取消时不再重试
+func (p *rpcPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := p.candidates()
+	var lastErr error
+	for i, endpoint := range candidates {
+		attempt := req.Clone(req.Context())
+		target := *endpoint.target
+		attempt.URL = &target
+		attempt.Host = target.Host
+		attempt.Body = io.NopCloser(bytes.NewReader(body))
+		attempt.ContentLength = int64(len(body))
+
+		resp, err := p.transport.RoundTrip(attempt)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			if i > 0 {
+				p.promote(endpoint)
+			}
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil {
+			// 最后一个节点的错误响应原样返回，由调用方解析
+			if i == len(candidates)-1 {
+				return resp, nil
+			}
+			resp.Body.Close()
+			err = fmt.Errorf("状态码: %d", resp.StatusCode)
+		}
+		lastErr = err
+		p.markFailed(endpoint, err)
+	}
+	return nil, lastErr
+}
+
+// candidates 返回发送请求时尝试节点的顺序：当前节点在前，其余健康节点按延迟从低到高，不健康节点在最后
+func (p *rpcPool) candidates() []*rpcEndpoint {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	active := p.endpoints[p.active]
+	others := make([]*rpcEndpoint, 0, len(p.endpoints)-1)
+	for _, endpoint := range p.endpoints {
+		if endpoint != active {
+			others = append(others, endpoint)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		if others[i].healthy != others[j].healthy {
+			return others[i].healthy
+		}
+		return others[i].latency < others[j].latency
+	})
+	return append([]*rpcEndpoint{active}, others...)
+}
+
+// markFailed 将请求失败的节点标记为不健康，直到下一次健康检查恢复
+func (p *rpcPool) markFailed(endpoint *rpcEndpoint, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	endpoint.healthy = false
+	endpoint.lastError = err.Error()
+	logrus.Warnf("网络 %s 的RPC节点 %s 请求失败: %v", p.network, endpointLabel(endpoint.target), err)
+}
+
+// promote 将正常响应请求的节点设为当前节点
+func (p *rpcPool) promote(endpoint *rpcEndpoint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, candidate := range p.endpoints {
+		if candidate == endpoint && i != p.active {
+			p.active = i
+			logrus.Warnf("网络 %s 已切换到RPC节点 %s", p.network, endpointLabel(endpoint.target))
+		}
+	}
+}
+
+// run 定时检查各节点，直到 ctx 取消
+func (p *rpcPool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check 并发获取各节点的最新区块号并记录延迟；请求失败或区块号落后最高节点超过 rpc_max_block_lag 的节点为不健康，
+// 之后选择当前节点
+func (p *rpcPool) check(ctx context.Context) {
+	type probe struct {
+		blockNumber uint64
+		latency     time.Duration
+		err         error
+	}
+	probes := make([]probe, len(p.endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range p.endpoints {
+		wg.Add(1)
+		go func(i int, client *ethclient.Client) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, rpcCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			blockNumber, err := client.BlockNumber(checkCtx)
+			probes[i] = probe{blockNumber: blockNumber, latency: time.Since(start), err: err}
+		}(i, endpoint.client)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	highest := uint64(0)
+	for _, result := range probes {
+		if result.err == nil && result.blockNumber > highest {
+			highest = result.blockNumber
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	for i, endpoint := range p.endpoints {
+		result := probes[i]
+		endpoint.checkedAt = now
+		endpoint.latency = result.latency
+		switch {
+		case result.err != nil:
+			endpoint.healthy = false
+			endpoint.lastError = result.err.Error()
+		case highest-result.blockNumber > p.maxLag:
+			endpoint.healthy = false
+			endpoint.blockNumber = result.blockNumber
+			endpoint.lastError = fmt.Sprintf("区块号 %d 落后最高节点 %d 个区块", result.blockNumber, highest-result.blockNumber)
+		default:
+			endpoint.healthy = true
+			endpoint.blockNumber = result.blockNumber
+			endpoint.lastError = ""
+		}
+	}
+	p.selectLocked()
+}
+
+// selectLocked 选择当前节点：当前节点不健康，或其他健康节点的延迟不到当前节点的一半时，切换到延迟最低的健康节点；
+// 没有健康节点时保持不变。调用方需持有 mutex 写锁
+func (p *rpcPool) selectLocked() {
+	best := -1
+	for i, endpoint := range p.endpoints {
+		if endpoint.healthy && (best < 0 || endpoint.latency < p.endpoints[best].latency) {
+			best = i
+		}
+	}
+	if best < 0 {
+		logrus.Errorf("告警: 网络 %s 的所有RPC节点都不健康", p.network)
+		return
+	}
+
+	current := p.endpoints[p.active]
+	if best == p.active || (current.healthy && p.endpoints[best].latency*2 >= current.latency) {
+		return
+	}
+	if current.healthy {
+		logrus.Infof("网络 %s 切换到延迟更低的RPC节点 %s (%s)", p.network,
+			endpointLabel(p.endpoints[best].target), p.endpoints[best].latency.Round(time.Millisecond))
+	} else {
+		logrus.Warnf("网络 %s 的RPC节点 %s 不健康 (%s)，切换到 %s", p.network,
+			endpointLabel(current.target), current.lastError, endpointLabel(p.endpoints[best].target))
+	}
+	p.active = best
+}
+
+// status 返回各节点最近一次健康检查的结果，用于健康检查接口
+func (p *rpcPool) status() []map[string]interface{} {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	result := make([]map[string]interface{}, 0, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		item := map[string]interface{}{
+			"endpoint":    endpointLabel(endpoint.target),
+			"active":      i == p.active,
+			"healthy":     endpoint.healthy,
+			"latencyMs":   endpoint.latency.Milliseconds(),
+			"blockNumber": endpoint.blockNumber,
+		}
+		if endpoint.lastError != "" {
+			item["error"] = endpoint.lastError
+		}
+		if !endpoint.checkedAt.IsZero() {
+			item["checkedAt"] = endpoint.checkedAt
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// close 关闭各节点的健康检查客户端
+func (p *rpcPool) close() {
+	for _, endpoint := range p.endpoints {
+		endpoint.client.Close()
+	}
+}
@@ -0,0 +1,19 @@
+package blockchain
+
+import (
+	"testing"
+
+	"autotransaction/config"
+)
+
+func TestDialNetworkRejectsWebSocketFailover(t *testing.T) {
+	_, pool, err := dialNetwork(config.NetworkConfig{
+		Name:    "ethereum",
+		RPCURL:  "https://mainnet.example.com",
+		RPCURLs: []string{"wss://backup.example.com"},
+	})
+	if err == nil {
+		pool.close()
+		t.Fatal("多个节点时应拒绝 ws(s) 地址")
+	}
+}
@@ -0,0 +1,246 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 外部签名服务类型
+const (
+	signerClef   = "clef"   // geth Clef，通过 account_signTransaction 签名
+	signerRemote = "remote" // HTTP签名服务，如 KMS 签名代理或托管钱包的签名API
+)
+
+// defaultSignerTimeout 未配置时等待外部签名服务的时间
+const defaultSignerTimeout = 30 * time.Second
+
+// Signer 为交易签名，私钥可以在进程内，也可以在硬件钱包或远程签名服务中
+type Signer interface {
+	// Address 返回签名账户地址
+	Address() common.Address
+	// SignTx 签名交易，返回的交易必须由 Address 签名
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// newSigner 按钱包配置创建签名器，配置了外部签名服务时不需要私钥
+func newSigner(walletCfg config.WalletConfig) (Signer, error) {
+	signerCfg := walletCfg.Signer
+	if signerCfg.Type == "" {
+		key, err := loadPrivateKey(walletCfg)
+		if err != nil {
+			return nil, err
+		}
+		return newKeySigner(key), nil
+	}
+
+	if signerCfg.URL == "" || !common.IsHexAddress(signerCfg.Address) {
+		return nil, fmt.Errorf("外部签名服务需要配置 url 和 address")
+	}
+	timeout := defaultSignerTimeout
+	if signerCfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(signerCfg.TimeoutSeconds) * time.Second
+	}
+	address := common.HexToAddress(signerCfg.Address)
+
+	switch signerCfg.Type {
+	case signerClef:
+		client, err := rpc.Dial(signerCfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("连接Clef失败: %v", err)
+		}
+		return &clefSigner{client: client, address: address, timeout: timeout}, nil
+	case signerRemote:
+		token := ""
+		if signerCfg.AuthTokenEnv != "" {
+			token = os.Getenv(signerCfg.AuthTokenEnv)
+		}
+		return &remoteSigner{
+			url:        signerCfg.URL,
+			address:    address,
+			authToken:  token,
+			httpClient: &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的签名服务类型: %s", signerCfg.Type)
+	}
+}
+
+// keySigner 使用进程内私钥签名
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// newKeySigner 创建进程内私钥签名器
+func newKeySigner(key *ecdsa.PrivateKey) *keySigner {
+	return &keySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// Address 实现 Signer 接口
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 实现 Signer 接口
+func (s *keySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.key)
+}
+
+// clefSigner 通过 Clef 的 account_signTransaction 接口签名，私钥可在Clef管理的keystore或硬件钱包中
+type clefSigner struct {
+	client  *rpc.Client
+	address common.Address
+	timeout time.Duration
+}
+
+// clefTxArgs 与 Clef 的 SendTxArgs 对应
+type clefTxArgs struct {
+	From     common.MixedcaseAddress  `json:"from"`
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice *hexutil.Big             `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     *hexutil.Bytes           `json:"data"`
+	ChainID  *hexutil.Big             `json:"chainId"`
+}
+
+// Address 实现 Signer 接口
+func (s *clefSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 实现 Signer 接口，需要在Clef中确认或由其规则自动批准
+func (s *clefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	data := hexutil.Bytes(tx.Data())
+	args := clefTxArgs{
+		From:     common.NewMixedcaseAddress(s.address),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     &data,
+		ChainID:  (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("Clef签名失败: %v", err)
+	}
+	return decodeSignedTx(result.Raw, tx, chainID, s.address)
+}
+
+// remoteSigner 通过HTTP签名服务签名
+// 请求体为 {"address", "chainId", "transaction"}，transaction 为未签名交易的RLP编码，
+// 响应体为 {"signedTransaction"}，为签名后交易的RLP编码
+type remoteSigner struct {
+	url        string
+	address    common.Address
+	authToken  string
+	httpClient *http.Client
+}
+
+// Address 实现 Signer 接口
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 实现 Signer 接口
+func (s *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	unsigned, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("编码交易失败: %v", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"address":     s.address.Hex(),
+		"chainId":     chainID.String(),
+		"transaction": hexutil.Encode(unsigned),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("编码签名请求失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建签名请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.authToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求签名服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取签名服务响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("签名服务返回错误 %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		SignedTransaction string `json:"signedTransaction"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("解析签名服务响应失败: %v", err)
+	}
+	raw, err := hexutil.Decode(result.SignedTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名交易失败: %v", err)
+	}
+	return decodeSignedTx(raw, tx, chainID, s.address)
+}
+
+// decodeSignedTx 解码外部签名服务返回的交易，并校验签名者和交易内容未被改动
+func decodeSignedTx(raw []byte, original *types.Transaction, chainID *big.Int, address common.Address) (*types.Transaction, error) {
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("解码签名交易失败: %v", err)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("恢复签名者失败: %v", err)
+	}
+	if sender != address {
+		return nil, fmt.Errorf("签名者 %s 与钱包地址 %s 不一致", sender.Hex(), address.Hex())
+	}
+
+	if signed.Nonce() != original.Nonce() || signed.Gas() != original.Gas() ||
+		signed.Value().Cmp(original.Value()) != 0 || !bytes.Equal(signed.Data(), original.Data()) ||
+		(signed.To() == nil) != (original.To() == nil) || (signed.To() != nil && *signed.To() != *original.To()) {
+		return nil, fmt.Errorf("签名服务返回的交易内容与请求不一致")
+	}
+	return signed, nil
+}
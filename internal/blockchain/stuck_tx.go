@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/sirupsen/logrus"
+)
+
+// 卡住交易的处理方式
+const (
+	stuckActionSpeedUp = "speed_up" // 以更高的gas价格重新发送同一笔交易
+	stuckActionCancel  = "cancel"   // 用同一nonce发送转给自己的空交易取消原交易
+)
+
+// minReplacementBumpPercent 节点接受替换交易要求的最小gas价格涨幅
+const minReplacementBumpPercent = 10
+
+// handleStuckTransactions 处理等待打包超过阈值的订单交易：
+// 加速达到最大次数后（或配置为直接取消时）发送取消交易
+func (b *BlockchainExecutor) handleStuckTransactions() {
+	stuckCfg := b.cfg.Blockchain.StuckTx
+	if !stuckCfg.Enabled {
+		return
+	}
+
+	threshold := time.Duration(stuckCfg.PendingTimeoutSeconds) * time.Second
+	if threshold <= 0 {
+		threshold = 3 * time.Minute
+	}
+
+	b.mutex.RLock()
+	stuck := make([]BlockchainOrder, 0)
+	for _, order := range b.orders {
+		if order.Status == "pending" && order.TxHash != "" && !order.SubmittedAt.IsZero() &&
+			time.Since(order.SubmittedAt) > threshold {
+			stuck = append(stuck, order)
+		}
+	}
+	b.mutex.RUnlock()
+
+	for _, order := range stuck {
+		client, ok := b.clients[order.Network]
+		if !ok {
+			continue
+		}
+
+		// 已在取消中的订单只能继续提高取消交易的gas价格
+		cancel := order.Canceling || stuckCfg.Action == stuckActionCancel ||
+			(stuckCfg.MaxSpeedUps > 0 && order.Replacements >= stuckCfg.MaxSpeedUps)
+
+		replaced, err := b.replaceTransaction(client, order, cancel, stuckCfg.GasBumpPercent)
+		if err != nil {
+			logrus.Errorf("替换订单 %s 卡住的交易 %s 失败: %v", order.ID, order.TxHash, err)
+			continue
+		}
+
+		action := "加速"
+		if cancel {
+			action = "取消"
+		}
+		logrus.Warnf("订单 %s 的交易 %s 等待打包超过 %s，已发送%s交易: %s",
+			order.ID, order.TxHash, threshold, action, replaced.Hash().Hex())
+
+		order.PreviousTxHashes = append(order.PreviousTxHashes, order.TxHash)
+		order.TxHash = replaced.Hash().Hex()
+		order.Replacements++
+		order.Canceling = cancel
+		order.SubmittedAt = time.Now()
+		b.updateOrderInMap(order)
+	}
+}
+
+// CancelOpenOrders 撤销所有未完成的链上订单：排队等待gas价格回落的订单直接撤销，
+// 已发送未打包的交易用同一nonce发送取消交易，返回处理的订单数
+func (b *BlockchainExecutor) CancelOpenOrders(reason string) int {
+	b.mutex.RLock()
+	open := make([]BlockchainOrder, 0)
+	for _, order := range b.orders {
+		if order.Status == "gas_queued" || (order.Status == "pending" && order.TxHash != "" && !order.Canceling) {
+			open = append(open, order)
+		}
+	}
+	b.mutex.RUnlock()
+
+	canceled := 0
+	for _, order := range open {
+		if order.Status == "gas_queued" {
+			order.Status = "canceled"
+			order.ErrorMessage = reason
+			b.updateOrderInMap(order)
+			canceled++
+			continue
+		}
+
+		client, ok := b.clients[order.Network]
+		if !ok {
+			continue
+		}
+		replaced, err := b.replaceTransaction(client, order, true, b.cfg.Blockchain.StuckTx.GasBumpPercent)
+		if err != nil {
+			logrus.Errorf("取消订单 %s 的交易 %s 失败: %v", order.ID, order.TxHash, err)
+			continue
+		}
+		logrus.Warnf("订单 %s 已发送取消交易: %s", order.ID, replaced.Hash().Hex())
+
+		order.PreviousTxHashes = append(order.PreviousTxHashes, order.TxHash)
+		order.TxHash = replaced.Hash().Hex()
+		order.Replacements++
+		order.Canceling = true
+		order.SubmittedAt = time.Now()
+		b.updateOrderInMap(order)
+		canceled++
+	}
+	return canceled
+}
+
+// replaceTransaction 用相同nonce和更高的gas价格发送替换交易
+// cancel 为 true 时替换为转给自己的空交易，否则原样重发交易内容
+func (b *BlockchainExecutor) replaceTransaction(client *ethclient.Client, order BlockchainOrder, cancel bool, bumpPercent int) (*types.Transaction, error) {
+	ctx, cancelCtx := context.WithTimeout(b.ctx, 30*time.Second)
+	defer cancelCtx()
+
+	// 经私有中继提交的交易不在公开内存池中，需向中继查询和发送
+	if private, ok := b.privateClients[order.Network]; ok && order.PrivateRelay {
+		client = private
+	}
+
+	original, _, err := client.TransactionByHash(ctx, common.HexToHash(order.TxHash))
+	if err != nil {
+		return nil, fmt.Errorf("查询原交易失败: %v", err)
+	}
+
+	if bumpPercent < minReplacementBumpPercent {
+		bumpPercent = minReplacementBumpPercent
+	}
+	gasPrice := bumpGasPrice(original.GasPrice(), bumpPercent)
+	if suggested, err := client.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+
+	w, err := b.orderWallet(order)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *types.Transaction
+	if cancel {
+		tx = types.NewTransaction(original.Nonce(), w.address, big.NewInt(0), 21000, gasPrice, nil)
+	} else {
+		tx = types.NewTransaction(original.Nonce(), *original.To(), original.Value(), original.Gas(), gasPrice, original.Data())
+	}
+
+	chainID, err := client.NetworkID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取网络ID失败: %v", err)
+	}
+	signedTx, err := w.sign(ctx, tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("发送交易失败: %v", err)
+	}
+
+	if manager, ok := w.nonces[order.Network]; ok {
+		manager.Track(signedTx)
+	}
+	return signedTx, nil
+}
+
+// findReceipt 查找订单当前交易或被替换前交易的回执，返回回执和对应的交易哈希
+// 替换交易发出后原交易仍可能先被打包，因此需要检查所有哈希
+func findReceipt(client *ethclient.Client, order BlockchainOrder) (*types.Receipt, string) {
+	hashes := append([]string{order.TxHash}, order.PreviousTxHashes...)
+	for _, hash := range hashes {
+		receipt, err := client.TransactionReceipt(context.Background(), common.HexToHash(hash))
+		if err == nil {
+			return receipt, hash
+		}
+	}
+	return nil, ""
+}
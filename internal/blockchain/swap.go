@@ -0,0 +1,238 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 路由合约版本
+const (
+	routerV2 = "v2" // Uniswap V2 / PancakeSwap 路由: swapExactTokensForTokens
+	routerV3 = "v3" // Uniswap V3 SwapRouter: exactInputSingle
+)
+
+// defaultSwapDeadline 未配置时兑换交易的有效期
+const defaultSwapDeadline = 5 * time.Minute
+
+const routerV2ABI = `[{"name":"swapExactTokensForTokens","type":"function","stateMutability":"nonpayable",
+"inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+"outputs":[{"name":"amounts","type":"uint256[]"}]}]`
+
+const routerV3ABI = `[{"name":"exactInputSingle","type":"function","stateMutability":"payable",
+"inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],
+"outputs":[{"name":"amountOut","type":"uint256"}]}]`
+
+var (
+	parsedRouterV2ABI = mustParseABI(routerV2ABI)
+	parsedRouterV3ABI = mustParseABI(routerV3ABI)
+)
+
+// exactInputSingleParams 与 V3 SwapRouter 的 ExactInputSingleParams 结构对应
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	Deadline          *big.Int
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// swapCall 构建好的路由合约调用
+type swapCall struct {
+	router       common.Address
+	tokenIn      common.Address
+	tokenOut     common.Address
+	data         []byte
+	amountIn     *big.Int
+	amountOutMin *big.Int
+	quantity     decimal.Decimal // 按标的代币精度截断后的订单数量
+
+	spender common.Address // 需要授权输入代币的合约，为零地址时为 router
+	route   string         // 兑换路径: router 或聚合器名称，见 routeSwap
+}
+
+// maxQuoteAge 用于滑点检查的链上价格的最大时效，超过时不检查
+const maxQuoteAge = 2 * time.Minute
+
+// SetDEXPriceProvider 设置链上价格来源，下单前按最新价格检查滑点，
+// 并按 fees.dex.native_pairs 将实际消耗的gas换算为计价货币
+func (b *BlockchainExecutor) SetDEXPriceProvider(prices strategy.DEXPriceProvider) {
+	b.dexPrices = prices
+}
+
+// checkQuoteSlippage 比较订单价格与最新链上价格，不利滑点超过容忍度时
+// 按 risk.slippage_action 拒绝订单或按最新价格重新定价；没有近期价格时不检查
+func (b *BlockchainExecutor) checkQuoteSlippage(order *BlockchainOrder) error {
+	if b.dexPrices == nil {
+		return nil
+	}
+	quote, at, ok := b.dexPrices.LatestDEXPrice(order.Symbol)
+	if !ok || time.Since(at) > maxQuoteAge {
+		return nil
+	}
+
+	err := b.riskManager.CheckQuoteSlippage(order.Direction, order.Price, quote)
+	if err == nil {
+		return nil
+	}
+	if !b.riskManager.RequoteOnSlippage() {
+		return err
+	}
+	logrus.Warnf("区块链订单 %s %v，按最新链上价格重新定价", order.ID, err)
+	order.Price = quote
+	return nil
+}
+
+// buildSwap 按订单构建DEX路由合约的兑换调用，配置了聚合器时按扣除gas后的获得数量选择兑换路径
+// 买入用计价代币兑换标的代币，卖出反之；最少获得数量按风险配置的滑点容忍度计算
+func (b *BlockchainExecutor) buildSwap(client *ethclient.Client, order BlockchainOrder, wallet common.Address) (swapCall, error) {
+	networkCfg, ok := b.networkConfig(order.Network)
+	if !ok {
+		return swapCall{}, fmt.Errorf("未找到网络 %s 的配置", order.Network)
+	}
+	routerCfg := networkCfg.Router
+	if routerCfg.Address == "" {
+		return swapCall{}, fmt.Errorf("网络 %s 未配置DEX路由合约", order.Network)
+	}
+
+	pair, ok := findPair(b.cfg.Current().Trading.Pairs, order.Symbol)
+	if !ok || pair.TokenAddress == "" {
+		return swapCall{}, fmt.Errorf("交易对 %s 未配置代币合约地址", order.Symbol)
+	}
+	quoteToken := pair.QuoteTokenAddress
+	if quoteToken == "" {
+		quoteToken = routerCfg.QuoteTokenAddress
+	}
+	if quoteToken == "" {
+		return swapCall{}, fmt.Errorf("交易对 %s 未配置计价代币合约地址", order.Symbol)
+	}
+
+	baseToken := common.HexToAddress(pair.TokenAddress)
+	quote := common.HexToAddress(quoteToken)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseDecimals, err := queryTokenDecimals(ctx, client, baseToken)
+	if err != nil {
+		return swapCall{}, err
+	}
+	quoteDecimals, err := queryTokenDecimals(ctx, client, quote)
+	if err != nil {
+		return swapCall{}, err
+	}
+
+	// 订单数量按标的代币精度截断，使持仓与链上实际兑换的数量一致
+	quantity := order.Quantity.Truncate(baseDecimals)
+	if !quantity.IsPositive() {
+		return swapCall{}, fmt.Errorf("订单数量 %s 小于代币最小单位(精度 %d)", order.Quantity.String(), baseDecimals)
+	}
+
+	tokenIn, tokenOut := quote, baseToken
+	decimalsIn, decimalsOut := quoteDecimals, baseDecimals
+	amountIn, expectedOut := quantity.Mul(order.Price), quantity
+	if order.Direction == "sell" {
+		tokenIn, tokenOut = baseToken, quote
+		decimalsIn, decimalsOut = baseDecimals, quoteDecimals
+		amountIn, expectedOut = quantity, quantity.Mul(order.Price)
+	}
+
+	// 最少获得数量 = 预期数量 * (1 - 滑点容忍度)
+	tolerance := decimal.NewFromFloat(b.cfg.Current().Risk.SlippageTolerance).Div(decimal.NewFromInt(100))
+	minOut := expectedOut.Mul(decimal.NewFromInt(1).Sub(tolerance))
+
+	call := swapCall{
+		router:       common.HexToAddress(routerCfg.Address),
+		tokenIn:      tokenIn,
+		tokenOut:     tokenOut,
+		amountIn:     toTokenUnits(amountIn, decimalsIn),
+		amountOutMin: toTokenUnits(minOut, decimalsOut),
+		quantity:     quantity,
+	}
+	if call.amountIn.Sign() <= 0 {
+		return swapCall{}, fmt.Errorf("兑换输入数量为0")
+	}
+
+	deadlineDuration := defaultSwapDeadline
+	if routerCfg.DeadlineSeconds > 0 {
+		deadlineDuration = time.Duration(routerCfg.DeadlineSeconds) * time.Second
+	}
+	deadline := big.NewInt(time.Now().Add(deadlineDuration).Unix())
+
+	switch routerCfg.Version {
+	case "", routerV2:
+		call.data, err = parsedRouterV2ABI.Pack("swapExactTokensForTokens",
+			call.amountIn, call.amountOutMin, swapPath(tokenIn, tokenOut, routerCfg.PathVia), wallet, deadline)
+	case routerV3:
+		call.data, err = parsedRouterV3ABI.Pack("exactInputSingle", exactInputSingleParams{
+			TokenIn:           tokenIn,
+			TokenOut:          tokenOut,
+			Fee:               big.NewInt(int64(routerCfg.PoolFee)),
+			Recipient:         wallet,
+			Deadline:          deadline,
+			AmountIn:          call.amountIn,
+			AmountOutMinimum:  call.amountOutMin,
+			SqrtPriceLimitX96: big.NewInt(0),
+		})
+	default:
+		return swapCall{}, fmt.Errorf("未知的路由合约版本: %s", routerCfg.Version)
+	}
+	if err != nil {
+		return swapCall{}, fmt.Errorf("编码路由合约调用失败: %v", err)
+	}
+
+	return b.routeSwap(client, order, wallet, call, expectedOut, decimalsOut), nil
+}
+
+// swapPath 构建 V2 兑换路径: 输入代币 -> 中间代币 -> 输出代币
+func swapPath(tokenIn, tokenOut common.Address, via []string) []common.Address {
+	path := []common.Address{tokenIn}
+	for _, hop := range via {
+		hopAddress := common.HexToAddress(hop)
+		if hopAddress == tokenIn || hopAddress == tokenOut {
+			continue
+		}
+		path = append(path, hopAddress)
+	}
+	return append(path, tokenOut)
+}
+
+// findPair 查找交易对配置
+func findPair(pairs []config.PairConfig, symbol string) (config.PairConfig, bool) {
+	for _, pair := range pairs {
+		if pair.Symbol == symbol {
+			return pair, true
+		}
+	}
+	return config.PairConfig{}, false
+}
+
+// mustParseABI 解析内置的ABI定义
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("解析内置ABI失败: %v", err))
+	}
+	return parsed
+}
+
+// approvalTarget 返回需要授权输入代币的合约
+func (s swapCall) approvalTarget() common.Address {
+	if s.spender != (common.Address{}) {
+		return s.spender
+	}
+	return s.router
+}
@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// erc20MetadataABI ERC-20 元数据接口，symbol 和 name 为可选方法
+const erc20MetadataABI = `[{"name":"symbol","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"name":"name","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]}]`
+
+var parsedERC20MetadataABI = mustParseABI(erc20MetadataABI)
+
+// TokenMetadata ERC-20 代币的元数据
+type TokenMetadata struct {
+	Address  string
+	Symbol   string // 合约未实现 symbol() 时为空
+	Name     string // 合约未实现 name() 时为空
+	Decimals int32
+}
+
+// tokenKey 元数据缓存的键，代币合约地址只在同一条链上唯一，按连接该链的客户端区分
+type tokenKey struct {
+	client *ethclient.Client
+	token  common.Address
+}
+
+// tokenCache 代币元数据缓存，元数据部署后不会变化，进程内只查询一次
+var tokenCache = struct {
+	entries map[tokenKey]TokenMetadata
+	mutex   sync.RWMutex
+}{entries: make(map[tokenKey]TokenMetadata)}
+
+// queryTokenMetadata 查询ERC-20代币的元数据，优先使用缓存；decimals() 调用失败时返回错误，
+// symbol() 和 name() 失败时留空
+func queryTokenMetadata(ctx context.Context, client *ethclient.Client, token common.Address) (TokenMetadata, error) {
+	key := tokenKey{client: client, token: token}
+	tokenCache.mutex.RLock()
+	metadata, ok := tokenCache.entries[key]
+	tokenCache.mutex.RUnlock()
+	if ok {
+		return metadata, nil
+	}
+
+	out, err := callView(ctx, client, parsedERC20MetadataABI, token, "decimals")
+	if err != nil {
+		return TokenMetadata{}, fmt.Errorf("调用decimals失败: %v", err)
+	}
+	metadata = TokenMetadata{
+		Address:  token.Hex(),
+		Symbol:   queryTokenString(ctx, client, token, "symbol"),
+		Name:     queryTokenString(ctx, client, token, "name"),
+		Decimals: int32(out[0].(uint8)),
+	}
+
+	tokenCache.mutex.Lock()
+	tokenCache.entries[key] = metadata
+	tokenCache.mutex.Unlock()
+	return metadata, nil
+}
+
+// queryTokenString 调用返回字符串的元数据方法，兼容早期以 bytes32 返回的代币（如 MKR），调用失败时返回空字符串
+func queryTokenString(ctx context.Context, client *ethclient.Client, token common.Address, method string) string {
+	data, err := parsedERC20MetadataABI.Pack(method)
+	if err != nil {
+		return ""
+	}
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil || len(result) == 0 {
+		return ""
+	}
+	if out, err := parsedERC20MetadataABI.Unpack(method, result); err == nil && len(out) == 1 {
+		if text, ok := out[0].(string); ok {
+			return text
+		}
+	}
+	if len(result) == 32 {
+		return strings.TrimSpace(string(bytes.TrimRight(result, "\x00")))
+	}
+	return ""
+}
+
+// queryTokenDecimals 查询ERC-20代币的精度
+func queryTokenDecimals(ctx context.Context, client *ethclient.Client, token common.Address) (int32, error) {
+	metadata, err := queryTokenMetadata(ctx, client, token)
+	if err != nil {
+		return 0, err
+	}
+	return metadata.Decimals, nil
+}
+
+// toTokenUnits 按代币精度将数量换算为最小单位，舍去多余的小数
+func toTokenUnits(amount decimal.Decimal, decimals int32) *big.Int {
+	return amount.Shift(decimals).Truncate(0).BigInt()
+}
+
+// fromTokenUnits 按代币精度将最小单位的数量换算为代币数量
+func fromTokenUnits(amount *big.Int, decimals int32) decimal.Decimal {
+	return decimal.NewFromBigInt(amount, -decimals)
+}
@@ -0,0 +1,483 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 代币安全检查项
+const (
+	safetyCheckVerified  = "verified_source"      // 合约源码已在区块浏览器验证
+	safetyCheckTax       = "transfer_tax"         // 税率函数返回的买卖税率
+	safetyCheckBlacklist = "blacklist"            // 合约字节码中的黑名单函数
+	safetyCheckLiquidity = "liquidity_lock"       // 流动性池LP代币的销毁或锁仓比例
+	safetyCheckHolders   = "holder_concentration" // 合约所有者和部署者的持有比例
+)
+
+// defaultSafetyCacheMinutes 未配置 cache_minutes 时检查结果的缓存时间
+const defaultSafetyCacheMinutes = 60
+
+// burnAddresses 视为已销毁的LP代币持有地址
+var burnAddresses = []common.Address{
+	{},
+	common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+}
+
+// taxFunctions 常见的税率查询函数，返回值不超过100时视为百分比，不超过10000时视为基点
+var taxFunctions = []string{
+	"buyTax()", "sellTax()", "_buyTax()", "_sellTax()",
+	"buyTotalFees()", "sellTotalFees()", "totalFees()", "_taxFee()",
+}
+
+// blacklistFunctions 常见的黑名单函数，可用于禁止指定地址卖出
+var blacklistFunctions = []string{
+	"blacklist(address)", "addToBlacklist(address)", "setBlacklist(address,bool)", "blacklistAddress(address,bool)",
+	"isBlacklisted(address)", "addBots(address[])", "setBots(address[],bool)", "blockBots(address[])",
+}
+
+// SafetyCheck 单项代币安全检查的结果
+type SafetyCheck struct {
+	Name    string
+	Passed  bool
+	Skipped bool   // 缺少数据或未配置，无法检查，不影响结果
+	Detail  string // 检查依据或失败原因
+}
+
+// TokenSafetyReport 代币合约的安全检查结果
+type TokenSafetyReport struct {
+	Network     string
+	Address     string
+	Safe        bool // 所有未跳过的检查项均通过
+	Allowlisted bool // 在 allowlist 中，未检查
+	Checks      []SafetyCheck
+	CheckedAt   time.Time
+}
+
+// failures 返回未通过的检查项说明
+func (r TokenSafetyReport) failures() []string {
+	failures := make([]string, 0)
+	for _, check := range r.Checks {
+		if !check.Passed && !check.Skipped {
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name, check.Detail))
+		}
+	}
+	return failures
+}
+
+// tokenSafetyChecker 代币安全检查，结果按网络和代币地址缓存
+type tokenSafetyChecker struct {
+	cfg        *config.Config
+	httpClient *http.Client
+	cache      map[string]TokenSafetyReport // 键为 网络-小写代币地址
+	mutex      sync.Mutex
+}
+
+// newTokenSafetyChecker 创建代币安全检查
+func newTokenSafetyChecker(cfg *config.Config) *tokenSafetyChecker {
+	return &tokenSafetyChecker{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		cache:      make(map[string]TokenSafetyReport),
+	}
+}
+
+// TokenSafety 获取代币合约的安全检查结果，缓存未过期且不要求刷新时直接返回缓存
+func (b *BlockchainExecutor) TokenSafety(network, address string, refresh bool) (TokenSafetyReport, error) {
+	client, ok := b.clients[network]
+	if !ok {
+		return TokenSafetyReport{}, fmt.Errorf("未找到网络 %s 的客户端", network)
+	}
+	if !common.IsHexAddress(address) {
+		return TokenSafetyReport{}, fmt.Errorf("不是有效的代币地址: %s", address)
+	}
+	networkCfg, _ := b.networkConfig(network)
+	return b.safety.report(b.ctx, client, networkCfg, common.HexToAddress(address), b.quoteTokenFor(network, address), refresh)
+}
+
+// checkTokenSafety 买入前检查标的代币合约，未启用代币安全检查时不检查
+func (b *BlockchainExecutor) checkTokenSafety(order BlockchainOrder) error {
+	if !b.cfg.Blockchain.TokenSafety.Enabled || order.Direction != "buy" {
+		return nil
+	}
+	pair, ok := findPair(b.cfg.Current().Trading.Pairs, order.Symbol)
+	if !ok || pair.TokenAddress == "" {
+		return nil
+	}
+
+	report, err := b.TokenSafety(order.Network, pair.TokenAddress, false)
+	if err != nil {
+		return fmt.Errorf("代币安全检查失败: %v", err)
+	}
+	if !report.Safe {
+		return fmt.Errorf("代币 %s 未通过安全检查: %s", pair.TokenAddress, strings.Join(report.failures(), "; "))
+	}
+	return nil
+}
+
+// quoteTokenFor 返回代币在网络上兑换使用的计价代币，交易对单独配置时优先
+func (b *BlockchainExecutor) quoteTokenFor(network, token string) common.Address {
+	for _, pair := range b.cfg.Current().Trading.Pairs {
+		if pair.Blockchain == network && strings.EqualFold(pair.TokenAddress, token) && pair.QuoteTokenAddress != "" {
+			return common.HexToAddress(pair.QuoteTokenAddress)
+		}
+	}
+	networkCfg, _ := b.networkConfig(network)
+	return common.HexToAddress(networkCfg.Router.QuoteTokenAddress)
+}
+
+// report 检查代币合约，检查过程中出现查询错误时不缓存结果，下次重新检查
+func (c *tokenSafetyChecker) report(ctx context.Context, client *ethclient.Client, networkCfg config.NetworkConfig, token, quote common.Address, refresh bool) (TokenSafetyReport, error) {
+	safetyCfg := c.cfg.Blockchain.TokenSafety
+	key := fmt.Sprintf("%s-%s", networkCfg.Name, strings.ToLower(token.Hex()))
+	ttl := time.Duration(safetyCfg.CacheMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultSafetyCacheMinutes * time.Minute
+	}
+
+	c.mutex.Lock()
+	cached, ok := c.cache[key]
+	c.mutex.Unlock()
+	if ok && !refresh && time.Since(cached.CheckedAt) < ttl {
+		return cached, nil
+	}
+
+	report := TokenSafetyReport{
+		Network:   networkCfg.Name,
+		Address:   token.Hex(),
+		Checks:    make([]SafetyCheck, 0),
+		CheckedAt: time.Now(),
+	}
+	for _, address := range safetyCfg.Allowlist {
+		if common.HexToAddress(address) == token {
+			report.Safe = true
+			report.Allowlisted = true
+			return report, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	code, err := client.CodeAt(ctx, token, nil)
+	if err != nil {
+		return TokenSafetyReport{}, fmt.Errorf("获取合约代码失败: %v", err)
+	}
+	if len(code) == 0 {
+		return TokenSafetyReport{}, fmt.Errorf("地址 %s 上没有合约代码", token.Hex())
+	}
+	totalSupply, err := callUint(ctx, client, token, "totalSupply()")
+	if err != nil {
+		return TokenSafetyReport{}, err
+	}
+
+	transient := false
+	add := func(check SafetyCheck, err error) {
+		if err != nil {
+			transient = true
+			check.Passed = false
+			check.Detail = fmt.Sprintf("检查失败: %v", err)
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	add(c.checkVerified(ctx, networkCfg, token))
+	add(checkTransferTax(ctx, client, token, safetyCfg.MaxTransferTax))
+	add(checkBlacklist(code, safetyCfg.BlockBlacklist), nil)
+	add(c.checkLiquidityLock(ctx, client, networkCfg, token, quote))
+	add(c.checkHolders(ctx, client, networkCfg, token, totalSupply))
+
+	report.Safe = len(report.failures()) == 0
+	if !report.Safe {
+		logrus.Warnf("代币 %s (%s) 未通过安全检查: %s", token.Hex(), networkCfg.Name, strings.Join(report.failures(), "; "))
+	}
+	if !transient {
+		c.mutex.Lock()
+		c.cache[key] = report
+		c.mutex.Unlock()
+	}
+	return report, nil
+}
+
+// checkVerified 通过区块浏览器检查合约源码是否已验证，未配置浏览器时跳过
+func (c *tokenSafetyChecker) checkVerified(ctx context.Context, networkCfg config.NetworkConfig, token common.Address) (SafetyCheck, error) {
+	check := SafetyCheck{Name: safetyCheckVerified}
+	if networkCfg.Explorer.APIURL == "" {
+		check.Skipped = true
+		check.Detail = "未配置区块浏览器"
+		return check, nil
+	}
+
+	var result []struct {
+		SourceCode   string `json:"SourceCode"`
+		ContractName string `json:"ContractName"`
+		Proxy        string `json:"Proxy"`
+	}
+	params := url.Values{"module": {"contract"}, "action": {"getsourcecode"}, "address": {token.Hex()}}
+	if err := c.explorerQuery(ctx, networkCfg.Explorer, params, &result); err != nil {
+		return check, err
+	}
+	verified := len(result) > 0 && result[0].SourceCode != ""
+	switch {
+	case verified && result[0].Proxy == "1":
+		check.Detail = fmt.Sprintf("源码已验证（%s，代理合约，实现合约可被升级）", result[0].ContractName)
+	case verified:
+		check.Detail = fmt.Sprintf("源码已验证（%s）", result[0].ContractName)
+	default:
+		check.Detail = "源码未验证"
+	}
+	check.Passed = verified || !c.cfg.Blockchain.TokenSafety.RequireVerified
+	return check, nil
+}
+
+// checkTransferTax 调用常见的税率查询函数，取最高税率；合约未实现这些函数时视为无税率。
+// 只能发现通过公开函数暴露的税率，无法发现隐藏在转账逻辑中的税率
+func checkTransferTax(ctx context.Context, client *ethclient.Client, token common.Address, maxTax float64) (SafetyCheck, error) {
+	check := SafetyCheck{Name: safetyCheckTax}
+	highest := decimal.Zero
+	found := make([]string, 0)
+	for _, signature := range taxFunctions {
+		value, err := callUint(ctx, client, token, signature)
+		if err != nil {
+			continue
+		}
+		percent := decimal.NewFromBigInt(value, 0)
+		switch {
+		case value.Cmp(big.NewInt(100)) <= 0:
+		case value.Cmp(big.NewInt(10000)) <= 0:
+			percent = percent.Div(decimal.NewFromInt(100))
+		default:
+			continue
+		}
+		found = append(found, fmt.Sprintf("%s=%s%%", strings.TrimSuffix(signature, "()"), percent.String()))
+		if percent.GreaterThan(highest) {
+			highest = percent
+		}
+	}
+
+	if len(found) == 0 {
+		check.Passed = true
+		check.Detail = "未发现税率函数"
+		return check, nil
+	}
+	check.Detail = strings.Join(found, ", ")
+	check.Passed = maxTax <= 0 || highest.LessThanOrEqual(decimal.NewFromFloat(maxTax))
+	return check, nil
+}
+
+// checkBlacklist 在合约字节码中查找黑名单函数的选择器，代理合约只能检查代理本身
+func checkBlacklist(code []byte, block bool) SafetyCheck {
+	check := SafetyCheck{Name: safetyCheckBlacklist, Passed: true}
+	found := make([]string, 0)
+	for _, signature := range blacklistFunctions {
+		// 函数分发表中以 PUSH4 指令压入选择器
+		if bytes.Contains(code, append([]byte{0x63}, selector(signature)...)) {
+			found = append(found, signature)
+		}
+	}
+	if len(found) == 0 {
+		check.Detail = "未发现黑名单函数"
+		return check
+	}
+	check.Detail = "包含 " + strings.Join(found, ", ")
+	check.Passed = !block
+	return check
+}
+
+// checkLiquidityLock 检查代币与计价代币的 v2 流动性池中已销毁或由锁仓地址持有的LP代币比例
+func (c *tokenSafetyChecker) checkLiquidityLock(ctx context.Context, client *ethclient.Client, networkCfg config.NetworkConfig, token, quote common.Address) (SafetyCheck, error) {
+	check := SafetyCheck{Name: safetyCheckLiquidity}
+	if networkCfg.Router.Version == "v3" || networkCfg.Router.Address == "" || quote == (common.Address{}) {
+		check.Skipped = true
+		check.Detail = "只支持 v2 路由的流动性池"
+		return check, nil
+	}
+
+	factory, err := callAddress(ctx, client, common.HexToAddress(networkCfg.Router.Address), "factory()")
+	if err != nil {
+		return check, err
+	}
+	pool, err := callAddress(ctx, client, factory, "getPair(address,address)", token, quote)
+	if err != nil {
+		return check, err
+	}
+	if pool == (common.Address{}) {
+		check.Detail = "未找到与计价代币的流动性池"
+		return check, nil
+	}
+	supply, err := callUint(ctx, client, pool, "totalSupply()")
+	if err != nil {
+		return check, err
+	}
+	if supply.Sign() == 0 {
+		check.Detail = "流动性池为空"
+		return check, nil
+	}
+
+	holders := append([]common.Address{}, burnAddresses...)
+	for _, locker := range c.cfg.Blockchain.TokenSafety.LockerAddresses {
+		holders = append(holders, common.HexToAddress(locker))
+	}
+	locked := new(big.Int)
+	for _, holder := range holders {
+		balance, err := queryRawTokenBalance(ctx, client, pool, holder)
+		if err != nil {
+			return check, err
+		}
+		locked.Add(locked, balance)
+	}
+
+	percent := sharePercent(locked, supply)
+	minLocked := c.cfg.Blockchain.TokenSafety.MinLiquidityLocked
+	check.Detail = fmt.Sprintf("流动性池 %s 已销毁或锁仓 %s%%", pool.Hex(), percent.StringFixed(2))
+	check.Passed = minLocked <= 0 || percent.GreaterThanOrEqual(decimal.NewFromFloat(minLocked))
+	return check, nil
+}
+
+// checkHolders 检查合约所有者（owner()）和部署者的持有比例，无法获取这两个地址时跳过
+func (c *tokenSafetyChecker) checkHolders(ctx context.Context, client *ethclient.Client, networkCfg config.NetworkConfig, token common.Address, totalSupply *big.Int) (SafetyCheck, error) {
+	check := SafetyCheck{Name: safetyCheckHolders}
+	holders := make([]common.Address, 0, 2)
+	if owner, err := callAddress(ctx, client, token, "owner()"); err == nil && owner != (common.Address{}) {
+		holders = append(holders, owner)
+	}
+	if networkCfg.Explorer.APIURL != "" {
+		var result []struct {
+			ContractCreator string `json:"contractCreator"`
+		}
+		params := url.Values{"module": {"contract"}, "action": {"getcontractcreation"}, "contractaddresses": {token.Hex()}}
+		if err := c.explorerQuery(ctx, networkCfg.Explorer, params, &result); err != nil {
+			return check, err
+		}
+		if len(result) > 0 && common.IsHexAddress(result[0].ContractCreator) {
+			if creator := common.HexToAddress(result[0].ContractCreator); len(holders) == 0 || holders[0] != creator {
+				holders = append(holders, creator)
+			}
+		}
+	}
+	if len(holders) == 0 || totalSupply.Sign() == 0 {
+		check.Skipped = true
+		check.Detail = "所有者已放弃且无法获取部署者"
+		return check, nil
+	}
+
+	highest := decimal.Zero
+	details := make([]string, 0, len(holders))
+	for _, holder := range holders {
+		balance, err := queryRawTokenBalance(ctx, client, token, holder)
+		if err != nil {
+			return check, err
+		}
+		percent := sharePercent(balance, totalSupply)
+		details = append(details, fmt.Sprintf("%s 持有 %s%%", holder.Hex(), percent.StringFixed(2)))
+		if percent.GreaterThan(highest) {
+			highest = percent
+		}
+	}
+
+	maxPercent := c.cfg.Blockchain.TokenSafety.MaxHolderPercent
+	check.Detail = strings.Join(details, ", ")
+	check.Passed = maxPercent <= 0 || highest.LessThanOrEqual(decimal.NewFromFloat(maxPercent))
+	return check, nil
+}
+
+// explorerQuery 调用 Etherscan 兼容的区块浏览器接口，解析响应中的 result
+func (c *tokenSafetyChecker) explorerQuery(ctx context.Context, explorer config.ExplorerConfig, params url.Values, out interface{}) error {
+	if explorer.APIKeyEnv != "" {
+		params.Set("apikey", os.Getenv(explorer.APIKeyEnv))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, explorer.APIURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求区块浏览器失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("区块浏览器返回错误 %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	if envelope.Status != "1" {
+		return fmt.Errorf("区块浏览器返回错误: %s %s", envelope.Message, string(envelope.Result))
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// selector 返回函数签名的4字节选择器
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// callSelector 调用参数均为地址的合约函数，返回32字节的返回值
+func callSelector(ctx context.Context, client *ethclient.Client, contract common.Address, signature string, args ...common.Address) ([]byte, error) {
+	data := selector(signature)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用 %s 失败: %v", signature, err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("%s 的返回值不是32字节", signature)
+	}
+	return result, nil
+}
+
+// callUint 调用返回 uint256 的合约函数
+func callUint(ctx context.Context, client *ethclient.Client, contract common.Address, signature string, args ...common.Address) (*big.Int, error) {
+	result, err := callSelector(ctx, client, contract, signature, args...)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// callAddress 调用返回地址的合约函数
+func callAddress(ctx context.Context, client *ethclient.Client, contract common.Address, signature string, args ...common.Address) (common.Address, error) {
+	result, err := callSelector(ctx, client, contract, signature, args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(result), nil
+}
+
+// sharePercent 返回 part 占 total 的百分比
+func sharePercent(part, total *big.Int) decimal.Decimal {
+	return decimal.NewFromBigInt(part, 0).Mul(decimal.NewFromInt(100)).Div(decimal.NewFromBigInt(total, 0))
+}
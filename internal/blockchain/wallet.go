@@ -0,0 +1,194 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"autotransaction/config"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// defaultWalletName 由 wallet_private_key 配置的钱包名称
+const defaultWalletName = "default"
+
+// wallet 用于签名交易的钱包，每个可用网络一个nonce管理器
+type wallet struct {
+	name     string
+	signer   Signer
+	address  common.Address
+	networks []string                 // 可使用的网络，为空时不限
+	nonces   map[string]*nonceManager // 键为网络名称
+}
+
+// usableOn 判断钱包是否可在指定网络上使用
+func (w *wallet) usableOn(network string) bool {
+	if len(w.networks) == 0 {
+		return true
+	}
+	for _, name := range w.networks {
+		if name == network {
+			return true
+		}
+	}
+	return false
+}
+
+// sign 使用钱包的签名器签名交易
+func (w *wallet) sign(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signer.SignTx(ctx, tx, chainID)
+}
+
+// loadWallets 按配置加载所有钱包
+func loadWallets(contracts config.ContractsConfig) (map[string]*wallet, error) {
+	configs := contracts.Wallets
+	if contracts.WalletPrivateKey != "" {
+		configs = append([]config.WalletConfig{{
+			Name:       defaultWalletName,
+			PrivateKey: contracts.WalletPrivateKey,
+		}}, configs...)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("未配置钱包")
+	}
+
+	wallets := make(map[string]*wallet)
+	for _, walletCfg := range configs {
+		if walletCfg.Name == "" {
+			return nil, fmt.Errorf("钱包缺少名称")
+		}
+		if _, exists := wallets[walletCfg.Name]; exists {
+			return nil, fmt.Errorf("钱包 %s 重复配置", walletCfg.Name)
+		}
+
+		signer, err := newSigner(walletCfg)
+		if err != nil {
+			return nil, fmt.Errorf("加载钱包 %s 的签名器失败: %v", walletCfg.Name, err)
+		}
+		wallets[walletCfg.Name] = &wallet{
+			name:     walletCfg.Name,
+			signer:   signer,
+			address:  signer.Address(),
+			networks: walletCfg.Networks,
+			nonces:   make(map[string]*nonceManager),
+		}
+	}
+
+	if contracts.DefaultWallet != "" {
+		if _, ok := wallets[contracts.DefaultWallet]; !ok {
+			return nil, fmt.Errorf("默认钱包 %s 不存在", contracts.DefaultWallet)
+		}
+	}
+	return wallets, nil
+}
+
+// loadPrivateKey 从配置的来源读取钱包私钥
+func loadPrivateKey(walletCfg config.WalletConfig) (*ecdsa.PrivateKey, error) {
+	switch {
+	case walletCfg.PrivateKey != "":
+		return parseHexKey(walletCfg.PrivateKey)
+	case walletCfg.PrivateKeyEnv != "":
+		value := os.Getenv(walletCfg.PrivateKeyEnv)
+		if value == "" {
+			return nil, fmt.Errorf("环境变量 %s 为空", walletCfg.PrivateKeyEnv)
+		}
+		return parseHexKey(value)
+	case walletCfg.PrivateKeyFile != "":
+		content, err := os.ReadFile(walletCfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取私钥文件失败: %v", err)
+		}
+		return parseHexKey(string(content))
+	case walletCfg.KeystoreFile != "":
+		return loadKeystore(walletCfg)
+	default:
+		return nil, fmt.Errorf("未配置私钥来源")
+	}
+}
+
+// loadKeystore 解密 geth keystore 文件
+func loadKeystore(walletCfg config.WalletConfig) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := os.ReadFile(walletCfg.KeystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取keystore文件失败: %v", err)
+	}
+
+	var password string
+	switch {
+	case walletCfg.PasswordEnv != "":
+		password = os.Getenv(walletCfg.PasswordEnv)
+	case walletCfg.PasswordFile != "":
+		content, err := os.ReadFile(walletCfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取keystore密码文件失败: %v", err)
+		}
+		password = strings.TrimRight(string(content), "\r\n")
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("解密keystore失败: %v", err)
+	}
+	return key.PrivateKey, nil
+}
+
+// parseHexKey 解析十六进制私钥，允许带 0x 前缀和首尾空白
+func parseHexKey(value string) (*ecdsa.PrivateKey, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "0x")
+	key, err := crypto.HexToECDSA(value)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// pairWallet 返回交易对在指定网络上使用的钱包
+// 依次使用交易对配置的钱包、默认钱包，都未配置时使用名为 default 的钱包
+func (b *BlockchainExecutor) pairWallet(symbol, network string) (*wallet, error) {
+	name := b.cfg.Blockchain.Contracts.DefaultWallet
+	if pair, ok := findPair(b.cfg.Current().Trading.Pairs, symbol); ok && pair.Wallet != "" {
+		name = pair.Wallet
+	}
+	if name == "" {
+		name = defaultWalletName
+	}
+
+	w, ok := b.wallets[name]
+	if !ok {
+		return nil, fmt.Errorf("钱包 %s 不存在", name)
+	}
+	if !w.usableOn(network) {
+		return nil, fmt.Errorf("钱包 %s 不可用于网络 %s", name, network)
+	}
+	return w, nil
+}
+
+// orderWallet 返回订单使用的钱包，未记录钱包的旧订单按交易对配置选择
+func (b *BlockchainExecutor) orderWallet(order BlockchainOrder) (*wallet, error) {
+	if order.Wallet == "" {
+		return b.pairWallet(order.Symbol, order.Network)
+	}
+	w, ok := b.wallets[order.Wallet]
+	if !ok {
+		return nil, fmt.Errorf("钱包 %s 不存在", order.Wallet)
+	}
+	return w, nil
+}
+
+// walletAddresses 返回可在指定网络上使用的所有钱包地址
+func (b *BlockchainExecutor) walletAddresses(network string) []common.Address {
+	addresses := make([]common.Address, 0, len(b.wallets))
+	for _, w := range b.wallets {
+		if w.usableOn(network) {
+			addresses = append(addresses, w.address)
+		}
+	}
+	return addresses
+}
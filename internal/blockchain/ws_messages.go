@@ -0,0 +1,395 @@
+package blockchain
+
+import (
+	"time"
+
+	"autotransaction/internal/approval"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+	"autotransaction/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// WebSocket 订阅协议
+//
+// 连接建立后不推送任何消息，客户端按需订阅频道，可选地只订阅部分交易对（对所有频道生效）：
+//   {"action":"subscribe","channels":["market","trades"],"symbols":["BTC/USDT"]}
+//   {"action":"unsubscribe","channels":["market"]}
+//   {"action":"unsubscribe"}  取消全部订阅
+// 频道: market (行情), trades (订单和成交), positions (持仓), signals (策略信号), risk (风险拒绝),
+// approvals (审批请求的创建、批准、拒绝和过期)。
+// 订单、持仓、信号和审批请求只推送连接所属账户的数据，账户通过 X-Account-ID 请求头或 account 查询参数指定。
+// 每次请求后服务端返回当前的订阅状态，请求无效时返回错误：
+//   {"type":"subscriptions","timestamp":1700000000,"channels":["market","trades"],"symbols":["BTC/USDT"]}
+//   {"type":"error","timestamp":1700000000,"error":"未知的频道: foo"}
+//
+// 具有 trader 角色的连接可以批准或拒绝连接所属账户的审批请求，成功时返回审批后的 approval 消息：
+//   {"action":"approve","id":"APPROVAL-..."}
+//   {"action":"reject","id":"APPROVAL-...","reason":"仓位过大"}
+//
+// WebSocket 消息格式
+//
+// 所有消息均为JSON对象，包含 type 和 timestamp (Unix秒) 字段。
+// 为避免前端针对不同消息类型分别处理浮点数与高精度数值，
+// 所有价格、数量、金额类字段统一为十进制字符串：
+//   - 价格、金额: utils.FormatPrice，保留2位小数，如 "68432.21"
+//   - 数量:       utils.FormatQuantity，保留6位小数，如 "0.150000"
+//   - 百分比:     utils.FormatDecimal(v, 2)，如 "-1.12"
+//
+// marketUpdate:
+//   {"type":"marketUpdate","timestamp":1700000000,
+//    "marketData":[{"pair":"BTC/USDT","price":"68432.21","change24h":"2.34"}]}
+//
+// orderUpdate:
+//   {"type":"orderUpdate","timestamp":1700000000,
+//    "order":{"id":"...","pair":"ETH/BNB","side":"buy","price":"4532.67","amount":"0.100000",
+//             "status":"confirmed","network":"ethereum","txHash":"0x..."}}
+//   交易所订单没有 network 和 txHash，另有 filledAmount 和 strategy
+//
+// positionUpdate:
+//   {"type":"positionUpdate","timestamp":1700000000,
+//    "position":{"pair":"ETH/BNB","network":"ethereum","amount":"0.100000","entryPrice":"4500.00",
+//                "currentPrice":"4532.67","value":"453.27","profitLoss":"3.27"}}
+//
+// fill: 交易所订单的一笔成交，price 和 amount 为本次成交，filledAmount 为累计成交
+//   {"type":"fill","timestamp":1700000000,
+//    "fill":{"orderId":"...","pair":"BTC/USDT","side":"buy","price":"68432.21","amount":"0.050000",
+//            "fee":"3.42","status":"partially_filled","filledAmount":"0.050000","strategy":"ma_cross"}}
+//
+// riskRejection:
+//   {"type":"riskRejection","timestamp":1700000000,
+//    "rejection":{"pair":"BTC/USDT","side":"buy","strategy":"ma_cross","reason":"..."}}
+//
+// signal:
+//   {"type":"signal","timestamp":1700000000,
+//    "signal":{"pair":"BTC/USDT","side":"buy","price":"68432.21","amount":"0.150000",
+//              "confidence":"0.80","strategy":"ma_cross","regime":"trending"}}
+//
+// approval: status 为 pending、approved、rejected 或 expired
+//   {"type":"approval","timestamp":1700000000,
+//    "approval":{"id":"APPROVAL-...","pair":"BTC/USDT","side":"buy","price":"68432.21","amount":"0.150000",
+//                "notional":"10264.83","strategy":"ma_cross","reason":"...","status":"pending","expiresAt":1700001800}}
+
+const (
+	wsTypeMarketUpdate   = "marketUpdate"
+	wsTypeOrderUpdate    = "orderUpdate"
+	wsTypePositionUpdate = "positionUpdate"
+	wsTypeSignal         = "signal"
+	wsTypeFill           = "fill"
+	wsTypeRiskRejection  = "riskRejection"
+	wsTypeApproval       = "approval"
+	wsTypeSubscriptions  = "subscriptions"
+	wsTypeError          = "error"
+)
+
+// 可订阅的频道
+const (
+	wsChannelMarket    = "market"
+	wsChannelTrades    = "trades"
+	wsChannelPositions = "positions"
+	wsChannelSignals   = "signals"
+	wsChannelRisk      = "risk"
+	wsChannelApprovals = "approvals"
+)
+
+// isWSChannel 判断是否为可订阅的频道
+func isWSChannel(channel string) bool {
+	switch channel {
+	case wsChannelMarket, wsChannelTrades, wsChannelPositions, wsChannelSignals, wsChannelRisk, wsChannelApprovals:
+		return true
+	}
+	return false
+}
+
+// wsMessage WebSocket推送消息
+type wsMessage struct {
+	Type       string           `json:"type"`
+	Timestamp  int64            `json:"timestamp"`
+	MarketData []wsMarketTicker `json:"marketData,omitempty"`
+	Order      *wsOrder         `json:"order,omitempty"`
+	Position   *wsPosition      `json:"position,omitempty"`
+	Signal     *wsSignal        `json:"signal,omitempty"`
+	Fill       *wsFill          `json:"fill,omitempty"`
+	Rejection  *wsRejection     `json:"rejection,omitempty"`
+	Approval   *wsApproval      `json:"approval,omitempty"`
+	Channels   []string         `json:"channels,omitempty"`
+	Symbols    []string         `json:"symbols,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// wsMarketTicker 行情数据
+type wsMarketTicker struct {
+	Pair      string `json:"pair"`
+	Price     string `json:"price"`
+	Change24h string `json:"change24h"`
+}
+
+// wsOrder 订单数据
+type wsOrder struct {
+	ID      string `json:"id"`
+	Pair    string `json:"pair"`
+	Side    string `json:"side"`
+	Price   string `json:"price"`
+	Amount  string `json:"amount"`
+	Status  string `json:"status"`
+	Network string `json:"network,omitempty"`
+	TxHash  string `json:"txHash,omitempty"`
+
+	FilledAmount string `json:"filledAmount,omitempty"`
+	Strategy     string `json:"strategy,omitempty"`
+}
+
+// wsPosition 持仓数据
+type wsPosition struct {
+	Pair         string `json:"pair"`
+	Network      string `json:"network,omitempty"`
+	Amount       string `json:"amount"`
+	EntryPrice   string `json:"entryPrice"`
+	CurrentPrice string `json:"currentPrice"`
+	Value        string `json:"value"`
+	ProfitLoss   string `json:"profitLoss"`
+}
+
+// wsSignal 策略信号
+type wsSignal struct {
+	Pair       string `json:"pair"`
+	Side       string `json:"side"`
+	Price      string `json:"price"`
+	Amount     string `json:"amount"`
+	Confidence string `json:"confidence"`
+	Strategy   string `json:"strategy,omitempty"`
+	Regime     string `json:"regime,omitempty"`
+}
+
+// wsFill 成交数据
+type wsFill struct {
+	OrderID      string `json:"orderId"`
+	Pair         string `json:"pair"`
+	Side         string `json:"side"`
+	Price        string `json:"price"`
+	Amount       string `json:"amount"`
+	Fee          string `json:"fee"`
+	Status       string `json:"status"`
+	FilledAmount string `json:"filledAmount"`
+	Strategy     string `json:"strategy,omitempty"`
+}
+
+// wsRejection 风险拒绝数据
+type wsRejection struct {
+	Pair     string `json:"pair"`
+	Side     string `json:"side"`
+	Strategy string `json:"strategy,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// wsApproval 审批请求
+type wsApproval struct {
+	ID        string `json:"id"`
+	Pair      string `json:"pair"`
+	Side      string `json:"side"`
+	Price     string `json:"price"`
+	Amount    string `json:"amount"`
+	Notional  string `json:"notional"`
+	Strategy  string `json:"strategy,omitempty"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	ExpiresAt int64  `json:"expiresAt"`
+	DecidedBy string `json:"decidedBy,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// marketTicker 服务端内部使用的行情数据
+type marketTicker struct {
+	Pair      string
+	Price     decimal.Decimal
+	Change24h decimal.Decimal
+}
+
+// newMarketUpdateMessage 创建行情更新消息
+func newMarketUpdateMessage(tickers []marketTicker) wsMessage {
+	data := make([]wsMarketTicker, 0, len(tickers))
+	for _, ticker := range tickers {
+		data = append(data, wsMarketTicker{
+			Pair:      ticker.Pair,
+			Price:     utils.FormatPrice(ticker.Price),
+			Change24h: utils.FormatDecimal(ticker.Change24h, 2),
+		})
+	}
+
+	return wsMessage{
+		Type:       wsTypeMarketUpdate,
+		Timestamp:  time.Now().Unix(),
+		MarketData: data,
+	}
+}
+
+// newOrderUpdateMessage 创建订单更新消息
+func newOrderUpdateMessage(order BlockchainOrder) wsMessage {
+	return wsMessage{
+		Type:      wsTypeOrderUpdate,
+		Timestamp: time.Now().Unix(),
+		Order: &wsOrder{
+			ID:      order.ID,
+			Pair:    order.Symbol,
+			Side:    order.Direction,
+			Price:   utils.FormatPrice(order.Price),
+			Amount:  utils.FormatQuantity(order.Quantity),
+			Status:  order.Status,
+			Network: order.Network,
+			TxHash:  order.TxHash,
+		},
+	}
+}
+
+// newPositionUpdateMessage 创建持仓更新消息，浮动盈亏扣除开仓手续费和gas费用
+func newPositionUpdateMessage(position BlockchainPosition) wsMessage {
+	value := position.CurrentPrice.Mul(position.Quantity)
+	profitLoss := decimal.Zero
+	if !position.EntryPrice.IsZero() {
+		profitLoss = position.CurrentPrice.Sub(position.EntryPrice).Mul(position.Quantity).Sub(position.Fees)
+	}
+
+	return wsMessage{
+		Type:      wsTypePositionUpdate,
+		Timestamp: time.Now().Unix(),
+		Position: &wsPosition{
+			Pair:         position.Symbol,
+			Network:      position.Network,
+			Amount:       utils.FormatQuantity(position.Quantity),
+			EntryPrice:   utils.FormatPrice(position.EntryPrice),
+			CurrentPrice: utils.FormatPrice(position.CurrentPrice),
+			Value:        utils.FormatPrice(value),
+			ProfitLoss:   utils.FormatPrice(profitLoss),
+		},
+	}
+}
+
+// newExchangeOrderUpdateMessage 创建交易所订单更新消息
+func newExchangeOrderUpdateMessage(order execution.Order) wsMessage {
+	return wsMessage{
+		Type:      wsTypeOrderUpdate,
+		Timestamp: time.Now().Unix(),
+		Order: &wsOrder{
+			ID:           order.ID,
+			Pair:         order.Symbol,
+			Side:         order.Direction,
+			Price:        utils.FormatPrice(order.Price),
+			Amount:       utils.FormatQuantity(order.Quantity),
+			Status:       order.Status,
+			FilledAmount: utils.FormatQuantity(order.FilledQuantity),
+			Strategy:     order.StrategyName,
+		},
+	}
+}
+
+// newExchangePositionUpdateMessage 创建交易所持仓更新消息
+func newExchangePositionUpdateMessage(position execution.Position) wsMessage {
+	value := position.CurrentPrice.Mul(position.Quantity)
+	profitLoss := position.CurrentPrice.Sub(position.EntryPrice).Mul(position.Quantity)
+
+	return wsMessage{
+		Type:      wsTypePositionUpdate,
+		Timestamp: time.Now().Unix(),
+		Position: &wsPosition{
+			Pair:         position.Symbol,
+			Amount:       utils.FormatQuantity(position.Quantity),
+			EntryPrice:   utils.FormatPrice(position.EntryPrice),
+			CurrentPrice: utils.FormatPrice(position.CurrentPrice),
+			Value:        utils.FormatPrice(value),
+			ProfitLoss:   utils.FormatPrice(profitLoss),
+		},
+	}
+}
+
+// newSignalMessage 创建策略信号消息
+func newSignalMessage(signal strategy.Signal) wsMessage {
+	return wsMessage{
+		Type:      wsTypeSignal,
+		Timestamp: time.Now().Unix(),
+		Signal: &wsSignal{
+			Pair:       signal.Symbol,
+			Side:       signal.Direction,
+			Price:      utils.FormatPrice(signal.Price),
+			Amount:     utils.FormatQuantity(signal.Quantity),
+			Confidence: utils.FormatDecimal(decimal.NewFromFloat(signal.Confidence), 2),
+			Strategy:   signal.StrategyName,
+			Regime:     signal.Regime,
+		},
+	}
+}
+
+// newFillMessage 创建成交消息，fill 的数量、价格和手续费为本次成交
+func newFillMessage(fill execution.Order) wsMessage {
+	return wsMessage{
+		Type:      wsTypeFill,
+		Timestamp: time.Now().Unix(),
+		Fill: &wsFill{
+			OrderID:      fill.ID,
+			Pair:         fill.Symbol,
+			Side:         fill.Direction,
+			Price:        utils.FormatPrice(fill.Price),
+			Amount:       utils.FormatQuantity(fill.Quantity),
+			Fee:          utils.FormatPrice(fill.Fee),
+			Status:       fill.Status,
+			FilledAmount: utils.FormatQuantity(fill.FilledQuantity),
+			Strategy:     fill.StrategyName,
+		},
+	}
+}
+
+// newRiskRejectionMessage 创建风险拒绝消息
+func newRiskRejectionMessage(rejection risk.Rejection) wsMessage {
+	return wsMessage{
+		Type:      wsTypeRiskRejection,
+		Timestamp: rejection.Timestamp.Unix(),
+		Rejection: &wsRejection{
+			Pair:     rejection.Symbol,
+			Side:     rejection.Direction,
+			Strategy: rejection.Strategy,
+			Reason:   rejection.Reason,
+		},
+	}
+}
+
+// newApprovalMessage 创建审批请求消息
+func newApprovalMessage(request approval.Request) wsMessage {
+	return wsMessage{
+		Type:      wsTypeApproval,
+		Timestamp: time.Now().Unix(),
+		Approval: &wsApproval{
+			ID:        request.ID,
+			Pair:      request.Signal.Symbol,
+			Side:      request.Signal.Direction,
+			Price:     utils.FormatPrice(request.Signal.Price),
+			Amount:    utils.FormatQuantity(request.Signal.Quantity),
+			Notional:  utils.FormatPrice(request.Notional),
+			Strategy:  request.Signal.StrategyName,
+			Reason:    request.Reason,
+			Status:    request.Status,
+			ExpiresAt: request.ExpiresAt.Unix(),
+			DecidedBy: request.DecidedBy,
+			Note:      request.Note,
+		},
+	}
+}
+
+// newSubscriptionsMessage 创建订阅状态消息
+func newSubscriptionsMessage(channels, symbols []string) wsMessage {
+	return wsMessage{
+		Type:      wsTypeSubscriptions,
+		Timestamp: time.Now().Unix(),
+		Channels:  channels,
+		Symbols:   symbols,
+	}
+}
+
+// newErrorMessage 创建错误消息
+func newErrorMessage(message string) wsMessage {
+	return wsMessage{
+		Type:      wsTypeError,
+		Timestamp: time.Now().Unix(),
+		Error:     message,
+	}
+}
@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"autotransaction/internal/execution"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	priceFormat    = regexp.MustCompile(`^-?\d+\.\d{2}$`)
+	quantityFormat = regexp.MustCompile(`^-?\d+\.\d{6}$`)
+)
+
+// decodeWSPayload 将消息序列化为JSON后取出 field 对应的对象
+func decodeWSPayload(t *testing.T, message wsMessage, field string) map[string]interface{} {
+	t.Helper()
+	content, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	switch payload := decoded[field].(type) {
+	case map[string]interface{}:
+		return payload
+	case []interface{}:
+		return payload[0].(map[string]interface{})
+	}
+	t.Fatalf("消息缺少 %s 字段: %s", field, content)
+	return nil
+}
+
+// assertDecimalFields 检查字段为指定精度的十进制字符串
+func assertDecimalFields(t *testing.T, payload map[string]interface{}, format *regexp.Regexp, fields ...string) {
+	t.Helper()
+	for _, field := range fields {
+		value, ok := payload[field].(string)
+		if !ok {
+			t.Fatalf("%s 应为字符串，实际 %T", field, payload[field])
+		}
+		if !format.MatchString(value) {
+			t.Fatalf("%s 的格式不符合约定: %q", field, value)
+		}
+	}
+}
+
+func TestWSNumericFieldsAreDecimalStrings(t *testing.T) {
+	price := decimal.RequireFromString("68432.2149")
+	quantity := decimal.RequireFromString("0.15")
+
+	market := decodeWSPayload(t, newMarketUpdateMessage([]marketTicker{
+		{Pair: "BTC/USDT", Price: price, Change24h: decimal.RequireFromString("-1.1234")},
+	}), "marketData")
+	assertDecimalFields(t, market, priceFormat, "price", "change24h")
+	if market["price"] != "68432.21" {
+		t.Fatalf("价格应保留2位小数，实际 %v", market["price"])
+	}
+
+	order := decodeWSPayload(t, newExchangeOrderUpdateMessage(execution.Order{
+		Symbol: "BTC/USDT", Price: price, Quantity: quantity, FilledQuantity: decimal.Zero,
+	}), "order")
+	assertDecimalFields(t, order, priceFormat, "price")
+	assertDecimalFields(t, order, quantityFormat, "amount", "filledAmount")
+
+	chainOrder := decodeWSPayload(t, newOrderUpdateMessage(BlockchainOrder{Symbol: "ETH/USDT", Price: price, Quantity: quantity}), "order")
+	assertDecimalFields(t, chainOrder, priceFormat, "price")
+	assertDecimalFields(t, chainOrder, quantityFormat, "amount")
+
+	position := decodeWSPayload(t, newPositionUpdateMessage(BlockchainPosition{
+		Symbol: "ETH/USDT", Quantity: quantity, EntryPrice: decimal.NewFromInt(4500), CurrentPrice: price,
+	}), "position")
+	assertDecimalFields(t, position, priceFormat, "entryPrice", "currentPrice", "value", "profitLoss")
+	assertDecimalFields(t, position, quantityFormat, "amount")
+	if position["amount"] != "0.150000" {
+		t.Fatalf("数量应保留6位小数，实际 %v", position["amount"])
+	}
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventSignal        = "signal"         // 策略产生的交易信号，Payload 为 strategy.Signal
+	EventRiskRejection = "risk_rejection" // 被风险检查拒绝的信号，Payload 为 risk.Rejection
+	EventFill          = "fill"           // 交易所订单的一笔成交，Payload 为 execution.Order，数量和价格为本次成交
+	EventPosition      = "position"       // 交易所持仓变化，Payload 为 execution.Position，清仓时数量为0
+
+	// 告警事件，由通知模块按配置路由到通知渠道
+	EventCircuitBreaker = "circuit_breaker"   // 每日亏损熔断触发，Payload 为 risk.CircuitBreakerStatus
+	EventForcedExit     = "forced_exit"       // 止损、止盈、熔断或交易时段结束触发的强制平仓，Payload 为 risk.ForcedExit
+	EventOrderFailed    = "order_failed"      // 链上交易失败，Payload 为 blockchain.BlockchainOrder
+	EventDeadLetter     = "order_dead_letter" // 订单任务重试用尽或强制平仓失败，进入死信队列，Payload 为 execution.Job
+	EventApproval       = "approval"          // 信号进入人工审批队列、被批准、拒绝或过期，Payload 为 approval.Request
+	EventTradingHalt    = "trading_halt"      // 紧急停止或恢复交易，Payload 为 risk.HaltStatus
+)
+
+// Event 系统内部事件
+type Event struct {
+	Type      string
+	Account   string
+	Symbol    string
+	Timestamp time.Time
+	Payload   interface{}
+}
+
+// Handler 是处理事件的接口，在发布者的调用中同步执行，不能阻塞
+type Handler interface {
+	HandleEvent(event Event)
+}
+
+// Bus 将各模块产生的事件分发给订阅者
+type Bus struct {
+	handlers []Handler
+	mutex    sync.RWMutex
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{handlers: make([]Handler, 0)}
+}
+
+// Subscribe 订阅所有事件
+func (b *Bus) Subscribe(handler Handler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，总线为nil时忽略
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, handler := range b.handlers {
+		handler.HandleEvent(event)
+	}
+}
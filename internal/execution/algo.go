@@ -0,0 +1,284 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"autotransaction/internal/audit"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 执行算法
+const (
+	AlgoTWAP    = "twap"    // 在一段时间内等间隔拆分下单
+	AlgoIceberg = "iceberg" // 冰山单，每次只挂出一部分数量，成交后再挂出下一部分
+)
+
+// 未配置时的执行算法参数
+const (
+	defaultAlgoSlices   = 5
+	defaultTWAPDuration = 10 * time.Minute
+	defaultAlgoRefresh  = 5 * time.Second
+)
+
+// normalizeAlgo 补全执行算法的拆分笔数和时长并校验，普通订单不做处理
+func (e *Executor) normalizeAlgo(order *Order) error {
+	switch order.Algo {
+	case "":
+		return nil
+	case AlgoTWAP:
+	case AlgoIceberg:
+		if order.Type != OrderTypeLimit || order.TimeInForce != TimeInForceGTC {
+			return fmt.Errorf("冰山单需要使用 GTC 限价单")
+		}
+	default:
+		return fmt.Errorf("未知的执行算法: %s", order.Algo)
+	}
+
+	algoCfg := e.cfg.Execution.Algo
+	if order.AlgoSlices <= 0 {
+		order.AlgoSlices = algoCfg.Slices
+	}
+	if order.AlgoSlices <= 0 {
+		order.AlgoSlices = defaultAlgoSlices
+	}
+	if order.Algo == AlgoTWAP && order.AlgoDuration <= 0 {
+		order.AlgoDuration = time.Duration(algoCfg.TWAPDurationSeconds) * time.Second
+		if order.AlgoDuration <= 0 {
+			order.AlgoDuration = defaultTWAPDuration
+		}
+	}
+	// 父订单本身不挂单，有效期由子订单各自计算
+	order.ExpiresAt = time.Time{}
+	return nil
+}
+
+// algoRefresh 返回检查子订单是否成交的间隔
+func (e *Executor) algoRefresh() time.Duration {
+	if seconds := e.cfg.Execution.Algo.RefreshSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultAlgoRefresh
+}
+
+// startAlgo 保存执行算法的父订单并在后台按算法下子订单，返回父订单
+func (e *Executor) startAlgo(parent Order, signal strategy.Signal) Order {
+	e.mutex.Lock()
+	e.setOrderLocked(parent)
+	e.mutex.Unlock()
+	e.persistOpenOrders()
+
+	logrus.Infof("执行算法订单: %s %s %s 数量: %s，算法: %s，拆分为 %d 笔",
+		parent.ID, parent.Symbol, parent.Direction, parent.Quantity.String(), parent.Algo, parent.AlgoSlices)
+	e.recordOrderEvent(audit.EventOrderSubmitted, parent, fmt.Sprintf("执行算法 %s，拆分为 %d 笔", parent.Algo, parent.AlgoSlices))
+
+	switch parent.Algo {
+	case AlgoTWAP:
+		go e.runTWAP(parent, signal)
+	case AlgoIceberg:
+		go e.runIceberg(parent, signal)
+	}
+	return parent
+}
+
+// runTWAP 在 AlgoDuration 内等间隔下 AlgoSlices 笔子订单，最后一笔为剩余数量
+// 全部子订单结束后，仍未全部成交的父订单标记为过期，已成交部分保留
+func (e *Executor) runTWAP(parent Order, signal strategy.Signal) {
+	interval := parent.AlgoDuration / time.Duration(parent.AlgoSlices)
+	clip := parent.Quantity.Div(decimal.NewFromInt(int64(parent.AlgoSlices)))
+	submitted := decimal.Zero
+	for i := 1; i <= parent.AlgoSlices; i++ {
+		if i > 1 {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+
+		quantity := clip
+		if i == parent.AlgoSlices {
+			quantity = parent.Quantity.Sub(submitted)
+		}
+		child, ok := e.submitAlgoSlice(parent, i, quantity, signal)
+		if !ok {
+			return
+		}
+		submitted = submitted.Add(child.Quantity)
+	}
+
+	// 等待挂单中的限价子订单成交或过期
+	if !e.waitAlgo(func() bool { return !e.hasOpenChildren(parent.ID) }) {
+		return
+	}
+	e.finishAlgo(parent.ID, "expired", "TWAP 执行结束，剩余数量未成交")
+}
+
+// runIceberg 每次挂出 1/AlgoSlices 的数量，挂出部分全部成交后再挂出下一部分，直到父订单全部成交
+// 挂出部分过期或被撤销时停止，仍未全部成交的父订单标记为过期
+func (e *Executor) runIceberg(parent Order, signal strategy.Signal) {
+	clip := parent.Quantity.Div(decimal.NewFromInt(int64(parent.AlgoSlices)))
+	for i := 1; ; i++ {
+		current, ok := e.getOrder(parent.ID)
+		if !ok || !isOpenStatus(current.Status) {
+			return
+		}
+		remaining := current.Quantity.Sub(current.FilledQuantity)
+		if !remaining.IsPositive() {
+			return
+		}
+
+		child, ok := e.submitAlgoSlice(parent, i, decimal.Min(clip, remaining), signal)
+		if !ok {
+			return
+		}
+		if !e.waitAlgo(func() bool {
+			child, _ = e.getOrder(child.ID)
+			return !isOpenStatus(child.Status)
+		}) {
+			return
+		}
+		if child.Status != "filled" {
+			e.finishAlgo(parent.ID, "expired", fmt.Sprintf("冰山单挂出部分 %s 未全部成交: %s", child.ID, child.Status))
+			return
+		}
+	}
+}
+
+// submitAlgoSlice 创建并执行父订单的第 n 笔子订单，父订单已结束或子订单未通过下单检查时返回false
+// 市价子订单按最新行情定价，避免执行期间的正常价格变动被判为滑点
+func (e *Executor) submitAlgoSlice(parent Order, n int, quantity decimal.Decimal, signal strategy.Signal) (Order, bool) {
+	child := Order{
+		Symbol:      parent.Symbol,
+		Direction:   parent.Direction,
+		Price:       parent.Price,
+		Quantity:    quantity,
+		Type:        parent.Type,
+		StopPrice:   parent.StopPrice,
+		TimeInForce: parent.TimeInForce,
+		Regime:      parent.Regime,
+	}
+	if parent.ClientOrderID != "" {
+		child.ClientOrderID = fmt.Sprintf("%s-%d", parent.ClientOrderID, n)
+	}
+	child, err := e.newChildOrder(parent.ID, child)
+	if err != nil {
+		logrus.Infof("执行算法订单 %s 停止拆单: %v", parent.ID, err)
+		return child, false
+	}
+	if !isLimitType(child.Type) {
+		e.mutex.RLock()
+		if quote, ok := e.lastPrices[child.Symbol]; ok {
+			child.Price = quote
+		}
+		e.mutex.RUnlock()
+	}
+
+	err = e.prepareOrder(&child, signal)
+	if err == nil && !child.Quantity.IsPositive() {
+		err = fmt.Errorf("拆分后的数量 %s 低于交易规则的最小数量", quantity.String())
+	}
+	if err != nil {
+		e.recordOrderEvent(audit.EventOrderFailed, child, err.Error())
+		logrus.Warnf("执行算法订单 %s 的第 %d 笔子订单下单失败: %v", parent.ID, n, err)
+		e.finishAlgo(parent.ID, "canceled", fmt.Sprintf("第 %d 笔子订单下单失败: %v", n, err))
+		return child, false
+	}
+
+	logrus.Infof("执行算法订单 %s 的第 %d/%d 笔子订单 %s", parent.ID, n, parent.AlgoSlices, child.ID)
+	return e.executeOrder(child), true
+}
+
+// fillAlgoParentLocked 将子订单的一笔成交汇总到执行算法的父订单，更新成交数量、成交均价和手续费
+// 父订单不是执行算法订单时不做处理，调用方需持有 e.mutex 写锁
+func (e *Executor) fillAlgoParentLocked(parentID string, quantity, price, fee decimal.Decimal) {
+	parent, ok := e.orders[parentID]
+	if !ok || parent.Algo == "" {
+		return
+	}
+
+	filledValue := parent.AvgFillPrice.Mul(parent.FilledQuantity).Add(price.Mul(quantity))
+	parent.FilledQuantity = parent.FilledQuantity.Add(quantity)
+	parent.AvgFillPrice = filledValue.Div(parent.FilledQuantity)
+	parent.Fee = parent.Fee.Add(fee)
+	// 父订单已撤销时只汇总成交，不改变状态
+	if isOpenStatus(parent.Status) {
+		if parent.FilledQuantity.GreaterThanOrEqual(parent.Quantity) {
+			parent.Status = "filled"
+		} else {
+			parent.Status = "partially_filled"
+		}
+	}
+	e.setOrderLocked(parent)
+}
+
+// finishAlgo 结束仍未全部成交的执行算法父订单并撤销其挂单中的子订单，已成交部分保留
+func (e *Executor) finishAlgo(parentID, status, reason string) {
+	e.matchMutex.Lock()
+	defer e.matchMutex.Unlock()
+
+	parent, ok := e.getOrder(parentID)
+	if !ok || !isOpenStatus(parent.Status) {
+		return
+	}
+	parent = e.closeUnfilled(parent, status, reason)
+	logrus.Infof("执行算法订单 %s 已结束(%s): %s，已成交: %s/%s，成交均价: %s",
+		parentID, status, reason, parent.FilledQuantity.String(), parent.Quantity.String(), parent.AvgFillPrice.String())
+	e.persistOpenOrders()
+}
+
+// stopInterruptedAlgos 撤销上次运行时未完成的执行算法订单及其子订单，重启后不再继续拆单
+func (e *Executor) stopInterruptedAlgos() {
+	e.mutex.RLock()
+	interrupted := make([]string, 0)
+	for id, order := range e.orders {
+		if order.Algo != "" && isOpenStatus(order.Status) {
+			interrupted = append(interrupted, id)
+		}
+	}
+	e.mutex.RUnlock()
+
+	for _, id := range interrupted {
+		e.finishAlgo(id, "canceled", "进程重启，执行算法已中断")
+	}
+}
+
+// waitAlgo 每隔 execution.algo.refresh_seconds 检查一次，直到 done 返回true；执行器停止时返回false
+func (e *Executor) waitAlgo(done func() bool) bool {
+	ticker := time.NewTicker(e.algoRefresh())
+	defer ticker.Stop()
+
+	for !done() {
+		select {
+		case <-e.ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// hasOpenChildren 判断订单是否还有挂单中的子订单
+func (e *Executor) hasOpenChildren(parentID string) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	for _, order := range e.orders {
+		if order.ParentID == parentID && isOpenStatus(order.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// getOrder 获取订单的当前状态
+func (e *Executor) getOrder(id string) (Order, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	order, ok := e.orders[id]
+	return order, ok
+}
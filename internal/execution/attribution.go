@@ -0,0 +1,84 @@
+package execution
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// StrategyPerformance 单个策略实例的归因表现
+type StrategyPerformance struct {
+	Strategy    string
+	Trades      int
+	Volume      decimal.Decimal // 成交额
+	RealizedPnL decimal.Decimal // 已实现盈亏
+	OpenQty     map[string]decimal.Decimal
+}
+
+// attributedLot 策略实例在某个交易对上的虚拟持仓，用于计算该实例的已实现盈亏
+type attributedLot struct {
+	quantity   decimal.Decimal
+	entryPrice decimal.Decimal
+}
+
+// recordAttribution 按策略实例记录成交，调用方需持有 e.mutex 写锁
+func (e *Executor) recordAttribution(order Order) {
+	name := order.StrategyName
+	if name == "" {
+		return
+	}
+
+	perf, ok := e.performance[name]
+	if !ok {
+		perf = &StrategyPerformance{
+			Strategy:    name,
+			Volume:      decimal.Zero,
+			RealizedPnL: decimal.Zero,
+			OpenQty:     make(map[string]decimal.Decimal),
+		}
+		e.performance[name] = perf
+	}
+
+	perf.Trades++
+	perf.Volume = perf.Volume.Add(order.Price.Mul(order.Quantity))
+
+	key := fmt.Sprintf("%s-%s", name, order.Symbol)
+	lot := e.lots[key]
+	switch order.Direction {
+	case "buy":
+		total := lot.entryPrice.Mul(lot.quantity).Add(order.Price.Mul(order.Quantity))
+		lot.quantity = lot.quantity.Add(order.Quantity)
+		lot.entryPrice = total.Div(lot.quantity)
+	case "sell":
+		closed := decimal.Min(lot.quantity, order.Quantity)
+		perf.RealizedPnL = perf.RealizedPnL.Add(order.Price.Sub(lot.entryPrice).Mul(closed))
+		lot.quantity = lot.quantity.Sub(closed)
+	}
+
+	if lot.quantity.IsZero() {
+		delete(e.lots, key)
+		delete(perf.OpenQty, order.Symbol)
+	} else {
+		e.lots[key] = lot
+		perf.OpenQty[order.Symbol] = lot.quantity
+	}
+}
+
+// GetStrategyPerformance 获取各策略实例的归因表现，可用于对比金丝雀实例与当前实例
+func (e *Executor) GetStrategyPerformance() map[string]StrategyPerformance {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	result := make(map[string]StrategyPerformance)
+	for name, perf := range e.performance {
+		openQty := make(map[string]decimal.Decimal)
+		for symbol, qty := range perf.OpenQty {
+			openQty[symbol] = qty
+		}
+		copied := *perf
+		copied.OpenQty = openQty
+		result[name] = copied
+	}
+
+	return result
+}
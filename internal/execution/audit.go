@@ -0,0 +1,31 @@
+package execution
+
+import (
+	"strings"
+
+	"autotransaction/internal/audit"
+	"autotransaction/internal/metrics"
+)
+
+// SetAuditLog 设置审计日志，记录订单的提交、成交、撤销和失败
+func (e *Executor) SetAuditLog(log *audit.Log) {
+	e.audit = log
+}
+
+// recordOrderEvent 记录订单审计事件和监控指标，price 和 quantity 为本次事件涉及的价格和数量
+func (e *Executor) recordOrderEvent(eventType string, order Order, reason string) {
+	e.metrics.ObserveOrder(metrics.VenueExchange, order.Symbol, strings.TrimPrefix(eventType, "order_"))
+	e.audit.Record(audit.Event{
+		Type:      eventType,
+		Account:   order.Account,
+		Symbol:    order.Symbol,
+		Direction: order.Direction,
+		Strategy:  order.StrategyName,
+		OrderID:   order.ID,
+		SignalID:  order.SignalID,
+		Price:     order.Price,
+		Quantity:  order.Quantity,
+		Status:    order.Status,
+		Reason:    reason,
+	})
+}
@@ -0,0 +1,267 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"autotransaction/internal/audit"
+
+	"github.com/sirupsen/logrus"
+)
+
+// openOrdersFile 未完成订单及其父订单的持久化文件名
+const openOrdersFile = "open_orders.json"
+
+// isOpenStatus 判断订单是否仍在挂单中
+func isOpenStatus(status string) bool {
+	return status == "pending" || status == "partially_filled"
+}
+
+// SubmitChildOrder 提交属于某个父订单（阶梯、TWAP、OCO等）的子订单
+func (e *Executor) SubmitChildOrder(parentID string, child Order) (Order, error) {
+	child, err := e.newChildOrder(parentID, child)
+	if err != nil {
+		return child, err
+	}
+
+	e.mutex.Lock()
+	e.setOrderLocked(child)
+	e.mutex.Unlock()
+	e.persistOpenOrders()
+
+	return child, nil
+}
+
+// newChildOrder 检查父订单仍在挂单中，并按父订单补全子订单的账户、策略和信号，不保存子订单
+func (e *Executor) newChildOrder(parentID string, child Order) (Order, error) {
+	e.mutex.RLock()
+	parent, ok := e.orders[parentID]
+	e.mutex.RUnlock()
+	if !ok {
+		return child, fmt.Errorf("父订单 %s 不存在", parentID)
+	}
+	if !isOpenStatus(parent.Status) {
+		return child, fmt.Errorf("父订单 %s 已结束，状态: %s", parentID, parent.Status)
+	}
+
+	if child.ID == "" {
+		child.ID = generateOrderID()
+	}
+	if child.Account == "" {
+		child.Account = parent.Account
+	}
+	// 子订单归属于父订单的策略和信号
+	if child.StrategyName == "" {
+		child.StrategyName = parent.StrategyName
+	}
+	if child.SignalID == "" {
+		child.SignalID = parent.SignalID
+	}
+	child.ParentID = parentID
+	child.Status = "pending"
+	child.Timestamp = time.Now()
+	return child, nil
+}
+
+// CancelOrder 撤销订单，并撤销其所有仍在挂单中的子订单
+func (e *Executor) CancelOrder(orderID string) error {
+	e.matchMutex.Lock()
+	defer e.matchMutex.Unlock()
+
+	e.mutex.Lock()
+	order, ok := e.orders[orderID]
+	if !ok {
+		e.mutex.Unlock()
+		return fmt.Errorf("订单 %s 不存在", orderID)
+	}
+	wasOpen := isOpenStatus(order.Status)
+	if wasOpen {
+		// 在实际应用中，这里应该调用交易所API撤单
+		order.Status = "canceled"
+		e.setOrderLocked(order)
+		e.recordOrderEvent(audit.EventOrderCanceled, order, "手动撤单")
+	}
+	canceled := e.cancelChildrenLocked(orderID)
+	e.mutex.Unlock()
+	if wasOpen {
+		e.recordSlippage(order)
+	}
+
+	if canceled > 0 {
+		logrus.Infof("订单 %s 已撤销，同时撤销 %d 个子订单", orderID, canceled)
+	}
+	e.persistOpenOrders()
+	return nil
+}
+
+// CancelOpenOrders 撤销所有仍在挂单中的订单（含子订单），并丢弃订单队列中等待执行的非强制平仓任务
+// 返回撤销的订单数和丢弃的任务数
+func (e *Executor) CancelOpenOrders(reason string) (int, int) {
+	e.matchMutex.Lock()
+	e.mutex.Lock()
+	canceled := make([]Order, 0)
+	for _, order := range e.orders {
+		if !isOpenStatus(order.Status) {
+			continue
+		}
+		// 在实际应用中，这里应该调用交易所API撤单
+		order.Status = "canceled"
+		e.setOrderLocked(order)
+		e.recordOrderEvent(audit.EventOrderCanceled, order, reason)
+		canceled = append(canceled, order)
+	}
+	e.mutex.Unlock()
+	e.matchMutex.Unlock()
+	for _, order := range canceled {
+		e.recordSlippage(order)
+	}
+
+	dropped := e.jobs.DropQueued()
+
+	logrus.Warnf("已撤销 %d 个挂单，丢弃 %d 个排队任务: %s", len(canceled), dropped, reason)
+	e.persistOpenOrders()
+	return len(canceled), dropped
+}
+
+// FinalizeOrder 将父订单标记为最终状态（如 filled、rejected），并撤销其所有仍在挂单中的子订单
+func (e *Executor) FinalizeOrder(orderID, status string) error {
+	e.mutex.Lock()
+	order, ok := e.orders[orderID]
+	if !ok {
+		e.mutex.Unlock()
+		return fmt.Errorf("订单 %s 不存在", orderID)
+	}
+	order.Status = status
+	e.setOrderLocked(order)
+	canceled := e.cancelChildrenLocked(orderID)
+	e.mutex.Unlock()
+
+	if canceled > 0 {
+		logrus.Infof("订单 %s 已结束(%s)，撤销 %d 个剩余子订单", orderID, status, canceled)
+	}
+	e.persistOpenOrders()
+	return nil
+}
+
+// cancelChildrenLocked 递归撤销仍在挂单中的子订单，返回撤销数量，调用方需持有 e.mutex 写锁
+func (e *Executor) cancelChildrenLocked(parentID string) int {
+	canceled := 0
+	for id, order := range e.orders {
+		if order.ParentID != parentID {
+			continue
+		}
+		if isOpenStatus(order.Status) {
+			order.Status = "canceled"
+			e.setOrderLocked(order)
+			e.recordOrderEvent(audit.EventOrderCanceled, order, "父订单已结束")
+			canceled++
+		}
+		canceled += e.cancelChildrenLocked(id)
+	}
+	return canceled
+}
+
+// cancelOrphanedChildren 撤销父订单已结束或已不存在的挂单子订单
+func (e *Executor) cancelOrphanedChildren() {
+	if !e.cfg.Execution.CancelOrphanChildren {
+		return
+	}
+
+	e.mutex.Lock()
+	canceled := 0
+	for id, order := range e.orders {
+		if order.ParentID == "" || !isOpenStatus(order.Status) {
+			continue
+		}
+		parent, ok := e.orders[order.ParentID]
+		if ok && isOpenStatus(parent.Status) {
+			continue
+		}
+		order.Status = "canceled"
+		e.setOrderLocked(order)
+		e.recordOrderEvent(audit.EventOrderCanceled, order, "父订单已结束或不存在")
+		canceled++
+		logrus.Warnf("撤销孤立子订单 %s (父订单 %s)", id, order.ParentID)
+	}
+	e.mutex.Unlock()
+
+	if canceled > 0 {
+		e.persistOpenOrders()
+	}
+}
+
+// persistOpenOrders 持久化仍在挂单中的订单及其父订单，重启后据此清理孤立子订单
+func (e *Executor) persistOpenOrders() {
+	if !e.cfg.Execution.CancelOrphanChildren {
+		return
+	}
+
+	e.mutex.RLock()
+	open := make(map[string]Order)
+	for id, order := range e.orders {
+		if !isOpenStatus(order.Status) {
+			continue
+		}
+		open[id] = order
+		if parent, ok := e.orders[order.ParentID]; ok {
+			open[parent.ID] = parent
+		}
+	}
+	e.mutex.RUnlock()
+
+	if err := writeOpenOrders(e.cfg.System.DataDir, open); err != nil {
+		logrus.Errorf("保存未完成订单失败: %v", err)
+	}
+}
+
+// loadOpenOrders 加载上次运行时未完成的订单
+func (e *Executor) loadOpenOrders() error {
+	content, err := ioutil.ReadFile(filepath.Join(e.cfg.System.DataDir, openOrdersFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取未完成订单文件失败: %v", err)
+	}
+
+	var open map[string]Order
+	if err := json.Unmarshal(content, &open); err != nil {
+		return fmt.Errorf("解析未完成订单文件失败: %v", err)
+	}
+
+	e.mutex.Lock()
+	for id, order := range open {
+		if _, exists := e.orders[id]; !exists {
+			e.orders[id] = order
+			e.indexClientOrderLocked(order)
+		}
+	}
+	e.mutex.Unlock()
+
+	logrus.Infof("已加载 %d 个未完成订单", len(open))
+	return nil
+}
+
+// writeOpenOrders 写入未完成订单文件，先写临时文件再重命名
+func writeOpenOrders(dataDir string, open map[string]Order) error {
+	content, err := json.MarshalIndent(open, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化未完成订单失败: %v", err)
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+
+	path := filepath.Join(dataDir, openOrdersFile)
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("写入未完成订单文件失败: %v", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,77 @@
+package execution
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/risk"
+
+	"github.com/shopspring/decimal"
+)
+
+// openParent 保存一个挂单中的父订单并提交 children 个子订单，返回父订单和子订单ID
+func openParent(t *testing.T, e *Executor, id string, children int) []string {
+	t.Helper()
+	e.mutex.Lock()
+	e.setOrderLocked(Order{ID: id, Symbol: "BTC/USDT", Direction: "buy", Quantity: decimal.NewFromInt(2), Status: "pending"})
+	e.mutex.Unlock()
+
+	ids := make([]string, 0, children)
+	for i := 0; i < children; i++ {
+		child, err := e.SubmitChildOrder(id, Order{Symbol: "BTC/USDT", Direction: "buy", Type: OrderTypeLimit, Price: decimal.NewFromInt(90), Quantity: decimal.NewFromInt(1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, child.ID)
+	}
+	return ids
+}
+
+func TestCancelParentCancelsOpenChildren(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Execution.CancelOrphanChildren = true
+	e := testExecutor(t, cfg)
+	children := openParent(t, e, "PARENT", 2)
+
+	if err := e.CancelOrder("PARENT"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range children {
+		if order, _ := e.getOrder(id); order.Status != "canceled" {
+			t.Fatalf("撤销父订单后子订单 %s 应被撤销，状态: %s", id, order.Status)
+		}
+	}
+}
+
+func TestRestartCancelsOrphanedChildren(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Execution.CancelOrphanChildren = true
+	e := testExecutor(t, cfg)
+	orphans := openParent(t, e, "ENDED", 2)
+	kept := openParent(t, e, "OPEN", 1)
+
+	// 模拟父订单已结束、子订单尚未撤销时进程退出
+	e.mutex.Lock()
+	parent := e.orders["ENDED"]
+	parent.Status = "canceled"
+	e.setOrderLocked(parent)
+	e.mutex.Unlock()
+	e.persistOpenOrders()
+	e.Stop()
+
+	restarted := NewExecutor(cfg, risk.NewRiskManager(cfg))
+	if err := restarted.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Stop()
+
+	for _, id := range orphans {
+		order, ok := restarted.getOrder(id)
+		if !ok || order.Status != "canceled" {
+			t.Fatalf("重启后父订单已结束的子订单 %s 应被撤销，状态: %s", id, order.Status)
+		}
+	}
+	if order, ok := restarted.getOrder(kept[0]); !ok || order.Status != "pending" {
+		t.Fatalf("父订单仍在挂单中的子订单应保留，状态: %s", order.Status)
+	}
+}
@@ -3,11 +3,18 @@ package execution
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/fees"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/portfolio"
 	"autotransaction/internal/risk"
+	"autotransaction/internal/store"
 	"autotransaction/internal/strategy"
 
 	"github.com/shopspring/decimal"
@@ -16,46 +23,97 @@ import (
 
 // Order 表示交易订单
 type Order struct {
-	ID        string
-	Symbol    string
-	Direction string // "buy" 或 "sell"
-	Price     decimal.Decimal
-	Quantity  decimal.Decimal
-	Status    string // "pending", "filled", "canceled", "rejected"
-	Timestamp time.Time
+	ID             string
+	ParentID       string // 父订单ID，阶梯/TWAP/OCO等拆分出的子订单使用
+	Account        string
+	Symbol         string
+	Direction      string // "buy" 或 "sell"
+	Price          decimal.Decimal
+	Quantity       decimal.Decimal
+	Status         string // "pending", "partially_filled", "filled", "canceled", "expired", "rejected"
+	Type           string // "market", "limit", "stop_limit"，限价类订单的 Price 为限价
+	StopPrice      decimal.Decimal
+	Triggered      bool   // 止损限价单是否已触发
+	TimeInForce    string // "GTC", "IOC", "FOK"，仅对限价类订单有效
+	ExpiresAt      time.Time
+	FilledQuantity decimal.Decimal // 已成交数量
+	AvgFillPrice   decimal.Decimal // 成交均价
+	Fee            decimal.Decimal // 累计手续费（计价货币）
+	Regime         string          // 下单时的市场状态
+	StrategyName   string          // 产生订单的策略实例名称
+	SignalID       string          // 产生订单的信号ID
+	SignalPrice    decimal.Decimal // 信号价格，订单成交结束时与成交均价比较统计实际滑点
+	ClientOrderID  string          // 幂等键，同一账户下唯一
+	Timestamp      time.Time
+
+	// 执行算法的父订单本身不挂单，由子订单分批成交，子订单的成交汇总到父订单的成交数量、成交均价和手续费，见 algo.go
+	Algo         string // "twap" 或 "iceberg"，为空时为普通订单
+	AlgoSlices   int
+	AlgoDuration time.Duration
 }
 
 // Position 表示持仓
 type Position struct {
+	Account      string
 	Symbol       string
 	Quantity     decimal.Decimal
 	EntryPrice   decimal.Decimal
 	CurrentPrice decimal.Decimal
 	Timestamp    time.Time
+
+	// 永续合约持仓的方向、杠杆、占用保证金和估算的强平价格，现货持仓为零值
+	Side             string // risk.SideLong 或 risk.SideShort
+	Leverage         decimal.Decimal
+	Margin           decimal.Decimal
+	LiquidationPrice decimal.Decimal
 }
 
 // Executor 负责执行交易
 type Executor struct {
-	cfg         *config.Config
-	riskManager *risk.RiskManager
-	positions   map[string]Position
-	orders      map[string]Order
-	mutex       sync.RWMutex
-	ctx         context.Context
-	cancel      context.CancelFunc
+	cfg          *config.Config
+	riskManager  *risk.RiskManager
+	fees         *fees.Model
+	positions    map[string]Position // 键为 账户-交易对
+	orders       map[string]Order
+	clientOrders map[string]string               // 幂等键到订单ID的映射，键为 账户-幂等键
+	symbolRules  map[string]SymbolRules          // 从交易所获取的交易规则缓存
+	performance  map[string]*StrategyPerformance // 按策略实例归因的表现
+	lots         map[string]attributedLot        // 键为 策略实例-交易对
+	lastPrices   map[string]decimal.Decimal      // 交易对最新价格，用于撮合限价单
+	store        store.Store                     // 为nil时不持久化
+	portfolio    *portfolio.Portfolio            // 为nil时不跟踪账户资金
+	audit        *audit.Log                      // 为nil时不记录审计日志
+	events       *events.Bus                     // 为nil时不发布事件
+	metrics      *metrics.Metrics                // 为nil时不记录监控指标
+	httpClient   *http.Client
+	mutex        sync.RWMutex
+	matchMutex   sync.Mutex // 串行化限价单的撮合、撤销和超时处理
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	jobs *JobQueue // 订单队列，见 queue.go
 }
 
 // NewExecutor 创建一个新的交易执行器
 func NewExecutor(cfg *config.Config, riskManager *risk.RiskManager) *Executor {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Executor{
-		cfg:         cfg,
-		riskManager: riskManager,
-		positions:   make(map[string]Position),
-		orders:      make(map[string]Order),
-		ctx:         ctx,
-		cancel:      cancel,
+	e := &Executor{
+		cfg:          cfg,
+		riskManager:  riskManager,
+		fees:         fees.NewModel(cfg),
+		positions:    make(map[string]Position),
+		orders:       make(map[string]Order),
+		clientOrders: make(map[string]string),
+		symbolRules:  make(map[string]SymbolRules),
+		performance:  make(map[string]*StrategyPerformance),
+		lots:         make(map[string]attributedLot),
+		lastPrices:   make(map[string]decimal.Decimal),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		ctx:          ctx,
+		cancel:       cancel,
 	}
+	e.jobs = NewJobQueue(cfg, e.submitJob)
+	return e
 }
 
 // Start 启动交易执行器
@@ -65,8 +123,37 @@ func (e *Executor) Start() error {
 	// 注册为策略信号的处理器
 	// 注意：这里需要在外部将Executor注册到StrategyManager
 
-	// 启动订单状态更新协程
+	// 从存储中恢复订单和持仓
+	if err := e.loadHistory(); err != nil {
+		return fmt.Errorf("加载历史订单失败: %v", err)
+	}
+
+	// 获取交易所交易规则
+	if e.cfg.Execution.AutoSymbolRules {
+		if err := e.loadSymbolRules(); err != nil {
+			logrus.Warnf("获取交易所交易规则失败，仅使用配置中的交易规则: %v", err)
+		}
+	}
+
+	// 设置永续合约交易对的杠杆
+	if err := e.configurePerpetuals(); err != nil {
+		logrus.Warnf("设置永续合约杠杆失败: %v", err)
+	}
+
+	// 恢复上次运行时未完成的订单并撤销其中的孤立子订单
+	if e.cfg.Execution.CancelOrphanChildren {
+		if err := e.loadOpenOrders(); err != nil {
+			logrus.Warnf("恢复未完成订单失败: %v", err)
+		}
+		e.cancelOrphanedChildren()
+	}
+
+	// 上次运行时未完成的执行算法订单不再继续拆单
+	e.stopInterruptedAlgos()
+
+	// 启动订单状态更新协程和订单队列
 	go e.updateOrderStatus()
+	go e.jobs.Run(e.ctx)
 
 	return nil
 }
@@ -79,43 +166,179 @@ func (e *Executor) Stop() {
 
 // HandleSignal 实现 strategy.SignalHandler 接口
 func (e *Executor) HandleSignal(signal strategy.Signal) {
-	// 检查风险控制
-	if !e.riskManager.CheckSignal(signal) {
-		logrus.Warnf("信号 %s %s 未通过风险检查，已拒绝", signal.Symbol, signal.Direction)
+	// 区块链交易对和指定在链上执行的信号由区块链交易执行器处理
+	if !routesToExchange(e.cfg.Current().Trading.Pairs, signal) {
 		return
 	}
 
+	if err := e.jobs.Enqueue(signal); err != nil {
+		logrus.Warnf("信号 %s %s 已拒绝: %v", signal.Symbol, signal.Direction, err)
+	}
+}
+
+// SubmitSignal 对信号进行风险检查后下单，返回创建的订单
+// 信号带有幂等键且该键已创建过订单时，直接返回已有订单而不重复下单
+func (e *Executor) SubmitSignal(signal strategy.Signal) (Order, error) {
+	account := signal.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	if existing, ok := e.findClientOrder(account, signal.ClientOrderID); ok {
+		logrus.Infof("幂等键 %s 已创建订单 %s，不重复下单", signal.ClientOrderID, existing.ID)
+		return existing, nil
+	}
+
+	// 检查风险控制
+	if err := e.riskManager.ValidateSignal(signal); err != nil {
+		return Order{}, fmt.Errorf("未通过风险检查: %v", err)
+	}
+
 	// 创建订单
-	order := Order{
-		ID:        generateOrderID(),
-		Symbol:    signal.Symbol,
-		Direction: signal.Direction,
-		Price:     signal.Price,
-		Quantity:  signal.Quantity,
-		Status:    "pending",
-		Timestamp: time.Now(),
+	order := newOrder(signal, account)
+	if err := e.prepareOrder(&order, signal); err != nil {
+		e.riskManager.ReleaseSlippageProbe(signal.Symbol)
+		e.recordOrderEvent(audit.EventOrderFailed, order, err.Error())
+		return order, err
+	}
+
+	// 占用幂等键，并发提交的相同信号只有一个会下单
+	if existing, ok, err := e.claimClientOrder(order); ok {
+		e.riskManager.ReleaseSlippageProbe(signal.Symbol)
+		if err != nil {
+			return order, err
+		}
+		logrus.Infof("幂等键 %s 已创建订单 %s，不重复下单", order.ClientOrderID, existing.ID)
+		return existing, nil
+	}
+
+	// 执行算法的父订单由子订单分批成交
+	if order.Algo != "" {
+		return e.startAlgo(order, signal), nil
+	}
+
+	// 执行订单，成交结束时按成交均价记录实际滑点
+	return e.executeOrder(order), nil
+}
+
+// newOrder 按信号创建待执行的订单
+func newOrder(signal strategy.Signal, account string) Order {
+	return Order{
+		ID:            generateOrderID(),
+		Account:       account,
+		Symbol:        signal.Symbol,
+		Direction:     signal.Direction,
+		Price:         signal.Price,
+		Quantity:      signal.Quantity,
+		Status:        "pending",
+		Type:          signal.OrderType,
+		StopPrice:     signal.StopPrice,
+		TimeInForce:   signal.TimeInForce,
+		Regime:        signal.Regime,
+		StrategyName:  signal.StrategyName,
+		SignalID:      signal.ID,
+		SignalPrice:   signal.Price,
+		ClientOrderID: signal.ClientOrderID,
+		Timestamp:     time.Now(),
+		Algo:          signal.Algo,
+		AlgoSlices:    signal.AlgoSlices,
+		AlgoDuration:  time.Duration(signal.AlgoDuration) * time.Second,
+	}
+}
+
+// prepareOrder 下单前的订单检查：确定订单类型、检查行情滑点、按交易所规则调整价格和数量、检查虚拟余额
+func (e *Executor) prepareOrder(order *Order, signal strategy.Signal) error {
+	if err := e.normalizeOrderType(order); err != nil {
+		return err
+	}
+	if err := e.normalizeAlgo(order); err != nil {
+		return err
+	}
+	if err := e.checkQuoteSlippage(order); err != nil {
+		return err
+	}
+
+	// 按交易所规则调整价格和数量，区块链交易对没有交易所规则
+	if routesToExchange(e.cfg.Current().Trading.Pairs, signal) {
+		if err := e.applySymbolRules(order); err != nil {
+			return fmt.Errorf("不符合交易规则: %w", err)
+		}
 	}
 
-	// 执行订单
-	e.executeOrder(order)
+	// 模拟交易模式下检查虚拟余额
+	return e.checkVirtualBalance(*order)
 }
 
-// executeOrder 执行订单
-func (e *Executor) executeOrder(order Order) {
+// checkQuoteSlippage 市价单下单前比较信号价格与最新行情价格，不利滑点超过容忍度时
+// 按 risk.slippage_action 拒绝订单或按最新价格重新定价；尚未收到行情时不检查
+func (e *Executor) checkQuoteSlippage(order *Order) error {
+	if isLimitType(order.Type) {
+		return nil
+	}
+	e.mutex.RLock()
+	quote, ok := e.lastPrices[order.Symbol]
+	e.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	err := e.riskManager.CheckQuoteSlippage(order.Direction, order.Price, quote)
+	if err == nil {
+		return nil
+	}
+	if !e.riskManager.RequoteOnSlippage() {
+		return err
+	}
+	logrus.Warnf("订单 %s %v，按最新价格重新定价", order.ID, err)
+	order.Price = quote
+	return nil
+}
+
+// executeOrder 执行订单，返回更新状态后的订单
+func (e *Executor) executeOrder(order Order) Order {
 	// 在实际应用中，这里应该调用交易所API执行订单
 	logrus.Infof("执行订单: %s %s %s 价格: %s 数量: %s",
 		order.ID, order.Symbol, order.Direction, order.Price.String(), order.Quantity.String())
+	e.recordOrderEvent(audit.EventOrderSubmitted, order, "")
+
+	// 限价类订单挂单等待价格满足条件
+	if isLimitType(order.Type) {
+		return e.placeLimitOrder(order)
+	}
 
-	// 模拟订单执行
-	order.Status = "filled"
+	// 模拟市价单立即全部成交
+	order = e.applyFill(order, order.Quantity, e.marketFillPrice(order))
+	e.persistOpenOrders()
 
-	// 更新订单状态
-	e.mutex.Lock()
-	e.orders[order.ID] = order
-	e.mutex.Unlock()
+	return order
+}
+
+// SetPortfolio 设置账户资金跟踪器，成交后同步更新账户余额
+func (e *Executor) SetPortfolio(p *portfolio.Portfolio) {
+	e.portfolio = p
+}
 
-	// 更新持仓
-	e.updatePosition(order)
+// SetEventBus 设置事件总线，成交和持仓变化会实时发布到总线
+func (e *Executor) SetEventBus(bus *events.Bus) {
+	e.events = bus
+	e.jobs.SetEventBus(bus)
+}
+
+// SetMetrics 设置监控指标，统计订单的提交、成交、撤销和失败
+func (e *Executor) SetMetrics(m *metrics.Metrics) {
+	e.metrics = m
+}
+
+// applyToPortfolio 将成交及其手续费计入账户余额，永续合约不交割标的资产，平仓盈亏在更新持仓时计入
+func (e *Executor) applyToPortfolio(order Order, fee decimal.Decimal) {
+	if e.portfolio == nil {
+		return
+	}
+	if _, perpetual := e.cfg.PerpetualLeverage(order.Symbol); !perpetual {
+		e.portfolio.ApplyFill(order.Account, order.Symbol, order.Direction, order.Price, order.Quantity)
+	}
+	if fee.IsPositive() {
+		e.portfolio.ChargeFee(order.Account, order.Symbol, fee)
+	}
 }
 
 // updatePosition 更新持仓信息
@@ -123,12 +346,18 @@ func (e *Executor) updatePosition(order Order) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	position, exists := e.positions[order.Symbol]
+	key := risk.PositionKey(order.Account, order.Symbol)
+	if leverage, ok := e.cfg.PerpetualLeverage(order.Symbol); ok {
+		e.updatePerpetualPositionLocked(key, order, leverage)
+		return
+	}
+	position, exists := e.positions[key]
 
 	if order.Direction == "buy" {
 		if !exists {
 			// 新建仓位
 			position = Position{
+				Account:      order.Account,
 				Symbol:       order.Symbol,
 				Quantity:     order.Quantity,
 				EntryPrice:   order.Price,
@@ -156,29 +385,35 @@ func (e *Executor) updatePosition(order Order) {
 
 		if newQuantity.LessThanOrEqual(decimal.Zero) {
 			// 清仓
-			delete(e.positions, order.Symbol)
-			logrus.Infof("已清仓: %s", order.Symbol)
+			position.Quantity = decimal.Zero
+			position.CurrentPrice = order.Price
+			delete(e.positions, key)
+			logrus.Infof("账户 %s 已清仓: %s", order.Account, order.Symbol)
 		} else {
 			// 部分减仓
 			position.Quantity = newQuantity
 			position.CurrentPrice = order.Price
 			position.Timestamp = time.Now()
-			e.positions[order.Symbol] = position
+			e.positions[key] = position
 		}
 	}
 
-	if exists && position.Quantity.GreaterThan(decimal.Zero) {
-		e.positions[order.Symbol] = position
+	if position.Quantity.GreaterThan(decimal.Zero) {
+		e.positions[key] = position
 	}
+	e.savePosition(key, position)
+	e.syncPosition(position)
+}
 
-	// 通知风险管理器更新持仓信息
-	riskPosition := risk.Position{
-		Symbol:       position.Symbol,
-		Quantity:     position.Quantity,
-		EntryPrice:   position.EntryPrice,
-		CurrentPrice: position.CurrentPrice,
-	}
-	e.riskManager.UpdatePosition(riskPosition)
+// syncPosition 通知风险管理器更新持仓信息并发布持仓变化
+func (e *Executor) syncPosition(position Position) {
+	e.riskManager.UpdatePosition(position.riskPosition())
+	e.events.Publish(events.Event{
+		Type:    events.EventPosition,
+		Account: position.Account,
+		Symbol:  position.Symbol,
+		Payload: position,
+	})
 }
 
 // updateOrderStatus 更新订单状态
@@ -191,12 +426,15 @@ func (e *Executor) updateOrderStatus() {
 		case <-e.ctx.Done():
 			return
 		case <-ticker.C:
+			e.cancelOrphanedChildren()
+			e.expireLimitOrders()
+
 			// 在实际应用中，这里应该查询交易所API获取订单状态
-			// 这里只是简单模拟
+			// 这里只是简单模拟市价单成交，限价类订单由行情数据撮合，执行算法的父订单由子订单成交
 			e.mutex.RLock()
 			pendingOrders := make([]Order, 0)
 			for _, order := range e.orders {
-				if order.Status == "pending" {
+				if order.Status == "pending" && !isLimitType(order.Type) && order.Algo == "" {
 					pendingOrders = append(pendingOrders, order)
 				}
 			}
@@ -204,21 +442,17 @@ func (e *Executor) updateOrderStatus() {
 
 			// 更新挂起订单的状态
 			for _, order := range pendingOrders {
-				// 模拟订单成交
-				order.Status = "filled"
-
-				e.mutex.Lock()
-				e.orders[order.ID] = order
-				e.mutex.Unlock()
-
-				// 更新持仓
-				e.updatePosition(order)
+				// 模拟订单全部成交并更新持仓
+				e.applyFill(order, order.Quantity.Sub(order.FilledQuantity), e.marketFillPrice(order))
+			}
+			if len(pendingOrders) > 0 {
+				e.persistOpenOrders()
 			}
 		}
 	}
 }
 
-// GetPositions 获取当前所有持仓
+// GetPositions 获取当前所有持仓，键为 账户-交易对
 func (e *Executor) GetPositions() map[string]Position {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -246,8 +480,19 @@ func (e *Executor) GetOrders() map[string]Order {
 	return result
 }
 
-// generateOrderID 生成订单ID
-func generateOrderID() string {
-	// 在实际应用中，应该生成唯一的订单ID
-	return fmt.Sprintf("ORDER-%d", time.Now().UnixNano())
+// GetOrdersByRegime 按下单时的市场状态对已成交订单分组，用于按市场状态分析交易表现
+// 执行算法的父订单不计入，其成交已包含在子订单中
+func (e *Executor) GetOrdersByRegime() map[string][]Order {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	result := make(map[string][]Order)
+	for _, order := range e.orders {
+		if order.Status != "filled" || order.Algo != "" {
+			continue
+		}
+		result[order.Regime] = append(result[order.Regime], order)
+	}
+
+	return result
 }
@@ -0,0 +1,176 @@
+package execution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// futuresClient Binance U本位永续合约接口客户端，用于设置杠杆、获取合约交易规则和查询合约持仓
+type futuresClient struct {
+	baseURL    string
+	apiKey     string
+	apiSecret  string
+	httpClient *http.Client
+}
+
+// futuresPosition 合约持仓接口返回的单个交易对持仓（单向持仓模式）
+type futuresPosition struct {
+	Symbol           string `json:"symbol"`
+	PositionAmt      string `json:"positionAmt"` // 正数为多头，负数为空头
+	EntryPrice       string `json:"entryPrice"`
+	MarkPrice        string `json:"markPrice"`
+	LiquidationPrice string `json:"liquidationPrice"`
+	Leverage         string `json:"leverage"`
+}
+
+// newFuturesClient 创建永续合约接口客户端
+func newFuturesClient(cfg config.ExchangeConfig, httpClient *http.Client) *futuresClient {
+	baseURL := cfg.FuturesBaseURL
+	if baseURL == "" {
+		baseURL = market.DefaultBinanceFuturesURL
+	}
+	return &futuresClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		apiSecret:  cfg.APISecret,
+		httpClient: httpClient,
+	}
+}
+
+// do 发送请求并返回响应内容，signed 为 true 时按 HMAC-SHA256 对参数签名
+func (c *futuresClient) do(ctx context.Context, method, path string, query url.Values, signed bool) ([]byte, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if signed {
+		query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+		query.Set("recvWindow", "5000")
+		mac := hmac.New(sha256.New, []byte(c.apiSecret))
+		mac.Write([]byte(query.Encode()))
+		query.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		request.Header.Set("X-MBX-APIKEY", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("请求合约接口 %s 失败: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取合约接口响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("合约接口返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+	return body, nil
+}
+
+// setLeverage 设置交易对的杠杆倍数，交易所只支持整数倍
+func (c *futuresClient) setLeverage(ctx context.Context, symbol string, leverage int) error {
+	query := url.Values{}
+	query.Set("symbol", market.BinanceSymbol(symbol))
+	query.Set("leverage", strconv.Itoa(leverage))
+	_, err := c.do(ctx, http.MethodPost, "/fapi/v1/leverage", query, true)
+	return err
+}
+
+// exchangeInfo 获取合约市场的交易规则，键为交易所格式的交易对
+func (c *futuresClient) exchangeInfo(ctx context.Context) (map[string]SymbolRules, error) {
+	body, err := c.do(ctx, http.MethodGet, "/fapi/v1/exchangeInfo", nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var info exchangeInfoResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("解析合约交易规则失败: %v", err)
+	}
+	rules := make(map[string]SymbolRules, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		rules[symbol.Symbol] = parseSymbolFilters(symbol.Filters)
+	}
+	return rules, nil
+}
+
+// positions 查询合约账户的持仓，键为交易所格式的交易对，不包含数量为0的交易对
+func (c *futuresClient) positions(ctx context.Context) (map[string]futuresPosition, error) {
+	body, err := c.do(ctx, http.MethodGet, "/fapi/v2/positionRisk", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []futuresPosition
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析合约持仓失败: %v", err)
+	}
+	result := make(map[string]futuresPosition, len(list))
+	for _, position := range list {
+		if amount, _ := decimal.NewFromString(position.PositionAmt); amount.IsZero() {
+			continue
+		}
+		result[position.Symbol] = position
+	}
+	return result, nil
+}
+
+// perpetualPairs 返回启用的永续合约交易对
+func (e *Executor) perpetualPairs() []config.PairConfig {
+	pairs := make([]config.PairConfig, 0)
+	for _, pair := range e.cfg.Current().Trading.Pairs {
+		if pair.Enabled && pair.IsPerpetual() {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// futuresLive 判断是否连接真实的合约账户，模拟行情、模拟交易或未配置API密钥时只在本地计算保证金和强平价格
+func (e *Executor) futuresLive() bool {
+	exchangeCfg := e.cfg.Exchange
+	return !exchangeCfg.MockMode && !e.isPaperTrading() && exchangeCfg.APIKey != "" && exchangeCfg.APISecret != ""
+}
+
+// configurePerpetuals 按配置设置各永续合约交易对在交易所的杠杆倍数
+func (e *Executor) configurePerpetuals() error {
+	if !e.futuresLive() {
+		return nil
+	}
+
+	client := newFuturesClient(e.cfg.Exchange, e.httpClient)
+	for _, pair := range e.perpetualPairs() {
+		leverage, _ := e.cfg.PerpetualLeverage(pair.Symbol)
+		if leverage != float64(int(leverage)) {
+			logrus.Warnf("交易所只支持整数倍杠杆，%s 的杠杆 %v 倍按 %d 倍设置", pair.Symbol, leverage, int(leverage))
+		}
+		if err := client.setLeverage(e.ctx, pair.Symbol, int(leverage)); err != nil {
+			return fmt.Errorf("设置 %s 的杠杆失败: %v", pair.Symbol, err)
+		}
+		logrus.Infof("已设置 %s 的杠杆为 %d 倍", pair.Symbol, int(leverage))
+	}
+	return nil
+}
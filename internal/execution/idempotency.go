@@ -0,0 +1,62 @@
+package execution
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/pkg/utils"
+)
+
+// orderSequence 订单ID的序号，避免同一纳秒内生成相同的ID
+var orderSequence uint64
+
+// generateOrderID 生成订单ID
+func generateOrderID() string {
+	return fmt.Sprintf("ORDER-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&orderSequence, 1))
+}
+
+// indexClientOrderLocked 记录订单的幂等键，调用方需持有 e.mutex 写锁
+func (e *Executor) indexClientOrderLocked(order Order) {
+	if order.ClientOrderID != "" {
+		e.clientOrders[utils.ClientOrderKey(order.Account, order.ClientOrderID)] = order.ID
+	}
+}
+
+// findClientOrder 查找幂等键已创建的订单
+func (e *Executor) findClientOrder(account, clientOrderID string) (Order, bool) {
+	if clientOrderID == "" {
+		return Order{}, false
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	id, ok := e.clientOrders[utils.ClientOrderKey(account, clientOrderID)]
+	if !ok {
+		return Order{}, false
+	}
+	order, ok := e.orders[id]
+	return order, ok
+}
+
+// claimClientOrder 在下单前占用订单的幂等键
+// 键已被其他订单占用时返回 true 和该订单；该订单仍在创建中时返回错误
+func (e *Executor) claimClientOrder(order Order) (Order, bool, error) {
+	if order.ClientOrderID == "" {
+		return Order{}, false, nil
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	key := utils.ClientOrderKey(order.Account, order.ClientOrderID)
+	if id, ok := e.clientOrders[key]; ok {
+		if existing, ok := e.orders[id]; ok {
+			return existing, true, nil
+		}
+		return Order{}, true, fmt.Errorf("幂等键 %s 的订单 %s 正在创建", order.ClientOrderID, id)
+	}
+	e.clientOrders[key] = order.ID
+	return Order{}, false, nil
+}
@@ -0,0 +1,247 @@
+package execution
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/reconcile"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// riskPosition 转换为风险管理器使用的持仓
+func (p Position) riskPosition() risk.Position {
+	return risk.Position{
+		Account:          p.Account,
+		Symbol:           p.Symbol,
+		Quantity:         p.Quantity,
+		EntryPrice:       p.EntryPrice,
+		CurrentPrice:     p.CurrentPrice,
+		Side:             p.Side,
+		Leverage:         p.Leverage,
+		LiquidationPrice: p.LiquidationPrice,
+		Venue:            strategy.VenueExchange,
+	}
+}
+
+// signedQuantity 返回带方向的持仓数量，空头为负数
+func (p Position) signedQuantity() decimal.Decimal {
+	if p.Side == risk.SideShort {
+		return p.Quantity.Neg()
+	}
+	return p.Quantity
+}
+
+// updatePerpetualPositionLocked 按永续合约成交更新单向持仓：同向加仓按成交价加权开仓价，反向先减仓，
+// 数量超过持仓时反手开仓；平仓部分的盈亏计入账户余额，调用方需持有 e.mutex 写锁
+func (e *Executor) updatePerpetualPositionLocked(key string, order Order, leverage float64) {
+	side := risk.SideLong
+	if order.Direction == "sell" {
+		side = risk.SideShort
+	}
+
+	position, exists := e.positions[key]
+	if !exists {
+		position = Position{Account: order.Account, Symbol: order.Symbol}
+	}
+
+	realized := decimal.Zero
+	switch {
+	case !position.Quantity.IsPositive():
+		position.Side = side
+		position.Quantity = order.Quantity
+		position.EntryPrice = order.Price
+	case position.Side == side:
+		totalValue := position.EntryPrice.Mul(position.Quantity).Add(order.Price.Mul(order.Quantity))
+		position.Quantity = position.Quantity.Add(order.Quantity)
+		position.EntryPrice = totalValue.Div(position.Quantity)
+	default:
+		closed := decimal.Min(position.Quantity, order.Quantity)
+		realized = risk.Position{
+			Side:         position.Side,
+			Quantity:     closed,
+			EntryPrice:   position.EntryPrice,
+			CurrentPrice: order.Price,
+		}.UnrealizedPnL()
+		position.Quantity = position.Quantity.Sub(closed)
+		if remaining := order.Quantity.Sub(closed); remaining.IsPositive() {
+			// 反手开仓
+			position.Side = side
+			position.Quantity = remaining
+			position.EntryPrice = order.Price
+		}
+	}
+	position.CurrentPrice = order.Price
+	position.Timestamp = time.Now()
+	position.Leverage = decimal.NewFromFloat(leverage)
+
+	if position.Quantity.IsPositive() {
+		position.Margin = position.EntryPrice.Mul(position.Quantity).Div(position.Leverage)
+		position.LiquidationPrice = risk.LiquidationPrice(position.Side, position.EntryPrice, position.Leverage,
+			e.cfg.Current().Risk.Margin.MaintenanceMarginRate)
+		e.positions[key] = position
+	} else {
+		position.Margin = decimal.Zero
+		position.LiquidationPrice = decimal.Zero
+		delete(e.positions, key)
+		logrus.Infof("账户 %s 已平仓: %s", order.Account, order.Symbol)
+	}
+	e.savePosition(key, position)
+
+	if !realized.IsZero() && e.portfolio != nil {
+		e.portfolio.ApplyRealizedPnL(order.Account, order.Symbol, realized)
+	}
+	e.syncPosition(position)
+}
+
+// checkMarginBalance 模拟交易模式下检查永续合约开仓所需的初始保证金和手续费是否超过虚拟余额
+func (e *Executor) checkMarginBalance(order Order, leverage float64) error {
+	if !e.isPaperTrading() || e.portfolio == nil {
+		return nil
+	}
+
+	// 只计算开仓部分，反向减仓不占用新的保证金
+	quantity := order.Quantity
+	e.mutex.RLock()
+	position, exists := e.positions[risk.PositionKey(order.Account, order.Symbol)]
+	e.mutex.RUnlock()
+	if exists && position.Quantity.IsPositive() && position.riskPosition().ExitDirection() == order.Direction {
+		quantity = quantity.Sub(position.Quantity)
+	}
+	if !quantity.IsPositive() {
+		return nil
+	}
+
+	price := order.Price
+	if !isLimitType(order.Type) {
+		price = e.marketFillPrice(order)
+	}
+	required := price.Mul(quantity).Div(decimal.NewFromFloat(leverage))
+	required = required.Add(e.tradingFee(order, price, order.Quantity))
+
+	quote := order.Symbol[strings.Index(order.Symbol, "/")+1:]
+	available := e.portfolio.Balances(order.Account)[quote].Sub(e.usedMargin(order.Account))
+	if required.GreaterThan(available) {
+		return fmt.Errorf("虚拟账户 %s 可用保证金不足: 需要 %s %s，可用 %s", order.Account, required.StringFixed(2), quote, available.StringFixed(2))
+	}
+	return nil
+}
+
+// usedMargin 统计账户永续合约持仓占用的保证金
+func (e *Executor) usedMargin(account string) decimal.Decimal {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	used := decimal.Zero
+	for _, position := range e.positions {
+		if position.Account == account {
+			used = used.Add(position.Margin)
+		}
+	}
+	return used
+}
+
+// reconcilePerpetuals 按交易对比较本地永续合约持仓与交易所合约持仓，数量带方向，空头为负数
+func (e *Executor) reconcilePerpetuals(tolerance decimal.Decimal, correct bool) ([]reconcile.Mismatch, error) {
+	pairs := e.perpetualPairs()
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	actualPositions, err := newFuturesClient(e.cfg.Exchange, e.httpClient).positions(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Symbol < pairs[j].Symbol })
+	positions := e.GetPositions()
+	mismatches := make([]reconcile.Mismatch, 0)
+	for _, pair := range pairs {
+		local := decimal.Zero
+		accounts := make([]string, 0)
+		for _, position := range positions {
+			if position.Symbol == pair.Symbol && position.Quantity.IsPositive() {
+				local = local.Add(position.signedQuantity())
+				accounts = append(accounts, position.Account)
+			}
+		}
+		actualPosition := actualPositions[market.BinanceSymbol(pair.Symbol)]
+		actual, _ := decimal.NewFromString(actualPosition.PositionAmt)
+		if !reconcile.Exceeds(local, actual, tolerance) {
+			continue
+		}
+
+		mismatch := reconcile.Mismatch{
+			Venue:      metrics.VenueExchange,
+			Asset:      market.BaseAsset(pair.Symbol),
+			Symbol:     pair.Symbol,
+			Accounts:   accounts,
+			Local:      local,
+			Actual:     actual,
+			Difference: actual.Sub(local),
+			DetectedAt: time.Now(),
+		}
+		if correct {
+			if len(accounts) > 1 {
+				mismatch.Note = "持仓分属多个账户，无法确定校正哪个账户"
+			} else {
+				account := e.cfg.Strategy.Account
+				if len(accounts) == 1 {
+					account = accounts[0]
+				}
+				e.correctPerpetualPosition(account, pair.Symbol, actualPosition)
+				mismatch.Corrected = true
+			}
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches, nil
+}
+
+// correctPerpetualPosition 按交易所合约持仓校正账户的永续合约持仓，包括方向、开仓价、杠杆和强平价格
+func (e *Executor) correctPerpetualPosition(account, symbol string, actual futuresPosition) {
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	amount, _ := decimal.NewFromString(actual.PositionAmt)
+
+	e.mutex.Lock()
+	key := risk.PositionKey(account, symbol)
+	position, exists := e.positions[key]
+	if !exists {
+		position = Position{Account: account, Symbol: symbol}
+	}
+	position.Side = risk.SideLong
+	if amount.IsNegative() {
+		position.Side = risk.SideShort
+	}
+	position.Quantity = amount.Abs()
+	position.EntryPrice, _ = decimal.NewFromString(actual.EntryPrice)
+	if markPrice, err := decimal.NewFromString(actual.MarkPrice); err == nil && markPrice.IsPositive() {
+		position.CurrentPrice = markPrice
+	}
+	position.Leverage, _ = decimal.NewFromString(actual.Leverage)
+	position.LiquidationPrice, _ = decimal.NewFromString(actual.LiquidationPrice)
+	position.Margin = decimal.Zero
+	if position.Leverage.IsPositive() {
+		position.Margin = position.EntryPrice.Mul(position.Quantity).Div(position.Leverage)
+	}
+	position.Timestamp = time.Now()
+	if position.Quantity.IsPositive() {
+		e.positions[key] = position
+	} else {
+		delete(e.positions, key)
+	}
+	e.savePosition(key, position)
+	e.mutex.Unlock()
+
+	logrus.Warnf("已按交易所合约持仓校正账户 %s 的 %s 持仓: %s", account, symbol, amount.String())
+	e.syncPosition(position)
+}
@@ -0,0 +1,261 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 订单类型
+const (
+	OrderTypeMarket    = "market"
+	OrderTypeLimit     = "limit"
+	OrderTypeStopLimit = "stop_limit"
+)
+
+// 订单有效期
+const (
+	TimeInForceGTC = "GTC" // 撤单前有效，超过配置的超时时间后撤销未成交部分
+	TimeInForceIOC = "IOC" // 立即成交可成交部分，剩余部分撤销
+	TimeInForceFOK = "FOK" // 全部立即成交，否则撤销
+)
+
+// isLimitType 判断订单是否需要挂单等待价格满足条件
+func isLimitType(orderType string) bool {
+	return orderType == OrderTypeLimit || orderType == OrderTypeStopLimit
+}
+
+// normalizeOrderType 补全订单类型和有效期的默认值并校验
+func (e *Executor) normalizeOrderType(order *Order) error {
+	if order.Type == "" {
+		order.Type = e.cfg.Execution.DefaultOrderType
+	}
+	if order.Type == "" {
+		order.Type = OrderTypeMarket
+	}
+
+	switch order.Type {
+	case OrderTypeMarket:
+		return nil
+	case OrderTypeLimit:
+	case OrderTypeStopLimit:
+		if !order.StopPrice.IsPositive() {
+			return fmt.Errorf("止损限价单需要大于0的触发价格")
+		}
+	default:
+		return fmt.Errorf("未知的订单类型: %s", order.Type)
+	}
+
+	if order.TimeInForce == "" {
+		order.TimeInForce = TimeInForceGTC
+	}
+	switch order.TimeInForce {
+	case TimeInForceGTC:
+		if timeout := e.cfg.Execution.LimitOrderTimeoutSeconds; timeout > 0 {
+			order.ExpiresAt = order.Timestamp.Add(time.Duration(timeout) * time.Second)
+		}
+	case TimeInForceIOC, TimeInForceFOK:
+	default:
+		return fmt.Errorf("未知的订单有效期: %s", order.TimeInForce)
+	}
+	return nil
+}
+
+// placeLimitOrder 挂出限价单或止损限价单，按最新价格尝试立即撮合
+func (e *Executor) placeLimitOrder(order Order) Order {
+	e.matchMutex.Lock()
+	defer e.matchMutex.Unlock()
+
+	order.Status = "pending"
+
+	e.mutex.Lock()
+	e.setOrderLocked(order)
+	lastPrice, hasPrice := e.lastPrices[order.Symbol]
+	e.mutex.Unlock()
+
+	if hasPrice {
+		order = e.matchOrder(order, market.MarketData{
+			Symbol: order.Symbol,
+			High:   lastPrice,
+			Low:    lastPrice,
+			Close:  lastPrice,
+		})
+	}
+
+	// IOC 和 FOK 订单不保留未成交部分
+	if isOpenStatus(order.Status) && order.TimeInForce != TimeInForceGTC {
+		order = e.closeUnfilled(order, "canceled", fmt.Sprintf("%s 订单未能立即全部成交", order.TimeInForce))
+		logrus.Infof("订单 %s (%s) 未能立即全部成交，剩余部分已撤销，已成交: %s",
+			order.ID, order.TimeInForce, order.FilledQuantity.String())
+	}
+
+	e.persistOpenOrders()
+	return order
+}
+
+// HandleData 实现 market.DataHandler 接口，用最新K线撮合挂单中的限价单
+func (e *Executor) HandleData(data market.MarketData) {
+	e.matchMutex.Lock()
+	defer e.matchMutex.Unlock()
+
+	e.mutex.Lock()
+	e.lastPrices[data.Symbol] = data.Close
+	open := make([]Order, 0)
+	for _, order := range e.orders {
+		if order.Symbol == data.Symbol && isLimitType(order.Type) && isOpenStatus(order.Status) && order.Algo == "" {
+			open = append(open, order)
+		}
+	}
+	e.mutex.Unlock()
+
+	if len(open) == 0 {
+		return
+	}
+	for _, order := range open {
+		e.matchOrder(order, data)
+	}
+	e.persistOpenOrders()
+}
+
+// matchOrder 判断限价单在给定价格区间内能否成交，可成交时按限价记录成交（可能为部分成交）
+// 调用方需持有 e.matchMutex，避免同一订单被并发撮合
+func (e *Executor) matchOrder(order Order, data market.MarketData) Order {
+	// 止损限价单价格触及触发价后才转为限价单
+	if order.Type == OrderTypeStopLimit && !order.Triggered {
+		triggered := (order.Direction == "buy" && data.High.GreaterThanOrEqual(order.StopPrice)) ||
+			(order.Direction == "sell" && data.Low.LessThanOrEqual(order.StopPrice))
+		if !triggered {
+			return order
+		}
+		order.Triggered = true
+		e.mutex.Lock()
+		e.setOrderLocked(order)
+		e.mutex.Unlock()
+		logrus.Infof("止损限价单 %s 已触发，触发价: %s", order.ID, order.StopPrice.String())
+	}
+
+	marketable := (order.Direction == "buy" && data.Low.LessThanOrEqual(order.Price)) ||
+		(order.Direction == "sell" && data.High.GreaterThanOrEqual(order.Price))
+	if !marketable {
+		return order
+	}
+
+	remaining := order.Quantity.Sub(order.FilledQuantity)
+	quantity := remaining
+	// 按K线成交量的参与比例限制单次成交数量，模拟部分成交
+	if participation := e.cfg.Execution.LimitFillParticipation; participation > 0 && data.Volume.IsPositive() {
+		quantity = decimal.Min(remaining, data.Volume.Mul(decimal.NewFromFloat(participation)))
+	}
+	if !quantity.IsPositive() {
+		return order
+	}
+	if order.TimeInForce == TimeInForceFOK && quantity.LessThan(remaining) {
+		return order
+	}
+
+	return e.applyFill(order, quantity, order.Price)
+}
+
+// expireLimitOrders 撤销超过有效期仍未全部成交的限价单
+func (e *Executor) expireLimitOrders() {
+	e.matchMutex.Lock()
+	defer e.matchMutex.Unlock()
+
+	now := time.Now()
+	e.mutex.RLock()
+	expired := make([]Order, 0)
+	for _, order := range e.orders {
+		if isOpenStatus(order.Status) && !order.ExpiresAt.IsZero() && now.After(order.ExpiresAt) {
+			expired = append(expired, order)
+		}
+	}
+	e.mutex.RUnlock()
+
+	for _, order := range expired {
+		order = e.closeUnfilled(order, "expired", "超过有效期未全部成交")
+		logrus.Infof("限价单 %s 超时未全部成交，已撤销剩余部分，已成交: %s/%s",
+			order.ID, order.FilledQuantity.String(), order.Quantity.String())
+	}
+	if len(expired) > 0 {
+		e.persistOpenOrders()
+	}
+}
+
+// closeUnfilled 撤销订单的未成交部分，已部分成交的订单保留成交数量
+func (e *Executor) closeUnfilled(order Order, status, reason string) Order {
+	// 在实际应用中，这里应该调用交易所API撤单
+	order.Status = status
+	e.mutex.Lock()
+	e.setOrderLocked(order)
+	e.cancelChildrenLocked(order.ID)
+	e.mutex.Unlock()
+	e.recordOrderEvent(audit.EventOrderCanceled, order, reason)
+	e.recordSlippage(order)
+	return order
+}
+
+// applyFill 记录订单的一笔成交并更新持仓，成交数量达到订单数量时订单变为已成交
+func (e *Executor) applyFill(order Order, quantity, price decimal.Decimal) Order {
+	fillID := order.ID
+	if order.FilledQuantity.IsPositive() || quantity.LessThan(order.Quantity) {
+		fillID = fmt.Sprintf("%s-%d", order.ID, time.Now().UnixNano())
+	}
+
+	fee := e.tradingFee(order, price, quantity)
+	filledValue := order.AvgFillPrice.Mul(order.FilledQuantity).Add(price.Mul(quantity))
+	order.FilledQuantity = order.FilledQuantity.Add(quantity)
+	order.AvgFillPrice = filledValue.Div(order.FilledQuantity)
+	order.Fee = order.Fee.Add(fee)
+	if order.FilledQuantity.GreaterThanOrEqual(order.Quantity) {
+		order.Status = "filled"
+	} else {
+		order.Status = "partially_filled"
+	}
+
+	// 按本次成交的数量和价格更新归因、余额和持仓
+	fill := order
+	fill.Quantity = quantity
+	fill.Price = price
+	fill.Fee = fee
+
+	e.mutex.Lock()
+	e.setOrderLocked(order)
+	e.fillAlgoParentLocked(order.ParentID, quantity, price, fee)
+	e.recordAttribution(fill)
+	e.mutex.Unlock()
+	e.saveFill(fillID, fill)
+	e.applyToPortfolio(fill, fee)
+	e.updatePosition(fill)
+	e.riskManager.RecordFee(order.Account, fee)
+	if order.Status == "filled" {
+		e.recordSlippage(order)
+	}
+	e.recordOrderEvent(audit.EventOrderFilled, fill, "")
+	e.events.Publish(events.Event{
+		Type:    events.EventFill,
+		Account: fill.Account,
+		Symbol:  fill.Symbol,
+		Payload: fill,
+	})
+
+	return order
+}
+
+// recordSlippage 订单成交结束（全部成交，或部分成交后撤销、过期）时，按成交均价与信号价格的偏差记录实际滑点，用于滑点熔断
+// 执行算法的父订单不记录，其成交已由子订单记录
+func (e *Executor) recordSlippage(order Order) {
+	if order.Algo != "" || !order.FilledQuantity.IsPositive() {
+		return
+	}
+	expected := order.SignalPrice
+	if !expected.IsPositive() {
+		expected = order.Price
+	}
+	e.riskManager.RecordFill(order.Symbol, order.Direction, expected, order.AvgFillPrice)
+}
@@ -0,0 +1,66 @@
+package execution
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// basisPoint 一个基点
+var basisPoint = decimal.NewFromFloat(0.0001)
+
+// isPaperTrading 判断是否为模拟交易模式
+func (e *Executor) isPaperTrading() bool {
+	return e.cfg.Execution.PaperTrading.Enabled
+}
+
+// marketFillPrice 返回市价单的成交价格
+// 模拟交易模式下以最新行情价格叠加不利滑点成交，行情未到达时使用订单价格
+func (e *Executor) marketFillPrice(order Order) decimal.Decimal {
+	if !e.isPaperTrading() {
+		return order.Price
+	}
+
+	e.mutex.RLock()
+	price, ok := e.lastPrices[order.Symbol]
+	e.mutex.RUnlock()
+	if !ok {
+		price = order.Price
+	}
+
+	slippage := decimal.NewFromFloat(e.cfg.Execution.PaperTrading.SlippageBps).Mul(basisPoint)
+	if order.Direction == "buy" {
+		return price.Mul(decimal.NewFromInt(1).Add(slippage))
+	}
+	return price.Mul(decimal.NewFromInt(1).Sub(slippage))
+}
+
+// tradingFee 计算订单一笔成交的手续费（计价货币），限价类订单按挂单费率，市价单按吃单费率
+func (e *Executor) tradingFee(order Order, price, quantity decimal.Decimal) decimal.Decimal {
+	return e.fees.ExchangeFee(isLimitType(order.Type), price, quantity)
+}
+
+// checkVirtualBalance 模拟交易模式下检查虚拟余额是否足以支付买入金额和手续费，永续合约检查开仓所需的保证金
+func (e *Executor) checkVirtualBalance(order Order) error {
+	if leverage, ok := e.cfg.PerpetualLeverage(order.Symbol); ok {
+		return e.checkMarginBalance(order, leverage)
+	}
+	if !e.isPaperTrading() || e.portfolio == nil || order.Direction != "buy" {
+		return nil
+	}
+
+	price := order.Price
+	if !isLimitType(order.Type) {
+		price = e.marketFillPrice(order)
+	}
+	cost := price.Mul(order.Quantity)
+	cost = cost.Add(e.tradingFee(order, price, order.Quantity))
+
+	quote := order.Symbol[strings.Index(order.Symbol, "/")+1:]
+	cash := e.portfolio.Balances(order.Account)[quote]
+	if cost.GreaterThan(cash) {
+		return fmt.Errorf("虚拟账户 %s 余额不足: 需要 %s %s，可用 %s", order.Account, cost.StringFixed(2), quote, cash.StringFixed(2))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package execution
+
+import (
+	"sort"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/risk"
+
+	"github.com/shopspring/decimal"
+)
+
+// PerformanceReport 根据订单记录计算的策略实例绩效
+// 以订单创建时间和成交均价计算，重启后从存储加载的订单同样计入
+type PerformanceReport struct {
+	Strategy     string
+	Orders       int             // 有成交的订单数
+	ClosedTrades int             // 平掉持仓的订单数，永续合约空头由买入平仓
+	Wins         int             // 盈利的平仓订单数
+	WinRate      float64         // 盈利平仓订单的占比，0-1
+	AvgReturn    float64         // 平仓订单的平均收益率，相对平仓部分的持仓成本
+	RealizedPnL  decimal.Decimal // 已实现盈亏，已扣除买卖双方的手续费
+	Fees         decimal.Decimal // 累计手续费
+	AvgHoldTime  time.Duration   // 按平仓数量加权的平均持仓时长
+	MaxDrawdown  decimal.Decimal // 已实现盈亏曲线相对历史峰值的最大回撤
+	OpenQty      map[string]decimal.Decimal
+	FirstTrade   time.Time
+	LastTrade    time.Time
+}
+
+// reportLot 计算绩效时的虚拟持仓，多头的持仓成本包含开仓手续费，空头为扣除开仓手续费后的卖出所得
+type reportLot struct {
+	symbol   string
+	short    bool // 永续合约空头
+	quantity decimal.Decimal
+	cost     decimal.Decimal
+	openedAt time.Time // 按数量加权的建仓时间
+}
+
+// StrategyOrders 获取策略实例有成交的订单，按下单时间从早到晚排列
+func (e *Executor) StrategyOrders(name string) []Order {
+	return e.filledOrders(func(order Order) bool {
+		return order.StrategyName == name
+	})
+}
+
+// GetPerformanceReport 根据订单记录计算策略实例的绩效，按账户和交易对分别跟踪持仓成本
+func (e *Executor) GetPerformanceReport(name string) PerformanceReport {
+	return buildPerformanceReport(e.cfg, name, e.StrategyOrders(name))
+}
+
+// RealizedPnL 根据订单记录计算账户的累计已实现盈亏，实现 portfolio.RealizedPnLSource 接口
+func (e *Executor) RealizedPnL(account string) decimal.Decimal {
+	orders := e.filledOrders(func(order Order) bool {
+		return order.Account == account
+	})
+	return buildPerformanceReport(e.cfg, "", orders).RealizedPnL
+}
+
+// filledOrders 获取满足条件且有成交的订单，按下单时间从早到晚排列
+// 执行算法的父订单不计入，其成交已包含在子订单中
+func (e *Executor) filledOrders(match func(Order) bool) []Order {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	orders := make([]Order, 0)
+	for _, order := range e.orders {
+		if order.FilledQuantity.IsPositive() && order.Algo == "" && match(order) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Timestamp.Before(orders[j].Timestamp)
+	})
+	return orders
+}
+
+// buildPerformanceReport 按时间顺序回放订单计算绩效，orders 需按时间排列
+// 现货卖出只平掉已有的多头，永续合约反向成交先平仓，超出部分反向开仓，OpenQty 中空头为负数
+func buildPerformanceReport(cfg *config.Config, name string, orders []Order) PerformanceReport {
+	report := PerformanceReport{
+		Strategy:    name,
+		RealizedPnL: decimal.Zero,
+		Fees:        decimal.Zero,
+		MaxDrawdown: decimal.Zero,
+		OpenQty:     make(map[string]decimal.Decimal),
+	}
+
+	lots := make(map[string]reportLot)
+	peak := decimal.Zero
+	var totalReturn, heldQty, heldSeconds float64
+	for _, order := range orders {
+		quantity, price := order.FilledQuantity, order.AvgFillPrice
+		report.Orders++
+		report.Fees = report.Fees.Add(order.Fee)
+		if report.FirstTrade.IsZero() {
+			report.FirstTrade = order.Timestamp
+		}
+		report.LastTrade = order.Timestamp
+
+		key := risk.PositionKey(order.Account, order.Symbol)
+		lot := lots[key]
+		lot.symbol = order.Symbol
+		_, perpetual := cfg.PerpetualLeverage(order.Symbol)
+		short := order.Direction == "sell"
+
+		remaining := quantity
+		if lot.quantity.IsPositive() && lot.short != short {
+			closed := decimal.Min(lot.quantity, quantity)
+			basis := lot.cost.Mul(closed).Div(lot.quantity)
+			fee := order.Fee.Mul(closed).Div(quantity)
+			pnl := price.Mul(closed).Sub(fee).Sub(basis)
+			if lot.short {
+				pnl = basis.Sub(price.Mul(closed)).Sub(fee)
+			}
+
+			report.ClosedTrades++
+			if pnl.IsPositive() {
+				report.Wins++
+			}
+			if basis.IsPositive() {
+				totalReturn += pnl.Div(basis).InexactFloat64()
+			}
+			heldQty += closed.InexactFloat64()
+			heldSeconds += order.Timestamp.Sub(lot.openedAt).Seconds() * closed.InexactFloat64()
+
+			report.RealizedPnL = report.RealizedPnL.Add(pnl)
+			if report.RealizedPnL.GreaterThan(peak) {
+				peak = report.RealizedPnL
+			}
+			if drawdown := peak.Sub(report.RealizedPnL); drawdown.GreaterThan(report.MaxDrawdown) {
+				report.MaxDrawdown = drawdown
+			}
+
+			lot.cost = lot.cost.Sub(basis)
+			lot.quantity = lot.quantity.Sub(closed)
+			remaining = quantity.Sub(closed)
+		}
+
+		// 卖出的不是本策略买入的现货持仓时不计入
+		opens := !short || perpetual
+		if remaining.IsPositive() && opens && (!lot.quantity.IsPositive() || lot.short == short) {
+			if lot.quantity.IsPositive() {
+				weight := remaining.Div(lot.quantity.Add(remaining)).InexactFloat64()
+				lot.openedAt = lot.openedAt.Add(time.Duration(float64(order.Timestamp.Sub(lot.openedAt)) * weight))
+			} else {
+				lot.openedAt = order.Timestamp
+			}
+			fee := order.Fee
+			if remaining.LessThan(quantity) {
+				fee = order.Fee.Mul(remaining).Div(quantity)
+			}
+			// 多头成本加上开仓手续费，空头的成本为扣除手续费后的卖出所得
+			if short {
+				fee = fee.Neg()
+			}
+			lot.short = short
+			lot.quantity = lot.quantity.Add(remaining)
+			lot.cost = lot.cost.Add(price.Mul(remaining)).Add(fee)
+		}
+
+		if lot.quantity.IsPositive() {
+			lots[key] = lot
+		} else {
+			delete(lots, key)
+		}
+	}
+
+	for _, lot := range lots {
+		quantity := lot.quantity
+		if lot.short {
+			quantity = quantity.Neg()
+		}
+		report.OpenQty[lot.symbol] = report.OpenQty[lot.symbol].Add(quantity)
+	}
+	if report.ClosedTrades > 0 {
+		report.WinRate = float64(report.Wins) / float64(report.ClosedTrades)
+		report.AvgReturn = totalReturn / float64(report.ClosedTrades)
+	}
+	if heldQty > 0 {
+		report.AvgHoldTime = time.Duration(heldSeconds / heldQty * float64(time.Second))
+	}
+	return report
+}
@@ -0,0 +1,43 @@
+package execution
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCanaryPerformanceTrackedSeparately(t *testing.T) {
+	e := testExecutor(t, &config.Config{})
+	trade := func(name, direction string, price, quantity int64) {
+		t.Helper()
+		e.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(price), High: decimal.NewFromInt(price), Low: decimal.NewFromInt(price)})
+		if _, err := e.SubmitSignal(strategy.Signal{
+			Symbol:       "BTC/USDT",
+			Direction:    direction,
+			Price:        decimal.NewFromInt(price),
+			Quantity:     decimal.NewFromInt(quantity),
+			StrategyName: name,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 当前实例与金丝雀实例同时买入，金丝雀实例数量为 1/4，之后同价卖出
+	trade("ma", "buy", 100, 4)
+	trade("ma:canary", "buy", 100, 1)
+	trade("ma", "sell", 110, 4)
+	trade("ma:canary", "sell", 110, 1)
+
+	current := e.GetPerformanceReport("ma")
+	canary := e.GetPerformanceReport("ma:canary")
+	if current.Orders != 2 || canary.Orders != 2 {
+		t.Fatalf("两个实例的订单应分别统计: 当前 %d，金丝雀 %d", current.Orders, canary.Orders)
+	}
+	if !current.RealizedPnL.Equal(decimal.NewFromInt(40)) || !canary.RealizedPnL.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("已实现盈亏应按实例分别计算: 当前 %s，金丝雀 %s", current.RealizedPnL, canary.RealizedPnL)
+	}
+}
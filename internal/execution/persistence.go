@@ -0,0 +1,146 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"autotransaction/internal/store"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Fill 表示一笔成交记录
+type Fill struct {
+	OrderID   string
+	Account   string
+	Symbol    string
+	Direction string
+	Price     decimal.Decimal
+	Quantity  decimal.Decimal
+	Fee       decimal.Decimal // 手续费（计价货币）
+	Timestamp time.Time
+}
+
+// SetStore 设置持久化存储，订单、成交和持仓将在重启后保留
+func (e *Executor) SetStore(s store.Store) {
+	e.store = s
+}
+
+// loadHistory 从存储中加载订单和持仓，并将持仓同步给风险管理器
+func (e *Executor) loadHistory() error {
+	if e.store == nil {
+		return nil
+	}
+
+	orders := make(map[string]Order)
+	err := e.store.Load(store.CollectionOrders, func(id string, decode func(v interface{}) error) error {
+		var order Order
+		if err := decode(&order); err != nil {
+			return fmt.Errorf("解析订单 %s 失败: %v", id, err)
+		}
+		orders[id] = order
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	positions := make(map[string]Position)
+	err = e.store.Load(store.CollectionPositions, func(id string, decode func(v interface{}) error) error {
+		var position Position
+		if err := decode(&position); err != nil {
+			return fmt.Errorf("解析持仓 %s 失败: %v", id, err)
+		}
+		positions[id] = position
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	for id, order := range orders {
+		e.orders[id] = order
+		e.indexClientOrderLocked(order)
+	}
+	for key, position := range positions {
+		e.positions[key] = position
+	}
+	e.mutex.Unlock()
+
+	for _, position := range positions {
+		e.riskManager.UpdatePosition(position.riskPosition())
+	}
+
+	logrus.Infof("已从存储加载 %d 个订单和 %d 个持仓", len(orders), len(positions))
+	return nil
+}
+
+// setOrderLocked 更新订单并持久化，调用方需持有 e.mutex 写锁
+func (e *Executor) setOrderLocked(order Order) {
+	e.orders[order.ID] = order
+	e.indexClientOrderLocked(order)
+	if e.store == nil {
+		return
+	}
+	if err := e.store.Put(store.CollectionOrders, order.ID, order); err != nil {
+		logrus.Errorf("保存订单 %s 失败: %v", order.ID, err)
+	}
+}
+
+// saveFill 持久化成交记录，部分成交的订单每笔成交使用不同的 fillID
+func (e *Executor) saveFill(fillID string, order Order) {
+	if e.store == nil {
+		return
+	}
+
+	fill := Fill{
+		OrderID:   order.ID,
+		Account:   order.Account,
+		Symbol:    order.Symbol,
+		Direction: order.Direction,
+		Price:     order.Price,
+		Quantity:  order.Quantity,
+		Fee:       order.Fee,
+		Timestamp: time.Now(),
+	}
+	if err := e.store.Put(store.CollectionFills, fillID, fill); err != nil {
+		logrus.Errorf("保存成交记录 %s 失败: %v", fillID, err)
+	}
+}
+
+// savePosition 持久化持仓，数量为0时删除
+func (e *Executor) savePosition(key string, position Position) {
+	if e.store == nil {
+		return
+	}
+
+	var err error
+	if position.Quantity.IsPositive() {
+		err = e.store.Put(store.CollectionPositions, key, position)
+	} else {
+		err = e.store.Delete(store.CollectionPositions, key)
+	}
+	if err != nil {
+		logrus.Errorf("保存持仓 %s 失败: %v", key, err)
+	}
+}
+
+// GetFills 获取所有成交记录
+func (e *Executor) GetFills() ([]Fill, error) {
+	fills := make([]Fill, 0)
+	if e.store == nil {
+		return fills, nil
+	}
+
+	err := e.store.Load(store.CollectionFills, func(id string, decode func(v interface{}) error) error {
+		var fill Fill
+		if err := decode(&fill); err != nil {
+			return fmt.Errorf("解析成交记录 %s 失败: %v", id, err)
+		}
+		fills = append(fills, fill)
+		return nil
+	})
+	return fills, err
+}
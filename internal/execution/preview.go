@@ -0,0 +1,48 @@
+package execution
+
+import (
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// Preview 交易所订单的模拟执行结果（dry-run），只做检查和估算，不下单
+type Preview struct {
+	Order         Order           // 按订单类型和交易规则调整后的订单
+	RiskError     string          // 未通过风险检查的原因
+	Error         string          // 未通过下单前检查（订单类型、滑点、交易规则、虚拟余额）的原因
+	ExpectedPrice decimal.Decimal // 预计成交价格，限价类订单为限价
+	Notional      decimal.Decimal // 预计成交额
+	Fee           decimal.Decimal // 预计手续费（计价货币），限价类订单按挂单费率
+}
+
+// Accepted 判断信号是否通过全部检查，实际提交时会下单
+func (p Preview) Accepted() bool {
+	return p.RiskError == "" && p.Error == ""
+}
+
+// PreviewSignal 对信号执行与 SubmitSignal 相同的检查并估算成交价格和手续费，不下单也不记录审计日志
+func (e *Executor) PreviewSignal(signal strategy.Signal) Preview {
+	account := signal.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	preview := Preview{Order: newOrder(signal, account)}
+	if err := e.riskManager.PreviewSignal(signal); err != nil {
+		preview.RiskError = err.Error()
+	}
+	if err := e.prepareOrder(&preview.Order, signal); err != nil {
+		preview.Error = err.Error()
+	}
+
+	order := preview.Order
+	preview.ExpectedPrice = order.Price
+	if !isLimitType(order.Type) {
+		preview.ExpectedPrice = e.marketFillPrice(order)
+	}
+	preview.Notional = preview.ExpectedPrice.Mul(order.Quantity)
+	preview.Fee = e.tradingFee(order, preview.ExpectedPrice, order.Quantity)
+	return preview
+}
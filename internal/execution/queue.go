@@ -0,0 +1,351 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/events"
+	"autotransaction/internal/strategy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 订单任务的优先级，数值越大越先执行
+const (
+	PriorityEntry      = 0 // 开仓
+	PriorityExit       = 1 // 策略平仓
+	PriorityForcedExit = 2 // 风险管理器触发的强制平仓
+)
+
+// 订单队列的默认配置
+const (
+	defaultMaxQueued      = 1000
+	defaultMaxAttempts    = 5
+	defaultRetryBackoff   = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultDeadLetterSize = 100
+)
+
+// ErrTransient 暂时性错误，如交易所或节点不可达，订单任务稍后重试
+var ErrTransient = errors.New("暂时性错误")
+
+// ErrJobNotFound 死信队列中不存在该任务
+var ErrJobNotFound = errors.New("任务不存在")
+
+// jobSequence 保证同一纳秒内生成的任务ID唯一
+var jobSequence uint64
+
+// Job 订单队列中的一个任务，由一个信号产生
+type Job struct {
+	ID          string          `json:"id"`
+	Signal      strategy.Signal `json:"signal"`
+	Priority    int             `json:"priority"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	EnqueuedAt  time.Time       `json:"enqueuedAt"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+	FailedAt    time.Time       `json:"failedAt"` // 进入死信队列的时间
+}
+
+// JobQueue 按优先级依次执行信号的订单队列，交易执行器和区块链交易执行器各使用一个
+// submit 返回包装了 ErrTransient 的错误时按指数退避重试，重试用尽或强制平仓失败时进入死信队列
+type JobQueue struct {
+	cfg         *config.Config
+	submit      func(signal strategy.Signal) error
+	events      *events.Bus // 为nil时不发布事件
+	queue       []*Job
+	deadLetters []*Job // 重试用尽或强制平仓失败的任务
+	wake        chan struct{}
+	mutex       sync.Mutex
+}
+
+// NewJobQueue 创建订单队列，submit 执行一个信号
+func NewJobQueue(cfg *config.Config, submit func(signal strategy.Signal) error) *JobQueue {
+	return &JobQueue{
+		cfg:    cfg,
+		submit: submit,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// SetEventBus 设置事件总线，任务进入死信队列时发布事件
+func (q *JobQueue) SetEventBus(bus *events.Bus) {
+	q.events = bus
+}
+
+// signalPriority 按信号类型确定任务优先级：强制平仓 > 平仓 > 开仓
+func signalPriority(signal strategy.Signal) int {
+	switch {
+	case signal.Forced:
+		return PriorityForcedExit
+	case signal.Direction == "sell":
+		return PriorityExit
+	default:
+		return PriorityEntry
+	}
+}
+
+// Enqueue 将信号作为任务加入订单队列，队列已满时拒绝开仓信号，平仓信号总是入队
+func (q *JobQueue) Enqueue(signal strategy.Signal) error {
+	maxQueued := q.cfg.Execution.Queue.MaxQueued
+	if maxQueued <= 0 {
+		maxQueued = defaultMaxQueued
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          fmt.Sprintf("JOB-%d-%d", now.UnixNano(), atomic.AddUint64(&jobSequence, 1)),
+		Signal:      signal,
+		Priority:    signalPriority(signal),
+		EnqueuedAt:  now,
+		NextAttempt: now,
+	}
+
+	q.mutex.Lock()
+	if len(q.queue) >= maxQueued && job.Priority == PriorityEntry {
+		q.mutex.Unlock()
+		return fmt.Errorf("订单队列已满(%d)", maxQueued)
+	}
+	q.queue = append(q.queue, job)
+	q.mutex.Unlock()
+
+	q.wakeUp()
+	return nil
+}
+
+// wakeUp 通知队列处理协程有新的任务
+func (q *JobQueue) wakeUp() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextJob 取出已到执行时间的优先级最高的任务，同优先级按入队顺序；没有可执行任务时返回下一个任务的等待时间
+func (q *JobQueue) nextJob() (*Job, time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+	best := -1
+	wait := time.Duration(-1)
+	for i, job := range q.queue {
+		if job.NextAttempt.After(now) {
+			if until := job.NextAttempt.Sub(now); wait < 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+		if best < 0 || job.Priority > q.queue[best].Priority ||
+			(job.Priority == q.queue[best].Priority && job.EnqueuedAt.Before(q.queue[best].EnqueuedAt)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, wait
+	}
+
+	job := q.queue[best]
+	q.queue = append(q.queue[:best], q.queue[best+1:]...)
+	return job, 0
+}
+
+// Run 依次执行订单队列中的任务直到 ctx 取消，同一时间只执行一个任务以保证优先级顺序
+func (q *JobQueue) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		job, wait := q.nextJob()
+		if job != nil {
+			q.runJob(job)
+			continue
+		}
+
+		if wait < 0 {
+			wait = time.Hour
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// runJob 执行一个任务：暂时性失败时按指数退避重新入队，重试用尽或强制平仓失败时进入死信队列
+func (q *JobQueue) runJob(job *Job) {
+	job.Attempts++
+	err := q.submit(job.Signal)
+	if err == nil {
+		return
+	}
+	job.LastError = err.Error()
+
+	queueCfg := q.cfg.Execution.Queue
+	maxAttempts := queueCfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if errors.Is(err, ErrTransient) && job.Attempts < maxAttempts {
+		backoff := q.retryBackoff(job.Attempts)
+		job.NextAttempt = time.Now().Add(backoff)
+		logrus.Warnf("订单任务 %s (%s %s) 第 %d 次执行失败，%s 后重试: %v",
+			job.ID, job.Signal.Symbol, job.Signal.Direction, job.Attempts, backoff, err)
+
+		q.mutex.Lock()
+		q.queue = append(q.queue, job)
+		q.mutex.Unlock()
+		return
+	}
+
+	if errors.Is(err, ErrTransient) || job.Signal.Forced {
+		q.deadLetter(job)
+		return
+	}
+	logrus.Warnf("信号 %s %s 已拒绝: %v", job.Signal.Symbol, job.Signal.Direction, err)
+}
+
+// retryBackoff 返回第 attempts 次失败后的重试等待时间，每次翻倍，不超过上限
+func (q *JobQueue) retryBackoff(attempts int) time.Duration {
+	queueCfg := q.cfg.Execution.Queue
+	backoff := time.Duration(queueCfg.RetryBackoffSeconds) * time.Second
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff := time.Duration(queueCfg.MaxBackoffSeconds) * time.Second
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// deadLetter 将失败的任务放入死信队列并发布告警事件，超出保留数量时丢弃最早的任务
+func (q *JobQueue) deadLetter(job *Job) {
+	size := q.cfg.Execution.Queue.DeadLetterSize
+	if size <= 0 {
+		size = defaultDeadLetterSize
+	}
+	job.FailedAt = time.Now()
+
+	q.mutex.Lock()
+	q.deadLetters = append(q.deadLetters, job)
+	if len(q.deadLetters) > size {
+		q.deadLetters = q.deadLetters[len(q.deadLetters)-size:]
+	}
+	q.mutex.Unlock()
+
+	logrus.Errorf("告警: 订单任务 %s (%s %s) 执行 %d 次后失败，已进入死信队列: %s",
+		job.ID, job.Signal.Symbol, job.Signal.Direction, job.Attempts, job.LastError)
+	q.events.Publish(events.Event{
+		Type:    events.EventDeadLetter,
+		Account: job.Signal.Account,
+		Symbol:  job.Signal.Symbol,
+		Payload: *job,
+	})
+}
+
+// DropQueued 丢弃等待执行的非强制平仓任务，返回丢弃的数量
+func (q *JobQueue) DropQueued() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	kept := q.queue[:0]
+	dropped := 0
+	for _, job := range q.queue {
+		if job.Signal.Forced {
+			kept = append(kept, job)
+			continue
+		}
+		dropped++
+	}
+	q.queue = kept
+	return dropped
+}
+
+// Jobs 返回订单队列中等待执行的任务
+func (q *JobQueue) Jobs() []Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(q.queue))
+	for _, job := range q.queue {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// DeadLetters 返回死信队列中的任务，最近失败的在后
+func (q *JobQueue) DeadLetters() []Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(q.deadLetters))
+	for _, job := range q.deadLetters {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// RetryDeadLetter 将死信队列中的任务重新放入订单队列，重新计算尝试次数
+func (q *JobQueue) RetryDeadLetter(id string) (Job, error) {
+	q.mutex.Lock()
+	for i, job := range q.deadLetters {
+		if job.ID != id {
+			continue
+		}
+		q.deadLetters = append(q.deadLetters[:i], q.deadLetters[i+1:]...)
+		job.Attempts = 0
+		job.FailedAt = time.Time{}
+		job.NextAttempt = time.Now()
+		q.queue = append(q.queue, job)
+		q.mutex.Unlock()
+
+		logrus.Infof("死信任务 %s 已重新提交", id)
+		q.wakeUp()
+		return *job, nil
+	}
+	q.mutex.Unlock()
+	return Job{}, ErrJobNotFound
+}
+
+// QueuedJobs 返回订单队列中等待执行的任务
+func (e *Executor) QueuedJobs() []Job {
+	return e.jobs.Jobs()
+}
+
+// DeadLetters 返回死信队列中的任务，最近失败的在后
+func (e *Executor) DeadLetters() []Job {
+	return e.jobs.DeadLetters()
+}
+
+// RetryDeadLetter 将死信队列中的任务重新放入订单队列，重新计算尝试次数
+func (e *Executor) RetryDeadLetter(id string) (Job, error) {
+	return e.jobs.RetryDeadLetter(id)
+}
+
+// submitJob 执行订单队列中的信号
+func (e *Executor) submitJob(signal strategy.Signal) error {
+	_, err := e.SubmitSignal(signal)
+	return err
+}
@@ -0,0 +1,185 @@
+package execution
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/metrics"
+	"autotransaction/internal/reconcile"
+	"autotransaction/internal/risk"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// accountResponse 交易所账户信息接口的响应
+type accountResponse struct {
+	Balances []struct {
+		Asset  string `json:"asset"`
+		Free   string `json:"free"`
+		Locked string `json:"locked"`
+	} `json:"balances"`
+}
+
+// fetchExchangeBalances 查询交易所账户各资产的余额（可用与冻结之和），请求以 HMAC-SHA256 签名
+func (e *Executor) fetchExchangeBalances() (map[string]decimal.Decimal, error) {
+	exchangeCfg := e.cfg.Exchange
+	query := url.Values{}
+	query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	query.Set("recvWindow", "5000")
+	mac := hmac.New(sha256.New, []byte(exchangeCfg.APISecret))
+	mac.Write([]byte(query.Encode()))
+	query.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	request, err := http.NewRequestWithContext(e.ctx, http.MethodGet,
+		strings.TrimRight(exchangeCfg.BaseURL, "/")+"/api/v3/account?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-MBX-APIKEY", exchangeCfg.APIKey)
+
+	resp, err := e.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("请求交易所账户余额失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取账户余额响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("交易所返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	var account accountResponse
+	if err := json.Unmarshal(body, &account); err != nil {
+		return nil, fmt.Errorf("解析账户余额失败: %v", err)
+	}
+	balances := make(map[string]decimal.Decimal, len(account.Balances))
+	for _, balance := range account.Balances {
+		free, _ := decimal.NewFromString(balance.Free)
+		locked, _ := decimal.NewFromString(balance.Locked)
+		balances[balance.Asset] = free.Add(locked)
+	}
+	return balances, nil
+}
+
+// ReconcilePositions 实现 reconcile.Source 接口，按资产比较本地现货持仓合计与交易所账户余额，
+// 永续合约按交易对比较本地持仓与交易所合约持仓；模拟行情、模拟交易或未配置API密钥时返回 reconcile.ErrUnavailable
+func (e *Executor) ReconcilePositions(tolerance decimal.Decimal, correct bool) ([]reconcile.Mismatch, error) {
+	exchangeCfg := e.cfg.Exchange
+	if exchangeCfg.MockMode || e.isPaperTrading() || exchangeCfg.APIKey == "" || exchangeCfg.APISecret == "" {
+		return nil, fmt.Errorf("%w: 模拟模式或未配置交易所API密钥", reconcile.ErrUnavailable)
+	}
+	balances, err := e.fetchExchangeBalances()
+	if err != nil {
+		return nil, err
+	}
+
+	// 资产 -> 交易该资产的交易对
+	symbols := make(map[string][]string)
+	for _, pair := range e.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || pair.Blockchain != "" || pair.IsPerpetual() {
+			continue
+		}
+		asset := market.BaseAsset(pair.Symbol)
+		symbols[asset] = append(symbols[asset], pair.Symbol)
+	}
+
+	positions := e.GetPositions()
+	assets := make([]string, 0, len(symbols))
+	for asset := range symbols {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	mismatches := make([]reconcile.Mismatch, 0)
+	for _, asset := range assets {
+		local := decimal.Zero
+		accounts := make([]string, 0)
+		for _, position := range positions {
+			if market.BaseAsset(position.Symbol) == asset && position.Side == "" && position.Quantity.IsPositive() {
+				local = local.Add(position.Quantity)
+				accounts = append(accounts, position.Account)
+			}
+		}
+		actual := balances[asset]
+		if !reconcile.Exceeds(local, actual, tolerance) {
+			continue
+		}
+
+		mismatch := reconcile.Mismatch{
+			Venue:      metrics.VenueExchange,
+			Asset:      asset,
+			Accounts:   accounts,
+			Local:      local,
+			Actual:     actual,
+			Difference: actual.Sub(local),
+			DetectedAt: time.Now(),
+		}
+		if len(symbols[asset]) == 1 {
+			mismatch.Symbol = symbols[asset][0]
+		}
+		if correct {
+			switch {
+			case mismatch.Symbol == "":
+				mismatch.Note = "该资产对应多个交易对，无法确定校正哪个持仓"
+			case len(accounts) > 1:
+				mismatch.Note = "持仓分属多个账户，无法确定校正哪个账户"
+			default:
+				account := e.cfg.Strategy.Account
+				if len(accounts) == 1 {
+					account = accounts[0]
+				}
+				e.correctPosition(account, mismatch.Symbol, actual)
+				mismatch.Corrected = true
+			}
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	perpetuals, err := e.reconcilePerpetuals(tolerance, correct)
+	if err != nil {
+		return mismatches, fmt.Errorf("永续合约持仓对账失败: %v", err)
+	}
+	return append(mismatches, perpetuals...), nil
+}
+
+// correctPosition 按交易所实际余额校正账户的持仓数量，新发现的持仓成本未知，EntryPrice 记为0
+func (e *Executor) correctPosition(account, symbol string, quantity decimal.Decimal) {
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	e.mutex.Lock()
+	key := risk.PositionKey(account, symbol)
+	position, exists := e.positions[key]
+	if !exists {
+		position = Position{Account: account, Symbol: symbol}
+	}
+	position.Quantity = quantity
+	position.Timestamp = time.Now()
+	if quantity.IsPositive() {
+		e.positions[key] = position
+	} else {
+		delete(e.positions, key)
+	}
+	e.savePosition(key, position)
+	e.mutex.Unlock()
+
+	logrus.Warnf("已按交易所余额校正账户 %s 的 %s 持仓: %s", account, symbol, quantity.String())
+	e.syncPosition(position)
+}
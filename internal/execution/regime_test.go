@@ -0,0 +1,38 @@
+package execution
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrdersGroupedBySignalRegime(t *testing.T) {
+	e := testExecutor(t, &config.Config{})
+	e.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), High: decimal.NewFromInt(100), Low: decimal.NewFromInt(100)})
+
+	for _, regime := range []string{strategy.RegimeTrending, strategy.RegimeRanging, strategy.RegimeTrending} {
+		order, err := e.SubmitSignal(strategy.Signal{
+			Symbol:    "BTC/USDT",
+			Direction: "buy",
+			Price:     decimal.NewFromInt(100),
+			Quantity:  decimal.NewFromInt(1),
+			Regime:    regime,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order.Regime != regime {
+			t.Fatalf("订单应标记信号的市场状态 %s，实际 %s", regime, order.Regime)
+		}
+	}
+
+	byRegime := e.GetOrdersByRegime()
+	if len(byRegime[strategy.RegimeTrending]) != 2 || len(byRegime[strategy.RegimeRanging]) != 1 {
+		t.Fatalf("已成交订单应按市场状态分组: trending %d，ranging %d",
+			len(byRegime[strategy.RegimeTrending]), len(byRegime[strategy.RegimeRanging]))
+	}
+}
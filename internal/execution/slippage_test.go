@@ -0,0 +1,114 @@
+package execution
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// testExecutor 返回不连接交易所、不持久化的执行器
+func testExecutor(t *testing.T, cfg *config.Config) *Executor {
+	t.Helper()
+	cfg.Risk.MaxPositionSize = 1000
+	cfg.Risk.MaxOpenPositions = 10
+	cfg.System.DataDir = t.TempDir()
+	e := NewExecutor(cfg, risk.NewRiskManager(cfg))
+	t.Cleanup(e.Stop)
+	return e
+}
+
+func TestRealizedFillSlippageTripsBreaker(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 2, MaxAvgSlippage: 0.5}
+	cfg.Execution.PaperTrading = config.PaperTradingConfig{Enabled: true, SlippageBps: 100}
+	e := testExecutor(t, cfg)
+	e.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), High: decimal.NewFromInt(100), Low: decimal.NewFromInt(100)})
+
+	for i := 0; i < 2; i++ {
+		order, err := e.SubmitSignal(strategy.Signal{
+			Symbol:    "BTC/USDT",
+			Direction: "buy",
+			Price:     decimal.NewFromInt(100),
+			Quantity:  decimal.NewFromInt(1),
+		})
+		if err != nil {
+			t.Fatalf("第 %d 笔下单失败: %v", i+1, err)
+		}
+		if !order.AvgFillPrice.Equal(decimal.NewFromInt(101)) {
+			t.Fatalf("模拟成交应有 1%% 的不利滑点，成交均价 %s", order.AvgFillPrice)
+		}
+	}
+	if !e.riskManager.IsSlippageHalted("BTC/USDT") {
+		t.Fatal("按实际成交均价统计的滑点超过阈值时应熔断")
+	}
+}
+
+func TestPartialLimitFillRecordsSlippageOnCancel(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 1, MaxAvgSlippage: 0.5}
+	cfg.Execution.LimitFillParticipation = 0.5
+	e := testExecutor(t, cfg)
+
+	// 信号价格 100，限价 102 挂单，部分成交后撤单
+	order := newOrder(strategy.Signal{
+		Symbol:    "BTC/USDT",
+		Direction: "buy",
+		Price:     decimal.NewFromInt(100),
+		Quantity:  decimal.NewFromInt(2),
+		OrderType: OrderTypeLimit,
+	}, config.DefaultAccountID)
+	order.Price = decimal.NewFromInt(102)
+	if err := e.normalizeOrderType(&order); err != nil {
+		t.Fatal(err)
+	}
+	e.executeOrder(order)
+	e.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(101), High: decimal.NewFromInt(101), Low: decimal.NewFromInt(101), Volume: decimal.NewFromInt(1)})
+	if e.riskManager.IsSlippageHalted("BTC/USDT") {
+		t.Fatal("订单仍在挂单中时不应记录滑点")
+	}
+	if err := e.CancelOrder(order.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !e.riskManager.IsSlippageHalted("BTC/USDT") {
+		t.Fatal("部分成交后撤单应按成交均价记录滑点")
+	}
+}
+
+func TestFailedProbeOrderReleasesProbe(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 1, MaxAvgSlippage: 0.5}
+	cfg.Risk.SlippageTolerance = 1
+	e := testExecutor(t, cfg)
+	const symbol = "BTC/USDT"
+	signal := strategy.Signal{
+		Symbol:    symbol,
+		Direction: "buy",
+		Price:     decimal.NewFromInt(100),
+		Quantity:  decimal.NewFromInt(1),
+	}
+
+	e.riskManager.RecordFill(symbol, "buy", decimal.NewFromInt(100), decimal.NewFromInt(102))
+	if !e.riskManager.ProbeSymbol(symbol) {
+		t.Fatal("熔断的交易对应能进入试单模式")
+	}
+
+	// 最新报价偏离信号价格 10%，试单在下单前被拒绝
+	e.HandleData(market.MarketData{Symbol: symbol, Close: decimal.NewFromInt(110), High: decimal.NewFromInt(110), Low: decimal.NewFromInt(110)})
+	if _, err := e.SubmitSignal(signal); err == nil {
+		t.Fatal("报价滑点超过容忍度时试单应失败")
+	}
+
+	// 失败的试单不应占用试单，下一笔信号仍可试单，成交滑点正常后恢复交易
+	e.HandleData(market.MarketData{Symbol: symbol, Close: decimal.NewFromInt(100), High: decimal.NewFromInt(100), Low: decimal.NewFromInt(100)})
+	if _, err := e.SubmitSignal(signal); err != nil {
+		t.Fatalf("试单失败后应允许重新试单: %v", err)
+	}
+	if e.riskManager.IsSlippageHalted(symbol) {
+		t.Fatal("重新试单滑点正常后应恢复交易")
+	}
+}
@@ -0,0 +1,252 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// SymbolRules 表示交易对的下单规则
+type SymbolRules struct {
+	TickSize    decimal.Decimal // 价格最小变动单位
+	StepSize    decimal.Decimal // 数量最小变动单位
+	MinNotional decimal.Decimal // 最小名义价值
+}
+
+// exchangeInfoResponse 交易所 exchangeInfo 接口的响应（仅包含需要的字段）
+type exchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol  string                   `json:"symbol"`
+		Filters []map[string]interface{} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// loadSymbolRules 从交易所获取并缓存交易规则
+func (e *Executor) loadSymbolRules() error {
+	symbols := make([]string, 0)
+	for _, pair := range e.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || pair.Blockchain != "" || pair.IsPerpetual() {
+			continue
+		}
+		symbols = append(symbols, market.BinanceSymbol(pair.Symbol))
+	}
+
+	if len(symbols) == 0 {
+		return e.storeSymbolRules(make(map[string]SymbolRules))
+	}
+
+	symbolsJSON, err := json.Marshal(symbols)
+	if err != nil {
+		return fmt.Errorf("交易对列表序列化失败: %v", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v3/exchangeInfo?symbols=%s",
+		strings.TrimRight(e.cfg.Exchange.BaseURL, "/"), url.QueryEscape(string(symbolsJSON)))
+
+	resp, err := e.httpClient.Get(apiURL)
+	if err != nil {
+		return fmt.Errorf("请求交易所交易规则失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取交易规则响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("交易所返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	var info exchangeInfoResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return fmt.Errorf("解析交易规则失败: %v", err)
+	}
+
+	rules := make(map[string]SymbolRules)
+	for _, symbol := range info.Symbols {
+		rules[symbol.Symbol] = parseSymbolFilters(symbol.Filters)
+	}
+	return e.storeSymbolRules(rules)
+}
+
+// storeSymbolRules 并入永续合约交易对的交易规则后缓存，现货与合约使用相同格式的交易对名称
+func (e *Executor) storeSymbolRules(rules map[string]SymbolRules) error {
+	if perpetuals := e.perpetualPairs(); len(perpetuals) > 0 {
+		futuresRules, err := newFuturesClient(e.cfg.Exchange, e.httpClient).exchangeInfo(e.ctx)
+		if err != nil {
+			if len(rules) == 0 {
+				return fmt.Errorf("获取合约交易规则失败: %v", err)
+			}
+			logrus.Warnf("获取合约交易规则失败，仅使用现货交易规则: %v", err)
+		}
+		for _, pair := range perpetuals {
+			if pairRules, ok := futuresRules[market.BinanceSymbol(pair.Symbol)]; ok {
+				rules[market.BinanceSymbol(pair.Symbol)] = pairRules
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	e.mutex.Lock()
+	e.symbolRules = rules
+	e.mutex.Unlock()
+
+	logrus.Infof("已加载 %d 个交易对的交易规则", len(rules))
+	return nil
+}
+
+// parseSymbolFilters 解析交易所的过滤器配置
+func parseSymbolFilters(filters []map[string]interface{}) SymbolRules {
+	var rules SymbolRules
+
+	for _, filter := range filters {
+		switch filter["filterType"] {
+		case "PRICE_FILTER":
+			rules.TickSize = parseFilterValue(filter["tickSize"])
+		case "LOT_SIZE":
+			rules.StepSize = parseFilterValue(filter["stepSize"])
+		case "MIN_NOTIONAL", "NOTIONAL":
+			rules.MinNotional = parseFilterValue(filter["minNotional"])
+			if rules.MinNotional.IsZero() {
+				// 合约市场的最小名义价值字段为 notional
+				rules.MinNotional = parseFilterValue(filter["notional"])
+			}
+		}
+	}
+
+	return rules
+}
+
+// parseFilterValue 解析过滤器中以字符串表示的数值
+func parseFilterValue(value interface{}) decimal.Decimal {
+	str, ok := value.(string)
+	if !ok {
+		return decimal.Zero
+	}
+
+	result, err := decimal.NewFromString(str)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return result
+}
+
+// getSymbolRules 获取交易对的交易规则，配置中的非零值优先于交易所规则
+func (e *Executor) getSymbolRules(symbol string) (SymbolRules, error) {
+	var rules SymbolRules
+
+	if e.cfg.Execution.AutoSymbolRules {
+		e.mutex.RLock()
+		fetched, ok := e.symbolRules[market.BinanceSymbol(symbol)]
+		loaded := len(e.symbolRules) > 0
+		e.mutex.RUnlock()
+
+		// 启动时未能获取交易所交易规则（如交易所不可达）时重新获取，失败时订单稍后重试
+		if !ok && !loaded && !hasRulesOverride(e.cfg.Current().Trading.Pairs, symbol) {
+			if err := e.loadSymbolRules(); err != nil {
+				return rules, fmt.Errorf("%w: %v", ErrTransient, err)
+			}
+			e.mutex.RLock()
+			fetched, ok = e.symbolRules[market.BinanceSymbol(symbol)]
+			e.mutex.RUnlock()
+		}
+
+		if !ok && !hasRulesOverride(e.cfg.Current().Trading.Pairs, symbol) {
+			return rules, fmt.Errorf("交易所交易规则中未找到交易对 %s", symbol)
+		}
+		rules = fetched
+	}
+
+	for _, pair := range e.cfg.Current().Trading.Pairs {
+		if pair.Symbol != symbol {
+			continue
+		}
+		if pair.TickSize > 0 {
+			rules.TickSize = decimal.NewFromFloat(pair.TickSize)
+		}
+		if pair.StepSize > 0 {
+			rules.StepSize = decimal.NewFromFloat(pair.StepSize)
+		}
+		if pair.MinNotional > 0 {
+			rules.MinNotional = decimal.NewFromFloat(pair.MinNotional)
+		}
+		break
+	}
+
+	return rules, nil
+}
+
+// applySymbolRules 按交易规则调整订单价格和数量
+func (e *Executor) applySymbolRules(order *Order) error {
+	rules, err := e.getSymbolRules(order.Symbol)
+	if err != nil {
+		return err
+	}
+
+	if rules.TickSize.IsPositive() {
+		order.Price = order.Price.Div(rules.TickSize).Round(0).Mul(rules.TickSize)
+		if order.StopPrice.IsPositive() {
+			order.StopPrice = order.StopPrice.Div(rules.TickSize).Round(0).Mul(rules.TickSize)
+		}
+	}
+
+	if rules.StepSize.IsPositive() {
+		order.Quantity = order.Quantity.Div(rules.StepSize).Floor().Mul(rules.StepSize)
+	}
+
+	if order.Quantity.LessThanOrEqual(decimal.Zero) {
+		return fmt.Errorf("按数量精度 %s 调整后 %s 的下单数量为0", rules.StepSize.String(), order.Symbol)
+	}
+
+	notional := order.Price.Mul(order.Quantity)
+	if rules.MinNotional.IsPositive() && notional.LessThan(rules.MinNotional) {
+		return fmt.Errorf("%s 订单名义价值 %s 低于最小值 %s", order.Symbol, notional.String(), rules.MinNotional.String())
+	}
+
+	return nil
+}
+
+// hasRulesOverride 判断交易对是否在配置中完整指定了交易规则
+func hasRulesOverride(pairs []config.PairConfig, symbol string) bool {
+	for _, pair := range pairs {
+		if pair.Symbol == symbol {
+			return pair.TickSize > 0 && pair.StepSize > 0
+		}
+	}
+	return false
+}
+
+// isExchangePair 判断交易对是否在中心化交易所交易
+func isExchangePair(pairs []config.PairConfig, symbol string) bool {
+	for _, pair := range pairs {
+		if pair.Symbol == symbol {
+			return pair.Blockchain == ""
+		}
+	}
+	return true
+}
+
+// routesToExchange 判断信号是否应在交易所执行，信号未指定场所时按交易对配置判断
+func routesToExchange(pairs []config.PairConfig, signal strategy.Signal) bool {
+	switch signal.Venue {
+	case strategy.VenueExchange:
+		return true
+	case strategy.VenueBlockchain:
+		return false
+	}
+	return isExchangePair(pairs, signal.Symbol)
+}
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/risk"
+
+	"github.com/shopspring/decimal"
+)
+
+// exchangeInfoServer 模拟交易所 exchangeInfo 接口，只返回 BTCUSDT 的过滤器
+func exchangeInfoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/exchangeInfo" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","filters":[
+			{"filterType":"PRICE_FILTER","tickSize":"0.01000000"},
+			{"filterType":"LOT_SIZE","stepSize":"0.00010000"},
+			{"filterType":"NOTIONAL","minNotional":"5.00000000"}]}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newRulesExecutor(t *testing.T, pairs ...config.PairConfig) *Executor {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Exchange.BaseURL = exchangeInfoServer(t).URL
+	cfg.Execution.AutoSymbolRules = true
+	cfg.Trading.Pairs = pairs
+	e := NewExecutor(cfg, risk.NewRiskManager(cfg))
+	if err := e.loadSymbolRules(); err != nil {
+		t.Fatalf("加载交易规则失败: %v", err)
+	}
+	return e
+}
+
+func TestFetchedSymbolRulesRoundOrder(t *testing.T) {
+	e := newRulesExecutor(t, config.PairConfig{Symbol: "BTC/USDT", Enabled: true})
+
+	order := Order{Symbol: "BTC/USDT", Price: decimal.RequireFromString("30000.126"), Quantity: decimal.RequireFromString("0.123456")}
+	if err := e.applySymbolRules(&order); err != nil {
+		t.Fatalf("调整订单失败: %v", err)
+	}
+	if !order.Price.Equal(decimal.RequireFromString("30000.13")) {
+		t.Errorf("价格应按 tickSize 0.01 四舍五入为 30000.13，实际 %s", order.Price)
+	}
+	if !order.Quantity.Equal(decimal.RequireFromString("0.1234")) {
+		t.Errorf("数量应按 stepSize 0.0001 向下取整为 0.1234，实际 %s", order.Quantity)
+	}
+
+	small := Order{Symbol: "BTC/USDT", Price: decimal.NewFromInt(30000), Quantity: decimal.RequireFromString("0.0001")}
+	if err := e.applySymbolRules(&small); err == nil {
+		t.Error("名义价值低于交易所最小值的订单应被拒绝")
+	}
+}
+
+func TestConfigOverridesFetchedSymbolRules(t *testing.T) {
+	e := newRulesExecutor(t, config.PairConfig{Symbol: "BTC/USDT", Enabled: true, StepSize: 0.01})
+
+	order := Order{Symbol: "BTC/USDT", Price: decimal.NewFromInt(30000), Quantity: decimal.RequireFromString("0.123456")}
+	if err := e.applySymbolRules(&order); err != nil {
+		t.Fatalf("调整订单失败: %v", err)
+	}
+	if !order.Quantity.Equal(decimal.RequireFromString("0.12")) {
+		t.Errorf("配置的 step_size 应覆盖交易所规则，期望 0.12，实际 %s", order.Quantity)
+	}
+}
+
+func TestMissingSymbolRulesError(t *testing.T) {
+	e := newRulesExecutor(t,
+		config.PairConfig{Symbol: "BTC/USDT", Enabled: true},
+		config.PairConfig{Symbol: "DOGE/USDT", Enabled: true})
+
+	order := Order{Symbol: "DOGE/USDT", Price: decimal.RequireFromString("0.1"), Quantity: decimal.NewFromInt(100)}
+	err := e.applySymbolRules(&order)
+	if err == nil || !strings.Contains(err.Error(), "未找到交易对 DOGE/USDT") {
+		t.Errorf("交易所规则中没有的交易对应返回明确的错误，实际: %v", err)
+	}
+}
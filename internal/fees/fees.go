@@ -0,0 +1,77 @@
+package fees
+
+import (
+	"autotransaction/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// defaultV2LPFeeRate Uniswap V2/PancakeSwap 流动性池的手续费率
+var defaultV2LPFeeRate = decimal.NewFromFloat(0.003)
+
+// v3FeeUnit V3 池费率的单位，3000 表示0.3%
+var v3FeeUnit = decimal.NewFromInt(1000000)
+
+// Model 手续费模型，按 fees 配置计算交易所的挂单/吃单手续费和链上兑换的流动性池手续费及gas费用
+type Model struct {
+	cfg *config.Config
+}
+
+// NewModel 创建手续费模型
+func NewModel(cfg *config.Config) *Model {
+	return &Model{cfg: cfg}
+}
+
+// ExchangeRate 返回交易所的手续费率，挂单（限价类订单）为 maker 费率，否则为 taker 费率
+func (m *Model) ExchangeRate(maker bool) decimal.Decimal {
+	if maker {
+		return decimal.NewFromFloat(m.cfg.Fees.MakerRate)
+	}
+	return decimal.NewFromFloat(m.cfg.Fees.TakerRate)
+}
+
+// ExchangeFee 计算交易所一笔成交的手续费（计价货币）
+func (m *Model) ExchangeFee(maker bool, price, quantity decimal.Decimal) decimal.Decimal {
+	return price.Mul(quantity).Mul(m.ExchangeRate(maker))
+}
+
+// LPFeeRate 返回网络上兑换的流动性池手续费率
+// 未配置 lp_fee_rate 时 v3 路由按 pool_fee 计算，其余按 V2 池的0.3%
+func (m *Model) LPFeeRate(network string) decimal.Decimal {
+	if m.cfg.Fees.DEX.LPFeeRate > 0 {
+		return decimal.NewFromFloat(m.cfg.Fees.DEX.LPFeeRate)
+	}
+	for _, networkCfg := range m.cfg.Blockchain.Networks {
+		if networkCfg.Name == network && networkCfg.Router.Version == "v3" && networkCfg.Router.PoolFee > 0 {
+			return decimal.NewFromInt(int64(networkCfg.Router.PoolFee)).Div(v3FeeUnit)
+		}
+	}
+	return defaultV2LPFeeRate
+}
+
+// LPFee 计算一笔链上兑换的流动性池手续费（计价货币）
+func (m *Model) LPFee(network string, price, quantity decimal.Decimal) decimal.Decimal {
+	return price.Mul(quantity).Mul(m.LPFeeRate(network))
+}
+
+// EstimatedGasCost 返回每笔链上兑换的估计gas费用（计价货币）
+func (m *Model) EstimatedGasCost() decimal.Decimal {
+	return decimal.NewFromFloat(m.cfg.Fees.DEX.GasCost)
+}
+
+// NativePair 返回网络原生币的交易对，用于将实际消耗的gas换算为计价货币
+func (m *Model) NativePair(network string) (string, bool) {
+	symbol, ok := m.cfg.Fees.DEX.NativePairs[network]
+	return symbol, ok && symbol != ""
+}
+
+// PairFee 估算交易对成交的手续费：按成交额收取的费率和每笔固定费用（计价货币）
+// 链上交易对为流动性池手续费率和估计gas费用，交易所交易对按 maker 或 taker 费率计算，没有固定费用
+func (m *Model) PairFee(symbol string, maker bool) (rate, fixed decimal.Decimal) {
+	for _, pair := range m.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol && pair.Blockchain != "" {
+			return m.LPFeeRate(pair.Blockchain), m.EstimatedGasCost()
+		}
+	}
+	return m.ExchangeRate(maker), decimal.Zero
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 组件健康状态
+const (
+	StatusOK       = "ok"       // 正常
+	StatusDegraded = "degraded" // 可用但性能或功能受损，如节点区块落后
+	StatusDown     = "down"     // 不可用
+)
+
+// defaultCheckTimeout 未配置时单次检查所有组件的超时时间
+const defaultCheckTimeout = 5 * time.Second
+
+// cacheTTL 检查结果的缓存时间，避免频繁的探针请求反复访问外部服务
+const cacheTTL = 5 * time.Second
+
+// Result 单个组件的检查结果
+type Result struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Latency int64                  `json:"latencyMs"`
+}
+
+// Report 所有组件的检查结果
+type Report struct {
+	Status     string            `json:"status"`
+	CheckedAt  time.Time         `json:"checkedAt"`
+	Components map[string]Result `json:"components"`
+}
+
+// Checker 检查单个组件的健康状态，需在 ctx 超时前返回
+type Checker func(ctx context.Context) Result
+
+// component 已注册的组件
+type component struct {
+	name     string
+	critical bool
+	checker  Checker
+}
+
+// Registry 汇总各组件的健康检查
+type Registry struct {
+	components []component
+	timeout    time.Duration
+	last       *Report
+	mutex      sync.Mutex
+}
+
+// NewRegistry 创建健康检查注册表，timeout 为0时使用默认超时
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+// Register 注册组件检查，关键组件不可用时整体状态为不可用，非关键组件不可用时整体状态为降级
+func (r *Registry) Register(name string, critical bool, checker Checker) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.components = append(r.components, component{name: name, critical: critical, checker: checker})
+}
+
+// Check 并发检查所有组件并汇总整体状态，结果缓存一小段时间
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.last != nil && time.Since(r.last.CheckedAt) < cacheTTL {
+		return *r.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	results := make([]Result, len(r.components))
+	var wg sync.WaitGroup
+	for i, c := range r.components {
+		wg.Add(1)
+		go func(i int, c component) {
+			defer wg.Done()
+			start := time.Now()
+			result := c.checker(ctx)
+			result.Latency = time.Since(start).Milliseconds()
+			results[i] = result
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusOK,
+		CheckedAt:  time.Now(),
+		Components: make(map[string]Result, len(r.components)),
+	}
+	for i, c := range r.components {
+		result := results[i]
+		report.Components[c.name] = result
+		switch {
+		case result.Status == StatusDown && c.critical:
+			report.Status = StatusDown
+		case result.Status != StatusOK && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	r.last = &report
+	return report
+}
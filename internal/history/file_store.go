@@ -0,0 +1,252 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileStore 基于本地文件的K线存储，每个 来源/交易对/周期 一个 JSON Lines 文件，新K线追加到文件末尾
+// 配置了保留数量时，文件行数超过其两倍后重写文件，只保留最近的K线；未配置时保留全部历史
+type FileStore struct {
+	dir        string
+	maxCandles int            // 每个文件保留的K线数量，0表示不限制
+	counts     map[string]int // 各文件当前的行数
+	mutex      sync.Mutex
+}
+
+// NewFileStore 创建K线文件存储
+func NewFileStore(dir string, maxCandles int) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建历史数据目录失败: %v", err)
+	}
+
+	return &FileStore{
+		dir:        dir,
+		maxCandles: maxCandles,
+		counts:     make(map[string]int),
+	}, nil
+}
+
+// Append 追加一根已收盘的K线
+func (s *FileStore) Append(source string, candle Candle) error {
+	line, err := json.Marshal(candle)
+	if err != nil {
+		return fmt.Errorf("序列化K线失败: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := s.path(source, candle.Symbol, candle.Interval)
+	count, ok := s.counts[path]
+	if !ok {
+		candles, err := readCandles(path)
+		if err != nil {
+			return err
+		}
+		count = len(candles)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建历史数据目录失败: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开历史数据文件失败: %v", err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入历史数据文件失败: %v", err)
+	}
+	count++
+
+	if s.maxCandles > 0 && count > 2*s.maxCandles {
+		if err := s.compactLocked(path); err != nil {
+			return err
+		}
+		count = s.maxCandles
+	}
+	s.counts[path] = count
+	return nil
+}
+
+// Candles 返回最近的 limit 根K线，按时间从早到晚排列，limit 不大于0时返回全部
+func (s *FileStore) Candles(source, symbol, interval string, limit int) ([]Candle, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := s.path(source, symbol, interval)
+	if limit <= 0 {
+		candles, err := readCandles(path)
+		if err != nil {
+			return nil, err
+		}
+		return dedupCandles(candles), nil
+	}
+
+	// 只从文件末尾读取所需的行数，去重后不足时加倍读取，直到读到文件开头
+	for lines := limit; ; lines *= 2 {
+		candles, whole, err := readTailCandles(path, lines)
+		if err != nil {
+			return nil, err
+		}
+		candles = dedupCandles(candles)
+		if len(candles) >= limit || whole {
+			if len(candles) > limit {
+				candles = candles[len(candles)-limit:]
+			}
+			return candles, nil
+		}
+	}
+}
+
+// Close 关闭存储，文件在每次写入后关闭，无需额外处理
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// compactLocked 重写文件，只保留最近的 maxCandles 根K线，调用方需持有 s.mutex
+func (s *FileStore) compactLocked(path string) error {
+	candles, err := readCandles(path)
+	if err != nil {
+		return err
+	}
+	candles = dedupCandles(candles)
+	if len(candles) > s.maxCandles {
+		candles = candles[len(candles)-s.maxCandles:]
+	}
+
+	var builder strings.Builder
+	for _, candle := range candles {
+		line, err := json.Marshal(candle)
+		if err != nil {
+			return fmt.Errorf("序列化K线失败: %v", err)
+		}
+		builder.Write(line)
+		builder.WriteByte('\n')
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("写入历史数据文件失败: %v", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// path 返回K线文件路径，交易对中的 / 替换为 _
+func (s *FileStore) path(source, symbol, interval string) string {
+	return filepath.Join(s.dir, source, strings.ReplaceAll(symbol, "/", "_"), interval+".jsonl")
+}
+
+// readCandles 读取文件中的全部K线，文件不存在时返回空，无法解析的行（如写入中断的最后一行）会被跳过
+func readCandles(path string) ([]Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开历史数据文件失败: %v", err)
+	}
+	defer file.Close()
+
+	candles := make([]Candle, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var candle Candle
+		if err := json.Unmarshal(scanner.Bytes(), &candle); err != nil {
+			continue
+		}
+		candles = append(candles, candle)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史数据文件失败: %v", err)
+	}
+	return candles, nil
+}
+
+// tailChunkSize 从文件末尾向前读取时每次读取的字节数
+const tailChunkSize = 64 * 1024
+
+// readTailCandles 从文件末尾向前读取最后 lines 行K线，返回的 whole 表示已读到文件开头
+func readTailCandles(path string, lines int) ([]Candle, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("打开历史数据文件失败: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("读取历史数据文件失败: %v", err)
+	}
+
+	// 向前读取直到包含 lines 个完整行（即 lines+1 个换行符，最后一行以换行结尾）
+	offset := info.Size()
+	var tail []byte
+	for offset > 0 && bytes.Count(tail, []byte{'\n'}) <= lines {
+		size := int64(tailChunkSize)
+		if size > offset {
+			size = offset
+		}
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return nil, false, fmt.Errorf("读取历史数据文件失败: %v", err)
+		}
+		tail = append(chunk, tail...)
+	}
+
+	rows := bytes.Split(tail, []byte{'\n'})
+	whole := offset == 0
+	if !whole {
+		// 第一行可能不完整
+		rows = rows[1:]
+	}
+
+	candles := make([]Candle, 0, lines)
+	for _, row := range rows {
+		var candle Candle
+		if err := json.Unmarshal(row, &candle); err != nil {
+			continue
+		}
+		candles = append(candles, candle)
+	}
+	if len(candles) > lines {
+		candles = candles[len(candles)-lines:]
+		whole = false
+	}
+	return candles, whole, nil
+}
+
+// dedupCandles 同一开始时间的K线只保留最后写入的一根，并丢弃早于前一根的乱序K线
+func dedupCandles(candles []Candle) []Candle {
+	result := make([]Candle, 0, len(candles))
+	for _, candle := range candles {
+		if n := len(result); n > 0 {
+			last := result[n-1].OpenTime
+			if candle.OpenTime.Equal(last) {
+				result[n-1] = candle
+				continue
+			}
+			if candle.OpenTime.Before(last) {
+				continue
+			}
+		}
+		result = append(result, candle)
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCandlesReadsTailOfLargeFile(t *testing.T) {
+	s, err := NewFileStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(i int, close int64) Candle {
+		return Candle{
+			Symbol:   "BTC/USDT",
+			Interval: "1m",
+			OpenTime: start.Add(time.Duration(i) * time.Minute),
+			Open:     decimal.NewFromInt(100),
+			High:     decimal.NewFromInt(110),
+			Low:      decimal.NewFromInt(90),
+			Close:    decimal.NewFromInt(close),
+			Volume:   decimal.NewFromInt(1000),
+		}
+	}
+
+	// 写入超过单次读取块大小的数据，最后一根K线重复写入一次
+	const total = 2000
+	for i := 0; i < total; i++ {
+		if err := s.Append("binance", candle(i, int64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Append("binance", candle(total-1, -1)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(s.path("binance", "BTC/USDT", "1m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() <= tailChunkSize {
+		t.Fatalf("测试数据应超过单次读取块大小，实际 %d 字节", info.Size())
+	}
+
+	candles, err := s.Candles("binance", "BTC/USDT", "1m", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 3 {
+		t.Fatalf("应返回最近 3 根K线，实际 %d 根", len(candles))
+	}
+	for i, c := range candles {
+		if want := start.Add(time.Duration(total-3+i) * time.Minute); !c.OpenTime.Equal(want) {
+			t.Fatalf("第 %d 根K线的开始时间应为 %s，实际 %s", i, want, c.OpenTime)
+		}
+	}
+	if !candles[2].Close.Equal(decimal.NewFromInt(-1)) {
+		t.Fatalf("同一开始时间应保留最后写入的K线，实际收盘价 %s", candles[2].Close)
+	}
+
+	all, err := s.Candles("binance", "BTC/USDT", "1m", total+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != total {
+		t.Fatalf("limit 超过文件行数时应返回全部 %d 根K线，实际 %d 根", total, len(all))
+	}
+
+	// 写入中断留下的不完整行不影响读取
+	file, err := os.OpenFile(filepath.Join(s.dir, "binance", "BTC_USDT", "1m.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"symbol":"BTC/USDT","inter`)
+	file.Close()
+	if candles, err := s.Candles("binance", "BTC/USDT", "1m", 1); err != nil || len(candles) != 1 || !candles[0].Close.Equal(decimal.NewFromInt(-1)) {
+		t.Fatalf("应跳过不完整的最后一行: %v %v", candles, err)
+	}
+}
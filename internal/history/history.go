@@ -0,0 +1,61 @@
+package history
+
+import (
+	"time"
+
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// 行情来源，不同来源的K线分开存储
+const (
+	SourceExchange   = "exchange"
+	SourceBlockchain = "blockchain"
+)
+
+// Candle 一根已收盘的K线，OpenTime 为所在周期的开始时间
+type Candle struct {
+	Symbol   string          `json:"symbol"`
+	Interval string          `json:"interval"`
+	OpenTime time.Time       `json:"open_time"`
+	Open     decimal.Decimal `json:"open"`
+	High     decimal.Decimal `json:"high"`
+	Low      decimal.Decimal `json:"low"`
+	Close    decimal.Decimal `json:"close"`
+	Volume   decimal.Decimal `json:"volume"`
+}
+
+// MarketData 将K线转换为市场数据，时间戳为K线开始时间，与交易所K线接口一致
+func (c Candle) MarketData() market.MarketData {
+	return market.MarketData{
+		Symbol:    c.Symbol,
+		Timestamp: c.OpenTime,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+	}
+}
+
+// merge 将一段行情并入K线：最高价取较大值、最低价取较小值、收盘价取最新值、成交量累加
+func (c *Candle) merge(data market.MarketData) {
+	if data.High.GreaterThan(c.High) {
+		c.High = data.High
+	}
+	if data.Low.LessThan(c.Low) {
+		c.Low = data.Low
+	}
+	c.Close = data.Close
+	c.Volume = c.Volume.Add(data.Volume)
+}
+
+// Store 历史K线存储接口，按 来源-交易对-周期 分别存储
+type Store interface {
+	// Append 追加一根已收盘的K线
+	Append(source string, candle Candle) error
+	// Candles 返回最近的 limit 根K线，按时间从早到晚排列
+	Candles(source, symbol, interval string, limit int) ([]Candle, error)
+	Close() error
+}
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"autotransaction/internal/market"
+
+	"github.com/sirupsen/logrus"
+)
+
+// interval 一个聚合周期
+type interval struct {
+	name      string
+	duration  time.Duration
+	resampler *market.Resampler
+}
+
+// Recorder 将一个来源的实时行情按配置的周期聚合为K线并写入存储
+// 实现 market.DataHandler 接口接收行情，实现 market.HistoryProvider 接口提供历史K线
+// K线收盘后才写入，进程退出时未收盘的K线不会保存
+type Recorder struct {
+	store     Store
+	source    string
+	intervals []interval // 按周期从短到长排列
+}
+
+// NewRecorder 创建行情记录器，intervals 为需要聚合的K线周期，如 1m、5m、1h
+// feed 为输入行情的K线周期，输入为定时采样的行情时为0，见 market.Resampler
+func NewRecorder(store Store, source string, intervals []string, feed time.Duration) (*Recorder, error) {
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("未配置K线周期")
+	}
+
+	parsed := make([]interval, 0, len(intervals))
+	for _, name := range intervals {
+		resampler, err := market.NewResampler(name, feed)
+		if err != nil {
+			return nil, err
+		}
+		duration, _ := market.ParseInterval(name)
+		parsed = append(parsed, interval{name: name, duration: duration, resampler: resampler})
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].duration < parsed[j].duration
+	})
+
+	return &Recorder{
+		store:     store,
+		source:    source,
+		intervals: parsed,
+	}, nil
+}
+
+// HandleData 实现 market.DataHandler 接口，将行情并入各周期的K线，K线收盘时写入存储
+func (r *Recorder) HandleData(data market.MarketData) {
+	for _, iv := range r.intervals {
+		for _, bar := range iv.resampler.Add(data) {
+			candle := Candle{
+				Symbol:   bar.Symbol,
+				Interval: iv.name,
+				OpenTime: bar.Timestamp,
+				Open:     bar.Open,
+				High:     bar.High,
+				Low:      bar.Low,
+				Close:    bar.Close,
+				Volume:   bar.Volume,
+			}
+			if err := r.store.Append(r.source, candle); err != nil {
+				logrus.Errorf("保存 %s 的 %s K线失败: %v", bar.Symbol, iv.name, err)
+			}
+		}
+	}
+}
+
+// Candles 实现 market.HistoryProvider 接口，返回最近 limit 根已收盘的K线，按时间从早到晚排列
+// 请求的周期未直接记录时，由能整除该周期的最长已记录周期合成
+func (r *Recorder) Candles(symbol, intervalName string, limit int) ([]market.MarketData, error) {
+	duration, err := market.ParseInterval(intervalName)
+	if err != nil {
+		return nil, err
+	}
+
+	var base *interval
+	for i := range r.intervals {
+		iv := &r.intervals[i]
+		if duration%iv.duration == 0 {
+			base = iv
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("没有可用于合成 %s K线的已记录周期", intervalName)
+	}
+
+	ratio := int(duration / base.duration)
+	fetch := limit
+	if limit > 0 {
+		// 多取一组，使首根可能不完整的合成K线落在返回范围之外
+		fetch = (limit + 1) * ratio
+	}
+	candles, err := r.store.Candles(r.source, symbol, base.name, fetch)
+	if err != nil {
+		return nil, err
+	}
+	if ratio > 1 && len(candles) > 0 {
+		// 最后一根合成K线的周期尚未结束时不返回
+		closedUntil := candles[len(candles)-1].OpenTime.Add(base.duration)
+		candles = resample(candles, intervalName, duration)
+		if last := candles[len(candles)-1]; last.OpenTime.Add(duration).After(closedUntil) {
+			candles = candles[:len(candles)-1]
+		}
+	}
+	if limit > 0 && len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+
+	result := make([]market.MarketData, len(candles))
+	for i, candle := range candles {
+		result[i] = candle.MarketData()
+	}
+	return result, nil
+}
+
+// resample 将较短周期的K线合成为较长周期的K线，输入需按时间排列
+func resample(candles []Candle, name string, duration time.Duration) []Candle {
+	result := make([]Candle, 0, len(candles))
+	for _, candle := range candles {
+		openTime := candle.OpenTime.Truncate(duration)
+		if n := len(result); n > 0 && result[n-1].OpenTime.Equal(openTime) {
+			result[n-1].merge(candle.MarketData())
+			continue
+		}
+		candle.Interval = name
+		candle.OpenTime = openTime
+		result = append(result, candle)
+	}
+	return result
+}
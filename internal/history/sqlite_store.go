@@ -0,0 +1,119 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // 注册 sqlite3 驱动
+)
+
+// SQLiteStore 基于 SQLite 的K线存储，以 (来源, 交易对, 周期, 开始时间) 为主键
+// 同一根K线重复写入时覆盖旧值；maxCandles 为0时保留全部历史，大于0时只保留每个 来源/交易对/周期 最近的K线
+type SQLiteStore struct {
+	db         *sql.DB
+	maxCandles int
+}
+
+// sqliteCandleSchema K线表，价格和成交量以字符串保存避免精度损失，open_time 为毫秒时间戳
+const sqliteCandleSchema = `CREATE TABLE IF NOT EXISTS candles (
+	source    TEXT NOT NULL,
+	symbol    TEXT NOT NULL,
+	interval  TEXT NOT NULL,
+	open_time INTEGER NOT NULL,
+	open      TEXT NOT NULL,
+	high      TEXT NOT NULL,
+	low       TEXT NOT NULL,
+	close     TEXT NOT NULL,
+	volume    TEXT NOT NULL,
+	PRIMARY KEY (source, symbol, interval, open_time)
+)`
+
+// NewSQLiteStore 打开或创建K线数据库文件
+func NewSQLiteStore(path string, maxCandles int) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建历史数据目录失败: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("打开历史数据库失败: %v", err)
+	}
+	// SQLite 同一时刻只允许一个写入者，使用单个连接避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteCandleSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建K线表失败: %v", err)
+	}
+	return &SQLiteStore{db: db, maxCandles: maxCandles}, nil
+}
+
+// Append 写入一根已收盘的K线，已有相同开始时间的K线时覆盖
+func (s *SQLiteStore) Append(source string, candle Candle) error {
+	_, err := s.db.Exec(`INSERT INTO candles (source, symbol, interval, open_time, open, high, low, close, volume)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, symbol, interval, open_time) DO UPDATE SET
+			open = excluded.open, high = excluded.high, low = excluded.low, close = excluded.close, volume = excluded.volume`,
+		source, candle.Symbol, candle.Interval, candle.OpenTime.UnixMilli(),
+		candle.Open.String(), candle.High.String(), candle.Low.String(), candle.Close.String(), candle.Volume.String())
+	if err != nil {
+		return fmt.Errorf("写入K线失败: %v", err)
+	}
+	if s.maxCandles <= 0 {
+		return nil
+	}
+
+	// 删除超出保留数量的最早K线
+	_, err = s.db.Exec(`DELETE FROM candles WHERE source = ? AND symbol = ? AND interval = ? AND open_time <= (
+		SELECT open_time FROM candles WHERE source = ? AND symbol = ? AND interval = ?
+		ORDER BY open_time DESC LIMIT 1 OFFSET ?)`,
+		source, candle.Symbol, candle.Interval, source, candle.Symbol, candle.Interval, s.maxCandles)
+	if err != nil {
+		return fmt.Errorf("清理过期K线失败: %v", err)
+	}
+	return nil
+}
+
+// Candles 返回最近的 limit 根K线，按时间从早到晚排列，limit 不大于0时返回全部
+func (s *SQLiteStore) Candles(source, symbol, interval string, limit int) ([]Candle, error) {
+	query := `SELECT open_time, open, high, low, close, volume FROM candles
+		WHERE source = ? AND symbol = ? AND interval = ? ORDER BY open_time DESC`
+	args := []interface{}{source, symbol, interval}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取K线失败: %v", err)
+	}
+	defer rows.Close()
+
+	candles := make([]Candle, 0)
+	for rows.Next() {
+		candle := Candle{Symbol: symbol, Interval: interval}
+		var openTime int64
+		if err := rows.Scan(&openTime, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume); err != nil {
+			return nil, fmt.Errorf("读取K线失败: %v", err)
+		}
+		candle.OpenTime = time.UnixMilli(openTime).UTC()
+		candles = append(candles, candle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取K线失败: %v", err)
+	}
+
+	// 查询按时间倒序取最近的K线，返回前调整为从早到晚
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+	return candles, nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
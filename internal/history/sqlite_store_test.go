@@ -0,0 +1,89 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSQLiteStoreKeepsHistoryAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candles.db")
+	s, err := NewSQLiteStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(i int, close string) Candle {
+		return Candle{
+			Symbol:   "BTC/USDT",
+			Interval: "1m",
+			OpenTime: start.Add(time.Duration(i) * time.Minute),
+			Open:     decimal.NewFromInt(100),
+			High:     decimal.NewFromInt(110),
+			Low:      decimal.NewFromInt(90),
+			Close:    decimal.RequireFromString(close),
+			Volume:   decimal.NewFromInt(1000),
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := s.Append(SourceExchange, candle(i, "100.5")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 同一根K线重复写入时覆盖旧值
+	if err := s.Append(SourceExchange, candle(4, "101.25")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(SourceBlockchain, candle(9, "1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewSQLiteStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	all, err := restarted.Candles(SourceExchange, "BTC/USDT", "1m", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 || !all[0].OpenTime.Equal(start) {
+		t.Fatalf("未配置保留数量时应保留全部K线: %v", all)
+	}
+	recent, err := restarted.Candles(SourceExchange, "BTC/USDT", "1m", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || !recent[1].OpenTime.Equal(start.Add(4*time.Minute)) || recent[1].Close.String() != "101.25" {
+		t.Fatalf("应按时间从早到晚返回最近的K线且重复写入覆盖旧值: %v", recent)
+	}
+}
+
+func TestSQLiteStoreTrimsToMaxCandles(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "candles.db"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		err := s.Append(SourceExchange, Candle{Symbol: "BTC/USDT", Interval: "1m", OpenTime: start.Add(time.Duration(i) * time.Minute)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candles, err := s.Candles(SourceExchange, "BTC/USDT", "1m", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 3 || !candles[0].OpenTime.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("配置保留数量时应只保留最近的K线: %v", candles)
+	}
+}
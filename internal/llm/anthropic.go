@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicBaseURL 未配置地址时使用的 Anthropic 接口地址
+	anthropicBaseURL = "https://api.anthropic.com"
+	// anthropicVersion Anthropic 接口版本
+	anthropicVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens Anthropic 接口要求 max_tokens，调用方未给出时使用
+	anthropicDefaultMaxTokens = 1024
+)
+
+// anthropicProvider Anthropic Messages 接口，system 消息作为单独的 system 字段发送
+type anthropicProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// anthropicError Anthropic 接口的错误信息
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (p *anthropicProvider) Endpoint() string {
+	return p.baseURL + "/v1/messages"
+}
+
+func (p *anthropicProvider) NewRequest(req ChatRequest) (*http.Request, error) {
+	system, messages := anthropicMessages(req.Messages)
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+	body := map[string]interface{}{
+		"model":       p.model,
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": req.Temperature,
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	if req.Stream {
+		body["stream"] = true
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			tools = append(tools, map[string]interface{}{
+				"name":         tool.Name,
+				"description":  tool.Description,
+				"input_schema": tool.Parameters,
+			})
+		}
+		body["tools"] = tools
+		if req.DisableTools {
+			body["tool_choice"] = map[string]string{"type": "none"}
+		}
+	}
+
+	httpReq, err := newJSONRequest(p.Endpoint(), body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	return httpReq, nil
+}
+
+func (p *anthropicProvider) ParseResponse(body []byte) (*LLMResponse, error) {
+	var message struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`    // tool_use
+			Name  string          `json:"name"`  // tool_use
+			Input json.RawMessage `json:"input"` // tool_use
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		Error *anthropicError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("解析LLM响应失败: %v, 响应体: %s", err, string(body))
+	}
+	if message.Error != nil {
+		return nil, fmt.Errorf("LLM API返回错误: %s", message.Error.Message)
+	}
+
+	var completion strings.Builder
+	var toolCalls []ToolCall
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			completion.WriteString(block.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: block.Input})
+		}
+	}
+	return &LLMResponse{
+		Completion: completion.String(),
+		Usage:      newUsage(message.Usage.InputTokens, message.Usage.OutputTokens),
+		ToolCalls:  toolCalls,
+	}, nil
+}
+
+// ParseStreamLine 只处理 data 行，事件类型同时包含在数据的 type 字段中
+func (p *anthropicProvider) ParseStreamLine(line string) (string, bool, error) {
+	payload, ok := sseData(line)
+	if !ok {
+		return "", false, nil
+	}
+
+	var event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+		Error *anthropicError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return "", false, fmt.Errorf("解析LLM流式响应失败: %v, 数据: %s", err, payload)
+	}
+
+	switch event.Type {
+	case "content_block_delta":
+		if event.Delta.Type == "text_delta" {
+			return event.Delta.Text, false, nil
+		}
+	case "message_stop":
+		return "", true, nil
+	case "error":
+		message := payload
+		if event.Error != nil {
+			message = event.Error.Message
+		}
+		return "", false, fmt.Errorf("LLM流式响应返回错误: %s", message)
+	}
+	return "", false, nil
+}
+
+// anthropicMessages 取出 system 消息，并将工具调用转换为 tool_use 和 tool_result 内容块
+// 连续的工具结果合并为一条 user 消息
+func anthropicMessages(messages []ChatMessage) ([]string, []map[string]interface{}) {
+	var system []string
+	result := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		switch {
+		case message.Role == "system":
+			system = append(system, message.Content)
+		case message.Role == "tool":
+			block := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": message.ToolCallID,
+				"content":     message.Content,
+			}
+			if last := len(result) - 1; last >= 0 && result[last]["role"] == "user" {
+				if blocks, ok := result[last]["content"].([]map[string]interface{}); ok {
+					result[last]["content"] = append(blocks, block)
+					continue
+				}
+			}
+			result = append(result, map[string]interface{}{
+				"role":    "user",
+				"content": []map[string]interface{}{block},
+			})
+		case len(message.ToolCalls) > 0:
+			blocks := make([]map[string]interface{}, 0, len(message.ToolCalls)+1)
+			if message.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": message.Content})
+			}
+			for _, call := range message.ToolCalls {
+				blocks = append(blocks, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Name,
+					"input": call.Arguments,
+				})
+			}
+			result = append(result, map[string]interface{}{
+				"role":    message.Role,
+				"content": blocks,
+			})
+		default:
+			result = append(result, map[string]interface{}{
+				"role":    message.Role,
+				"content": message.Content,
+			})
+		}
+	}
+	return system, result
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"sync"
+	"time"
+
+	"autotransaction/config"
+)
+
+const (
+	// defaultConversationTTL 未配置时会话的过期时间
+	defaultConversationTTL = 30 * time.Minute
+	// defaultConversationTurns 未配置时每个会话保留的问答轮数
+	defaultConversationTurns = 20
+	// defaultHistoryTokens 未配置时提供给LLM的历史问答的token上限
+	defaultHistoryTokens = 4000
+	// defaultMaxConversations 未配置时同时保留的会话数
+	defaultMaxConversations = 1000
+)
+
+// Turn 会话中的一轮问答
+type Turn struct {
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Conversation 一个账户的问答会话
+type Conversation struct {
+	ID        string    `json:"id"`
+	Account   string    `json:"account"`
+	Turns     []Turn    `json:"turns"`    // 按时间升序
+	TradeIDs  []string  `json:"tradeIds"` // 会话中引用过的交易，后续提问时仍提供给LLM
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Conversations 保存问答会话，会话只在内存中保留，超过过期时间没有新提问即被清除
+type Conversations struct {
+	ttl           time.Duration
+	maxTurns      int
+	historyTokens int
+	maxSessions   int
+	sessions      map[string]*Conversation // 键为账户和会话ID
+	mutex         sync.Mutex
+}
+
+// NewConversations 按配置创建会话存储
+func NewConversations(cfg config.LLMConversationConfig) *Conversations {
+	c := &Conversations{
+		ttl:           time.Duration(cfg.TTLMinutes) * time.Minute,
+		maxTurns:      cfg.MaxTurns,
+		historyTokens: cfg.MaxHistoryTokens,
+		maxSessions:   cfg.MaxSessions,
+		sessions:      make(map[string]*Conversation),
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultConversationTTL
+	}
+	if c.maxTurns <= 0 {
+		c.maxTurns = defaultConversationTurns
+	}
+	if c.historyTokens <= 0 {
+		c.historyTokens = defaultHistoryTokens
+	}
+	if c.maxSessions <= 0 {
+		c.maxSessions = defaultMaxConversations
+	}
+	return c
+}
+
+// Get 返回账户的会话，会话不存在或已过期时 ok 为 false
+func (c *Conversations) Get(account, id string) (Conversation, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.evictExpiredLocked(time.Now())
+	conversation, ok := c.sessions[conversationKey(account, id)]
+	if !ok {
+		return Conversation{}, false
+	}
+	return conversation.copy(), true
+}
+
+// Append 记录一轮问答和本轮引用的交易，会话不存在时创建
+// 超出保留轮数时丢弃最早的问答，会话数超出上限时清除最久未使用的会话
+func (c *Conversations) Append(account, id string, turn Turn, tradeIDs []string) Conversation {
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.evictExpiredLocked(now)
+	key := conversationKey(account, id)
+	conversation, ok := c.sessions[key]
+	if !ok {
+		conversation = &Conversation{ID: id, Account: account, CreatedAt: now, UpdatedAt: now}
+		c.sessions[key] = conversation
+		c.evictOldestLocked()
+	}
+
+	conversation.Turns = append(conversation.Turns, turn)
+	if len(conversation.Turns) > c.maxTurns {
+		conversation.Turns = append([]Turn(nil), conversation.Turns[len(conversation.Turns)-c.maxTurns:]...)
+	}
+	conversation.TradeIDs = tradeIDs
+	conversation.UpdatedAt = now
+	return conversation.copy()
+}
+
+// Delete 删除账户的会话，会话不存在时返回 false
+func (c *Conversations) Delete(account, id string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := conversationKey(account, id)
+	if _, ok := c.sessions[key]; !ok {
+		return false
+	}
+	delete(c.sessions, key)
+	return true
+}
+
+// History 将会话中的问答转换为提供给LLM的历史消息
+// 从最新的问答向前保留，超出保留轮数或历史token上限时丢弃更早的问答
+func (c *Conversations) History(conversation Conversation) []ChatMessage {
+	start, tokens := len(conversation.Turns), 0
+	for i := len(conversation.Turns) - 1; i >= 0 && len(conversation.Turns)-i <= c.maxTurns; i-- {
+		turn := conversation.Turns[i]
+		tokens += estimateTokens(turn.Question) + estimateTokens(turn.Answer)
+		if tokens > c.historyTokens {
+			break
+		}
+		start = i
+	}
+
+	messages := make([]ChatMessage, 0, 2*(len(conversation.Turns)-start))
+	for _, turn := range conversation.Turns[start:] {
+		messages = append(messages,
+			ChatMessage{Role: "user", Content: turn.Question},
+			ChatMessage{Role: "assistant", Content: turn.Answer},
+		)
+	}
+	return messages
+}
+
+// evictExpiredLocked 清除过期的会话，调用方需持有锁
+func (c *Conversations) evictExpiredLocked(now time.Time) {
+	for key, conversation := range c.sessions {
+		if now.Sub(conversation.UpdatedAt) > c.ttl {
+			delete(c.sessions, key)
+		}
+	}
+}
+
+// evictOldestLocked 会话数超出上限时清除最久未使用的会话，调用方需持有锁
+func (c *Conversations) evictOldestLocked() {
+	for len(c.sessions) > c.maxSessions {
+		oldestKey := ""
+		var oldest time.Time
+		for key, conversation := range c.sessions {
+			if oldestKey == "" || conversation.UpdatedAt.Before(oldest) {
+				oldestKey, oldest = key, conversation.UpdatedAt
+			}
+		}
+		delete(c.sessions, oldestKey)
+	}
+}
+
+// copy 返回会话的副本，避免调用方修改存储中的会话
+func (c *Conversation) copy() Conversation {
+	conversation := *c
+	conversation.Turns = append([]Turn(nil), c.Turns...)
+	conversation.TradeIDs = append([]string(nil), c.TradeIDs...)
+	return conversation
+}
+
+func conversationKey(account, id string) string {
+	return account + "/" + id
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"autotransaction/internal/health"
+)
+
+// CheckHealth 检查LLM引擎API是否可达
+// 只发送不带提示词的 HEAD 请求，不消耗token；收到任何非5xx响应即视为可达，5xx 视为降级
+func (s *LLMService) CheckHealth(ctx context.Context) health.Result {
+	provider, err := s.provider()
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Message: err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", provider.Endpoint(), nil)
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Message: fmt.Sprintf("创建HTTP请求失败: %v", err)}
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Message: fmt.Sprintf("LLM API不可达: %v", err)}
+	}
+	resp.Body.Close()
+
+	details := map[string]interface{}{"engine": s.defaultEngine, "statusCode": resp.StatusCode}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return health.Result{Status: health.StatusDegraded, Message: fmt.Sprintf("LLM API返回状态码 %d", resp.StatusCode), Details: details}
+	}
+	return health.Result{Status: health.StatusOK, Details: details}
+}
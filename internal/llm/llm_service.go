@@ -1,23 +1,35 @@
 package llm
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
+	"sort"
 	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/metrics"
+
+	"github.com/sirupsen/logrus"
 )
 
 // LLMService 提供大型语言模型服务
 type LLMService struct {
 	cfg           *config.Config
 	httpClient    *http.Client
-	deepseekAPI   string
-	qwenAPI       string
-	defaultEngine string
+	streamClient  *http.Client // 流式请求不设置整体超时，由停顿超时控制
+	providers     map[string]Provider
+	defaultEngine string           // 本服务使用的引擎，见 WithEngine
+	metrics       *metrics.Metrics // 为nil时不记录监控指标
+	prompts       *Prompts         // 各接口使用的提示词模板
+	usage         *usageTracker    // 与 WithEngine 返回的服务共享
+	tools         []Tool           // 为空时不调用工具，见 WithTools
+}
+
+// SetMetrics 设置监控指标，记录每次LLM请求的耗时
+func (s *LLMService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
 }
 
 // LLMResponse 结构体用于存储LLM API的响应
@@ -25,33 +37,85 @@ type LLMResponse struct {
 	Completion string                 `json:"completion"`
 	Data       map[string]interface{} `json:"data"`
 	Error      string                 `json:"error,omitempty"`
+	Prompt     string                 `json:"prompt,omitempty"` // 使用的提示词及版本，如 market_analysis@builtin
+	Engine     string                 `json:"engine,omitempty"` // 实际回答的引擎，回退时为备用引擎
+	Usage      *Usage                 `json:"usage,omitempty"`
+	Tools      []string               `json:"tools,omitempty"` // 回答前调用的工具，按调用顺序
+	ToolCalls  []ToolCall             `json:"-"`               // LLM请求的工具调用，由 callWithTools 处理
 }
 
 // NewLLMService 创建一个新的LLM服务
+// 配置了 deepseek_api、qwen_api 时以旧版接口注册 deepseek、qwen 引擎，providers 中的同名引擎优先
 func NewLLMService(cfg *config.Config) *LLMService {
+	providers := make(map[string]Provider)
+	if cfg.LLM.DeepseekAPI != "" {
+		providers["deepseek"] = &legacyProvider{url: cfg.LLM.DeepseekAPI, apiKey: cfg.LLM.APIKey}
+	}
+	if cfg.LLM.QwenAPI != "" {
+		providers["qwen"] = &legacyProvider{url: cfg.LLM.QwenAPI, apiKey: cfg.LLM.APIKey}
+	}
+	for _, providerCfg := range cfg.LLM.Providers {
+		provider, err := newProvider(providerCfg, cfg.LLM.APIKey)
+		if err != nil {
+			logrus.Errorf("创建LLM引擎 %s 失败: %v", providerCfg.Name, err)
+			continue
+		}
+		providers[providerCfg.Name] = provider
+	}
+
 	return &LLMService{
-		cfg: cfg,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-		deepseekAPI:   cfg.LLM.DeepseekAPI,
-		qwenAPI:       cfg.LLM.QwenAPI,
+		cfg:           cfg,
+		httpClient:    &http.Client{}, // 超时由每次请求的 context 控制，见 requestTimeout
+		streamClient:  &http.Client{},
+		providers:     providers,
 		defaultEngine: cfg.LLM.DefaultEngine,
+		prompts:       defaultPrompts(),
+		usage:         newUsageTracker(cfg),
 	}
 }
 
-// AnalyzeMarket 使用LLM分析市场情况
-func (s *LLMService) AnalyzeMarket(marketData map[string]interface{}) (*LLMResponse, error) {
-	prompt := "分析以下市场数据，提供市场趋势分析和交易建议：\n"
+// SetPrompts 设置自定义的提示词模板，见 LoadPrompts
+func (s *LLMService) SetPrompts(prompts *Prompts) {
+	s.prompts = prompts
+}
 
-	marketDataJSON, err := json.Marshal(marketData)
-	if err != nil {
-		return nil, fmt.Errorf("市场数据序列化失败: %v", err)
+// Prompts 返回各提示词当前使用的版本
+func (s *LLMService) Prompts() []PromptInfo {
+	return s.prompts.List()
+}
+
+// WithEngine 返回使用指定引擎的LLM服务，与原服务共享HTTP客户端和监控指标，name 为空时返回原服务
+func (s *LLMService) WithEngine(name string) (*LLMService, error) {
+	if name == "" || name == s.defaultEngine {
+		return s, nil
+	}
+	if _, ok := s.providers[name]; !ok {
+		return nil, fmt.Errorf("未知的LLM引擎: %s", name)
 	}
 
-	prompt += string(marketDataJSON)
+	service := *s
+	service.defaultEngine = name
+	return &service, nil
+}
+
+// Engine 返回本服务使用的LLM引擎名称
+func (s *LLMService) Engine() string {
+	return s.defaultEngine
+}
+
+// Engines 返回已配置的LLM引擎名称
+func (s *LLMService) Engines() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	return s.callLLM(prompt, map[string]interface{}{
+// AnalyzeMarket 使用LLM分析市场情况
+func (s *LLMService) AnalyzeMarket(marketData map[string]interface{}) (*LLMResponse, error) {
+	return s.complete(PromptMarketAnalysis, map[string]interface{}{"data": marketData}, map[string]interface{}{
 		"temperature": 0.2,
 		"max_tokens":  1000,
 	})
@@ -59,16 +123,7 @@ func (s *LLMService) AnalyzeMarket(marketData map[string]interface{}) (*LLMRespo
 
 // OptimizeStrategy 优化交易策略
 func (s *LLMService) OptimizeStrategy(strategyData map[string]interface{}) (*LLMResponse, error) {
-	prompt := "分析以下交易策略的历史表现，并提供优化建议：\n"
-
-	strategyDataJSON, err := json.Marshal(strategyData)
-	if err != nil {
-		return nil, fmt.Errorf("策略数据序列化失败: %v", err)
-	}
-
-	prompt += string(strategyDataJSON)
-
-	return s.callLLM(prompt, map[string]interface{}{
+	return s.complete(PromptOptimizeStrategy, map[string]interface{}{"data": strategyData}, map[string]interface{}{
 		"temperature": 0.3,
 		"max_tokens":  1200,
 	})
@@ -76,53 +131,29 @@ func (s *LLMService) OptimizeStrategy(strategyData map[string]interface{}) (*LLM
 
 // GetTradingRecommendations 获取交易建议
 func (s *LLMService) GetTradingRecommendations(marketData map[string]interface{}, userPreferences map[string]interface{}) (*LLMResponse, error) {
-	prompt := "基于以下市场数据和用户偏好，提供个性化交易建议：\n"
-
-	data := map[string]interface{}{
-		"market_data":      marketData,
-		"user_preferences": userPreferences,
-	}
-
-	dataJSON, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("数据序列化失败: %v", err)
-	}
-
-	prompt += string(dataJSON)
-
-	return s.callLLM(prompt, map[string]interface{}{
+	return s.complete(PromptTradingRecommendations, map[string]interface{}{
+		"data": map[string]interface{}{
+			"market_data":      marketData,
+			"user_preferences": userPreferences,
+		},
+	}, map[string]interface{}{
 		"temperature": 0.4,
 		"max_tokens":  1000,
 	})
 }
 
-// AnswerQuestion 回答用户问题
-func (s *LLMService) AnswerQuestion(question string, context map[string]interface{}) (*LLMResponse, error) {
-	prompt := fmt.Sprintf("问题: %s\n\n上下文: ", question)
-
-	if context != nil {
-		contextJSON, err := json.Marshal(context)
-		if err != nil {
-			return nil, fmt.Errorf("上下文序列化失败: %v", err)
-		}
-		prompt += string(contextJSON)
-	}
-
-	return s.callLLM(prompt, map[string]interface{}{
+// AnswerQuestion 回答用户问题，history 为同一会话中之前的问答，在本次提问前发送给LLM
+func (s *LLMService) AnswerQuestion(question string, context map[string]interface{}, history []ChatMessage) (*LLMResponse, error) {
+	return s.complete(PromptAnswerQuestion, map[string]interface{}{"question": question, "data": context}, map[string]interface{}{
 		"temperature": 0.5,
 		"max_tokens":  800,
+		"history":     history,
 	})
 }
 
 // AnalyzeNews 分析新闻情感
 func (s *LLMService) AnalyzeNews(newsArticles []map[string]string) (*LLMResponse, error) {
-	prompt := "分析以下加密货币相关新闻文章，提供情感分析和可能的市场影响：\n"
-
-	for i, article := range newsArticles {
-		prompt += fmt.Sprintf("\n文章 %d: %s\n内容: %s\n", i+1, article["title"], article["content"])
-	}
-
-	return s.callLLM(prompt, map[string]interface{}{
+	return s.complete(PromptNewsAnalysis, map[string]interface{}{"data": newsArticles}, map[string]interface{}{
 		"temperature": 0.2,
 		"max_tokens":  1000,
 	})
@@ -130,16 +161,7 @@ func (s *LLMService) AnalyzeNews(newsArticles []map[string]string) (*LLMResponse
 
 // ExplainTrade 解释交易
 func (s *LLMService) ExplainTrade(tradeData map[string]interface{}) (*LLMResponse, error) {
-	prompt := "以通俗易懂的语言解释以下交易的逻辑和执行情况：\n"
-
-	tradeDataJSON, err := json.Marshal(tradeData)
-	if err != nil {
-		return nil, fmt.Errorf("交易数据序列化失败: %v", err)
-	}
-
-	prompt += string(tradeDataJSON)
-
-	return s.callLLM(prompt, map[string]interface{}{
+	return s.complete(PromptExplainTrade, map[string]interface{}{"data": tradeData}, map[string]interface{}{
 		"temperature": 0.3,
 		"max_tokens":  500,
 	})
@@ -147,16 +169,7 @@ func (s *LLMService) ExplainTrade(tradeData map[string]interface{}) (*LLMRespons
 
 // AnalyzePortfolioRisk 分析投资组合风险
 func (s *LLMService) AnalyzePortfolioRisk(portfolioData map[string]interface{}) (*LLMResponse, error) {
-	prompt := "分析以下投资组合的风险状况，并提供风险管理建议：\n"
-
-	portfolioDataJSON, err := json.Marshal(portfolioData)
-	if err != nil {
-		return nil, fmt.Errorf("投资组合数据序列化失败: %v", err)
-	}
-
-	prompt += string(portfolioDataJSON)
-
-	return s.callLLM(prompt, map[string]interface{}{
+	return s.complete(PromptPortfolioRisk, map[string]interface{}{"data": portfolioData}, map[string]interface{}{
 		"temperature": 0.2,
 		"max_tokens":  800,
 	})
@@ -164,84 +177,154 @@ func (s *LLMService) AnalyzePortfolioRisk(portfolioData map[string]interface{})
 
 // GetMarketSummary 获取市场摘要
 func (s *LLMService) GetMarketSummary(marketData map[string]interface{}) (*LLMResponse, error) {
-	prompt := "根据以下市场数据，提供简洁的市场趋势摘要：\n"
+	return s.complete(PromptMarketSummary, map[string]interface{}{"data": marketData}, map[string]interface{}{
+		"temperature": 0.3,
+		"max_tokens":  400,
+	})
+}
 
-	marketDataJSON, err := json.Marshal(marketData)
+// complete 使用提示词模板生成提示词并调用LLM，回答中记录使用的提示词版本和用量
+// 本月费用超出预算时只允许必要的提示词
+func (s *LLMService) complete(name string, vars map[string]interface{}, params map[string]interface{}) (*LLMResponse, error) {
+	if err := s.usage.allow(name); err != nil {
+		return nil, err
+	}
+	prompt, version, err := s.prompts.Render(name, vars)
 	if err != nil {
-		return nil, fmt.Errorf("市场数据序列化失败: %v", err)
+		return nil, err
 	}
 
-	prompt += string(marketDataJSON)
-
-	return s.callLLM(prompt, map[string]interface{}{
-		"temperature": 0.3,
-		"max_tokens":  400,
-	})
+	response, err := s.callWithTools(prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	response.Prompt = name + "@" + version
+	s.recordUsage(name, prompt, response)
+	return response, nil
 }
 
-// callLLM 调用LLM API
+// callLLM 调用LLM API并记录请求耗时
+// 当前引擎重试后仍失败时，若配置了其他备用引擎则改用备用引擎
 func (s *LLMService) callLLM(prompt string, params map[string]interface{}) (*LLMResponse, error) {
-	var apiURL string
-
-	// 根据配置选择使用的LLM引擎
-	switch s.defaultEngine {
-	case "deepseek":
-		apiURL = s.deepseekAPI
-	case "qwen":
-		apiURL = s.qwenAPI
-	default:
-		return nil, fmt.Errorf("未知的LLM引擎: %s", s.defaultEngine)
+	response, err := s.callEngine(s.defaultEngine, prompt, params)
+	if err == nil {
+		return response, nil
 	}
 
-	// 构建请求体
-	requestBody := map[string]interface{}{
-		"prompt": prompt,
+	fallback := s.cfg.LLM.FallbackEngine
+	if fallback == "" || fallback == s.defaultEngine {
+		return nil, err
 	}
+	logrus.Warnf("LLM引擎 %s 请求失败，改用备用引擎 %s: %v", s.defaultEngine, fallback, err)
+	return s.callEngine(fallback, prompt, params)
+}
 
-	// 添加其他参数
-	for k, v := range params {
-		requestBody[k] = v
+// callEngine 使用指定引擎发送非流式请求，网络错误和5xx/429响应按指数退避重试
+func (s *LLMService) callEngine(engine string, prompt string, params map[string]interface{}) (*LLMResponse, error) {
+	provider, ok := s.providers[engine]
+	if !ok {
+		return nil, fmt.Errorf("未知的LLM引擎: %s", engine)
 	}
 
-	requestJSON, err := json.Marshal(requestBody)
+	start := time.Now()
+	backoff := retryBackoff
+	var response *LLMResponse
+	var err error
+	for attempt := 0; ; attempt++ {
+		response, err = s.requestLLM(provider, prompt, params)
+		if err == nil || attempt >= s.cfg.LLM.RetryAttempts || !retryable(err) {
+			break
+		}
+
+		logrus.Warnf("LLM引擎 %s 请求失败，%v 后第%d次重试: %v", engine, backoff, attempt+1, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+	s.metrics.ObserveLLMRequest(engine, "request", time.Since(start), err)
 	if err != nil {
-		return nil, fmt.Errorf("请求体序列化失败: %v", err)
+		return nil, err
 	}
+	response.Engine = engine
+	return response, nil
+}
 
-	// 创建HTTP请求
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(string(requestJSON)))
+// requestLLM 发送一次非流式请求并解析响应，超过配置的超时时间时取消请求
+func (s *LLMService) requestLLM(provider Provider, prompt string, params map[string]interface{}) (*LLMResponse, error) {
+	req, err := s.newRequest(provider, prompt, params)
 	if err != nil {
-		return nil, fmt.Errorf("创建HTTP请求失败: %v", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if s.cfg.LLM.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+s.cfg.LLM.APIKey)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout())
+	defer cancel()
 
 	// 发送请求
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("发送LLM API请求失败: %v", err)
+		return nil, &requestError{message: fmt.Sprintf("发送LLM API请求失败: %v", err), retryable: true}
 	}
 	defer resp.Body.Close()
 
 	// 读取响应
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
+		return nil, &requestError{message: fmt.Sprintf("读取响应失败: %v", err), retryable: true}
 	}
 
 	// 检查状态码
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("LLM API返回错误: %s, 状态码: %d", string(respBody), resp.StatusCode)
+		return nil, &requestError{
+			message:   fmt.Sprintf("LLM API返回错误: %s, 状态码: %d", string(respBody), resp.StatusCode),
+			retryable: resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests,
+		}
 	}
 
 	// 解析响应
-	var llmResponse LLMResponse
-	if err := json.Unmarshal(respBody, &llmResponse); err != nil {
-		return nil, fmt.Errorf("解析LLM响应失败: %v, 响应体: %s", err, string(respBody))
+	return provider.ParseResponse(respBody)
+}
+
+// provider 返回本服务使用的LLM引擎
+func (s *LLMService) provider() (Provider, error) {
+	provider, ok := s.providers[s.defaultEngine]
+	if !ok {
+		return nil, fmt.Errorf("未知的LLM引擎: %s", s.defaultEngine)
+	}
+	return provider, nil
+}
+
+// newRequest 构建LLM API请求，提示词作为一条用户消息发送
+// params 支持 temperature、max_tokens、stream、json、history 和工具调用的 tools、tool_messages、disable_tools
+// history 为在提示词之前发送的历史消息，tool_messages 为提示词之后的工具调用及其结果
+func (s *LLMService) newRequest(provider Provider, prompt string, params map[string]interface{}) (*http.Request, error) {
+	history, _ := params["history"].([]ChatMessage)
+	toolMessages, _ := params["tool_messages"].([]ChatMessage)
+	messages := append(append([]ChatMessage(nil), history...), ChatMessage{Role: "user", Content: prompt})
+	req := ChatRequest{
+		Messages: append(messages, toolMessages...),
+	}
+	if tools, ok := params["tools"].([]Tool); ok {
+		req.Tools = tools
+	}
+	if disableTools, ok := params["disable_tools"].(bool); ok {
+		req.DisableTools = disableTools
+	}
+	if temperature, ok := params["temperature"].(float64); ok {
+		req.Temperature = temperature
+	}
+	if stream, ok := params["stream"].(bool); ok {
+		req.Stream = stream
+	}
+	if jsonOutput, ok := params["json"].(bool); ok {
+		req.JSONOutput = jsonOutput
+	}
+
+	// 按输入规模调整输出token预算，避免大型投资组合的回答被截断
+	if maxTokens, ok := params["max_tokens"].(int); ok {
+		req.MaxTokens = s.scaleMaxTokens(prompt, maxTokens)
 	}
 
-	return &llmResponse, nil
+	return provider.NewRequest(req)
 }
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autotransaction/config"
+)
+
+// newTestService 返回使用模拟 OpenAI 兼容接口的LLM服务，handler 处理 /chat/completions 请求
+func newTestService(t *testing.T, handler http.HandlerFunc, configure func(cfg *config.Config)) *LLMService {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.DefaultEngine = "test"
+	cfg.LLM.Providers = []config.LLMProviderConfig{{Name: "test", Type: "openai", BaseURL: server.URL, Model: "test-model"}}
+	if configure != nil {
+		configure(cfg)
+	}
+	return NewLLMService(cfg)
+}
+
+// writeCompletion 以非流式 chat completions 格式返回回答
+func writeCompletion(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{{"message": map[string]string{"content": content}}},
+		"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5},
+	})
+}
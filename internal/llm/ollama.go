@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ollamaBaseURL 未配置地址时使用的本机 Ollama 地址
+const ollamaBaseURL = "http://localhost:11434"
+
+// ollamaProvider 本地 Ollama 的 /api/chat 接口，流式响应为逐行的 JSON 而不是 SSE
+type ollamaProvider struct {
+	baseURL string
+	model   string
+}
+
+// ollamaResponse Ollama 的响应，流式响应的每一行格式相同
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+
+	PromptEvalCount int `json:"prompt_eval_count"` // 输入token数
+	EvalCount       int `json:"eval_count"`        // 输出token数
+}
+
+// ollamaMessage Ollama 的消息，工具调用的参数为 JSON 对象且没有调用ID
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // tool 消息对应的工具名称
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+func (p *ollamaProvider) Endpoint() string {
+	return p.baseURL + "/api/chat"
+}
+
+func (p *ollamaProvider) NewRequest(req ChatRequest) (*http.Request, error) {
+	options := map[string]interface{}{
+		"temperature": req.Temperature,
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+
+	// Ollama 默认流式返回，非流式请求需要显式关闭
+	body := map[string]interface{}{
+		"model":    p.model,
+		"messages": ollamaMessages(req.Messages),
+		"stream":   req.Stream,
+		"options":  options,
+	}
+	if req.JSONOutput {
+		body["format"] = "json"
+	}
+	// Ollama 不支持禁止调用工具，达到调用轮数上限时不再发送工具定义
+	if len(req.Tools) > 0 && !req.DisableTools {
+		body["tools"] = openAITools(req.Tools)
+	}
+	return newJSONRequest(p.Endpoint(), body)
+}
+
+func (p *ollamaProvider) ParseResponse(body []byte) (*LLMResponse, error) {
+	var response ollamaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析LLM响应失败: %v, 响应体: %s", err, string(body))
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("LLM API返回错误: %s", response.Error)
+	}
+	result := &LLMResponse{
+		Completion: response.Message.Content,
+		Usage:      newUsage(response.PromptEvalCount, response.EvalCount),
+	}
+	for i, call := range response.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return result, nil
+}
+
+// ollamaMessages 将消息转换为 Ollama 接口的格式
+func ollamaMessages(messages []ChatMessage) []ollamaMessage {
+	result := make([]ollamaMessage, 0, len(messages))
+	for _, message := range messages {
+		item := ollamaMessage{Role: message.Role, Content: message.Content, ToolName: message.ToolName}
+		for _, call := range message.ToolCalls {
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = call.Arguments
+			item.ToolCalls = append(item.ToolCalls, toolCall)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func (p *ollamaProvider) ParseStreamLine(line string) (string, bool, error) {
+	var chunk ollamaResponse
+	if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+		return "", false, fmt.Errorf("解析LLM流式响应失败: %v, 数据: %s", err, line)
+	}
+	if chunk.Error != "" {
+		return "", false, fmt.Errorf("LLM流式响应返回错误: %s", chunk.Error)
+	}
+	return chunk.Message.Content, chunk.Done, nil
+}
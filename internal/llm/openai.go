@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// openAIBaseURL 未配置地址时使用的 OpenAI 接口地址
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// openAIProvider OpenAI 兼容的 chat completions 接口，DeepSeek、通义千问兼容模式等也使用该格式
+type openAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// openAIError OpenAI 接口的错误信息
+type openAIError struct {
+	Message string `json:"message"`
+}
+
+func (p *openAIProvider) Endpoint() string {
+	return p.baseURL + "/chat/completions"
+}
+
+func (p *openAIProvider) NewRequest(req ChatRequest) (*http.Request, error) {
+	body := map[string]interface{}{
+		"model":       p.model,
+		"messages":    openAIMessages(req.Messages),
+		"temperature": req.Temperature,
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if req.Stream {
+		body["stream"] = true
+	}
+	if req.JSONOutput {
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = openAITools(req.Tools)
+		if req.DisableTools {
+			body["tool_choice"] = "none"
+		}
+	}
+
+	httpReq, err := newJSONRequest(p.Endpoint(), body)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return httpReq, nil
+}
+
+func (p *openAIProvider) ParseResponse(body []byte) (*LLMResponse, error) {
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"` // JSON 字符串
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("解析LLM响应失败: %v, 响应体: %s", err, string(body))
+	}
+	if completion.Error != nil {
+		return nil, fmt.Errorf("LLM API返回错误: %s", completion.Error.Message)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("LLM响应中没有回答: %s", string(body))
+	}
+
+	message := completion.Choices[0].Message
+	response := &LLMResponse{
+		Completion: message.Content,
+		Usage:      newUsage(completion.Usage.PromptTokens, completion.Usage.CompletionTokens),
+	}
+	for _, call := range message.ToolCalls {
+		if call.Function.Arguments == "" {
+			call.Function.Arguments = "{}"
+		}
+		response.ToolCalls = append(response.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	return response, nil
+}
+
+func (p *openAIProvider) ParseStreamLine(line string) (string, bool, error) {
+	payload, ok := sseData(line)
+	if !ok {
+		return "", false, nil
+	}
+	if payload == streamDone {
+		return "", true, nil
+	}
+
+	var chunk struct {
+		Choices []struct {
+			Delta ChatMessage `json:"delta"`
+		} `json:"choices"`
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+		return "", false, fmt.Errorf("解析LLM流式响应失败: %v, 数据: %s", err, payload)
+	}
+	if chunk.Error != nil {
+		return "", false, fmt.Errorf("LLM流式响应返回错误: %s", chunk.Error.Message)
+	}
+	if len(chunk.Choices) == 0 {
+		return "", false, nil
+	}
+	return chunk.Choices[0].Delta.Content, false, nil
+}
+
+// openAIMessages 将消息转换为 OpenAI 接口的格式，工具调用的参数以 JSON 字符串发送
+func openAIMessages(messages []ChatMessage) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		item := map[string]interface{}{
+			"role":    message.Role,
+			"content": message.Content,
+		}
+		if len(message.ToolCalls) > 0 {
+			calls := make([]map[string]interface{}, 0, len(message.ToolCalls))
+			for _, call := range message.ToolCalls {
+				calls = append(calls, map[string]interface{}{
+					"id":   call.ID,
+					"type": "function",
+					"function": map[string]interface{}{
+						"name":      call.Name,
+						"arguments": string(call.Arguments),
+					},
+				})
+			}
+			item["tool_calls"] = calls
+		}
+		if message.ToolCallID != "" {
+			item["tool_call_id"] = message.ToolCallID
+		}
+		result = append(result, item)
+	}
+	return result
+}
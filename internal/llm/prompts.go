@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"autotransaction/config"
+)
+
+// 提示词名称，与 config.LLMPromptNames 一致
+const (
+	PromptMarketAnalysis          = "market_analysis"
+	PromptOptimizeStrategy        = "optimize_strategy"
+	PromptTradingRecommendations  = "trading_recommendations"
+	PromptAnswerQuestion          = "answer_question"
+	PromptNewsAnalysis            = "news_analysis"
+	PromptExplainTrade            = "explain_trade"
+	PromptPortfolioRisk           = "portfolio_risk"
+	PromptMarketSummary           = "market_summary"
+	PromptMarketSentiment         = "market_sentiment"
+	PromptStrategyRecommendations = "strategy_recommendations"
+	PromptExplainMarketMovements  = "explain_market_movements"
+	PromptPortfolioSummary        = "portfolio_summary"
+	PromptTradeSuggestions        = "trade_suggestions"
+	PromptSentimentScore          = "sentiment_score"
+	PromptStrategyProposals       = "strategy_proposals"
+)
+
+const (
+	// builtinVersion 内置模板的版本
+	builtinVersion = "builtin"
+	// customVersion 未指定版本的自定义模板的版本
+	customVersion = "custom"
+	// promptFileExt 模板文件的扩展名
+	promptFileExt = ".tmpl"
+)
+
+// builtinPrompts 内置的提示词模板
+// 模板变量: .data 为提供给LLM的数据；answer_question 另有 .question；trade_suggestions、sentiment_score 和 strategy_proposals 另有 .schema，
+// 为要求LLM输出的 JSON 格式，自定义模板必须保留，否则无法解析回答
+// 模板函数: json 将值序列化为 JSON，add 对两个整数求和
+var builtinPrompts = map[string]string{
+	PromptMarketAnalysis:          "分析以下市场数据，提供市场趋势分析和交易建议：\n{{json .data}}",
+	PromptOptimizeStrategy:        "分析以下交易策略的历史表现，并提供优化建议：\n{{json .data}}",
+	PromptTradingRecommendations:  "基于以下市场数据和用户偏好，提供个性化交易建议：\n{{json .data}}",
+	PromptAnswerQuestion:          "问题: {{.question}}\n\n上下文: {{if .data}}{{json .data}}{{end}}",
+	PromptNewsAnalysis:            "分析以下加密货币相关新闻文章，提供情感分析和可能的市场影响：\n{{range $i, $article := .data}}\n文章 {{add $i 1}}: {{$article.title}}\n内容: {{$article.content}}\n{{end}}",
+	PromptExplainTrade:            "以通俗易懂的语言解释以下交易的逻辑和执行情况：\n{{json .data}}",
+	PromptPortfolioRisk:           "分析以下投资组合的风险状况，并提供风险管理建议：\n{{json .data}}",
+	PromptMarketSummary:           "根据以下市场数据，提供简洁的市场趋势摘要：\n{{json .data}}",
+	PromptMarketSentiment:         "分析以下市场数据和新闻，提供关于整体市场情绪的评估（看涨、看跌或中性）及其原因：\n{{json .data}}",
+	PromptStrategyRecommendations: "基于以下用户偏好和当前市场状况，推荐适合的交易策略：\n{{json .data}}",
+	PromptExplainMarketMovements:  "基于以下市场数据和新闻，解释最近的市场走势及其可能的原因：\n{{json .data}}",
+	PromptPortfolioSummary:        "基于以下投资组合数据，提供简洁的自然语言摘要，包括总价值、主要资产、表现和风险评估：\n{{json .data}}",
+	PromptTradeSuggestions: "基于以下市场数据和用户偏好，提供具体的交易建议。" +
+		"只输出一个符合以下格式的 JSON 对象，不要输出其他内容；没有合适的交易机会时 recommendations 为空数组：\n{{.schema}}\n{{json .data}}",
+	PromptSentimentScore: "分析以下加密货币新闻，分别评估新闻对每个资产的市场情绪。" +
+		"只输出一个符合以下格式的 JSON 对象，不要输出其他内容；新闻没有涉及的资产不要给出评分：\n{{.schema}}\n{{json .data}}",
+	PromptStrategyProposals: "以下是一个交易策略的类型、当前参数、实盘表现，以及之前各组参数在训练区间和测试区间的回测结果。" +
+		"提出 {{.data.count}} 组新的参数以提高测试区间的表现，避免只在训练区间有效的过拟合参数，不要重复已回测过的参数。" +
+		"只输出一个符合以下格式的 JSON 对象，不要输出其他内容：\n{{.schema}}\n{{json .data}}",
+}
+
+// promptFuncs 模板中可用的函数
+var promptFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("数据序列化失败: %v", err)
+		}
+		return string(data), nil
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+}
+
+// promptTemplate 一个版本的提示词模板
+type promptTemplate struct {
+	version  string
+	source   string // builtin、config 或模板文件路径
+	text     string
+	template *template.Template
+}
+
+// PromptInfo 提示词当前使用的版本和可用的版本
+type PromptInfo struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Source   string   `json:"source"`
+	Versions []string `json:"versions"`
+	Template string   `json:"template"`
+}
+
+// Prompts 各提示词的模板，每个提示词可以有多个版本，渲染时使用选定的版本
+type Prompts struct {
+	versions map[string]map[string]*promptTemplate // 名称 -> 版本 -> 模板
+	active   map[string]string                     // 名称 -> 使用的版本
+}
+
+// defaultPrompts 只包含内置模板的提示词
+func defaultPrompts() *Prompts {
+	prompts := &Prompts{
+		versions: make(map[string]map[string]*promptTemplate),
+		active:   make(map[string]string),
+	}
+	for name, text := range builtinPrompts {
+		if err := prompts.add(name, builtinVersion, builtinVersion, text); err != nil {
+			panic(err)
+		}
+		prompts.active[name] = builtinVersion
+	}
+	return prompts
+}
+
+// LoadPrompts 加载模板文件和配置中的自定义模板，并按配置选择各提示词使用的版本
+func LoadPrompts(cfg config.LLMPromptsConfig) (*Prompts, error) {
+	prompts := defaultPrompts()
+
+	if cfg.Dir != "" {
+		paths, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+promptFileExt))
+		if err != nil {
+			return nil, fmt.Errorf("查找模板文件失败: %v", err)
+		}
+		for _, path := range paths {
+			name, version := strings.TrimSuffix(filepath.Base(path), promptFileExt), customVersion
+			if i := strings.Index(name, "@"); i >= 0 {
+				name, version = name[:i], name[i+1:]
+			}
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("读取模板文件 %s 失败: %v", path, err)
+			}
+			if err := prompts.add(name, version, path, string(text)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, templateCfg := range cfg.Templates {
+		version := templateCfg.Version
+		if version == "" {
+			version = customVersion
+		}
+		if err := prompts.add(templateCfg.Prompt, version, "config", templateCfg.Template); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, versions := range prompts.versions {
+		version, err := selectVersion(name, versions, cfg.Versions[name])
+		if err != nil {
+			return nil, err
+		}
+		prompts.active[name] = version
+	}
+	return prompts, nil
+}
+
+// selectVersion 选择提示词使用的版本，未指定时使用唯一的自定义版本，没有自定义版本时使用内置模板
+func selectVersion(name string, versions map[string]*promptTemplate, selected string) (string, error) {
+	if selected != "" {
+		if _, ok := versions[selected]; !ok {
+			return "", fmt.Errorf("提示词 %s 没有版本 %s", name, selected)
+		}
+		return selected, nil
+	}
+
+	custom := make([]string, 0)
+	for version := range versions {
+		if version != builtinVersion {
+			custom = append(custom, version)
+		}
+	}
+	switch len(custom) {
+	case 0:
+		return builtinVersion, nil
+	case 1:
+		return custom[0], nil
+	default:
+		sort.Strings(custom)
+		return "", fmt.Errorf("提示词 %s 有多个版本 (%s)，请在 llm.prompts.versions 中指定使用的版本",
+			name, strings.Join(custom, ", "))
+	}
+}
+
+// add 解析并添加一个版本的模板
+func (p *Prompts) add(name, version, source, text string) error {
+	if _, ok := builtinPrompts[name]; !ok {
+		return fmt.Errorf("未知的提示词 %s (%s)", name, source)
+	}
+	if existing, ok := p.versions[name][version]; ok {
+		return fmt.Errorf("提示词 %s 的版本 %s 重复定义: %s 和 %s", name, version, existing.source, source)
+	}
+
+	parsed, err := template.New(name + "@" + version).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("解析提示词 %s 的模板 (%s) 失败: %v", name, source, err)
+	}
+
+	if p.versions[name] == nil {
+		p.versions[name] = make(map[string]*promptTemplate)
+	}
+	p.versions[name][version] = &promptTemplate{version: version, source: source, text: text, template: parsed}
+	return nil
+}
+
+// Render 使用提示词当前的版本渲染模板，返回提示词和使用的版本
+func (p *Prompts) Render(name string, vars map[string]interface{}) (string, string, error) {
+	prompt, ok := p.versions[name][p.active[name]]
+	if !ok {
+		return "", "", fmt.Errorf("未知的提示词: %s", name)
+	}
+
+	var builder strings.Builder
+	if err := prompt.template.Execute(&builder, vars); err != nil {
+		return "", "", fmt.Errorf("生成提示词 %s@%s 失败: %v", name, prompt.version, err)
+	}
+	return builder.String(), prompt.version, nil
+}
+
+// List 返回各提示词当前使用的版本，按名称排序
+func (p *Prompts) List() []PromptInfo {
+	list := make([]PromptInfo, 0, len(p.versions))
+	for name, versions := range p.versions {
+		prompt := versions[p.active[name]]
+		info := PromptInfo{
+			Name:     name,
+			Version:  prompt.version,
+			Source:   prompt.source,
+			Versions: make([]string, 0, len(versions)),
+			Template: prompt.text,
+		}
+		for version := range versions {
+			info.Versions = append(info.Versions, version)
+		}
+		sort.Strings(info.Versions)
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
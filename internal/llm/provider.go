@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"autotransaction/config"
+)
+
+// ChatMessage 对话中的一条消息
+type ChatMessage struct {
+	Role    string `json:"role"` // system, user, assistant, tool
+	Content string `json:"content"`
+
+	// 工具调用相关的字段由各引擎转换为各自的格式
+	ToolCalls  []ToolCall `json:"-"` // assistant 消息中LLM请求的工具调用
+	ToolCallID string     `json:"-"` // tool 消息对应的工具调用ID
+	ToolName   string     `json:"-"` // tool 消息对应的工具名称
+}
+
+// ChatRequest 一次对话请求
+type ChatRequest struct {
+	Messages    []ChatMessage
+	Temperature float64
+	MaxTokens   int
+	Stream      bool
+	JSONOutput  bool // 要求回答为 JSON 对象，支持的引擎使用原生的 JSON 输出模式
+
+	Tools        []Tool // LLM可调用的工具，旧版接口忽略
+	DisableTools bool   // 保留工具定义但不允许继续调用，要求LLM直接回答
+}
+
+// Provider LLM引擎，负责构建对应接口格式的请求和解析响应，HTTP请求、超时和回退由 LLMService 统一处理
+type Provider interface {
+	// Endpoint 返回接口地址，用于健康检查
+	Endpoint() string
+	// NewRequest 构建对话请求
+	NewRequest(req ChatRequest) (*http.Request, error)
+	// ParseResponse 解析非流式响应体
+	ParseResponse(body []byte) (*LLMResponse, error)
+	// ParseStreamLine 解析流式响应的一行，返回增量文本，done 表示响应结束
+	ParseStreamLine(line string) (chunk string, done bool, err error)
+}
+
+// newProvider 按配置创建LLM引擎，apiKey 为未单独配置密钥时使用的 llm.api_key
+func newProvider(cfg config.LLMProviderConfig, apiKey string) (Provider, error) {
+	if cfg.APIKey != "" {
+		apiKey = cfg.APIKey
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+
+	switch cfg.Type {
+	case "openai":
+		if baseURL == "" {
+			baseURL = openAIBaseURL
+		}
+		return &openAIProvider{baseURL: baseURL, apiKey: apiKey, model: cfg.Model}, nil
+	case "anthropic":
+		if baseURL == "" {
+			baseURL = anthropicBaseURL
+		}
+		return &anthropicProvider{baseURL: baseURL, apiKey: apiKey, model: cfg.Model}, nil
+	case "ollama":
+		if baseURL == "" {
+			baseURL = ollamaBaseURL
+		}
+		return &ollamaProvider{baseURL: baseURL, model: cfg.Model}, nil
+	default:
+		return nil, fmt.Errorf("未知的LLM引擎类型: %s", cfg.Type)
+	}
+}
+
+// newJSONRequest 以 JSON 请求体构建 POST 请求
+func newJSONRequest(url string, body interface{}) (*http.Request, error) {
+	requestJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("请求体序列化失败: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(requestJSON))
+	if err != nil {
+		return nil, fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// sseData 取出 SSE 数据行的内容，其他行返回 false
+func sseData(line string) (string, bool) {
+	if !strings.HasPrefix(line, "data:") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "data:")), true
+}
+
+// joinPrompt 将消息拼接为旧版接口的单个提示词
+func joinPrompt(messages []ChatMessage) string {
+	parts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		parts = append(parts, message.Content)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// legacyProvider 旧版 deepseek/qwen 接口，请求体为 prompt，响应为 completion/data/error
+type legacyProvider struct {
+	url    string
+	apiKey string
+}
+
+func (p *legacyProvider) Endpoint() string {
+	return p.url
+}
+
+func (p *legacyProvider) NewRequest(req ChatRequest) (*http.Request, error) {
+	body := map[string]interface{}{
+		"prompt":      joinPrompt(req.Messages),
+		"temperature": req.Temperature,
+		"max_tokens":  req.MaxTokens,
+	}
+	if req.Stream {
+		body["stream"] = true
+	}
+
+	httpReq, err := newJSONRequest(p.url, body)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return httpReq, nil
+}
+
+func (p *legacyProvider) ParseResponse(body []byte) (*LLMResponse, error) {
+	var response LLMResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析LLM响应失败: %v, 响应体: %s", err, string(body))
+	}
+	return &response, nil
+}
+
+func (p *legacyProvider) ParseStreamLine(line string) (string, bool, error) {
+	payload, ok := sseData(line)
+	if !ok {
+		return "", false, nil
+	}
+	if payload == streamDone {
+		return "", true, nil
+	}
+
+	var chunk LLMResponse
+	if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+		return "", false, fmt.Errorf("解析LLM流式响应失败: %v, 数据: %s", err, payload)
+	}
+	if chunk.Error != "" {
+		return "", false, fmt.Errorf("LLM流式响应返回错误: %s", chunk.Error)
+	}
+	return chunk.Completion, false, nil
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tradeRecommendationSchema 交易建议的输出格式，随提示词发送给LLM
+const tradeRecommendationSchema = `{
+  "recommendations": [
+    {
+      "symbol": "string, 交易对，必须是 allowed_symbols 中的一个，如 BTC/USDT",
+      "side": "string, buy 或 sell",
+      "confidence": "number, 0到1之间的置信度",
+      "size": "number, 下单数量（基础货币），大于0",
+      "price": "number, 参考价格（计价货币），大于0",
+      "rationale": "string, 建议的理由"
+    }
+  ]
+}`
+
+// TradeRecommendation LLM给出的一条结构化交易建议
+type TradeRecommendation struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // buy 或 sell
+	Confidence float64 `json:"confidence"`
+	Size       float64 `json:"size"`  // 下单数量（基础货币）
+	Price      float64 `json:"price"` // 参考价格
+	Rationale  string  `json:"rationale"`
+}
+
+// InvalidRecommendation 未通过校验的交易建议
+type InvalidRecommendation struct {
+	Recommendation TradeRecommendation `json:"recommendation"`
+	Reason         string              `json:"reason"`
+}
+
+// TradeSuggestions 交易建议的解析结果
+type TradeSuggestions struct {
+	Recommendations []TradeRecommendation   `json:"recommendations"`
+	Invalid         []InvalidRecommendation `json:"invalid"`
+	Prompt          string                  `json:"prompt"` // 使用的提示词及版本
+}
+
+// Validate 校验交易建议的字段，symbols 为允许的交易对，为空时不限制
+func (r TradeRecommendation) Validate(symbols []string) error {
+	if r.Symbol == "" {
+		return fmt.Errorf("缺少交易对")
+	}
+	if len(symbols) > 0 {
+		allowed := false
+		for _, symbol := range symbols {
+			if symbol == r.Symbol {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("未配置的交易对: %s", r.Symbol)
+		}
+	}
+	if r.Side != "buy" && r.Side != "sell" {
+		return fmt.Errorf("无效的交易方向: %s", r.Side)
+	}
+	if r.Confidence < 0 || r.Confidence > 1 {
+		return fmt.Errorf("置信度应在0到1之间: %v", r.Confidence)
+	}
+	if r.Size <= 0 {
+		return fmt.Errorf("下单数量应大于0: %v", r.Size)
+	}
+	if r.Price <= 0 {
+		return fmt.Errorf("参考价格应大于0: %v", r.Price)
+	}
+	if strings.TrimSpace(r.Rationale) == "" {
+		return fmt.Errorf("缺少建议理由")
+	}
+	return nil
+}
+
+// GetTradeSuggestions 使用LLM生成结构化的交易建议
+// 提示词中给出输出格式，支持的引擎同时启用 JSON 输出模式；未通过校验的建议放入 Invalid，不会被执行
+func (s *LLMService) GetTradeSuggestions(marketData map[string]interface{}, userPreferences map[string]interface{}) (*TradeSuggestions, error) {
+	symbols := s.enabledSymbols()
+	response, err := s.complete(PromptTradeSuggestions, map[string]interface{}{
+		"schema": tradeRecommendationSchema,
+		"data": map[string]interface{}{
+			"market_data":      marketData,
+			"user_preferences": userPreferences,
+			"allowed_symbols":  symbols,
+			"timestamp":        time.Now().Unix(),
+		},
+	}, map[string]interface{}{
+		"temperature": 0.3,
+		"max_tokens":  1000,
+		"json":        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations, err := parseTradeRecommendations(response.Completion)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := &TradeSuggestions{
+		Recommendations: make([]TradeRecommendation, 0, len(recommendations)),
+		Invalid:         make([]InvalidRecommendation, 0),
+		Prompt:          response.Prompt,
+	}
+	for _, recommendation := range recommendations {
+		recommendation.Symbol = strings.ToUpper(strings.TrimSpace(recommendation.Symbol))
+		recommendation.Side = strings.ToLower(strings.TrimSpace(recommendation.Side))
+		if err := recommendation.Validate(symbols); err != nil {
+			suggestions.Invalid = append(suggestions.Invalid, InvalidRecommendation{Recommendation: recommendation, Reason: err.Error()})
+			continue
+		}
+		suggestions.Recommendations = append(suggestions.Recommendations, recommendation)
+	}
+	return suggestions, nil
+}
+
+// parseTradeRecommendations 从回答中解析交易建议
+// 兼容不支持 JSON 输出模式的引擎在 JSON 外包裹 Markdown 代码块或说明文字的情况
+func parseTradeRecommendations(completion string) ([]TradeRecommendation, error) {
+	object, err := jsonObject(completion)
+	if err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Recommendations []TradeRecommendation `json:"recommendations"`
+	}
+	if err := json.Unmarshal([]byte(object), &output); err != nil {
+		return nil, fmt.Errorf("解析交易建议失败: %v, 回答: %s", err, completion)
+	}
+	return output.Recommendations, nil
+}
+
+// jsonObject 取出回答中第一个 { 到最后一个 } 之间的 JSON 对象
+func jsonObject(completion string) (string, error) {
+	start := strings.Index(completion, "{")
+	end := strings.LastIndex(completion, "}")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("LLM回答中没有 JSON 对象: %s", completion)
+	}
+	return completion[start : end+1], nil
+}
+
+// enabledSymbols 返回已启用的交易对
+func (s *LLMService) enabledSymbols() []string {
+	symbols := make([]string, 0, len(s.cfg.Current().Trading.Pairs))
+	for _, pair := range s.cfg.Current().Trading.Pairs {
+		if pair.Enabled {
+			symbols = append(symbols, pair.Symbol)
+		}
+	}
+	return symbols
+}
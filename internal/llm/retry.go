@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// defaultRequestTimeout 未配置超时时间时单次非流式请求的超时时间
+	defaultRequestTimeout = 60 * time.Second
+	// retryBackoff 第一次重试前的等待时间，之后每次翻倍
+	retryBackoff = time.Second
+	// maxRetryBackoff 重试等待时间的上限
+	maxRetryBackoff = 30 * time.Second
+)
+
+// requestError LLM API请求失败，retryable 表示重试可能成功
+type requestError struct {
+	message   string
+	retryable bool
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// retryable 判断请求错误是否可以重试，只有网络错误、超时和5xx/429响应可以重试
+func retryable(err error) bool {
+	var reqErr *requestError
+	return errors.As(err, &reqErr) && reqErr.retryable
+}
+
+// requestTimeout 返回单次非流式请求的超时时间
+func (s *LLMService) requestTimeout() time.Duration {
+	if s.cfg.LLM.TimeoutSeconds > 0 {
+		return time.Duration(s.cfg.LLM.TimeoutSeconds) * time.Second
+	}
+	return defaultRequestTimeout
+}
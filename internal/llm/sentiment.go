@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sentimentScoreSchema 情绪评分的输出格式，随提示词发送给LLM
+const sentimentScoreSchema = `{
+  "scores": [
+    {
+      "asset": "string, 资产代码，必须是 assets 中的一个，如 BTC",
+      "score": "number, -1到1之间的情绪分数，-1为极度看跌，0为中性，1为极度看涨",
+      "confidence": "number, 0到1之间的置信度，新闻较少或观点分歧时应较低",
+      "summary": "string, 一句话概括情绪的依据"
+    }
+  ]
+}`
+
+// SentimentScore LLM对一个资产的新闻情绪评分
+type SentimentScore struct {
+	Asset      string  `json:"asset"`
+	Score      float64 `json:"score"` // -1 极度看跌 到 1 极度看涨
+	Confidence float64 `json:"confidence"`
+	Summary    string  `json:"summary"`
+}
+
+// Validate 校验情绪评分的字段，assets 为允许的资产
+func (s SentimentScore) Validate(assets []string) error {
+	allowed := false
+	for _, asset := range assets {
+		if asset == s.Asset {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("未关注的资产: %s", s.Asset)
+	}
+	if s.Score < -1 || s.Score > 1 {
+		return fmt.Errorf("情绪分数应在-1到1之间: %v", s.Score)
+	}
+	if s.Confidence < 0 || s.Confidence > 1 {
+		return fmt.Errorf("置信度应在0到1之间: %v", s.Confidence)
+	}
+	return nil
+}
+
+// ScoreSentiment 使用LLM对新闻中各资产的情绪评分，只返回通过校验的评分
+// 新闻中没有涉及的资产可以不给出评分
+func (s *LLMService) ScoreSentiment(newsArticles []map[string]string, assets []string) ([]SentimentScore, error) {
+	response, err := s.complete(PromptSentimentScore, map[string]interface{}{
+		"schema": sentimentScoreSchema,
+		"data": map[string]interface{}{
+			"assets":    assets,
+			"news":      newsArticles,
+			"timestamp": time.Now().Unix(),
+		},
+	}, map[string]interface{}{
+		"temperature": 0.1,
+		"max_tokens":  800,
+		"json":        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := jsonObject(response.Completion)
+	if err != nil {
+		return nil, err
+	}
+	var output struct {
+		Scores []SentimentScore `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(object), &output); err != nil {
+		return nil, fmt.Errorf("解析情绪评分失败: %v, 回答: %s", err, response.Completion)
+	}
+
+	scores := make([]SentimentScore, 0, len(output.Scores))
+	for _, score := range output.Scores {
+		score.Asset = strings.ToUpper(strings.TrimSpace(score.Asset))
+		if err := score.Validate(assets); err != nil {
+			logrus.Warnf("忽略无效的情绪评分: %v", err)
+			continue
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
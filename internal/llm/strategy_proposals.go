@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// strategyProposalSchema 策略参数提议的输出格式，随提示词发送给LLM
+const strategyProposalSchema = `{
+  "proposals": [
+    {
+      "params": "object, 要修改的策略参数及其新值，未列出的参数保持当前值",
+      "rationale": "string, 调整的理由"
+    }
+  ]
+}`
+
+// StrategyProposal LLM提出的一组策略参数调整
+type StrategyProposal struct {
+	Params    map[string]interface{} `json:"params"`
+	Rationale string                 `json:"rationale"`
+}
+
+// StrategyProposals 策略参数提议的解析结果
+type StrategyProposals struct {
+	Proposals []StrategyProposal `json:"proposals"`
+	Prompt    string             `json:"prompt"` // 使用的提示词及版本
+	Usage     *Usage             `json:"usage,omitempty"`
+}
+
+// ProposeStrategyParams 请LLM根据策略的表现和之前的回测结果提出 count 组参数调整
+// strategyData 包含策略类型、当前参数、实盘表现和已回测的参数及结果；没有参数的提议被丢弃
+func (s *LLMService) ProposeStrategyParams(strategyData map[string]interface{}, count int) (*StrategyProposals, error) {
+	data := make(map[string]interface{}, len(strategyData)+1)
+	for key, value := range strategyData {
+		data[key] = value
+	}
+	data["count"] = count
+
+	response, err := s.complete(PromptStrategyProposals, map[string]interface{}{
+		"schema": strategyProposalSchema,
+		"data":   data,
+	}, map[string]interface{}{
+		"temperature": 0.4,
+		"max_tokens":  1000,
+		"json":        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := jsonObject(response.Completion)
+	if err != nil {
+		return nil, err
+	}
+	var output struct {
+		Proposals []StrategyProposal `json:"proposals"`
+	}
+	if err := json.Unmarshal([]byte(object), &output); err != nil {
+		return nil, fmt.Errorf("解析策略参数提议失败: %v, 回答: %s", err, response.Completion)
+	}
+
+	proposals := &StrategyProposals{
+		Proposals: make([]StrategyProposal, 0, len(output.Proposals)),
+		Prompt:    response.Prompt,
+		Usage:     response.Usage,
+	}
+	for _, proposal := range output.Proposals {
+		if len(proposal.Params) == 0 {
+			continue
+		}
+		proposal.Rationale = strings.TrimSpace(proposal.Rationale)
+		proposals.Proposals = append(proposals.Proposals, proposal)
+		if len(proposals.Proposals) == count {
+			break
+		}
+	}
+	return proposals, nil
+}
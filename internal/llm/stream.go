@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// streamDone 流式响应结束标记
+	streamDone = "[DONE]"
+	// defaultStreamIdleTimeout 默认的流式响应停顿超时
+	defaultStreamIdleTimeout = 15 * time.Second
+)
+
+// StreamHandler 接收流式响应的回调
+// OnChunk 在收到每段文本时调用；OnRestart 在流式请求中途失败、改为非流式请求重新生成时调用，
+// 调用方应丢弃已收到的部分内容，最终完整内容以返回的 LLMResponse 为准
+type StreamHandler struct {
+	OnChunk   func(chunk string)
+	OnRestart func()
+}
+
+// AnswerQuestionStream 以流式方式回答用户问题，history 为同一会话中之前的问答
+func (s *LLMService) AnswerQuestionStream(question string, context map[string]interface{}, history []ChatMessage, handler StreamHandler) (*LLMResponse, error) {
+	if err := s.usage.allow(PromptAnswerQuestion); err != nil {
+		return nil, err
+	}
+	prompt, version, err := s.prompts.Render(PromptAnswerQuestion, map[string]interface{}{"question": question, "data": context})
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{
+		"temperature": 0.5,
+		"max_tokens":  800,
+		"history":     history,
+	}
+	var response *LLMResponse
+	if len(s.tools) > 0 {
+		// 调用工具的中间轮次不流式返回，完整回答生成后一次性返回
+		response, err = s.callWithTools(prompt, params)
+		if err == nil && handler.OnChunk != nil {
+			handler.OnChunk(response.Completion)
+		}
+	} else {
+		response, err = s.callLLMStream(prompt, params, handler)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response.Prompt = PromptAnswerQuestion + "@" + version
+	s.recordUsage(PromptAnswerQuestion, prompt, response)
+	return response, nil
+}
+
+// callLLMStream 以流式方式调用LLM API
+// 流式请求出错或停顿超时时，若启用了回退则对同一提示词改用非流式请求，保证调用方得到完整回答
+func (s *LLMService) callLLMStream(prompt string, params map[string]interface{}, handler StreamHandler) (*LLMResponse, error) {
+	start := time.Now()
+	response, err := s.streamLLM(prompt, params, handler)
+	s.metrics.ObserveLLMRequest(s.defaultEngine, "stream", time.Since(start), err)
+	if err == nil {
+		return response, nil
+	}
+
+	if !s.cfg.LLM.StreamFallback {
+		return nil, err
+	}
+
+	logrus.Warnf("LLM流式请求失败，改用非流式请求: %v", err)
+	if handler.OnRestart != nil {
+		handler.OnRestart()
+	}
+	return s.callLLM(prompt, params)
+}
+
+// streamLLM 发送流式请求并逐行读取响应，由引擎解析SSE或逐行JSON格式
+func (s *LLMService) streamLLM(prompt string, params map[string]interface{}, handler StreamHandler) (*LLMResponse, error) {
+	streamParams := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		streamParams[k] = v
+	}
+	streamParams["stream"] = true
+
+	provider, err := s.provider()
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(provider, prompt, streamParams)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// 超过停顿时间未收到数据则取消请求
+	idleTimeout := time.Duration(s.cfg.LLM.StreamIdleTimeoutSeconds) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = defaultStreamIdleTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idleTimer := time.AfterFunc(idleTimeout, cancel)
+	defer idleTimer.Stop()
+
+	resp, err := s.streamClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("发送LLM流式请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("LLM API返回错误: %s, 状态码: %d", string(respBody), resp.StatusCode)
+	}
+
+	var completion strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		idleTimer.Reset(idleTimeout)
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		chunk, done, err := provider.ParseStreamLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		completion.WriteString(chunk)
+		if handler.OnChunk != nil && chunk != "" {
+			handler.OnChunk(chunk)
+		}
+		if done {
+			return &LLMResponse{Completion: completion.String()}, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("LLM流式响应超过 %s 未收到数据", idleTimeout)
+		}
+		return nil, fmt.Errorf("读取LLM流式响应失败: %v", err)
+	}
+
+	// 未收到结束标记即断开，视为中途失败
+	return nil, fmt.Errorf("LLM流式响应意外结束")
+}
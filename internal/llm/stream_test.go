@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"autotransaction/config"
+)
+
+func TestStreamFailureFallsBackToNonStreaming(t *testing.T) {
+	const full = "BTC 近期处于上升趋势，建议继续持有。"
+	streamed := 0
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			writeCompletion(w, full)
+			return
+		}
+
+		// 流式响应返回部分内容后出错
+		streamed++
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"BTC 近期\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "data: {\"error\":{\"message\":\"upstream overloaded\"}}\n\n")
+	}, func(cfg *config.Config) {
+		cfg.LLM.StreamFallback = true
+	})
+
+	var chunks []string
+	restarted := false
+	response, err := service.AnswerQuestionStream("BTC 走势如何？", nil, nil, StreamHandler{
+		OnChunk: func(chunk string) { chunks = append(chunks, chunk) },
+		OnRestart: func() {
+			restarted = true
+			chunks = nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("流式请求失败后应回退为非流式请求: %v", err)
+	}
+	if streamed != 1 {
+		t.Fatalf("应先发送 1 次流式请求，实际 %d 次", streamed)
+	}
+	if !restarted {
+		t.Fatal("回退前应通知调用方丢弃已收到的部分内容")
+	}
+	if response.Completion != full {
+		t.Fatalf("应返回非流式请求的完整回答，实际 %q", response.Completion)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("回退后不应保留流式请求的部分内容: %v", chunks)
+	}
+}
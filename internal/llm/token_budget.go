@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	// charsPerToken 粗略估算时每个token对应的字符数
+	charsPerToken = 4
+	// outputPerInputToken 每个输入token额外分配的输出token数，输入越多（如持仓资产越多）需要的回答越长
+	outputPerInputToken = 0.5
+)
+
+// estimateTokens 粗略估算文本的token数
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// scaleMaxTokens 根据输入内容的大小调整本次请求的max_tokens
+// base 为调用方给出的基础预算，结果不低于 base 且不超过配置的上限
+func (s *LLMService) scaleMaxTokens(prompt string, base int) int {
+	ceiling := s.cfg.LLM.MaxTokensCeiling
+	if ceiling <= 0 {
+		ceiling = s.cfg.LLM.MaxTokens
+	}
+
+	if !s.cfg.LLM.ScaleMaxTokens {
+		if ceiling > 0 && base > ceiling {
+			return ceiling
+		}
+		return base
+	}
+
+	budget := base + int(float64(estimateTokens(prompt))*outputPerInputToken)
+	if ceiling > 0 && budget > ceiling {
+		budget = ceiling
+	}
+	return budget
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"autotransaction/config"
+)
+
+// portfolio 返回包含 assets 个资产的投资组合数据
+func portfolio(assets int) map[string]interface{} {
+	positions := make([]map[string]interface{}, assets)
+	for i := range positions {
+		positions[i] = map[string]interface{}{
+			"symbol":     fmt.Sprintf("ASSET%d/USDT", i),
+			"quantity":   1.5,
+			"entryPrice": 100.25,
+			"value":      150.375,
+		}
+	}
+	return map[string]interface{}{"positions": positions}
+}
+
+func TestLargePortfolioGetsHigherTokenBudget(t *testing.T) {
+	var budgets []int
+	s := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		budgets = append(budgets, body.MaxTokens)
+		writeCompletion(w, "ok")
+	}, func(cfg *config.Config) {
+		cfg.LLM.ScaleMaxTokens = true
+		cfg.LLM.MaxTokensCeiling = 4000
+	})
+
+	for _, assets := range []int{1, 40, 1000} {
+		if _, err := s.AnalyzePortfolioRisk(portfolio(assets)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	small, large, huge := budgets[0], budgets[1], budgets[2]
+	if small < 800 || small >= large {
+		t.Fatalf("小组合的预算应不低于基础值 800 且低于大组合: 小 %d，大 %d", small, large)
+	}
+	if large > 4000 || huge != 4000 {
+		t.Fatalf("预算不应超过上限 4000: 大 %d，超大 %d", large, huge)
+	}
+}
@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultToolSteps 未配置时一次回答中最多调用工具的轮数
+	defaultToolSteps = 5
+	// maxToolResultLength 返回给LLM的工具结果的最大字符数，避免上下文过长
+	maxToolResultLength = 8000
+)
+
+// Tool LLM可调用的工具，由调用方提供，用于查询实时的系统数据
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}                          // 参数的 JSON Schema
+	Handler     func(args json.RawMessage) (interface{}, error) // 返回值序列化为 JSON 后返回给LLM
+}
+
+// ToolCall LLM请求的一次工具调用
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage // JSON 对象
+}
+
+// WithTools 返回可调用指定工具的服务副本，未启用工具调用时返回本服务
+// 市场分析和问答接口使用工具时，LLM可在回答前多轮调用工具查询实时数据
+func (s *LLMService) WithTools(tools []Tool) *LLMService {
+	if !s.cfg.LLM.Tools.Enabled || len(tools) == 0 {
+		return s
+	}
+	service := *s
+	service.tools = tools
+	return &service
+}
+
+// callWithTools 调用LLM，LLM请求调用工具时执行工具并将结果返回给LLM，直到LLM给出回答
+// 达到最多调用轮数后不再允许调用工具，要求LLM直接回答；各轮的用量合计到最终的回答中
+func (s *LLMService) callWithTools(prompt string, params map[string]interface{}) (*LLMResponse, error) {
+	if len(s.tools) == 0 {
+		return s.callLLM(prompt, params)
+	}
+
+	maxSteps := s.cfg.LLM.Tools.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultToolSteps
+	}
+
+	var messages []ChatMessage
+	var usage *Usage
+	used := make([]string, 0)
+	for step := 0; ; step++ {
+		stepParams := make(map[string]interface{}, len(params)+3)
+		for key, value := range params {
+			stepParams[key] = value
+		}
+		stepParams["tools"] = s.tools
+		stepParams["tool_messages"] = messages
+		stepParams["disable_tools"] = step >= maxSteps
+
+		response, err := s.callLLM(prompt, stepParams)
+		if err != nil {
+			return nil, err
+		}
+		usage = addUsage(usage, response, prompt, messages)
+
+		if len(response.ToolCalls) == 0 || step >= maxSteps {
+			if len(response.ToolCalls) > 0 && response.Completion == "" {
+				return nil, fmt.Errorf("LLM在 %d 轮工具调用后仍未给出回答", maxSteps)
+			}
+			response.ToolCalls = nil
+			response.Tools = used
+			response.Usage = usage
+			return response, nil
+		}
+
+		results := make([]ChatMessage, 0, len(response.ToolCalls))
+		for i, call := range response.ToolCalls {
+			used = append(used, call.Name)
+			var result string
+			if json.Valid(call.Arguments) {
+				result = s.runTool(call)
+			} else {
+				// 空的或无效的参数不能原样发回给要求 JSON 对象的引擎
+				if len(strings.TrimSpace(string(call.Arguments))) == 0 {
+					call.Arguments = json.RawMessage("{}")
+					result = s.runTool(call)
+				} else {
+					result = "错误: 参数不是有效的 JSON: " + string(call.Arguments)
+				}
+				response.ToolCalls[i].Arguments = json.RawMessage("{}")
+			}
+			results = append(results, ChatMessage{Role: "tool", Content: result, ToolCallID: call.ID, ToolName: call.Name})
+		}
+		messages = append(messages, ChatMessage{Role: "assistant", Content: response.Completion, ToolCalls: response.ToolCalls})
+		messages = append(messages, results...)
+	}
+}
+
+// runTool 执行一次工具调用，返回提供给LLM的结果，出错时返回错误信息让LLM自行处理
+func (s *LLMService) runTool(call ToolCall) string {
+	var tool *Tool
+	for i := range s.tools {
+		if s.tools[i].Name == call.Name {
+			tool = &s.tools[i]
+			break
+		}
+	}
+	if tool == nil {
+		return fmt.Sprintf("错误: 未知的工具 %s", call.Name)
+	}
+
+	result, err := tool.Handler(call.Arguments)
+	if err != nil {
+		logrus.Warnf("LLM调用工具 %s 失败: %v", call.Name, err)
+		return "错误: " + err.Error()
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("错误: 工具结果序列化失败: %v", err)
+	}
+	logrus.Debugf("LLM调用工具 %s，参数: %s", call.Name, string(call.Arguments))
+	return truncateText(string(data), maxToolResultLength)
+}
+
+// addUsage 将一轮请求的用量累加到合计中，引擎未返回用量时按本轮发送的内容估算
+func addUsage(total *Usage, response *LLMResponse, prompt string, messages []ChatMessage) *Usage {
+	if total == nil {
+		total = &Usage{}
+	}
+	usage := response.Usage
+	if usage == nil {
+		sent := estimateTokens(prompt)
+		for _, message := range messages {
+			sent += estimateTokens(message.Content)
+		}
+		usage = &Usage{PromptTokens: sent, CompletionTokens: estimateTokens(response.Completion), Estimated: true}
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.Estimated = total.Estimated || usage.Estimated
+	return total
+}
+
+// truncateText 截断超过 max 个字符的文本
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "...(已截断)"
+}
+
+// openAITools 将工具转换为 OpenAI 兼容接口的 function 格式，Ollama 也使用该格式
+func openAITools(tools []Tool) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  tool.Parameters,
+			},
+		})
+	}
+	return result
+}
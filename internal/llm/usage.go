@@ -0,0 +1,293 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// usageRetentionDays 保留的每日用量天数
+const usageRetentionDays = 90
+
+// ErrBudgetExceeded 本月的LLM费用已超出预算，非必要的请求被拒绝
+var ErrBudgetExceeded = errors.New("本月LLM费用已超出预算")
+
+// Usage 一次LLM请求的token用量和估算费用
+type Usage struct {
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`      // 按引擎单价估算的费用（美元）
+	Estimated        bool    `json:"estimated"` // 引擎未返回用量，按字符数估算
+}
+
+// newUsage 按引擎返回的token数创建用量，引擎未返回时为nil，由 LLMService 按字符数估算
+func newUsage(promptTokens, completionTokens int) *Usage {
+	if promptTokens == 0 && completionTokens == 0 {
+		return nil
+	}
+	return &Usage{PromptTokens: promptTokens, CompletionTokens: completionTokens}
+}
+
+// UsageTotals 累计的请求数、token数和估算费用
+type UsageTotals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotals) add(other UsageTotals) {
+	t.Requests += other.Requests
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.Cost += other.Cost
+}
+
+// DailyUsage 一天(UTC)内各提示词的用量
+type DailyUsage struct {
+	Date      string                 `json:"date"`      // 如 2024-01-02
+	Endpoints map[string]UsageTotals `json:"endpoints"` // 键为提示词名称
+}
+
+// Total 返回当天所有提示词的合计
+func (d DailyUsage) Total() UsageTotals {
+	var total UsageTotals
+	for _, totals := range d.Endpoints {
+		total.add(totals)
+	}
+	return total
+}
+
+// BudgetStatus 本月的费用和预算
+type BudgetStatus struct {
+	Month     string   `json:"month"` // 如 2024-01
+	Spent     float64  `json:"spent"`
+	Limit     float64  `json:"limit"` // 为0时不限制
+	Exceeded  bool     `json:"exceeded"`
+	Essential []string `json:"essential"` // 超出预算后仍可使用的提示词
+}
+
+// usageTracker 按天和提示词累计LLM用量，并按月度预算拒绝非必要的请求
+type usageTracker struct {
+	budget config.LLMBudgetConfig
+	prices map[string]config.LLMProviderConfig // 引擎名称 -> 引擎配置，用于估算费用
+	days   map[string]*DailyUsage              // 键为日期
+	store  store.Store                         // 为nil时用量不持久化，重启后预算重新计算
+	mutex  sync.Mutex
+}
+
+func newUsageTracker(cfg *config.Config) *usageTracker {
+	prices := make(map[string]config.LLMProviderConfig, len(cfg.LLM.Providers))
+	for _, provider := range cfg.LLM.Providers {
+		prices[provider.Name] = provider
+	}
+	return &usageTracker{
+		budget: cfg.LLM.Budget,
+		prices: prices,
+		days:   make(map[string]*DailyUsage),
+	}
+}
+
+// allow 检查本月费用是否超出预算，超出后只允许配置为必要的提示词
+func (t *usageTracker) allow(endpoint string) error {
+	status := t.status()
+	if !status.Exceeded {
+		return nil
+	}
+	for _, essential := range status.Essential {
+		if essential == endpoint {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 已用 %.2f / %.2f 美元", ErrBudgetExceeded, status.Spent, status.Limit)
+}
+
+// cost 按引擎配置的单价估算费用，未配置单价的引擎（如本地模型）费用为0
+func (t *usageTracker) cost(engine string, usage Usage) float64 {
+	provider := t.prices[engine]
+	return (float64(usage.PromptTokens)*provider.InputPrice + float64(usage.CompletionTokens)*provider.OutputPrice) / 1e6
+}
+
+// record 累计一次请求的用量，返回本月的费用
+func (t *usageTracker) record(endpoint string, usage Usage) float64 {
+	now := time.Now().UTC()
+	date := now.Format("2006-01-02")
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	before := t.monthSpentLocked(now)
+	day, ok := t.days[date]
+	if !ok {
+		day = &DailyUsage{Date: date, Endpoints: make(map[string]UsageTotals)}
+		t.days[date] = day
+		t.pruneLocked(now)
+	}
+	totals := day.Endpoints[endpoint]
+	totals.add(UsageTotals{Requests: 1, PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens, Cost: usage.Cost})
+	day.Endpoints[endpoint] = totals
+	t.persistLocked(day)
+
+	spent := before + usage.Cost
+	if limit := t.budget.MonthlyLimit; limit > 0 && before < limit && spent >= limit {
+		logrus.Warnf("本月LLM费用 %.2f 美元已达到预算 %.2f 美元，非必要的LLM功能已停用", spent, limit)
+	}
+	return spent
+}
+
+// status 返回本月的费用和预算
+func (t *usageTracker) status() BudgetStatus {
+	now := time.Now().UTC()
+
+	t.mutex.Lock()
+	spent := t.monthSpentLocked(now)
+	t.mutex.Unlock()
+
+	return BudgetStatus{
+		Month:     now.Format("2006-01"),
+		Spent:     spent,
+		Limit:     t.budget.MonthlyLimit,
+		Exceeded:  t.budget.MonthlyLimit > 0 && spent >= t.budget.MonthlyLimit,
+		Essential: t.budget.Essential,
+	}
+}
+
+// daily 返回最近 days 天的用量，最新的在前，没有请求的日期不返回
+func (t *usageTracker) daily(days int) []DailyUsage {
+	since := time.Now().UTC().AddDate(0, 0, 1-days).Format("2006-01-02")
+
+	t.mutex.Lock()
+	list := make([]DailyUsage, 0, len(t.days))
+	for date, day := range t.days {
+		if date < since {
+			continue
+		}
+		endpoints := make(map[string]UsageTotals, len(day.Endpoints))
+		for endpoint, totals := range day.Endpoints {
+			endpoints[endpoint] = totals
+		}
+		list = append(list, DailyUsage{Date: date, Endpoints: endpoints})
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Date > list[j].Date
+	})
+	return list
+}
+
+// monthSpentLocked 返回 now 所在月份的费用，调用方需持有锁
+func (t *usageTracker) monthSpentLocked(now time.Time) float64 {
+	month := now.Format("2006-01")
+	var spent float64
+	for date, day := range t.days {
+		if strings.HasPrefix(date, month) {
+			spent += day.Total().Cost
+		}
+	}
+	return spent
+}
+
+// pruneLocked 丢弃超过保留天数的用量，调用方需持有锁
+func (t *usageTracker) pruneLocked(now time.Time) {
+	cutoff := now.AddDate(0, 0, -usageRetentionDays).Format("2006-01-02")
+	for date := range t.days {
+		if date >= cutoff {
+			continue
+		}
+		delete(t.days, date)
+		if t.store != nil {
+			if err := t.store.Delete(store.CollectionLLMUsage, date); err != nil {
+				logrus.Warnf("删除 %s 的LLM用量失败: %v", date, err)
+			}
+		}
+	}
+}
+
+// persistLocked 保存一天的用量，调用方需持有锁
+func (t *usageTracker) persistLocked(day *DailyUsage) {
+	if t.store == nil {
+		return
+	}
+	if err := t.store.Put(store.CollectionLLMUsage, day.Date, day); err != nil {
+		logrus.Warnf("保存 %s 的LLM用量失败: %v", day.Date, err)
+	}
+}
+
+// load 从存储中加载每日用量
+func (t *usageTracker) load() error {
+	if t.store == nil {
+		return nil
+	}
+
+	days := make([]*DailyUsage, 0)
+	err := t.store.Load(store.CollectionLLMUsage, func(id string, decode func(v interface{}) error) error {
+		var day DailyUsage
+		if err := decode(&day); err != nil {
+			return fmt.Errorf("解析 %s 的LLM用量失败: %v", id, err)
+		}
+		if day.Endpoints == nil {
+			day.Endpoints = make(map[string]UsageTotals)
+		}
+		days = append(days, &day)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, day := range days {
+		t.days[day.Date] = day
+	}
+	t.pruneLocked(time.Now().UTC())
+	return nil
+}
+
+// SetStore 设置持久化存储，LLM用量将在重启后保留，月度预算据此跨重启累计
+func (s *LLMService) SetStore(st store.Store) {
+	s.usage.store = st
+}
+
+// LoadUsage 从存储中加载已记录的LLM用量
+func (s *LLMService) LoadUsage() error {
+	return s.usage.load()
+}
+
+// DailyUsage 返回最近 days 天按提示词统计的用量，最新的在前
+func (s *LLMService) DailyUsage(days int) []DailyUsage {
+	return s.usage.daily(days)
+}
+
+// Budget 返回本月的LLM费用和预算
+func (s *LLMService) Budget() BudgetStatus {
+	return s.usage.status()
+}
+
+// recordUsage 记录一次回答的用量，引擎未返回用量时按提示词和回答的字符数估算
+func (s *LLMService) recordUsage(endpoint, prompt string, response *LLMResponse) {
+	if response.Engine == "" {
+		response.Engine = s.defaultEngine
+	}
+	if response.Usage == nil {
+		response.Usage = &Usage{
+			PromptTokens:     estimateTokens(prompt),
+			CompletionTokens: estimateTokens(response.Completion),
+			Estimated:        true,
+		}
+	}
+	response.Usage.Cost = s.usage.cost(response.Engine, *response.Usage)
+
+	spent := s.usage.record(endpoint, *response.Usage)
+	s.metrics.ObserveLLMUsage(response.Engine, endpoint, response.Usage.PromptTokens, response.Usage.CompletionTokens, response.Usage.Cost)
+	s.metrics.SetLLMMonthlyCost(spent)
+}
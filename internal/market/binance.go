@@ -0,0 +1,389 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBinanceWSURL     = "wss://stream.binance.com:9443"
+	defaultKlineInterval    = "1m"
+	binanceInvalidSymbol    = -1121 // Binance 错误码: 交易对不存在
+	maxReconnectBackoff     = time.Minute
+	minHealthyStream        = time.Minute     // 连接保持超过该时间后断开时重置重连退避
+	binanceWSReadTimeout    = 2 * time.Minute // 超过该时间未收到任何消息视为连接失效
+	binanceHistoricalMaxBar = 1000            // klines 接口单次最多返回的K线数量
+)
+
+// Trade 表示一笔逐笔成交
+type Trade struct {
+	Symbol       string
+	Price        decimal.Decimal
+	Quantity     decimal.Decimal
+	BuyerIsMaker bool
+	Timestamp    time.Time
+}
+
+// TradeHandler 是处理逐笔成交的接口
+type TradeHandler interface {
+	HandleTrade(trade Trade)
+}
+
+// binanceClient Binance 行情接口客户端
+type binanceClient struct {
+	baseURL    string
+	wsURL      string
+	apiKey     string
+	httpClient *http.Client
+
+	futuresURL string // 永续合约接口地址，用于获取资金费率
+}
+
+// binanceError Binance 接口返回的错误
+type binanceError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// binanceStreamMessage 组合流推送的消息
+type binanceStreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// binanceKlineEvent K线推送
+type binanceKlineEvent struct {
+	Kline struct {
+		OpenTime int64  `json:"t"`
+		Open     string `json:"o"`
+		High     string `json:"h"`
+		Low      string `json:"l"`
+		Close    string `json:"c"`
+		Volume   string `json:"v"`
+		Closed   bool   `json:"x"`
+	} `json:"k"`
+}
+
+// binanceTradeEvent 逐笔成交推送
+type binanceTradeEvent struct {
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	BuyerIsMaker bool   `json:"m"`
+}
+
+// newBinanceClient 创建 Binance 客户端
+func newBinanceClient(cfg config.ExchangeConfig) *binanceClient {
+	wsURL := cfg.WSURL
+	if wsURL == "" {
+		wsURL = defaultBinanceWSURL
+	}
+	futuresURL := cfg.FuturesBaseURL
+	if futuresURL == "" {
+		futuresURL = DefaultBinanceFuturesURL
+	}
+	return &binanceClient{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		wsURL:      strings.TrimRight(wsURL, "/"),
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		futuresURL: strings.TrimRight(futuresURL, "/"),
+	}
+}
+
+// BinanceSymbol 将 "BTC/USDT" 格式的交易对转换为 Binance 使用的 "BTCUSDT"，行情和下单共用同一格式
+func BinanceSymbol(symbol string) string {
+	return strings.ToUpper(strings.ReplaceAll(symbol, "/", ""))
+}
+
+// BaseAsset 返回交易对的基础货币，如 BTC/USDT 的 BTC
+func BaseAsset(symbol string) string {
+	if i := strings.Index(symbol, "/"); i >= 0 {
+		return symbol[:i]
+	}
+	return symbol
+}
+
+// klines 获取K线数据，按时间从早到晚排列；交易对不存在时返回 ErrUnknownSymbol
+func (c *binanceClient) klines(symbol, interval string, limit int) ([]MarketData, error) {
+	if limit > binanceHistoricalMaxBar {
+		limit = binanceHistoricalMaxBar
+	}
+
+	query := url.Values{}
+	query.Set("symbol", BinanceSymbol(symbol))
+	query.Set("interval", interval)
+	query.Set("limit", fmt.Sprintf("%d", limit))
+
+	req, err := http.NewRequest("GET", c.baseURL+"/api/v3/klines?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建K线请求失败: %v", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-MBX-APIKEY", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求K线数据失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取K线响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr binanceError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Code == binanceInvalidSymbol {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+		}
+		return nil, fmt.Errorf("交易所返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	var rows [][]interface{}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("解析K线数据失败: %v", err)
+	}
+
+	result := make([]MarketData, 0, len(rows))
+	for _, row := range rows {
+		data, err := parseKlineRow(symbol, row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+// parseKlineRow 解析 klines 接口返回的一行数据
+// 格式: [开盘时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 收盘时间, ...]
+func parseKlineRow(symbol string, row []interface{}) (MarketData, error) {
+	if len(row) < 6 {
+		return MarketData{}, fmt.Errorf("K线数据格式错误: %v", row)
+	}
+
+	openTime, ok := row[0].(float64)
+	if !ok {
+		return MarketData{}, fmt.Errorf("K线开盘时间格式错误: %v", row[0])
+	}
+
+	values := make([]decimal.Decimal, 5)
+	for i := range values {
+		text, ok := row[i+1].(string)
+		if !ok {
+			return MarketData{}, fmt.Errorf("K线数值格式错误: %v", row[i+1])
+		}
+		value, err := decimal.NewFromString(text)
+		if err != nil {
+			return MarketData{}, fmt.Errorf("K线数值格式错误: %v", err)
+		}
+		values[i] = value
+	}
+
+	return MarketData{
+		Symbol:    symbol,
+		Timestamp: time.Unix(0, int64(openTime)*int64(time.Millisecond)),
+		Open:      values[0],
+		High:      values[1],
+		Low:       values[2],
+		Close:     values[3],
+		Volume:    values[4],
+	}, nil
+}
+
+// ping 检查交易所REST接口的连通性
+func (c *binanceClient) ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v3/ping", nil)
+	if err != nil {
+		return fmt.Errorf("创建连通性检查请求失败: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求交易所失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("交易所返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+	return nil
+}
+
+// streamPair 通过 WebSocket 订阅交易对的K线和逐笔成交，连接断开后按指数退避重连，
+// 直到 ctx 取消或交易对被判定为下架
+func (m *MarketDataService) streamPair(ctx context.Context, symbol string) {
+	defer m.wg.Done()
+
+	logrus.Infof("开始订阅 %s 的市场数据", symbol)
+
+	backoff := time.Second
+	unknownCount := 0 // 连续返回交易对不存在的次数
+	for {
+		// 每次连接前通过 REST 接口确认交易对仍然存在（Binance 对不存在的交易对订阅不会报错）
+		_, err := m.binance.klines(symbol, m.klineInterval(), 1)
+		if err == nil {
+			unknownCount = 0
+			connectedAt := time.Now()
+			err = m.runStream(ctx, symbol)
+			if err == nil {
+				logrus.Infof("停止订阅 %s 的市场数据", symbol)
+				return
+			}
+			// 连接已稳定运行一段时间后断开，视为偶发中断，从最短间隔开始重连
+			if time.Since(connectedAt) >= minHealthyStream {
+				backoff = time.Second
+			}
+			logrus.Warnf("%s 的行情连接中断: %v，%s 后重连", symbol, err, backoff)
+		} else if m.recordFetchError(symbol, err, &unknownCount) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			logrus.Infof("停止订阅 %s 的市场数据", symbol)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// runStream 建立 WebSocket 连接并处理推送，ctx 取消时返回 nil，连接出错时返回错误
+func (m *MarketDataService) runStream(ctx context.Context, symbol string) error {
+	stream := strings.ToLower(BinanceSymbol(symbol))
+	streamURL := fmt.Sprintf("%s/stream?streams=%s@kline_%s/%s@trade",
+		m.binance.wsURL, stream, m.klineInterval(), stream)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, streamURL, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("连接行情推送失败: %v", err)
+	}
+	defer conn.Close()
+
+	// ctx 取消时关闭连接以结束阻塞的读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(binanceWSReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("读取行情推送失败: %v", err)
+		}
+		m.recordFeed()
+
+		var msg binanceStreamMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			logrus.Warnf("解析行情推送失败: %v", err)
+			continue
+		}
+
+		switch {
+		case strings.Contains(msg.Stream, "@kline_"):
+			m.handleKlineEvent(symbol, msg.Data)
+		case strings.HasSuffix(msg.Stream, "@trade"):
+			m.handleTradeEvent(symbol, msg.Data)
+		}
+	}
+}
+
+// handleKlineEvent 处理K线推送，只分发已收盘的K线
+func (m *MarketDataService) handleKlineEvent(symbol string, payload json.RawMessage) {
+	var event binanceKlineEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		logrus.Warnf("解析 %s 的K线推送失败: %v", symbol, err)
+		return
+	}
+	if !event.Kline.Closed {
+		return
+	}
+
+	k := event.Kline
+	data, err := parseKlineRow(symbol, []interface{}{float64(k.OpenTime), k.Open, k.High, k.Low, k.Close, k.Volume})
+	if err != nil {
+		logrus.Warnf("解析 %s 的K线推送失败: %v", symbol, err)
+		return
+	}
+	m.distributeData(data)
+}
+
+// handleTradeEvent 处理逐笔成交推送
+func (m *MarketDataService) handleTradeEvent(symbol string, payload json.RawMessage) {
+	var event binanceTradeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		logrus.Warnf("解析 %s 的成交推送失败: %v", symbol, err)
+		return
+	}
+
+	price, err := decimal.NewFromString(event.Price)
+	if err != nil {
+		return
+	}
+	quantity, err := decimal.NewFromString(event.Quantity)
+	if err != nil {
+		return
+	}
+
+	m.distributeTrade(Trade{
+		Symbol:       symbol,
+		Price:        price,
+		Quantity:     quantity,
+		BuyerIsMaker: event.BuyerIsMaker,
+		Timestamp:    time.Unix(0, event.TradeTime*int64(time.Millisecond)),
+	})
+}
+
+// klineInterval 实时订阅的K线周期
+func (m *MarketDataService) klineInterval() string {
+	if m.cfg.Exchange.KlineInterval != "" {
+		return m.cfg.Exchange.KlineInterval
+	}
+	return defaultKlineInterval
+}
+
+// FeedInterval 返回实时行情每条数据覆盖的时长，即订阅的K线周期；模拟模式的行情为定时采样，返回0
+func (m *MarketDataService) FeedInterval() time.Duration {
+	if m.cfg.Exchange.MockMode {
+		return 0
+	}
+	duration, err := ParseInterval(m.klineInterval())
+	if err != nil {
+		return 0
+	}
+	return duration
+}
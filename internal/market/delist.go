@@ -0,0 +1,67 @@
+package market
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnknownSymbol 交易所返回交易对不存在（通常意味着已下架）
+var ErrUnknownSymbol = errors.New("交易对不存在")
+
+// DelistHandler 交易对被判定为下架时的回调
+type DelistHandler func(symbol string)
+
+// RegisterDelistHandler 注册交易对下架回调
+func (m *MarketDataService) RegisterDelistHandler(handler DelistHandler) {
+	m.handlersMutex.Lock()
+	defer m.handlersMutex.Unlock()
+	m.delistHandlers = append(m.delistHandlers, handler)
+}
+
+// IsDelisted 判断交易对是否已被判定为下架
+func (m *MarketDataService) IsDelisted(symbol string) bool {
+	m.delistedMutex.RLock()
+	defer m.delistedMutex.RUnlock()
+	return m.delisted[symbol]
+}
+
+// recordFetchError 记录数据获取错误，连续出现交易对不存在错误达到阈值时判定为下架，返回是否已下架
+func (m *MarketDataService) recordFetchError(symbol string, err error, unknownCount *int) bool {
+	if !errors.Is(err, ErrUnknownSymbol) {
+		logrus.Warnf("获取 %s 的市场数据失败: %v", symbol, err)
+		return false
+	}
+
+	*unknownCount++
+	threshold := m.cfg.Exchange.DelistAfterErrors
+	if threshold <= 0 || *unknownCount < threshold {
+		logrus.Warnf("交易所返回 %s 不存在 (连续 %d 次)", symbol, *unknownCount)
+		return false
+	}
+
+	m.markDelisted(symbol)
+	return true
+}
+
+// markDelisted 将交易对标记为下架并通知回调
+func (m *MarketDataService) markDelisted(symbol string) {
+	m.delistedMutex.Lock()
+	if m.delisted[symbol] {
+		m.delistedMutex.Unlock()
+		return
+	}
+	m.delisted[symbol] = true
+	m.delistedMutex.Unlock()
+
+	logrus.Errorf("告警: 交易对 %s 已被交易所下架，停止获取其市场数据，相关持仓需人工处理", symbol)
+
+	m.handlersMutex.RLock()
+	handlers := make([]DelistHandler, len(m.delistHandlers))
+	copy(handlers, m.delistHandlers)
+	m.handlersMutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(symbol)
+	}
+}
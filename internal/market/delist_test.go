@@ -0,0 +1,58 @@
+package market
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"autotransaction/config"
+)
+
+func TestRepeatedUnknownSymbolMarksDelisted(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Exchange.BaseURL = server.URL
+	cfg.Exchange.DelistAfterErrors = 2
+	m := NewMarketDataService(cfg)
+	defer m.Stop()
+
+	delisted := make(chan string, 1)
+	m.RegisterDelistHandler(func(symbol string) { delisted <- symbol })
+	m.startPair("OLD/USDT")
+
+	select {
+	case symbol := <-delisted:
+		if symbol != "OLD/USDT" {
+			t.Fatalf("下架回调的交易对错误: %s", symbol)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("连续返回交易对不存在后应判定为下架")
+	}
+	if !m.IsDelisted("OLD/USDT") {
+		t.Fatal("交易对应被标记为下架")
+	}
+
+	// 数据获取协程应已退出，不再请求交易所
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("下架后应停止获取市场数据")
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Fatalf("应在第 2 次错误后停止请求，实际请求 %d 次", got)
+	}
+}
@@ -0,0 +1,171 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBinanceFuturesURL Binance U本位永续合约接口地址，资金费率和合约下单共用
+const DefaultBinanceFuturesURL = "https://fapi.binance.com"
+
+const defaultFundingPollSeconds = 60
+
+// FundingRate 永续合约的资金费率，Rate 为每个结算周期的费率，正数表示多头向空头支付
+type FundingRate struct {
+	Symbol          string
+	Rate            decimal.Decimal
+	MarkPrice       decimal.Decimal
+	IndexPrice      decimal.Decimal
+	NextFundingTime time.Time
+	Timestamp       time.Time // 获取费率的时间
+}
+
+// binancePremiumIndex premiumIndex 接口的响应
+type binancePremiumIndex struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+}
+
+// premiumIndex 获取永续合约的标记价格和资金费率；交易对不存在时返回 ErrUnknownSymbol
+func (c *binanceClient) premiumIndex(ctx context.Context, symbol string) (FundingRate, error) {
+	query := url.Values{}
+	query.Set("symbol", BinanceSymbol(symbol))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.futuresURL+"/fapi/v1/premiumIndex?"+query.Encode(), nil)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("创建资金费率请求失败: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("请求资金费率失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("读取资金费率响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr binanceError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Code == binanceInvalidSymbol {
+			return FundingRate{}, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+		}
+		return FundingRate{}, fmt.Errorf("交易所返回错误: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	var index binancePremiumIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		return FundingRate{}, fmt.Errorf("解析资金费率失败: %v", err)
+	}
+	rate, err := decimal.NewFromString(index.LastFundingRate)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("资金费率格式错误: %v", err)
+	}
+	markPrice, _ := decimal.NewFromString(index.MarkPrice)
+	indexPrice, _ := decimal.NewFromString(index.IndexPrice)
+
+	return FundingRate{
+		Symbol:          symbol,
+		Rate:            rate,
+		MarkPrice:       markPrice,
+		IndexPrice:      indexPrice,
+		NextFundingTime: time.Unix(0, index.NextFundingTime*int64(time.Millisecond)),
+		Timestamp:       time.Now(),
+	}, nil
+}
+
+// LatestFundingRate 返回永续合约交易对最近一次获取的资金费率
+func (m *MarketDataService) LatestFundingRate(symbol string) (FundingRate, bool) {
+	m.fundingMutex.RLock()
+	defer m.fundingMutex.RUnlock()
+	rate, ok := m.funding[symbol]
+	return rate, ok
+}
+
+// FundingRates 返回所有永续合约交易对最近一次获取的资金费率
+func (m *MarketDataService) FundingRates() []FundingRate {
+	m.fundingMutex.RLock()
+	defer m.fundingMutex.RUnlock()
+
+	rates := make([]FundingRate, 0, len(m.funding))
+	for _, rate := range m.funding {
+		rates = append(rates, rate)
+	}
+	return rates
+}
+
+// pollFunding 定时获取所有启用的永续合约交易对的资金费率，每轮按当前配置选择交易对，配置重载后无需重启
+func (m *MarketDataService) pollFunding() {
+	defer m.wg.Done()
+
+	seconds := m.cfg.Exchange.FundingPollSeconds
+	if seconds <= 0 {
+		seconds = defaultFundingPollSeconds
+	}
+	ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		m.refreshFunding()
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshFunding 获取一轮资金费率，已下架或获取失败的交易对保留上一次的费率
+func (m *MarketDataService) refreshFunding() {
+	for _, pair := range m.cfg.Current().Trading.Pairs {
+		if !pair.Enabled || !pair.IsPerpetual() || m.IsDelisted(pair.Symbol) {
+			continue
+		}
+
+		var rate FundingRate
+		if m.cfg.Exchange.MockMode {
+			rate = m.generateMockFunding(pair.Symbol)
+		} else {
+			var err error
+			rate, err = m.binance.premiumIndex(m.ctx, pair.Symbol)
+			if err != nil {
+				if m.ctx.Err() == nil {
+					logrus.Warnf("获取 %s 的资金费率失败: %v", pair.Symbol, err)
+				}
+				continue
+			}
+		}
+
+		m.fundingMutex.Lock()
+		m.funding[pair.Symbol] = rate
+		m.fundingMutex.Unlock()
+	}
+}
+
+// generateMockFunding 生成模拟资金费率（模拟模式），在 -0.01% 到 0.05% 之间随时间变化
+func (m *MarketDataService) generateMockFunding(symbol string) FundingRate {
+	now := time.Now()
+	rate := decimal.NewFromInt(now.Unix()%60 - 10).Div(decimal.NewFromInt(100000))
+	price := m.generateMockData(symbol).Close
+	return FundingRate{
+		Symbol:          symbol,
+		Rate:            rate,
+		MarkPrice:       price,
+		IndexPrice:      price,
+		NextFundingTime: now.Truncate(8 * time.Hour).Add(8 * time.Hour),
+		Timestamp:       now,
+	}
+}
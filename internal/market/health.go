@@ -0,0 +1,44 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"autotransaction/internal/health"
+)
+
+// recordFeed 记录收到行情的时间
+func (m *MarketDataService) recordFeed() {
+	m.feedMutex.Lock()
+	m.lastFeedAt = time.Now()
+	m.feedMutex.Unlock()
+}
+
+// CheckHealth 检查交易所连通性和行情推送是否及时
+// REST接口不可达时为不可用；接口正常但超过 binanceWSReadTimeout 未收到行情时为降级
+func (m *MarketDataService) CheckHealth(ctx context.Context) health.Result {
+	if m.cfg.Exchange.MockMode {
+		return health.Result{Status: health.StatusOK, Message: "模拟模式", Details: map[string]interface{}{"mockMode": true}}
+	}
+
+	if err := m.binance.ping(ctx); err != nil {
+		return health.Result{Status: health.StatusDown, Message: err.Error()}
+	}
+
+	m.feedMutex.RLock()
+	lastFeedAt := m.lastFeedAt
+	m.feedMutex.RUnlock()
+
+	details := map[string]interface{}{}
+	if lastFeedAt.IsZero() {
+		return health.Result{Status: health.StatusDegraded, Message: "尚未收到行情推送", Details: details}
+	}
+	age := time.Since(lastFeedAt)
+	details["lastFeedAt"] = lastFeedAt
+	details["feedAgeSeconds"] = int64(age.Seconds())
+	if age > binanceWSReadTimeout {
+		return health.Result{Status: health.StatusDegraded, Message: fmt.Sprintf("行情推送已 %s 未更新", age.Truncate(time.Second)), Details: details}
+	}
+	return health.Result{Status: health.StatusOK, Details: details}
+}
@@ -27,14 +27,36 @@ type DataHandler interface {
 	HandleData(data MarketData)
 }
 
+// HistoryProvider 提供本地记录的历史K线，按时间从早到晚排列
+type HistoryProvider interface {
+	Candles(symbol, interval string, limit int) ([]MarketData, error)
+}
+
 // MarketDataService 负责获取和分发市场数据
 type MarketDataService struct {
 	cfg           *config.Config
 	handlers      []DataHandler
 	handlersMutex sync.RWMutex
-	ctx           context.Context
-	cancel        context.CancelFunc
-	wg            sync.WaitGroup
+	tradeHandlers []TradeHandler
+	binance       *binanceClient
+	history       HistoryProvider // 未启用历史数据存储时为nil
+
+	delistHandlers []DelistHandler
+	delisted       map[string]bool // 已判定为下架的交易对
+	delistedMutex  sync.RWMutex
+
+	lastFeedAt time.Time // 最近一次收到行情的时间，用于健康检查
+	feedMutex  sync.RWMutex
+
+	pairs      map[string]context.CancelFunc // 正在获取数据的交易对
+	pairsMutex sync.Mutex
+
+	funding      map[string]FundingRate // 永续合约交易对最近一次获取的资金费率
+	fundingMutex sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // NewMarketDataService 创建一个新的市场数据服务
@@ -43,6 +65,10 @@ func NewMarketDataService(cfg *config.Config) *MarketDataService {
 	return &MarketDataService{
 		cfg:      cfg,
 		handlers: make([]DataHandler, 0),
+		delisted: make(map[string]bool),
+		pairs:    make(map[string]context.CancelFunc),
+		funding:  make(map[string]FundingRate),
+		binance:  newBinanceClient(cfg.Exchange),
 		ctx:      ctx,
 		cancel:   cancel,
 	}
@@ -53,18 +79,72 @@ func (m *MarketDataService) Start() error {
 	logrus.Info("启动市场数据服务")
 
 	// 为每个交易对启动一个数据获取协程
-	for _, pair := range m.cfg.Trading.Pairs {
+	for _, pair := range m.cfg.Current().Trading.Pairs {
 		if !pair.Enabled {
 			continue
 		}
-
-		m.wg.Add(1)
-		go m.fetchDataForPair(pair.Symbol)
+		m.startPair(pair.Symbol)
 	}
 
+	// 定时获取永续合约的资金费率
+	m.wg.Add(1)
+	go m.pollFunding()
+
 	return nil
 }
 
+// startPair 启动交易对的数据获取协程，已在运行时不重复启动
+func (m *MarketDataService) startPair(symbol string) {
+	m.pairsMutex.Lock()
+	defer m.pairsMutex.Unlock()
+
+	if _, running := m.pairs[symbol]; running {
+		return
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.pairs[symbol] = cancel
+
+	m.wg.Add(1)
+	if m.cfg.Exchange.MockMode {
+		go m.fetchDataForPair(ctx, symbol)
+	} else {
+		go m.streamPair(ctx, symbol)
+	}
+}
+
+// stopPair 停止交易对的数据获取协程
+func (m *MarketDataService) stopPair(symbol string) {
+	m.pairsMutex.Lock()
+	defer m.pairsMutex.Unlock()
+
+	if cancel, running := m.pairs[symbol]; running {
+		cancel()
+		delete(m.pairs, symbol)
+	}
+}
+
+// ReloadConfig 实现 config.ReloadHandler 接口，为新启用的交易对开始获取数据，停止已禁用或已删除的交易对
+func (m *MarketDataService) ReloadConfig(previous *config.Config) {
+	enabled := make(map[string]bool)
+	for _, pair := range m.cfg.Current().Trading.Pairs {
+		if pair.Enabled {
+			enabled[pair.Symbol] = true
+		}
+	}
+
+	for _, pair := range previous.Trading.Pairs {
+		if pair.Enabled && !enabled[pair.Symbol] {
+			logrus.Infof("交易对 %s 已停用，停止获取市场数据", pair.Symbol)
+			m.stopPair(pair.Symbol)
+		}
+	}
+	for _, pair := range m.cfg.Current().Trading.Pairs {
+		if pair.Enabled && !m.IsDelisted(pair.Symbol) {
+			m.startPair(pair.Symbol)
+		}
+	}
+}
+
 // Stop 停止市场数据服务
 func (m *MarketDataService) Stop() {
 	logrus.Info("停止市场数据服务")
@@ -79,8 +159,15 @@ func (m *MarketDataService) RegisterHandler(handler DataHandler) {
 	m.handlers = append(m.handlers, handler)
 }
 
-// fetchDataForPair 为特定交易对获取数据
-func (m *MarketDataService) fetchDataForPair(symbol string) {
+// RegisterTradeHandler 注册一个逐笔成交处理器
+func (m *MarketDataService) RegisterTradeHandler(handler TradeHandler) {
+	m.handlersMutex.Lock()
+	defer m.handlersMutex.Unlock()
+	m.tradeHandlers = append(m.tradeHandlers, handler)
+}
+
+// fetchDataForPair 为特定交易对定时生成模拟数据（模拟模式）
+func (m *MarketDataService) fetchDataForPair(ctx context.Context, symbol string) {
 	defer m.wg.Done()
 
 	logrus.Infof("开始获取 %s 的市场数据", symbol)
@@ -88,20 +175,33 @@ func (m *MarketDataService) fetchDataForPair(symbol string) {
 	ticker := time.NewTicker(time.Minute) // 每分钟获取一次数据
 	defer ticker.Stop()
 
+	unknownCount := 0 // 连续返回交易对不存在的次数
+
 	for {
 		select {
-		case <-m.ctx.Done():
+		case <-ctx.Done():
 			logrus.Infof("停止获取 %s 的市场数据", symbol)
 			return
 		case <-ticker.C:
-			// 这里应该调用交易所API获取实际数据
-			// 为了演示，我们生成模拟数据
-			data := m.generateMockData(symbol)
+			data, err := m.fetchData(symbol)
+			if err != nil {
+				if m.recordFetchError(symbol, err, &unknownCount) {
+					return
+				}
+				continue
+			}
+			unknownCount = 0
+			m.recordFeed()
 			m.distributeData(data)
 		}
 	}
 }
 
+// fetchData 获取交易对的最新数据（模拟模式）
+func (m *MarketDataService) fetchData(symbol string) (MarketData, error) {
+	return m.generateMockData(symbol), nil
+}
+
 // distributeData 将数据分发给所有处理器
 func (m *MarketDataService) distributeData(data MarketData) {
 	m.handlersMutex.RLock()
@@ -112,6 +212,16 @@ func (m *MarketDataService) distributeData(data MarketData) {
 	}
 }
 
+// distributeTrade 将逐笔成交分发给所有处理器
+func (m *MarketDataService) distributeTrade(trade Trade) {
+	m.handlersMutex.RLock()
+	defer m.handlersMutex.RUnlock()
+
+	for _, handler := range m.tradeHandlers {
+		handler.HandleTrade(trade)
+	}
+}
+
 // generateMockData 生成模拟市场数据（仅用于演示）
 func (m *MarketDataService) generateMockData(symbol string) MarketData {
 	price := decimal.NewFromFloat(float64(time.Now().Unix() % 1000))
@@ -126,10 +236,33 @@ func (m *MarketDataService) generateMockData(symbol string) MarketData {
 	}
 }
 
+// SetHistory 设置本地历史K线来源，模拟模式下以及交易所接口不可用时使用
+func (m *MarketDataService) SetHistory(history HistoryProvider) {
+	m.history = history
+}
+
+// Candles 实现 HistoryProvider 接口，与 GetHistoricalData 相同，供回测等按历史K线来源使用
+func (m *MarketDataService) Candles(symbol, interval string, limit int) ([]MarketData, error) {
+	return m.GetHistoricalData(symbol, interval, limit)
+}
+
 // GetHistoricalData 获取历史数据
+// 优先使用交易所K线接口；模拟模式或接口失败时使用本地记录的历史K线，都不可用时返回模拟数据
 func (m *MarketDataService) GetHistoricalData(symbol string, interval string, limit int) ([]MarketData, error) {
-	// 实际实现中应该调用交易所API获取历史数据
-	// 这里返回模拟数据
+	if !m.cfg.Exchange.MockMode {
+		data, err := m.binance.klines(symbol, interval, limit)
+		if err != nil && m.history != nil {
+			logrus.Warnf("从交易所获取 %s 历史K线失败，使用本地历史数据: %v", symbol, err)
+			return m.history.Candles(symbol, interval, limit)
+		}
+		return data, err
+	}
+
+	if m.history != nil {
+		return m.history.Candles(symbol, interval, limit)
+	}
+
+	// 未启用历史数据存储时返回模拟数据
 	result := make([]MarketData, limit)
 
 	baseTime := time.Now()
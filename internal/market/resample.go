@@ -0,0 +1,125 @@
+package market
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Resampler 将行情按交易对聚合为固定周期的K线，每根K线收盘时才返回
+// 输入为已收盘的K线时（如交易所推送的1分钟K线），覆盖到周期末尾的那根K线到达即收盘；
+// 输入为逐笔或定时采样的行情时，收到下一周期的第一条行情才收盘
+type Resampler struct {
+	interval string
+	duration time.Duration
+	source   time.Duration            // 每条输入行情覆盖的时长，为0表示时间点行情
+	current  map[string]*resampledBar // 各交易对最近的K线，时间戳为周期开始时间
+	mutex    sync.Mutex
+}
+
+// resampledBar 聚合中的K线
+type resampledBar struct {
+	MarketData
+	closed bool // 已收盘并返回，同一周期的后续行情不再并入
+}
+
+// NewResampler 创建K线聚合器，source 为输入行情的K线周期，输入不是K线时为0
+func NewResampler(interval string, source time.Duration) (*Resampler, error) {
+	duration, err := ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resampler{
+		interval: interval,
+		duration: duration,
+		source:   source,
+		current:  make(map[string]*resampledBar),
+	}, nil
+}
+
+// Interval 返回聚合的K线周期
+func (r *Resampler) Interval() string {
+	return r.interval
+}
+
+// Add 并入一条行情，返回因此收盘的K线，按时间从早到晚排列；早于当前K线或属于已收盘K线的行情被忽略
+// 缺少末尾数据的K线在下一周期的行情到达时收盘，因此一次最多返回两根K线
+func (r *Resampler) Add(data MarketData) []MarketData {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	openTime := data.Timestamp.Truncate(r.duration)
+	closed := make([]MarketData, 0, 1)
+
+	bar, ok := r.current[data.Symbol]
+	switch {
+	case ok && openTime.Before(bar.Timestamp):
+		return closed
+	case ok && openTime.Equal(bar.Timestamp):
+		if bar.closed {
+			return closed
+		}
+		mergeBar(&bar.MarketData, data)
+	default:
+		if ok && !bar.closed {
+			closed = append(closed, bar.MarketData)
+		}
+		bar = &resampledBar{MarketData: MarketData{
+			Symbol:    data.Symbol,
+			Timestamp: openTime,
+			Open:      data.Open,
+			High:      data.High,
+			Low:       data.Low,
+			Close:     data.Close,
+			Volume:    data.Volume,
+		}}
+		r.current[data.Symbol] = bar
+	}
+
+	// 输入K线已覆盖到周期末尾时当前K线立即收盘
+	if r.source > 0 && !data.Timestamp.Add(r.source).Before(openTime.Add(r.duration)) {
+		bar.closed = true
+		closed = append(closed, bar.MarketData)
+	}
+	return closed
+}
+
+// mergeBar 将一段行情并入K线：最高价取较大值、最低价取较小值、收盘价取最新值、成交量累加
+func mergeBar(bar *MarketData, data MarketData) {
+	if data.High.GreaterThan(bar.High) {
+		bar.High = data.High
+	}
+	if data.Low.LessThan(bar.Low) {
+		bar.Low = data.Low
+	}
+	bar.Close = data.Close
+	bar.Volume = bar.Volume.Add(data.Volume)
+}
+
+// ParseInterval 解析K线周期，如 1m、5m、1h、4h、1d、1w
+func ParseInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("无效的K线周期: %q", interval)
+	}
+	count, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("无效的K线周期: %q", interval)
+	}
+
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("无效的K线周期: %q，单位应为 m、h、d 或 w", interval)
+	}
+	return time.Duration(count) * unit, nil
+}
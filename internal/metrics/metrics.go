@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"time"
+
+	"autotransaction/internal/events"
+	"autotransaction/internal/risk"
+	"autotransaction/internal/strategy"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "autotrade"
+
+// 订单场所
+const (
+	VenueExchange   = "exchange"
+	VenueBlockchain = "blockchain"
+)
+
+// PositionProvider 提供当前持仓，风险管理器汇总了交易所和链上的持仓并随行情更新价格
+type PositionProvider interface {
+	GetPositions() map[string]risk.Position
+}
+
+// Metrics 交易系统的业务监控指标，为nil时所有记录方法都不做任何事
+type Metrics struct {
+	orders         *prometheus.CounterVec
+	signals        *prometheus.CounterVec
+	riskRejections *prometheus.CounterVec
+	gasSpent       *prometheus.CounterVec
+	reconciliation *prometheus.CounterVec
+	mismatches     *prometheus.GaugeVec
+	llmDuration    *prometheus.HistogramVec
+	llmTokens      *prometheus.CounterVec
+	llmCost        *prometheus.CounterVec
+	llmMonthlyCost prometheus.Gauge
+	wsClients      prometheus.Gauge
+	registry       prometheus.Registerer
+}
+
+// New 创建业务指标并注册到给定的注册表
+func New(registry prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		orders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_total",
+			Help:      "订单生命周期事件数，status 为 submitted/filled/canceled/failed，部分成交每笔计为一次 filled",
+		}, []string{"venue", "symbol", "status"}),
+		signals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "signals_total",
+			Help:      "各策略实例产生的交易信号数",
+		}, []string{"strategy", "symbol", "direction"}),
+		riskRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "risk_rejections_total",
+			Help:      "被风险检查拒绝的信号数",
+		}, []string{"strategy", "symbol"}),
+		gasSpent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_spent_native_total",
+			Help:      "已打包交易消耗的gas费用，以网络原生币计",
+		}, []string{"network"}),
+		reconciliation: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconciliations_total",
+			Help:      "持仓对账次数，status 为 ok/error/skipped，skipped 表示无法获取实际余额",
+		}, []string{"venue", "status"}),
+		mismatches: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "position_mismatches",
+			Help:      "最近一次对账中本地持仓与交易所或链上实际余额不一致的交易对数",
+		}, []string{"venue"}),
+		llmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "llm_request_duration_seconds",
+			Help:      "LLM API请求耗时，流式请求计到响应结束",
+			Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+		}, []string{"engine", "mode", "status"}),
+		llmTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_tokens_total",
+			Help:      "LLM请求消耗的token数，type 为 prompt 或 completion，引擎未返回用量时按字符数估算",
+		}, []string{"engine", "endpoint", "type"}),
+		llmCost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_cost_usd_total",
+			Help:      "按引擎token单价估算的LLM费用（美元）",
+		}, []string{"engine", "endpoint"}),
+		llmMonthlyCost: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "llm_monthly_cost_usd",
+			Help:      "本月(UTC)估算的LLM费用（美元），用于与月度预算比较",
+		}),
+		wsClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_clients",
+			Help:      "当前连接的WebSocket客户端数",
+		}),
+		registry: registry,
+	}
+
+	for _, collector := range []prometheus.Collector{m.orders, m.signals, m.riskRejections, m.gasSpent, m.llmDuration, m.wsClients,
+		m.llmTokens, m.llmCost, m.llmMonthlyCost, m.reconciliation, m.mismatches} {
+		if err := registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// RegisterPositions 注册持仓价值指标，每次采集时按当前价格计算
+func (m *Metrics) RegisterPositions(provider PositionProvider) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(newPositionCollector(provider))
+}
+
+// ObserveOrder 记录一次订单生命周期事件
+func (m *Metrics) ObserveOrder(venue, symbol, status string) {
+	if m == nil {
+		return
+	}
+	m.orders.WithLabelValues(venue, symbol, status).Inc()
+}
+
+// AddGasSpent 累计网络上消耗的gas费用（原生币）
+func (m *Metrics) AddGasSpent(network string, amount float64) {
+	if m == nil {
+		return
+	}
+	m.gasSpent.WithLabelValues(network).Add(amount)
+}
+
+// ObserveReconciliation 记录一次持仓对账，status 为 ok、error 或 skipped
+func (m *Metrics) ObserveReconciliation(venue, status string) {
+	if m == nil {
+		return
+	}
+	m.reconciliation.WithLabelValues(venue, status).Inc()
+}
+
+// SetPositionMismatches 设置场所最近一次对账发现的持仓差异数
+func (m *Metrics) SetPositionMismatches(venue string, count int) {
+	if m == nil {
+		return
+	}
+	m.mismatches.WithLabelValues(venue).Set(float64(count))
+}
+
+// ObserveLLMRequest 记录一次LLM请求的耗时，mode 为 request 或 stream
+func (m *Metrics) ObserveLLMRequest(engine, mode string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.llmDuration.WithLabelValues(engine, mode, status).Observe(duration.Seconds())
+}
+
+// ObserveLLMUsage 记录一次LLM请求的token用量和估算费用，endpoint 为提示词名称
+func (m *Metrics) ObserveLLMUsage(engine, endpoint string, promptTokens, completionTokens int, cost float64) {
+	if m == nil {
+		return
+	}
+	m.llmTokens.WithLabelValues(engine, endpoint, "prompt").Add(float64(promptTokens))
+	m.llmTokens.WithLabelValues(engine, endpoint, "completion").Add(float64(completionTokens))
+	m.llmCost.WithLabelValues(engine, endpoint).Add(cost)
+}
+
+// SetLLMMonthlyCost 设置本月的LLM费用
+func (m *Metrics) SetLLMMonthlyCost(cost float64) {
+	if m == nil {
+		return
+	}
+	m.llmMonthlyCost.Set(cost)
+}
+
+// SetWebSocketClients 设置当前的WebSocket客户端数
+func (m *Metrics) SetWebSocketClients(count int) {
+	if m == nil {
+		return
+	}
+	m.wsClients.Set(float64(count))
+}
+
+// HandleEvent 实现 events.Handler 接口，统计交易信号和风险拒绝
+func (m *Metrics) HandleEvent(event events.Event) {
+	if m == nil {
+		return
+	}
+	switch payload := event.Payload.(type) {
+	case strategy.Signal:
+		m.signals.WithLabelValues(payload.StrategyName, payload.Symbol, payload.Direction).Inc()
+	case risk.Rejection:
+		m.riskRejections.WithLabelValues(payload.Strategy, payload.Symbol).Inc()
+	}
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// positionCollector 在采集时读取当前持仓，持仓清空后对应的时间序列随之消失
+type positionCollector struct {
+	provider PositionProvider
+	value    *prometheus.Desc
+	quantity *prometheus.Desc
+}
+
+// newPositionCollector 创建持仓指标采集器
+func newPositionCollector(provider PositionProvider) *positionCollector {
+	labels := []string{"account", "symbol"}
+	return &positionCollector{
+		provider: provider,
+		value: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "position_value"),
+			"持仓按当前价格计算的价值（计价货币）", labels, nil),
+		quantity: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "position_quantity"),
+			"持仓数量", labels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *positionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.value
+	ch <- c.quantity
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *positionCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, position := range c.provider.GetPositions() {
+		value := position.Quantity.Mul(position.CurrentPrice).InexactFloat64()
+		ch <- prometheus.MustNewConstMetric(c.value, prometheus.GaugeValue, value, position.Account, position.Symbol)
+		ch <- prometheus.MustNewConstMetric(c.quantity, prometheus.GaugeValue, position.Quantity.InexactFloat64(), position.Account, position.Symbol)
+	}
+}
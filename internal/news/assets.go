@@ -0,0 +1,144 @@
+package news
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"autotransaction/config"
+)
+
+// assetNames 常见资产的英文和中文名称，新闻中出现代码或名称即视为涉及该资产
+var assetNames = map[string][]string{
+	"BTC":   {"bitcoin", "比特币"},
+	"ETH":   {"ethereum", "ether", "以太坊"},
+	"BNB":   {"binance coin", "bnb chain", "币安币"},
+	"SOL":   {"solana"},
+	"XRP":   {"ripple", "瑞波"},
+	"ADA":   {"cardano"},
+	"DOGE":  {"dogecoin", "狗狗币"},
+	"DOT":   {"polkadot", "波卡"},
+	"AVAX":  {"avalanche"},
+	"MATIC": {"polygon"},
+	"LINK":  {"chainlink"},
+	"LTC":   {"litecoin", "莱特币"},
+	"UNI":   {"uniswap"},
+	"USDT":  {"tether"},
+}
+
+// assetMatcher 识别文本中涉及的关注资产
+type assetMatcher struct {
+	patterns map[string]*regexp.Regexp // 资产代码和英文名称，按单词匹配
+	chinese  map[string][]string       // 中文名称，按子串匹配
+}
+
+func newAssetMatcher(assets []string) *assetMatcher {
+	matcher := &assetMatcher{
+		patterns: make(map[string]*regexp.Regexp),
+		chinese:  make(map[string][]string),
+	}
+	for _, asset := range assets {
+		words := []string{regexp.QuoteMeta(asset)}
+		for _, name := range assetNames[asset] {
+			if isASCII(name) {
+				words = append(words, regexp.QuoteMeta(name))
+			} else {
+				matcher.chinese[asset] = append(matcher.chinese[asset], name)
+			}
+		}
+		matcher.patterns[asset] = regexp.MustCompile(`(?i)\b(` + strings.Join(words, "|") + `)\b`)
+	}
+	return matcher
+}
+
+// match 返回文本涉及的关注资产，按代码排序
+func (m *assetMatcher) match(text string) []string {
+	assets := make([]string, 0)
+	for asset, pattern := range m.patterns {
+		if pattern.MatchString(text) {
+			assets = append(assets, asset)
+			continue
+		}
+		for _, name := range m.chinese[asset] {
+			if strings.Contains(text, name) {
+				assets = append(assets, asset)
+				break
+			}
+		}
+	}
+	sort.Strings(assets)
+	return assets
+}
+
+// trackedAssets 返回配置的关注资产，未配置时使用交易对的基础资产
+func trackedAssets(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	assets := make([]string, 0)
+	add := func(asset string) {
+		asset = strings.ToUpper(strings.TrimSpace(asset))
+		if asset != "" && !seen[asset] {
+			seen[asset] = true
+			assets = append(assets, asset)
+		}
+	}
+
+	for _, asset := range cfg.News.Assets {
+		add(asset)
+	}
+	if len(assets) == 0 {
+		for _, pair := range cfg.Current().Trading.Pairs {
+			add(strings.Split(pair.Symbol, "/")[0])
+		}
+	}
+	sort.Strings(assets)
+	return assets
+}
+
+// searchTerms 返回按关注资产搜索新闻的关键词，有英文名称的资产使用第一个英文名称
+func searchTerms(assets []string) []string {
+	terms := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		term := asset
+		for _, name := range assetNames[asset] {
+			if isASCII(name) {
+				term = name
+				break
+			}
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// mergeAssets 合并新闻源标注的资产和从文本识别的资产，只保留关注的资产
+func mergeAssets(tagged, matched, tracked []string) []string {
+	merged := make([]string, 0, len(matched))
+	for _, assets := range [][]string{tagged, matched} {
+		for _, asset := range assets {
+			asset = strings.ToUpper(asset)
+			if containsAsset(tracked, asset) && !containsAsset(merged, asset) {
+				merged = append(merged, asset)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+func containsAsset(assets []string, asset string) bool {
+	for _, candidate := range assets {
+		if candidate == asset {
+			return true
+		}
+	}
+	return false
+}
+
+func isASCII(text string) bool {
+	for _, r := range text {
+		if r > 127 {
+			return false
+		}
+	}
+	return true
+}
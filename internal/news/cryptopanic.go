@@ -0,0 +1,77 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cryptoPanicURL 未配置地址时使用的 CryptoPanic 接口地址
+const cryptoPanicURL = "https://cryptopanic.com/api/v1/posts/"
+
+// cryptoPanicSource CryptoPanic 新闻聚合接口，按关注资产的代码过滤，新闻自带涉及的币种
+type cryptoPanicSource struct {
+	name   string
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+type cryptoPanicResponse struct {
+	Results []struct {
+		Kind        string    `json:"kind"`
+		Title       string    `json:"title"`
+		URL         string    `json:"url"`
+		PublishedAt time.Time `json:"published_at"`
+		Source      struct {
+			Title string `json:"title"`
+		} `json:"source"`
+		Currencies []struct {
+			Code string `json:"code"`
+		} `json:"currencies"`
+	} `json:"results"`
+}
+
+func (s *cryptoPanicSource) Name() string {
+	return s.name
+}
+
+func (s *cryptoPanicSource) Fetch(ctx context.Context, assets []string) ([]Article, error) {
+	query := url.Values{}
+	query.Set("auth_token", s.apiKey)
+	query.Set("public", "true")
+	query.Set("kind", "news")
+	if len(assets) > 0 {
+		query.Set("currencies", strings.Join(assets, ","))
+	}
+
+	body, err := fetch(ctx, s.client, s.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response cryptoPanicResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	articles := make([]Article, 0, len(response.Results))
+	for _, post := range response.Results {
+		tagged := make([]string, 0, len(post.Currencies))
+		for _, currency := range post.Currencies {
+			tagged = append(tagged, currency.Code)
+		}
+		articles = append(articles, Article{
+			Title:       cleanText(post.Title),
+			Source:      post.Source.Title,
+			URL:         post.URL,
+			PublishedAt: post.PublishedAt,
+			Assets:      tagged,
+		})
+	}
+	return articles, nil
+}
@@ -0,0 +1,347 @@
+package news
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultInterval 未配置抓取间隔时的抓取间隔
+	defaultInterval = 15 * time.Minute
+	// defaultMaxArticles 未配置时保留的新闻数量
+	defaultMaxArticles = 500
+	// defaultMaxAge 未配置时新闻的保留时间
+	defaultMaxAge = 72 * time.Hour
+	// fetchTimeout 单个新闻源一次抓取的超时
+	fetchTimeout = 30 * time.Second
+	// maxContentLength 保存的新闻正文的最大字符数，避免LLM提示词过长
+	maxContentLength = 1000
+)
+
+// Article 一条新闻
+type Article struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Source      string    `json:"source"` // 发布新闻的媒体，如 CoinDesk
+	Feed        string    `json:"feed"`   // 抓取到该新闻的新闻源名称
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"publishedAt"`
+	Assets      []string  `json:"assets"` // 新闻涉及的关注资产
+}
+
+// PromptData 返回提供给LLM分析的新闻内容
+func (a Article) PromptData() map[string]string {
+	return map[string]string{
+		"title":   a.Title,
+		"content": a.Content,
+		"source":  a.Source,
+		"date":    a.PublishedAt.UTC().Format(time.RFC3339),
+		"assets":  strings.Join(a.Assets, ","),
+	}
+}
+
+// Source 新闻源，返回最新的新闻，assets 为关注的资产
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, assets []string) ([]Article, error)
+}
+
+// Service 定时从各新闻源抓取新闻，按链接和标题去重，只保留涉及关注资产的新闻
+type Service struct {
+	cfg      config.NewsConfig
+	assets   []string
+	matcher  *assetMatcher
+	sources  []Source
+	articles map[string]Article // 键为新闻ID
+	titles   map[string]string  // 规范化的标题 -> 新闻ID，用于识别不同来源转载的同一新闻
+	store    store.Store        // 为nil时新闻不持久化
+	mutex    sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewService 按配置创建新闻源，关注的资产未配置时使用交易对的基础资产
+func NewService(cfg *config.Config) (*Service, error) {
+	assets := trackedAssets(cfg)
+	client := &http.Client{Timeout: fetchTimeout}
+
+	sources := make([]Source, 0, len(cfg.News.Sources))
+	for _, sourceCfg := range cfg.News.Sources {
+		source, err := newSource(sourceCfg, client)
+		if err != nil {
+			return nil, fmt.Errorf("创建新闻源 %s 失败: %v", sourceCfg.Name, err)
+		}
+		sources = append(sources, source)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		cfg:      cfg.News,
+		assets:   assets,
+		matcher:  newAssetMatcher(assets),
+		sources:  sources,
+		articles: make(map[string]Article),
+		titles:   make(map[string]string),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// SetStore 设置持久化存储，抓取的新闻将在重启后保留
+func (s *Service) SetStore(st store.Store) {
+	s.store = st
+}
+
+// Start 加载已保存的新闻，随后立即抓取一次并按配置的间隔定时抓取
+func (s *Service) Start() error {
+	if err := s.load(); err != nil {
+		return fmt.Errorf("加载已保存的新闻失败: %v", err)
+	}
+
+	interval := time.Duration(s.cfg.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	logrus.Infof("新闻源已启用，每 %v 抓取一次，关注的资产: %s", interval, strings.Join(s.assets, ", "))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.refresh()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.refresh()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止定时抓取
+func (s *Service) Stop() {
+	s.cancel()
+}
+
+// Latest 返回最新的新闻，最新发布的在前，asset 不为空时只返回涉及该资产的新闻，limit 为0时不限制数量
+func (s *Service) Latest(asset string, limit int) []Article {
+	asset = strings.ToUpper(asset)
+
+	s.mutex.RLock()
+	list := make([]Article, 0, len(s.articles))
+	for _, article := range s.articles {
+		if asset == "" || containsAsset(article.Assets, asset) {
+			list = append(list, article)
+		}
+	}
+	s.mutex.RUnlock()
+
+	sortArticles(list)
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// Assets 返回关注的资产
+func (s *Service) Assets() []string {
+	return s.assets
+}
+
+// refresh 依次抓取各新闻源，单个新闻源失败不影响其他新闻源
+func (s *Service) refresh() {
+	for _, source := range s.sources {
+		ctx, cancel := context.WithTimeout(s.ctx, fetchTimeout)
+		articles, err := source.Fetch(ctx, s.assets)
+		cancel()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			logrus.Warnf("抓取新闻源 %s 失败: %v", source.Name(), err)
+			continue
+		}
+
+		added := s.add(source.Name(), articles)
+		logrus.Debugf("新闻源 %s 返回 %d 条新闻，新增 %d 条", source.Name(), len(articles), added)
+	}
+	s.prune()
+}
+
+// add 保存涉及关注资产且未重复的新闻，返回新增的数量
+func (s *Service) add(feed string, articles []Article) int {
+	maxAge := s.maxAge()
+	added := make([]Article, 0)
+
+	s.mutex.Lock()
+	for _, article := range articles {
+		article.Title = strings.TrimSpace(article.Title)
+		if article.Title == "" || time.Since(article.PublishedAt) > maxAge {
+			continue
+		}
+		article.Feed = feed
+		article.Content = truncate(article.Content, maxContentLength)
+		article.Assets = mergeAssets(article.Assets, s.matcher.match(article.Title+"\n"+article.Content), s.assets)
+		if len(article.Assets) == 0 {
+			continue
+		}
+
+		article.ID = articleID(article)
+		title := normalizeTitle(article.Title)
+		if _, ok := s.articles[article.ID]; ok {
+			continue
+		}
+		if _, ok := s.titles[title]; ok {
+			continue
+		}
+		s.articles[article.ID] = article
+		s.titles[title] = article.ID
+		added = append(added, article)
+	}
+	s.mutex.Unlock()
+
+	for _, article := range added {
+		s.persist(article)
+	}
+	return len(added)
+}
+
+// prune 丢弃超过保留时间和超出保留数量的最早的新闻
+func (s *Service) prune() {
+	maxArticles := s.cfg.MaxArticles
+	if maxArticles <= 0 {
+		maxArticles = defaultMaxArticles
+	}
+	maxAge := s.maxAge()
+
+	s.mutex.Lock()
+	list := make([]Article, 0, len(s.articles))
+	for _, article := range s.articles {
+		list = append(list, article)
+	}
+	sortArticles(list)
+
+	removed := make([]string, 0)
+	for i, article := range list {
+		if i >= maxArticles || time.Since(article.PublishedAt) > maxAge {
+			delete(s.articles, article.ID)
+			delete(s.titles, normalizeTitle(article.Title))
+			removed = append(removed, article.ID)
+		}
+	}
+	s.mutex.Unlock()
+
+	if s.store == nil {
+		return
+	}
+	for _, id := range removed {
+		if err := s.store.Delete(store.CollectionNews, id); err != nil {
+			logrus.Warnf("删除新闻 %s 失败: %v", id, err)
+		}
+	}
+}
+
+// load 从存储中加载新闻
+func (s *Service) load() error {
+	if s.store == nil {
+		return nil
+	}
+
+	articles := make([]Article, 0)
+	err := s.store.Load(store.CollectionNews, func(id string, decode func(v interface{}) error) error {
+		var article Article
+		if err := decode(&article); err != nil {
+			return fmt.Errorf("解析新闻 %s 失败: %v", id, err)
+		}
+		articles = append(articles, article)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	for _, article := range articles {
+		s.articles[article.ID] = article
+		s.titles[normalizeTitle(article.Title)] = article.ID
+	}
+	s.mutex.Unlock()
+
+	logrus.Infof("已加载 %d 条新闻", len(articles))
+	s.prune()
+	return nil
+}
+
+// persist 保存新闻到存储
+func (s *Service) persist(article Article) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(store.CollectionNews, article.ID, article); err != nil {
+		logrus.Warnf("保存新闻 %s 失败: %v", article.ID, err)
+	}
+}
+
+func (s *Service) maxAge() time.Duration {
+	if s.cfg.MaxAgeHours > 0 {
+		return time.Duration(s.cfg.MaxAgeHours) * time.Hour
+	}
+	return defaultMaxAge
+}
+
+// articleID 按链接生成新闻ID，没有链接时按标题生成
+func articleID(article Article) string {
+	key := article.URL
+	if key == "" {
+		key = normalizeTitle(article.Title)
+	}
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:10])
+}
+
+// normalizeTitle 去掉大小写和标点的差异，用于识别不同来源转载的同一新闻
+func normalizeTitle(title string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if r == ' ' || r == '\t' || strings.ContainsRune(".,:;!?'\"-–—()[]“”‘’，。：；！？、（）", r) {
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// sortArticles 按发布时间从新到旧排序
+func sortArticles(list []Article) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].PublishedAt.Equal(list[j].PublishedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].PublishedAt.After(list[j].PublishedAt)
+	})
+}
+
+// truncate 截断超过 max 个字符的文本
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "..."
+}
@@ -0,0 +1,87 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// newsAPIURL 未配置地址时使用的 NewsAPI 搜索接口地址
+const newsAPIURL = "https://newsapi.org/v2/everything"
+
+// newsAPIPageSize 每次抓取的新闻数量
+const newsAPIPageSize = 50
+
+// newsAPISource NewsAPI 的全文搜索接口，未配置关键词时按关注资产的名称搜索
+type newsAPISource struct {
+	name   string
+	url    string
+	apiKey string
+	query  string
+	client *http.Client
+}
+
+type newsAPIResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Articles []struct {
+		Source struct {
+			Name string `json:"name"`
+		} `json:"source"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		Content     string    `json:"content"`
+		URL         string    `json:"url"`
+		PublishedAt time.Time `json:"publishedAt"`
+	} `json:"articles"`
+}
+
+func (s *newsAPISource) Name() string {
+	return s.name
+}
+
+func (s *newsAPISource) Fetch(ctx context.Context, assets []string) ([]Article, error) {
+	q := s.query
+	if q == "" {
+		q = strings.Join(searchTerms(assets), " OR ")
+	}
+	query := url.Values{}
+	query.Set("q", q)
+	query.Set("sortBy", "publishedAt")
+	query.Set("pageSize", fmt.Sprint(newsAPIPageSize))
+
+	header := http.Header{}
+	header.Set("X-Api-Key", s.apiKey)
+	body, err := fetch(ctx, s.client, s.url+"?"+query.Encode(), header)
+	if err != nil {
+		return nil, err
+	}
+
+	var response newsAPIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if response.Status == "error" {
+		return nil, fmt.Errorf("接口返回错误: %s", response.Message)
+	}
+
+	articles := make([]Article, 0, len(response.Articles))
+	for _, item := range response.Articles {
+		content := item.Description
+		if content == "" {
+			content = item.Content
+		}
+		articles = append(articles, Article{
+			Title:       cleanText(item.Title),
+			Content:     cleanText(content),
+			Source:      item.Source.Name,
+			URL:         item.URL,
+			PublishedAt: item.PublishedAt,
+		})
+	}
+	return articles, nil
+}
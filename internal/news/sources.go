@@ -0,0 +1,187 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+)
+
+// maxResponseSize 新闻源响应体的最大长度
+const maxResponseSize = 5 * 1024 * 1024
+
+// htmlTagPattern 匹配新闻摘要中的 HTML 标签
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// rssTimeLayouts RSS 和 Atom 中常见的时间格式
+var rssTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+}
+
+// newSource 按配置创建新闻源
+func newSource(cfg config.NewsSourceConfig, client *http.Client) (Source, error) {
+	switch cfg.Type {
+	case "rss":
+		return &rssSource{name: cfg.Name, url: cfg.URL, client: client}, nil
+	case "cryptopanic":
+		url := cfg.URL
+		if url == "" {
+			url = cryptoPanicURL
+		}
+		return &cryptoPanicSource{name: cfg.Name, url: url, apiKey: cfg.APIKey, client: client}, nil
+	case "newsapi":
+		url := cfg.URL
+		if url == "" {
+			url = newsAPIURL
+		}
+		return &newsAPISource{name: cfg.Name, url: url, apiKey: cfg.APIKey, query: cfg.Query, client: client}, nil
+	default:
+		return nil, fmt.Errorf("未知的新闻源类型: %s", cfg.Type)
+	}
+}
+
+// rssSource RSS 2.0 或 Atom 订阅
+type rssSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// rssFeed 同时兼容 RSS 的 channel/item 和 Atom 的 entry
+type rssFeed struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+func (s *rssSource) Name() string {
+	return s.name
+}
+
+func (s *rssSource) Fetch(ctx context.Context, assets []string) ([]Article, error) {
+	body, err := fetch(ctx, s.client, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("解析订阅失败: %v", err)
+	}
+
+	articles := make([]Article, 0, len(feed.Channel.Items)+len(feed.Entries))
+	for _, item := range feed.Channel.Items {
+		articles = append(articles, Article{
+			Title:       cleanText(item.Title),
+			Content:     cleanText(item.Description),
+			Source:      cleanText(feed.Channel.Title),
+			URL:         strings.TrimSpace(item.Link),
+			PublishedAt: parseTime(item.PubDate),
+		})
+	}
+	for _, entry := range feed.Entries {
+		content := entry.Summary
+		if content == "" {
+			content = entry.Content
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		articles = append(articles, Article{
+			Title:       cleanText(entry.Title),
+			Content:     cleanText(content),
+			Source:      cleanText(feed.Title),
+			URL:         entry.link(),
+			PublishedAt: parseTime(published),
+		})
+	}
+	return articles, nil
+}
+
+// link 返回 Atom 条目的正文链接
+func (e atomEntry) link() string {
+	for _, link := range e.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// fetch 发送 GET 请求并读取响应体，非200响应返回错误
+func fetch(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", "autotrade-news/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("返回状态码 %d: %s", resp.StatusCode, truncate(string(body), 200))
+	}
+	return body, nil
+}
+
+// cleanText 去掉 HTML 标签和实体，合并空白
+func cleanText(text string) string {
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// parseTime 解析新闻的发布时间，无法解析时视为刚刚发布
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range rssTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"autotransaction/config"
+)
+
+// telegramAPI Telegram 机器人接口地址
+const telegramAPI = "https://api.telegram.org"
+
+// defaultSMTPPort 未配置端口时使用的 SMTP 提交端口
+const defaultSMTPPort = 587
+
+// newChannel 按类型创建通知渠道
+func newChannel(cfg config.NotifyChannelConfig) (Channel, error) {
+	client := &http.Client{Timeout: sendTimeout}
+	switch cfg.Type {
+	case "telegram":
+		if cfg.BotToken == "" || cfg.ChatID == "" {
+			return nil, fmt.Errorf("需要 bot_token 和 chat_id")
+		}
+		return &telegramChannel{client: client, token: cfg.BotToken, chatID: cfg.ChatID}, nil
+	case "discord":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("需要 webhook_url")
+		}
+		return &discordChannel{client: client, webhookURL: cfg.WebhookURL}, nil
+	case "email":
+		if cfg.SMTPHost == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, fmt.Errorf("需要 smtp_host、from 和 to")
+		}
+		port := cfg.SMTPPort
+		if port == 0 {
+			port = defaultSMTPPort
+		}
+		return &emailChannel{
+			addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)),
+			host:     cfg.SMTPHost,
+			username: cfg.Username,
+			password: cfg.Password,
+			from:     cfg.From,
+			to:       cfg.To,
+		}, nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("需要 webhook_url")
+		}
+		retries := cfg.MaxRetries
+		if retries == 0 {
+			retries = defaultWebhookRetries
+		} else if retries < 0 {
+			retries = 0
+		}
+		return &webhookChannel{client: client, endpoint: cfg.WebhookURL, secret: cfg.Secret, retries: retries}, nil
+	default:
+		return nil, fmt.Errorf("未知的通知渠道类型: %s", cfg.Type)
+	}
+}
+
+// telegramChannel 通过 Telegram 机器人发送消息
+type telegramChannel struct {
+	client *http.Client
+	token  string
+	chatID string
+}
+
+// Send 调用 sendMessage 接口发送消息
+func (t *telegramChannel) Send(ctx context.Context, msg Message) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, t.token)
+	return postJSON(ctx, t.client, endpoint, map[string]interface{}{
+		"chat_id": t.chatID,
+		"text":    msg.String(),
+	})
+}
+
+// discordChannel 通过频道 Webhook 发送 Discord 消息
+type discordChannel struct {
+	client     *http.Client
+	webhookURL string
+}
+
+// Send 以粗体标题发送消息
+func (d *discordChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, d.client, d.webhookURL, map[string]interface{}{
+		"content": fmt.Sprintf("**%s**\n%s", msg.Title, msg.Text),
+	})
+}
+
+// emailChannel 通过 SMTP 发送邮件，配置了用户名时使用 PLAIN 认证
+type emailChannel struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// Send 发送纯文本邮件，标题作为邮件主题
+// net/smtp 不支持 context，超时由服务器连接决定
+func (e *emailChannel) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if e.username != "" {
+		auth = smtp.PlainAuth("", e.username, e.password, e.host)
+	}
+
+	var body strings.Builder
+	body.WriteString("From: " + e.from + "\r\n")
+	body.WriteString("To: " + strings.Join(e.to, ", ") + "\r\n")
+	body.WriteString("Subject: " + msg.Title + "\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(msg.String())
+
+	return smtp.SendMail(e.addr, auth, e.from, e.to, []byte(body.String()))
+}
+
+// statusError 接收方返回的非2xx响应
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("请求失败，状态码: %d，响应: %s", e.code, e.body)
+}
+
+// postJSON 以 JSON 请求体发送 POST 请求，非2xx响应返回错误
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %v", err)
+	}
+	return post(ctx, client, endpoint, data, nil)
+}
+
+// post 发送 JSON 请求体，header 为附加的请求头，非2xx响应返回 *statusError
+func post(ctx context.Context, client *http.Client, endpoint string, data []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// 请求地址中可能包含令牌，不写入日志
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"fmt"
+
+	"autotransaction/internal/approval"
+	"autotransaction/internal/blockchain"
+	"autotransaction/internal/events"
+	"autotransaction/internal/execution"
+	"autotransaction/internal/risk"
+)
+
+// formatEvent 将告警事件转换为通知，不需要告警的事件返回 false
+// 数量和价格在 Data 中以字符串表示，避免精度损失
+func formatEvent(event events.Event) (Message, bool) {
+	msg := Message{Event: event.Type, Timestamp: event.Timestamp}
+
+	switch payload := event.Payload.(type) {
+	case execution.Order:
+		if event.Type != events.EventFill {
+			return msg, false
+		}
+		msg.Title = fmt.Sprintf("成交: %s %s", directionName(payload.Direction), payload.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n策略: %s\n价格: %s\n数量: %s\n手续费: %s\n订单: %s",
+			payload.Account, payload.StrategyName, payload.Price.String(), payload.Quantity.String(),
+			payload.Fee.String(), payload.ID)
+		msg.Data = map[string]interface{}{
+			"orderId":       payload.ID,
+			"account":       payload.Account,
+			"symbol":        payload.Symbol,
+			"side":          payload.Direction,
+			"type":          payload.Type,
+			"status":        payload.Status,
+			"price":         payload.Price.String(),
+			"quantity":      payload.Quantity.String(),
+			"filledAmount":  payload.FilledQuantity.String(),
+			"avgFillPrice":  payload.AvgFillPrice.String(),
+			"fee":           payload.Fee.String(),
+			"strategy":      payload.StrategyName,
+			"signalId":      payload.SignalID,
+			"clientOrderId": payload.ClientOrderID,
+		}
+	case execution.Job:
+		signal := payload.Signal
+		msg.Title = fmt.Sprintf("订单任务失败: %s %s", directionName(signal.Direction), signal.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n策略: %s\n数量: %s\n尝试次数: %d\n原因: %s\n任务: %s\n已进入死信队列，需人工处理",
+			signal.Account, signal.StrategyName, signal.Quantity.String(), payload.Attempts, payload.LastError, payload.ID)
+		msg.Data = map[string]interface{}{
+			"jobId":    payload.ID,
+			"account":  signal.Account,
+			"symbol":   signal.Symbol,
+			"side":     signal.Direction,
+			"price":    signal.Price.String(),
+			"quantity": signal.Quantity.String(),
+			"forced":   signal.Forced,
+			"attempts": payload.Attempts,
+			"error":    payload.LastError,
+			"strategy": signal.StrategyName,
+			"signalId": signal.ID,
+		}
+	case risk.Rejection:
+		msg.Title = fmt.Sprintf("风险检查拒绝: %s %s", directionName(payload.Direction), payload.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n策略: %s\n原因: %s", payload.Account, payload.Strategy, payload.Reason)
+		msg.Data = map[string]interface{}{
+			"account":  payload.Account,
+			"symbol":   payload.Symbol,
+			"side":     payload.Direction,
+			"strategy": payload.Strategy,
+			"reason":   payload.Reason,
+		}
+	case risk.CircuitBreakerStatus:
+		msg.Title = "每日亏损熔断已触发"
+		msg.Text = fmt.Sprintf("账户: %s\n%s\n当日盈亏: %s (已实现 %s，未实现 %s)\n已暂停该账户的开仓，需手动解除",
+			payload.Account, payload.Reason, payload.DailyPnL.StringFixed(2), payload.RealizedPnL.StringFixed(2),
+			payload.UnrealizedPnL.StringFixed(2))
+		msg.Data = map[string]interface{}{
+			"account":       payload.Account,
+			"reason":        payload.Reason,
+			"dailyPnl":      payload.DailyPnL.String(),
+			"realizedPnl":   payload.RealizedPnL.String(),
+			"unrealizedPnl": payload.UnrealizedPnL.String(),
+		}
+	case risk.HaltStatus:
+		if payload.Halted {
+			msg.Title = "交易已紧急停止"
+			msg.Text = fmt.Sprintf("%s\n已拒绝所有新信号，强制平仓仍会执行，需手动恢复", payload.Reason)
+		} else {
+			msg.Title = "交易已恢复"
+			msg.Text = fmt.Sprintf("紧急停止已解除，停止原因: %s", payload.Reason)
+		}
+		msg.Data = map[string]interface{}{
+			"halted": payload.Halted,
+			"reason": payload.Reason,
+		}
+	case risk.ForcedExit:
+		position := payload.Position
+		msg.Title = fmt.Sprintf("强制平仓: %s", position.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n原因: %s\n数量: %s\n持仓成本: %s\n当前价格: %s",
+			position.Account, payload.Reason, position.Quantity.String(),
+			position.EntryPrice.String(), position.CurrentPrice.String())
+		msg.Data = map[string]interface{}{
+			"account":      position.Account,
+			"symbol":       position.Symbol,
+			"reason":       payload.Reason,
+			"quantity":     position.Quantity.String(),
+			"entryPrice":   position.EntryPrice.String(),
+			"currentPrice": position.CurrentPrice.String(),
+		}
+		if position.Side != "" {
+			msg.Text += fmt.Sprintf("\n方向: %s\n强平价格: %s", position.Side, position.LiquidationPrice.String())
+			msg.Data["side"] = position.Side
+			msg.Data["liquidationPrice"] = position.LiquidationPrice.String()
+		}
+	case blockchain.BlockchainOrder:
+		msg.Title = fmt.Sprintf("链上交易失败: %s %s", directionName(payload.Direction), payload.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n网络: %s\n数量: %s\n交易哈希: %s\n原因: %s\n订单: %s",
+			payload.Account, payload.Network, payload.Quantity.String(),
+			payload.TxHash, payload.ErrorMessage, payload.ID)
+		msg.Data = map[string]interface{}{
+			"orderId":       payload.ID,
+			"account":       payload.Account,
+			"symbol":        payload.Symbol,
+			"side":          payload.Direction,
+			"status":        payload.Status,
+			"network":       payload.Network,
+			"price":         payload.Price.String(),
+			"quantity":      payload.Quantity.String(),
+			"txHash":        payload.TxHash,
+			"error":         payload.ErrorMessage,
+			"strategy":      payload.StrategyName,
+			"signalId":      payload.SignalID,
+			"clientOrderId": payload.ClientOrderID,
+		}
+	case approval.Request:
+		signal := payload.Signal
+		msg.Title = fmt.Sprintf("%s: %s %s", approvalStatusName(payload.Status), directionName(signal.Direction), signal.Symbol)
+		msg.Text = fmt.Sprintf("账户: %s\n策略: %s\n价格: %s\n数量: %s\n原因: %s\n审批请求: %s",
+			payload.Account, signal.StrategyName, signal.Price.String(), signal.Quantity.String(),
+			payload.Reason, payload.ID)
+		if payload.DecidedBy != "" {
+			msg.Text += "\n审批人: " + payload.DecidedBy
+		}
+		msg.Data = map[string]interface{}{
+			"approvalId": payload.ID,
+			"account":    payload.Account,
+			"symbol":     signal.Symbol,
+			"side":       signal.Direction,
+			"price":      signal.Price.String(),
+			"quantity":   signal.Quantity.String(),
+			"notional":   payload.Notional.String(),
+			"strategy":   signal.StrategyName,
+			"signalId":   signal.ID,
+			"reason":     payload.Reason,
+			"status":     payload.Status,
+			"expiresAt":  payload.ExpiresAt.Unix(),
+			"decidedBy":  payload.DecidedBy,
+			"note":       payload.Note,
+		}
+	default:
+		return msg, false
+	}
+	return msg, true
+}
+
+// directionName 返回交易方向的中文名称
+func directionName(direction string) string {
+	switch direction {
+	case "buy":
+		return "买入"
+	case "sell":
+		return "卖出"
+	}
+	return direction
+}
+
+// approvalStatusName 返回审批状态对应的通知标题
+func approvalStatusName(status string) string {
+	switch status {
+	case approval.StatusPending:
+		return "信号等待人工审批"
+	case approval.StatusApproved:
+		return "审批已批准"
+	case approval.StatusRejected:
+		return "审批已拒绝"
+	case approval.StatusExpired:
+		return "审批已过期"
+	}
+	return "审批状态变化"
+}
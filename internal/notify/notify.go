@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/events"
+
+	"github.com/sirupsen/logrus"
+)
+
+// queueSize 等待发送的通知数量上限，超出时丢弃新的通知
+const queueSize = 100
+
+// sendTimeout 发送通知的单次请求超时时间
+const sendTimeout = 10 * time.Second
+
+// Message 一条告警通知
+type Message struct {
+	Event     string // 事件类型，见 events 包
+	Title     string
+	Text      string
+	Timestamp time.Time
+
+	Data map[string]interface{} // 结构化的事件数据，webhook 渠道作为请求体的 data 字段发送
+}
+
+// String 返回通知的纯文本内容
+func (m Message) String() string {
+	return fmt.Sprintf("%s\n%s\n%s", m.Title, m.Text, m.Timestamp.Format("2006-01-02 15:04:05"))
+}
+
+// Channel 通知渠道
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// delivery 发往一个渠道的通知
+type delivery struct {
+	channel string
+	msg     Message
+}
+
+// Notifier 订阅事件总线，将告警事件按配置的路由发送到各通知渠道
+// 通知在后台依次发送，事件发布方不会因网络请求阻塞
+type Notifier struct {
+	channels map[string]Channel
+	routes   map[string][]string // 事件类型 -> 渠道名称，* 表示所有事件
+	queue    chan delivery
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewNotifier 按配置创建通知渠道和路由
+func NewNotifier(cfg config.NotifyConfig) (*Notifier, error) {
+	channels := make(map[string]Channel)
+	for _, channelCfg := range cfg.Channels {
+		channel, err := newChannel(channelCfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建通知渠道 %s 失败: %v", channelCfg.Name, err)
+		}
+		channels[channelCfg.Name] = channel
+	}
+
+	routes := make(map[string][]string)
+	for _, route := range cfg.Routes {
+		for _, name := range route.Channels {
+			if _, ok := channels[name]; !ok {
+				return nil, fmt.Errorf("未配置的通知渠道: %s", name)
+			}
+		}
+		routes[route.Event] = append(routes[route.Event], route.Channels...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Notifier{
+		channels: channels,
+		routes:   routes,
+		queue:    make(chan delivery, queueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start 开始在后台发送通知
+func (n *Notifier) Start() {
+	go n.run()
+}
+
+// Stop 停止发送通知，尚未发送的通知被丢弃
+func (n *Notifier) Stop() {
+	n.cancel()
+}
+
+// HandleEvent 实现 events.Handler 接口，将需要告警的事件转换为通知并加入发送队列
+func (n *Notifier) HandleEvent(event events.Event) {
+	msg, ok := formatEvent(event)
+	if !ok {
+		return
+	}
+	n.Notify(msg)
+}
+
+// Notify 将通知发送到路由到该事件类型的所有渠道，同一渠道只发送一次
+func (n *Notifier) Notify(msg Message) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	sent := make(map[string]bool)
+	for _, names := range [][]string{n.routes[msg.Event], n.routes["*"]} {
+		for _, name := range names {
+			if sent[name] {
+				continue
+			}
+			sent[name] = true
+
+			select {
+			case n.queue <- delivery{channel: name, msg: msg}:
+			default:
+				logrus.Warnf("通知队列已满，丢弃发往 %s 的通知: %s", name, msg.Title)
+			}
+		}
+	}
+}
+
+// run 依次发送队列中的通知，发送失败只记录日志
+// 单次请求的超时由各渠道的 HTTP 客户端控制，webhook 渠道的退避重试在停止时中断
+func (n *Notifier) run() {
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case d := <-n.queue:
+			if err := n.channels[d.channel].Send(n.ctx, d.msg); err != nil {
+				logrus.Errorf("发送通知到 %s 失败: %v", d.channel, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultWebhookRetries 未配置重试次数时 webhook 渠道的重试次数
+const defaultWebhookRetries = 3
+
+// webhookBackoff 第一次重试前的等待时间，之后每次翻倍
+const webhookBackoff = time.Second
+
+// webhookMaxBackoff 重试等待时间的上限
+const webhookMaxBackoff = 30 * time.Second
+
+// webhook 请求头，接收方用时间戳和签名校验请求来源，用投递ID对重试的请求去重
+const (
+	webhookSignatureHeader = "X-Autotrade-Signature"
+	webhookTimestampHeader = "X-Autotrade-Timestamp"
+	webhookDeliveryHeader  = "X-Autotrade-Delivery"
+	webhookEventHeader     = "X-Autotrade-Event"
+)
+
+// webhookSeq 投递ID的序号
+var webhookSeq uint64
+
+// webhookChannel 以 JSON 将事件发送到自定义地址，配置了密钥时附带 HMAC-SHA256 签名
+// 请求失败或返回5xx/429时按指数退避重试，其他4xx响应视为接收方拒绝，不再重试
+type webhookChannel struct {
+	client   *http.Client
+	endpoint string
+	secret   string
+	retries  int
+}
+
+// Send 发送事件，重试时使用相同的投递ID并重新签名
+func (w *webhookChannel) Send(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"event":     msg.Event,
+		"title":     msg.Title,
+		"text":      msg.Text,
+		"timestamp": msg.Timestamp.Unix(),
+		"data":      msg.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %v", err)
+	}
+	delivery := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&webhookSeq, 1))
+
+	backoff := webhookBackoff
+	for attempt := 0; ; attempt++ {
+		err = post(ctx, w.client, w.endpoint, data, w.header(msg.Event, delivery, data))
+		if err == nil || attempt >= w.retries || !retryable(err) {
+			return err
+		}
+
+		logrus.Warnf("发送 webhook 失败，%v 后第%d次重试: %v", backoff, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+}
+
+// header 生成 webhook 请求头，签名内容为 时间戳 + "." + 请求体
+func (w *webhookChannel) header(event, delivery string, data []byte) http.Header {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set(webhookEventHeader, event)
+	header.Set(webhookDeliveryHeader, delivery)
+	header.Set(webhookTimestampHeader, timestamp)
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(data)
+		header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return header
+}
+
+// retryable 判断发送失败是否可以重试，网络错误、5xx和429可以重试
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500 || status.code == http.StatusTooManyRequests
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Portfolio 跟踪各账户的现金和资产余额，并按仓位计算方法确定下单数量
+type Portfolio struct {
+	cfg      *config.Config
+	sizer    PositionSizer
+	balances map[string]map[string]decimal.Decimal // 账户 -> 资产 -> 余额
+	prices   map[string]decimal.Decimal            // 交易对最新价格
+	returns  map[string][]float64                  // 交易对最近的收益率，用于估算波动率
+	lookback int
+	mutex    sync.RWMutex
+}
+
+// NewPortfolio 创建账户资金跟踪器，使用配置中的初始余额
+func NewPortfolio(cfg *config.Config) (*Portfolio, error) {
+	// 仅用于模拟交易虚拟余额时不需要仓位计算方法
+	var sizer PositionSizer
+	if cfg.Portfolio.Enabled {
+		var err error
+		sizer, err = NewPositionSizer(cfg.Portfolio.Sizer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lookback := cfg.Portfolio.Sizer.VolatilityLookback
+	if lookback <= 1 {
+		lookback = 20
+	}
+
+	p := &Portfolio{
+		cfg:      cfg,
+		sizer:    sizer,
+		balances: make(map[string]map[string]decimal.Decimal),
+		prices:   make(map[string]decimal.Decimal),
+		returns:  make(map[string][]float64),
+		lookback: lookback,
+	}
+
+	for _, balance := range cfg.Portfolio.Balances {
+		account := balance.Account
+		if account == "" {
+			account = config.DefaultAccountID
+		}
+		if balance.Amount < 0 {
+			return nil, fmt.Errorf("账户 %s 的 %s 初始余额不能为负", account, balance.Asset)
+		}
+		p.adjustLocked(account, balance.Asset, decimal.NewFromFloat(balance.Amount))
+	}
+
+	return p, nil
+}
+
+// HandleData 实现 market.DataHandler 接口，记录最新价格和收益率
+func (p *Portfolio) HandleData(data market.MarketData) {
+	if !data.Close.IsPositive() {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if last, ok := p.prices[data.Symbol]; ok {
+		ret, _ := data.Close.Div(last).Sub(decimal.NewFromInt(1)).Float64()
+		returns := append(p.returns[data.Symbol], ret)
+		if len(returns) > p.lookback {
+			returns = returns[len(returns)-p.lookback:]
+		}
+		p.returns[data.Symbol] = returns
+	}
+	p.prices[data.Symbol] = data.Close
+}
+
+// ApplyFill 按成交更新账户的计价货币和标的资产余额
+func (p *Portfolio) ApplyFill(account, symbol, direction string, price, quantity decimal.Decimal) {
+	base, quote := splitSymbol(symbol)
+	notional := price.Mul(quantity)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	switch direction {
+	case "buy":
+		p.adjustLocked(account, base, quantity)
+		p.adjustLocked(account, quote, notional.Neg())
+	case "sell":
+		p.adjustLocked(account, base, quantity.Neg())
+		p.adjustLocked(account, quote, notional)
+	default:
+		return
+	}
+
+	if p.balances[account][quote].IsNegative() {
+		logrus.Warnf("账户 %s 的 %s 余额为负: %s", account, quote, p.balances[account][quote].String())
+	}
+}
+
+// ApplyRealizedPnL 将永续合约平仓的已实现盈亏计入账户的计价货币余额，合约不交割标的资产
+func (p *Portfolio) ApplyRealizedPnL(account, symbol string, pnl decimal.Decimal) {
+	_, quote := splitSymbol(symbol)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.adjustLocked(account, quote, pnl)
+}
+
+// ChargeFee 从账户的计价货币余额中扣除手续费
+func (p *Portfolio) ChargeFee(account, symbol string, fee decimal.Decimal) {
+	_, quote := splitSymbol(symbol)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.adjustLocked(account, quote, fee.Neg())
+}
+
+// Balances 获取账户各资产余额
+func (p *Portfolio) Balances(account string) map[string]decimal.Decimal {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	result := make(map[string]decimal.Decimal)
+	for asset, amount := range p.balances[account] {
+		result[asset] = amount
+	}
+	return result
+}
+
+// Cash 获取账户的计价货币余额
+func (p *Portfolio) Cash(account string) decimal.Decimal {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.balances[account][p.cfg.Trading.BaseCurrency]
+}
+
+// Equity 计算账户按最新价格折算为计价货币的总权益
+func (p *Portfolio) Equity(account string) decimal.Decimal {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.equityLocked(account)
+}
+
+// OrderQuantity 实现 strategy.OrderSizer 接口
+// 买入按仓位计算方法确定数量且不超过可用现金，卖出返回账户持有的全部标的数量
+// 永续合约买卖均按仓位计算方法确定数量，不超过可用现金按杠杆放大后的金额
+func (p *Portfolio) OrderQuantity(account, symbol, direction string, price decimal.Decimal) decimal.Decimal {
+	if !price.IsPositive() || p.sizer == nil {
+		return decimal.Zero
+	}
+	base, quote := splitSymbol(symbol)
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	leverage, perpetual := p.cfg.PerpetualLeverage(symbol)
+	if direction == "sell" && !perpetual {
+		return decimal.Max(p.balances[account][base], decimal.Zero)
+	}
+
+	fraction := p.sizer.EquityFraction(SizeRequest{
+		Symbol:     symbol,
+		Volatility: p.volatilityLocked(symbol),
+	})
+	if fraction <= 0 {
+		return decimal.Zero
+	}
+
+	notional := p.equityLocked(account).Mul(decimal.NewFromFloat(fraction))
+	cash := p.balances[account][quote]
+	if perpetual {
+		cash = cash.Mul(decimal.NewFromFloat(leverage))
+	}
+	if notional.GreaterThan(cash) {
+		notional = decimal.Max(cash, decimal.Zero)
+	}
+	return notional.Div(price).Round(8)
+}
+
+// equityLocked 计算账户总权益，调用方需持有锁
+// 无法折算为计价货币（尚无价格）的资产不计入
+func (p *Portfolio) equityLocked(account string) decimal.Decimal {
+	baseCurrency := p.cfg.Trading.BaseCurrency
+	equity := decimal.Zero
+	for asset, amount := range p.balances[account] {
+		if asset == baseCurrency {
+			equity = equity.Add(amount)
+			continue
+		}
+		if price, ok := p.prices[asset+"/"+baseCurrency]; ok {
+			equity = equity.Add(amount.Mul(price))
+		}
+	}
+	return equity
+}
+
+// volatilityLocked 计算交易对最近收益率的标准差，数据不足时返回0，调用方需持有锁
+func (p *Portfolio) volatilityLocked(symbol string) float64 {
+	returns := p.returns[symbol]
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// adjustLocked 调整账户资产余额，调用方需持有锁
+func (p *Portfolio) adjustLocked(account, asset string, delta decimal.Decimal) {
+	balances, ok := p.balances[account]
+	if !ok {
+		balances = make(map[string]decimal.Decimal)
+		p.balances[account] = balances
+	}
+	balances[asset] = balances[asset].Add(delta)
+}
+
+// splitSymbol 将交易对拆分为标的资产和计价货币，如 BTC/USDT -> BTC, USDT
+func splitSymbol(symbol string) (string, string) {
+	parts := strings.SplitN(symbol, "/", 2)
+	if len(parts) != 2 {
+		return symbol, ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,100 @@
+package portfolio
+
+import (
+	"fmt"
+
+	"autotransaction/config"
+)
+
+// SizeRequest 计算仓位所需的信息
+type SizeRequest struct {
+	Symbol     string
+	Volatility float64 // 交易对每根K线收益率的标准差，数据不足时为0
+}
+
+// PositionSizer 计算单笔买入应使用的账户权益比例
+type PositionSizer interface {
+	EquityFraction(req SizeRequest) float64
+}
+
+// NewPositionSizer 按配置创建仓位计算方法，默认为固定比例
+func NewPositionSizer(cfg config.SizerConfig) (PositionSizer, error) {
+	maxFraction := cfg.MaxFraction
+	if maxFraction <= 0 || maxFraction > 1 {
+		maxFraction = 1
+	}
+
+	switch cfg.Method {
+	case "", "fixed_fraction":
+		if cfg.Fraction <= 0 || cfg.Fraction > 1 {
+			return nil, fmt.Errorf("固定比例必须在(0, 1]之间: %v", cfg.Fraction)
+		}
+		return &FixedFractionSizer{Fraction: cfg.Fraction, MaxFraction: maxFraction}, nil
+	case "kelly":
+		if cfg.WinRate <= 0 || cfg.WinRate >= 1 || cfg.PayoffRatio <= 0 {
+			return nil, fmt.Errorf("凯利公式需要(0, 1)之间的胜率和大于0的盈亏比")
+		}
+		scale := cfg.KellyScale
+		if scale <= 0 {
+			scale = 1
+		}
+		return &KellySizer{WinRate: cfg.WinRate, PayoffRatio: cfg.PayoffRatio, Scale: scale, MaxFraction: maxFraction}, nil
+	case "volatility_target":
+		if cfg.TargetVolatility <= 0 {
+			return nil, fmt.Errorf("目标波动率必须大于0")
+		}
+		return &VolatilityTargetSizer{TargetVolatility: cfg.TargetVolatility, MaxFraction: maxFraction}, nil
+	default:
+		return nil, fmt.Errorf("未知的仓位计算方法: %s", cfg.Method)
+	}
+}
+
+// FixedFractionSizer 每笔使用固定比例的账户权益
+type FixedFractionSizer struct {
+	Fraction    float64
+	MaxFraction float64
+}
+
+// EquityFraction 实现 PositionSizer 接口
+func (s *FixedFractionSizer) EquityFraction(req SizeRequest) float64 {
+	return clampFraction(s.Fraction, s.MaxFraction)
+}
+
+// KellySizer 按凯利公式 f = W - (1-W)/R 计算比例，Scale 用于分数凯利（如0.5为半凯利）
+type KellySizer struct {
+	WinRate     float64
+	PayoffRatio float64
+	Scale       float64
+	MaxFraction float64
+}
+
+// EquityFraction 实现 PositionSizer 接口，期望为负时不开仓
+func (s *KellySizer) EquityFraction(req SizeRequest) float64 {
+	kelly := s.WinRate - (1-s.WinRate)/s.PayoffRatio
+	return clampFraction(kelly*s.Scale, s.MaxFraction)
+}
+
+// VolatilityTargetSizer 按目标波动率与交易对实际波动率之比确定比例，波动越大仓位越小
+type VolatilityTargetSizer struct {
+	TargetVolatility float64
+	MaxFraction      float64
+}
+
+// EquityFraction 实现 PositionSizer 接口，波动率未知时不开仓
+func (s *VolatilityTargetSizer) EquityFraction(req SizeRequest) float64 {
+	if req.Volatility <= 0 {
+		return 0
+	}
+	return clampFraction(s.TargetVolatility/req.Volatility, s.MaxFraction)
+}
+
+// clampFraction 将比例限制在[0, maxFraction]之间
+func clampFraction(fraction, maxFraction float64) float64 {
+	if fraction < 0 {
+		return 0
+	}
+	if fraction > maxFraction {
+		return maxFraction
+	}
+	return fraction
+}
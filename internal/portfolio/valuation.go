@@ -0,0 +1,236 @@
+package portfolio
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+	"autotransaction/internal/risk"
+
+	"github.com/shopspring/decimal"
+)
+
+// PositionSource 提供账户的当前持仓，由风险管理器实现，包含交易所和链上的持仓
+type PositionSource interface {
+	GetAccountPositions(account string) map[string]risk.Position
+}
+
+// RealizedPnLSource 提供账户的累计已实现盈亏，由交易执行器实现
+type RealizedPnLSource interface {
+	RealizedPnL(account string) decimal.Decimal
+}
+
+// PositionValue 按最新价格估值的单个持仓
+type PositionValue struct {
+	Symbol        string
+	Side          string // 永续合约持仓方向，现货为空
+	Quantity      decimal.Decimal
+	EntryPrice    decimal.Decimal
+	Price         decimal.Decimal // 最新价格，尚未收到行情时为持仓记录的价格
+	Value         decimal.Decimal // 计入权益的价值，现货为持仓市值，永续合约不持有标的资产，为未实现盈亏
+	UnrealizedPnL decimal.Decimal
+	Allocation    float64 // 占账户总权益的比例，0-1
+}
+
+// Valuation 账户按最新价格的估值
+type Valuation struct {
+	Account        string
+	Timestamp      time.Time
+	Cash           decimal.Decimal // 计价货币余额，未启用资金跟踪时为0
+	PositionsValue decimal.Decimal
+	Equity         decimal.Decimal // 现金与持仓市值之和
+	UnrealizedPnL  decimal.Decimal
+	RealizedPnL    decimal.Decimal
+	CashAllocation float64
+	Positions      []PositionValue // 按市值从大到小排列
+}
+
+// ValuationPoint 估值历史中的一个快照
+type ValuationPoint struct {
+	Timestamp      time.Time
+	Equity         decimal.Decimal
+	PositionsValue decimal.Decimal
+	UnrealizedPnL  decimal.Decimal
+	RealizedPnL    decimal.Decimal
+}
+
+// ValuationService 按实时行情为账户持仓估值，计算盈亏和资产配置比例，并定时记录估值快照
+type ValuationService struct {
+	cfg       *config.Config
+	positions PositionSource
+	realized  RealizedPnLSource // 为nil时不计算已实现盈亏
+	portfolio *Portfolio        // 为nil时不计入现金
+	prices    map[string]decimal.Decimal
+	history   map[string][]ValuationPoint // 账户 -> 估值快照，按时间排列
+	mutex     sync.RWMutex
+	stopChan  chan struct{}
+}
+
+// NewValuationService 创建账户估值服务
+func NewValuationService(cfg *config.Config, positions PositionSource, realized RealizedPnLSource) *ValuationService {
+	return &ValuationService{
+		cfg:       cfg,
+		positions: positions,
+		realized:  realized,
+		prices:    make(map[string]decimal.Decimal),
+		history:   make(map[string][]ValuationPoint),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetPortfolio 设置账户资金跟踪器，估值中计入现金余额
+func (s *ValuationService) SetPortfolio(p *Portfolio) {
+	s.portfolio = p
+}
+
+// HandleData 实现 market.DataHandler 接口，记录交易对的最新价格
+func (s *ValuationService) HandleData(data market.MarketData) {
+	if !data.Close.IsPositive() {
+		return
+	}
+
+	s.mutex.Lock()
+	s.prices[data.Symbol] = data.Close
+	s.mutex.Unlock()
+}
+
+// Start 立即记录一次估值快照并开始定时记录，未配置间隔时不记录
+func (s *ValuationService) Start() {
+	if s.cfg.Portfolio.ValuationIntervalSeconds <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.cfg.Portfolio.ValuationIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+
+		s.snapshot()
+		for {
+			select {
+			case <-s.stopChan:
+				return
+			case <-ticker.C:
+				s.snapshot()
+			}
+		}
+	}()
+}
+
+// Stop 停止记录估值快照
+func (s *ValuationService) Stop() {
+	close(s.stopChan)
+}
+
+// Value 按最新价格计算账户的估值
+func (s *ValuationService) Value(account string) Valuation {
+	valuation := Valuation{
+		Account:        account,
+		Timestamp:      time.Now(),
+		Cash:           decimal.Zero,
+		PositionsValue: decimal.Zero,
+		UnrealizedPnL:  decimal.Zero,
+		RealizedPnL:    decimal.Zero,
+		Positions:      make([]PositionValue, 0),
+	}
+	if s.portfolio != nil {
+		valuation.Cash = s.portfolio.Cash(account)
+	}
+	if s.realized != nil {
+		valuation.RealizedPnL = s.realized.RealizedPnL(account)
+	}
+
+	positions := s.positions.GetAccountPositions(account)
+	s.mutex.RLock()
+	for _, position := range positions {
+		price, ok := s.prices[position.Symbol]
+		if !ok {
+			price = position.CurrentPrice
+		}
+		value := price.Mul(position.Quantity)
+		unrealized := decimal.Zero
+		if position.EntryPrice.IsPositive() {
+			position.CurrentPrice = price
+			unrealized = position.UnrealizedPnL()
+		}
+		if position.Side != "" {
+			value = unrealized
+		}
+
+		valuation.Positions = append(valuation.Positions, PositionValue{
+			Symbol:        position.Symbol,
+			Side:          position.Side,
+			Quantity:      position.Quantity,
+			EntryPrice:    position.EntryPrice,
+			Price:         price,
+			Value:         value,
+			UnrealizedPnL: unrealized,
+		})
+		valuation.PositionsValue = valuation.PositionsValue.Add(value)
+		valuation.UnrealizedPnL = valuation.UnrealizedPnL.Add(unrealized)
+	}
+	s.mutex.RUnlock()
+
+	valuation.Equity = valuation.Cash.Add(valuation.PositionsValue)
+	if valuation.Equity.IsPositive() {
+		for i := range valuation.Positions {
+			valuation.Positions[i].Allocation = valuation.Positions[i].Value.Div(valuation.Equity).InexactFloat64()
+		}
+		valuation.CashAllocation = valuation.Cash.Div(valuation.Equity).InexactFloat64()
+	}
+	sort.Slice(valuation.Positions, func(i, j int) bool {
+		return valuation.Positions[i].Value.GreaterThan(valuation.Positions[j].Value)
+	})
+	return valuation
+}
+
+// History 获取账户在 since 之后的估值快照，按时间从早到晚排列
+func (s *ValuationService) History(account string, since time.Time) []ValuationPoint {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	points := s.history[account]
+	start := sort.Search(len(points), func(i int) bool {
+		return points[i].Timestamp.After(since)
+	})
+	result := make([]ValuationPoint, len(points)-start)
+	copy(result, points[start:])
+	return result
+}
+
+// snapshot 为所有账户记录一次估值快照，超出保留数量的最早快照被丢弃
+func (s *ValuationService) snapshot() {
+	historySize := s.cfg.Portfolio.ValuationHistorySize
+	for _, account := range accountIDs(s.cfg) {
+		valuation := s.Value(account)
+		point := ValuationPoint{
+			Timestamp:      valuation.Timestamp,
+			Equity:         valuation.Equity,
+			PositionsValue: valuation.PositionsValue,
+			UnrealizedPnL:  valuation.UnrealizedPnL,
+			RealizedPnL:    valuation.RealizedPnL,
+		}
+
+		s.mutex.Lock()
+		points := append(s.history[account], point)
+		if historySize > 0 && len(points) > historySize {
+			points = points[len(points)-historySize:]
+		}
+		s.history[account] = points
+		s.mutex.Unlock()
+	}
+}
+
+// accountIDs 返回配置的所有账户，未配置多账户时只有默认账户
+func accountIDs(cfg *config.Config) []string {
+	if len(cfg.Accounts) == 0 {
+		return []string{config.DefaultAccountID}
+	}
+
+	ids := make([]string, 0, len(cfg.Accounts))
+	for _, account := range cfg.Accounts {
+		ids = append(ids, account.ID)
+	}
+	return ids
+}
@@ -0,0 +1,181 @@
+package reconcile
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/metrics"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultInterval 未配置时的对账间隔
+const defaultInterval = 5 * time.Minute
+
+// ErrUnavailable 对账来源当前无法获取实际余额（如模拟模式或未配置API密钥），本轮跳过
+var ErrUnavailable = errors.New("无法获取实际余额")
+
+// Mismatch 本地跟踪的持仓与交易所或链上实际余额的差异
+type Mismatch struct {
+	Venue      string          `json:"venue"` // exchange 或 blockchain
+	Asset      string          `json:"asset"`
+	Symbol     string          `json:"symbol,omitempty"` // 资产只对应一个交易对时为该交易对，可按其校正持仓
+	Network    string          `json:"network,omitempty"`
+	Accounts   []string        `json:"accounts"` // 本地持有该交易对的账户
+	Local      decimal.Decimal `json:"local"`    // 本地跟踪的持仓数量，多个账户时为合计
+	Actual     decimal.Decimal `json:"actual"`   // 交易所或链上的实际余额
+	Difference decimal.Decimal `json:"difference"`
+	Corrected  bool            `json:"corrected"`      // 已按实际余额校正本地持仓
+	Note       string          `json:"note,omitempty"` // 未能自动校正的原因
+	DetectedAt time.Time       `json:"detectedAt"`
+}
+
+// Source 可对账的持仓来源，比较本地持仓与实际余额，correct 为 true 时按实际余额校正本地持仓
+type Source interface {
+	ReconcilePositions(tolerance decimal.Decimal, correct bool) ([]Mismatch, error)
+}
+
+// Status 一个来源最近一次对账的结果
+type Status struct {
+	Venue      string     `json:"venue"`
+	LastRun    time.Time  `json:"lastRun"`
+	Error      string     `json:"error,omitempty"`
+	Skipped    bool       `json:"skipped"` // 无法获取实际余额，本轮未对账
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// Service 定期将各来源的本地持仓与交易所余额和链上代币余额对账，记录差异并按配置校正
+type Service struct {
+	cfg      *config.Config
+	sources  map[string]Source // 场所 -> 来源
+	statuses map[string]Status
+	metrics  *metrics.Metrics // 为nil时不记录监控指标
+	mutex    sync.RWMutex
+	runMutex sync.Mutex // 串行化对账
+	stopChan chan struct{}
+}
+
+// NewService 创建对账服务
+func NewService(cfg *config.Config) *Service {
+	return &Service{
+		cfg:      cfg,
+		sources:  make(map[string]Source),
+		statuses: make(map[string]Status),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// AddSource 添加对账来源，venue 为 exchange 或 blockchain
+func (s *Service) AddSource(venue string, source Source) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sources[venue] = source
+}
+
+// SetMetrics 设置监控指标，记录各场所的持仓差异数
+func (s *Service) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// Start 启动定期对账，未启用时不做任何事
+func (s *Service) Start() {
+	reconcileCfg := s.cfg.Reconciliation
+	if !reconcileCfg.Enabled {
+		return
+	}
+	interval := time.Duration(reconcileCfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	logrus.Infof("启动持仓对账，间隔: %s，自动校正: %v", interval, reconcileCfg.AutoCorrect)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.Run()
+		for {
+			select {
+			case <-s.stopChan:
+				return
+			case <-ticker.C:
+				s.Run()
+			}
+		}
+	}()
+}
+
+// Stop 停止定期对账
+func (s *Service) Stop() {
+	close(s.stopChan)
+}
+
+// Run 立即对所有来源执行一轮对账，返回各来源的结果
+func (s *Service) Run() []Status {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	s.mutex.RLock()
+	sources := make(map[string]Source, len(s.sources))
+	for venue, source := range s.sources {
+		sources[venue] = source
+	}
+	s.mutex.RUnlock()
+
+	reconcileCfg := s.cfg.Reconciliation
+	tolerance := decimal.NewFromFloat(reconcileCfg.Tolerance)
+	for venue, source := range sources {
+		status := Status{Venue: venue, LastRun: time.Now(), Mismatches: make([]Mismatch, 0)}
+		mismatches, err := source.ReconcilePositions(tolerance, reconcileCfg.AutoCorrect)
+		switch {
+		case errors.Is(err, ErrUnavailable):
+			status.Skipped = true
+			status.Error = err.Error()
+			s.metrics.ObserveReconciliation(venue, "skipped")
+			logrus.Debugf("%s 持仓对账已跳过: %v", venue, err)
+		case err != nil:
+			status.Error = err.Error()
+			s.metrics.ObserveReconciliation(venue, "error")
+			logrus.Errorf("%s 持仓对账失败: %v", venue, err)
+		default:
+			status.Mismatches = append(status.Mismatches, mismatches...)
+			for _, mismatch := range mismatches {
+				logrus.Warnf("持仓对账差异: %s %s %s 本地 %s，实际 %s，已校正: %v %s", venue, mismatch.Asset, mismatch.Network,
+					mismatch.Local.String(), mismatch.Actual.String(), mismatch.Corrected, mismatch.Note)
+			}
+			s.metrics.ObserveReconciliation(venue, "ok")
+			s.metrics.SetPositionMismatches(venue, len(mismatches))
+		}
+
+		s.mutex.Lock()
+		s.statuses[venue] = status
+		s.mutex.Unlock()
+	}
+	return s.Statuses()
+}
+
+// Statuses 返回各来源最近一次对账的结果
+func (s *Service) Statuses() []Status {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]Status, 0, len(s.statuses))
+	for _, venue := range []string{metrics.VenueExchange, metrics.VenueBlockchain} {
+		if status, ok := s.statuses[venue]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Exceeds 判断本地数量与实际数量的差异是否超过容差，容差为相对较大一方的比例
+func Exceeds(local, actual, tolerance decimal.Decimal) bool {
+	difference := actual.Sub(local).Abs()
+	if difference.IsZero() {
+		return false
+	}
+	return difference.GreaterThan(decimal.Max(local.Abs(), actual.Abs()).Mul(tolerance))
+}
@@ -0,0 +1,254 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/events"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// CircuitBreakerStatus 账户的每日亏损熔断器状态
+type CircuitBreakerStatus struct {
+	Account       string
+	Enabled       bool
+	Day           string
+	RealizedPnL   decimal.Decimal
+	UnrealizedPnL decimal.Decimal // 相对当日起点的未实现盈亏变化
+	DailyPnL      decimal.Decimal
+	MaxDailyLoss  decimal.Decimal
+	Tripped       bool
+	TrippedAt     time.Time
+	Reason        string
+}
+
+// dailyPnL 账户的当日盈亏统计
+// 当日盈亏 = 当日已实现盈亏 + (当前未实现盈亏 - 当日起点的未实现盈亏)
+type dailyPnL struct {
+	day                string
+	realized           decimal.Decimal
+	unrealizedBaseline decimal.Decimal
+	tripped            bool
+	trippedAt          time.Time
+	reason             string
+}
+
+// recordRealizedLocked 持仓减少时按减少的数量计入所属账户的已实现盈亏，反手时原持仓全部计入，调用方需持有锁
+func (rm *RiskManager) recordRealizedLocked(previous, current Position) {
+	if previous.EntryPrice.IsZero() || !current.CurrentPrice.IsPositive() {
+		return
+	}
+
+	remaining := decimal.Max(current.Quantity, decimal.Zero)
+	if current.PositionSide() != previous.PositionSide() {
+		remaining = decimal.Zero
+	}
+	closed := previous.Quantity.Sub(remaining)
+	if !closed.IsPositive() {
+		return
+	}
+
+	daily := rm.rollDayLocked(previous.Account)
+	pnl := Position{
+		Side:         previous.Side,
+		Quantity:     closed,
+		EntryPrice:   previous.EntryPrice,
+		CurrentPrice: current.CurrentPrice,
+	}.UnrealizedPnL()
+	daily.realized = daily.realized.Add(pnl)
+}
+
+// RecordFee 将交易手续费计入账户的当日已实现盈亏，并检查该账户是否触发每日亏损熔断
+func (rm *RiskManager) RecordFee(account string, fee decimal.Decimal) {
+	if !fee.IsPositive() {
+		return
+	}
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	daily := rm.rollDayLocked(account)
+	daily.realized = daily.realized.Sub(fee)
+	rm.checkDailyLossLocked(account)
+}
+
+// checkAllDailyLossLocked 检查所有账户的当日亏损，调用方需持有锁
+func (rm *RiskManager) checkAllDailyLossLocked() {
+	for _, account := range rm.breakerAccountsLocked() {
+		rm.checkDailyLossLocked(account)
+	}
+}
+
+// checkDailyLossLocked 检查账户的当日亏损是否超过阈值，超过时触发该账户的熔断，调用方需持有锁
+func (rm *RiskManager) checkDailyLossLocked(account string) {
+	breakerCfg := rm.cfg.Current().Risk.CircuitBreaker
+	if !breakerCfg.Enabled || breakerCfg.MaxDailyLoss <= 0 {
+		return
+	}
+
+	daily := rm.rollDayLocked(account)
+	if daily.tripped {
+		return
+	}
+
+	pnl := rm.dailyPnLLocked(account)
+	maxLoss := decimal.NewFromFloat(breakerCfg.MaxDailyLoss)
+	if pnl.GreaterThan(maxLoss.Neg()) {
+		return
+	}
+
+	daily.tripped = true
+	daily.trippedAt = time.Now()
+	daily.reason = fmt.Sprintf("当日亏损 %s 超过阈值 %s", pnl.Neg().StringFixed(2), maxLoss.String())
+	logrus.Errorf("账户 %s 每日亏损熔断已触发: %s，暂停该账户的开仓", account, daily.reason)
+	status := rm.circuitBreakerStatusLocked(account)
+	go rm.events.Publish(events.Event{
+		Type:    events.EventCircuitBreaker,
+		Account: account,
+		Payload: status,
+	})
+
+	// 只平掉触发熔断的账户的持仓
+	if breakerCfg.FlattenOnTrip {
+		for _, position := range rm.positions {
+			if position.Account != account || rm.untradeable[position.Symbol] {
+				continue
+			}
+			rm.emitExit(position, "每日亏损熔断")
+		}
+	}
+}
+
+// isCircuitBroken 判断账户的每日亏损熔断器是否已触发，调用方需持有锁
+func (rm *RiskManager) isCircuitBroken(account string) bool {
+	daily, ok := rm.daily[account]
+	return ok && rm.cfg.Current().Risk.CircuitBreaker.Enabled && daily.tripped && daily.day == breakerDay(time.Now())
+}
+
+// GetCircuitBreakerStatus 获取账户的每日亏损熔断器状态
+func (rm *RiskManager) GetCircuitBreakerStatus(account string) CircuitBreakerStatus {
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	rm.rollDayLocked(account)
+	return rm.circuitBreakerStatusLocked(account)
+}
+
+// GetCircuitBreakerStatuses 获取所有账户的每日亏损熔断器状态，按账户排序
+func (rm *RiskManager) GetCircuitBreakerStatuses() []CircuitBreakerStatus {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	accounts := rm.breakerAccountsLocked()
+	statuses := make([]CircuitBreakerStatus, 0, len(accounts))
+	for _, account := range accounts {
+		rm.rollDayLocked(account)
+		statuses = append(statuses, rm.circuitBreakerStatusLocked(account))
+	}
+	return statuses
+}
+
+// circuitBreakerStatusLocked 获取账户当日的熔断器状态，调用方需持有锁并已调用 rollDayLocked
+func (rm *RiskManager) circuitBreakerStatusLocked(account string) CircuitBreakerStatus {
+	daily := rm.daily[account]
+	unrealized := rm.unrealizedPnLLocked(account).Sub(daily.unrealizedBaseline)
+	return CircuitBreakerStatus{
+		Account:       account,
+		Enabled:       rm.cfg.Current().Risk.CircuitBreaker.Enabled,
+		Day:           daily.day,
+		RealizedPnL:   daily.realized,
+		UnrealizedPnL: unrealized,
+		DailyPnL:      daily.realized.Add(unrealized),
+		MaxDailyLoss:  decimal.NewFromFloat(rm.cfg.Current().Risk.CircuitBreaker.MaxDailyLoss),
+		Tripped:       daily.tripped,
+		TrippedAt:     daily.trippedAt,
+		Reason:        daily.reason,
+	}
+}
+
+// ResetCircuitBreaker 手动解除账户的熔断，并以当前时点作为该账户新的当日盈亏起点
+func (rm *RiskManager) ResetCircuitBreaker(account string) {
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	rm.daily[account] = &dailyPnL{
+		day:                breakerDay(time.Now()),
+		unrealizedBaseline: rm.unrealizedPnLLocked(account),
+	}
+	logrus.Infof("账户 %s 的每日亏损熔断已手动解除", account)
+}
+
+// rollDayLocked 返回账户的当日盈亏统计，跨日时重置当日盈亏和熔断状态，调用方需持有锁
+func (rm *RiskManager) rollDayLocked(account string) *dailyPnL {
+	today := breakerDay(time.Now())
+	daily, ok := rm.daily[account]
+	if ok && daily.day == today {
+		return daily
+	}
+
+	if ok && daily.tripped {
+		logrus.Infof("进入新的交易日，账户 %s 的每日亏损熔断自动解除", account)
+	}
+	daily = &dailyPnL{
+		day:                today,
+		unrealizedBaseline: rm.unrealizedPnLLocked(account),
+	}
+	rm.daily[account] = daily
+	return daily
+}
+
+// dailyPnLLocked 计算账户的当日盈亏，调用方需持有锁并已调用 rollDayLocked
+func (rm *RiskManager) dailyPnLLocked(account string) decimal.Decimal {
+	daily := rm.daily[account]
+	return daily.realized.Add(rm.unrealizedPnLLocked(account).Sub(daily.unrealizedBaseline))
+}
+
+// unrealizedPnLLocked 计算账户所有持仓的未实现盈亏，调用方需持有锁
+func (rm *RiskManager) unrealizedPnLLocked(account string) decimal.Decimal {
+	total := decimal.Zero
+	for _, position := range rm.positions {
+		if position.Account != account || position.EntryPrice.IsZero() || !position.CurrentPrice.IsPositive() {
+			continue
+		}
+		total = total.Add(position.UnrealizedPnL())
+	}
+	return total
+}
+
+// breakerAccountsLocked 返回配置的账户以及有持仓或当日盈亏统计的账户，按账户排序，调用方需持有锁
+func (rm *RiskManager) breakerAccountsLocked() []string {
+	seen := map[string]bool{config.DefaultAccountID: true}
+	for _, account := range rm.cfg.Accounts {
+		seen[account.ID] = true
+	}
+	for _, position := range rm.positions {
+		seen[position.Account] = true
+	}
+	for account := range rm.daily {
+		seen[account] = true
+	}
+
+	accounts := make([]string, 0, len(seen))
+	for account := range seen {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// breakerDay 返回用于划分交易日的日期（UTC）
+func breakerDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
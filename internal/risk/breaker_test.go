@@ -0,0 +1,78 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// exitRecorder 记录风险管理器发出的强制平仓信号
+type exitRecorder chan strategy.Signal
+
+func (r exitRecorder) HandleSignal(signal strategy.Signal) {
+	r <- signal
+}
+
+func TestCircuitBreakerIsPerAccount(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.StopLoss = 0.5
+	cfg.Risk.TakeProfit = 0.5
+	cfg.Risk.CircuitBreaker = config.CircuitBreakerConfig{Enabled: true, MaxDailyLoss: 100, FlattenOnTrip: true}
+	cfg.Accounts = []config.AccountConfig{{ID: "a"}, {ID: "b"}}
+	rm := NewRiskManager(cfg)
+	exits := make(exitRecorder, 4)
+	rm.RegisterExitHandler(exits)
+
+	position := func(account, symbol string, price int64) Position {
+		return Position{Account: account, Symbol: symbol, Quantity: decimal.NewFromInt(10), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(price)}
+	}
+	rm.UpdatePosition(position("a", "BTC/USDT", 100))
+	rm.UpdatePosition(position("b", "ETH/USDT", 100))
+
+	// 账户 a 亏损 200 超过阈值，只触发账户 a 的熔断
+	rm.UpdatePosition(position("a", "BTC/USDT", 80))
+	if !rm.GetCircuitBreakerStatus("a").Tripped {
+		t.Fatal("账户 a 亏损超过阈值时应触发熔断")
+	}
+	if rm.GetCircuitBreakerStatus("b").Tripped {
+		t.Fatal("账户 a 的亏损不应触发账户 b 的熔断")
+	}
+
+	signal := buySignal("BTC/USDT")
+	signal.Account = "a"
+	if err := rm.PreviewSignal(signal); err == nil {
+		t.Fatal("熔断后账户 a 不应继续开仓")
+	}
+	signal.Account = "b"
+	if err := rm.PreviewSignal(signal); err != nil {
+		t.Fatalf("账户 b 应允许开仓: %v", err)
+	}
+
+	// 触发熔断时只平掉账户 a 的持仓
+	select {
+	case exit := <-exits:
+		if exit.Account != "a" || exit.Symbol != "BTC/USDT" {
+			t.Fatalf("只应平掉账户 a 的持仓，实际平仓 %s %s", exit.Account, exit.Symbol)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("触发熔断时应平掉账户 a 的持仓")
+	}
+	select {
+	case exit := <-exits:
+		t.Fatalf("不应平掉其他账户的持仓，实际平仓 %s %s", exit.Account, exit.Symbol)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 手动解除只影响账户 a
+	rm.ResetCircuitBreaker("a")
+	if rm.GetCircuitBreakerStatus("a").Tripped {
+		t.Fatal("手动解除后账户 a 不应处于熔断状态")
+	}
+	if statuses := rm.GetCircuitBreakerStatuses(); len(statuses) != 3 {
+		t.Fatalf("应返回默认账户和账户 a、b 的熔断状态，实际 %d 个", len(statuses))
+	}
+}
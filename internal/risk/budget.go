@@ -0,0 +1,58 @@
+package risk
+
+import (
+	"fmt"
+
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// symbolBudget 返回交易对分配到的风险资金，未配置预算的交易对不受限制
+func (rm *RiskManager) symbolBudget(symbol string) (decimal.Decimal, bool) {
+	if rm.cfg.Current().Risk.RiskCapital <= 0 {
+		return decimal.Zero, false
+	}
+
+	for _, budget := range rm.cfg.Current().Risk.RiskBudget {
+		if budget.Symbol == symbol {
+			return decimal.NewFromFloat(rm.cfg.Current().Risk.RiskCapital * budget.Weight), true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// symbolExposure 计算账户在交易对上的持仓市值，各账户的风险检查互不影响，调用方需持有 rm.mutex
+func (rm *RiskManager) symbolExposure(account, symbol string, fallbackPrice decimal.Decimal) decimal.Decimal {
+	exposure := decimal.Zero
+	for _, position := range rm.positions {
+		if position.Account != account || position.Symbol != symbol {
+			continue
+		}
+		price := position.CurrentPrice
+		if price.IsZero() {
+			price = fallbackPrice
+		}
+		exposure = exposure.Add(position.Quantity.Mul(price))
+	}
+	return exposure
+}
+
+// checkRiskBudget 检查开仓后账户在交易对上的持仓市值是否超过其风险预算，调用方需持有 rm.mutex
+func (rm *RiskManager) checkRiskBudget(signal strategy.Signal) error {
+	if rm.openingSideLocked(signal) == "" {
+		return nil
+	}
+
+	budget, ok := rm.symbolBudget(signal.Symbol)
+	if !ok {
+		return nil
+	}
+
+	exposure := rm.symbolExposure(signalAccount(signal), signal.Symbol, signal.Price).Add(signal.Quantity.Mul(signal.Price))
+	if exposure.GreaterThan(budget) {
+		return fmt.Errorf("%s 买入后持仓市值 %s 超过风险预算 %s",
+			signal.Symbol, exposure.StringFixed(2), budget.StringFixed(2))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package risk
+
+import (
+	"testing"
+
+	"autotransaction/config"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRiskBudgetBlocksSymbolAtBudget(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.RiskCapital = 1000
+	cfg.Risk.RiskBudget = []config.SymbolRiskBudget{{Symbol: "BTC/USDT", Weight: 0.3}, {Symbol: "ETH/USDT", Weight: 0.7}}
+	rm := NewRiskManager(cfg)
+
+	// BTC 持仓市值 300 已用满预算，ETH 持仓市值 200 低于预算 700
+	rm.UpdatePosition(Position{Symbol: "BTC/USDT", Quantity: decimal.NewFromInt(3), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(100)})
+	rm.UpdatePosition(Position{Symbol: "ETH/USDT", Quantity: decimal.NewFromInt(2), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(100)})
+
+	if err := rm.PreviewSignal(buySignal("BTC/USDT")); err == nil {
+		t.Fatal("交易对已用满风险预算时不应继续买入")
+	}
+	if err := rm.PreviewSignal(buySignal("ETH/USDT")); err != nil {
+		t.Fatalf("交易对未用满风险预算时应允许买入: %v", err)
+	}
+
+	// 卖出减仓不受风险预算限制
+	sell := buySignal("BTC/USDT")
+	sell.Direction = "sell"
+	if err := rm.PreviewSignal(sell); err != nil {
+		t.Fatalf("卖出不应受风险预算限制: %v", err)
+	}
+}
+
+func TestRiskBudgetIsPerAccount(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.RiskCapital = 1000
+	cfg.Risk.RiskBudget = []config.SymbolRiskBudget{{Symbol: "BTC/USDT", Weight: 0.3}}
+	cfg.Accounts = []config.AccountConfig{{ID: "a"}, {ID: "b"}}
+	rm := NewRiskManager(cfg)
+
+	// 账户 a 的 BTC 持仓已用满预算
+	rm.UpdatePosition(Position{Account: "a", Symbol: "BTC/USDT", Quantity: decimal.NewFromInt(3), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(100)})
+
+	signal := buySignal("BTC/USDT")
+	signal.Account = "a"
+	if err := rm.PreviewSignal(signal); err == nil {
+		t.Fatal("账户 a 已用满风险预算时不应继续买入")
+	}
+	signal.Account = "b"
+	if err := rm.PreviewSignal(signal); err != nil {
+		t.Fatalf("账户 a 的持仓不应占用账户 b 的风险预算: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package risk
+
+import (
+	"time"
+
+	"autotransaction/internal/events"
+	"autotransaction/internal/strategy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterExitHandler 注册强制平仓信号的处理器（通常为交易执行器）
+func (rm *RiskManager) RegisterExitHandler(handler strategy.SignalHandler) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.exitHandlers = append(rm.exitHandlers, handler)
+}
+
+// ForcedExit 风险管理器触发的强制平仓
+type ForcedExit struct {
+	Position Position
+	Reason   string
+}
+
+// emitExit 为持仓生成平仓信号（多头卖出、空头买入）并异步分发给平仓处理器
+// 执行器处理信号时会回调风险管理器，因此不能在持有锁的情况下同步分发，调用方需持有锁
+func (rm *RiskManager) emitExit(position Position, reason string) {
+	signal := strategy.Signal{
+		Symbol:     position.Symbol,
+		Direction:  position.ExitDirection(),
+		Price:      position.CurrentPrice,
+		Quantity:   position.Quantity,
+		Timestamp:  time.Now().Unix(),
+		Confidence: 1,
+		Account:    position.Account,
+		Venue:      position.Venue,
+		ID:         strategy.NewSignalID(),
+		Forced:     true,
+	}
+
+	handlers := append([]strategy.SignalHandler{}, rm.exitHandlers...)
+	if len(handlers) == 0 {
+		logrus.Warnf("账户 %s 的 %s 需要平仓(%s)，但未注册平仓处理器", position.Account, position.Symbol, reason)
+		return
+	}
+
+	logrus.Warnf("账户 %s 的 %s 触发强制平仓: %s，数量: %s",
+		position.Account, position.Symbol, reason, position.Quantity.String())
+
+	go func() {
+		rm.events.Publish(events.Event{
+			Type:    events.EventForcedExit,
+			Account: position.Account,
+			Symbol:  position.Symbol,
+			Payload: ForcedExit{Position: position, Reason: reason},
+		})
+		for _, handler := range handlers {
+			handler.HandleSignal(signal)
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// checkExposureLimits 检查开仓后账户在交易对和其所属资产分组上的持仓市值是否超过最大敞口，调用方需持有 rm.mutex
+func (rm *RiskManager) checkExposureLimits(signal strategy.Signal) error {
+	if rm.openingSideLocked(signal) == "" {
+		return nil
+	}
+	account := signalAccount(signal)
+	limits := rm.cfg.Current().Risk.ExposureLimits
+	added := signal.Quantity.Mul(signal.Price)
+
+	for _, limit := range limits.Symbols {
+		if limit.Symbol != signal.Symbol || limit.MaxNotional <= 0 {
+			continue
+		}
+		exposure := rm.symbolExposure(account, signal.Symbol, signal.Price).Add(added)
+		maxNotional := decimal.NewFromFloat(limit.MaxNotional)
+		if exposure.GreaterThan(maxNotional) {
+			return fmt.Errorf("%s 买入后敞口 %s 超过交易对上限 %s",
+				signal.Symbol, exposure.StringFixed(2), maxNotional.StringFixed(2))
+		}
+	}
+
+	asset := market.BaseAsset(signal.Symbol)
+	for _, group := range limits.Groups {
+		if group.MaxNotional <= 0 || !containsAsset(group.Assets, asset) {
+			continue
+		}
+		exposure := rm.groupExposure(account, group.Assets, signal.Symbol, signal.Price).Add(added)
+		maxNotional := decimal.NewFromFloat(group.MaxNotional)
+		if exposure.GreaterThan(maxNotional) {
+			return fmt.Errorf("%s 买入后资产分组 %s 的敞口 %s 超过上限 %s",
+				signal.Symbol, group.Name, exposure.StringFixed(2), maxNotional.StringFixed(2))
+		}
+	}
+
+	return nil
+}
+
+// groupExposure 计算账户在资产分组上的持仓市值（多空持仓均计入），调用方需持有 rm.mutex
+// 持仓没有最新价格时，信号交易对使用信号价格估算
+func (rm *RiskManager) groupExposure(account string, assets []string, symbol string, signalPrice decimal.Decimal) decimal.Decimal {
+	exposure := decimal.Zero
+	for _, position := range rm.positions {
+		if position.Account != account || !containsAsset(assets, market.BaseAsset(position.Symbol)) {
+			continue
+		}
+		price := position.CurrentPrice
+		if price.IsZero() && position.Symbol == symbol {
+			price = signalPrice
+		}
+		exposure = exposure.Add(position.Quantity.Mul(price))
+	}
+	return exposure
+}
+
+// containsAsset 判断资产是否在列表中，不区分大小写
+func containsAsset(assets []string, asset string) bool {
+	for _, a := range assets {
+		if strings.EqualFold(a, asset) {
+			return true
+		}
+	}
+	return false
+}
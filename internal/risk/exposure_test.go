@@ -0,0 +1,44 @@
+package risk
+
+import (
+	"testing"
+
+	"autotransaction/config"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestExposureLimitsArePerAccount(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.ExposureLimits = config.ExposureLimitsConfig{
+		Symbols: []config.SymbolExposureLimit{{Symbol: "BTC/USDT", MaxNotional: 300}},
+		Groups:  []config.AssetGroupLimit{{Name: "L1", Assets: []string{"BTC", "ETH"}, MaxNotional: 500}},
+	}
+	cfg.Accounts = []config.AccountConfig{{ID: "a"}, {ID: "b"}}
+	rm := NewRiskManager(cfg)
+
+	// 账户 a 的 BTC 持仓已达到交易对上限，BTC 和 ETH 合计已达到分组上限
+	rm.UpdatePosition(Position{Account: "a", Symbol: "BTC/USDT", Quantity: decimal.NewFromInt(3), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(100)})
+	rm.UpdatePosition(Position{Account: "a", Symbol: "ETH/USDT", Quantity: decimal.NewFromInt(2), EntryPrice: decimal.NewFromInt(100), CurrentPrice: decimal.NewFromInt(100)})
+
+	btc := buySignal("BTC/USDT")
+	btc.Account = "a"
+	if err := rm.PreviewSignal(btc); err == nil {
+		t.Fatal("账户 a 达到交易对敞口上限时不应继续买入")
+	}
+	eth := buySignal("ETH/USDT")
+	eth.Account = "a"
+	if err := rm.PreviewSignal(eth); err == nil {
+		t.Fatal("账户 a 达到资产分组敞口上限时不应继续买入")
+	}
+
+	// 账户 a 的持仓不占用账户 b 的敞口
+	btc.Account = "b"
+	if err := rm.PreviewSignal(btc); err != nil {
+		t.Fatalf("账户 b 不应受账户 a 的交易对敞口限制: %v", err)
+	}
+	eth.Account = "b"
+	if err := rm.PreviewSignal(eth); err != nil {
+		t.Fatalf("账户 b 不应受账户 a 的资产分组敞口限制: %v", err)
+	}
+}
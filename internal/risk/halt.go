@@ -0,0 +1,91 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
+	"autotransaction/internal/strategy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HaltStatus 紧急停止（kill switch）的状态
+type HaltStatus struct {
+	Halted    bool
+	Reason    string
+	HaltedAt  time.Time
+	ResumedAt time.Time
+}
+
+// Halt 紧急停止交易：拒绝所有新的策略、手动和LLM信号，风险管理器触发的强制平仓仍然执行
+// 已处于停止状态时只更新原因
+func (rm *RiskManager) Halt(reason string) HaltStatus {
+	rm.mutex.Lock()
+	if !rm.halt.Halted {
+		rm.halt.HaltedAt = time.Now()
+	}
+	rm.halt.Halted = true
+	rm.halt.Reason = reason
+	status := rm.halt
+	rm.mutex.Unlock()
+
+	logrus.Errorf("交易已紧急停止: %s", reason)
+	rm.audit.Record(audit.Event{Type: audit.EventTradingHalt, Status: "halted", Reason: reason})
+	rm.events.Publish(events.Event{Type: events.EventTradingHalt, Payload: status})
+	return status
+}
+
+// Resume 解除紧急停止，恢复处理新信号
+func (rm *RiskManager) Resume() HaltStatus {
+	rm.mutex.Lock()
+	if !rm.halt.Halted {
+		status := rm.halt
+		rm.mutex.Unlock()
+		return status
+	}
+	rm.halt.Halted = false
+	rm.halt.ResumedAt = time.Now()
+	status := rm.halt
+	rm.mutex.Unlock()
+
+	logrus.Warn("紧急停止已解除，恢复交易")
+	rm.audit.Record(audit.Event{Type: audit.EventTradingHalt, Status: "resumed", Reason: status.Reason})
+	rm.events.Publish(events.Event{Type: events.EventTradingHalt, Payload: status})
+	return status
+}
+
+// GetHaltStatus 获取紧急停止状态
+func (rm *RiskManager) GetHaltStatus() HaltStatus {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+	return rm.halt
+}
+
+// FlattenAll 为所有持仓发出强制平仓信号，不可交易的交易对除外，返回平仓的持仓数
+func (rm *RiskManager) FlattenAll(reason string) int {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	flattened := 0
+	for _, position := range rm.positions {
+		if rm.untradeable[position.Symbol] || !position.Quantity.IsPositive() {
+			continue
+		}
+		rm.emitExit(position, reason)
+		flattened++
+	}
+	return flattened
+}
+
+// checkHalt 紧急停止时拒绝非强制平仓的信号
+func (rm *RiskManager) checkHalt(signal strategy.Signal) error {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	if rm.halt.Halted && !signal.Forced {
+		return fmt.Errorf("交易已紧急停止: %s", rm.halt.Reason)
+	}
+	return nil
+}
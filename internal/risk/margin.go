@@ -0,0 +1,124 @@
+package risk
+
+import (
+	"fmt"
+
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// 持仓方向，现货持仓只有多头
+const (
+	SideLong  = "long"
+	SideShort = "short"
+)
+
+// PositionSide 返回持仓方向，未设置时为多头
+func (p Position) PositionSide() string {
+	if p.Side == SideShort {
+		return SideShort
+	}
+	return SideLong
+}
+
+// ExitDirection 返回平掉持仓的交易方向，多头为 sell，空头为 buy
+func (p Position) ExitDirection() string {
+	if p.PositionSide() == SideShort {
+		return "buy"
+	}
+	return "sell"
+}
+
+// UnrealizedPnL 按最新价格计算持仓的未实现盈亏，空头在价格下跌时盈利
+func (p Position) UnrealizedPnL() decimal.Decimal {
+	pnl := p.CurrentPrice.Sub(p.EntryPrice).Mul(p.Quantity)
+	if p.PositionSide() == SideShort {
+		return pnl.Neg()
+	}
+	return pnl
+}
+
+// LiquidationPrice 按逐仓保证金估算强平价格：保证金为持仓价值的 1/杠杆，亏损使剩余保证金低于维持保证金时强平
+// 多头为 开仓价 × (1 - 1/杠杆 + 维持保证金率)，空头为 开仓价 × (1 + 1/杠杆 - 维持保证金率)
+func LiquidationPrice(side string, entryPrice, leverage decimal.Decimal, maintenanceMarginRate float64) decimal.Decimal {
+	if !entryPrice.IsPositive() || !leverage.IsPositive() {
+		return decimal.Zero
+	}
+	distance := decimal.NewFromInt(1).Div(leverage).Sub(decimal.NewFromFloat(maintenanceMarginRate))
+	if side == SideShort {
+		return entryPrice.Mul(decimal.NewFromInt(1).Add(distance))
+	}
+	return decimal.Max(entryPrice.Mul(decimal.NewFromInt(1).Sub(distance)), decimal.Zero)
+}
+
+// openingSide 返回信号开仓或加仓的方向
+func (rm *RiskManager) openingSide(signal strategy.Signal) string {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+	return rm.openingSideLocked(signal)
+}
+
+// openingSideLocked 返回信号开仓或加仓的方向(SideLong/SideShort)，只减仓或平仓时返回空字符串，调用方需持有锁
+// 现货只有买入会开仓；永续合约与持仓同向、没有持仓或数量超过持仓（反手）时开仓
+func (rm *RiskManager) openingSideLocked(signal strategy.Signal) string {
+	if _, ok := rm.cfg.PerpetualLeverage(signal.Symbol); !ok {
+		if signal.Direction == "buy" {
+			return SideLong
+		}
+		return ""
+	}
+
+	side := SideLong
+	if signal.Direction == "sell" {
+		side = SideShort
+	}
+	position, exists := rm.positions[rm.signalPositionKey(signal)]
+	if !exists || !position.Quantity.IsPositive() || position.PositionSide() == side {
+		return side
+	}
+	if signal.Quantity.GreaterThan(position.Quantity) {
+		return side
+	}
+	return ""
+}
+
+// checkMarginLocked 检查永续合约开仓的杠杆倍数，以及开仓价距离强平价格是否大于提前平仓的缓冲，调用方需持有锁
+func (rm *RiskManager) checkMarginLocked(signal strategy.Signal, side string) error {
+	leverage, ok := rm.cfg.PerpetualLeverage(signal.Symbol)
+	if !ok || side == "" {
+		return nil
+	}
+
+	margin := rm.cfg.Current().Risk.Margin
+	if margin.MaxLeverage > 0 && leverage > margin.MaxLeverage {
+		return fmt.Errorf("%s 的杠杆 %v 倍超过上限 %v 倍", signal.Symbol, leverage, margin.MaxLeverage)
+	}
+
+	// 强平距离不大于缓冲时，开仓后会立即被强制平仓
+	distance := 1/leverage - margin.MaintenanceMarginRate
+	if distance <= margin.LiquidationBuffer {
+		return fmt.Errorf("%s 的杠杆 %v 倍过高，开仓价距离强平价格仅 %.2f%%", signal.Symbol, leverage, distance*100)
+	}
+	return nil
+}
+
+// checkLiquidation 检查永续合约持仓的最新价格是否接近强平价格，接近时强制平仓，调用方需持有锁
+// 返回是否已发出平仓信号
+func (rm *RiskManager) checkLiquidation(position Position) bool {
+	if !position.LiquidationPrice.IsPositive() || !position.CurrentPrice.IsPositive() || !position.Quantity.IsPositive() {
+		return false
+	}
+
+	buffer := decimal.NewFromFloat(rm.cfg.Current().Risk.Margin.LiquidationBuffer)
+	distance := position.CurrentPrice.Sub(position.LiquidationPrice).Div(position.CurrentPrice)
+	if position.PositionSide() == SideShort {
+		distance = distance.Neg()
+	}
+	if distance.GreaterThan(buffer) {
+		return false
+	}
+
+	rm.exitOnce(position, fmt.Sprintf("价格 %s 接近强平价格 %s", position.CurrentPrice.String(), position.LiquidationPrice.String()))
+	return true
+}
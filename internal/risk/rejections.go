@@ -0,0 +1,62 @@
+package risk
+
+import (
+	"time"
+
+	"autotransaction/internal/events"
+	"autotransaction/internal/strategy"
+)
+
+// maxRejections 保留的最近拒绝记录数量
+const maxRejections = 200
+
+// Rejection 被风险检查拒绝的信号及原因
+type Rejection struct {
+	Account   string
+	Symbol    string
+	Direction string
+	Strategy  string
+	Reason    string
+	Timestamp time.Time
+}
+
+// recordRejection 记录被拒绝的信号，只保留最近的记录
+func (rm *RiskManager) recordRejection(signal strategy.Signal, reason error) {
+	rejection := Rejection{
+		Account:   signalAccount(signal),
+		Symbol:    signal.Symbol,
+		Direction: signal.Direction,
+		Strategy:  signal.StrategyName,
+		Reason:    reason.Error(),
+		Timestamp: time.Now(),
+	}
+
+	rm.rejectionsMu.Lock()
+	rm.rejections = append(rm.rejections, rejection)
+	if len(rm.rejections) > maxRejections {
+		rm.rejections = rm.rejections[len(rm.rejections)-maxRejections:]
+	}
+	rm.rejectionsMu.Unlock()
+
+	rm.events.Publish(events.Event{
+		Type:      events.EventRiskRejection,
+		Account:   rejection.Account,
+		Symbol:    rejection.Symbol,
+		Timestamp: rejection.Timestamp,
+		Payload:   rejection,
+	})
+}
+
+// GetRejections 获取账户最近被拒绝的信号，按时间从新到旧排列，account 为空时返回所有账户
+func (rm *RiskManager) GetRejections(account string) []Rejection {
+	rm.rejectionsMu.Lock()
+	defer rm.rejectionsMu.Unlock()
+
+	result := make([]Rejection, 0)
+	for i := len(rm.rejections) - 1; i >= 0; i-- {
+		if account == "" || rm.rejections[i].Account == account {
+			result = append(result, rm.rejections[i])
+		}
+	}
+	return result
+}
@@ -1,9 +1,14 @@
 package risk
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
 	"autotransaction/internal/strategy"
 
 	"github.com/shopspring/decimal"
@@ -12,70 +17,239 @@ import (
 
 // Position 表示持仓信息
 type Position struct {
+	Account      string
 	Symbol       string
 	Quantity     decimal.Decimal
 	EntryPrice   decimal.Decimal
 	CurrentPrice decimal.Decimal
+
+	// 永续合约持仓的方向、杠杆和估算的强平价格，现货持仓为零值
+	Side             string // SideLong 或 SideShort，为空时为多头
+	Leverage         decimal.Decimal
+	LiquidationPrice decimal.Decimal
+
+	// Venue 持仓所在场所，为空时为交易所；同一交易对在交易所和链上的持仓分别跟踪，链上持仓为各网络的合计
+	Venue string
+}
+
+// key 返回持仓在风险管理器中的键
+func (p Position) key() string {
+	return VenuePositionKey(p.Account, p.Symbol, p.Venue)
 }
 
 // RiskManager 负责风险管理
 type RiskManager struct {
 	cfg       *config.Config
-	positions map[string]Position
-	mutex     sync.RWMutex
+	positions map[string]Position       // 键为 账户-交易对
+	slippage  map[string]*slippageState // 每个交易对的实际滑点统计
+
+	trendProvider TrendProvider // 多周期趋势确认使用的趋势提供者
+	exitHandlers  []strategy.SignalHandler
+	flattened     map[string]time.Time // 记录已在某个交易窗口关闭前平仓的持仓
+	untradeable   map[string]bool      // 已下架等原因无法交易的交易对
+	pendingExits  map[string]time.Time // 已发出止损/止盈平仓信号的持仓及发出时间
+	daily         map[string]*dailyPnL // 每个账户的当日盈亏统计和熔断状态
+	rejections    []Rejection          // 最近被拒绝的信号
+	rejectionsMu  sync.Mutex
+	audit         *audit.Log  // 为nil时不记录审计日志
+	events        *events.Bus // 为nil时不发布事件
+	mutex         sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	sentimentProvider SentimentProvider // 新闻情绪过滤使用的情绪提供者
+	halt              HaltStatus        // 紧急停止状态
 }
 
 // NewRiskManager 创建一个新的风险管理器
 func NewRiskManager(cfg *config.Config) *RiskManager {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &RiskManager{
-		cfg:       cfg,
-		positions: make(map[string]Position),
+		cfg:          cfg,
+		positions:    make(map[string]Position),
+		slippage:     make(map[string]*slippageState),
+		flattened:    make(map[string]time.Time),
+		untradeable:  make(map[string]bool),
+		pendingExits: make(map[string]time.Time),
+		daily:        make(map[string]*dailyPnL),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start 启动风险管理器的定时检查
+func (rm *RiskManager) Start() error {
+	logrus.Info("启动风险管理器")
+	go rm.monitor()
+	return nil
+}
+
+// Stop 停止风险管理器
+func (rm *RiskManager) Stop() {
+	logrus.Info("停止风险管理器")
+	rm.cancel()
+}
+
+// monitor 定时执行与时间相关的风险检查
+func (rm *RiskManager) monitor() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rm.ctx.Done():
+			return
+		case <-ticker.C:
+			rm.flattenBeforeClose()
+		}
 	}
 }
 
 // CheckSignal 检查交易信号是否符合风险控制要求
 func (rm *RiskManager) CheckSignal(signal strategy.Signal) bool {
+	if err := rm.ValidateSignal(signal); err != nil {
+		logrus.Warnf("拒绝 %s %s 信号: %v", signal.Symbol, signal.Direction, err)
+		return false
+	}
+	return true
+}
+
+// ValidateSignal 检查交易信号是否符合风险控制要求，不符合时返回拒绝原因并记录
+func (rm *RiskManager) ValidateSignal(signal strategy.Signal) error {
+	err := rm.validateSignal(signal)
+	if err == nil {
+		// 滑点熔断的交易对只允许一笔试单通过
+		err = rm.takeSlippageProbe(signal.Symbol)
+	}
+	event := audit.Event{
+		Type:      audit.EventRiskApproved,
+		Account:   signal.Account,
+		Symbol:    signal.Symbol,
+		Direction: signal.Direction,
+		Strategy:  signal.StrategyName,
+		Price:     signal.Price,
+		Quantity:  signal.Quantity,
+	}
+	if err != nil {
+		rm.recordRejection(signal, err)
+		event.Type = audit.EventRiskRejected
+		event.Reason = err.Error()
+	}
+	rm.audit.Record(event)
+	return err
+}
+
+// PreviewSignal 执行与 ValidateSignal 相同的风险检查，但不记录拒绝和审计日志，用于模拟下单（dry-run）
+func (rm *RiskManager) PreviewSignal(signal strategy.Signal) error {
+	return rm.validateSignal(signal)
+}
+
+// SetAuditLog 设置审计日志，记录每次风险检查的结果
+func (rm *RiskManager) SetAuditLog(log *audit.Log) {
+	rm.audit = log
+}
+
+// SetEventBus 设置事件总线，被拒绝的信号会发布到总线
+func (rm *RiskManager) SetEventBus(bus *events.Bus) {
+	rm.events = bus
+}
+
+// validateSignal 依次执行各项风险检查，返回第一个不通过的原因
+func (rm *RiskManager) validateSignal(signal strategy.Signal) error {
+	// 紧急停止后拒绝所有新信号，强制平仓除外
+	if err := rm.checkHalt(signal); err != nil {
+		return err
+	}
+
+	// 检查是否在允许的交易时间窗口内
+	if err := rm.checkTradingSchedule(signal); err != nil {
+		return err
+	}
+
+	// 检查开仓是否顺应更高周期趋势（可能访问外部数据，不持有锁）
+	if err := rm.checkTrend(signal); err != nil {
+		return err
+	}
+
+	// 检查开仓时资产的新闻情绪是否过于悲观
+	if err := rm.checkSentiment(signal); err != nil {
+		return err
+	}
+
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
 
+	// 信号开仓或加仓的方向，只减仓或平仓时为空
+	opening := rm.openingSideLocked(signal)
+
+	// 账户每日亏损熔断后暂停该账户的开仓，减仓仍然允许
+	if account := signalAccount(signal); opening != "" && rm.isCircuitBroken(account) {
+		return fmt.Errorf("账户 %s 每日亏损熔断已触发: %s", account, rm.daily[account].reason)
+	}
+
+	// 检查交易对是否已无法交易
+	if rm.untradeable[signal.Symbol] {
+		return fmt.Errorf("%s 已被标记为不可交易", signal.Symbol)
+	}
+
+	// 检查交易对是否因实际滑点过高被熔断
+	if rm.isSlippageHalted(signal.Symbol) {
+		return fmt.Errorf("%s 因实际滑点过高已暂停交易", signal.Symbol)
+	}
+
+	// 检查交易对持仓是否超过其分配的风险预算
+	if err := rm.checkRiskBudget(signal); err != nil {
+		return err
+	}
+
+	// 检查交易对和资产分组的最大敞口
+	if err := rm.checkExposureLimits(signal); err != nil {
+		return err
+	}
+
+	// 检查永续合约的杠杆和强平距离
+	if err := rm.checkMarginLocked(signal, opening); err != nil {
+		return err
+	}
+
+	account := signalAccount(signal)
+	limits := rm.cfg.AccountLimits(account)
+
 	// 检查最大持仓数量
-	if signal.Direction == "buy" {
-		// 如果是买入信号，检查当前持仓数量是否已达到最大值
-		if len(rm.positions) >= rm.cfg.Risk.MaxOpenPositions {
-			logrus.Warnf("达到最大持仓数量限制 (%d)，拒绝买入信号", rm.cfg.Risk.MaxOpenPositions)
-			return false
+	if opening != "" {
+		// 如果是开仓信号，检查该账户当前持仓数量是否已达到最大值
+		if rm.countAccountPositions(account) >= limits.MaxOpenPositions {
+			return fmt.Errorf("账户 %s 达到最大持仓数量限制 (%d)", account, limits.MaxOpenPositions)
 		}
 	}
 
 	// 检查单个交易对的最大仓位比例
-	if signal.Direction == "buy" {
+	if opening != "" {
 		// 在实际应用中，这里应该检查账户余额，确保不超过最大仓位比例
 		// 这里简化处理，假设每个交易对的仓位不超过配置的最大值
-		position, exists := rm.positions[signal.Symbol]
-		if exists {
+		position, exists := rm.positions[rm.signalPositionKey(signal)]
+		if exists && position.PositionSide() == opening {
 			// 如果已有仓位，检查增加后是否超过限制
 			// 这里需要根据实际情况计算仓位比例
 			// 简化处理，假设数量直接对应比例
 			newQuantity := position.Quantity.Add(signal.Quantity)
-			maxAllowed := decimal.NewFromFloat(rm.cfg.Risk.MaxPositionSize)
+			maxAllowed := decimal.NewFromFloat(limits.MaxPositionSize)
 
 			if newQuantity.GreaterThan(maxAllowed) {
-				logrus.Warnf("超过最大仓位比例限制 (%f)，拒绝买入信号", rm.cfg.Risk.MaxPositionSize)
-				return false
+				return fmt.Errorf("账户 %s 超过最大仓位比例限制 (%f)", account, limits.MaxPositionSize)
 			}
 		}
 	}
 
-	// 如果是卖出信号，检查是否有足够的持仓
-	if signal.Direction == "sell" {
-		position, exists := rm.positions[signal.Symbol]
+	// 如果是现货卖出信号，检查该账户是否有足够的持仓，永续合约卖出可以开空仓
+	if _, perpetual := rm.cfg.PerpetualLeverage(signal.Symbol); signal.Direction == "sell" && !perpetual {
+		position, exists := rm.positions[rm.signalPositionKey(signal)]
 		if !exists || position.Quantity.LessThan(signal.Quantity) {
-			logrus.Warnf("没有足够的持仓，拒绝卖出信号")
-			return false
+			return fmt.Errorf("账户 %s 没有足够的持仓", account)
 		}
 	}
 
-	return true
+	return nil
 }
 
 // UpdatePosition 更新持仓信息
@@ -83,48 +257,56 @@ func (rm *RiskManager) UpdatePosition(position Position) {
 	rm.mutex.Lock()
 	defer rm.mutex.Unlock()
 
+	if position.Account == "" {
+		position.Account = config.DefaultAccountID
+	}
+	key := position.key()
+	if previous, ok := rm.positions[key]; ok {
+		rm.recordRealizedLocked(previous, position)
+	}
+
 	if position.Quantity.LessThanOrEqual(decimal.Zero) {
 		// 如果数量为0或负数，删除该持仓
-		delete(rm.positions, position.Symbol)
+		delete(rm.positions, key)
+		delete(rm.pendingExits, key)
 	} else {
 		// 更新持仓信息
-		rm.positions[position.Symbol] = position
+		rm.positions[key] = position
 	}
 
-	// 检查止损和止盈
-	rm.checkStopLossAndTakeProfit(position)
+	// 检查强平距离、止损和止盈
+	if !rm.checkLiquidation(position) {
+		rm.checkStopLossAndTakeProfit(position)
+	}
+	rm.checkDailyLossLocked(position.Account)
 }
 
 // checkStopLossAndTakeProfit 检查是否触发止损或止盈
 func (rm *RiskManager) checkStopLossAndTakeProfit(position Position) {
-	// 如果没有持仓，直接返回
-	if position.Quantity.LessThanOrEqual(decimal.Zero) {
+	// 如果没有持仓或持仓成本未知（如从链上恢复的已有持仓），直接返回
+	if position.Quantity.LessThanOrEqual(decimal.Zero) || position.EntryPrice.IsZero() {
 		return
 	}
 
-	// 计算当前盈亏比例
+	// 计算当前盈亏比例，空头在价格下跌时盈利
 	entryValue := position.EntryPrice.Mul(position.Quantity)
-	currentValue := position.CurrentPrice.Mul(position.Quantity)
-	profitLoss := currentValue.Sub(entryValue).Div(entryValue)
+	profitLoss := position.UnrealizedPnL().Div(entryValue)
 
 	// 检查止损
-	stopLoss := decimal.NewFromFloat(-rm.cfg.Risk.StopLoss)
+	stopLoss := decimal.NewFromFloat(-rm.cfg.Current().Risk.StopLoss)
 	if profitLoss.LessThanOrEqual(stopLoss) {
-		logrus.Warnf("%s 触发止损，当前亏损: %s%%", position.Symbol, profitLoss.Mul(decimal.NewFromInt(100)).String())
-		// 在实际应用中，这里应该触发卖出操作
-		// 由于这是示例，我们只记录日志
+		rm.exitOnce(position, fmt.Sprintf("触发止损，当前亏损: %s%%", profitLoss.Mul(decimal.NewFromInt(100)).StringFixed(2)))
+		return
 	}
 
 	// 检查止盈
-	takeProfit := decimal.NewFromFloat(rm.cfg.Risk.TakeProfit)
+	takeProfit := decimal.NewFromFloat(rm.cfg.Current().Risk.TakeProfit)
 	if profitLoss.GreaterThanOrEqual(takeProfit) {
-		logrus.Infof("%s 触发止盈，当前盈利: %s%%", position.Symbol, profitLoss.Mul(decimal.NewFromInt(100)).String())
-		// 在实际应用中，这里应该触发卖出操作
-		// 由于这是示例，我们只记录日志
+		rm.exitOnce(position, fmt.Sprintf("触发止盈，当前盈利: %s%%", profitLoss.Mul(decimal.NewFromInt(100)).StringFixed(2)))
 	}
 }
 
-// GetPositions 获取当前所有持仓
+// GetPositions 获取所有账户的持仓，交易所持仓的键为 账户-交易对，链上持仓的键追加 -blockchain
 func (rm *RiskManager) GetPositions() map[string]Position {
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
@@ -137,3 +319,78 @@ func (rm *RiskManager) GetPositions() map[string]Position {
 
 	return result
 }
+
+// GetAccountPositions 获取指定账户的持仓，键与 GetPositions 相同
+func (rm *RiskManager) GetAccountPositions(account string) map[string]Position {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	result := make(map[string]Position)
+	for key, position := range rm.positions {
+		if position.Account == account {
+			result[key] = position
+		}
+	}
+
+	return result
+}
+
+// countAccountPositions 统计账户的持仓数量，调用方需持有锁
+func (rm *RiskManager) countAccountPositions(account string) int {
+	count := 0
+	for _, position := range rm.positions {
+		if position.Account == account {
+			count++
+		}
+	}
+	return count
+}
+
+// signalAccount 返回信号所属账户
+func signalAccount(signal strategy.Signal) string {
+	if signal.Account == "" {
+		return config.DefaultAccountID
+	}
+	return signal.Account
+}
+
+// PositionKey 生成 账户-交易对 格式的持仓键
+func PositionKey(account, symbol string) string {
+	return fmt.Sprintf("%s-%s", account, symbol)
+}
+
+// VenuePositionKey 生成风险管理器中的持仓键，交易所持仓为 账户-交易对，其他场所追加场所名称，避免与交易所持仓互相覆盖
+func VenuePositionKey(account, symbol, venue string) string {
+	if venue == "" || venue == strategy.VenueExchange {
+		return PositionKey(account, symbol)
+	}
+	return PositionKey(account, symbol) + "-" + venue
+}
+
+// signalPositionKey 返回信号所作用的持仓的键，信号未指定场所时按交易对配置判断
+func (rm *RiskManager) signalPositionKey(signal strategy.Signal) string {
+	venue := signal.Venue
+	if venue == "" {
+		for _, pair := range rm.cfg.Current().Trading.Pairs {
+			if pair.Symbol == signal.Symbol && pair.Blockchain != "" {
+				venue = strategy.VenueBlockchain
+				break
+			}
+		}
+	}
+	return VenuePositionKey(signalAccount(signal), signal.Symbol, venue)
+}
+
+// Holdings 获取账户各交易对的多头持仓数量（交易所和链上合计），实现 strategy.HoldingsProvider 接口
+func (rm *RiskManager) Holdings(account string) map[string]decimal.Decimal {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	result := make(map[string]decimal.Decimal)
+	for _, position := range rm.positions {
+		if position.Account == account && position.PositionSide() == SideLong {
+			result[position.Symbol] = result[position.Symbol].Add(position.Quantity)
+		}
+	}
+	return result
+}
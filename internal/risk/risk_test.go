@@ -0,0 +1,78 @@
+package risk
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAccountPositionsAreIsolated(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.MaxOpenPositions = 1
+	cfg.Accounts = []config.AccountConfig{{ID: "a"}, {ID: "b"}}
+	rm := NewRiskManager(cfg)
+
+	rm.UpdatePosition(Position{Account: "a", Symbol: "BTC/USDT", Quantity: decimal.NewFromInt(2), EntryPrice: decimal.NewFromInt(100)})
+
+	if positions := rm.GetAccountPositions("b"); len(positions) != 0 {
+		t.Fatalf("账户 b 不应看到账户 a 的持仓: %v", positions)
+	}
+	// 账户 a 已达到持仓数量上限，账户 b 仍可开仓
+	signal := buySignal("ETH/USDT")
+	signal.Account = "a"
+	if err := rm.PreviewSignal(signal); err == nil {
+		t.Fatal("账户 a 达到最大持仓数量后不应再开仓")
+	}
+	signal.Account = "b"
+	if err := rm.PreviewSignal(signal); err != nil {
+		t.Fatalf("账户 a 的持仓不应影响账户 b: %v", err)
+	}
+	// 账户 b 不能卖出账户 a 的持仓
+	sell := buySignal("BTC/USDT")
+	sell.Direction = "sell"
+	sell.Account = "b"
+	if err := rm.PreviewSignal(sell); err == nil {
+		t.Fatal("账户 b 没有持仓时不应允许卖出")
+	}
+}
+
+func TestVenuePositionsDoNotOverwrite(t *testing.T) {
+	cfg := testRiskConfig()
+	rm := NewRiskManager(cfg)
+
+	rm.UpdatePosition(Position{Symbol: "ETH/USDT", Quantity: decimal.NewFromInt(3), Venue: strategy.VenueExchange})
+	rm.UpdatePosition(Position{Symbol: "ETH/USDT", Quantity: decimal.NewFromInt(2), Venue: strategy.VenueBlockchain})
+
+	if positions := rm.GetPositions(); len(positions) != 2 {
+		t.Fatalf("交易所和链上持仓应分别保存，实际 %d 个", len(positions))
+	}
+	if holding := rm.Holdings(config.DefaultAccountID)["ETH/USDT"]; !holding.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("账户持有量应合计各场所的持仓，实际 %s", holding)
+	}
+
+	// 链上持仓只能在链上卖出，数量按链上持仓检查
+	sell := buySignal("ETH/USDT")
+	sell.Direction = "sell"
+	sell.Venue = strategy.VenueBlockchain
+	sell.Quantity = decimal.NewFromInt(3)
+	if err := rm.PreviewSignal(sell); err == nil {
+		t.Fatal("卖出数量超过链上持仓时应拒绝")
+	}
+	sell.Venue = strategy.VenueExchange
+	if err := rm.PreviewSignal(sell); err != nil {
+		t.Fatalf("交易所持仓足够时应允许卖出: %v", err)
+	}
+
+	// 链上持仓清零不影响交易所持仓
+	rm.UpdatePosition(Position{Symbol: "ETH/USDT", Venue: strategy.VenueBlockchain})
+	positions := rm.GetPositions()
+	if len(positions) != 1 {
+		t.Fatalf("链上持仓清零后应只剩交易所持仓，实际 %d 个", len(positions))
+	}
+	if position := positions[PositionKey(config.DefaultAccountID, "ETH/USDT")]; !position.Quantity.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("交易所持仓应保持不变，实际 %s", position.Quantity)
+	}
+}
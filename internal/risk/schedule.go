@@ -0,0 +1,146 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkTradingSchedule 检查信号是否在允许的交易时间窗口内
+func (rm *RiskManager) checkTradingSchedule(signal strategy.Signal) error {
+	schedule := rm.cfg.Current().Risk.TradingSchedule
+	if !schedule.Enabled {
+		return nil
+	}
+
+	now, err := rm.scheduleNow()
+	if err != nil {
+		return fmt.Errorf("交易时间窗口配置无效: %v", err)
+	}
+
+	if _, ok := currentWindowClose(rm.windowsFor(signal.Symbol), now); !ok {
+		return fmt.Errorf("%s 当前不在交易时间窗口内", signal.Symbol)
+	}
+
+	return nil
+}
+
+// flattenBeforeClose 在交易时间窗口关闭前平掉持仓
+func (rm *RiskManager) flattenBeforeClose() {
+	schedule := rm.cfg.Current().Risk.TradingSchedule
+	if !schedule.Enabled || schedule.FlattenBeforeCloseMinutes <= 0 {
+		return
+	}
+
+	now, err := rm.scheduleNow()
+	if err != nil {
+		logrus.Warnf("交易时间窗口配置无效: %v", err)
+		return
+	}
+	lead := time.Duration(schedule.FlattenBeforeCloseMinutes) * time.Minute
+
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	for key, position := range rm.positions {
+		if rm.untradeable[position.Symbol] {
+			continue
+		}
+
+		closeAt, ok := currentWindowClose(rm.windowsFor(position.Symbol), now)
+		if !ok || closeAt.Sub(now) > lead {
+			continue
+		}
+
+		// 每个窗口只平仓一次
+		if flattened, ok := rm.flattened[key]; ok && flattened.Equal(closeAt) {
+			continue
+		}
+		rm.flattened[key] = closeAt
+
+		rm.emitExit(position, fmt.Sprintf("交易时间窗口将于 %s 关闭", closeAt.Format("15:04")))
+	}
+}
+
+// windowsFor 返回交易对适用的交易时间窗口，交易对单独配置时覆盖全局配置
+func (rm *RiskManager) windowsFor(symbol string) []config.TradingWindowConfig {
+	schedule := rm.cfg.Current().Risk.TradingSchedule
+	for _, symbolSchedule := range schedule.Symbols {
+		if symbolSchedule.Symbol == symbol {
+			return symbolSchedule.Windows
+		}
+	}
+	return schedule.Windows
+}
+
+// scheduleNow 返回交易时间窗口所在时区的当前时间
+func (rm *RiskManager) scheduleNow() (time.Time, error) {
+	timezone := rm.cfg.Current().Risk.TradingSchedule.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时区 %s: %v", timezone, err)
+	}
+
+	return time.Now().In(location), nil
+}
+
+// currentWindowClose 判断当前时间是否在任一窗口内，并返回该窗口的关闭时间
+func currentWindowClose(windows []config.TradingWindowConfig, now time.Time) (time.Time, bool) {
+	for _, window := range windows {
+		start, err := time.Parse("15:04", window.Start)
+		if err != nil {
+			logrus.Warnf("无效的交易窗口开始时间: %s", window.Start)
+			continue
+		}
+		end, err := time.Parse("15:04", window.End)
+		if err != nil {
+			logrus.Warnf("无效的交易窗口结束时间: %s", window.End)
+			continue
+		}
+
+		// 跨夜窗口可能从前一天开始，因此同时检查今天和昨天开始的窗口
+		for _, dayOffset := range []int{0, -1} {
+			day := now.AddDate(0, 0, dayOffset)
+			if !windowAppliesOn(window.Days, day.Weekday()) {
+				continue
+			}
+
+			openAt := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, now.Location())
+			closeAt := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, now.Location())
+			if !closeAt.After(openAt) {
+				closeAt = closeAt.AddDate(0, 0, 1)
+			}
+
+			if !now.Before(openAt) && now.Before(closeAt) {
+				return closeAt, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// windowAppliesOn 判断窗口是否适用于指定星期
+func windowAppliesOn(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+
+	name := strings.ToLower(weekday.String())
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if day == name || day == name[:3] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+)
+
+// windowAround 返回相对当前时间 from 到 to 的每日交易窗口（UTC）
+func windowAround(from, to time.Duration) config.TradingWindowConfig {
+	now := time.Now().UTC()
+	return config.TradingWindowConfig{Start: now.Add(from).Format("15:04"), End: now.Add(to).Format("15:04")}
+}
+
+func TestTradingScheduleRejectsSignalOutsideWindow(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.TradingSchedule = config.TradingScheduleConfig{
+		Enabled: true,
+		Windows: []config.TradingWindowConfig{windowAround(2*time.Hour, 3*time.Hour)},
+		Symbols: []config.SymbolScheduleConfig{
+			{Symbol: "ETH/USDT", Windows: []config.TradingWindowConfig{windowAround(-time.Hour, time.Hour)}},
+		},
+	}
+	rm := NewRiskManager(cfg)
+
+	if err := rm.PreviewSignal(buySignal("BTC/USDT")); err == nil {
+		t.Fatal("交易时间窗口外的信号应被拒绝")
+	}
+	if err := rm.PreviewSignal(buySignal("ETH/USDT")); err != nil {
+		t.Fatalf("交易时间窗口内的信号应被允许: %v", err)
+	}
+}
+
+func TestCurrentWindowCloseOvernightAndDays(t *testing.T) {
+	overnight := []config.TradingWindowConfig{{Days: []string{"fri"}, Start: "22:00", End: "02:00"}}
+	// 2026-01-02 为星期五，周五开始的跨夜窗口持续到周六 02:00
+	saturday := time.Date(2026, 1, 3, 1, 30, 0, 0, time.UTC)
+	closeAt, ok := currentWindowClose(overnight, saturday)
+	if !ok || !closeAt.Equal(time.Date(2026, 1, 3, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("周六凌晨应在周五开始的跨夜窗口内，关闭时间 %v", closeAt)
+	}
+	if _, ok := currentWindowClose(overnight, saturday.Add(time.Hour)); ok {
+		t.Fatal("跨夜窗口关闭后不应在窗口内")
+	}
+	if _, ok := currentWindowClose(overnight, saturday.Add(22*time.Hour)); ok {
+		t.Fatal("周六晚上不适用只在周五开始的窗口")
+	}
+}
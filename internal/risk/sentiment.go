@@ -0,0 +1,49 @@
+package risk
+
+import (
+	"fmt"
+
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+)
+
+// SentimentProvider 提供资产的聚合新闻情绪
+type SentimentProvider interface {
+	// Sentiment 返回交易对基础资产的聚合情绪分数(-1 到 1)，没有近期评分时 ok 为 false
+	Sentiment(symbol string) (score float64, ok bool)
+}
+
+// SetSentimentProvider 设置新闻情绪过滤使用的情绪提供者
+func (rm *RiskManager) SetSentimentProvider(provider SentimentProvider) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.sentimentProvider = provider
+}
+
+// checkSentiment 检查开仓时基础资产的聚合新闻情绪是否低于阈值，没有近期评分时不限制
+func (rm *RiskManager) checkSentiment(signal strategy.Signal) error {
+	filter := rm.cfg.Current().Risk.SentimentFilter
+	if !filter.Enabled || rm.openingSide(signal) != SideLong {
+		return nil
+	}
+
+	rm.mutex.RLock()
+	provider := rm.sentimentProvider
+	rm.mutex.RUnlock()
+
+	if provider == nil {
+		return fmt.Errorf("未设置情绪提供者")
+	}
+
+	score, ok := provider.Sentiment(signal.Symbol)
+	if !ok {
+		return nil
+	}
+
+	if score < filter.MinScore {
+		return fmt.Errorf("%s 的新闻情绪 %.2f 低于阈值 %.2f，不允许买入",
+			market.BaseAsset(signal.Symbol), score, filter.MinScore)
+	}
+
+	return nil
+}
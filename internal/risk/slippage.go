@@ -0,0 +1,185 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 超过滑点容忍度时的处理方式
+const (
+	SlippageActionReject  = "reject"
+	SlippageActionRequote = "requote"
+)
+
+// ErrSlippageExceeded 当前报价相对信号价格的不利滑点超过容忍度
+var ErrSlippageExceeded = errors.New("预期滑点超过容忍度")
+
+// slippageState 记录单个交易对的实际滑点统计
+type slippageState struct {
+	samples    []decimal.Decimal // 最近成交的不利滑点(%)
+	halted     bool              // 是否已熔断
+	probing    bool              // 是否允许一笔试单
+	probeTaken bool              // 试单已被某个信号占用，等待其成交结果，期间其余信号仍被拒绝
+}
+
+// RecordFill 记录一笔订单的预期价格与实际成交均价，用于滑点熔断
+func (rm *RiskManager) RecordFill(symbol, direction string, expectedPrice, fillPrice decimal.Decimal) {
+	breakerCfg := rm.cfg.Current().Risk.SlippageBreaker
+	if !breakerCfg.Enabled || expectedPrice.IsZero() {
+		return
+	}
+
+	slippage := adverseSlippage(direction, expectedPrice, fillPrice)
+
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	state, ok := rm.slippage[symbol]
+	if !ok {
+		state = &slippageState{}
+		rm.slippage[symbol] = state
+	}
+
+	threshold := decimal.NewFromFloat(breakerCfg.MaxAvgSlippage)
+
+	// 试单成交后根据试单滑点决定是否恢复交易
+	if state.probeTaken {
+		state.probing = false
+		state.probeTaken = false
+		if slippage.LessThanOrEqual(threshold) {
+			state.halted = false
+			state.samples = nil
+			logrus.Infof("%s 试单滑点 %s%% 正常，恢复交易", symbol, slippage.StringFixed(4))
+		} else {
+			logrus.Errorf("%s 试单滑点 %s%% 仍超过阈值 %s%%，继续暂停交易",
+				symbol, slippage.StringFixed(4), threshold.String())
+		}
+		return
+	}
+
+	window := breakerCfg.Window
+	if window <= 0 {
+		window = 1
+	}
+
+	state.samples = append(state.samples, slippage)
+	if len(state.samples) > window {
+		state.samples = state.samples[len(state.samples)-window:]
+	}
+
+	if len(state.samples) < window || state.halted {
+		return
+	}
+
+	sum := decimal.Zero
+	for _, sample := range state.samples {
+		sum = sum.Add(sample)
+	}
+	average := sum.Div(decimal.NewFromInt(int64(len(state.samples))))
+
+	if average.GreaterThan(threshold) {
+		state.halted = true
+		logrus.Errorf("告警: %s 最近 %d 笔成交平均滑点 %s%% 超过阈值 %s%%，已暂停该交易对的交易，需要试单或手动恢复",
+			symbol, len(state.samples), average.StringFixed(4), threshold.String())
+	}
+}
+
+// CheckQuoteSlippage 下单前比较信号价格与当前报价，不利滑点超过 risk.slippage_tolerance 时返回 ErrSlippageExceeded
+func (rm *RiskManager) CheckQuoteSlippage(direction string, signalPrice, quote decimal.Decimal) error {
+	if !signalPrice.IsPositive() || !quote.IsPositive() {
+		return nil
+	}
+	slippage := adverseSlippage(direction, signalPrice, quote)
+	tolerance := decimal.NewFromFloat(rm.cfg.Current().Risk.SlippageTolerance)
+	if slippage.GreaterThan(tolerance) {
+		return fmt.Errorf("%w: 信号价格 %s，当前报价 %s，不利滑点 %s%% 超过 %s%%",
+			ErrSlippageExceeded, signalPrice.String(), quote.String(), slippage.StringFixed(4), tolerance.String())
+	}
+	return nil
+}
+
+// RequoteOnSlippage 判断超过滑点容忍度的订单是否按当前报价重新定价，否则拒绝
+func (rm *RiskManager) RequoteOnSlippage() bool {
+	return rm.cfg.Current().Risk.SlippageAction == SlippageActionRequote
+}
+
+// adverseSlippage 返回实际价格相对预期价格的不利滑点(%)，买入高于预期或卖出低于预期为正
+func adverseSlippage(direction string, expectedPrice, price decimal.Decimal) decimal.Decimal {
+	slippage := price.Sub(expectedPrice).Div(expectedPrice).Mul(decimal.NewFromInt(100))
+	if direction == "sell" {
+		slippage = slippage.Neg()
+	}
+	return slippage
+}
+
+// ProbeSymbol 允许被滑点熔断的交易对执行一笔试单，试单滑点正常则自动恢复
+func (rm *RiskManager) ProbeSymbol(symbol string) bool {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	state, ok := rm.slippage[symbol]
+	if !ok || !state.halted {
+		return false
+	}
+
+	state.probing = true
+	state.probeTaken = false
+	logrus.Infof("%s 进入试单模式，允许执行一笔交易", symbol)
+	return true
+}
+
+// ResumeSymbol 手动恢复被滑点熔断的交易对
+func (rm *RiskManager) ResumeSymbol(symbol string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	delete(rm.slippage, symbol)
+	logrus.Infof("%s 已手动恢复交易", symbol)
+}
+
+// IsSlippageHalted 判断交易对是否因滑点过高被暂停
+func (rm *RiskManager) IsSlippageHalted(symbol string) bool {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+	return rm.isSlippageHalted(symbol)
+}
+
+// isSlippageHalted 判断交易对是否因滑点过高被暂停，允许试单且试单尚未被占用时不算暂停，调用方需持有锁
+func (rm *RiskManager) isSlippageHalted(symbol string) bool {
+	state, ok := rm.slippage[symbol]
+	return ok && state.halted && (!state.probing || state.probeTaken)
+}
+
+// takeSlippageProbe 通过风险检查的信号占用被熔断交易对的试单，试单已被占用时返回错误
+// 未熔断的交易对不做处理，试单的成交结果由 RecordFill 结算，未能下单时由 ReleaseSlippageProbe 释放
+func (rm *RiskManager) takeSlippageProbe(symbol string) error {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	state, ok := rm.slippage[symbol]
+	if !ok || !state.halted {
+		return nil
+	}
+	if !state.probing || state.probeTaken {
+		return fmt.Errorf("%s 因实际滑点过高已暂停交易", symbol)
+	}
+	state.probeTaken = true
+	logrus.Infof("%s 的试单已提交，等待成交结果", symbol)
+	return nil
+}
+
+// ReleaseSlippageProbe 试单在下单前失败或因幂等键重复未下单时释放占用，之后的信号可以重新试单
+func (rm *RiskManager) ReleaseSlippageProbe(symbol string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	state, ok := rm.slippage[symbol]
+	if !ok || !state.halted || !state.probeTaken {
+		return
+	}
+	state.probeTaken = false
+	logrus.Infof("%s 的试单未能下单，已释放试单", symbol)
+}
@@ -0,0 +1,117 @@
+package risk
+
+import (
+	"testing"
+
+	"autotransaction/config"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// testRiskConfig 返回只启用基本持仓限制的风险配置
+func testRiskConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Risk.MaxPositionSize = 1000
+	cfg.Risk.MaxOpenPositions = 10
+	return cfg
+}
+
+func buySignal(symbol string) strategy.Signal {
+	return strategy.Signal{
+		Symbol:    symbol,
+		Direction: "buy",
+		Price:     decimal.NewFromInt(100),
+		Quantity:  decimal.NewFromInt(1),
+	}
+}
+
+func TestSlippageBreakerTripsAndProbeResumes(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 3, MaxAvgSlippage: 0.5}
+	rm := NewRiskManager(cfg)
+	const symbol = "BTC/USDT"
+	expected := decimal.NewFromInt(100)
+
+	// 窗口未满时不熔断
+	rm.RecordFill(symbol, "buy", expected, decimal.NewFromInt(101))
+	rm.RecordFill(symbol, "buy", expected, decimal.NewFromInt(101))
+	if rm.IsSlippageHalted(symbol) {
+		t.Fatal("成交笔数未达到统计窗口时不应熔断")
+	}
+	rm.RecordFill(symbol, "buy", expected, decimal.NewFromInt(101))
+	if !rm.IsSlippageHalted(symbol) {
+		t.Fatal("平均滑点 1% 超过 0.5% 阈值时应熔断")
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err == nil {
+		t.Fatal("熔断后的交易对应拒绝信号")
+	}
+	if err := rm.ValidateSignal(buySignal("ETH/USDT")); err != nil {
+		t.Fatalf("其他交易对不应受影响: %v", err)
+	}
+
+	// 试单模式只允许一笔信号通过
+	if !rm.ProbeSymbol(symbol) {
+		t.Fatal("熔断的交易对应能进入试单模式")
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err != nil {
+		t.Fatalf("试单应通过风险检查: %v", err)
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err == nil {
+		t.Fatal("试单结算前其余信号应被拒绝")
+	}
+
+	// 试单滑点正常时恢复交易
+	rm.RecordFill(symbol, "buy", expected, expected)
+	if rm.IsSlippageHalted(symbol) {
+		t.Fatal("试单滑点正常后应恢复交易")
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err != nil {
+		t.Fatalf("恢复后信号应通过: %v", err)
+	}
+}
+
+func TestSlippageProbeWithHighSlippageStaysHalted(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 1, MaxAvgSlippage: 0.5}
+	rm := NewRiskManager(cfg)
+	const symbol = "BTC/USDT"
+
+	rm.RecordFill(symbol, "sell", decimal.NewFromInt(100), decimal.NewFromInt(98))
+	if !rm.IsSlippageHalted(symbol) {
+		t.Fatal("卖出低于预期 2% 应熔断")
+	}
+	rm.ProbeSymbol(symbol)
+	if err := rm.ValidateSignal(buySignal(symbol)); err != nil {
+		t.Fatalf("试单应通过风险检查: %v", err)
+	}
+	rm.RecordFill(symbol, "buy", decimal.NewFromInt(100), decimal.NewFromInt(102))
+	if !rm.IsSlippageHalted(symbol) {
+		t.Fatal("试单滑点仍过高时应继续暂停")
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err == nil {
+		t.Fatal("试单失败后信号应被拒绝")
+	}
+}
+
+func TestReleasedSlippageProbeCanBeRetaken(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.SlippageBreaker = config.SlippageBreakerConfig{Enabled: true, Window: 1, MaxAvgSlippage: 0.5}
+	rm := NewRiskManager(cfg)
+	const symbol = "BTC/USDT"
+
+	rm.RecordFill(symbol, "buy", decimal.NewFromInt(100), decimal.NewFromInt(102))
+	rm.ProbeSymbol(symbol)
+	if err := rm.ValidateSignal(buySignal(symbol)); err != nil {
+		t.Fatalf("试单应通过风险检查: %v", err)
+	}
+
+	// 试单未能下单，释放后下一笔信号可以重新试单
+	rm.ReleaseSlippageProbe(symbol)
+	if err := rm.ValidateSignal(buySignal(symbol)); err != nil {
+		t.Fatalf("释放后应允许重新试单: %v", err)
+	}
+	if err := rm.ValidateSignal(buySignal(symbol)); err == nil {
+		t.Fatal("重新试单结算前其余信号应被拒绝")
+	}
+}
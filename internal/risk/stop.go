@@ -0,0 +1,47 @@
+package risk
+
+import (
+	"time"
+
+	"autotransaction/internal/market"
+)
+
+// exitRetryInterval 止损/止盈平仓信号发出后未平仓时，重新发出前的等待时间
+const exitRetryInterval = time.Minute
+
+// HandleData 实现 market.DataHandler 接口，用最新价格更新持仓市值并检查止损止盈
+func (rm *RiskManager) HandleData(data market.MarketData) {
+	if !data.Close.IsPositive() {
+		return
+	}
+
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	for key, position := range rm.positions {
+		if position.Symbol != data.Symbol {
+			continue
+		}
+		position.CurrentPrice = data.Close
+		rm.positions[key] = position
+		if !rm.checkLiquidation(position) {
+			rm.checkStopLossAndTakeProfit(position)
+		}
+	}
+	rm.checkAllDailyLossLocked()
+}
+
+// exitOnce 为触发止损或止盈的持仓发出平仓信号，平仓完成前不重复发出，调用方需持有锁
+func (rm *RiskManager) exitOnce(position Position, reason string) {
+	if rm.untradeable[position.Symbol] {
+		return
+	}
+
+	key := position.key()
+	if sentAt, ok := rm.pendingExits[key]; ok && time.Since(sentAt) < exitRetryInterval {
+		return
+	}
+	rm.pendingExits[key] = time.Now()
+
+	rm.emitExit(position, reason)
+}
@@ -0,0 +1,106 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+
+	"autotransaction/internal/market"
+	"autotransaction/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// TrendProvider 提供更高时间周期的趋势方向
+type TrendProvider interface {
+	// Trend 返回交易对的趋势方向: "up" 或 "down"
+	Trend(symbol string) (string, error)
+}
+
+// HistoricalTrendProvider 根据更高周期的历史K线判断趋势，最新收盘价高于均线视为上升趋势
+type HistoricalTrendProvider struct {
+	marketData *market.MarketDataService
+	interval   string
+	period     int
+}
+
+// NewHistoricalTrendProvider 创建一个基于历史K线的趋势提供者
+func NewHistoricalTrendProvider(marketData *market.MarketDataService, interval string, period int) *HistoricalTrendProvider {
+	return &HistoricalTrendProvider{
+		marketData: marketData,
+		interval:   interval,
+		period:     period,
+	}
+}
+
+// Trend 实现 TrendProvider 接口
+func (p *HistoricalTrendProvider) Trend(symbol string) (string, error) {
+	if p.period <= 0 {
+		return "", fmt.Errorf("无效的趋势均线周期: %d", p.period)
+	}
+
+	histData, err := p.marketData.GetHistoricalData(symbol, p.interval, p.period)
+	if err != nil {
+		return "", fmt.Errorf("获取 %s 的 %s 历史数据失败: %v", symbol, p.interval, err)
+	}
+	if len(histData) < p.period {
+		return "", fmt.Errorf("%s 的 %s 历史数据不足 %d 条", symbol, p.interval, p.period)
+	}
+
+	// 按时间升序排列，最后一条为最新数据
+	sort.Slice(histData, func(i, j int) bool {
+		return histData[i].Timestamp.Before(histData[j].Timestamp)
+	})
+
+	sum := decimal.Zero
+	for _, data := range histData[len(histData)-p.period:] {
+		sum = sum.Add(data.Close)
+	}
+	average := sum.Div(decimal.NewFromInt(int64(p.period)))
+
+	if histData[len(histData)-1].Close.GreaterThan(average) {
+		return "up", nil
+	}
+	return "down", nil
+}
+
+// SetTrendProvider 设置多周期趋势确认使用的趋势提供者
+func (rm *RiskManager) SetTrendProvider(provider TrendProvider) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.trendProvider = provider
+}
+
+// checkTrend 检查开仓信号是否顺应更高周期趋势，开多需要上升趋势，开空需要下降趋势
+func (rm *RiskManager) checkTrend(signal strategy.Signal) error {
+	if !rm.cfg.Current().Risk.TrendFilter.Enabled {
+		return nil
+	}
+	side := rm.openingSide(signal)
+	if side == "" {
+		return nil
+	}
+
+	rm.mutex.RLock()
+	provider := rm.trendProvider
+	rm.mutex.RUnlock()
+
+	if provider == nil {
+		return fmt.Errorf("未设置趋势提供者")
+	}
+
+	trend, err := provider.Trend(signal.Symbol)
+	if err != nil {
+		return fmt.Errorf("获取 %s 的更高周期趋势失败: %v", signal.Symbol, err)
+	}
+
+	if side == SideLong && trend != "up" {
+		return fmt.Errorf("%s 的 %s 周期趋势为 %s，不允许逆势买入",
+			signal.Symbol, rm.cfg.Current().Risk.TrendFilter.Interval, trend)
+	}
+	if side == SideShort && trend != "down" {
+		return fmt.Errorf("%s 的 %s 周期趋势为 %s，不允许逆势开空",
+			signal.Symbol, rm.cfg.Current().Risk.TrendFilter.Interval, trend)
+	}
+
+	return nil
+}
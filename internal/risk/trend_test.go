@@ -0,0 +1,28 @@
+package risk
+
+import "testing"
+
+// fixedTrend 所有交易对返回同一趋势方向
+type fixedTrend string
+
+func (t fixedTrend) Trend(symbol string) (string, error) {
+	return string(t), nil
+}
+
+func TestTrendFilterRejectsCounterTrendLong(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.Risk.TrendFilter.Enabled = true
+	cfg.Risk.TrendFilter.Interval = "1d"
+	cfg.Risk.TrendFilter.Period = 20
+	rm := NewRiskManager(cfg)
+
+	rm.SetTrendProvider(fixedTrend("down"))
+	if err := rm.PreviewSignal(buySignal("BTC/USDT")); err == nil {
+		t.Fatal("更高周期趋势向下时应拒绝开多")
+	}
+
+	rm.SetTrendProvider(fixedTrend("up"))
+	if err := rm.PreviewSignal(buySignal("BTC/USDT")); err != nil {
+		t.Fatalf("更高周期趋势向上时应允许开多: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package risk
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// MarkUntradeable 将交易对标记为不可交易（如已被交易所下架），
+// 拒绝其后续所有信号，相关持仓不再自动平仓，需要人工处理
+func (rm *RiskManager) MarkUntradeable(symbol string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	rm.untradeable[symbol] = true
+
+	for _, position := range rm.positions {
+		if position.Symbol != symbol {
+			continue
+		}
+		logrus.Errorf("告警: 账户 %s 的 %s 持仓 (数量: %s) 已无法交易，需要人工处理",
+			position.Account, position.Symbol, position.Quantity.String())
+	}
+}
+
+// IsUntradeable 判断交易对是否已被标记为不可交易
+func (rm *RiskManager) IsUntradeable(symbol string) bool {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+	return rm.untradeable[symbol]
+}
+
+// GetUntradeablePositions 获取所有需要人工处理的不可交易持仓，键为 账户-交易对
+func (rm *RiskManager) GetUntradeablePositions() map[string]Position {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	result := make(map[string]Position)
+	for key, position := range rm.positions {
+		if rm.untradeable[position.Symbol] {
+			result[key] = position
+		}
+	}
+	return result
+}
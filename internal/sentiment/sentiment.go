@@ -0,0 +1,293 @@
+package sentiment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/llm"
+	"autotransaction/internal/news"
+	"autotransaction/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultInterval 未配置评分间隔时的评分间隔
+	defaultInterval = 60 * time.Minute
+	// defaultMaxArticles 未配置时每个资产参与评分的新闻数量
+	defaultMaxArticles = 20
+	// defaultWindow 未配置时聚合情绪的时间窗口
+	defaultWindow = 24 * time.Hour
+	// defaultHistorySize 未配置时每个资产保留的评分数量
+	defaultHistorySize = 500
+)
+
+// Point 一次评分中一个资产的情绪分数
+type Point struct {
+	ID         string    `json:"id"`
+	Asset      string    `json:"asset"`
+	Score      float64   `json:"score"` // -1 极度看跌 到 1 极度看涨
+	Confidence float64   `json:"confidence"`
+	Summary    string    `json:"summary"`
+	Articles   int       `json:"articles"` // 评分时涉及该资产的新闻数量
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Aggregate 资产在时间窗口内的聚合情绪
+type Aggregate struct {
+	Asset   string
+	Score   float64 // 按置信度加权的平均分数
+	Samples int     // 窗口内的评分数量
+	Latest  Point   // 最新的一次评分
+}
+
+// Service 定时由LLM对各关注资产的最新新闻评分，按资产保存情绪分数的时间序列
+type Service struct {
+	cfg        config.SentimentConfig
+	llmService *llm.LLMService
+	news       *news.Service
+	history    map[string][]Point // 资产 -> 按时间升序的评分
+	store      store.Store        // 为nil时评分不持久化
+	mutex      sync.RWMutex
+	refreshing sync.Mutex // 保证同一时间只有一次评分
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// NewService 创建情绪评分服务，评分的资产为新闻服务关注的资产
+func NewService(cfg config.SentimentConfig, llmService *llm.LLMService, newsService *news.Service) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		cfg:        cfg,
+		llmService: llmService,
+		news:       newsService,
+		history:    make(map[string][]Point),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// SetStore 设置持久化存储，情绪评分将在重启后保留
+func (s *Service) SetStore(st store.Store) {
+	s.store = st
+}
+
+// Start 加载已保存的评分，随后按配置的间隔定时评分
+// 启动时不立即评分，新闻服务需要先完成第一次抓取
+func (s *Service) Start() error {
+	if err := s.load(); err != nil {
+		return fmt.Errorf("加载已保存的情绪评分失败: %v", err)
+	}
+
+	interval := time.Duration(s.cfg.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	logrus.Infof("新闻情绪评分已启用，每 %v 评分一次", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Refresh(); err != nil {
+					logrus.Warnf("新闻情绪评分失败: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止定时评分
+func (s *Service) Stop() {
+	s.cancel()
+}
+
+// Refresh 立即使用各关注资产的最新新闻评分，返回本次记录的评分
+func (s *Service) Refresh() ([]Point, error) {
+	s.refreshing.Lock()
+	defer s.refreshing.Unlock()
+
+	maxArticles := s.cfg.MaxArticles
+	if maxArticles <= 0 {
+		maxArticles = defaultMaxArticles
+	}
+
+	// 合并各资产的最新新闻，一次请求完成所有资产的评分
+	assets := make([]string, 0)
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	articles := make([]map[string]string, 0)
+	for _, asset := range s.news.Assets() {
+		latest := s.news.Latest(asset, maxArticles)
+		if len(latest) == 0 {
+			continue
+		}
+		assets = append(assets, asset)
+		counts[asset] = len(latest)
+		for _, article := range latest {
+			if seen[article.ID] {
+				continue
+			}
+			seen[article.ID] = true
+			articles = append(articles, article.PromptData())
+		}
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("没有关注资产的新闻")
+	}
+
+	scores, err := s.llmService.ScoreSentiment(articles, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	points := make([]Point, 0, len(scores))
+	for _, score := range scores {
+		points = append(points, Point{
+			ID:         fmt.Sprintf("%s-%d", score.Asset, now.UnixNano()),
+			Asset:      score.Asset,
+			Score:      score.Score,
+			Confidence: score.Confidence,
+			Summary:    score.Summary,
+			Articles:   counts[score.Asset],
+			Timestamp:  now,
+		})
+	}
+	s.persist(points, s.add(points))
+
+	logrus.Infof("新闻情绪评分完成: %d 篇新闻，%d 个资产获得评分", len(articles), len(points))
+	return points, nil
+}
+
+// History 返回资产自 since 起的评分，按时间升序，since 为零值时返回全部
+func (s *Service) History(asset string, since time.Time) []Point {
+	asset = strings.ToUpper(asset)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	points := make([]Point, 0)
+	for _, point := range s.history[asset] {
+		if point.Timestamp.Before(since) {
+			continue
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// Aggregate 返回资产在配置的时间窗口内的聚合情绪，窗口内没有有效评分时 ok 为 false
+func (s *Service) Aggregate(asset string) (Aggregate, bool) {
+	window := time.Duration(s.cfg.WindowHours) * time.Hour
+	if window <= 0 {
+		window = defaultWindow
+	}
+	points := s.History(asset, time.Now().Add(-window))
+
+	var weighted, weights float64
+	for _, point := range points {
+		weighted += point.Score * point.Confidence
+		weights += point.Confidence
+	}
+	if weights == 0 {
+		return Aggregate{}, false
+	}
+	return Aggregate{
+		Asset:   strings.ToUpper(asset),
+		Score:   weighted / weights,
+		Samples: len(points),
+		Latest:  points[len(points)-1],
+	}, true
+}
+
+// Sentiment 返回交易对基础资产的聚合情绪分数，实现 risk.SentimentProvider 接口
+func (s *Service) Sentiment(symbol string) (float64, bool) {
+	aggregate, ok := s.Aggregate(strings.SplitN(symbol, "/", 2)[0])
+	return aggregate.Score, ok
+}
+
+// Assets 返回评分的资产
+func (s *Service) Assets() []string {
+	return s.news.Assets()
+}
+
+// add 记录评分，超出保留数量时丢弃最早的评分，返回被丢弃的评分ID
+func (s *Service) add(points []Point) []string {
+	historySize := s.cfg.HistorySize
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removed := make([]string, 0)
+	for _, point := range points {
+		history := append(s.history[point.Asset], point)
+		if len(history) > historySize {
+			for _, old := range history[:len(history)-historySize] {
+				removed = append(removed, old.ID)
+			}
+			history = append([]Point(nil), history[len(history)-historySize:]...)
+		}
+		s.history[point.Asset] = history
+	}
+	return removed
+}
+
+// persist 保存新的评分并删除被丢弃的评分
+func (s *Service) persist(points []Point, removed []string) {
+	if s.store == nil {
+		return
+	}
+	for _, point := range points {
+		if err := s.store.Put(store.CollectionSentiment, point.ID, point); err != nil {
+			logrus.Warnf("保存情绪评分 %s 失败: %v", point.ID, err)
+		}
+	}
+	for _, id := range removed {
+		if err := s.store.Delete(store.CollectionSentiment, id); err != nil {
+			logrus.Warnf("删除情绪评分 %s 失败: %v", id, err)
+		}
+	}
+}
+
+// load 从存储中加载评分
+func (s *Service) load() error {
+	if s.store == nil {
+		return nil
+	}
+
+	points := make([]Point, 0)
+	err := s.store.Load(store.CollectionSentiment, func(id string, decode func(v interface{}) error) error {
+		var point Point
+		if err := decode(&point); err != nil {
+			return fmt.Errorf("解析情绪评分 %s 失败: %v", id, err)
+		}
+		points = append(points, point)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	s.persist(nil, s.add(points))
+
+	logrus.Infof("已加载 %d 条情绪评分", len(points))
+	return nil
+}
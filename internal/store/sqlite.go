@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // 注册 sqlite3 驱动
+)
+
+// SQLiteStore 基于 SQLite 的存储，所有集合存放在同一张表中，以 (集合, ID) 为主键
+// 每次 Put/Delete 是一条独立的事务，进程退出时不会留下不完整的记录
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// sqliteSchema 记录表，value 为记录的 JSON
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS records (
+	collection TEXT NOT NULL,
+	id         TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (collection, id)
+)`
+
+// NewSQLiteStore 打开或创建 SQLite 数据库文件
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %v", err)
+	}
+	// SQLite 同一时刻只允许一个写入者，使用单个连接避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建数据表失败: %v", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Put 写入或更新一条记录
+func (s *SQLiteStore) Put(collection, id string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化记录失败: %v", err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO records (collection, id, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (collection, id) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		collection, id, string(content), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("写入记录失败: %v", err)
+	}
+	return nil
+}
+
+// Delete 删除一条记录
+func (s *SQLiteStore) Delete(collection, id string) error {
+	if _, err := s.db.Exec(`DELETE FROM records WHERE collection = ? AND id = ?`, collection, id); err != nil {
+		return fmt.Errorf("删除记录失败: %v", err)
+	}
+	return nil
+}
+
+// Load 遍历集合中的所有记录，先读出全部记录再回调，回调中可以继续写入
+func (s *SQLiteStore) Load(collection string, fn func(id string, decode func(v interface{}) error) error) error {
+	rows, err := s.db.Query(`SELECT id, value FROM records WHERE collection = ?`, collection)
+	if err != nil {
+		return fmt.Errorf("读取集合 %s 失败: %v", collection, err)
+	}
+	snapshot := make(map[string]json.RawMessage)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return fmt.Errorf("读取集合 %s 失败: %v", collection, err)
+		}
+		snapshot[id] = json.RawMessage(content)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("读取集合 %s 失败: %v", collection, err)
+	}
+
+	for id, content := range snapshot {
+		content := content
+		decode := func(v interface{}) error {
+			return json.Unmarshal(content, v)
+		}
+		if err := fn(id, decode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping 检查数据库是否可用
+func (s *SQLiteStore) Ping() error {
+	if err := s.db.Ping(); err != nil {
+		return fmt.Errorf("数据库不可用: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 数据集合名称
+const (
+	CollectionOrders              = "orders"
+	CollectionFills               = "fills"
+	CollectionPositions           = "positions"
+	CollectionBlockchainOrders    = "blockchain_orders"
+	CollectionBlockchainPositions = "blockchain_positions"
+	CollectionNews                = "news"
+	CollectionSentiment           = "sentiment"
+	CollectionLLMUsage            = "llm_usage"
+	CollectionBacktests           = "backtests"
+	CollectionOptimizations       = "optimizations"
+)
+
+// Store 订单、成交和持仓的持久化存储接口
+// 数据按集合存放，每条记录以ID为键，值为任意可JSON序列化的结构
+type Store interface {
+	Put(collection, id string, value interface{}) error
+	Delete(collection, id string) error
+	// Load 遍历集合中的所有记录，decode 将记录反序列化到传入的结构
+	Load(collection string, fn func(id string, decode func(v interface{}) error) error) error
+	// Ping 检查存储是否可用，用于健康检查
+	Ping() error
+	Close() error
+}
+
+// compactMinEntries 追加日志至少达到该条数才会压缩，避免小集合频繁重写快照
+const compactMinEntries = 1000
+
+// FileStore 基于本地文件的存储，每个集合一个快照文件和一个追加日志
+// Put/Delete 只向日志追加一行，日志条数超过集合记录数（且不少于 compactMinEntries）时
+// 将集合整体写入快照（先写临时文件再重命名）并清空日志
+type FileStore struct {
+	dir         string
+	collections map[string]map[string]json.RawMessage
+	mutex       sync.Mutex
+
+	logs       map[string]*os.File // 各集合已打开的追加日志
+	logEntries map[string]int      // 各集合日志中的记录条数
+}
+
+// logEntry 追加日志中的一条操作
+type logEntry struct {
+	Op    string          `json:"op"` // put 或 delete
+	ID    string          `json:"id"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// NewFileStore 创建文件存储并加载已有数据
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %v", err)
+	}
+
+	return &FileStore{
+		dir:         dir,
+		collections: make(map[string]map[string]json.RawMessage),
+		logs:        make(map[string]*os.File),
+		logEntries:  make(map[string]int),
+	}, nil
+}
+
+// Put 写入或更新一条记录
+func (s *FileStore) Put(collection, id string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化记录失败: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	records, err := s.collectionLocked(collection)
+	if err != nil {
+		return err
+	}
+	if err := s.appendLocked(collection, logEntry{Op: "put", ID: id, Value: content}); err != nil {
+		return err
+	}
+	records[id] = content
+	return s.maybeCompactLocked(collection)
+}
+
+// Delete 删除一条记录
+func (s *FileStore) Delete(collection, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	records, err := s.collectionLocked(collection)
+	if err != nil {
+		return err
+	}
+	if _, ok := records[id]; !ok {
+		return nil
+	}
+	if err := s.appendLocked(collection, logEntry{Op: "delete", ID: id}); err != nil {
+		return err
+	}
+	delete(records, id)
+	return s.maybeCompactLocked(collection)
+}
+
+// Load 遍历集合中的所有记录
+func (s *FileStore) Load(collection string, fn func(id string, decode func(v interface{}) error) error) error {
+	s.mutex.Lock()
+	records, err := s.collectionLocked(collection)
+	snapshot := make(map[string]json.RawMessage, len(records))
+	for id, content := range records {
+		snapshot[id] = content
+	}
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for id, content := range snapshot {
+		content := content
+		decode := func(v interface{}) error {
+			return json.Unmarshal(content, v)
+		}
+		if err := fn(id, decode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping 检查存储目录是否可写
+func (s *FileStore) Ping() error {
+	file, err := ioutil.TempFile(s.dir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("存储目录不可写: %v", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Close 将有追加日志的集合压缩为快照并关闭日志文件
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var firstErr error
+	for collection := range s.logs {
+		if err := s.compactLocked(collection); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for collection, file := range s.logs {
+		file.Close()
+		delete(s.logs, collection)
+	}
+	return firstErr
+}
+
+// collectionLocked 返回集合数据，首次访问时加载快照并重放追加日志，调用方需持有 s.mutex
+func (s *FileStore) collectionLocked(collection string) (map[string]json.RawMessage, error) {
+	if records, ok := s.collections[collection]; ok {
+		return records, nil
+	}
+
+	records := make(map[string]json.RawMessage)
+	content, err := ioutil.ReadFile(s.path(collection))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取集合 %s 失败: %v", collection, err)
+	}
+	if err == nil && len(content) > 0 {
+		if err := json.Unmarshal(content, &records); err != nil {
+			return nil, fmt.Errorf("解析集合 %s 失败: %v", collection, err)
+		}
+	}
+
+	entries, err := s.replayLog(collection, records)
+	if err != nil {
+		return nil, err
+	}
+
+	s.collections[collection] = records
+	s.logEntries[collection] = entries
+	return records, nil
+}
+
+// replayLog 将追加日志中的操作依次应用到快照数据，返回日志条数
+// 进程在写入过程中退出可能留下不完整的最后一行，忽略并截掉该行
+func (s *FileStore) replayLog(collection string, records map[string]json.RawMessage) (int, error) {
+	content, err := ioutil.ReadFile(s.logPath(collection))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取集合 %s 的日志失败: %v", collection, err)
+	}
+
+	entries := 0
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				// 截掉不完整的记录，避免之后追加的内容与其拼接在同一行
+				logrus.Warnf("忽略集合 %s 日志中不完整的最后一条记录", collection)
+				if err := os.Truncate(s.logPath(collection), int64(len(content)-len(line))); err != nil {
+					return 0, fmt.Errorf("截断集合 %s 的日志失败: %v", collection, err)
+				}
+				break
+			}
+			return 0, fmt.Errorf("解析集合 %s 的日志失败: %v", collection, err)
+		}
+		switch entry.Op {
+		case "put":
+			records[entry.ID] = entry.Value
+		case "delete":
+			delete(records, entry.ID)
+		}
+		entries++
+	}
+	return entries, nil
+}
+
+// appendLocked 向集合的追加日志写入一条操作，调用方需持有 s.mutex
+func (s *FileStore) appendLocked(collection string, entry logEntry) error {
+	file, ok := s.logs[collection]
+	if !ok {
+		var err error
+		file, err = os.OpenFile(s.logPath(collection), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("打开集合 %s 的日志失败: %v", collection, err)
+		}
+		s.logs[collection] = file
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化集合 %s 的日志失败: %v", collection, err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入集合 %s 的日志失败: %v", collection, err)
+	}
+	s.logEntries[collection]++
+	return nil
+}
+
+// maybeCompactLocked 日志条数超过集合记录数且不少于 compactMinEntries 时压缩，调用方需持有 s.mutex
+func (s *FileStore) maybeCompactLocked(collection string) error {
+	entries := s.logEntries[collection]
+	if entries < compactMinEntries || entries <= len(s.collections[collection]) {
+		return nil
+	}
+	return s.compactLocked(collection)
+}
+
+// compactLocked 将集合写入快照文件并清空追加日志，调用方需持有 s.mutex
+// 快照写入后、日志清空前退出时，下次加载会在新快照上重放日志，结果不变
+func (s *FileStore) compactLocked(collection string) error {
+	if s.logEntries[collection] == 0 {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s.collections[collection], "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化集合 %s 失败: %v", collection, err)
+	}
+
+	path := s.path(collection)
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("写入集合 %s 失败: %v", collection, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入集合 %s 失败: %v", collection, err)
+	}
+
+	if file, ok := s.logs[collection]; ok {
+		file.Close()
+		delete(s.logs, collection)
+	}
+	if err := os.Remove(s.logPath(collection)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清空集合 %s 的日志失败: %v", collection, err)
+	}
+	s.logEntries[collection] = 0
+	return nil
+}
+
+// path 返回集合快照对应的文件路径
+func (s *FileStore) path(collection string) string {
+	return filepath.Join(s.dir, collection+".json")
+}
+
+// logPath 返回集合追加日志对应的文件路径
+func (s *FileStore) logPath(collection string) string {
+	return filepath.Join(s.dir, collection+".log")
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadAll 读取集合中的全部记录
+func loadAll(t *testing.T, s Store, collection string) map[string]int {
+	t.Helper()
+	result := make(map[string]int)
+	err := s.Load(collection, func(id string, decode func(v interface{}) error) error {
+		var value int
+		if err := decode(&value); err != nil {
+			return err
+		}
+		result[id] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestFileStoreReplaysLogAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := s.Put(CollectionOrders, id, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put(CollectionOrders, "a", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(CollectionOrders, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入只追加日志，不重写快照
+	if _, err := os.Stat(filepath.Join(dir, CollectionOrders+".json")); !os.IsNotExist(err) {
+		t.Fatalf("未达到压缩条件时不应写入快照: %v", err)
+	}
+
+	// 模拟进程在写入最后一条日志时退出
+	logFile, err := os.OpenFile(filepath.Join(dir, CollectionOrders+".log"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile.WriteString(`{"op":"put","id":"d","val`)
+	logFile.Close()
+
+	restarted, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := loadAll(t, restarted, CollectionOrders)
+	if len(records) != 2 || records["a"] != 2 || records["c"] != 1 {
+		t.Fatalf("重启后应按日志恢复记录: %v", records)
+	}
+	// 截掉不完整的记录后继续追加
+	if err := restarted.Put(CollectionOrders, "e", 3); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := loadAll(t, again, CollectionOrders); len(records) != 3 || records["e"] != 3 {
+		t.Fatalf("截断不完整记录后追加的日志应可正常读取: %v", records)
+	}
+}
+
+func TestFileStoreCompactsLog(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 反复更新同一条记录，日志条数超过记录数后压缩
+	for i := 0; i < compactMinEntries; i++ {
+		if err := s.Put(CollectionPositions, "BTC/USDT", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, CollectionPositions+".log")); !os.IsNotExist(err) {
+		t.Fatalf("压缩后应清空日志: %v", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, CollectionPositions+".json"))
+	if err != nil {
+		t.Fatalf("压缩后应写入快照: %v", err)
+	}
+	if len(content) > 100 {
+		t.Fatalf("快照只应包含最新记录: %s", content)
+	}
+
+	if err := s.Put(CollectionPositions, "ETH/USDT", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	restarted, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := loadAll(t, restarted, CollectionPositions)
+	if len(records) != 2 || records["BTC/USDT"] != compactMinEntries-1 || records["ETH/USDT"] != 1 {
+		t.Fatalf("重启后应从快照恢复最新记录: %v", records)
+	}
+}
+
+func TestSQLiteStorePersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := s.Put(CollectionOrders, id, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put(CollectionOrders, "a", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(CollectionOrders, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(CollectionFills, "a", 9); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	records := loadAll(t, restarted, CollectionOrders)
+	if len(records) != 2 || records["a"] != 2 || records["c"] != 1 {
+		t.Fatalf("重启后应恢复集合中的最新记录: %v", records)
+	}
+	if fills := loadAll(t, restarted, CollectionFills); len(fills) != 1 || fills["a"] != 9 {
+		t.Fatalf("不同集合的记录应分开存放: %v", fills)
+	}
+
+	// 遍历时可以继续写入
+	err = restarted.Load(CollectionOrders, func(id string, decode func(v interface{}) error) error {
+		return restarted.Put(CollectionPositions, id, 1)
+	})
+	if err != nil {
+		t.Fatalf("遍历集合时写入失败: %v", err)
+	}
+	if positions := loadAll(t, restarted, CollectionPositions); len(positions) != 2 {
+		t.Fatalf("遍历时写入的记录应可读取: %v", positions)
+	}
+}
@@ -0,0 +1,200 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register("arbitrage", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newArbitrage(name, deps.Config, deps.DEXPrices, deps.Sizer, params)
+	})
+}
+
+// DEXPriceProvider 提供交易对最新的链上价格，由区块链行情服务实现
+type DEXPriceProvider interface {
+	LatestDEXPrice(symbol string) (price decimal.Decimal, at time.Time, ok bool)
+}
+
+// basisPoints 一个基点对应的比例
+var basisPoints = decimal.NewFromInt(10000)
+
+// Arbitrage 跨场所套利策略：比较同一交易对在交易所和链上的价格，
+// 价差超过双边手续费、滑点和Gas成本时同时发出两条腿的信号，在便宜的场所买入、在贵的场所卖出
+// 卖出腿需要在对应场所预先持有库存，策略本身不做资金划转
+type Arbitrage struct {
+	name      string
+	cfg       *config.Config
+	dexPrices DEXPriceProvider
+	sizer     OrderSizer
+	pairs     map[string]bool
+
+	feeExchange decimal.Decimal // 交易所手续费，基点
+	feeDEX      decimal.Decimal // 链上交易手续费，基点
+	slippage    decimal.Decimal // 预估滑点，基点，两条腿各计一次
+	gasCost     decimal.Decimal // 每次链上交易的Gas成本，以计价货币计
+	minProfit   decimal.Decimal // 扣除成本后的最小利润，基点
+	quantity    decimal.Decimal // 固定下单数量，为零时按账户资金计算
+	maxAge      time.Duration   // 链上价格的最大时效
+	cooldown    time.Duration   // 同一交易对两次套利的最小间隔
+
+	lastTrade map[string]time.Time
+	mutex     sync.Mutex
+}
+
+// newArbitrage 创建跨场所套利策略
+func newArbitrage(name string, cfg *config.Config, dexPrices DEXPriceProvider, sizer OrderSizer, params map[string]interface{}) (*Arbitrage, error) {
+	if dexPrices == nil {
+		return nil, fmt.Errorf("套利策略需要启用区块链行情")
+	}
+
+	// 未配置 pairs 时使用所有启用且配置了区块链的交易对
+	pairs := make(map[string]bool)
+	if list, ok := params["pairs"].([]interface{}); ok {
+		for _, item := range list {
+			pairs[fmt.Sprintf("%v", item)] = true
+		}
+	} else {
+		for _, pair := range cfg.Current().Trading.Pairs {
+			if pair.Enabled && pair.Blockchain != "" {
+				pairs[pair.Symbol] = true
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("套利策略没有同时在交易所和链上交易的交易对")
+	}
+
+	a := &Arbitrage{
+		name:        name,
+		cfg:         cfg,
+		dexPrices:   dexPrices,
+		sizer:       sizer,
+		pairs:       pairs,
+		feeExchange: paramDecimal(params, "fee_bps_exchange", decimal.NewFromInt(10)),
+		feeDEX:      paramDecimal(params, "fee_bps_dex", decimal.NewFromInt(30)),
+		slippage:    paramDecimal(params, "slippage_bps", decimal.NewFromInt(50)),
+		gasCost:     paramDecimal(params, "gas_cost", decimal.Zero),
+		minProfit:   paramDecimal(params, "min_profit_bps", decimal.NewFromInt(10)),
+		quantity:    paramDecimal(params, "quantity", decimal.Zero),
+		maxAge:      time.Duration(paramInt(params, "max_price_age_seconds", 120)) * time.Second,
+		cooldown:    time.Duration(paramInt(params, "cooldown_seconds", 300)) * time.Second,
+		lastTrade:   make(map[string]time.Time),
+	}
+	if a.quantity.IsNegative() {
+		return nil, fmt.Errorf("下单数量 quantity 不能为负数: %s", a.quantity.String())
+	}
+	return a, nil
+}
+
+// Name 返回策略名称
+func (a *Arbitrage) Name() string {
+	return a.name
+}
+
+// Init 初始化策略
+func (a *Arbitrage) Init() error {
+	logrus.Infof("初始化套利策略 %s (交易所手续费: %s基点, 链上手续费: %s基点, 滑点: %s基点, 最小利润: %s基点, 交易对数量: %d)",
+		a.name, a.feeExchange.String(), a.feeDEX.String(), a.slippage.String(), a.minProfit.String(), len(a.pairs))
+	return nil
+}
+
+// Process 收到交易所行情时与最新链上价格比较，价差足够时生成买卖两条腿的信号
+func (a *Arbitrage) Process(data market.MarketData) ([]Signal, error) {
+	if !a.pairs[data.Symbol] || !data.Close.IsPositive() {
+		return []Signal{}, nil
+	}
+
+	dexPrice, at, ok := a.dexPrices.LatestDEXPrice(data.Symbol)
+	if !ok || !dexPrice.IsPositive() || time.Since(at) > a.maxAge {
+		return []Signal{}, nil
+	}
+
+	buyVenue, buyPrice, sellVenue, sellPrice := VenueExchange, data.Close, VenueBlockchain, dexPrice
+	if dexPrice.LessThan(data.Close) {
+		buyVenue, buyPrice, sellVenue, sellPrice = VenueBlockchain, dexPrice, VenueExchange, data.Close
+	}
+
+	quantity := a.quantity
+	if quantity.IsZero() {
+		quantity = calculateQuantity(a.sizer, a.cfg, data.Symbol, "buy", buyPrice)
+	}
+	if !quantity.IsPositive() {
+		return []Signal{}, nil
+	}
+
+	// 价差和成本都换算为相对买入价的基点
+	spread := sellPrice.Sub(buyPrice).Div(buyPrice).Mul(basisPoints)
+	cost := a.feeExchange.Add(a.feeDEX).Add(a.slippage.Mul(decimal.NewFromInt(2)))
+	if a.gasCost.IsPositive() {
+		cost = cost.Add(a.gasCost.Div(buyPrice.Mul(quantity)).Mul(basisPoints))
+	}
+	if spread.LessThan(cost.Add(a.minProfit)) {
+		return []Signal{}, nil
+	}
+
+	a.mutex.Lock()
+	now := time.Now()
+	if last, ok := a.lastTrade[data.Symbol]; ok && now.Sub(last) < a.cooldown {
+		a.mutex.Unlock()
+		return []Signal{}, nil
+	}
+	a.lastTrade[data.Symbol] = now
+	a.mutex.Unlock()
+
+	// 信号强度随净价差相对成本的比例增加，净价差达到成本时为1
+	confidence := 1.0
+	if cost.IsPositive() {
+		ratio, _ := spread.Sub(cost).Div(cost).Float64()
+		confidence = 0.5 + ratio/2
+		if confidence > 1 {
+			confidence = 1
+		}
+	}
+
+	logrus.Infof("套利策略 %s 发现 %s 价差 %s基点 (成本 %s基点): 在%s以 %s 买入，在%s以 %s 卖出，数量 %s",
+		a.name, data.Symbol, spread.StringFixed(1), cost.StringFixed(1),
+		buyVenue, buyPrice.String(), sellVenue, sellPrice.String(), quantity.String())
+
+	timestamp := data.Timestamp.Unix()
+	return []Signal{
+		{
+			Symbol:     data.Symbol,
+			Direction:  "buy",
+			Price:      buyPrice,
+			Quantity:   quantity,
+			Timestamp:  timestamp,
+			Confidence: confidence,
+			Venue:      buyVenue,
+		},
+		{
+			Symbol:     data.Symbol,
+			Direction:  "sell",
+			Price:      sellPrice,
+			Quantity:   quantity,
+			Timestamp:  timestamp,
+			Confidence: confidence,
+			Venue:      sellVenue,
+		},
+	}, nil
+}
+
+// paramDecimal 读取小数参数，未配置或格式错误时返回默认值
+func paramDecimal(params map[string]interface{}, key string, defaultValue decimal.Decimal) decimal.Decimal {
+	value, ok := params[key]
+	if !ok || value == nil {
+		return defaultValue
+	}
+	result, err := decimal.NewFromString(fmt.Sprintf("%v", value))
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
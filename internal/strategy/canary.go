@@ -0,0 +1,65 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// canarySuffix 金丝雀策略实例名称的后缀
+const canarySuffix = ":canary"
+
+// startCanary 使用新参数创建与当前策略并行运行的金丝雀实例，按配置比例缩小下单数量，
+// 其成交按实例名称单独归因，便于在全面切换前对比新旧参数的表现
+func (sm *StrategyManager) startCanary() error {
+	canaryCfg := sm.cfg.Strategy.Canary
+	if !canaryCfg.Enabled {
+		return nil
+	}
+
+	fraction := canaryCfg.SizeFraction
+	if fraction <= 0 || fraction > 1 {
+		return fmt.Errorf("金丝雀仓位比例必须在(0, 1]之间: %v", fraction)
+	}
+
+	// 金丝雀参数覆盖当前参数，未指定的参数沿用当前配置
+	params := make(map[string]interface{})
+	for k, v := range sm.cfg.Current().Strategy.Params {
+		params[k] = v
+	}
+	for k, v := range canaryCfg.Params {
+		params[k] = v
+	}
+
+	name := sm.cfg.Strategy.Name + canarySuffix
+	canary, err := sm.newStrategyInstance(sm.cfg.Strategy.Name, name, params)
+	if err != nil {
+		return err
+	}
+
+	if err := sm.addStrategy(canary, sm.cfg.Strategy.Name, params); err != nil {
+		return err
+	}
+	sm.setSizeFraction(name, fraction)
+
+	logrus.Infof("金丝雀策略 %s 已启动，仓位比例: %v", name, fraction)
+	return nil
+}
+
+// setSizeFraction 设置策略实例的下单数量比例
+func (sm *StrategyManager) setSizeFraction(name string, fraction float64) {
+	sm.strategiesMu.Lock()
+	defer sm.strategiesMu.Unlock()
+	sm.sizeFractions[name] = decimal.NewFromFloat(fraction)
+}
+
+// applySizeFraction 按策略实例的仓位比例缩放信号数量，调用方需持有 strategiesMu
+func (sm *StrategyManager) applySizeFraction(signal Signal) Signal {
+	fraction, ok := sm.sizeFractions[signal.StrategyName]
+	if !ok {
+		return signal
+	}
+	signal.Quantity = signal.Quantity.Mul(fraction).Round(8)
+	return signal
+}
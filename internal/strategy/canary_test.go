@@ -0,0 +1,61 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// fixedTestKind 测试使用的策略类型，每次处理行情都买入 params["quantity"]，信号强度为 params["confidence"]
+const fixedTestKind = "fixed_test"
+
+func init() {
+	Register(fixedTestKind, func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		confidence, _ := strconv.ParseFloat(fmt.Sprintf("%v", params["confidence"]), 64)
+		return fixedSignalStrategy{name: name, direction: "buy", quantity: 4, confidence: confidence}, nil
+	})
+}
+
+func TestCanaryTradesAtReducedSize(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Strategy.Name = fixedTestKind
+	cfg.Strategy.Params = map[string]interface{}{"confidence": 0.5}
+	cfg.Strategy.Canary = config.CanaryConfig{
+		Enabled:      true,
+		SizeFraction: 0.25,
+		Params:       map[string]interface{}{"confidence": 0.8},
+	}
+	sm := NewStrategyManager(cfg, nil)
+	handler := &recordingHandler{}
+	sm.RegisterSignalHandler(handler)
+	if err := sm.CreateStrategy(fixedTestKind, fixedTestKind, cfg.Strategy.Params); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.startCanary(); err != nil {
+		t.Fatal(err)
+	}
+
+	sm.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), Timestamp: time.Now()})
+
+	byStrategy := make(map[string]Signal)
+	for _, signal := range handler.signals {
+		byStrategy[signal.StrategyName] = signal
+	}
+	current, ok := byStrategy[fixedTestKind]
+	if !ok || !current.Quantity.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("当前实例应按完整数量下单: %+v", handler.signals)
+	}
+	canary, ok := byStrategy[fixedTestKind+canarySuffix]
+	if !ok || !canary.Quantity.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("金丝雀实例应按 0.25 的比例下单: %+v", handler.signals)
+	}
+	if canary.Confidence != 0.8 || current.Confidence != 0.5 {
+		t.Fatalf("金丝雀实例应使用覆盖后的参数: 当前 %v，金丝雀 %v", current.Confidence, canary.Confidence)
+	}
+}
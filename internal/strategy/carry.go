@@ -0,0 +1,250 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register("funding_carry", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		if deps.MarketData == nil {
+			return nil, fmt.Errorf("资金费率套利策略需要市场数据服务提供资金费率")
+		}
+		return newFundingCarry(name, deps.Config, deps.MarketData, deps.Sizer, params)
+	})
+}
+
+// FundingRateProvider 提供永续合约交易对最近的资金费率，由市场数据服务实现
+type FundingRateProvider interface {
+	LatestFundingRate(symbol string) (market.FundingRate, bool)
+}
+
+// carryLeg 一组期现套利：卖出永续合约，同时买入现货对冲价格风险
+type carryLeg struct {
+	perpetual string
+	spot      string // 为空时只开永续合约空仓，不对冲现货
+}
+
+// carryPosition 已建立的期现套利仓位
+type carryPosition struct {
+	quantity decimal.Decimal
+	openedAt time.Time
+}
+
+// FundingCarry 资金费率套利策略：永续合约的年化资金费率超过开仓阈值时卖出永续合约、买入等量现货，
+// 持仓期间作为空头收取资金费；年化费率回落到平仓阈值以下时买回永续合约、卖出现货
+// 套利仓位只记录在策略内存中，重启后需人工检查已有仓位
+type FundingCarry struct {
+	name    string
+	cfg     *config.Config
+	funding FundingRateProvider
+	sizer   OrderSizer
+	legs    map[string]carryLeg // 永续合约交易对 -> 套利组
+
+	entryThreshold decimal.Decimal // 开仓的年化资金费率
+	exitThreshold  decimal.Decimal // 平仓的年化资金费率
+	periodsPerYear decimal.Decimal // 每年的资金费结算次数
+	quantity       decimal.Decimal // 固定下单数量，为零时按账户资金计算
+	maxAge         time.Duration   // 资金费率和现货价格的最大时效
+
+	spotPrices map[string]market.MarketData // 现货交易对最新行情
+	positions  map[string]carryPosition     // 永续合约交易对 -> 已建立的套利仓位
+	mutex      sync.Mutex
+}
+
+// newFundingCarry 创建资金费率套利策略
+func newFundingCarry(name string, cfg *config.Config, funding FundingRateProvider, sizer OrderSizer, params map[string]interface{}) (*FundingCarry, error) {
+	perpetuals := make(map[string]bool)
+	for _, pair := range cfg.Current().Trading.Pairs {
+		if pair.IsPerpetual() {
+			perpetuals[pair.Symbol] = true
+		}
+	}
+
+	// 未配置 legs 时对所有启用的永续合约交易对只做空收取资金费
+	legs := make(map[string]carryLeg)
+	if list, ok := params["legs"].([]interface{}); ok {
+		for i, item := range list {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("legs[%d] 格式错误，需要 {perpetual, spot}", i)
+			}
+			leg := carryLeg{perpetual: fmt.Sprintf("%v", fields["perpetual"])}
+			if spot, ok := fields["spot"]; ok && spot != nil {
+				leg.spot = fmt.Sprintf("%v", spot)
+			}
+			if !perpetuals[leg.perpetual] {
+				return nil, fmt.Errorf("legs[%d] 的 %s 不是永续合约交易对", i, leg.perpetual)
+			}
+			if leg.spot != "" && perpetuals[leg.spot] {
+				return nil, fmt.Errorf("legs[%d] 的现货交易对 %s 不能是永续合约交易对", i, leg.spot)
+			}
+			legs[leg.perpetual] = leg
+		}
+	} else {
+		for _, pair := range cfg.Current().Trading.Pairs {
+			if pair.Enabled && pair.IsPerpetual() {
+				legs[pair.Symbol] = carryLeg{perpetual: pair.Symbol}
+			}
+		}
+	}
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("资金费率套利策略没有可交易的永续合约交易对")
+	}
+
+	intervalHours := paramInt(params, "funding_interval_hours", 8)
+	if intervalHours <= 0 || intervalHours > 24 {
+		return nil, fmt.Errorf("资金费结算间隔 funding_interval_hours 需在1到24小时之间: %d", intervalHours)
+	}
+
+	c := &FundingCarry{
+		name:           name,
+		cfg:            cfg,
+		funding:        funding,
+		sizer:          sizer,
+		legs:           legs,
+		entryThreshold: paramDecimal(params, "entry_annualized", decimal.NewFromFloat(0.15)),
+		exitThreshold:  paramDecimal(params, "exit_annualized", decimal.NewFromFloat(0.05)),
+		periodsPerYear: decimal.NewFromInt(int64(24 / float64(intervalHours) * 365)),
+		quantity:       paramDecimal(params, "quantity", decimal.Zero),
+		maxAge:         time.Duration(paramInt(params, "max_rate_age_seconds", 600)) * time.Second,
+		spotPrices:     make(map[string]market.MarketData),
+		positions:      make(map[string]carryPosition),
+	}
+	if !c.entryThreshold.IsPositive() {
+		return nil, fmt.Errorf("开仓年化费率 entry_annualized 必须大于0: %s", c.entryThreshold.String())
+	}
+	if c.exitThreshold.GreaterThan(c.entryThreshold) {
+		return nil, fmt.Errorf("平仓年化费率 exit_annualized (%s) 不能大于开仓年化费率 entry_annualized (%s)",
+			c.exitThreshold.String(), c.entryThreshold.String())
+	}
+	if c.quantity.IsNegative() {
+		return nil, fmt.Errorf("下单数量 quantity 不能为负数: %s", c.quantity.String())
+	}
+	return c, nil
+}
+
+// Name 返回策略名称
+func (c *FundingCarry) Name() string {
+	return c.name
+}
+
+// Init 初始化策略
+func (c *FundingCarry) Init() error {
+	logrus.Infof("初始化资金费率套利策略 %s (开仓年化费率: %s, 平仓年化费率: %s, 套利组数量: %d)",
+		c.name, c.entryThreshold.String(), c.exitThreshold.String(), len(c.legs))
+	return nil
+}
+
+// annualize 将每个结算周期的资金费率换算为年化费率
+func (c *FundingCarry) annualize(rate decimal.Decimal) decimal.Decimal {
+	return rate.Mul(c.periodsPerYear)
+}
+
+// Process 记录现货行情；收到永续合约行情时按最新资金费率决定开仓或平仓
+func (c *FundingCarry) Process(data market.MarketData) ([]Signal, error) {
+	if !data.Close.IsPositive() {
+		return []Signal{}, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	leg, ok := c.legs[data.Symbol]
+	if !ok {
+		c.spotPrices[data.Symbol] = data
+		return []Signal{}, nil
+	}
+
+	rate, ok := c.funding.LatestFundingRate(data.Symbol)
+	if !ok || time.Since(rate.Timestamp) > c.maxAge {
+		return []Signal{}, nil
+	}
+	annualized := c.annualize(rate.Rate)
+
+	// 对冲腿需要有效的现货价格，否则不开仓
+	var spot market.MarketData
+	if leg.spot != "" {
+		spot, ok = c.spotPrices[leg.spot]
+		if !ok || time.Since(spot.Timestamp) > c.maxAge {
+			if _, open := c.positions[data.Symbol]; !open {
+				return []Signal{}, nil
+			}
+		}
+	}
+
+	position, open := c.positions[data.Symbol]
+	switch {
+	case !open && annualized.GreaterThanOrEqual(c.entryThreshold):
+		quantity := c.quantity
+		if quantity.IsZero() {
+			quantity = calculateQuantity(c.sizer, c.cfg, leg.perpetual, "sell", data.Close)
+		}
+		if !quantity.IsPositive() {
+			return []Signal{}, nil
+		}
+		c.positions[data.Symbol] = carryPosition{quantity: quantity, openedAt: time.Now()}
+
+		// 信号强度随年化费率超出开仓阈值的比例增加，达到两倍阈值时为1
+		ratio, _ := annualized.Sub(c.entryThreshold).Div(c.entryThreshold).Float64()
+		confidence := 0.5 + ratio/2
+		if confidence > 1 {
+			confidence = 1
+		}
+
+		logrus.Infof("资金费率套利策略 %s: %s 年化资金费率 %s%% 超过开仓阈值，卖出永续合约并买入现货 %s，数量 %s",
+			c.name, leg.perpetual, annualized.Mul(decimal.NewFromInt(100)).StringFixed(2), leg.spot, quantity.String())
+		return c.legSignals(leg, "sell", data, spot, quantity, confidence), nil
+
+	case open && annualized.LessThan(c.exitThreshold):
+		delete(c.positions, data.Symbol)
+		logrus.Infof("资金费率套利策略 %s: %s 年化资金费率 %s%% 低于平仓阈值，买回永续合约并卖出现货 %s，数量 %s (持仓 %s)",
+			c.name, leg.perpetual, annualized.Mul(decimal.NewFromInt(100)).StringFixed(2), leg.spot,
+			position.quantity.String(), time.Since(position.openedAt).Round(time.Minute))
+		return c.legSignals(leg, "buy", data, spot, position.quantity, 1), nil
+	}
+	return []Signal{}, nil
+}
+
+// legSignals 生成永续合约腿和现货对冲腿的信号，现货腿方向与永续合约相反；
+// 现货价格不可用时以永续合约价格作为现货腿的参考价格
+func (c *FundingCarry) legSignals(leg carryLeg, perpetualDirection string, perpetual, spot market.MarketData, quantity decimal.Decimal, confidence float64) []Signal {
+	timestamp := perpetual.Timestamp.Unix()
+	signals := []Signal{{
+		Symbol:     leg.perpetual,
+		Direction:  perpetualDirection,
+		Price:      perpetual.Close,
+		Quantity:   quantity,
+		Timestamp:  timestamp,
+		Confidence: confidence,
+		Venue:      VenueExchange,
+	}}
+	if leg.spot == "" {
+		return signals
+	}
+
+	spotDirection := "buy"
+	if perpetualDirection == "buy" {
+		spotDirection = "sell"
+	}
+	spotPrice := spot.Close
+	if !spotPrice.IsPositive() {
+		spotPrice = perpetual.Close
+	}
+	return append(signals, Signal{
+		Symbol:     leg.spot,
+		Direction:  spotDirection,
+		Price:      spotPrice,
+		Quantity:   quantity,
+		Timestamp:  timestamp,
+		Confidence: confidence,
+		Venue:      VenueExchange,
+	})
+}
@@ -0,0 +1,277 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 组合策略合并子策略投票的方式
+const (
+	compositeModeAll      = "all"      // 所有子策略方向一致时发出信号
+	compositeModeAny      = "any"      // 任一子策略给出方向且没有子策略持相反方向时发出信号
+	compositeModeWeighted = "weighted" // 按权重和信号强度加权，净票数占总权重的比例达到阈值时发出信号
+)
+
+// defaultCompositeThreshold 加权投票的默认阈值
+const defaultCompositeThreshold = 0.5
+
+func init() {
+	Register("composite", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newComposite(deps, name, params)
+	})
+}
+
+// compositeChild 组合策略的一个子策略
+type compositeChild struct {
+	strategy Strategy
+	weight   decimal.Decimal
+}
+
+// compositeVote 子策略对某个交易对的当前立场，为其最近一次信号的方向，直到出现相反信号为止
+type compositeVote struct {
+	direction  string
+	confidence decimal.Decimal
+}
+
+// Composite 组合策略：在同一K线周期上运行多个子策略，按 all/any/weighted 合并各子策略的立场，
+// 只在合并后的方向变化时发出信号，避免子策略各自独立下单产生相互冲突的交易
+type Composite struct {
+	name      string
+	cfg       *config.Config
+	sizer     OrderSizer
+	interval  string
+	mode      string
+	threshold decimal.Decimal
+	children  []compositeChild
+	votes     map[string][]compositeVote // 交易对 -> 各子策略的立场
+	last      map[string]string          // 交易对 -> 上一次发出信号的方向
+}
+
+// newComposite 创建组合策略，子策略配置在 children 参数中：
+// [{type: "moving_average_crossover", weight: 2, params: {...}}, {type: "macd", params: {...}}]
+// 子策略统一使用组合策略的 interval，其参数中的 interval 被覆盖
+func newComposite(deps Dependencies, name string, params map[string]interface{}) (*Composite, error) {
+	mode := strings.ToLower(fmt.Sprintf("%v", params["mode"]))
+	if params["mode"] == nil {
+		mode = compositeModeWeighted
+	}
+	switch mode {
+	case compositeModeAll, compositeModeAny, compositeModeWeighted:
+	default:
+		return nil, fmt.Errorf("组合策略 %s 的 mode 应为 all、any 或 weighted，当前为 %q", name, mode)
+	}
+
+	threshold := paramDecimal(params, "threshold", decimal.NewFromFloat(defaultCompositeThreshold))
+	if !threshold.IsPositive() || threshold.GreaterThan(decimal.NewFromInt(1)) {
+		return nil, fmt.Errorf("组合策略 %s 的 threshold 应在 (0, 1] 之间", name)
+	}
+
+	interval := fmt.Sprintf("%v", params["interval"])
+	if params["interval"] == nil {
+		interval = "1h"
+	}
+
+	list, _ := params["children"].([]interface{})
+	if len(list) < 2 {
+		return nil, fmt.Errorf("组合策略 %s 至少需要两个子策略", name)
+	}
+	children := make([]compositeChild, 0, len(list))
+	for i, item := range list {
+		childCfg, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("组合策略 %s 的第 %d 个子策略配置无效", name, i+1)
+		}
+		child, err := newCompositeChild(deps, name, i, interval, childCfg)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	return &Composite{
+		name:      name,
+		cfg:       deps.Config,
+		sizer:     deps.Sizer,
+		interval:  interval,
+		mode:      mode,
+		threshold: threshold,
+		children:  children,
+		votes:     make(map[string][]compositeVote),
+		last:      make(map[string]string),
+	}, nil
+}
+
+// newCompositeChild 按子策略配置创建子策略，实例名称为 组合策略名/子策略名
+func newCompositeChild(deps Dependencies, parent string, index int, interval string, childCfg map[string]interface{}) (compositeChild, error) {
+	kind := fmt.Sprintf("%v", childCfg["type"])
+	if childCfg["type"] == nil || kind == "" {
+		return compositeChild{}, fmt.Errorf("组合策略 %s 的第 %d 个子策略未指定 type", parent, index+1)
+	}
+	childName := fmt.Sprintf("%s/%s-%d", parent, kind, index+1)
+	if childCfg["name"] != nil {
+		childName = fmt.Sprintf("%s/%v", parent, childCfg["name"])
+	}
+
+	params := make(map[string]interface{})
+	if childParams, ok := childCfg["params"].(map[string]interface{}); ok {
+		for key, value := range childParams {
+			params[key] = value
+		}
+	}
+	params["interval"] = interval
+
+	instance, err := New(kind, childName, deps, params)
+	if err != nil {
+		return compositeChild{}, fmt.Errorf("创建子策略 %s 失败: %v", childName, err)
+	}
+	if _, ok := instance.(ScheduledStrategy); ok {
+		return compositeChild{}, fmt.Errorf("子策略 %s 是定时策略，不能用于组合策略", childName)
+	}
+	if multi, ok := instance.(MultiTimeframeStrategy); ok && len(multi.Timeframes()) > 0 {
+		return compositeChild{}, fmt.Errorf("子策略 %s 使用多个K线周期，不能用于组合策略", childName)
+	}
+
+	weight := paramDecimal(childCfg, "weight", decimal.NewFromInt(1))
+	if !weight.IsPositive() {
+		return compositeChild{}, fmt.Errorf("子策略 %s 的 weight 必须大于0", childName)
+	}
+	return compositeChild{strategy: instance, weight: weight}, nil
+}
+
+// Name 返回策略名称
+func (c *Composite) Name() string {
+	return c.name
+}
+
+// Interval 实现 IntervalStrategy 接口，返回组合策略及其子策略使用的K线周期
+func (c *Composite) Interval() string {
+	return c.interval
+}
+
+// Init 初始化所有子策略
+func (c *Composite) Init() error {
+	logrus.Infof("初始化组合策略 %s (子策略: %d, 合并方式: %s, 间隔: %s)", c.name, len(c.children), c.mode, c.interval)
+	for _, child := range c.children {
+		if err := child.strategy.Init(); err != nil {
+			return fmt.Errorf("初始化子策略 %s 失败: %v", child.strategy.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Process 将K线交给所有子策略，更新各子策略的立场后合并，合并后的方向变化时发出信号
+func (c *Composite) Process(data market.MarketData) ([]Signal, error) {
+	votes := c.votesFor(data.Symbol)
+	for i, child := range c.children {
+		signals, err := child.strategy.Process(data)
+		if err != nil {
+			return nil, fmt.Errorf("子策略 %s 处理数据失败: %v", child.strategy.Name(), err)
+		}
+		for _, signal := range signals {
+			if signal.Symbol != data.Symbol || (signal.Direction != "buy" && signal.Direction != "sell") {
+				continue
+			}
+			confidence := decimal.NewFromFloat(signal.Confidence)
+			if !confidence.IsPositive() {
+				confidence = decimal.NewFromInt(1)
+			}
+			votes[i] = compositeVote{direction: signal.Direction, confidence: confidence}
+		}
+	}
+
+	direction, confidence := c.combine(votes)
+	if direction == "" || direction == c.last[data.Symbol] {
+		return []Signal{}, nil
+	}
+
+	quantity := calculateQuantity(c.sizer, c.cfg, data.Symbol, direction, data.Close)
+	if quantity.IsZero() {
+		return []Signal{}, nil
+	}
+	c.last[data.Symbol] = direction
+
+	return []Signal{
+		{
+			Symbol:     data.Symbol,
+			Direction:  direction,
+			Price:      data.Close,
+			Quantity:   quantity,
+			Timestamp:  data.Timestamp.Unix(),
+			Confidence: confidence,
+		},
+	}, nil
+}
+
+// votesFor 返回交易对各子策略的立场
+func (c *Composite) votesFor(symbol string) []compositeVote {
+	votes, ok := c.votes[symbol]
+	if !ok {
+		votes = make([]compositeVote, len(c.children))
+		c.votes[symbol] = votes
+	}
+	return votes
+}
+
+// combine 按合并方式计算各子策略立场的合并方向和信号强度，没有一致方向时返回空字符串
+func (c *Composite) combine(votes []compositeVote) (string, float64) {
+	buys, sells := 0, 0
+	score, totalWeight := decimal.Zero, decimal.Zero
+	agreeing := decimal.Zero
+	for i, vote := range votes {
+		weight := c.children[i].weight
+		totalWeight = totalWeight.Add(weight)
+		switch vote.direction {
+		case "buy":
+			buys++
+			score = score.Add(weight.Mul(vote.confidence))
+		case "sell":
+			sells++
+			score = score.Sub(weight.Mul(vote.confidence))
+		}
+	}
+
+	direction := ""
+	switch c.mode {
+	case compositeModeAll:
+		if buys == len(votes) {
+			direction = "buy"
+		} else if sells == len(votes) {
+			direction = "sell"
+		}
+	case compositeModeAny:
+		if buys > 0 && sells == 0 {
+			direction = "buy"
+		} else if sells > 0 && buys == 0 {
+			direction = "sell"
+		}
+	case compositeModeWeighted:
+		ratio := score.Div(totalWeight)
+		if ratio.GreaterThanOrEqual(c.threshold) {
+			direction = "buy"
+		} else if ratio.LessThanOrEqual(c.threshold.Neg()) {
+			direction = "sell"
+		}
+		confidence, _ := ratio.Abs().Float64()
+		return direction, confidence
+	}
+	if direction == "" {
+		return "", 0
+	}
+
+	// all/any 模式的信号强度为同向子策略的平均信号强度
+	count := 0
+	for _, vote := range votes {
+		if vote.direction == direction {
+			agreeing = agreeing.Add(vote.confidence)
+			count++
+		}
+	}
+	confidence, _ := agreeing.Div(decimal.NewFromInt(int64(count))).Float64()
+	return direction, confidence
+}
@@ -0,0 +1,115 @@
+package strategy
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// 同一轮数据处理中同一交易对同时出现买入和卖出信号时的处理方式
+const (
+	ConflictPolicyNet        = "net"        // 按数量轧差，只保留净方向的信号
+	ConflictPolicySuppress   = "suppress"   // 同时丢弃买卖信号
+	ConflictPolicyConfidence = "confidence" // 保留信号强度最高的信号
+)
+
+// resolveConflicts 处理同一轮中相互冲突的买卖信号，避免立即反向成交和重复手续费
+func resolveConflicts(signals []Signal, policy string) []Signal {
+	// 按 账户-交易对-场所 分组，保持信号的原始顺序；不同场所的信号互不冲突，如套利策略的两条腿
+	groups := make(map[string][]Signal)
+	order := make([]string, 0)
+	for _, signal := range signals {
+		key := signal.Account + "-" + signal.Symbol + "-" + signal.Venue
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], signal)
+	}
+
+	result := make([]Signal, 0, len(signals))
+	for _, key := range order {
+		group := groups[key]
+		if !hasConflict(group) {
+			result = append(result, group...)
+			continue
+		}
+
+		symbol := group[0].Symbol
+		switch policy {
+		case ConflictPolicyNet:
+			if netted, ok := netSignals(group); ok {
+				logrus.Infof("%s 存在冲突的买卖信号，轧差后为 %s %s", symbol, netted.Direction, netted.Quantity.String())
+				result = append(result, netted)
+			} else {
+				logrus.Infof("%s 存在冲突的买卖信号，轧差后数量为0，全部丢弃", symbol)
+			}
+		case ConflictPolicyConfidence:
+			if strongest, ok := strongestSignal(group); ok {
+				logrus.Infof("%s 存在冲突的买卖信号，保留信号强度最高的 %s 信号", symbol, strongest.Direction)
+				result = append(result, strongest)
+			} else {
+				logrus.Infof("%s 存在冲突的买卖信号且信号强度相同，全部丢弃", symbol)
+			}
+		default:
+			logrus.Infof("%s 存在冲突的买卖信号，全部丢弃", symbol)
+		}
+	}
+
+	return result
+}
+
+// hasConflict 判断信号组中是否同时存在买入和卖出
+func hasConflict(signals []Signal) bool {
+	hasBuy, hasSell := false, false
+	for _, signal := range signals {
+		switch signal.Direction {
+		case "buy":
+			hasBuy = true
+		case "sell":
+			hasSell = true
+		}
+	}
+	return hasBuy && hasSell
+}
+
+// netSignals 将买卖信号按数量轧差为一个净方向信号
+func netSignals(signals []Signal) (Signal, bool) {
+	buyQuantity, sellQuantity := decimal.Zero, decimal.Zero
+	var lastBuy, lastSell Signal
+	for _, signal := range signals {
+		if signal.Direction == "buy" {
+			buyQuantity = buyQuantity.Add(signal.Quantity)
+			lastBuy = signal
+		} else {
+			sellQuantity = sellQuantity.Add(signal.Quantity)
+			lastSell = signal
+		}
+	}
+
+	net := buyQuantity.Sub(sellQuantity)
+	switch {
+	case net.IsPositive():
+		lastBuy.Quantity = net
+		return lastBuy, true
+	case net.IsNegative():
+		lastSell.Quantity = net.Neg()
+		return lastSell, true
+	default:
+		return Signal{}, false
+	}
+}
+
+// strongestSignal 返回信号强度最高的信号，最高强度的信号方向不一致时返回false
+func strongestSignal(signals []Signal) (Signal, bool) {
+	best := signals[0]
+	tie := false
+	for _, signal := range signals[1:] {
+		switch {
+		case signal.Confidence > best.Confidence:
+			best = signal
+			tie = false
+		case signal.Confidence == best.Confidence && signal.Direction != best.Direction:
+			tie = true
+		}
+	}
+	return best, !tie
+}
@@ -0,0 +1,69 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// fixedSignalStrategy 每次处理行情都产生同一方向、数量和强度的信号
+type fixedSignalStrategy struct {
+	name       string
+	direction  string
+	quantity   int64
+	confidence float64
+}
+
+func (s fixedSignalStrategy) Init() error  { return nil }
+func (s fixedSignalStrategy) Name() string { return s.name }
+func (s fixedSignalStrategy) Process(data market.MarketData) ([]Signal, error) {
+	return []Signal{{
+		Symbol:     data.Symbol,
+		Direction:  s.direction,
+		Price:      data.Close,
+		Quantity:   decimal.NewFromInt(s.quantity),
+		Confidence: s.confidence,
+		Timestamp:  data.Timestamp.Unix(),
+	}}, nil
+}
+
+// conflictingSignals 在同一轮行情中让买入和卖出策略同时产生信号，返回按 policy 处理后分发的信号
+func conflictingSignals(t *testing.T, policy string) []Signal {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Strategy.ConflictPolicy = policy
+	sm := NewStrategyManager(cfg, nil)
+	handler := &recordingHandler{}
+	sm.RegisterSignalHandler(handler)
+	for _, strategy := range []Strategy{
+		fixedSignalStrategy{name: "buyer", direction: "buy", quantity: 3, confidence: 0.9},
+		fixedSignalStrategy{name: "seller", direction: "sell", quantity: 1, confidence: 0.4},
+	} {
+		if err := sm.AddStrategy(strategy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sm.HandleData(market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), Timestamp: time.Now()})
+	return handler.signals
+}
+
+func TestConflictingSignalsResolution(t *testing.T) {
+	if signals := conflictingSignals(t, ConflictPolicySuppress); len(signals) != 0 {
+		t.Fatalf("suppress 策略应丢弃冲突的买卖信号，实际: %+v", signals)
+	}
+
+	signals := conflictingSignals(t, ConflictPolicyNet)
+	if len(signals) != 1 || signals[0].Direction != "buy" || !signals[0].Quantity.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("net 策略应轧差为买入 2，实际: %+v", signals)
+	}
+
+	signals = conflictingSignals(t, ConflictPolicyConfidence)
+	if len(signals) != 1 || signals[0].StrategyName != "buyer" || !signals[0].Quantity.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("confidence 策略应保留强度最高的买入信号，实际: %+v", signals)
+	}
+}
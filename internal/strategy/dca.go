@@ -0,0 +1,139 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register("dca", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newDCA(name, deps.Config, params)
+	})
+}
+
+// DCA 定投策略：按 cron 表达式定时以固定金额买入配置的交易对
+// 市场数据只用于记录最新价格以换算买入数量，不会触发信号
+type DCA struct {
+	name     string
+	cfg      *config.Config
+	schedule *cronSchedule
+	expr     string
+	notional decimal.Decimal
+	pairs    map[string]bool
+
+	prices   map[string]decimal.Decimal
+	lastTick time.Time // 上次触发所在的分钟，避免同一分钟重复买入
+	mutex    sync.Mutex
+}
+
+// newDCA 创建定投策略
+func newDCA(name string, cfg *config.Config, params map[string]interface{}) (*DCA, error) {
+	expr := "0 0 * * *"
+	if value, ok := params["schedule"]; ok && value != nil {
+		expr = fmt.Sprintf("%v", value)
+	}
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	notional, err := decimal.NewFromString(fmt.Sprintf("%v", params["notional"]))
+	if err != nil || !notional.IsPositive() {
+		return nil, fmt.Errorf("定投金额 notional 必须大于0: %v", params["notional"])
+	}
+
+	// 未配置 pairs 时定投所有启用的交易对
+	pairs := make(map[string]bool)
+	if list, ok := params["pairs"].([]interface{}); ok {
+		for _, item := range list {
+			pairs[fmt.Sprintf("%v", item)] = true
+		}
+	} else {
+		for _, pair := range cfg.Current().Trading.Pairs {
+			if pair.Enabled {
+				pairs[pair.Symbol] = true
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("定投策略没有可交易的交易对")
+	}
+
+	return &DCA{
+		name:     name,
+		cfg:      cfg,
+		schedule: schedule,
+		expr:     expr,
+		notional: notional,
+		pairs:    pairs,
+		prices:   make(map[string]decimal.Decimal),
+	}, nil
+}
+
+// Name 返回策略名称
+func (d *DCA) Name() string {
+	return d.name
+}
+
+// Init 初始化策略
+func (d *DCA) Init() error {
+	logrus.Infof("初始化定投策略 %s (调度: %s, 每次金额: %s, 交易对数量: %d)",
+		d.name, d.expr, d.notional.String(), len(d.pairs))
+	return nil
+}
+
+// Process 记录交易对的最新价格
+func (d *DCA) Process(data market.MarketData) ([]Signal, error) {
+	if !d.pairs[data.Symbol] {
+		return []Signal{}, nil
+	}
+
+	d.mutex.Lock()
+	d.prices[data.Symbol] = data.Close
+	d.mutex.Unlock()
+
+	return []Signal{}, nil
+}
+
+// Tick 到达调度时间时为每个交易对生成固定金额的买入信号
+func (d *DCA) Tick(now time.Time) ([]Signal, error) {
+	minute := now.Truncate(time.Minute)
+	if !d.schedule.Matches(minute) {
+		return []Signal{}, nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if minute.Equal(d.lastTick) {
+		return []Signal{}, nil
+	}
+	d.lastTick = minute
+
+	signals := make([]Signal, 0, len(d.pairs))
+	for symbol := range d.pairs {
+		price, ok := d.prices[symbol]
+		if !ok || !price.IsPositive() {
+			logrus.Warnf("定投策略 %s 尚未获取到 %s 的价格，跳过本次买入", d.name, symbol)
+			continue
+		}
+
+		signals = append(signals, Signal{
+			Symbol:     symbol,
+			Direction:  "buy",
+			Price:      price,
+			Quantity:   d.notional.Div(price).Round(8),
+			Timestamp:  minute.Unix(),
+			Confidence: 1,
+		})
+	}
+
+	return signals, nil
+}
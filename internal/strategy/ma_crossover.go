@@ -11,54 +11,103 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// defaultOrderQuantity 未启用账户资金跟踪时每笔信号的固定下单数量
+const defaultOrderQuantity = 0.1
+
+// defaultConfidenceFullGap 默认在均线相对差距达到2%时认为信号强度为满值
+const defaultConfidenceFullGap = 0.02
+
+func init() {
+	Register("moving_average_crossover", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newMovingAverageCrossover(name, deps.Config, deps.MarketData, deps.Sizer, params), nil
+	})
+}
+
 // MovingAverageCrossover 实现了移动平均线交叉策略
 type MovingAverageCrossover struct {
+	name          string
 	cfg           *config.Config
 	marketData    *market.MarketDataService
+	sizer         OrderSizer
 	shortPeriod   int
 	longPeriod    int
+	warmupBars    int // 初始化时回填的历史K线数量
 	interval      string
 	priceHistory  map[string][]decimal.Decimal
 	lastCrossover map[string]string // 记录上一次交叉方向: "up" 或 "down"
+
+	// confidenceFullGap 均线相对差距达到该值时信号强度为1
+	confidenceFullGap decimal.Decimal
+	// scaleByConfidence 是否按信号强度缩放下单数量
+	scaleByConfidence bool
 }
 
 // NewMovingAverageCrossover 创建一个新的移动平均线交叉策略
 func NewMovingAverageCrossover(cfg *config.Config, marketData *market.MarketDataService) *MovingAverageCrossover {
+	return newMovingAverageCrossover("moving_average_crossover", cfg, marketData, nil, cfg.Current().Strategy.Params)
+}
+
+// newMovingAverageCrossover 使用指定的实例名称和参数创建移动平均线交叉策略
+func newMovingAverageCrossover(name string, cfg *config.Config, marketData *market.MarketDataService, sizer OrderSizer, params map[string]interface{}) *MovingAverageCrossover {
 	// 从配置中获取参数
-	shortPeriod, _ := strconv.Atoi(fmt.Sprintf("%v", cfg.Strategy.Params["short_period"]))
-	longPeriod, _ := strconv.Atoi(fmt.Sprintf("%v", cfg.Strategy.Params["long_period"]))
-	interval := fmt.Sprintf("%v", cfg.Strategy.Params["interval"])
+	shortPeriod, _ := strconv.Atoi(fmt.Sprintf("%v", params["short_period"]))
+	longPeriod, _ := strconv.Atoi(fmt.Sprintf("%v", params["long_period"]))
+	interval := fmt.Sprintf("%v", params["interval"])
+	if params["interval"] == nil {
+		interval = "1h"
+	}
+
+	fullGap, err := strconv.ParseFloat(fmt.Sprintf("%v", params["confidence_full_gap"]), 64)
+	if err != nil || fullGap <= 0 {
+		fullGap = defaultConfidenceFullGap
+	}
+	scaleByConfidence, _ := strconv.ParseBool(fmt.Sprintf("%v", params["scale_by_confidence"]))
+
+	warmupBars, err := strconv.Atoi(fmt.Sprintf("%v", params["warmup_bars"]))
+	if err != nil || warmupBars < longPeriod {
+		warmupBars = longPeriod + 10
+	}
 
 	return &MovingAverageCrossover{
-		cfg:           cfg,
-		marketData:    marketData,
-		shortPeriod:   shortPeriod,
-		longPeriod:    longPeriod,
-		interval:      interval,
-		priceHistory:  make(map[string][]decimal.Decimal),
-		lastCrossover: make(map[string]string),
+		name:              name,
+		cfg:               cfg,
+		marketData:        marketData,
+		sizer:             sizer,
+		shortPeriod:       shortPeriod,
+		longPeriod:        longPeriod,
+		warmupBars:        warmupBars,
+		interval:          interval,
+		priceHistory:      make(map[string][]decimal.Decimal),
+		lastCrossover:     make(map[string]string),
+		confidenceFullGap: decimal.NewFromFloat(fullGap),
+		scaleByConfidence: scaleByConfidence,
 	}
 }
 
 // Name 返回策略名称
 func (ma *MovingAverageCrossover) Name() string {
-	return "moving_average_crossover"
+	return ma.name
+}
+
+// Interval 实现 IntervalStrategy 接口，返回策略使用的K线周期
+func (ma *MovingAverageCrossover) Interval() string {
+	return ma.interval
 }
 
 // Init 初始化策略
 func (ma *MovingAverageCrossover) Init() error {
-	logrus.Infof("初始化移动平均线交叉策略 (短期: %d, 长期: %d, 间隔: %s)",
-		ma.shortPeriod, ma.longPeriod, ma.interval)
+	logrus.Infof("初始化移动平均线交叉策略 %s (短期: %d, 长期: %d, 间隔: %s)",
+		ma.name, ma.shortPeriod, ma.longPeriod, ma.interval)
 
 	// 为每个交易对加载历史数据
-	for _, pair := range ma.cfg.Trading.Pairs {
+	for _, pair := range ma.cfg.Current().Trading.Pairs {
 		if !pair.Enabled {
 			continue
 		}
 
 		// 获取足够长的历史数据以计算移动平均线
 		histData, err := ma.marketData.GetHistoricalData(
-			pair.Symbol, ma.interval, ma.longPeriod+10)
+			pair.Symbol, ma.interval, ma.warmupBars)
 		if err != nil {
 			return fmt.Errorf("获取 %s 的历史数据失败: %v", pair.Symbol, err)
 		}
@@ -97,7 +146,7 @@ func (ma *MovingAverageCrossover) Process(data market.MarketData) ([]Signal, err
 
 	// 添加新价格并保持数组长度
 	prices = append(prices, data.Close)
-	if len(prices) > ma.longPeriod+10 {
+	if len(prices) > ma.warmupBars {
 		prices = prices[1:]
 	}
 	ma.priceHistory[data.Symbol] = prices
@@ -124,30 +173,33 @@ func (ma *MovingAverageCrossover) Process(data market.MarketData) ([]Signal, err
 	if ok && lastCross != currentCross {
 		ma.lastCrossover[data.Symbol] = currentCross
 
-		// 生成信号
+		direction := "sell" // 短期均线下穿长期均线，卖出信号
 		if currentCross == "up" {
-			// 短期均线上穿长期均线，买入信号
-			return []Signal{
-				{
-					Symbol:    data.Symbol,
-					Direction: "buy",
-					Price:     data.Close,
-					Quantity:  calculateQuantity(data.Symbol, ma.cfg),
-					Timestamp: data.Timestamp.Unix(),
-				},
-			}, nil
-		} else {
-			// 短期均线下穿长期均线，卖出信号
-			return []Signal{
-				{
-					Symbol:    data.Symbol,
-					Direction: "sell",
-					Price:     data.Close,
-					Quantity:  calculateQuantity(data.Symbol, ma.cfg),
-					Timestamp: data.Timestamp.Unix(),
-				},
-			}, nil
+			direction = "buy" // 短期均线上穿长期均线，买入信号
+		}
+
+		// 根据均线相对差距计算信号强度并确定下单数量
+		confidence := ma.calculateConfidence(shortMA, longMA)
+		quantity := calculateQuantity(ma.sizer, ma.cfg, data.Symbol, direction, data.Close)
+		if ma.scaleByConfidence {
+			quantity = scaleQuantityByConfidence(quantity, confidence)
+		}
+		if quantity.IsZero() {
+			return []Signal{}, nil
 		}
+
+		// 生成信号
+
+		return []Signal{
+			{
+				Symbol:     data.Symbol,
+				Direction:  direction,
+				Price:      data.Close,
+				Quantity:   quantity,
+				Timestamp:  data.Timestamp.Unix(),
+				Confidence: confidence,
+			},
+		}, nil
 	}
 
 	// 没有交叉发生，返回空信号
@@ -168,9 +220,42 @@ func calculateMA(prices []decimal.Decimal, period int) decimal.Decimal {
 	return sum.Div(decimal.NewFromInt(int64(period)))
 }
 
-// calculateQuantity 计算交易数量
-func calculateQuantity(symbol string, cfg *config.Config) decimal.Decimal {
-	// 在实际应用中，这里应该根据账户余额和风险设置计算交易数量
-	// 这里简单返回一个固定值作为示例
-	return decimal.NewFromFloat(0.1)
+// calculateConfidence 根据短期与长期均线的相对差距计算信号强度 (0-1)
+func (ma *MovingAverageCrossover) calculateConfidence(shortMA, longMA decimal.Decimal) float64 {
+	if longMA.IsZero() {
+		return 0
+	}
+
+	gap := shortMA.Sub(longMA).Abs().Div(longMA.Abs())
+	confidence, _ := gap.Div(ma.confidenceFullGap).Float64()
+	if confidence > 1 {
+		confidence = 1
+	}
+
+	return confidence
+}
+
+// scaleQuantityByConfidence 按信号强度缩放下单数量
+func scaleQuantityByConfidence(quantity decimal.Decimal, confidence float64) decimal.Decimal {
+	if confidence <= 0 {
+		return decimal.Zero
+	}
+	if confidence >= 1 {
+		return quantity
+	}
+
+	return quantity.Mul(decimal.NewFromFloat(confidence)).Round(8)
+}
+
+// calculateQuantity 计算交易数量，未设置下单数量计算方法时使用固定值
+func calculateQuantity(sizer OrderSizer, cfg *config.Config, symbol, direction string, price decimal.Decimal) decimal.Decimal {
+	if sizer == nil {
+		return decimal.NewFromFloat(defaultOrderQuantity)
+	}
+
+	account := cfg.Strategy.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+	return sizer.OrderQuantity(account, symbol, direction, price)
 }
@@ -0,0 +1,68 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// crossoverSignal 在平稳价格后输入一根收盘价为 close 的K线，返回产生的上穿信号
+func crossoverSignal(t *testing.T, close float64) Signal {
+	t.Helper()
+
+	ma := newMovingAverageCrossover("ma_test", &config.Config{}, nil, nil, map[string]interface{}{
+		"short_period":        2,
+		"long_period":         4,
+		"confidence_full_gap": 0.05,
+		"scale_by_confidence": true,
+	})
+	const symbol = "BTCUSDT"
+	for i := 0; i < 4; i++ {
+		ma.priceHistory[symbol] = append(ma.priceHistory[symbol], decimal.NewFromInt(100))
+	}
+	ma.lastCrossover[symbol] = "down"
+
+	signals, err := ma.Process(market.MarketData{
+		Symbol:    symbol,
+		Close:     decimal.NewFromFloat(close),
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("处理K线失败: %v", err)
+	}
+	if len(signals) != 1 || signals[0].Direction != "buy" {
+		t.Fatalf("期望一个买入信号，实际: %+v", signals)
+	}
+	return signals[0]
+}
+
+func TestStrongerCrossoverHasHigherConfidenceAndSize(t *testing.T) {
+	weak := crossoverSignal(t, 101)
+	strong := crossoverSignal(t, 110)
+
+	if strong.Confidence <= weak.Confidence {
+		t.Errorf("强交叉的信号强度 %v 应高于弱交叉 %v", strong.Confidence, weak.Confidence)
+	}
+	if !strong.Quantity.GreaterThan(weak.Quantity) {
+		t.Errorf("强交叉的下单数量 %s 应大于弱交叉 %s", strong.Quantity, weak.Quantity)
+	}
+	if weak.Confidence <= 0 || strong.Confidence > 1 {
+		t.Errorf("信号强度应在 (0, 1] 之间: 弱 %v，强 %v", weak.Confidence, strong.Confidence)
+	}
+}
+
+func TestConfidenceCappedAtFullGap(t *testing.T) {
+	ma := newMovingAverageCrossover("ma_test", &config.Config{}, nil, nil, map[string]interface{}{})
+
+	confidence := ma.calculateConfidence(decimal.NewFromInt(110), decimal.NewFromInt(100))
+	if confidence != 1 {
+		t.Errorf("均线差距超过满值差距时信号强度应为1，实际 %v", confidence)
+	}
+	if got := scaleQuantityByConfidence(decimal.NewFromInt(1), 0.5); !got.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("按0.5的信号强度缩放后应为0.5，实际 %s", got)
+	}
+}
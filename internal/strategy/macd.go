@@ -0,0 +1,247 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register("macd", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newMACD(name, deps.Config, deps.MarketData, deps.Sizer, params)
+	})
+}
+
+// macdParams MACD 指标参数
+type macdParams struct {
+	fastPeriod   int
+	slowPeriod   int
+	signalPeriod int
+}
+
+// macdState 单个交易对的 MACD 指标状态（增量计算的各条EMA）
+type macdState struct {
+	params    macdParams
+	fastEMA   decimal.Decimal
+	slowEMA   decimal.Decimal
+	signalEMA decimal.Decimal
+	bars      int             // 已处理的K线数量
+	histogram decimal.Decimal // 上一根K线的柱状图值
+	ready     bool            // 柱状图是否已有效
+}
+
+// MACD 实现 MACD 策略：柱状图（MACD线 - 信号线）上穿零轴买入，下穿零轴卖出
+// 参数可通过 pair_params 按交易对覆盖
+type MACD struct {
+	name       string
+	cfg        *config.Config
+	marketData *market.MarketDataService
+	sizer      OrderSizer
+	interval   string
+	defaults   macdParams
+	pairParams map[string]macdParams
+	states     map[string]*macdState
+}
+
+// newMACD 创建 MACD 策略
+func newMACD(name string, cfg *config.Config, marketData *market.MarketDataService, sizer OrderSizer, params map[string]interface{}) (*MACD, error) {
+	defaults := macdParams{
+		fastPeriod:   paramInt(params, "fast_period", 12),
+		slowPeriod:   paramInt(params, "slow_period", 26),
+		signalPeriod: paramInt(params, "signal_period", 9),
+	}
+	if err := defaults.validate(); err != nil {
+		return nil, err
+	}
+
+	interval := fmt.Sprintf("%v", params["interval"])
+	if params["interval"] == nil {
+		interval = "1h"
+	}
+
+	// pair_params: [{symbol: "BTC/USDT", fast_period: 8, slow_period: 21, signal_period: 5}]
+	pairParams := make(map[string]macdParams)
+	if list, ok := params["pair_params"].([]interface{}); ok {
+		for _, item := range list {
+			override, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			symbol := fmt.Sprintf("%v", override["symbol"])
+			p := macdParams{
+				fastPeriod:   paramInt(override, "fast_period", defaults.fastPeriod),
+				slowPeriod:   paramInt(override, "slow_period", defaults.slowPeriod),
+				signalPeriod: paramInt(override, "signal_period", defaults.signalPeriod),
+			}
+			if err := p.validate(); err != nil {
+				return nil, fmt.Errorf("交易对 %s 的MACD参数无效: %v", symbol, err)
+			}
+			pairParams[symbol] = p
+		}
+	}
+
+	return &MACD{
+		name:       name,
+		cfg:        cfg,
+		marketData: marketData,
+		sizer:      sizer,
+		interval:   interval,
+		defaults:   defaults,
+		pairParams: pairParams,
+		states:     make(map[string]*macdState),
+	}, nil
+}
+
+// Name 返回策略名称
+func (m *MACD) Name() string {
+	return m.name
+}
+
+// Interval 实现 IntervalStrategy 接口，返回策略使用的K线周期
+func (m *MACD) Interval() string {
+	return m.interval
+}
+
+// Init 初始化策略，使用历史数据预热指标
+func (m *MACD) Init() error {
+	logrus.Infof("初始化MACD策略 %s (快线: %d, 慢线: %d, 信号线: %d, 间隔: %s)",
+		m.name, m.defaults.fastPeriod, m.defaults.slowPeriod, m.defaults.signalPeriod, m.interval)
+
+	for _, pair := range m.cfg.Current().Trading.Pairs {
+		if !pair.Enabled {
+			continue
+		}
+
+		state := m.state(pair.Symbol)
+		warmup := (state.params.slowPeriod + state.params.signalPeriod) * 3
+		histData, err := m.marketData.GetHistoricalData(pair.Symbol, m.interval, warmup)
+		if err != nil {
+			return fmt.Errorf("获取 %s 的历史数据失败: %v", pair.Symbol, err)
+		}
+
+		for _, data := range histData {
+			state.update(data.Close)
+		}
+	}
+
+	return nil
+}
+
+// Process 处理新的市场数据
+func (m *MACD) Process(data market.MarketData) ([]Signal, error) {
+	state := m.state(data.Symbol)
+	previous, wasReady := state.histogram, state.ready
+	state.update(data.Close)
+	if !wasReady || !state.ready {
+		return []Signal{}, nil
+	}
+
+	direction := ""
+	if !previous.IsPositive() && state.histogram.IsPositive() {
+		direction = "buy" // 柱状图上穿零轴
+	} else if !previous.IsNegative() && state.histogram.IsNegative() {
+		direction = "sell" // 柱状图下穿零轴
+	}
+	if direction == "" {
+		return []Signal{}, nil
+	}
+
+	// 柱状图相对价格的幅度作为信号强度
+	confidence := 1.0
+	if data.Close.IsPositive() {
+		confidence, _ = state.histogram.Abs().Div(data.Close).Div(decimal.NewFromFloat(defaultConfidenceFullGap)).Float64()
+		if confidence > 1 {
+			confidence = 1
+		}
+	}
+
+	quantity := calculateQuantity(m.sizer, m.cfg, data.Symbol, direction, data.Close)
+	if quantity.IsZero() {
+		return []Signal{}, nil
+	}
+
+	return []Signal{
+		{
+			Symbol:     data.Symbol,
+			Direction:  direction,
+			Price:      data.Close,
+			Quantity:   quantity,
+			Timestamp:  data.Timestamp.Unix(),
+			Confidence: confidence,
+		},
+	}, nil
+}
+
+// state 返回交易对的指标状态，不存在时按交易对参数创建
+func (m *MACD) state(symbol string) *macdState {
+	state, ok := m.states[symbol]
+	if !ok {
+		params, ok := m.pairParams[symbol]
+		if !ok {
+			params = m.defaults
+		}
+		state = &macdState{params: params}
+		m.states[symbol] = state
+	}
+	return state
+}
+
+// update 用新的收盘价更新各条EMA和柱状图
+func (s *macdState) update(price decimal.Decimal) {
+	s.bars++
+	if s.bars == 1 {
+		s.fastEMA = price
+		s.slowEMA = price
+		return
+	}
+
+	s.fastEMA = updateEMA(s.fastEMA, price, s.params.fastPeriod)
+	s.slowEMA = updateEMA(s.slowEMA, price, s.params.slowPeriod)
+	if s.bars < s.params.slowPeriod {
+		return
+	}
+
+	macdLine := s.fastEMA.Sub(s.slowEMA)
+	if s.bars == s.params.slowPeriod {
+		s.signalEMA = macdLine
+		return
+	}
+	s.signalEMA = updateEMA(s.signalEMA, macdLine, s.params.signalPeriod)
+	s.histogram = macdLine.Sub(s.signalEMA)
+	s.ready = s.bars >= s.params.slowPeriod+s.params.signalPeriod
+}
+
+// validate 检查参数是否有效
+func (p macdParams) validate() error {
+	if p.fastPeriod <= 0 || p.slowPeriod <= 0 || p.signalPeriod <= 0 {
+		return fmt.Errorf("MACD周期必须大于0")
+	}
+	if p.fastPeriod >= p.slowPeriod {
+		return fmt.Errorf("快线周期(%d)必须小于慢线周期(%d)", p.fastPeriod, p.slowPeriod)
+	}
+	return nil
+}
+
+// updateEMA 计算新的指数移动平均值
+func updateEMA(previous, price decimal.Decimal, period int) decimal.Decimal {
+	alpha := decimal.NewFromInt(2).Div(decimal.NewFromInt(int64(period + 1)))
+	return price.Sub(previous).Mul(alpha).Add(previous)
+}
+
+// paramInt 读取整数参数，未配置或格式错误时返回默认值
+func paramInt(params map[string]interface{}, key string, defaultValue int) int {
+	value, ok := params[key]
+	if !ok || value == nil {
+		return defaultValue
+	}
+	result, err := strconv.Atoi(fmt.Sprintf("%v", value))
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
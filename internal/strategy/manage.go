@@ -0,0 +1,139 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StrategyInfo 策略实例信息
+type StrategyInfo struct {
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Params  map[string]interface{} `json:"params"`
+	Enabled bool                   `json:"enabled"`
+}
+
+// Strategies 获取所有策略实例的信息，按名称排序
+func (sm *StrategyManager) Strategies() []StrategyInfo {
+	sm.strategiesMu.RLock()
+	defer sm.strategiesMu.RUnlock()
+
+	result := make([]StrategyInfo, 0, len(sm.infos))
+	for _, info := range sm.infos {
+		result = append(result, copyInfo(info))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// GetStrategyInfo 获取指定策略实例的信息
+func (sm *StrategyManager) GetStrategyInfo(name string) (StrategyInfo, bool) {
+	sm.strategiesMu.RLock()
+	defer sm.strategiesMu.RUnlock()
+
+	info, ok := sm.infos[name]
+	if !ok {
+		return StrategyInfo{}, false
+	}
+	return copyInfo(info), true
+}
+
+// CreateStrategy 按类型和参数创建策略实例并立即开始运行
+func (sm *StrategyManager) CreateStrategy(kind, name string, params map[string]interface{}) error {
+	if name == "" {
+		name = kind
+	}
+
+	strategy, err := sm.newStrategyInstance(kind, name, params)
+	if err != nil {
+		return err
+	}
+	return sm.addStrategy(strategy, kind, params)
+}
+
+// UpdateStrategy 使用新参数重建策略实例，替换正在运行的实例，保留启用状态
+func (sm *StrategyManager) UpdateStrategy(name string, params map[string]interface{}) error {
+	info, ok := sm.GetStrategyInfo(name)
+	if !ok {
+		return fmt.Errorf("策略 %s 不存在", name)
+	}
+
+	strategy, err := sm.newStrategyInstance(info.Type, name, params)
+	if err != nil {
+		return err
+	}
+	if err := strategy.Init(); err != nil {
+		return fmt.Errorf("初始化策略 %s 失败: %v", name, err)
+	}
+
+	sm.strategiesMu.Lock()
+	defer sm.strategiesMu.Unlock()
+
+	current, ok := sm.infos[name]
+	if !ok {
+		return fmt.Errorf("策略 %s 不存在", name)
+	}
+	sm.strategies[name] = strategy
+	current.Params = params
+
+	logrus.Infof("已更新策略 %s 的参数", name)
+	return nil
+}
+
+// RemoveStrategy 停止并移除策略实例
+func (sm *StrategyManager) RemoveStrategy(name string) error {
+	sm.strategiesMu.Lock()
+	defer sm.strategiesMu.Unlock()
+
+	if _, ok := sm.strategies[name]; !ok {
+		return fmt.Errorf("策略 %s 不存在", name)
+	}
+	delete(sm.strategies, name)
+	delete(sm.infos, name)
+	delete(sm.sizeFractions, name)
+	sm.filter.forget(name)
+	sm.forgetBars(name)
+
+	logrus.Infof("已移除策略: %s", name)
+	return nil
+}
+
+// SetStrategyEnabled 启用或暂停策略实例，暂停的策略不处理市场数据
+func (sm *StrategyManager) SetStrategyEnabled(name string, enabled bool) error {
+	sm.strategiesMu.Lock()
+	defer sm.strategiesMu.Unlock()
+
+	info, ok := sm.infos[name]
+	if !ok {
+		return fmt.Errorf("策略 %s 不存在", name)
+	}
+	info.Enabled = enabled
+
+	if enabled {
+		logrus.Infof("策略 %s 已启用", name)
+	} else {
+		logrus.Infof("策略 %s 已暂停", name)
+	}
+	return nil
+}
+
+// isEnabled 判断策略实例是否启用，调用方需持有 strategiesMu
+func (sm *StrategyManager) isEnabled(name string) bool {
+	info, ok := sm.infos[name]
+	return !ok || info.Enabled
+}
+
+// copyInfo 复制策略信息，避免调用方修改内部状态
+func copyInfo(info *StrategyInfo) StrategyInfo {
+	params := make(map[string]interface{}, len(info.Params))
+	for k, v := range info.Params {
+		params[k] = v
+	}
+	copied := *info
+	copied.Params = params
+	return copied
+}
@@ -0,0 +1,106 @@
+package strategy
+
+import (
+	"fmt"
+
+	"autotransaction/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pairStrategy 交易对专属策略实例的配置
+type pairStrategy struct {
+	symbol string
+	kind   string
+	name   string
+	params map[string]interface{}
+}
+
+// startPairStrategies 为单独配置了策略的交易对创建专属策略实例
+// 专属实例只接收该交易对的数据，全局策略不再处理这些交易对
+func (sm *StrategyManager) startPairStrategies() error {
+	for _, pair := range pairStrategies(sm.cfg) {
+		if err := sm.startPairStrategy(pair); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startPairStrategy 创建交易对的专属策略实例并将该交易对的数据路由给它
+func (sm *StrategyManager) startPairStrategy(pair pairStrategy) error {
+	if err := sm.CreateStrategy(pair.kind, pair.name, pair.params); err != nil {
+		return fmt.Errorf("创建交易对 %s 的策略失败: %v", pair.symbol, err)
+	}
+
+	sm.strategiesMu.Lock()
+	sm.pairRoutes[pair.symbol] = pair.name
+	sm.strategiesMu.Unlock()
+	logrus.Infof("交易对 %s 使用专属策略 %s", pair.symbol, pair.name)
+	return nil
+}
+
+// pairStrategies 返回配置中单独配置了策略的已启用交易对
+func pairStrategies(cfg *config.Config) []pairStrategy {
+	var result []pairStrategy
+	for _, pair := range cfg.Current().Trading.Pairs {
+		if !pair.Enabled || !pair.HasStrategyOverride() {
+			continue
+		}
+
+		kind := pair.Strategy
+		if kind == "" {
+			kind = cfg.Strategy.Name
+		}
+
+		// 与全局策略类型相同时在全局参数基础上覆盖
+		params := make(map[string]interface{})
+		if kind == cfg.Strategy.Name {
+			for k, v := range cfg.Current().Strategy.Params {
+				params[k] = v
+			}
+		}
+		for k, v := range pair.StrategyParams {
+			params[k] = v
+		}
+
+		result = append(result, pairStrategy{
+			symbol: pair.Symbol,
+			kind:   kind,
+			name:   pairStrategyName(kind, pair.Symbol),
+			params: params,
+		})
+	}
+	return result
+}
+
+// routesTo 判断交易对的数据是否应交给该策略处理，调用方需持有 strategiesMu 读锁
+// 专属策略实例被删除后，该交易对重新由全局策略处理
+func (sm *StrategyManager) routesTo(strategyName, symbol string) bool {
+	routed, ok := sm.pairRoutes[symbol]
+	if ok {
+		if _, exists := sm.strategies[routed]; !exists {
+			ok = false
+		}
+	}
+
+	if ok {
+		return strategyName == routed
+	}
+	return !sm.isPairStrategy(strategyName)
+}
+
+// isPairStrategy 判断策略是否为某个交易对的专属实例，调用方需持有 strategiesMu 读锁
+func (sm *StrategyManager) isPairStrategy(strategyName string) bool {
+	for _, name := range sm.pairRoutes {
+		if name == strategyName {
+			return true
+		}
+	}
+	return false
+}
+
+// pairStrategyName 生成交易对专属策略实例的名称
+func pairStrategyName(kind, symbol string) string {
+	return fmt.Sprintf("%s@%s", kind, symbol)
+}
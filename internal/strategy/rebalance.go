@@ -0,0 +1,210 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	Register("portfolio_rebalance", func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error) {
+		return newPortfolioRebalance(name, deps.Config, deps.Holdings)
+	})
+}
+
+// HoldingsProvider 提供账户当前持有的各交易对数量
+type HoldingsProvider interface {
+	Holdings(account string) map[string]decimal.Decimal
+}
+
+// PortfolioRebalance 按目标权重再平衡投资组合
+// 达到定时周期或任一交易对的权重偏离超过阈值时触发（以先到者为准），两次再平衡之间至少间隔最小周期
+type PortfolioRebalance struct {
+	name        string
+	cfg         *config.Config
+	holdings    HoldingsProvider
+	account     string
+	capital     decimal.Decimal
+	targets     map[string]decimal.Decimal // 目标权重
+	driftBand   decimal.Decimal
+	schedule    time.Duration
+	minInterval time.Duration
+
+	prices        map[string]decimal.Decimal
+	lastRebalance time.Time
+}
+
+// NewPortfolioRebalance 创建一个新的投资组合再平衡策略
+func NewPortfolioRebalance(cfg *config.Config, holdings HoldingsProvider) (*PortfolioRebalance, error) {
+	return newPortfolioRebalance("portfolio_rebalance", cfg, holdings)
+}
+
+// newPortfolioRebalance 使用指定的实例名称创建投资组合再平衡策略
+func newPortfolioRebalance(name string, cfg *config.Config, holdings HoldingsProvider) (*PortfolioRebalance, error) {
+	rebalanceCfg := cfg.Strategy.Rebalance
+	if holdings == nil {
+		return nil, fmt.Errorf("再平衡策略需要持仓数据来源")
+	}
+	if rebalanceCfg.Capital <= 0 {
+		return nil, fmt.Errorf("再平衡资金必须大于0")
+	}
+
+	targets := make(map[string]decimal.Decimal)
+	total := 0.0
+	for _, target := range rebalanceCfg.Targets {
+		if target.Weight < 0 {
+			return nil, fmt.Errorf("交易对 %s 的目标权重不能为负", target.Symbol)
+		}
+		targets[target.Symbol] = decimal.NewFromFloat(target.Weight)
+		total += target.Weight
+	}
+	if len(targets) == 0 || total > 1+1e-9 {
+		return nil, fmt.Errorf("目标权重之和必须在(0, 1]之间: %v", total)
+	}
+
+	account := cfg.Strategy.Account
+	if account == "" {
+		account = config.DefaultAccountID
+	}
+
+	return &PortfolioRebalance{
+		name:        name,
+		cfg:         cfg,
+		holdings:    holdings,
+		account:     account,
+		capital:     decimal.NewFromFloat(rebalanceCfg.Capital),
+		targets:     targets,
+		driftBand:   decimal.NewFromFloat(rebalanceCfg.DriftBand),
+		schedule:    time.Duration(rebalanceCfg.ScheduleMinutes) * time.Minute,
+		minInterval: time.Duration(rebalanceCfg.MinIntervalMinutes) * time.Minute,
+		prices:      make(map[string]decimal.Decimal),
+	}, nil
+}
+
+// Name 返回策略名称
+func (pr *PortfolioRebalance) Name() string {
+	return pr.name
+}
+
+// Init 初始化策略
+func (pr *PortfolioRebalance) Init() error {
+	logrus.Infof("初始化投资组合再平衡策略 (偏离阈值: %s, 定时周期: %s, 最小间隔: %s)",
+		pr.driftBand.String(), pr.schedule, pr.minInterval)
+	return nil
+}
+
+// Process 处理新的市场数据
+func (pr *PortfolioRebalance) Process(data market.MarketData) ([]Signal, error) {
+	if _, ok := pr.targets[data.Symbol]; !ok {
+		return []Signal{}, nil
+	}
+	pr.prices[data.Symbol] = data.Close
+
+	// 所有目标交易对都有价格后才能计算权重
+	if len(pr.prices) < len(pr.targets) {
+		return []Signal{}, nil
+	}
+
+	now := data.Timestamp
+	if !pr.lastRebalance.IsZero() && now.Sub(pr.lastRebalance) < pr.minInterval {
+		return []Signal{}, nil
+	}
+
+	values := pr.currentValues()
+	reason := ""
+	if pr.schedule > 0 && (pr.lastRebalance.IsZero() || now.Sub(pr.lastRebalance) >= pr.schedule) {
+		reason = "定时"
+	} else if symbol, drift, ok := pr.maxDrift(values); ok && pr.driftBand.IsPositive() && drift.GreaterThan(pr.driftBand) {
+		reason = fmt.Sprintf("%s 权重偏离 %s", symbol, drift.StringFixed(4))
+	}
+	if reason == "" {
+		return []Signal{}, nil
+	}
+
+	signals := pr.rebalanceSignals(values, now)
+	pr.lastRebalance = now
+	logrus.Infof("触发投资组合再平衡 (%s)，生成 %d 个交易信号", reason, len(signals))
+
+	return signals, nil
+}
+
+// currentValues 计算各目标交易对当前持仓市值
+func (pr *PortfolioRebalance) currentValues() map[string]decimal.Decimal {
+	holdings := pr.holdings.Holdings(pr.account)
+	values := make(map[string]decimal.Decimal)
+	for symbol := range pr.targets {
+		values[symbol] = holdings[symbol].Mul(pr.prices[symbol])
+	}
+	return values
+}
+
+// maxDrift 返回当前权重与目标权重偏离最大的交易对及其偏离值
+func (pr *PortfolioRebalance) maxDrift(values map[string]decimal.Decimal) (string, decimal.Decimal, bool) {
+	maxSymbol := ""
+	maxDrift := decimal.Zero
+	for symbol, target := range pr.targets {
+		drift := values[symbol].Div(pr.capital).Sub(target).Abs()
+		if maxSymbol == "" || drift.GreaterThan(maxDrift) {
+			maxSymbol = symbol
+			maxDrift = drift
+		}
+	}
+	return maxSymbol, maxDrift, maxSymbol != ""
+}
+
+// rebalanceSignals 根据当前与目标市值的差额生成交易信号，按数量步长向下取整并忽略低于最小名义价值的交易
+func (pr *PortfolioRebalance) rebalanceSignals(values map[string]decimal.Decimal, now time.Time) []Signal {
+	signals := make([]Signal, 0)
+	for symbol, target := range pr.targets {
+		price := pr.prices[symbol]
+		if !price.IsPositive() {
+			continue
+		}
+
+		diff := pr.capital.Mul(target).Sub(values[symbol])
+		direction := "buy"
+		if diff.IsNegative() {
+			direction = "sell"
+		}
+
+		pair := pr.pairConfig(symbol)
+		quantity := diff.Abs().Div(price)
+		if pair.StepSize > 0 {
+			step := decimal.NewFromFloat(pair.StepSize)
+			quantity = quantity.Div(step).Floor().Mul(step)
+		} else {
+			quantity = quantity.Truncate(8)
+		}
+
+		notional := quantity.Mul(price)
+		if quantity.IsZero() || notional.LessThan(decimal.NewFromFloat(pair.MinNotional)) {
+			continue
+		}
+
+		signals = append(signals, Signal{
+			Symbol:     symbol,
+			Direction:  direction,
+			Price:      price,
+			Quantity:   quantity,
+			Timestamp:  now.Unix(),
+			Confidence: 1,
+			Account:    pr.account,
+		})
+	}
+	return signals
+}
+
+// pairConfig 返回交易对配置
+func (pr *PortfolioRebalance) pairConfig(symbol string) config.PairConfig {
+	for _, pair := range pr.cfg.Current().Trading.Pairs {
+		if pair.Symbol == symbol {
+			return pair
+		}
+	}
+	return config.PairConfig{Symbol: symbol}
+}
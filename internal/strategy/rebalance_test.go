@@ -0,0 +1,102 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// staticHoldings 固定的持仓数据
+type staticHoldings map[string]decimal.Decimal
+
+func (h staticHoldings) Holdings(account string) map[string]decimal.Decimal {
+	return h
+}
+
+// rebalanceAt 在指定时间推送两个交易对的价格，返回再平衡生成的信号
+func rebalanceAt(t *testing.T, pr *PortfolioRebalance, at time.Time, btc, eth int64) []Signal {
+	t.Helper()
+	var signals []Signal
+	for symbol, price := range map[string]int64{"BTC/USDT": btc, "ETH/USDT": eth} {
+		result, err := pr.Process(market.MarketData{Symbol: symbol, Close: decimal.NewFromInt(price), Timestamp: at})
+		if err != nil {
+			t.Fatal(err)
+		}
+		signals = append(signals, result...)
+	}
+	return signals
+}
+
+func newTestRebalance(t *testing.T, rebalanceCfg config.RebalanceConfig) *PortfolioRebalance {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Trading.Pairs = []config.PairConfig{
+		{Symbol: "BTC/USDT", StepSize: 0.01, MinNotional: 10},
+		{Symbol: "ETH/USDT", StepSize: 0.01, MinNotional: 10},
+	}
+	rebalanceCfg.Capital = 1000
+	rebalanceCfg.Targets = []config.RebalanceTarget{{Symbol: "BTC/USDT", Weight: 0.5}, {Symbol: "ETH/USDT", Weight: 0.5}}
+	cfg.Strategy.Rebalance = rebalanceCfg
+
+	// 按 100 的价格两个交易对各占一半
+	holdings := staticHoldings{"BTC/USDT": decimal.NewFromInt(5), "ETH/USDT": decimal.NewFromInt(5)}
+	pr, err := NewPortfolioRebalance(cfg, holdings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pr
+}
+
+func TestRebalanceScheduleTrigger(t *testing.T) {
+	pr := newTestRebalance(t, config.RebalanceConfig{ScheduleMinutes: 60, MinIntervalMinutes: 30})
+	start := time.Now()
+
+	// 首次拿到全部价格时按定时触发，权重已达目标不需要交易
+	if signals := rebalanceAt(t, pr, start, 100, 100); len(signals) != 0 {
+		t.Fatalf("权重已达目标不应生成交易: %+v", signals)
+	}
+	if !pr.lastRebalance.Equal(start) {
+		t.Fatal("首次拿到全部价格时应按定时触发再平衡")
+	}
+
+	// 未到定时周期且未配置偏离阈值，即使权重偏离也不触发
+	if signals := rebalanceAt(t, pr, start.Add(45*time.Minute), 150, 100); len(signals) != 0 {
+		t.Fatalf("未到定时周期不应再平衡: %+v", signals)
+	}
+
+	signals := rebalanceAt(t, pr, start.Add(60*time.Minute), 150, 100)
+	if len(signals) != 1 || signals[0].Symbol != "BTC/USDT" || signals[0].Direction != "sell" {
+		t.Fatalf("到达定时周期应卖出超配的 BTC: %+v", signals)
+	}
+	// 超配 250，按 150 的价格和 0.01 的步长向下取整
+	if !signals[0].Quantity.Equal(decimal.RequireFromString("1.66")) {
+		t.Fatalf("卖出数量应按步长取整为 1.66，实际 %s", signals[0].Quantity)
+	}
+}
+
+func TestRebalanceDriftTriggerRespectsMinInterval(t *testing.T) {
+	pr := newTestRebalance(t, config.RebalanceConfig{DriftBand: 0.1, MinIntervalMinutes: 30})
+	start := time.Now()
+
+	if signals := rebalanceAt(t, pr, start, 100, 100); len(signals) != 0 || !pr.lastRebalance.IsZero() {
+		t.Fatalf("未偏离且未配置定时周期时不应再平衡: %+v", signals)
+	}
+
+	// BTC 权重升至 0.75，超过 0.1 的偏离阈值
+	if signals := rebalanceAt(t, pr, start.Add(10*time.Minute), 150, 100); len(signals) != 1 {
+		t.Fatalf("权重偏离超过阈值应触发再平衡: %+v", signals)
+	}
+
+	// 持仓数据未变化，偏离依然存在，但距上次再平衡不足最小间隔
+	if signals := rebalanceAt(t, pr, start.Add(20*time.Minute), 150, 100); len(signals) != 0 {
+		t.Fatalf("距上次再平衡不足最小间隔不应再次触发: %+v", signals)
+	}
+
+	if signals := rebalanceAt(t, pr, start.Add(40*time.Minute), 150, 100); len(signals) != 1 {
+		t.Fatalf("超过最小间隔后偏离依然存在应再次触发: %+v", signals)
+	}
+}
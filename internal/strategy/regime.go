@@ -0,0 +1,114 @@
+package strategy
+
+import (
+	"math"
+	"sync"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+)
+
+// 市场状态
+const (
+	RegimeTrending = "trending"
+	RegimeRanging  = "ranging"
+	RegimeVolatile = "volatile"
+	RegimeUnknown  = "unknown"
+)
+
+const (
+	defaultRegimeLookback            = 20
+	defaultRegimeVolatilityThreshold = 0.03
+	defaultRegimeTrendThreshold      = 0.3
+)
+
+// regimeClassifier 根据最近K线的统计特征判断各交易对当前的市场状态
+type regimeClassifier struct {
+	lookback            int
+	volatilityThreshold float64 // 收益率标准差超过该值视为高波动
+	trendThreshold      float64 // 效率比（净变动/路径总长度）超过该值视为趋势
+	closes              map[string][]float64
+	mutex               sync.Mutex
+}
+
+// newRegimeClassifier 创建市场状态分类器
+func newRegimeClassifier(cfg config.RegimeConfig) *regimeClassifier {
+	c := &regimeClassifier{
+		lookback:            cfg.Lookback,
+		volatilityThreshold: cfg.VolatilityThreshold,
+		trendThreshold:      cfg.TrendThreshold,
+		closes:              make(map[string][]float64),
+	}
+	if c.lookback < 2 {
+		c.lookback = defaultRegimeLookback
+	}
+	if c.volatilityThreshold <= 0 {
+		c.volatilityThreshold = defaultRegimeVolatilityThreshold
+	}
+	if c.trendThreshold <= 0 {
+		c.trendThreshold = defaultRegimeTrendThreshold
+	}
+	return c
+}
+
+// Update 记录新的K线收盘价
+func (c *regimeClassifier) Update(data market.MarketData) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	closes := append(c.closes[data.Symbol], data.Close.InexactFloat64())
+	if len(closes) > c.lookback {
+		closes = closes[len(closes)-c.lookback:]
+	}
+	c.closes[data.Symbol] = closes
+}
+
+// Regime 返回交易对当前的市场状态，数据不足时返回 unknown
+func (c *regimeClassifier) Regime(symbol string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return classifyRegime(c.closes[symbol], c.volatilityThreshold, c.trendThreshold)
+}
+
+// classifyRegime 根据收盘价序列判断市场状态:
+// 收益率标准差超过阈值为 volatile，否则效率比超过阈值为 trending，其余为 ranging
+func classifyRegime(closes []float64, volatilityThreshold, trendThreshold float64) string {
+	if len(closes) < 3 {
+		return RegimeUnknown
+	}
+
+	returns := make([]float64, 0, len(closes)-1)
+	path := 0.0
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] <= 0 {
+			return RegimeUnknown
+		}
+		returns = append(returns, closes[i]/closes[i-1]-1)
+		path += math.Abs(closes[i] - closes[i-1])
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	volatility := math.Sqrt(variance / float64(len(returns)))
+
+	if volatility > volatilityThreshold {
+		return RegimeVolatile
+	}
+
+	if path == 0 {
+		return RegimeRanging
+	}
+	efficiency := math.Abs(closes[len(closes)-1]-closes[0]) / path
+	if efficiency >= trendThreshold {
+		return RegimeTrending
+	}
+	return RegimeRanging
+}
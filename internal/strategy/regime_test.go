@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// lastSignalRegime 将收盘价序列逐根输入只运行 echoStrategy 的策略管理器，返回最后一个信号标记的市场状态
+func lastSignalRegime(t *testing.T, closes []float64) string {
+	t.Helper()
+	sm := NewStrategyManager(&config.Config{}, nil)
+	handler := &recordingHandler{}
+	sm.RegisterSignalHandler(handler)
+	if err := sm.AddStrategy(echoStrategy{}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, price := range closes {
+		sm.HandleData(market.MarketData{
+			Symbol:    "BTC/USDT",
+			Close:     decimal.NewFromFloat(price),
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	if len(handler.signals) != len(closes) {
+		t.Fatalf("每根K线应产生一个信号，实际 %d 个", len(handler.signals))
+	}
+	return handler.signals[len(handler.signals)-1].Regime
+}
+
+func TestSignalsTaggedWithRegime(t *testing.T) {
+	trending := make([]float64, 20)
+	ranging := make([]float64, 20)
+	for i := range trending {
+		trending[i] = 100 + float64(i)
+		ranging[i] = 100 + float64(i%2)
+	}
+
+	if regime := lastSignalRegime(t, trending); regime != RegimeTrending {
+		t.Fatalf("持续上涨的序列应标记为 %s，实际 %s", RegimeTrending, regime)
+	}
+	if regime := lastSignalRegime(t, ranging); regime != RegimeRanging {
+		t.Fatalf("来回震荡的序列应标记为 %s，实际 %s", RegimeRanging, regime)
+	}
+	if regime := lastSignalRegime(t, []float64{100, 110, 95, 112, 90}); regime != RegimeVolatile {
+		t.Fatalf("大幅波动的序列应标记为 %s，实际 %s", RegimeVolatile, regime)
+	}
+	if regime := lastSignalRegime(t, []float64{100, 101}); regime != RegimeUnknown {
+		t.Fatalf("数据不足时应标记为 %s，实际 %s", RegimeUnknown, regime)
+	}
+}
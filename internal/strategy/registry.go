@@ -0,0 +1,69 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+)
+
+// Dependencies 创建策略时可用的依赖
+type Dependencies struct {
+	Config     *config.Config
+	MarketData *market.MarketDataService
+	Holdings   HoldingsProvider
+	Sizer      OrderSizer       // 为nil时使用固定下单数量
+	DEXPrices  DEXPriceProvider // 未启用区块链时为nil
+}
+
+// Factory 按实例名称和参数创建策略
+type Factory func(deps Dependencies, name string, params map[string]interface{}) (Strategy, error)
+
+var (
+	registry      = make(map[string]Factory)
+	registryMutex sync.RWMutex
+)
+
+// Register 注册策略类型，新增策略只需在其文件的 init 中调用，无需修改 StrategyManager
+func Register(kind string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, exists := registry[kind]; exists {
+		panic(fmt.Sprintf("策略类型 %s 重复注册", kind))
+	}
+	registry[kind] = factory
+}
+
+// RegisteredStrategies 返回所有已注册的策略类型，按名称排序
+func RegisteredStrategies() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	kinds := make([]string, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New 按策略类型创建策略实例，回测等不经过 StrategyManager 运行策略时使用
+func New(kind, name string, deps Dependencies, params map[string]interface{}) (Strategy, error) {
+	factory, ok := lookupFactory(kind)
+	if !ok {
+		return nil, fmt.Errorf("未知的策略: %s", kind)
+	}
+	return factory(deps, name, params)
+}
+
+// lookupFactory 查找策略类型对应的工厂函数
+func lookupFactory(kind string) (Factory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	factory, ok := registry[kind]
+	return factory, ok
+}
@@ -0,0 +1,128 @@
+package strategy
+
+import (
+	"reflect"
+
+	"autotransaction/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// configuredInstances 返回配置的策略实例，未配置 instances 时为 name 和 params 指定的单个策略
+func configuredInstances(cfg config.StrategyConfig) []config.StrategyInstanceConfig {
+	if len(cfg.Instances) > 0 {
+		return cfg.Instances
+	}
+	return []config.StrategyInstanceConfig{{
+		Name:   cfg.Name,
+		Type:   cfg.Name,
+		Params: cfg.Params,
+	}}
+}
+
+// ReloadConfig 实现 config.ReloadHandler 接口，按新配置创建、更新或移除配置文件中定义的策略实例
+// 通过API创建的策略实例不受影响；类型变化的实例会被重建
+func (sm *StrategyManager) ReloadConfig(previous *config.Config) {
+	sm.reloadInstances(configuredInstances(previous.Strategy), configuredInstances(sm.cfg.Strategy))
+	sm.reloadPairStrategies(pairStrategies(previous), pairStrategies(sm.cfg))
+}
+
+// reloadInstances 对比前后的策略实例配置并应用差异
+func (sm *StrategyManager) reloadInstances(previous, current []config.StrategyInstanceConfig) {
+	before := make(map[string]config.StrategyInstanceConfig)
+	for _, instance := range previous {
+		if !instance.Disabled {
+			before[instance.Name] = instance
+		}
+	}
+
+	after := make(map[string]bool)
+	for _, instance := range current {
+		if instance.Disabled {
+			continue
+		}
+		after[instance.Name] = true
+
+		old, existed := before[instance.Name]
+		switch {
+		case !existed:
+			sm.reloadCreate(instance.Type, instance.Name, instance.Params)
+		case old.Type != instance.Type:
+			sm.reloadRemove(instance.Name)
+			sm.reloadCreate(instance.Type, instance.Name, instance.Params)
+		case !reflect.DeepEqual(old.Params, instance.Params):
+			if err := sm.UpdateStrategy(instance.Name, instance.Params); err != nil {
+				logrus.Errorf("热加载时更新策略 %s 失败: %v", instance.Name, err)
+			}
+		}
+	}
+
+	for name := range before {
+		if !after[name] {
+			sm.reloadRemove(name)
+		}
+	}
+}
+
+// reloadPairStrategies 对比前后的交易对专属策略配置并应用差异
+func (sm *StrategyManager) reloadPairStrategies(previous, current []pairStrategy) {
+	before := make(map[string]pairStrategy)
+	for _, pair := range previous {
+		before[pair.symbol] = pair
+	}
+
+	after := make(map[string]bool)
+	for _, pair := range current {
+		after[pair.symbol] = true
+
+		old, existed := before[pair.symbol]
+		switch {
+		case existed && old.name == pair.name:
+			if !reflect.DeepEqual(old.params, pair.params) {
+				if err := sm.UpdateStrategy(pair.name, pair.params); err != nil {
+					logrus.Errorf("热加载时更新交易对 %s 的策略失败: %v", pair.symbol, err)
+				}
+			}
+			continue
+		case existed:
+			sm.removePairStrategy(old)
+		}
+		if err := sm.startPairStrategy(pair); err != nil {
+			logrus.Errorf("热加载时%v", err)
+		}
+	}
+
+	for symbol, pair := range before {
+		if !after[symbol] {
+			sm.removePairStrategy(pair)
+		}
+	}
+}
+
+// removePairStrategy 移除交易对的专属策略实例，该交易对重新由全局策略处理
+func (sm *StrategyManager) removePairStrategy(pair pairStrategy) {
+	sm.reloadRemove(pair.name)
+
+	sm.strategiesMu.Lock()
+	if sm.pairRoutes[pair.symbol] == pair.name {
+		delete(sm.pairRoutes, pair.symbol)
+	}
+	sm.strategiesMu.Unlock()
+}
+
+// reloadCreate 热加载时创建策略实例，失败时只记录错误
+func (sm *StrategyManager) reloadCreate(kind, name string, params map[string]interface{}) {
+	if err := sm.CreateStrategy(kind, name, params); err != nil {
+		logrus.Errorf("热加载时创建策略 %s 失败: %v", name, err)
+	}
+}
+
+// reloadRemove 热加载时移除策略实例，实例已通过API删除时忽略
+func (sm *StrategyManager) reloadRemove(name string) {
+	if _, ok := sm.GetStrategyInfo(name); !ok {
+		return
+	}
+	if err := sm.RemoveStrategy(name); err != nil {
+		logrus.Errorf("热加载时移除策略 %s 失败: %v", name, err)
+	}
+}
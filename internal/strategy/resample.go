@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"autotransaction/internal/market"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IntervalStrategy 按固定K线周期运行的策略，如 interval 为 1h 的均线策略
+// 策略管理器将实时行情聚合为该周期的K线，每根K线收盘时才调用策略的 Process
+type IntervalStrategy interface {
+	Interval() string
+}
+
+// barsFor 将行情并入策略周期的K线，返回因此收盘的K线；策略未声明周期时直接返回原始行情
+func (sm *StrategyManager) barsFor(strategy Strategy, data market.MarketData) []market.MarketData {
+	periodic, ok := strategy.(IntervalStrategy)
+	if !ok || periodic.Interval() == "" {
+		return []market.MarketData{data}
+	}
+	interval := periodic.Interval()
+
+	sm.resamplersMu.Lock()
+	resampler, ok := sm.resamplers[strategy.Name()]
+	if !ok || resampler.Interval() != interval {
+		var err error
+		resampler, err = market.NewResampler(interval, sm.marketData.FeedInterval())
+		if err != nil {
+			sm.resamplersMu.Unlock()
+			logrus.Warnf("策略 %s 的K线周期无效，按原始行情运行: %v", strategy.Name(), err)
+			return []market.MarketData{data}
+		}
+		sm.resamplers[strategy.Name()] = resampler
+	}
+	sm.resamplersMu.Unlock()
+
+	return resampler.Add(data)
+}
+
+// forgetBars 丢弃策略正在聚合的K线，策略被移除时调用
+func (sm *StrategyManager) forgetBars(name string) {
+	sm.resamplersMu.Lock()
+	defer sm.resamplersMu.Unlock()
+	delete(sm.resamplers, name)
+	delete(sm.timeframes, name)
+}
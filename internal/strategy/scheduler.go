@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduledStrategy 是按时间而非市场数据产生信号的策略，如定投
+// 策略管理器每分钟调用一次 Tick
+type ScheduledStrategy interface {
+	Strategy
+	Tick(now time.Time) ([]Signal, error)
+}
+
+// runScheduler 每到整分钟触发一次定时策略
+func (sm *StrategyManager) runScheduler() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+
+		select {
+		case <-sm.ctx.Done():
+			timer.Stop()
+			return
+		case tick := <-timer.C:
+			sm.tickScheduled(tick)
+		}
+	}
+}
+
+// tickScheduled 调用所有已启用的定时策略，产生的信号与市场数据信号走相同的处理流程
+func (sm *StrategyManager) tickScheduled(now time.Time) {
+	sm.strategiesMu.RLock()
+	defer sm.strategiesMu.RUnlock()
+
+	sm.runStrategies(func(strategy Strategy) ([]Signal, error) {
+		scheduled, ok := strategy.(ScheduledStrategy)
+		if !ok {
+			return nil, nil
+		}
+		signals, err := scheduled.Tick(now)
+		if err == nil && len(signals) > 0 {
+			logrus.Infof("定时策略 %s 触发，生成 %d 个交易信号", strategy.Name(), len(signals))
+		}
+		return signals, err
+	})
+}
@@ -0,0 +1,76 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/config"
+)
+
+// lastSignal 记录策略在某个交易对上最后一次通过过滤的信号
+type lastSignal struct {
+	direction string
+	at        time.Time
+}
+
+// signalFilter 按 策略-交易对-场所 丢弃冷却时间内的信号和去重窗口内方向相同的重复信号，
+// 防止均线在价格附近反复交叉等噪声导致重复下单
+type signalFilter struct {
+	last  map[string]map[string]lastSignal // 策略名称 -> 交易对-场所 -> 最后一个信号
+	mutex sync.Mutex
+}
+
+// newSignalFilter 创建信号过滤器
+func newSignalFilter() *signalFilter {
+	return &signalFilter{last: make(map[string]map[string]lastSignal)}
+}
+
+// allow 判断信号是否可以分发，可以时记录该信号；不可以时返回原因
+func (f *signalFilter) allow(cfg config.SignalFilterConfig, strategyName string, signal Signal, now time.Time) (bool, string) {
+	cooldown, dedup := filterWindows(cfg, strategyName)
+	if cooldown <= 0 && dedup <= 0 {
+		return true, ""
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	records, ok := f.last[strategyName]
+	if !ok {
+		records = make(map[string]lastSignal)
+		f.last[strategyName] = records
+	}
+	key := signal.Symbol + "-" + signal.Venue
+	if last, ok := records[key]; ok {
+		elapsed := now.Sub(last.at)
+		if elapsed < cooldown {
+			return false, fmt.Sprintf("处于冷却时间内，距上一个信号 %s", elapsed.Round(time.Second))
+		}
+		if last.direction == signal.Direction && elapsed < dedup {
+			return false, fmt.Sprintf("与 %s 前的信号方向相同", elapsed.Round(time.Second))
+		}
+	}
+	records[key] = lastSignal{direction: signal.Direction, at: now}
+	return true, ""
+}
+
+// forget 清除策略的信号记录，策略被移除或重建时调用
+func (f *signalFilter) forget(strategyName string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.last, strategyName)
+}
+
+// filterWindows 返回策略实例的冷却时间和去重窗口，配置了覆盖时使用覆盖值
+func filterWindows(cfg config.SignalFilterConfig, strategyName string) (cooldown, dedup time.Duration) {
+	cooldownSeconds, dedupSeconds := cfg.CooldownSeconds, cfg.DedupWindowSeconds
+	for _, override := range cfg.Overrides {
+		if override.Name == strategyName {
+			cooldownSeconds, dedupSeconds = override.CooldownSeconds, override.DedupWindowSeconds
+			break
+		}
+	}
+	return time.Duration(cooldownSeconds) * time.Second, time.Duration(dedupSeconds) * time.Second
+}
@@ -0,0 +1,91 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// signalStateFile 已执行信号状态的持久化文件名
+const signalStateFile = "signal_state.json"
+
+// actedSignal 记录已执行的信号
+type actedSignal struct {
+	Direction string `json:"direction"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// signalState 持久化每个策略/交易对最后一次已执行的信号，
+// 重启后策略重新加载历史数据时不会再次触发已执行过的信号
+type signalState struct {
+	path    string
+	records map[string]actedSignal
+	mutex   sync.Mutex
+}
+
+// loadSignalState 从数据目录加载已执行信号状态
+func loadSignalState(dataDir string) (*signalState, error) {
+	state := &signalState{
+		path:    filepath.Join(dataDir, signalStateFile),
+		records: make(map[string]actedSignal),
+	}
+
+	content, err := ioutil.ReadFile(state.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("读取信号状态文件失败: %v", err)
+	}
+
+	if err := json.Unmarshal(content, &state.records); err != nil {
+		return nil, fmt.Errorf("解析信号状态文件失败: %v", err)
+	}
+
+	return state, nil
+}
+
+// isReplay 判断信号是否已在之前执行过（时间戳不晚于最后一次已执行信号）
+func (s *signalState) isReplay(strategyName string, signal Signal) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, ok := s.records[signalStateKey(strategyName, signal.Symbol)]
+	return ok && signal.Timestamp <= record.Timestamp
+}
+
+// markActed 记录已执行的信号并持久化
+func (s *signalState) markActed(strategyName string, signal Signal) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[signalStateKey(strategyName, signal.Symbol)] = actedSignal{
+		Direction: signal.Direction,
+		Timestamp: signal.Timestamp,
+	}
+
+	content, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化信号状态失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致状态文件损坏
+	tmpPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("写入信号状态文件失败: %v", err)
+	}
+
+	return os.Rename(tmpPath, s.path)
+}
+
+// signalStateKey 生成 策略-交易对 格式的状态键
+func signalStateKey(strategyName, symbol string) string {
+	return fmt.Sprintf("%s-%s", strategyName, symbol)
+}
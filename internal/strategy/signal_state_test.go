@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// echoStrategy 每根K线产生一个买入信号，信号时间戳为K线时间，用于模拟重启后重放历史数据
+type echoStrategy struct{}
+
+func (echoStrategy) Init() error  { return nil }
+func (echoStrategy) Name() string { return "echo" }
+func (echoStrategy) Process(data market.MarketData) ([]Signal, error) {
+	return []Signal{{
+		Symbol:    data.Symbol,
+		Direction: "buy",
+		Price:     data.Close,
+		Quantity:  decimal.NewFromInt(1),
+		Timestamp: data.Timestamp.Unix(),
+	}}, nil
+}
+
+// recordingHandler 记录收到的信号
+type recordingHandler struct {
+	signals []Signal
+}
+
+func (h *recordingHandler) HandleSignal(signal Signal) {
+	h.signals = append(h.signals, signal)
+}
+
+// startReplayManager 按 Start 的方式加载已执行信号状态，返回只运行 echoStrategy 的策略管理器
+func startReplayManager(t *testing.T, cfg *config.Config) (*StrategyManager, *recordingHandler) {
+	t.Helper()
+	sm := NewStrategyManager(cfg, nil)
+	state, err := loadSignalState(cfg.System.DataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm.signalState = state
+	if err := sm.AddStrategy(echoStrategy{}); err != nil {
+		t.Fatal(err)
+	}
+	handler := &recordingHandler{}
+	sm.RegisterSignalHandler(handler)
+	return sm, handler
+}
+
+func TestActedSignalNotReplayedAfterRestart(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.System.DataDir = t.TempDir()
+	cfg.Strategy.ReplayProtection = true
+	bar := market.MarketData{Symbol: "BTC/USDT", Close: decimal.NewFromInt(100), Timestamp: time.Unix(1700000000, 0)}
+
+	sm, handler := startReplayManager(t, cfg)
+	sm.HandleData(bar)
+	if len(handler.signals) != 1 {
+		t.Fatalf("重启前应执行 1 个信号，实际 %d 个", len(handler.signals))
+	}
+
+	// 模拟重启：新的策略管理器从数据目录加载状态后重新处理同一根历史K线
+	sm, handler = startReplayManager(t, cfg)
+	sm.HandleData(bar)
+	if len(handler.signals) != 0 {
+		t.Fatalf("重启前已执行的信号不应再次执行: %+v", handler.signals)
+	}
+
+	// 之后的新K线产生的信号正常执行
+	bar.Timestamp = bar.Timestamp.Add(time.Minute)
+	sm.HandleData(bar)
+	if len(handler.signals) != 1 {
+		t.Fatalf("重启后的新信号应正常执行，实际 %d 个", len(handler.signals))
+	}
+}
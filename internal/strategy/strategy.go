@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"autotransaction/config"
+	"autotransaction/internal/audit"
+	"autotransaction/internal/events"
 	"autotransaction/internal/market"
 
 	"github.com/shopspring/decimal"
@@ -19,8 +23,42 @@ type Signal struct {
 	Price     decimal.Decimal
 	Quantity  decimal.Decimal
 	Timestamp int64
+	// Confidence 信号强度 (0-1)，由策略根据指标给出，可用于按强度调整仓位
+	Confidence   float64
+	Account      string // 信号所属账户
+	Regime       string // 产生信号时的市场状态: trending, ranging, volatile
+	StrategyName string // 产生信号的策略实例名称，用于按实例归因交易表现
+
+	// 下单方式，为空时使用执行配置中的默认值
+	OrderType   string          // "market", "limit", "stop_limit"，限价类订单以 Price 为限价
+	StopPrice   decimal.Decimal // 止损限价单的触发价格
+	TimeInForce string          // "GTC", "IOC", "FOK"
+
+	// 执行算法，为空时一次性下单，仅对交易所订单有效
+	Algo         string // "twap" 在一段时间内等间隔拆单，"iceberg" 冰山单，每次只挂出一部分数量
+	AlgoSlices   int    // 拆分笔数，为0时使用 execution.algo.slices
+	AlgoDuration int    // TWAP 的执行时长（秒），为0时使用 execution.algo.twap_duration_seconds
+
+	// Venue 下单场所，为空时按交易对配置路由：配置了 blockchain 的交易对在链上执行，否则在交易所执行
+	Venue string
+
+	// ClientOrderID 幂等键，同一账户下相同键的信号只会下一次单，重试时返回已创建的订单
+	// 策略产生的信号为空时按 策略-交易对-场所-方向-时间戳 生成
+	ClientOrderID string
+
+	// ID 信号的唯一标识，分发前生成，订单记录该ID以追溯产生它的信号
+	ID string
+
+	// Forced 风险管理器触发的强制平仓（止损、止盈、熔断等），在订单队列中优先执行
+	Forced bool
 }
 
+// 下单场所
+const (
+	VenueExchange   = "exchange"
+	VenueBlockchain = "blockchain"
+)
+
 // Strategy 是交易策略的接口
 type Strategy interface {
 	Init() error
@@ -33,13 +71,33 @@ type SignalHandler interface {
 	HandleSignal(signal Signal)
 }
 
+// OrderSizer 根据账户资金计算下单数量
+type OrderSizer interface {
+	OrderQuantity(account, symbol, direction string, price decimal.Decimal) decimal.Decimal
+}
+
 // StrategyManager 管理所有交易策略
 type StrategyManager struct {
 	cfg            *config.Config
 	marketData     *market.MarketDataService
 	strategies     map[string]Strategy
+	infos          map[string]*StrategyInfo // 策略实例的类型、参数和启用状态
+	strategiesMu   sync.RWMutex
 	signalHandlers []SignalHandler
 	handlersMutex  sync.RWMutex
+	signalState    *signalState // 已执行信号状态，未启用重放保护时为nil
+	filter         *signalFilter
+	resamplers     map[string]*market.Resampler    // 按策略实例的K线周期聚合行情
+	timeframes     map[string]*TimeframeAggregator // 多周期策略实例的各周期K线
+	resamplersMu   sync.Mutex
+	regimes        *regimeClassifier
+	sizeFractions  map[string]decimal.Decimal // 按实例缩放下单数量，如金丝雀实例
+	holdings       HoldingsProvider
+	sizer          OrderSizer
+	pairRoutes     map[string]string // 交易对到其专属策略实例名称的映射
+	dexPrices      DEXPriceProvider  // 未启用区块链时为nil
+	audit          *audit.Log        // 为nil时不记录审计日志
+	events         *events.Bus       // 为nil时不发布事件
 	ctx            context.Context
 	cancel         context.CancelFunc
 }
@@ -51,7 +109,14 @@ func NewStrategyManager(cfg *config.Config, marketData *market.MarketDataService
 		cfg:            cfg,
 		marketData:     marketData,
 		strategies:     make(map[string]Strategy),
+		infos:          make(map[string]*StrategyInfo),
 		signalHandlers: make([]SignalHandler, 0),
+		filter:         newSignalFilter(),
+		resamplers:     make(map[string]*market.Resampler),
+		timeframes:     make(map[string]*TimeframeAggregator),
+		regimes:        newRegimeClassifier(cfg.Strategy.Regime),
+		sizeFractions:  make(map[string]decimal.Decimal),
+		pairRoutes:     make(map[string]string),
 		ctx:            ctx,
 		cancel:         cancel,
 	}
@@ -61,22 +126,39 @@ func NewStrategyManager(cfg *config.Config, marketData *market.MarketDataService
 func (sm *StrategyManager) Start() error {
 	logrus.Info("启动策略管理器")
 
-	// 创建并初始化策略
-	strategy, err := sm.createStrategy(sm.cfg.Strategy.Name)
-	if err != nil {
-		return fmt.Errorf("创建策略失败: %v", err)
+	// 加载已执行信号状态，防止重启后重复执行
+	if sm.cfg.Strategy.ReplayProtection {
+		state, err := loadSignalState(sm.cfg.System.DataDir)
+		if err != nil {
+			return fmt.Errorf("加载信号状态失败: %v", err)
+		}
+		sm.signalState = state
 	}
 
-	err = strategy.Init()
-	if err != nil {
-		return fmt.Errorf("初始化策略失败: %v", err)
+	// 创建并初始化策略，配置了 instances 时同时运行多个策略实例
+	for _, instance := range configuredInstances(sm.cfg.Strategy) {
+		if instance.Disabled {
+			continue
+		}
+		if err := sm.CreateStrategy(instance.Type, instance.Name, instance.Params); err != nil {
+			return fmt.Errorf("创建策略 %s 失败: %v", instance.Name, err)
+		}
 	}
 
-	sm.strategies[strategy.Name()] = strategy
+	if err := sm.startPairStrategies(); err != nil {
+		return err
+	}
+
+	if err := sm.startCanary(); err != nil {
+		return fmt.Errorf("启动金丝雀策略失败: %v", err)
+	}
 
 	// 注册为市场数据的处理器
 	sm.marketData.RegisterHandler(sm)
 
+	// 启动定时策略调度
+	go sm.runScheduler()
+
 	return nil
 }
 
@@ -86,6 +168,62 @@ func (sm *StrategyManager) Stop() {
 	sm.cancel()
 }
 
+// AddStrategy 添加策略，可在运行时调用
+// 添加前先调用策略的 Init 通过 GetHistoricalData 回填历史数据完成指标预热，
+// 使运行时新增的策略无需等待实时K线积累即可立即产生信号
+func (sm *StrategyManager) AddStrategy(strategy Strategy) error {
+	return sm.addStrategy(strategy, strategy.Name(), nil)
+}
+
+// addStrategy 添加策略并记录其类型和参数
+func (sm *StrategyManager) addStrategy(strategy Strategy, kind string, params map[string]interface{}) error {
+	if err := strategy.Init(); err != nil {
+		return fmt.Errorf("初始化策略 %s 失败: %v", strategy.Name(), err)
+	}
+
+	sm.strategiesMu.Lock()
+	defer sm.strategiesMu.Unlock()
+
+	if _, exists := sm.strategies[strategy.Name()]; exists {
+		return fmt.Errorf("策略 %s 已存在", strategy.Name())
+	}
+	sm.strategies[strategy.Name()] = strategy
+	sm.infos[strategy.Name()] = &StrategyInfo{
+		Name:    strategy.Name(),
+		Type:    kind,
+		Params:  params,
+		Enabled: true,
+	}
+
+	logrus.Infof("已添加策略: %s", strategy.Name())
+	return nil
+}
+
+// SetHoldingsProvider 设置持仓数据来源，供再平衡等需要当前持仓的策略使用
+func (sm *StrategyManager) SetHoldingsProvider(provider HoldingsProvider) {
+	sm.holdings = provider
+}
+
+// SetDEXPriceProvider 设置链上价格来源，供跨场所套利等策略使用，需在 Start 之前调用
+func (sm *StrategyManager) SetDEXPriceProvider(provider DEXPriceProvider) {
+	sm.dexPrices = provider
+}
+
+// SetOrderSizer 设置下单数量计算方法，需在 Start 之前调用
+func (sm *StrategyManager) SetOrderSizer(sizer OrderSizer) {
+	sm.sizer = sizer
+}
+
+// SetAuditLog 设置审计日志，记录每个分发的交易信号
+func (sm *StrategyManager) SetAuditLog(log *audit.Log) {
+	sm.audit = log
+}
+
+// SetEventBus 设置事件总线，每个分发的交易信号都会发布到总线
+func (sm *StrategyManager) SetEventBus(bus *events.Bus) {
+	sm.events = bus
+}
+
 // RegisterSignalHandler 注册一个信号处理器
 func (sm *StrategyManager) RegisterSignalHandler(handler SignalHandler) {
 	sm.handlersMutex.Lock()
@@ -95,19 +233,115 @@ func (sm *StrategyManager) RegisterSignalHandler(handler SignalHandler) {
 
 // HandleData 实现 market.DataHandler 接口
 func (sm *StrategyManager) HandleData(data market.MarketData) {
-	// 将市场数据传递给每个策略处理
-	for _, strategy := range sm.strategies {
-		signals, err := strategy.Process(data)
+	sm.strategiesMu.RLock()
+	defer sm.strategiesMu.RUnlock()
+
+	sm.regimes.Update(data)
+
+	// 将市场数据传递给负责该交易对的策略处理，声明了K线周期的策略只在K线收盘时处理，
+	// 多周期策略在最小周期的K线收盘时处理
+	sm.runStrategies(func(strategy Strategy) ([]Signal, error) {
+		if !sm.routesTo(strategy.Name(), data.Symbol) {
+			return nil, nil
+		}
+		if multi, ok := strategy.(MultiTimeframeStrategy); ok {
+			if aggregator, ok := sm.timeframesFor(multi); ok {
+				return sm.processTimeframes(multi, aggregator, data)
+			}
+		}
+		var signals []Signal
+		for _, bar := range sm.barsFor(strategy, data) {
+			barSignals, err := strategy.Process(bar)
+			if err != nil {
+				return signals, err
+			}
+			signals = append(signals, barSignals...)
+		}
+		return signals, nil
+	})
+}
+
+// runStrategies 对每个已启用的策略调用 produce，收集本轮产生的全部信号后统一处理和分发
+// 调用方需持有 strategiesMu 读锁
+func (sm *StrategyManager) runStrategies(produce func(strategy Strategy) ([]Signal, error)) {
+	signals := make([]Signal, 0)
+	acted := make(map[string][]Signal)
+	for name, strategy := range sm.strategies {
+		if !sm.isEnabled(name) {
+			continue
+		}
+
+		strategySignals, err := produce(strategy)
 		if err != nil {
 			logrus.Errorf("策略 %s 处理数据失败: %v", strategy.Name(), err)
 			continue
 		}
 
-		// 分发生成的信号
-		for _, signal := range signals {
-			sm.distributeSignal(signal)
+		for _, signal := range strategySignals {
+			if sm.signalState != nil && sm.signalState.isReplay(strategy.Name(), signal) {
+				logrus.Infof("策略 %s 的 %s %s 信号已在之前执行过，跳过",
+					strategy.Name(), signal.Symbol, signal.Direction)
+				continue
+			}
+
+			signal.StrategyName = strategy.Name()
+			if signal.ClientOrderID == "" {
+				signal.ClientOrderID = signalClientOrderID(signal)
+			}
+			signal = sm.applySizeFraction(signal)
+			if signal.Quantity.IsZero() {
+				continue
+			}
+			if ok, reason := sm.filter.allow(sm.cfg.Current().Strategy.SignalFilter, strategy.Name(), signal, time.Now()); !ok {
+				logrus.Infof("丢弃策略 %s 的 %s %s 信号: %s",
+					strategy.Name(), signal.Symbol, signal.Direction, reason)
+				continue
+			}
+			signals = append(signals, sm.withAccount(signal))
+			acted[strategy.Name()] = append(acted[strategy.Name()], signal)
 		}
 	}
+
+	// 处理同一轮中相互冲突的买卖信号后分发
+	for _, signal := range resolveConflicts(signals, sm.cfg.Current().Strategy.ConflictPolicy) {
+		signal.Regime = sm.regimes.Regime(signal.Symbol)
+		sm.distributeSignal(signal)
+	}
+
+	// 记录本轮已处理的信号，包括因冲突被合并或丢弃的信号
+	if sm.signalState != nil {
+		for strategyName, strategySignals := range acted {
+			for _, signal := range strategySignals {
+				if err := sm.signalState.markActed(strategyName, signal); err != nil {
+					logrus.Errorf("保存信号状态失败: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// withAccount 为信号设置所属账户
+func (sm *StrategyManager) withAccount(signal Signal) Signal {
+	if signal.Account == "" {
+		signal.Account = sm.cfg.Strategy.Account
+	}
+	if signal.Account == "" {
+		signal.Account = config.DefaultAccountID
+	}
+	return signal
+}
+
+// signalSequence 信号ID的序号，避免同一纳秒内生成相同的ID
+var signalSequence uint64
+
+// NewSignalID 生成信号ID，不经过策略管理器的信号（如手动下单和强制平仓）也使用它生成ID
+func NewSignalID() string {
+	return fmt.Sprintf("SIGNAL-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&signalSequence, 1))
+}
+
+// signalClientOrderID 按信号来源生成幂等键，同一策略重复产生的同一信号得到相同的键
+func signalClientOrderID(signal Signal) string {
+	return fmt.Sprintf("%s-%s-%s-%s-%d", signal.StrategyName, signal.Symbol, signal.Venue, signal.Direction, signal.Timestamp)
 }
 
 // distributeSignal 将信号分发给所有处理器
@@ -115,20 +349,42 @@ func (sm *StrategyManager) distributeSignal(signal Signal) {
 	sm.handlersMutex.RLock()
 	defer sm.handlersMutex.RUnlock()
 
+	if signal.ID == "" {
+		signal.ID = NewSignalID()
+	}
+
 	logrus.Infof("生成交易信号: %s %s 价格: %s 数量: %s",
 		signal.Symbol, signal.Direction, signal.Price.String(), signal.Quantity.String())
+	sm.audit.Record(audit.Event{
+		Type:      audit.EventSignal,
+		Account:   signal.Account,
+		Symbol:    signal.Symbol,
+		Direction: signal.Direction,
+		Strategy:  signal.StrategyName,
+		Price:     signal.Price,
+		Quantity:  signal.Quantity,
+		SignalID:  signal.ID,
+		Detail:    signal.Regime,
+	})
+	sm.events.Publish(events.Event{
+		Type:    events.EventSignal,
+		Account: signal.Account,
+		Symbol:  signal.Symbol,
+		Payload: signal,
+	})
 
 	for _, handler := range sm.signalHandlers {
 		handler.HandleSignal(signal)
 	}
 }
 
-// createStrategy 根据策略名称创建相应的策略实例
-func (sm *StrategyManager) createStrategy(name string) (Strategy, error) {
-	switch name {
-	case "moving_average_crossover":
-		return NewMovingAverageCrossover(sm.cfg, sm.marketData), nil
-	default:
-		return nil, fmt.Errorf("未知的策略: %s", name)
-	}
+// newStrategyInstance 按策略类型、实例名称和参数创建策略实例
+func (sm *StrategyManager) newStrategyInstance(kind, name string, params map[string]interface{}) (Strategy, error) {
+	return New(kind, name, Dependencies{
+		Config:     sm.cfg,
+		MarketData: sm.marketData,
+		Holdings:   sm.holdings,
+		Sizer:      sm.sizer,
+		DEXPrices:  sm.dexPrices,
+	}, params)
 }
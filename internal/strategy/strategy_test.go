@@ -0,0 +1,55 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"autotransaction/config"
+	"autotransaction/internal/market"
+
+	"github.com/shopspring/decimal"
+)
+
+// flatHistory 返回收盘价恒为 100 的小时K线，最后一根为 end 之前的一小时
+type flatHistory struct {
+	end time.Time
+}
+
+func (h flatHistory) Candles(symbol, interval string, limit int) ([]market.MarketData, error) {
+	bars := make([]market.MarketData, limit)
+	for i := range bars {
+		bars[i] = market.MarketData{
+			Symbol:    symbol,
+			Timestamp: h.end.Add(-time.Duration(limit-i) * time.Hour),
+			Close:     decimal.NewFromInt(100),
+		}
+	}
+	return bars, nil
+}
+
+func TestRuntimeAddedStrategyWarmsUpFromHistory(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Exchange.MockMode = true
+	cfg.Trading.Pairs = []config.PairConfig{{Symbol: "BTC/USDT", Enabled: true}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	marketData := market.NewMarketDataService(cfg)
+	marketData.SetHistory(flatHistory{end: start})
+
+	sm := NewStrategyManager(cfg, marketData)
+	handler := &recordingHandler{}
+	sm.RegisterSignalHandler(handler)
+	if err := sm.CreateStrategy("moving_average_crossover", "ma_live", map[string]interface{}{
+		"short_period": 2,
+		"long_period":  20,
+		"interval":     "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有一根实时K线收盘，远少于长期均线周期，回填的历史数据使策略立即产生信号
+	sm.HandleData(market.MarketData{Symbol: "BTC/USDT", Timestamp: start, Close: decimal.NewFromInt(120)})
+	sm.HandleData(market.MarketData{Symbol: "BTC/USDT", Timestamp: start.Add(time.Hour), Close: decimal.NewFromInt(120)})
+	if len(handler.signals) != 1 || handler.signals[0].Direction != "buy" {
+		t.Fatalf("运行时添加的策略应在第一根实时K线收盘时产生买入信号，实际: %+v", handler.signals)
+	}
+}
@@ -0,0 +1,213 @@
+package strategy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"autotransaction/internal/market"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MultiTimeframeStrategy 需要多个K线周期的策略，如以1h K线过滤趋势、以5m K线寻找入场点
+// 策略管理器和回测按 Timeframes 声明的各周期分别聚合行情，每根最小周期K线收盘时调用 ProcessBars 而不是 Process
+// Timeframes 返回空时按普通策略运行
+type MultiTimeframeStrategy interface {
+	Strategy
+	Timeframes() []string
+	ProcessBars(bars TimeframeBars) ([]Signal, error)
+}
+
+// TimeframeBars 同一交易对在某根最小周期K线收盘时各周期最近收盘的K线
+// 更高周期的K线只在其收盘后才出现，与最小周期在同一时刻收盘的K线一并给出，避免使用未收盘K线的未来数据
+type TimeframeBars struct {
+	Symbol   string
+	Interval string                       // 最小周期，即触发本次处理的周期
+	Bars     map[string]market.MarketData // 各周期最近收盘的K线，键为周期，尚无收盘K线的周期不在其中
+	Closed   []string                     // 本次收盘的周期，总包含最小周期
+}
+
+// Bar 返回周期最近收盘的K线
+func (t TimeframeBars) Bar(interval string) (market.MarketData, bool) {
+	bar, ok := t.Bars[interval]
+	return bar, ok
+}
+
+// IsClosed 判断周期的K线是否在本次收盘
+func (t TimeframeBars) IsClosed(interval string) bool {
+	for _, closed := range t.Closed {
+		if closed == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// timeframe 聚合中的一个周期
+type timeframe struct {
+	interval  string
+	duration  time.Duration
+	resampler *market.Resampler
+}
+
+// TimeframeAggregator 将行情同时聚合为多个周期的K线，并按交易对保存各周期最近收盘的K线
+type TimeframeAggregator struct {
+	frames []timeframe                             // 按周期从小到大排列，第一个为最小周期
+	latest map[string]map[string]market.MarketData // 交易对 -> 周期 -> 最近收盘的K线
+	mutex  sync.Mutex
+}
+
+// NewTimeframeAggregator 创建多周期K线聚合器，source 为输入行情的K线周期，输入不是K线时为0
+func NewTimeframeAggregator(intervals []string, source time.Duration) (*TimeframeAggregator, error) {
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("至少需要一个K线周期")
+	}
+
+	frames := make([]timeframe, 0, len(intervals))
+	for _, interval := range intervals {
+		duration, err := market.ParseInterval(interval)
+		if err != nil {
+			return nil, err
+		}
+		for _, frame := range frames {
+			if frame.duration == duration {
+				return nil, fmt.Errorf("K线周期 %s 与 %s 重复", interval, frame.interval)
+			}
+		}
+		resampler, err := market.NewResampler(interval, source)
+		if err != nil {
+			return nil, err
+		}
+
+		// 按周期从小到大插入
+		frame := timeframe{interval: interval, duration: duration, resampler: resampler}
+		i := len(frames)
+		for i > 0 && frames[i-1].duration > duration {
+			i--
+		}
+		frames = append(frames, timeframe{})
+		copy(frames[i+1:], frames[i:])
+		frames[i] = frame
+	}
+
+	return &TimeframeAggregator{
+		frames: frames,
+		latest: make(map[string]map[string]market.MarketData),
+	}, nil
+}
+
+// Add 并入一条行情，每根因此收盘的最小周期K线返回一个各周期K线的快照，按时间从早到晚排列
+func (a *TimeframeAggregator) Add(data market.MarketData) []TimeframeBars {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// 各周期因本条行情收盘的K线
+	closed := make([][]market.MarketData, len(a.frames))
+	for i, frame := range a.frames {
+		closed[i] = frame.resampler.Add(data)
+	}
+
+	latest, ok := a.latest[data.Symbol]
+	if !ok {
+		latest = make(map[string]market.MarketData, len(a.frames))
+		a.latest[data.Symbol] = latest
+	}
+
+	base := a.frames[0]
+	snapshots := make([]TimeframeBars, 0, len(closed[0]))
+	for _, baseBar := range closed[0] {
+		baseEnd := baseBar.Timestamp.Add(base.duration)
+		latest[base.interval] = baseBar
+		snapshot := TimeframeBars{
+			Symbol:   data.Symbol,
+			Interval: base.interval,
+			Closed:   []string{base.interval},
+		}
+
+		// 更高周期只并入在这根最小周期K线收盘时或之前收盘的K线
+		for i := 1; i < len(a.frames); i++ {
+			frame := a.frames[i]
+			remaining := closed[i][:0]
+			for _, bar := range closed[i] {
+				if bar.Timestamp.Add(frame.duration).After(baseEnd) {
+					remaining = append(remaining, bar)
+					continue
+				}
+				latest[frame.interval] = bar
+				snapshot.Closed = append(snapshot.Closed, frame.interval)
+			}
+			closed[i] = remaining
+		}
+
+		snapshot.Bars = make(map[string]market.MarketData, len(latest))
+		for interval, bar := range latest {
+			snapshot.Bars[interval] = bar
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	// 最小周期未收盘时更高周期收盘的K线（如输入行情缺失）留待下一个快照
+	for i := 1; i < len(a.frames); i++ {
+		for _, bar := range closed[i] {
+			latest[a.frames[i].interval] = bar
+		}
+	}
+	return snapshots
+}
+
+// timeframesFor 返回多周期策略的聚合器，周期变化时重新创建；策略未声明多个周期或周期无效时返回 false
+func (sm *StrategyManager) timeframesFor(strategy MultiTimeframeStrategy) (*TimeframeAggregator, bool) {
+	intervals := strategy.Timeframes()
+	if len(intervals) == 0 {
+		return nil, false
+	}
+
+	sm.resamplersMu.Lock()
+	defer sm.resamplersMu.Unlock()
+
+	aggregator, ok := sm.timeframes[strategy.Name()]
+	if ok && sameIntervals(aggregator, intervals) {
+		return aggregator, true
+	}
+	aggregator, err := NewTimeframeAggregator(intervals, sm.marketData.FeedInterval())
+	if err != nil {
+		logrus.Warnf("策略 %s 的K线周期无效，按原始行情运行: %v", strategy.Name(), err)
+		return nil, false
+	}
+	sm.timeframes[strategy.Name()] = aggregator
+	return aggregator, true
+}
+
+// processTimeframes 将行情并入多周期策略的各周期K线，每根最小周期K线收盘时调用策略的 ProcessBars
+func (sm *StrategyManager) processTimeframes(strategy MultiTimeframeStrategy, aggregator *TimeframeAggregator, data market.MarketData) ([]Signal, error) {
+	var signals []Signal
+	for _, bars := range aggregator.Add(data) {
+		barSignals, err := strategy.ProcessBars(bars)
+		if err != nil {
+			return signals, err
+		}
+		signals = append(signals, barSignals...)
+	}
+	return signals, nil
+}
+
+// sameIntervals 判断聚合器的周期是否与策略声明的周期一致（不计顺序）
+func sameIntervals(aggregator *TimeframeAggregator, intervals []string) bool {
+	if len(aggregator.frames) != len(intervals) {
+		return false
+	}
+	for _, interval := range intervals {
+		found := false
+		for _, frame := range aggregator.frames {
+			if frame.interval == interval {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -59,6 +59,11 @@ func SetupLogger(level string) {
 	}
 }
 
+// ClientOrderKey 生成 账户-幂等键 格式的索引键，不同账户可以使用相同的幂等键，交易所和链上订单共用
+func ClientOrderKey(account, clientOrderID string) string {
+	return account + "-" + clientOrderID
+}
+
 // GenerateID 生成唯一ID
 func GenerateID(prefix string) string {
 	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())